@@ -39,6 +39,12 @@ var compressionDeb string
 // compressionRpm is the local holder for the --compression-rpm flag value.
 var compressionRpm string
 
+// compressionApk is the local holder for the --compression-apk flag value.
+var compressionApk string
+
+// apkFormat is the local holder for the --apk-format flag value.
+var apkFormat string
+
 // signKey is the local holder for the --sign-key flag value.
 var signKey string
 
@@ -125,6 +131,12 @@ var buildCmd = &cobra.Command{
 				return err
 			}
 
+			// The container mounts the shared source cache, so honour the
+			// yap.json sourceCache setting before building its arguments.
+			if err := project.ApplySourceCache(fullJSONPath); err != nil {
+				return err
+			}
+
 			// Run prepare+build in a single container invocation so makedeps
 			// installed by prepare are available to build. Skip prepare only
 			// when the user explicitly requested -s (skip-sync) or -d (no-makedeps),
@@ -168,6 +180,18 @@ var buildCmd = &cobra.Command{
 			mpc.CompressionRpm = compressionRpm
 		}
 
+		if err := validateAPKOptions(apkFormat, compressionApk); err != nil {
+			return err
+		}
+
+		if compressionApk != "" {
+			mpc.CompressionApk = compressionApk
+		}
+
+		if apkFormat != "" {
+			mpc.APKFormat = apkFormat
+		}
+
 		err = mpc.MultiProject(distro, release, fullJSONPath)
 		if err != nil {
 			var yapErr *yapErrors.YapError
@@ -277,6 +301,39 @@ func validateCompression(compression string) error {
 		WithOperation("validateCompression")
 }
 
+// validateAPKOptions validates the APK package format version and the APK v3
+// compression algorithm against the canonical sets in pkg/constants. A
+// compression is rejected unless the format is v3, as v2 packages are
+// always gzip-compressed.
+func validateAPKOptions(format, compression string) error {
+	if !constants.IsSupportedAPKFormat(format) {
+		return yapErrors.New(
+			yapErrors.ErrTypeConfiguration,
+			i18n.T("errors.apk.unsupported_format"),
+		).WithContext("format", format).
+			WithOperation("validateAPKOptions")
+	}
+
+	if !constants.IsSupportedAPKCompression(compression) {
+		return yapErrors.New(
+			yapErrors.ErrTypeConfiguration,
+			"unsupported compression algorithm",
+		).WithContext("compression", compression).
+			WithOperation("validateAPKOptions")
+	}
+
+	if compression != "" && format != constants.APKFormatV3 {
+		return yapErrors.New(
+			yapErrors.ErrTypeConfiguration,
+			i18n.T("errors.apk.compression_requires_v3"),
+		).WithContext("compression", compression).
+			WithContext("format", format).
+			WithOperation("validateAPKOptions")
+	}
+
+	return nil
+}
+
 // resolveSigning resolves the signing configuration from CLI flags,
 // environment variables, and project config using the full priority chain.
 func resolveSigning(mpc *project.MultipleProject) (*signing.Config, error) {
@@ -380,6 +437,8 @@ func InitializeBuildDescriptions() {
 		"sbom-format":               "flags.build.sbom_format",
 		"compression-deb":           "flags.build.compression_deb",
 		"compression-rpm":           "flags.build.compression_rpm",
+		"compression-apk":           "flags.build.compression_apk",
+		"apk-format":                "flags.build.apk_format",
 		"repo":                      "flags.build.repo",
 		"debug-dir":                 "flags.build.debug_dir",
 		"sign":                      "flags.build.sign",
@@ -387,7 +446,9 @@ func InitializeBuildDescriptions() {
 		"sign-passphrase":           "flags.build.sign_passphrase",
 		"sign-key-name":             "flags.build.sign_key_name",
 		"skip-hash-check":           "flags.build.skip_hash_check",
+		"skip-pgp-check":            "flags.build.skip_pgp_check",
 		"nocheck":                   "flags.build.nocheck",
+		"source-package":            "flags.build.source_package",
 		"allow-unverified-repos":    "flags.build.allow_unverified_repos",
 	})
 }
@@ -428,8 +489,12 @@ func init() {
 		"zap", "z", false, "")
 	buildCmd.Flags().BoolVarP(&buildOpts.SkipHashCheck,
 		"skip-hash-check", "H", false, "")
+	buildCmd.Flags().BoolVarP(&buildOpts.SkipPGPCheck,
+		"skip-pgp-check", "", false, "")
 	buildCmd.Flags().BoolVarP(&buildOpts.NoCheck,
 		"nocheck", "", false, "")
+	buildCmd.Flags().BoolVarP(&buildOpts.SourcePackage,
+		"source-package", "", false, "")
 
 	// DEPENDENCY MANAGEMENT FLAGS
 	buildCmd.Flags().BoolVarP(&buildOpts.NoMakeDeps,
@@ -484,6 +549,10 @@ func init() {
 		"compression-deb", "", "zstd", "")
 	buildCmd.Flags().StringVarP(&compressionRpm,
 		"compression-rpm", "", "zstd", "")
+	buildCmd.Flags().StringVarP(&compressionApk,
+		"compression-apk", "", "", "")
+	buildCmd.Flags().StringVarP(&apkFormat,
+		"apk-format", "", "", "")
 
 	// SIGNING FLAGS
 	buildCmd.Flags().BoolVarP(&sign,
@@ -244,3 +244,28 @@ func TestForwardedBuildFlags_SourceRetries(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateAPKOptions(t *testing.T) {
+	tests := []struct {
+		format      string
+		compression string
+		shouldError bool
+	}{
+		{"", "", false},
+		{"v2", "", false},
+		{"v3", "zstd", false},
+		{"v3", "gzip", false},
+		{"v3", "xz", true},
+		{"v4", "", true},
+		{"", "zstd", true},
+		{"v2", "gzip", true},
+	}
+
+	for _, tt := range tests {
+		err := validateAPKOptions(tt.format, tt.compression)
+		if (err != nil) != tt.shouldError {
+			t.Errorf("validateAPKOptions(%q, %q) error = %v, shouldError = %v",
+				tt.format, tt.compression, err, tt.shouldError)
+		}
+	}
+}
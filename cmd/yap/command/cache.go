@@ -0,0 +1,170 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	yapErrors "github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/logger"
+	"github.com/M0Rf30/yap/v2/pkg/srccache"
+)
+
+const commandCache = "cache"
+
+// cacheOlderThan is the local holder for the prune --older-than flag value.
+var cacheOlderThan string
+
+// cacheCmd groups the cache management sub-commands.
+var cacheCmd = &cobra.Command{
+	Use:     commandCache,
+	GroupID: commandUtility,
+	Short:   "", // Set by InitializeLocalizedDescriptions
+	Long:    "", // Set by InitializeLocalizedDescriptions
+	Example: "", // Set by InitializeLocalizedDescriptions
+}
+
+// cacheSourcesCmd groups the shared source cache sub-commands.
+var cacheSourcesCmd = &cobra.Command{
+	Use:   "sources",
+	Short: "", // Set by InitializeLocalizedDescriptions
+}
+
+// cacheSourcesListCmd prints the entries of the shared source cache.
+var cacheSourcesListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "", // Set by InitializeLocalizedDescriptions
+	Args:    cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		entries, err := srccache.List()
+		if err != nil {
+			return err
+		}
+
+		printCacheEntries(entries)
+
+		return nil
+	},
+}
+
+// cacheSourcesPruneCmd removes stale entries from the shared source cache.
+var cacheSourcesPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "", // Set by InitializeLocalizedDescriptions
+	Args:  cobra.NoArgs,
+	RunE: func(_ *cobra.Command, _ []string) error {
+		olderThan, err := parseAge(cacheOlderThan)
+		if err != nil {
+			return err
+		}
+
+		removed, err := srccache.Prune(olderThan)
+		if err != nil {
+			return err
+		}
+
+		var freed int64
+		for _, entry := range removed {
+			freed += entry.Size
+		}
+
+		logger.Info(i18n.T("logger.cache.info.pruned"),
+			"entries", len(removed), "freed", formatSize(freed), "dir", srccache.Dir())
+
+		return nil
+	},
+}
+
+// printCacheEntries writes entries as a table, least recently used first.
+func printCacheEntries(entries []srccache.Entry) {
+	dir := srccache.Dir()
+	if dir == "" {
+		logger.Info(i18n.T("logger.cache.info.disabled"))
+
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "CHECKSUM\tSIZE\tLAST USED")
+
+	var total int64
+
+	for _, entry := range entries {
+		total += entry.Size
+
+		_, _ = fmt.Fprintf(w, "%s:%s\t%s\t%s\n", entry.Algorithm, entry.Checksum[:16],
+			formatSize(entry.Size), entry.LastUsed.Format(time.DateTime))
+	}
+
+	_ = w.Flush()
+
+	logger.Info(i18n.T("logger.cache.info.summary"),
+		"dir", dir, "entries", len(entries), "size", formatSize(total))
+}
+
+// parseAge parses a prune age. Besides time.ParseDuration units it accepts
+// a "d" suffix for days, e.g. "30d".
+func parseAge(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err == nil && n >= 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+
+	age, err := time.ParseDuration(value)
+	if err != nil || age < 0 {
+		return 0, yapErrors.New(yapErrors.ErrTypeValidation,
+			i18n.T("errors.cache.invalid_age")).
+			WithOperation("parseAge").
+			WithContext("value", value)
+	}
+
+	return age, nil
+}
+
+// formatSize renders a byte count with a binary unit suffix.
+func formatSize(size int64) string {
+	const unit = 1024
+
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
+
+// InitializeCacheDescriptions sets the localized descriptions for the cache
+// command tree.
+func InitializeCacheDescriptions() {
+	initCommandDescriptions(cacheCmd, commandCache, map[string]string{})
+	cacheSourcesCmd.Short = i18n.T("commands.cache.sources.short")
+	cacheSourcesListCmd.Short = i18n.T("commands.cache.sources_list.short")
+	cacheSourcesPruneCmd.Short = i18n.T("commands.cache.sources_prune.short")
+
+	if flag := cacheSourcesPruneCmd.Flag("older-than"); flag != nil {
+		flag.Usage = i18n.T("flags.cache.older_than")
+	}
+}
+
+//nolint:gochecknoinits // Required for cobra command registration
+func init() {
+	cacheSourcesPruneCmd.Flags().StringVar(&cacheOlderThan, "older-than", "30d", "")
+
+	cacheSourcesCmd.AddCommand(cacheSourcesListCmd, cacheSourcesPruneCmd)
+	cacheCmd.AddCommand(cacheSourcesCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
@@ -0,0 +1,48 @@
+package command
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitializeCacheDescriptions(t *testing.T) {
+	InitializeCacheDescriptions()
+	assert.NotEmpty(t, cacheCmd.Short)
+	assert.NotEmpty(t, cacheSourcesListCmd.Short)
+	assert.NotEmpty(t, cacheSourcesPruneCmd.Short)
+}
+
+func TestParseAge(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{value: "30d", want: 30 * 24 * time.Hour},
+		{value: "72h", want: 72 * time.Hour},
+		{value: "0s", want: 0},
+		{value: "-1h", wantErr: true},
+		{value: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseAge(tt.value)
+		if tt.wantErr {
+			assert.Error(t, err, tt.value)
+
+			continue
+		}
+
+		require.NoError(t, err, tt.value)
+		assert.Equal(t, tt.want, got, tt.value)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "512 B", formatSize(512))
+	assert.Equal(t, "1.5 KiB", formatSize(1536))
+	assert.Equal(t, "2.0 MiB", formatSize(2*1024*1024))
+}
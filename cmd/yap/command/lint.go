@@ -0,0 +1,108 @@
+package command
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	yapErrors "github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/lint"
+)
+
+const commandLint = "lint"
+
+var (
+	// lintFormat is the local holder for the --format flag.
+	lintFormat string
+
+	// lintOutput is the local holder for the --output flag.
+	lintOutput string
+
+	// lintDistro is the local holder for the --distro flag.
+	lintDistro string
+
+	// lintTargetArch is the local holder for the --target-arch flag.
+	lintTargetArch string
+)
+
+// lintCmd runs the lint rules over a PKGBUILD.
+var lintCmd = &cobra.Command{
+	Use:     commandLint + " [path]",
+	GroupID: buildGroup,
+	Short:   "", // Set by InitializeLocalizedDescriptions
+	Long:    "", // Set by InitializeLocalizedDescriptions
+	Example: "", // Set by InitializeLocalizedDescriptions
+	Args:    cobra.MaximumNArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		path := "."
+		if len(args) > 0 {
+			path = args[0]
+		}
+
+		var distro, release string
+		if lintDistro != "" {
+			distro, release = parseDistroAndRelease(lintDistro)
+		}
+
+		distro, release = ResolveDistroRelease(distro, release,
+			"logger.lint.no_distribution_specified")
+
+		findings, err := lint.Lint(path, lint.Options{
+			Distro:     distro,
+			Release:    release,
+			TargetArch: lintTargetArch,
+		})
+		if err != nil {
+			return err
+		}
+
+		var out io.Writer = os.Stdout
+
+		if lintOutput != "" {
+			file, err := os.Create(filepath.Clean(lintOutput))
+			if err != nil {
+				return err
+			}
+
+			defer func() { _ = file.Close() }()
+
+			out = file
+		}
+
+		if err := lint.Write(out, lint.Format(lintFormat), findings); err != nil {
+			return err
+		}
+
+		if count := lint.Count(findings, lint.SeverityError); count > 0 {
+			return yapErrors.New(yapErrors.ErrTypeValidation,
+				i18n.T("errors.lint.findings_failed")).
+				WithOperation("lint").
+				WithContext("errors", count)
+		}
+
+		return nil
+	},
+}
+
+// InitializeLintDescriptions sets the localized descriptions for lint.
+func InitializeLintDescriptions() {
+	initCommandDescriptions(lintCmd, commandLint, map[string]string{
+		"format":      "flags.lint.format",
+		"output":      "flags.lint.output",
+		"distro":      "flags.lint.distro",
+		"target-arch": "flags.lint.target_arch",
+	})
+}
+
+//nolint:gochecknoinits // Required for cobra command registration
+func init() {
+	lintCmd.Flags().StringVarP(&lintFormat, "format", "f", string(lint.FormatText), "")
+	lintCmd.Flags().StringVarP(&lintOutput, "output", "o", "", "")
+	lintCmd.Flags().StringVar(&lintDistro, "distro", "", "")
+	lintCmd.Flags().StringVar(&lintTargetArch, "target-arch", "", "")
+
+	rootCmd.AddCommand(lintCmd)
+}
@@ -0,0 +1,19 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitializeLintDescriptions(t *testing.T) {
+	InitializeLintDescriptions()
+	assert.NotEmpty(t, lintCmd.Short, "lintCmd.Short should be non-empty after InitializeLintDescriptions")
+	assert.NotEmpty(t, lintCmd.Flag("format").Usage)
+}
+
+func TestLintCommandDefinition(t *testing.T) {
+	assert.Equal(t, "lint [path]", lintCmd.Use)
+	assert.NotNil(t, lintCmd.RunE)
+	assert.Equal(t, "text", lintCmd.Flag("format").DefValue)
+}
@@ -0,0 +1,752 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/M0Rf30/yap/v2/pkg/apkindex"
+	"github.com/M0Rf30/yap/v2/pkg/aptrepo"
+	"github.com/M0Rf30/yap/v2/pkg/dnfcache"
+	yapErrors "github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/logger"
+	"github.com/M0Rf30/yap/v2/pkg/pacmandb"
+	"github.com/M0Rf30/yap/v2/pkg/reposerve"
+	"github.com/M0Rf30/yap/v2/pkg/signing"
+	"github.com/M0Rf30/yap/v2/pkg/xbpsrepo"
+)
+
+const (
+	commandRepo = "repo"
+	// repoPasswordEnv holds the basic-auth password of repo serve unless
+	// --password-file is given, so that it stays out of the process list.
+	repoPasswordEnv = "YAP_REPO_PASSWORD"
+	// repoPasswordPlaceholder stands for the password in the URLs printed
+	// by repo serve when basic authentication is enabled.
+	repoPasswordPlaceholder = "<password>"
+)
+
+// Local holders for the repo serve flag values.
+var (
+	repoListen       string
+	repoURL          string
+	repoUsername     string
+	repoPasswordFile string
+)
+
+// repoSignFlags holds the --sign, --sign-key and --sign-passphrase flag
+// values of a repo sub-command.
+type repoSignFlags struct {
+	sign       bool
+	key        string
+	passphrase string
+}
+
+// repoIndexFlags holds the flag values of repo index.
+type repoIndexFlags struct {
+	repoSignFlags
+
+	arch        string
+	signedBy    string
+	description string
+	name        string
+	update      bool
+	sqlite      bool
+}
+
+// repoPublishFlags holds the flag values of repo publish.
+type repoPublishFlags struct {
+	repoSignFlags
+
+	arch      string
+	suite     string
+	codename  string
+	component string
+	origin    string
+	label     string
+}
+
+// Flag values of the repo sub-commands.
+var (
+	repoIndexOpts   repoIndexFlags
+	repoPublishOpts repoPublishFlags
+	repoAddOpts     repoSignFlags
+	repoRemoveOpts  repoSignFlags
+)
+
+// repoCmd groups the package repository sub-commands.
+var repoCmd = &cobra.Command{
+	Use:     commandRepo,
+	GroupID: commandUtility,
+	Short:   "", // Set by InitializeLocalizedDescriptions
+	Long:    "", // Set by InitializeLocalizedDescriptions
+	Example: "", // Set by InitializeLocalizedDescriptions
+}
+
+// repoIndexCmd writes the repository index of a directory of packages.
+var repoIndexCmd = &cobra.Command{
+	Use:   "index <dir>",
+	Short: "", // Set by InitializeLocalizedDescriptions
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		paths, err := indexRepository(cmd.Context(), args[0], repoIndexOpts)
+		if err != nil {
+			return err
+		}
+
+		for _, path := range paths {
+			logger.Info(i18n.T("logger.repo.info.index_written"), "path", path)
+		}
+
+		return nil
+	},
+}
+
+// repoPublishCmd adds Debian packages to an apt repository tree.
+var repoPublishCmd = &cobra.Command{
+	Use:   "publish <repo-dir> <package|dir>...",
+	Short: "", // Set by InitializeLocalizedDescriptions
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		path, err := publishAPT(cmd.Context(), args[0], args[1:], repoPublishOpts)
+		if err != nil {
+			return err
+		}
+
+		logger.Info(i18n.T("logger.repo.info.index_written"), "path", path)
+
+		return nil
+	},
+}
+
+// repoAddCmd adds pacman packages to a repository database.
+var repoAddCmd = &cobra.Command{
+	Use:   "add <db> <package>...",
+	Short: "", // Set by InitializeLocalizedDescriptions
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := pacmanRepoOptions(repoAddOpts, "repoAdd")
+		if err != nil {
+			return err
+		}
+
+		paths, err := pacmandb.AddPackages(cmd.Context(), args[0], args[1:], opts)
+		if err != nil {
+			return err
+		}
+
+		for _, path := range paths {
+			logger.Info(i18n.T("logger.repo.info.index_written"), "path", path)
+		}
+
+		return nil
+	},
+}
+
+// repoRemoveCmd removes pacman packages from a repository database.
+var repoRemoveCmd = &cobra.Command{
+	Use:   "remove <db> <package-name>...",
+	Short: "", // Set by InitializeLocalizedDescriptions
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := pacmanRepoOptions(repoRemoveOpts, "repoRemove")
+		if err != nil {
+			return err
+		}
+
+		paths, err := pacmandb.RemovePackages(cmd.Context(), args[0], args[1:], opts)
+		if err != nil {
+			return err
+		}
+
+		for _, path := range paths {
+			logger.Info(i18n.T("logger.repo.info.index_written"), "path", path)
+		}
+
+		return nil
+	},
+}
+
+// repoServeCmd serves repository trees over HTTP.
+var repoServeCmd = &cobra.Command{
+	Use:   "serve <dir>",
+	Short: "", // Set by InitializeLocalizedDescriptions
+	Args:  cobra.ExactArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer cancel()
+
+		return serveRepositories(ctx, args[0])
+	},
+}
+
+// indexRepository indexes every package format found in dir: xbps
+// packages into <arch>-repodata files per directory, RPMs into repodata/,
+// APK packages into an APKINDEX.tar.gz per directory and pacman packages
+// into the <repo>.db and <repo>.files databases. --sign-key is rejected
+// when dir holds more than one format, as each format takes a different
+// kind of key.
+func indexRepository(ctx context.Context, dir string, flags repoIndexFlags) ([]string, error) {
+	type indexer struct {
+		ext   string
+		index func(context.Context, string, repoIndexFlags) ([]string, error)
+	}
+
+	var (
+		found []indexer
+		exts  []string
+	)
+
+	for _, candidate := range []indexer{
+		{".xbps", indexXBPS},
+		{".rpm", indexRPM},
+		{".apk", indexAPK},
+		{".pkg.tar.zst", indexPacman},
+	} {
+		ok, err := hasPackages(dir, candidate.ext)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			found = append(found, candidate)
+			exts = append(exts, candidate.ext)
+		}
+	}
+
+	if len(found) == 0 {
+		return nil, yapErrors.New(yapErrors.ErrTypeValidation,
+			i18n.T("errors.repo.no_packages")).
+			WithOperation("indexRepository").
+			WithContext("path", dir)
+	}
+
+	if flags.key != "" && len(found) > 1 {
+		return nil, yapErrors.New(yapErrors.ErrTypeValidation,
+			i18n.T("errors.repo.sign_key_mixed_formats")).
+			WithOperation("indexRepository").
+			WithContext("path", dir).
+			WithContext("formats", strings.Join(exts, " "))
+	}
+
+	var paths []string
+
+	for _, indexer := range found {
+		written, err := indexer.index(ctx, dir, flags)
+		if err != nil {
+			return nil, err
+		}
+
+		paths = append(paths, written...)
+	}
+
+	return paths, nil
+}
+
+// hasPackages reports whether dir, or any directory below it, holds a
+// file with the package extension ext.
+func hasPackages(dir, ext string) (bool, error) {
+	packages, err := findPackages(dir, ext)
+
+	return len(packages) > 0, err
+}
+
+// findPackages returns the files with the package extension ext in dir
+// and the directories below it, in lexical order.
+func findPackages(dir, ext string) ([]string, error) {
+	var packages []string
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ext) {
+			packages = append(packages, path)
+		}
+
+		return nil
+	})
+
+	return packages, err
+}
+
+// indexXBPS writes the <arch>-repodata files of every directory of xbps
+// packages under dir. With --sign, every package gets a .sig2 signature
+// first and the indexes record the public key.
+func indexXBPS(ctx context.Context, dir string, flags repoIndexFlags) ([]string, error) {
+	opts := xbpsrepo.Options{Arch: flags.arch, SignedBy: flags.signedBy}
+
+	var xbpsSigner *signing.XBPSSigner
+
+	if flags.sign {
+		cfg, err := signing.Resolve(signing.FormatXBPS, flags.key, flags.passphrase, "", "")
+		if err != nil {
+			return nil, err
+		}
+
+		cfg.Enabled = true
+
+		signer, err := signing.NewSigner(signing.FormatXBPS, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		var ok bool
+
+		xbpsSigner, ok = signer.(*signing.XBPSSigner)
+		if !ok {
+			return nil, yapErrors.New(yapErrors.ErrTypeConfiguration,
+				i18n.T("errors.repo.unsupported_signer")).
+				WithOperation("indexXBPS")
+		}
+
+		opts.PublicKey = xbpsSigner.PublicKey()
+	}
+
+	packages, err := findPackages(dir, ".xbps")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(packages) == 0 {
+		return xbpsrepo.WriteIndex(dir, opts)
+	}
+
+	var (
+		dirs  []string
+		paths []string
+	)
+
+	for _, pkg := range packages {
+		if xbpsSigner != nil {
+			if err := xbpsSigner.Sign(ctx, pkg); err != nil {
+				return nil, err
+			}
+		}
+
+		if pkgDir := filepath.Dir(pkg); !slices.Contains(dirs, pkgDir) {
+			dirs = append(dirs, pkgDir)
+		}
+	}
+
+	for _, pkgDir := range dirs {
+		written, err := xbpsrepo.WriteIndex(pkgDir, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		paths = append(paths, written...)
+	}
+
+	return paths, nil
+}
+
+// indexRPM writes the repodata/ metadata of the RPMs under dir. With
+// --sign, repomd.xml is signed into repomd.xml.asc.
+func indexRPM(ctx context.Context, dir string, flags repoIndexFlags) ([]string, error) {
+	opts := dnfcache.RepodataOptions{Update: flags.update, SQLite: flags.sqlite}
+
+	if flags.sign {
+		signer, err := repoGPGSigner(signing.FormatRPM, flags.key, flags.passphrase, "indexRPM")
+		if err != nil {
+			return nil, err
+		}
+
+		opts.Signer = signer
+	}
+
+	path, err := dnfcache.WriteRepodata(ctx, dir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{path}, nil
+}
+
+// indexAPK writes the APKINDEX.tar.gz of every directory of APK packages
+// under dir. With --sign, each index is signed with the RSA key of the
+// packages.
+func indexAPK(ctx context.Context, dir string, flags repoIndexFlags) ([]string, error) {
+	var rsaSigner *signing.RSASigner
+
+	if flags.sign {
+		cfg, err := signing.Resolve(signing.FormatAPK, flags.key, flags.passphrase, "", "")
+		if err != nil {
+			return nil, err
+		}
+
+		cfg.Enabled = true
+
+		signer, err := signing.NewSigner(signing.FormatAPK, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		var ok bool
+
+		rsaSigner, ok = signer.(*signing.RSASigner)
+		if !ok {
+			return nil, yapErrors.New(yapErrors.ErrTypeConfiguration,
+				i18n.T("errors.repo.unsupported_signer")).
+				WithOperation("indexAPK")
+		}
+	}
+
+	paths, err := apkindex.WriteIndex(dir, apkindex.WriteOptions{Description: flags.description})
+	if err != nil {
+		return nil, err
+	}
+
+	if rsaSigner != nil {
+		for _, path := range paths {
+			if err := rsaSigner.SignIndex(ctx, path); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return paths, nil
+}
+
+// indexPacman writes the pacman databases of the packages in dir, named
+// after --name or else the directory. With --sign, both databases get a
+// binary detached .sig signature.
+func indexPacman(ctx context.Context, dir string, flags repoIndexFlags) ([]string, error) {
+	name := flags.name
+	if name == "" {
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		name = filepath.Base(abs)
+	}
+
+	opts, err := pacmanRepoOptions(flags.repoSignFlags, "indexPacman")
+	if err != nil {
+		return nil, err
+	}
+
+	return pacmandb.WriteRepo(ctx, dir, name, opts)
+}
+
+// pacmanRepoOptions returns the options of the pacman database writers,
+// with the OpenPGP signer of the --sign-key of flags when signing.
+func pacmanRepoOptions(flags repoSignFlags, operation string) (pacmandb.RepoOptions, error) {
+	if !flags.sign {
+		return pacmandb.RepoOptions{}, nil
+	}
+
+	signer, err := repoGPGSigner(signing.FormatPacman, flags.key, flags.passphrase, operation)
+	if err != nil {
+		return pacmandb.RepoOptions{}, err
+	}
+
+	return pacmandb.RepoOptions{Signer: signer}, nil
+}
+
+// publishAPT publishes the .deb packages, and those of the directories,
+// among args to the apt repository at root. With --sign, the Release file
+// is signed into InRelease and Release.gpg.
+func publishAPT(ctx context.Context, root string, args []string, flags repoPublishFlags) (string, error) {
+	var debs []string
+
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return "", err
+		}
+
+		if !info.IsDir() {
+			debs = append(debs, arg)
+
+			continue
+		}
+
+		matches, err := filepath.Glob(filepath.Join(arg, "*.deb"))
+		if err != nil {
+			return "", err
+		}
+
+		debs = append(debs, matches...)
+	}
+
+	opts := aptrepo.PublishOptions{
+		Suite:     flags.suite,
+		Codename:  flags.codename,
+		Component: flags.component,
+		Arch:      flags.arch,
+		Origin:    flags.origin,
+		Label:     flags.label,
+	}
+
+	if flags.sign {
+		signer, err := repoGPGSigner(signing.FormatDEB, flags.key, flags.passphrase, "publishAPT")
+		if err != nil {
+			return "", err
+		}
+
+		opts.Signer = signer
+	}
+
+	return aptrepo.Publish(ctx, root, debs, opts)
+}
+
+// serveRepositories serves dir on --listen until ctx is cancelled, after
+// printing how to point clients at each repository found in it. With
+// --username, the printed URLs carry the username and a placeholder for
+// the password.
+func serveRepositories(ctx context.Context, dir string) error {
+	password, err := servePassword(repoPasswordFile)
+	if err != nil {
+		return err
+	}
+
+	if password != "" && repoUsername == "" {
+		return yapErrors.New(yapErrors.ErrTypeConfiguration, i18n.T("errors.repo.password_without_username")).
+			WithOperation("serveRepositories")
+	}
+
+	if repoUsername != "" && password == "" {
+		return yapErrors.New(yapErrors.ErrTypeConfiguration, i18n.T("errors.repo.username_without_password")).
+			WithOperation("serveRepositories").
+			WithContext("env", repoPasswordEnv)
+	}
+
+	trees, err := reposerve.Detect(dir)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", repoListen)
+	if err != nil {
+		return yapErrors.Wrap(err, yapErrors.ErrTypeNetwork, i18n.T("errors.repo.listen_failed")).
+			WithOperation("serveRepositories").
+			WithContext("address", repoListen)
+	}
+
+	baseURL, err := serveBaseURL(listener.Addr())
+	if err != nil {
+		_ = listener.Close()
+
+		return err
+	}
+
+	if len(trees) == 0 {
+		logger.Warn(i18n.T("logger.repo.warn.no_repositories"), "dir", dir)
+	}
+
+	specURL := specBaseURL(baseURL, repoUsername)
+
+	for _, tree := range trees {
+		fmt.Println(tree.Spec(specURL))
+	}
+
+	if repoUsername != "" {
+		logger.Info(i18n.T("logger.repo.info.basic_auth"), "username", repoUsername)
+	}
+
+	logger.Info(i18n.T("logger.repo.info.serving"), "dir", dir, "url", baseURL.String())
+
+	return reposerve.Serve(ctx, listener, dir, reposerve.Options{
+		Username: repoUsername,
+		Password: password,
+	})
+}
+
+// servePassword returns the basic-auth password of repo serve: the first
+// line of passwordFile when set, or else $YAP_REPO_PASSWORD.
+func servePassword(passwordFile string) (string, error) {
+	if passwordFile == "" {
+		return os.Getenv(repoPasswordEnv), nil
+	}
+
+	content, err := os.ReadFile(filepath.Clean(passwordFile))
+	if err != nil {
+		return "", yapErrors.Wrap(err, yapErrors.ErrTypeFileSystem, i18n.T("errors.repo.password_file_failed")).
+			WithOperation("servePassword").
+			WithContext("path", passwordFile)
+	}
+
+	password, _, _ := strings.Cut(string(content), "\n")
+
+	return strings.TrimSuffix(password, "\r"), nil
+}
+
+// serveBaseURL returns --url, or the URL clients reach addr at: the first
+// non-loopback IPv4 address of the host when listening on every address.
+func serveBaseURL(addr net.Addr) (*url.URL, error) {
+	if repoURL != "" {
+		parsed, err := url.Parse(strings.TrimSuffix(repoURL, "/"))
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return nil, yapErrors.New(yapErrors.ErrTypeValidation, i18n.T("errors.repo.invalid_url")).
+				WithOperation("serveBaseURL").
+				WithContext("url", repoURL)
+		}
+
+		return parsed, nil
+	}
+
+	host, port := "localhost", ""
+
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok {
+		port = strconv.Itoa(tcpAddr.Port)
+
+		if !tcpAddr.IP.IsUnspecified() {
+			host = tcpAddr.IP.String()
+		} else if ip := hostIPv4(); ip != "" {
+			host = ip
+		}
+	}
+
+	return &url.URL{Scheme: "http", Host: net.JoinHostPort(host, port)}, nil
+}
+
+// specBaseURL returns baseURL as printed in the client specs: with the
+// username and a password placeholder when username is set.
+func specBaseURL(baseURL *url.URL, username string) string {
+	if username == "" {
+		return baseURL.String()
+	}
+
+	withUser := *baseURL
+	withUser.User = url.User(username)
+
+	// The username is escaped, so the first "@" ends the userinfo.
+	return strings.Replace(withUser.String(), "@", ":"+repoPasswordPlaceholder+"@", 1)
+}
+
+// hostIPv4 returns the first non-loopback IPv4 address of the host, which
+// containers on a bridge network can reach, or "" when there is none.
+func hostIPv4() string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return ""
+	}
+
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+			return ipNet.IP.String()
+		}
+	}
+
+	return ""
+}
+
+// repoGPGSigner returns the OpenPGP signer of key, the --sign-key of the
+// sub-command, resolved for format, which signs repository metadata.
+func repoGPGSigner(format signing.Format, key, passphrase, operation string) (*signing.GPGSigner, error) {
+	cfg, err := signing.Resolve(format, key, passphrase, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	cfg.Enabled = true
+
+	signer, err := signing.NewSigner(format, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	gpgSigner, ok := signer.(*signing.GPGSigner)
+	if !ok {
+		return nil, yapErrors.New(yapErrors.ErrTypeConfiguration,
+			i18n.T("errors.repo.unsupported_signer")).
+			WithOperation(operation)
+	}
+
+	return gpgSigner, nil
+}
+
+// InitializeRepoDescriptions sets the localized descriptions for the repo
+// command tree.
+func InitializeRepoDescriptions() {
+	initCommandDescriptions(repoCmd, commandRepo, map[string]string{})
+	initCommandDescriptions(repoIndexCmd, commandRepo+".index", map[string]string{
+		"arch":            "flags.repo.arch",
+		"description":     "flags.repo.description",
+		"name":            "flags.repo.name",
+		"sign":            "flags.repo.sign",
+		"sign-key":        "flags.repo.sign_key",
+		"sign-passphrase": "flags.repo.sign_passphrase",
+		"signed-by":       "flags.repo.signed_by",
+		"sqlite":          "flags.repo.sqlite",
+		"update":          "flags.repo.update",
+	})
+	initCommandDescriptions(repoPublishCmd, commandRepo+".publish", map[string]string{
+		"arch":            "flags.repo.publish_arch",
+		"codename":        "flags.repo.codename",
+		"component":       "flags.repo.component",
+		"label":           "flags.repo.label",
+		"origin":          "flags.repo.origin",
+		"sign":            "flags.repo.publish_sign",
+		"sign-key":        "flags.repo.publish_sign_key",
+		"sign-passphrase": "flags.repo.sign_passphrase",
+		"suite":           "flags.repo.suite",
+	})
+
+	pacmanFlags := map[string]string{
+		"sign":            "flags.repo.pacman_sign",
+		"sign-key":        "flags.repo.publish_sign_key",
+		"sign-passphrase": "flags.repo.sign_passphrase",
+	}
+	initCommandDescriptions(repoAddCmd, commandRepo+".add", pacmanFlags)
+	initCommandDescriptions(repoRemoveCmd, commandRepo+".remove", pacmanFlags)
+	initCommandDescriptions(repoServeCmd, commandRepo+".serve", map[string]string{
+		"listen":        "flags.repo.listen",
+		"password-file": "flags.repo.password_file",
+		"url":           "flags.repo.url",
+		"username":      "flags.repo.username",
+	})
+}
+
+// addRepoSignFlags registers the signing flags of cmd, bound to flags.
+func addRepoSignFlags(cmd *cobra.Command, flags *repoSignFlags) {
+	cmd.Flags().BoolVarP(&flags.sign, "sign", "K", false, "")
+	cmd.Flags().StringVar(&flags.key, "sign-key", "", "")
+	cmd.Flags().StringVar(&flags.passphrase, "sign-passphrase", "", "")
+}
+
+//nolint:gochecknoinits // Required for cobra command registration
+func init() {
+	repoIndexCmd.Flags().StringVar(&repoIndexOpts.arch, "arch", "", "")
+	repoIndexCmd.Flags().StringVar(&repoIndexOpts.signedBy, "signed-by", "yap", "")
+	repoIndexCmd.Flags().StringVar(&repoIndexOpts.description, "description", "", "")
+	repoIndexCmd.Flags().StringVar(&repoIndexOpts.name, "name", "", "")
+	repoIndexCmd.Flags().BoolVar(&repoIndexOpts.update, "update", false, "")
+	repoIndexCmd.Flags().BoolVar(&repoIndexOpts.sqlite, "sqlite", false, "")
+	addRepoSignFlags(repoIndexCmd, &repoIndexOpts.repoSignFlags)
+
+	repoPublishCmd.Flags().StringVar(&repoPublishOpts.arch, "arch", "", "")
+	repoPublishCmd.Flags().StringVar(&repoPublishOpts.suite, "suite", "stable", "")
+	repoPublishCmd.Flags().StringVar(&repoPublishOpts.codename, "codename", "", "")
+	repoPublishCmd.Flags().StringVar(&repoPublishOpts.component, "component", "main", "")
+	repoPublishCmd.Flags().StringVar(&repoPublishOpts.origin, "origin", "", "")
+	repoPublishCmd.Flags().StringVar(&repoPublishOpts.label, "label", "", "")
+	addRepoSignFlags(repoPublishCmd, &repoPublishOpts.repoSignFlags)
+
+	addRepoSignFlags(repoAddCmd, &repoAddOpts)
+	addRepoSignFlags(repoRemoveCmd, &repoRemoveOpts)
+
+	repoServeCmd.Flags().StringVar(&repoListen, "listen", ":8080", "")
+	repoServeCmd.Flags().StringVar(&repoURL, "url", "", "")
+	repoServeCmd.Flags().StringVar(&repoUsername, "username", "", "")
+	repoServeCmd.Flags().StringVar(&repoPasswordFile, "password-file", "", "")
+
+	repoCmd.AddCommand(repoIndexCmd, repoPublishCmd, repoAddCmd, repoRemoveCmd, repoServeCmd)
+	rootCmd.AddCommand(repoCmd)
+}
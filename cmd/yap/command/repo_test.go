@@ -0,0 +1,269 @@
+package command
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+)
+
+// signedIndexFlags are the repo index flags of --sign without --sign-key.
+var signedIndexFlags = repoIndexFlags{repoSignFlags: repoSignFlags{sign: true}}
+
+func TestInitializeRepoDescriptions(t *testing.T) {
+	InitializeRepoDescriptions()
+	assert.NotEmpty(t, repoCmd.Short)
+	assert.NotEmpty(t, repoIndexCmd.Short)
+	assert.NotEmpty(t, repoIndexCmd.Flag("signed-by").Usage)
+	assert.NotEmpty(t, repoIndexCmd.Flag("update").Usage)
+	assert.NotEmpty(t, repoIndexCmd.Flag("description").Usage)
+	assert.NotEmpty(t, repoPublishCmd.Short)
+	assert.NotEmpty(t, repoPublishCmd.Flag("suite").Usage)
+	assert.NotEmpty(t, repoAddCmd.Short)
+	assert.NotEmpty(t, repoRemoveCmd.Flag("sign").Usage)
+	assert.NotEmpty(t, repoServeCmd.Short)
+	assert.NotEmpty(t, repoServeCmd.Flag("listen").Usage)
+}
+
+func TestIndexXBPSWithoutPackages(t *testing.T) {
+	_, err := indexXBPS(context.Background(), t.TempDir(), repoIndexFlags{})
+	require.Error(t, err)
+}
+
+func TestIndexXBPSSignWithoutKey(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("YAP_SIGN_KEY", "")
+	t.Setenv("YAP_XBPS_KEY", "")
+
+	_, err := indexXBPS(context.Background(), t.TempDir(), signedIndexFlags)
+	require.Error(t, err)
+}
+
+func TestIndexRepositoryWithoutPackages(t *testing.T) {
+	_, err := indexRepository(context.Background(), t.TempDir(), repoIndexFlags{})
+	require.Error(t, err)
+}
+
+func TestIndexRepositorySignKeyMixedFormats(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "foo-1.0-1.x86_64.rpm"), nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "foo-1.0-1-x86_64.pkg.tar.zst"), nil, 0o644))
+
+	flags := repoIndexFlags{repoSignFlags: repoSignFlags{sign: true, key: filepath.Join(dir, "key.gpg")}}
+
+	_, err := indexRepository(context.Background(), dir, flags)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), i18n.T("errors.repo.sign_key_mixed_formats"))
+}
+
+func TestIndexRPMSignWithoutKey(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("YAP_SIGN_KEY", "")
+	t.Setenv("YAP_RPM_KEY", "")
+
+	_, err := indexRPM(context.Background(), t.TempDir(), signedIndexFlags)
+	require.Error(t, err)
+}
+
+func TestIndexAPKSignWithoutKey(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("YAP_SIGN_KEY", "")
+	t.Setenv("YAP_APK_KEY", "")
+
+	_, err := indexAPK(context.Background(), t.TempDir(), signedIndexFlags)
+	require.Error(t, err)
+}
+
+func TestIndexPacmanSignWithoutKey(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("YAP_SIGN_KEY", "")
+	t.Setenv("YAP_PACMAN_KEY", "")
+
+	_, err := indexPacman(context.Background(), t.TempDir(), signedIndexFlags)
+	require.Error(t, err)
+}
+
+func TestPublishAPTMissingPackage(t *testing.T) {
+	_, err := publishAPT(context.Background(), t.TempDir(),
+		[]string{filepath.Join(t.TempDir(), "missing.deb")}, repoPublishFlags{})
+	require.Error(t, err)
+}
+
+func TestPublishAPTSignWithoutKey(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("YAP_SIGN_KEY", "")
+	t.Setenv("YAP_DEB_KEY", "")
+
+	_, err := publishAPT(context.Background(), t.TempDir(), []string{t.TempDir()},
+		repoPublishFlags{repoSignFlags: repoSignFlags{sign: true}})
+	require.Error(t, err)
+}
+
+func TestFindPackages(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"foo-1.0_1.x86_64.xbps", "nested/bar-1.0_1.x86_64.xbps", "nested/bar.rpm"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, nil, 0o644))
+	}
+
+	packages, err := findPackages(dir, ".xbps")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "foo-1.0_1.x86_64.xbps"),
+		filepath.Join(dir, "nested", "bar-1.0_1.x86_64.xbps"),
+	}, packages)
+
+	found, err := hasPackages(filepath.Join(dir, "nested"), ".rpm")
+	require.NoError(t, err)
+	assert.True(t, found)
+}
+
+func TestServeBaseURL(t *testing.T) {
+	defer func() {
+		repoURL = ""
+	}()
+
+	baseURL, err := serveBaseURL(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8080})
+	require.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.1:8080", baseURL.String())
+
+	baseURL, err = serveBaseURL(&net.TCPAddr{IP: net.IPv6unspecified, Port: 8080})
+	require.NoError(t, err)
+	assert.NotContains(t, baseURL.Host, "::")
+
+	repoURL = "http://host.containers.internal:8080/"
+	baseURL, err = serveBaseURL(&net.TCPAddr{IP: net.IPv6unspecified, Port: 8080})
+	require.NoError(t, err)
+	assert.Equal(t, "http://host.containers.internal:8080", baseURL.String())
+
+	repoURL = "host:8080"
+	_, err = serveBaseURL(&net.TCPAddr{IP: net.IPv6unspecified, Port: 8080})
+	require.Error(t, err)
+}
+
+func TestServeRepositoriesCredentials(t *testing.T) {
+	defer func() {
+		repoUsername = ""
+	}()
+
+	t.Setenv(repoPasswordEnv, "secret")
+	require.Error(t, serveRepositories(context.Background(), t.TempDir()))
+
+	t.Setenv(repoPasswordEnv, "")
+	repoUsername = "ci"
+	require.Error(t, serveRepositories(context.Background(), t.TempDir()))
+}
+
+func TestServeRepositoriesSpecsPasswordPlaceholder(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "rpms", "repodata"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "rpms", "repodata", "repomd.xml"), nil, 0o644))
+
+	t.Setenv(repoPasswordEnv, "secret")
+
+	stdout := os.Stdout
+	reader, writer, err := os.Pipe()
+	require.NoError(t, err)
+
+	os.Stdout = writer
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	repoListen, repoURL, repoUsername = "127.0.0.1:0", "http://repo.example:8080", "ci"
+
+	defer func() {
+		repoListen, repoURL, repoUsername = ":8080", "", ""
+	}()
+
+	err = serveRepositories(ctx, dir)
+
+	os.Stdout = stdout
+
+	require.NoError(t, writer.Close())
+	require.NoError(t, err)
+
+	output, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "--repo name=rpms,url=http://ci:<password>@repo.example:8080/rpms,format=rpm\n", string(output))
+}
+
+func TestSpecBaseURL(t *testing.T) {
+	baseURL := &url.URL{Scheme: "http", Host: "repo.example:8080", Path: "/repos"}
+
+	assert.Equal(t, "http://repo.example:8080/repos", specBaseURL(baseURL, ""))
+	assert.Equal(t, "http://ci:<password>@repo.example:8080/repos", specBaseURL(baseURL, "ci"))
+	assert.Equal(t, "http://ci%40example.com:<password>@repo.example:8080/repos",
+		specBaseURL(baseURL, "ci@example.com"))
+}
+
+func TestServePassword(t *testing.T) {
+	t.Setenv(repoPasswordEnv, "from-env")
+
+	password, err := servePassword("")
+	require.NoError(t, err)
+	assert.Equal(t, "from-env", password)
+
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("from-file\r\nignored\n"), 0o600))
+
+	password, err = servePassword(passwordFile)
+	require.NoError(t, err)
+	assert.Equal(t, "from-file", password)
+
+	_, err = servePassword(filepath.Join(t.TempDir(), "missing"))
+	require.Error(t, err)
+}
+
+func TestRepoPublishFlags(t *testing.T) {
+	require.NoError(t, repoPublishCmd.Flags().Set("arch", "arm64"))
+	require.NoError(t, repoPublishCmd.Flags().Set("sign", "true"))
+
+	defer func() {
+		repoPublishOpts.arch = ""
+		repoPublishOpts.sign = false
+	}()
+
+	assert.Empty(t, repoIndexOpts.arch, "publish --arch must not reach repo index")
+	assert.False(t, repoIndexOpts.sign, "publish --sign must not reach repo index")
+	assert.Equal(t, "stable", repoPublishOpts.suite)
+}
+
+func TestRepoAddRemoveFlags(t *testing.T) {
+	require.NoError(t, repoAddCmd.Flags().Set("sign", "true"))
+
+	defer func() {
+		repoAddOpts.sign = false
+	}()
+
+	assert.False(t, repoIndexOpts.sign, "add --sign must not reach repo index")
+	assert.False(t, repoRemoveOpts.sign, "add --sign must not reach repo remove")
+}
+
+func TestRepoIndexFlags(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "foo-1.0-1.x86_64.rpm"), nil, 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "foo-1.0-1-x86_64.pkg.tar.zst"), nil, 0o644))
+
+	require.NoError(t, repoIndexCmd.Flags().Set("sign-key", filepath.Join(dir, "key.gpg")))
+
+	defer func() {
+		repoIndexOpts.key = ""
+	}()
+
+	assert.Empty(t, repoAddOpts.key, "index --sign-key must not reach repo add")
+
+	_, err := indexRepository(context.Background(), dir, repoIndexOpts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), i18n.T("errors.repo.sign_key_mixed_formats"))
+}
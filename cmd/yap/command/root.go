@@ -137,6 +137,15 @@ func InitializeLocalizedDescriptions() {
 	// Update gensum command descriptions
 	InitializeGensumDescriptions()
 
+	// Update cache command descriptions
+	InitializeCacheDescriptions()
+
+	// Update repo command descriptions
+	InitializeRepoDescriptions()
+
+	// Update lint command descriptions
+	InitializeLintDescriptions()
+
 	// Update other command descriptions
 	updateOtherCommandDescriptions()
 }
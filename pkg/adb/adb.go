@@ -0,0 +1,369 @@
+// Package adb encodes the ADB database format of apk-tools 3, used by the
+// v3 .apk packages and APKINDEX files.
+//
+// An ADB file is an "ADB." magic and a schema identifier followed by
+// 8-byte aligned blocks: one ADB block holding the metadata tree, any
+// number of signature blocks over it and, for packages, one data block
+// per regular file. The whole stream may be compressed, in which case it
+// is prefixed by an "ADBd" (deflate) or "ADBc" (any algorithm) header.
+//
+// Reference: src/adb.h and src/apk_adb.h in
+// https://gitlab.alpinelinux.org/alpine/apk-tools
+package adb
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Schema identifiers stored after the file magic.
+const (
+	// SchemaPackage identifies a v3 .apk package ("pckg").
+	SchemaPackage uint32 = 0x676b6370
+	// SchemaIndex identifies a v3 APKINDEX ("indx").
+	SchemaIndex uint32 = 0x78646e69
+)
+
+// Block types.
+const (
+	BlockADB  uint32 = 0
+	BlockSig  uint32 = 1
+	BlockData uint32 = 2
+	blockExt  uint32 = 3
+)
+
+// Value type tags, stored in the four high bits of a Value.
+const (
+	typeInt    uint32 = 0x10000000
+	typeInt32  uint32 = 0x20000000
+	typeInt64  uint32 = 0x30000000
+	typeBlob8  uint32 = 0x80000000
+	typeBlob16 uint32 = 0x90000000
+	typeBlob32 uint32 = 0xa0000000
+	typeArray  uint32 = 0xd0000000
+	typeObject uint32 = 0xe0000000
+	typeMask   uint32 = 0xf0000000
+	valueMask  uint32 = 0x0fffffff
+)
+
+// Package object fields.
+const (
+	PkgInfo = iota + 1
+	PkgPaths
+	PkgScripts
+	PkgTriggers
+	PkgReplacesPriority
+	PkgMax
+)
+
+// Package info object fields.
+const (
+	InfoName = iota + 1
+	InfoVersion
+	InfoUniqueID
+	InfoDescription
+	InfoArch
+	InfoLicense
+	InfoOrigin
+	InfoMaintainer
+	InfoURL
+	InfoRepoCommit
+	InfoBuildTime
+	InfoInstalledSize
+	InfoFileSize
+	InfoProviderPriority
+	InfoDepends
+	InfoProvides
+	InfoReplaces
+	InfoInstallIf
+	InfoRecommends
+	InfoMax
+)
+
+// Directory object fields.
+const (
+	DirName = iota + 1
+	DirACL
+	DirFiles
+	DirMax
+)
+
+// File object fields.
+const (
+	FileName = iota + 1
+	FileACL
+	FileSize
+	FileMtime
+	FileHashes
+	FileTarget
+	FileMax
+)
+
+// ACL object fields.
+const (
+	ACLMode = iota + 1
+	ACLUser
+	ACLGroup
+	ACLXattrs
+	ACLMax
+)
+
+// Scripts object fields.
+const (
+	ScriptTrigger = iota + 1
+	ScriptPreInstall
+	ScriptPostInstall
+	ScriptPreDeinstall
+	ScriptPostDeinstall
+	ScriptPreUpgrade
+	ScriptPostUpgrade
+	ScriptMax
+)
+
+// Dependency object fields.
+const (
+	DepName = iota + 1
+	DepVersion
+	DepMatch
+	DepMax
+)
+
+// Index object fields.
+const (
+	IndexDescription = iota + 1
+	IndexPackages
+	IndexMax
+)
+
+// Version match flags of a dependency.
+const (
+	MatchEqual    = 1
+	MatchLess     = 2
+	MatchGreater  = 4
+	MatchFuzzy    = 8
+	MatchConflict = 16
+	// MatchAny is the mask of an unversioned dependency.
+	MatchAny = MatchEqual | MatchLess | MatchGreater
+)
+
+// headerSize is the size of the ADB block header: compatibility version,
+// version, two reserved bytes and the root value.
+const headerSize = 8
+
+// Value is an ADB value: a type tag and either an immediate integer or the
+// offset of the data within the ADB block.
+type Value uint32
+
+// Null is the value of an absent field.
+const Null Value = 0
+
+// DB builds the contents of an ADB block. Values are appended as they are
+// created; objects and arrays reference values created before them.
+type DB struct {
+	buf []byte
+}
+
+// NewDB returns an empty ADB block.
+func NewDB() *DB {
+	return &DB{buf: make([]byte, headerSize)}
+}
+
+// write appends the chunks aligned to alignment and returns their offset.
+func (db *DB) write(alignment int, chunks ...[]byte) uint32 {
+	for len(db.buf)%alignment != 0 {
+		db.buf = append(db.buf, 0)
+	}
+
+	offset := len(db.buf)
+	for _, chunk := range chunks {
+		db.buf = append(db.buf, chunk...)
+	}
+
+	return uint32(offset) //nolint:gosec // ADB blocks are far smaller than 4GB
+}
+
+// Blob stores raw bytes. Empty blobs are stored as Null.
+func (db *DB) Blob(data []byte) Value {
+	switch n := len(data); {
+	case n == 0:
+		return Null
+	case n <= math.MaxUint8:
+		return Value(typeBlob8 | db.write(1, []byte{byte(n)}, data))
+	case n <= math.MaxUint16:
+		return Value(typeBlob16 | db.write(2, binary.LittleEndian.AppendUint16(nil, uint16(n)), data))
+	default:
+		length := binary.LittleEndian.AppendUint32(nil, uint32(n)) //nolint:gosec // checked by the caller
+
+		return Value(typeBlob32 | db.write(4, length, data))
+	}
+}
+
+// String stores a string blob.
+func (db *DB) String(s string) Value {
+	return db.Blob([]byte(s))
+}
+
+// Int stores an integer, inline when it fits the value bits.
+func (db *DB) Int(v uint64) Value {
+	switch {
+	case v <= uint64(valueMask):
+		return Value(typeInt | uint32(v))
+	case v <= math.MaxUint32:
+		return Value(typeInt32 | db.write(4, binary.LittleEndian.AppendUint32(nil, uint32(v))))
+	default:
+		return Value(typeInt64 | db.write(8, binary.LittleEndian.AppendUint64(nil, v)))
+	}
+}
+
+// Object stores an object whose field i is fields[i]; fields[0] is
+// ignored and replaced by the field count, and trailing Null fields are
+// dropped.
+func (db *DB) Object(fields []Value) Value {
+	n := len(fields)
+	for n > 1 && fields[n-1] == Null {
+		n--
+	}
+
+	return Value(typeObject | db.values(n, fields[1:n]))
+}
+
+// Array stores an array of values.
+func (db *DB) Array(items []Value) Value {
+	if len(items) == 0 {
+		return Null
+	}
+
+	return Value(typeArray | db.values(len(items)+1, items))
+}
+
+// values writes the slot count followed by the values.
+func (db *DB) values(n int, values []Value) uint32 {
+	data := binary.LittleEndian.AppendUint32(nil, uint32(n)) //nolint:gosec // small count
+	for _, v := range values {
+		data = binary.LittleEndian.AppendUint32(data, uint32(v))
+	}
+
+	return db.write(4, data)
+}
+
+// BlobData returns the stored bytes of a blob, to patch them in place.
+func (db *DB) BlobData(v Value) []byte {
+	return ReadBlob(db.buf, v)
+}
+
+// SetRoot sets the root object of the block.
+func (db *DB) SetRoot(root Value) {
+	binary.LittleEndian.PutUint32(db.buf[4:], uint32(root))
+}
+
+// Bytes returns the contents of the ADB block.
+func (db *DB) Bytes() []byte {
+	return db.buf
+}
+
+// Root returns the root value of an ADB block.
+func Root(adb []byte) Value {
+	if len(adb) < headerSize {
+		return Null
+	}
+
+	return Value(binary.LittleEndian.Uint32(adb[4:]))
+}
+
+// ReadObject returns the fields of an object indexed like the values passed
+// to DB.Object, padded with Null to size slots, e.g. InfoMax: fields the
+// object lacks or does not know are Null.
+func ReadObject(adb []byte, v Value, size int) []Value {
+	fields := make([]Value, size)
+
+	if uint32(v)&typeMask == typeObject {
+		copy(fields[1:], readValues(adb, uint32(v)&valueMask)[1:])
+	}
+
+	return fields
+}
+
+// ReadArray returns the items of an array.
+func ReadArray(adb []byte, v Value) []Value {
+	if uint32(v)&typeMask != typeArray {
+		return nil
+	}
+
+	return readValues(adb, uint32(v)&valueMask)[1:]
+}
+
+// readValues reads the slots at offset, with the count slot cleared. It
+// returns at least the count slot.
+func readValues(adb []byte, offset uint32) []Value {
+	if uint64(offset)+4 > uint64(len(adb)) {
+		return make([]Value, 1)
+	}
+
+	n := binary.LittleEndian.Uint32(adb[offset:])
+	if n == 0 || uint64(offset)+4*uint64(n) > uint64(len(adb)) {
+		return make([]Value, 1)
+	}
+
+	values := make([]Value, n)
+	for i := uint32(1); i < n; i++ {
+		values[i] = Value(binary.LittleEndian.Uint32(adb[offset+4*i:]))
+	}
+
+	return values
+}
+
+// ReadBlob returns the bytes of a blob, nil for Null or malformed values.
+func ReadBlob(adb []byte, v Value) []byte {
+	offset := uint64(uint32(v) & valueMask)
+
+	var prefix, length uint64
+
+	switch uint32(v) & typeMask {
+	case typeBlob8:
+		if offset+1 > uint64(len(adb)) {
+			return nil
+		}
+
+		prefix, length = 1, uint64(adb[offset])
+	case typeBlob16:
+		if offset+2 > uint64(len(adb)) {
+			return nil
+		}
+
+		prefix, length = 2, uint64(binary.LittleEndian.Uint16(adb[offset:]))
+	case typeBlob32:
+		if offset+4 > uint64(len(adb)) {
+			return nil
+		}
+
+		prefix, length = 4, uint64(binary.LittleEndian.Uint32(adb[offset:]))
+	default:
+		return nil
+	}
+
+	if offset+prefix+length > uint64(len(adb)) {
+		return nil
+	}
+
+	return adb[offset+prefix : offset+prefix+length]
+}
+
+// ReadInt returns the value of an integer, 0 for Null or malformed values.
+func ReadInt(adb []byte, v Value) uint64 {
+	offset := uint64(uint32(v) & valueMask)
+
+	switch uint32(v) & typeMask {
+	case typeInt:
+		return offset
+	case typeInt32:
+		if offset+4 <= uint64(len(adb)) {
+			return uint64(binary.LittleEndian.Uint32(adb[offset:]))
+		}
+	case typeInt64:
+		if offset+8 <= uint64(len(adb)) {
+			return binary.LittleEndian.Uint64(adb[offset:])
+		}
+	}
+
+	return 0
+}
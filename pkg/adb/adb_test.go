@@ -0,0 +1,205 @@
+package adb_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/M0Rf30/yap/v2/pkg/adb"
+)
+
+func TestDBValues(t *testing.T) {
+	db := adb.NewDB()
+
+	short := db.String("name")
+	long := db.String(strings.Repeat("x", 300))
+	huge := db.Blob(bytes.Repeat([]byte{1}, math.MaxUint16+1))
+	small := db.Int(42)
+	int32Value := db.Int(1_700_000_000)
+	int64Value := db.Int(math.MaxUint32 + 1)
+	items := db.Array([]adb.Value{short, long})
+	object := db.Object([]adb.Value{adb.Null, short, adb.Null, small, adb.Null, adb.Null})
+	db.SetRoot(object)
+
+	data := db.Bytes()
+
+	if got := string(adb.ReadBlob(data, short)); got != "name" {
+		t.Errorf("short blob = %q", got)
+	}
+
+	if got := adb.ReadBlob(data, long); len(got) != 300 {
+		t.Errorf("long blob has %d bytes, want 300", len(got))
+	}
+
+	if got := adb.ReadBlob(data, huge); len(got) != math.MaxUint16+1 {
+		t.Errorf("huge blob has %d bytes, want %d", len(got), math.MaxUint16+1)
+	}
+
+	if db.String("") != adb.Null {
+		t.Error("empty blob is not Null")
+	}
+
+	for value, want := range map[adb.Value]uint64{
+		small: 42, int32Value: 1_700_000_000, int64Value: math.MaxUint32 + 1,
+	} {
+		if got := adb.ReadInt(data, value); got != want {
+			t.Errorf("ReadInt() = %d, want %d", got, want)
+		}
+	}
+
+	if got := adb.ReadArray(data, items); len(got) != 2 || got[1] != long {
+		t.Errorf("ReadArray() = %v", got)
+	}
+
+	if adb.Root(data) != object {
+		t.Errorf("Root() = %x, want %x", adb.Root(data), object)
+	}
+
+	// Trailing Null fields are dropped, and padded back by ReadObject.
+	fields := adb.ReadObject(data, object, 6)
+	if want := []adb.Value{adb.Null, short, adb.Null, small, adb.Null, adb.Null}; !slices.Equal(fields, want) {
+		t.Errorf("ReadObject() = %v, want %v", fields, want)
+	}
+}
+
+func TestWriterParse(t *testing.T) {
+	for _, compression := range []string{"", adb.CompressionDeflate, adb.CompressionZstd} {
+		var buf bytes.Buffer
+
+		writer, err := adb.NewWriter(&buf, adb.SchemaPackage, compression)
+		if err != nil {
+			t.Fatalf("NewWriter(%q) error = %v", compression, err)
+		}
+
+		db := adb.NewDB()
+		db.SetRoot(db.Object([]adb.Value{adb.Null, db.String("pkg")}))
+
+		if err := writer.WriteBlock(adb.BlockADB, db.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := writer.WriteBlockFrom(adb.BlockData, []byte{1, 0, 0, 0, 1, 0, 0, 0},
+			strings.NewReader("hello"), 5); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := writer.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		file, err := adb.Parse(buf.Bytes())
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", compression, err)
+		}
+
+		wantCompression := compression
+		if wantCompression == "" {
+			wantCompression = adb.CompressionNone
+		}
+
+		if file.Schema != adb.SchemaPackage || file.Compression != wantCompression {
+			t.Errorf("Parse(%q) schema = %x, compression = %q", compression, file.Schema, file.Compression)
+		}
+
+		if len(file.Blocks) != 2 || !bytes.Equal(file.ADB(), db.Bytes()) ||
+			string(file.Blocks[1].Data[8:]) != "hello" {
+			t.Errorf("Parse(%q) blocks = %+v", compression, file.Blocks)
+		}
+	}
+
+	if _, err := adb.NewWriter(&bytes.Buffer{}, adb.SchemaPackage, "xz"); err == nil {
+		t.Error("NewWriter() accepted xz")
+	}
+
+	if _, err := adb.Parse([]byte("not an adb file")); err == nil {
+		t.Error("Parse() accepted a file without the ADB magic")
+	}
+}
+
+func TestBlockAlignment(t *testing.T) {
+	var buf bytes.Buffer
+
+	writer, err := adb.NewWriter(&buf, adb.SchemaIndex, adb.CompressionNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A 9-byte ADB block is framed by a 4-byte header and padded to 16.
+	if err := writer.WriteBlock(adb.BlockADB, make([]byte, 9)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []byte{'A', 'D', 'B', '.', 'i', 'n', 'd', 'x', 13, 0, 0, 0}
+	if got := buf.Bytes(); len(got) != 8+16 || !bytes.Equal(got[:12], want) {
+		t.Errorf("block bytes = %v", got)
+	}
+}
+
+func TestSign(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+
+	writer, err := adb.NewWriter(&buf, adb.SchemaPackage, adb.CompressionDeflate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := adb.NewDB()
+	db.SetRoot(db.Object([]adb.Value{adb.Null, db.String("pkg")}))
+
+	if err := writer.WriteBlock(adb.BlockADB, db.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writer.WriteBlock(adb.BlockData, []byte("data")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	signed := buf.Bytes()
+	for _, signer := range []*rsa.PrivateKey{key, other, key} {
+		if signed, err = adb.Sign(signed, signer); err != nil {
+			t.Fatalf("Sign() error = %v", err)
+		}
+	}
+
+	file, err := adb.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Signing again with key replaces its signature instead of adding one.
+	types := make([]uint32, 0, len(file.Blocks))
+	for _, block := range file.Blocks {
+		types = append(types, block.Type)
+	}
+
+	if want := []uint32{adb.BlockADB, adb.BlockSig, adb.BlockSig, adb.BlockData}; !slices.Equal(types, want) {
+		t.Fatalf("block types = %v, want %v", types, want)
+	}
+
+	if !adb.VerifySignature(file.Schema, file.ADB(), file.Blocks[1].Data, &other.PublicKey) ||
+		!adb.VerifySignature(file.Schema, file.ADB(), file.Blocks[2].Data, &key.PublicKey) {
+		t.Error("signature blocks do not verify")
+	}
+
+	if adb.VerifySignature(adb.SchemaIndex, file.ADB(), file.Blocks[2].Data, &key.PublicKey) {
+		t.Error("signature verifies for another schema")
+	}
+}
@@ -0,0 +1,269 @@
+package adb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/klauspost/compress/flate"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+)
+
+// Compression algorithms of an ADB file.
+const (
+	CompressionNone    = "none"
+	CompressionDeflate = "deflate"
+	CompressionZstd    = "zstd"
+)
+
+// Compression algorithm identifiers of the "ADBc" header.
+const (
+	algDeflate = 1
+	algZstd    = 2
+	// zstdLevel is the level recorded for zstd streams, the zstd default.
+	zstdLevel = 3
+)
+
+// fileMagic starts every uncompressed ADB stream.
+var fileMagic = []byte("ADB.")
+
+// blockAlignment is the alignment of every block in the file.
+const blockAlignment = 8
+
+// maxBlockLength is the largest payload of a block with a 4-byte header;
+// larger blocks use the 16-byte extended header.
+const maxBlockLength = 0x3fffffff - 4
+
+// Writer writes the blocks of an ADB file.
+type Writer struct {
+	w          io.Writer
+	compressor io.WriteCloser
+}
+
+// NewWriter writes the compression and file headers of a schema to w.
+// compression is one of the Compression constants; empty means none.
+func NewWriter(w io.Writer, schema uint32, compression string) (*Writer, error) {
+	writer := &Writer{w: w}
+
+	var err error
+
+	switch compression {
+	case "", CompressionNone:
+	case CompressionDeflate:
+		if _, err = w.Write([]byte("ADBd")); err == nil {
+			writer.compressor, err = flate.NewWriter(w, flate.DefaultCompression)
+		}
+	case CompressionZstd:
+		if _, err = w.Write([]byte{'A', 'D', 'B', 'c', algZstd, zstdLevel}); err == nil {
+			writer.compressor, err = zstd.NewWriter(w)
+		}
+	default:
+		return nil, errors.New(errors.ErrTypeValidation,
+			i18n.T("errors.adb.unsupported_compression")).
+			WithOperation("NewWriter").
+			WithContext("compression", compression)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if writer.compressor != nil {
+		writer.w = writer.compressor
+	}
+
+	header := binary.LittleEndian.AppendUint32(append([]byte{}, fileMagic...), schema)
+	if _, err := writer.w.Write(header); err != nil {
+		return nil, err
+	}
+
+	return writer, nil
+}
+
+// WriteBlock writes a block holding data.
+func (w *Writer) WriteBlock(blockType uint32, data []byte) error {
+	return w.WriteBlockFrom(blockType, data, bytes.NewReader(nil), 0)
+}
+
+// WriteBlockFrom writes a block holding header followed by size bytes read
+// from r, as the data blocks carrying the file contents.
+func (w *Writer) WriteBlockFrom(blockType uint32, header []byte, r io.Reader, size int64) error {
+	length := uint64(len(header)) + uint64(size) //nolint:gosec // sizes are non-negative
+
+	var blockHeader []byte
+
+	if length <= maxBlockLength {
+		blockHeader = binary.LittleEndian.AppendUint32(nil, blockType<<30|uint32(4+length))
+	} else {
+		blockHeader = binary.LittleEndian.AppendUint32(nil, blockExt<<30|blockType)
+		blockHeader = binary.LittleEndian.AppendUint32(blockHeader, 0)
+		blockHeader = binary.LittleEndian.AppendUint64(blockHeader, 16+length)
+	}
+
+	if _, err := w.w.Write(blockHeader); err != nil {
+		return err
+	}
+
+	if _, err := w.w.Write(header); err != nil {
+		return err
+	}
+
+	if _, err := io.CopyN(w.w, r, size); err != nil {
+		return err
+	}
+
+	padding := (blockAlignment - (uint64(len(blockHeader))+length)%blockAlignment) % blockAlignment
+	_, err := w.w.Write(make([]byte, padding))
+
+	return err
+}
+
+// Close flushes the compressed stream. It does not close the underlying
+// writer.
+func (w *Writer) Close() error {
+	if w.compressor == nil {
+		return nil
+	}
+
+	return w.compressor.Close()
+}
+
+// Block is a block of a parsed ADB file.
+type Block struct {
+	Type uint32
+	Data []byte
+}
+
+// File is a parsed ADB file.
+type File struct {
+	Schema      uint32
+	Compression string
+	Blocks      []Block
+}
+
+// Parse decompresses and splits an ADB file into its blocks.
+func Parse(data []byte) (*File, error) {
+	file := &File{Compression: CompressionNone}
+
+	raw, err := decompress(data, file)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(raw) < 8 || !bytes.Equal(raw[:4], fileMagic) {
+		return nil, invalidFile("missing ADB magic")
+	}
+
+	file.Schema = binary.LittleEndian.Uint32(raw[4:])
+
+	for offset := uint64(8); offset < uint64(len(raw)); {
+		if uint64(len(raw))-offset < 4 {
+			return nil, invalidFile("truncated block header")
+		}
+
+		typeSize := binary.LittleEndian.Uint32(raw[offset:])
+		blockType, headerSize, rawSize := typeSize>>30, uint64(4), uint64(typeSize&0x3fffffff)
+
+		if blockType == blockExt {
+			if uint64(len(raw))-offset < 16 {
+				return nil, invalidFile("truncated block header")
+			}
+
+			blockType, headerSize = typeSize&0x3fffffff, 16
+			rawSize = binary.LittleEndian.Uint64(raw[offset+8:])
+		}
+
+		if rawSize < headerSize || rawSize > uint64(len(raw))-offset {
+			return nil, invalidFile("truncated block")
+		}
+
+		file.Blocks = append(file.Blocks, Block{
+			Type: blockType,
+			Data: raw[offset+headerSize : offset+rawSize],
+		})
+
+		offset += (rawSize + blockAlignment - 1) / blockAlignment * blockAlignment
+	}
+
+	if len(file.Blocks) == 0 || file.Blocks[0].Type != BlockADB {
+		return nil, invalidFile("missing ADB block")
+	}
+
+	return file, nil
+}
+
+// ADB returns the contents of the ADB block.
+func (f *File) ADB() []byte {
+	return f.Blocks[0].Data
+}
+
+// Write writes the file back with its original compression.
+func (f *File) Write(w io.Writer) error {
+	writer, err := NewWriter(w, f.Schema, f.Compression)
+	if err != nil {
+		return err
+	}
+
+	for _, block := range f.Blocks {
+		if err := writer.WriteBlock(block.Type, block.Data); err != nil {
+			return err
+		}
+	}
+
+	return writer.Close()
+}
+
+// decompress strips the compression header of data, recording the
+// algorithm in file.
+func decompress(data []byte, file *File) ([]byte, error) {
+	if len(data) < 4 || !bytes.Equal(data[:3], fileMagic[:3]) {
+		return nil, invalidFile("missing ADB magic")
+	}
+
+	alg := 0
+
+	switch data[3] {
+	case '.':
+		return data, nil
+	case 'd':
+		alg, data = algDeflate, data[4:]
+	case 'c':
+		if len(data) < 6 {
+			return nil, invalidFile("truncated compression header")
+		}
+
+		alg, data = int(data[4]), data[6:]
+	default:
+		return nil, invalidFile("unknown compression header")
+	}
+
+	switch alg {
+	case algDeflate:
+		file.Compression = CompressionDeflate
+
+		return io.ReadAll(flate.NewReader(bytes.NewReader(data)))
+	case algZstd:
+		file.Compression = CompressionZstd
+
+		decoder, err := zstd.NewReader(nil)
+		if err != nil {
+			return nil, err
+		}
+
+		defer decoder.Close()
+
+		return decoder.DecodeAll(data, nil)
+	default:
+		return nil, invalidFile("unknown compression algorithm")
+	}
+}
+
+// invalidFile returns the error of a malformed ADB file.
+func invalidFile(reason string) error {
+	return errors.New(errors.ErrTypePackaging, i18n.T("errors.adb.invalid_file")).
+		WithOperation("Parse").
+		WithContext("reason", reason)
+}
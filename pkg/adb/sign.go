@@ -0,0 +1,99 @@
+package adb
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/binary"
+)
+
+// digestSHA512 is the apk-tools identifier of SHA-512 in signature blocks.
+const digestSHA512 = 4
+
+// signatureHeaderSize is the size of a v0 signature before the signature
+// bytes: version, digest algorithm and key identifier.
+const signatureHeaderSize = 2 + 16
+
+// KeyID returns the identifier of an RSA public key in signature blocks:
+// the first 16 bytes of the SHA-512 of its PKCS#1 DER encoding.
+func KeyID(key *rsa.PublicKey) []byte {
+	sum := sha512.Sum512(x509.MarshalPKCS1PublicKey(key))
+
+	return sum[:16]
+}
+
+// SignatureBlock returns the contents of a signature block over the ADB
+// block of a file of schema, signed with PKCS#1 v1.5 SHA-512 by key.
+func SignatureBlock(schema uint32, adb []byte, key *rsa.PrivateKey) ([]byte, error) {
+	block := append([]byte{0, digestSHA512}, KeyID(&key.PublicKey)...)
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA512, signedDigest(schema, block, adb))
+	if err != nil {
+		return nil, err
+	}
+
+	return append(block, signature...), nil
+}
+
+// signedDigest returns the digest a signature block signs: the schema, the
+// signature header and the SHA-512 of the ADB block.
+func signedDigest(schema uint32, header, adb []byte) []byte {
+	adbDigest := sha512.Sum512(adb)
+
+	hash := sha512.New()
+	_ = binary.Write(hash, binary.LittleEndian, schema)
+	hash.Write(header[:signatureHeaderSize])
+	hash.Write(adbDigest[:])
+
+	return hash.Sum(nil)
+}
+
+// Sign returns the ADB file data with a signature block by key added after
+// its existing signatures, replacing an earlier signature by the same key.
+func Sign(data []byte, key *rsa.PrivateKey) ([]byte, error) {
+	file, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := SignatureBlock(file.Schema, file.ADB(), key)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks := []Block{file.Blocks[0]}
+	rest := file.Blocks[1:]
+
+	for len(rest) > 0 && rest[0].Type == BlockSig {
+		if len(rest[0].Data) < signatureHeaderSize ||
+			!bytes.Equal(rest[0].Data[2:signatureHeaderSize], signature[2:signatureHeaderSize]) {
+			blocks = append(blocks, rest[0])
+		}
+
+		rest = rest[1:]
+	}
+
+	file.Blocks = append(append(blocks, Block{Type: BlockSig, Data: signature}), rest...)
+
+	var buf bytes.Buffer
+	if err := file.Write(&buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// VerifySignature reports whether a signature block signs the ADB block of
+// a file of schema with key.
+func VerifySignature(schema uint32, adb, block []byte, key *rsa.PublicKey) bool {
+	if len(block) <= signatureHeaderSize || block[1] != digestSHA512 ||
+		!bytes.Equal(block[2:signatureHeaderSize], KeyID(key)) {
+		return false
+	}
+
+	return rsa.VerifyPKCS1v15(key, crypto.SHA512, signedDigest(schema, block, adb),
+		block[signatureHeaderSize:]) == nil
+}
@@ -0,0 +1,287 @@
+// Package alternatives handles the alternatives=() PKGBUILD entries, which
+// let packages providing the same command (/usr/bin/editor, java, cc)
+// coexist. deb and rpm packages register them with update-alternatives
+// from their scriptlets; apk and pacman packages ship the link and declare
+// the alternative name as a provided virtual package instead.
+//
+// When the in-process installers put a package into a root that has no
+// update-alternatives, Apply sets up the links the scriptlets could not,
+// so the highest-priority alternative is still in place for the build.
+package alternatives
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/safepath"
+)
+
+const (
+	// linksDir holds the /etc/alternatives/<name> links the generic links
+	// point through, as update-alternatives lays them out.
+	linksDir = "/etc/alternatives"
+	// stateDir records the alternatives Apply registered, one file per
+	// name with a "link path priority" line per alternative.
+	stateDir = "/var/lib/yap/alternatives"
+)
+
+// commands are the locations of update-alternatives: dpkg's, and the
+// alternatives tool of Fedora and openSUSE.
+var commands = []string{
+	"/usr/bin/update-alternatives",
+	"/usr/sbin/update-alternatives",
+	"/usr/sbin/alternatives",
+	"/sbin/update-alternatives",
+}
+
+// Alternative is an alternatives=() entry of the form
+// "link:name:path:priority", e.g. "/usr/bin/editor:editor:/usr/bin/foo:50".
+type Alternative struct {
+	Link     string
+	Name     string
+	Path     string
+	Priority int
+}
+
+// Parse parses an alternatives=() entry. The link and path must be
+// absolute and the name a single path component.
+func Parse(entry string) (Alternative, error) {
+	fields := strings.Split(entry, ":")
+	if len(fields) != 4 {
+		return Alternative{}, invalidEntry(entry)
+	}
+
+	priority, err := strconv.Atoi(fields[3])
+	if err != nil {
+		return Alternative{}, invalidEntry(entry)
+	}
+
+	alt := Alternative{Link: fields[0], Name: fields[1], Path: fields[2], Priority: priority}
+
+	if !filepath.IsAbs(alt.Link) || !filepath.IsAbs(alt.Path) || alt.Link == alt.Path ||
+		alt.Name == "" || strings.ContainsAny(alt.Name, "/ \t") {
+		return Alternative{}, invalidEntry(entry)
+	}
+
+	return alt, nil
+}
+
+// ParseAll parses the alternatives=() entries of a PKGBUILD.
+func ParseAll(entries []string) ([]Alternative, error) {
+	alts := make([]Alternative, 0, len(entries))
+
+	for _, entry := range entries {
+		alt, err := Parse(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		alts = append(alts, alt)
+	}
+
+	return alts, nil
+}
+
+func invalidEntry(entry string) error {
+	return errors.New(errors.ErrTypeValidation, i18n.T("errors.alternatives.invalid_entry")).
+		WithOperation("Parse").
+		WithContext("entry", entry)
+}
+
+// InstallCommand returns the update-alternatives call registering the
+// alternative.
+func (alt Alternative) InstallCommand() string {
+	return fmt.Sprintf("update-alternatives --install %s %s %s %d",
+		alt.Link, alt.Name, alt.Path, alt.Priority)
+}
+
+// RemoveCommand returns the update-alternatives call unregistering the
+// alternative.
+func (alt Alternative) RemoveCommand() string {
+	return fmt.Sprintf("update-alternatives --remove %s %s", alt.Name, alt.Path)
+}
+
+// FromScript returns the alternatives a scriptlet registers with
+// "update-alternatives --install" or "alternatives --install".
+func FromScript(script string) []Alternative {
+	var alts []Alternative
+
+	for line := range strings.SplitSeq(script, "\n") {
+		fields := strings.Fields(line)
+
+		for i, field := range fields {
+			if filepath.Base(field) != "update-alternatives" && filepath.Base(field) != "alternatives" {
+				continue
+			}
+
+			if len(fields) < i+6 || fields[i+1] != "--install" {
+				break
+			}
+
+			if alt, err := Parse(strings.Join(fields[i+2:i+6], ":")); err == nil {
+				alts = append(alts, alt)
+			}
+
+			break
+		}
+	}
+
+	return alts
+}
+
+// Available reports whether update-alternatives is installed in rootDir.
+func Available(rootDir string) bool {
+	for _, command := range commands {
+		if _, err := os.Stat(filepath.Join(rootOrSlash(rootDir), command)); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Apply registers the alternatives in rootDir the way update-alternatives
+// does in automatic mode: each generic link points through
+// /etc/alternatives/<name> to the highest-priority alternative of that
+// name. Alternatives registered by earlier calls are kept.
+func Apply(rootDir string, alts []Alternative) error {
+	rootDir = rootOrSlash(rootDir)
+
+	for _, alt := range alts {
+		if err := apply(rootDir, alt); err != nil {
+			return errors.Wrap(err, errors.ErrTypeFileSystem,
+				i18n.T("errors.alternatives.failed_to_apply")).
+				WithOperation("Apply").
+				WithContext("name", alt.Name).
+				WithContext("path", alt.Path)
+		}
+	}
+
+	return nil
+}
+
+func apply(rootDir string, alt Alternative) error {
+	statePath, err := safepath.Join(rootDir, filepath.Join(stateDir, alt.Name))
+	if err != nil {
+		return err
+	}
+
+	registered, err := readState(statePath)
+	if err != nil {
+		return err
+	}
+
+	registered = slices.DeleteFunc(registered, func(r Alternative) bool { return r.Path == alt.Path })
+	registered = append(registered, alt)
+
+	if err := writeState(statePath, registered); err != nil {
+		return err
+	}
+
+	best := registered[0]
+	for _, r := range registered[1:] {
+		if r.Priority > best.Priority {
+			best = r
+		}
+	}
+
+	nameLink := filepath.Join(linksDir, alt.Name)
+
+	if err := replaceSymlink(rootDir, nameLink, best.Path); err != nil {
+		return err
+	}
+
+	return replaceSymlink(rootDir, best.Link, nameLink)
+}
+
+// readState reads the alternatives registered under a name.
+func readState(statePath string) ([]Alternative, error) {
+	file, err := os.Open(statePath) //nolint:gosec // path under the install root
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var registered []Alternative
+
+	name := filepath.Base(statePath)
+	scanner := bufio.NewScanner(file)
+
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			continue
+		}
+
+		priority, err := strconv.Atoi(fields[2])
+		if err != nil {
+			continue
+		}
+
+		registered = append(registered, Alternative{
+			Link: fields[0], Name: name, Path: fields[1], Priority: priority,
+		})
+	}
+
+	return registered, scanner.Err()
+}
+
+func writeState(statePath string, registered []Alternative) error {
+	if err := os.MkdirAll(filepath.Dir(statePath), 0o755); err != nil { //nolint:gosec // system state dir
+		return err
+	}
+
+	var data strings.Builder
+	for _, r := range registered {
+		fmt.Fprintf(&data, "%s %s %d\n", r.Link, r.Path, r.Priority)
+	}
+
+	return os.WriteFile(statePath, []byte(data.String()), 0o644) //nolint:gosec // world-readable state
+}
+
+// replaceSymlink points the link at path under rootDir to target, creating
+// its parent directories. A real file at the link is left alone.
+func replaceSymlink(rootDir, link, target string) error {
+	linkPath, err := safepath.Join(rootDir, link)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(linkPath), 0o755); err != nil { //nolint:gosec // system directories
+		return err
+	}
+
+	info, err := os.Lstat(linkPath)
+	if err == nil && info.Mode()&os.ModeSymlink == 0 {
+		// Like update-alternatives, never replace a real file.
+		return nil
+	}
+
+	if err := os.Remove(linkPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return os.Symlink(target, linkPath)
+}
+
+func rootOrSlash(rootDir string) string {
+	if rootDir == "" {
+		return "/"
+	}
+
+	return rootDir
+}
@@ -0,0 +1,97 @@
+package alternatives_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/M0Rf30/yap/v2/pkg/alternatives"
+)
+
+func TestParse(t *testing.T) {
+	alt, err := alternatives.Parse("/usr/bin/editor:editor:/usr/bin/foo:50")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := alternatives.Alternative{Link: "/usr/bin/editor", Name: "editor", Path: "/usr/bin/foo", Priority: 50}
+	if alt != want {
+		t.Errorf("Parse() = %+v, want %+v", alt, want)
+	}
+
+	for _, entry := range []string{
+		"/usr/bin/editor:editor:/usr/bin/foo",
+		"/usr/bin/editor:editor:/usr/bin/foo:high",
+		"usr/bin/editor:editor:/usr/bin/foo:50",
+		"/usr/bin/editor:bin/editor:/usr/bin/foo:50",
+		"/usr/bin/foo:editor:/usr/bin/foo:50",
+		"/usr/bin/editor::/usr/bin/foo:50",
+	} {
+		if _, err := alternatives.Parse(entry); err == nil {
+			t.Errorf("Parse(%q) should fail", entry)
+		}
+	}
+}
+
+func TestFromScript(t *testing.T) {
+	alt := alternatives.Alternative{Link: "/usr/bin/editor", Name: "editor", Path: "/usr/bin/foo", Priority: 50}
+
+	script := "#!/bin/bash\n" +
+		"if [ \"$1\" = \"configure\" ]; then\n\t" + alt.InstallCommand() + "\nfi\n" +
+		"/usr/sbin/alternatives --install /usr/bin/cc cc /usr/bin/foo-cc 10 >/dev/null 2>&1 || :\n" +
+		alt.RemoveCommand() + "\n"
+
+	want := []alternatives.Alternative{
+		alt,
+		{Link: "/usr/bin/cc", Name: "cc", Path: "/usr/bin/foo-cc", Priority: 10},
+	}
+	if got := alternatives.FromScript(script); !reflect.DeepEqual(got, want) {
+		t.Errorf("FromScript() = %+v, want %+v", got, want)
+	}
+}
+
+func TestApply(t *testing.T) {
+	root := t.TempDir()
+
+	if alternatives.Available(root) {
+		t.Fatal("Available() in an empty root")
+	}
+
+	apply := func(path string, priority int) {
+		t.Helper()
+
+		alt := alternatives.Alternative{Link: "/usr/bin/editor", Name: "editor", Path: path, Priority: priority}
+		if err := alternatives.Apply(root, []alternatives.Alternative{alt}); err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+	}
+
+	readLink := func(name string) string {
+		t.Helper()
+
+		target, err := os.Readlink(filepath.Join(root, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return target
+	}
+
+	apply("/usr/bin/vim", 50)
+	apply("/usr/bin/nano", 40)
+
+	if got := readLink("usr/bin/editor"); got != "/etc/alternatives/editor" {
+		t.Errorf("/usr/bin/editor -> %q, want /etc/alternatives/editor", got)
+	}
+
+	if got := readLink("etc/alternatives/editor"); got != "/usr/bin/vim" {
+		t.Errorf("/etc/alternatives/editor -> %q, want the highest priority /usr/bin/vim", got)
+	}
+
+	apply("/usr/bin/nano", 60)
+
+	if got := readLink("etc/alternatives/editor"); got != "/usr/bin/nano" {
+		t.Errorf("/etc/alternatives/editor -> %q, want /usr/bin/nano after raising its priority", got)
+	}
+}
@@ -10,6 +10,9 @@
 //	if err := idx.Install(ctx, []string{"gcc", "musl-dev"}); err != nil {
 //		return err
 //	}
+//
+// WriteIndex goes the other way: it writes the APKINDEX.tar.gz of a
+// directory of .apk packages, as apk index does, for publishing a repository.
 package apkindex
 
 import (
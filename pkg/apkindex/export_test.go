@@ -8,6 +8,8 @@ import (
 	"path/filepath"
 
 	"github.com/cavaliergopher/grab/v3"
+
+	"github.com/M0Rf30/yap/v2/pkg/triggers"
 )
 
 // This file exports internal functions and variables for testing purposes.
@@ -43,7 +45,7 @@ func ExportExtractAPKEntry(tr *tar.Reader, hdr *tar.Header) error {
 
 // ExportExtractAPKData exposes extractAPKData for testing.
 func ExportExtractAPKData(r io.Reader) error {
-	return extractAPKData(r)
+	return extractAPKData(r, &triggers.Transaction{})
 }
 
 // ExportSha1Hex exposes sha1Hex for testing.
@@ -75,7 +77,7 @@ func ExportWriteInstalledStanzas(dbPath string, stanzas map[string]string) error
 
 // ExportTryReadPkgInfoFromNextStream exposes tryReadPkgInfoFromNextStream for testing.
 func ExportTryReadPkgInfoFromNextStream(br *bufio.Reader) string {
-	pkgInfo, _ := tryReadPkgInfoFromNextStream(br)
+	pkgInfo, _, _ := tryReadPkgInfoFromNextStream(br)
 	return pkgInfo
 }
 
@@ -10,12 +10,14 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/klauspost/compress/flate"
 	"github.com/klauspost/compress/gzip"
 
 	"github.com/M0Rf30/yap/v2/pkg/errors"
 	"github.com/M0Rf30/yap/v2/pkg/i18n"
 	"github.com/M0Rf30/yap/v2/pkg/logger"
 	"github.com/M0Rf30/yap/v2/pkg/safepath"
+	"github.com/M0Rf30/yap/v2/pkg/triggers"
 )
 
 const apkInstalledDB = "/lib/apk/db/installed"
@@ -39,7 +41,8 @@ func (idx *Index) InstallPackages(ctx context.Context, names []string) error {
 }
 
 // InstallPackagesWithOptions is the explicit-options variant of InstallPackages.
-// Scriptlets are not currently executed; they are logged as warnings.
+// Scriptlets are not currently executed; they are logged as warnings. The
+// triggers of the installed packages run once, after all are extracted.
 func (idx *Index) InstallPackagesWithOptions(
 	ctx context.Context, names []string, opts InstallOptions,
 ) error {
@@ -109,10 +112,12 @@ func (idx *Index) InstallPackagesWithOptions(
 	}
 
 	// 4. Extract each .apk to / and register in installed DB.
+	var tx transaction
+
 	for _, p := range toInstall {
 		apkPath := filepath.Join(tmpDir, p.Name+"-"+p.Version+".apk")
 
-		if err := extractAndRegister(apkPath, p); err != nil {
+		if err := extractAndRegister(apkPath, p, &tx); err != nil {
 			return errors.Wrap(err, errors.ErrTypePackaging, "failed to install package").
 				WithOperation("InstallPackagesWithOptions").
 				WithContext("package", p.Name)
@@ -121,6 +126,9 @@ func (idx *Index) InstallPackagesWithOptions(
 		logger.Debug(i18n.T("logger.apkindex.debug.installed"), "package", p.Name, "version", p.Version)
 	}
 
+	// 5. Run the triggers watching the directories the packages filled.
+	tx.fire(ctx)
+
 	return nil
 }
 
@@ -168,7 +176,8 @@ func readInstalledDB() map[string]bool {
 
 // extractAndRegister extracts a .apk file to / and registers it in the installed database.
 // Orchestrates extraction of control and data streams, then registers the package.
-func extractAndRegister(apkPath string, pkg *Package) error {
+// Its files and trigger are recorded in tx.
+func extractAndRegister(apkPath string, pkg *Package, tx *transaction) error {
 	// Open the .apk file (2-or-3-stream concatenated gzip: [signature] + control + data).
 	f, err := os.Open(apkPath) //nolint:gosec
 	if err != nil {
@@ -183,24 +192,26 @@ func extractAndRegister(apkPath string, pkg *Package) error {
 	br := bufio.NewReader(f)
 
 	// Try to read .PKGINFO from the first stream (control).
-	pkgInfo, err := tryReadPkgInfoFromNextStream(br)
+	pkgInfo, trigger, err := tryReadPkgInfoFromNextStream(br)
 	if err != nil {
 		return err
 	}
 
 	// If first stream was signature (no .PKGINFO), try the next one (control).
 	if pkgInfo == "" {
-		pkgInfo, err = tryReadPkgInfoFromNextStream(br)
+		pkgInfo, trigger, err = tryReadPkgInfoFromNextStream(br)
 		if err != nil {
 			return err
 		}
 	}
 
 	// Now br is positioned at the data.tar.gz stream.
-	if err := extractAPKData(br); err != nil {
+	if err := extractAPKData(br, &tx.Transaction); err != nil {
 		return err
 	}
 
+	tx.addTrigger(pkg.Name, pkgInfo, trigger)
+
 	// Register in /lib/apk/db/installed.
 	if err := registerInstalled(pkg, pkgInfo); err != nil {
 		return errors.Wrap(err, errors.ErrTypeFileSystem, "failed to register installed package").
@@ -212,13 +223,14 @@ func extractAndRegister(apkPath string, pkg *Package) error {
 }
 
 // tryReadPkgInfoFromNextStream reads the next gzip stream from br, looking for
-// .PKGINFO in the tar archive. Returns empty string if .PKGINFO is not found
-// (e.g., signature stream). Drains the stream so br is positioned at the next
-// member's magic bytes.
-func tryReadPkgInfoFromNextStream(br *bufio.Reader) (string, error) {
+// .PKGINFO and the .trigger script in the tar archive. Returns empty strings
+// if they are not found (e.g., signature stream). Drains the stream so br is
+// positioned at the next member's magic bytes; br must be a byte reader for
+// gzip not to read ahead into it.
+func tryReadPkgInfoFromNextStream(br flate.Reader) (pkgInfo, trigger string, err error) {
 	gz, err := gzip.NewReader(br)
 	if err != nil {
-		return "", errors.Wrap(err, errors.ErrTypeParser, "failed to create gzip reader").
+		return "", "", errors.Wrap(err, errors.ErrTypeParser, "failed to create gzip reader").
 			WithOperation("tryReadPkgInfoFromNextStream")
 	}
 	defer func() { _ = gz.Close() }()
@@ -228,8 +240,6 @@ func tryReadPkgInfoFromNextStream(br *bufio.Reader) (string, error) {
 
 	tr := tar.NewReader(gz)
 
-	var pkgInfo string
-
 	for {
 		hdr, err := tr.Next()
 		if err == io.EOF {
@@ -237,18 +247,25 @@ func tryReadPkgInfoFromNextStream(br *bufio.Reader) (string, error) {
 		}
 
 		if err != nil {
-			return "", errors.Wrap(err, errors.ErrTypeParser, "failed to read tar entry").
+			return "", "", errors.Wrap(err, errors.ErrTypeParser, "failed to read tar entry").
 				WithOperation("tryReadPkgInfoFromNextStream")
 		}
 
-		if hdr.Name == ".PKGINFO" {
-			data, err := io.ReadAll(io.LimitReader(tr, 1<<20)) // 1 MiB cap
-			if err != nil {
-				return "", errors.Wrap(err, errors.ErrTypeParser, "failed to read .PKGINFO file").
-					WithOperation("tryReadPkgInfoFromNextStream")
-			}
+		if hdr.Name != ".PKGINFO" && hdr.Name != ".trigger" {
+			continue
+		}
+
+		data, err := io.ReadAll(io.LimitReader(tr, 1<<20)) // 1 MiB cap
+		if err != nil {
+			return "", "", errors.Wrap(err, errors.ErrTypeParser, "failed to read control file").
+				WithOperation("tryReadPkgInfoFromNextStream").
+				WithContext("file", hdr.Name)
+		}
 
+		if hdr.Name == ".PKGINFO" {
 			pkgInfo = string(data)
+		} else {
+			trigger = string(data)
 		}
 	}
 
@@ -257,11 +274,12 @@ func tryReadPkgInfoFromNextStream(br *bufio.Reader) (string, error) {
 	// to defend against decompression bombs.
 	_, _ = io.Copy(io.Discard, io.LimitReader(gz, 16<<20))
 
-	return pkgInfo, nil
+	return pkgInfo, trigger, nil
 }
 
-// extractAPKData reads the data.tar.gz stream from an APK file and extracts files to the filesystem.
-func extractAPKData(r io.Reader) error {
+// extractAPKData reads the data.tar.gz stream from an APK file and extracts files to the filesystem,
+// recording them in tx.
+func extractAPKData(r io.Reader, tx *triggers.Transaction) error {
 	gz2, err := gzip.NewReader(r)
 	if err != nil {
 		return errors.Wrap(err, errors.ErrTypeParser, "failed to create gzip reader for data stream").
@@ -285,6 +303,10 @@ func extractAPKData(r io.Reader) error {
 		if err := extractAPKEntry(tr2, hdr); err != nil {
 			return err
 		}
+
+		if hdr.Typeflag != tar.TypeDir {
+			tx.Install(hdr.Name)
+		}
 	}
 
 	return nil
@@ -0,0 +1,131 @@
+package apkindex
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/logger"
+	"github.com/M0Rf30/yap/v2/pkg/shell"
+	"github.com/M0Rf30/yap/v2/pkg/triggers"
+)
+
+// triggerEnvAllowList enumerates the environment variables forwarded to
+// trigger scripts, as apk runs them with a minimal environment.
+var triggerEnvAllowList = map[string]bool{
+	"PATH": true,
+	"HOME": true,
+	"LANG": true,
+	"TERM": true,
+	"TZ":   true,
+}
+
+// apkTrigger is the .trigger script of an installed package with the
+// directory globs of the "triggers" field of its .PKGINFO.
+type apkTrigger struct {
+	pkgName string
+	script  string
+	globs   []string
+}
+
+// transaction collects the files an install transaction extracts and the
+// triggers of its packages, so that each trigger runs once when it is
+// over, as apk runs them.
+type transaction struct {
+	triggers.Transaction
+
+	pending []apkTrigger
+}
+
+// addTrigger records the .trigger script of an installed package, if its
+// .PKGINFO declares the directories it watches.
+func (tx *transaction) addTrigger(pkgName, pkgInfo, script string) {
+	if script == "" {
+		return
+	}
+
+	var globs []string
+
+	for line := range strings.SplitSeq(pkgInfo, "\n") {
+		key, value, ok := strings.Cut(line, " = ")
+		if ok && key == "triggers" {
+			globs = append(globs, strings.Fields(value)...)
+		}
+	}
+
+	if len(globs) > 0 {
+		tx.pending = append(tx.pending, apkTrigger{pkgName: pkgName, script: script, globs: globs})
+	}
+}
+
+// fire runs each trigger whose globs match directories the transaction
+// extracted files into, once, with those directories as arguments.
+// Failures are logged: apk too only reports failing triggers.
+func (tx *transaction) fire(ctx context.Context) {
+	for _, trigger := range tx.pending {
+		var dirs []string
+
+		for _, glob := range trigger.globs {
+			dirs = append(dirs, tx.Dirs(glob)...)
+		}
+
+		if len(dirs) == 0 {
+			continue
+		}
+
+		slices.Sort(dirs)
+		dirs = slices.Compact(dirs)
+
+		if err := runTrigger(ctx, trigger, dirs); err != nil {
+			logger.Warn(i18n.T("logger.apkindex.warn.trigger_failed"),
+				"package", trigger.pkgName, "error", err)
+		}
+	}
+}
+
+// runTrigger writes the trigger script to a temporary file and runs it
+// with /bin/sh, passing the matched directories as arguments.
+func runTrigger(ctx context.Context, trigger apkTrigger, dirs []string) error {
+	scriptFile, err := os.CreateTemp("", "yap-apk-trigger-*")
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = os.Remove(scriptFile.Name()) }()
+
+	_, err = scriptFile.WriteString(trigger.script)
+	if closeErr := scriptFile.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	logger.Debug(i18n.T("logger.apkindex.debug.running_trigger"),
+		"package", trigger.pkgName, "dirs", dirs)
+
+	cmd := exec.CommandContext(ctx, "/bin/sh", append([]string{scriptFile.Name()}, dirs...)...)
+	cmd.Env = shell.FilterEnv(triggerEnvAllowList, map[string]string{
+		"PATH": "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+	})
+
+	var output bytes.Buffer
+
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if err := cmd.Run(); err != nil {
+		return errors.Wrap(err, errors.ErrTypeBuild, "trigger script failed").
+			WithOperation("runTrigger").
+			WithContext("package", trigger.pkgName).
+			WithContext("output", strings.TrimSpace(output.String()))
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package apkindex //nolint:testpackage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTransactionFiresTriggersOnce tests that a trigger runs once, with
+// every matched directory, after the transaction extracted its files.
+func TestTransactionFiresTriggersOnce(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "fired")
+
+	var tx transaction
+
+	tx.addTrigger("hicolor-icon-theme",
+		"pkgname = hicolor-icon-theme\ntriggers = /usr/share/icons/*\n",
+		"#!/bin/sh\necho \"$@\" >> "+out+"\n")
+	tx.addTrigger("no-globs", "pkgname = no-globs\n", "#!/bin/sh\nexit 1\n")
+	require.Len(t, tx.pending, 1)
+
+	tx.Install("usr/share/icons/hicolor/48x48/apps/foo.png", "usr/share/icons/Adwaita/index.theme", "usr/bin/foo")
+	tx.fire(context.Background())
+
+	fired, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "/usr/share/icons/Adwaita /usr/share/icons/hicolor\n", string(fired))
+}
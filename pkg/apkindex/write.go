@@ -0,0 +1,308 @@
+package apkindex
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"crypto/sha1" //nolint:gosec // APK v2 checksums are SHA1
+	"encoding/base64"
+	"hash"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/gzip"
+
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/pkginfo"
+)
+
+const (
+	// indexFileName is the name of the signed repository index, one per
+	// directory of packages.
+	indexFileName = "APKINDEX.tar.gz"
+	// adbMagic starts an APK v3 package, which has no v2 control stream.
+	adbMagic = "ADB"
+)
+
+// WriteOptions configures WriteIndex.
+type WriteOptions struct {
+	// Description is stored as DESCRIPTION next to APKINDEX and shown by
+	// apk update, e.g. "v3.20.0-123-gabcdef".
+	Description string
+}
+
+// indexEntry is a package of an APKINDEX: its .PKGINFO, and the size and
+// control checksum of its file.
+type indexEntry struct {
+	info     pkginfo.Info
+	size     int64
+	checksum string
+}
+
+// WriteIndex writes an unsigned APKINDEX.tar.gz, as apk index does, in every
+// directory under dir holding .apk packages, and returns their paths.
+// An index is signed by prepending the stream of signing.RSASigner.SignIndex.
+func WriteIndex(dir string, opts WriteOptions) ([]string, error) {
+	byDir := make(map[string][]string)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if filepath.Ext(path) == ".apk" {
+			byDir[filepath.Dir(path)] = append(byDir[filepath.Dir(path)], path)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypeFileSystem, "failed to walk repository").
+			WithOperation("WriteIndex").
+			WithContext("dir", dir)
+	}
+
+	if len(byDir) == 0 {
+		return nil, errors.New(errors.ErrTypeValidation, "no APK packages to index").
+			WithOperation("WriteIndex").
+			WithContext("dir", dir)
+	}
+
+	dirs := make([]string, 0, len(byDir))
+	for pkgDir := range byDir {
+		dirs = append(dirs, pkgDir)
+	}
+
+	slices.Sort(dirs)
+
+	paths := make([]string, 0, len(dirs))
+
+	for _, pkgDir := range dirs {
+		entries := make([]*indexEntry, 0, len(byDir[pkgDir]))
+
+		for _, path := range byDir[pkgDir] {
+			entry, err := readIndexEntry(path)
+			if err != nil {
+				return nil, err
+			}
+
+			entries = append(entries, entry)
+		}
+
+		path := filepath.Join(pkgDir, indexFileName)
+		if err := writeIndexFile(path, entries, opts.Description); err != nil {
+			return nil, errors.Wrap(err, errors.ErrTypeFileSystem, "failed to write APKINDEX").
+				WithOperation("WriteIndex").
+				WithContext("path", path)
+		}
+
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
+
+// hashingReader hashes the bytes read through it. It is a byte reader, so
+// the gzip reader consumes exactly one member and the hash covers exactly
+// the compressed control stream.
+type hashingReader struct {
+	r    *bufio.Reader
+	hash hash.Hash
+}
+
+func (h *hashingReader) Read(p []byte) (int, error) {
+	n, err := h.r.Read(p)
+	h.hash.Write(p[:n])
+
+	return n, err
+}
+
+func (h *hashingReader) ReadByte() (byte, error) {
+	b, err := h.r.ReadByte()
+	if err == nil {
+		h.hash.Write([]byte{b})
+	}
+
+	return b, err
+}
+
+// readIndexEntry reads the .PKGINFO of an APK v2 package and computes its
+// Q1 control checksum, the SHA1 of the gzip stream holding .PKGINFO. The
+// signature stream of a signed package is skipped.
+func readIndexEntry(path string) (*indexEntry, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypeFileSystem, "failed to open package").
+			WithOperation("readIndexEntry").
+			WithContext("path", path)
+	}
+
+	defer func() { _ = file.Close() }()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypeFileSystem, "failed to stat package").
+			WithOperation("readIndexEntry").
+			WithContext("path", path)
+	}
+
+	br := bufio.NewReader(file)
+
+	if magic, _ := br.Peek(len(adbMagic)); string(magic) == adbMagic {
+		return nil, errors.New(errors.ErrTypeValidation, "APK v3 packages cannot be listed in an APKINDEX").
+			WithOperation("readIndexEntry").
+			WithContext("path", path)
+	}
+
+	hr := &hashingReader{r: br, hash: sha1.New()} //nolint:gosec // APK v2 checksums are SHA1
+
+	pkgInfo, _, err := tryReadPkgInfoFromNextStream(hr)
+	if err == nil && pkgInfo == "" {
+		hr.hash.Reset()
+
+		pkgInfo, _, err = tryReadPkgInfoFromNextStream(hr)
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypeParser, "failed to read package control stream").
+			WithOperation("readIndexEntry").
+			WithContext("path", path)
+	}
+
+	if pkgInfo == "" {
+		return nil, errors.New(errors.ErrTypeParser, "package has no .PKGINFO").
+			WithOperation("readIndexEntry").
+			WithContext("path", path)
+	}
+
+	entry := &indexEntry{
+		info:     pkginfo.Parse(pkgInfo),
+		size:     stat.Size(),
+		checksum: "Q1" + base64.StdEncoding.EncodeToString(hr.hash.Sum(nil)),
+	}
+
+	if entry.info.First("pkgname") == "" || entry.info.First("pkgver") == "" {
+		return nil, errors.New(errors.ErrTypeParser, ".PKGINFO lacks pkgname or pkgver").
+			WithOperation("readIndexEntry").
+			WithContext("path", path)
+	}
+
+	return entry, nil
+}
+
+// write writes the APKINDEX stanza of the package, with the fields in the
+// order apk-tools writes them.
+func (e *indexEntry) write(buf *bytes.Buffer) {
+	field := func(tag, value string) {
+		if value != "" {
+			buf.WriteString(tag + ":" + value + "\n")
+		}
+	}
+
+	field("C", e.checksum)
+	field("P", e.info.First("pkgname"))
+	field("V", e.info.First("pkgver"))
+	field("A", e.info.First("arch"))
+	field("S", strconv.FormatInt(e.size, 10))
+	field("I", e.info.First("size"))
+	field("T", e.info.First("pkgdesc"))
+	field("U", e.info.First("url"))
+	field("L", strings.Join(e.info["license"], " "))
+	field("o", e.info.First("origin"))
+	field("m", e.info.First("maintainer"))
+	field("t", e.info.First("builddate"))
+	field("c", e.info.First("commit"))
+	field("k", e.info.First("provider_priority"))
+	field("D", strings.Join(e.info["depend"], " "))
+	field("p", strings.Join(e.info["provides"], " "))
+	field("i", strings.Join(e.info["install_if"], " "))
+	buf.WriteString("\n")
+}
+
+// buildTime returns the build date of the package, zero if unknown.
+func (e *indexEntry) buildTime() int64 {
+	t, _ := strconv.ParseInt(e.info.First("builddate"), 10, 64)
+
+	return t
+}
+
+// writeIndexFile writes an APKINDEX.tar.gz listing entries by name, a single
+// gzip stream holding DESCRIPTION, when set, and APKINDEX. Its entries are
+// dated with the newest build so that the index is reproducible.
+func writeIndexFile(path string, entries []*indexEntry, description string) error {
+	slices.SortFunc(entries, func(a, b *indexEntry) int {
+		return strings.Compare(a.info.First("pkgname")+" "+a.info.First("pkgver"),
+			b.info.First("pkgname")+" "+b.info.First("pkgver"))
+	})
+
+	var (
+		index   bytes.Buffer
+		newest  int64
+		archive bytes.Buffer
+	)
+
+	for _, entry := range entries {
+		entry.write(&index)
+		newest = max(newest, entry.buildTime())
+	}
+
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+
+	type indexFile struct {
+		name string
+		data []byte
+	}
+
+	files := make([]indexFile, 0, 2)
+	if description != "" {
+		files = append(files, indexFile{"DESCRIPTION", []byte(description)})
+	}
+
+	files = append(files, indexFile{"APKINDEX", index.Bytes()})
+
+	for _, file := range files {
+		hdr := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     file.name,
+			Mode:     0o644,
+			Size:     int64(len(file.data)),
+			ModTime:  time.Unix(newest, 0),
+			Uname:    "root",
+			Gname:    "root",
+			Format:   tar.FormatUSTAR,
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if _, err := tw.Write(file.data); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	if err := gz.Close(); err != nil {
+		return err
+	}
+
+	//nolint:gosec // repository indexes are public
+	return os.WriteFile(path, archive.Bytes(), 0o644)
+}
@@ -0,0 +1,149 @@
+package apkindex //nolint:testpackage
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha1" //nolint:gosec // APK v2 checksums are SHA1
+	"encoding/base64"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteIndex(t *testing.T) {
+	dir := t.TempDir()
+	archDir := filepath.Join(dir, "x86_64")
+	require.NoError(t, os.Mkdir(archDir, 0o755))
+
+	fooControl := writeTestAPK(t, archDir, "foo-1.0-r0.apk", false,
+		"pkgname = foo\npkgver = 1.0-r0\narch = x86_64\nsize = 4096\npkgdesc = Foo tool\n"+
+			"builddate = 1700000000\nlicense = MIT\ndepend = so:libc.musl-x86_64.so.1\ndepend = bar\n")
+	writeTestAPK(t, archDir, "bar-2.0-r1.apk", true,
+		"# Generated by yap\npkgname = bar\npkgver = 2.0-r1\narch = x86_64\nsize = 1024\n"+
+			"builddate = 1700000100\nprovides = cmd:bar=2.0-r1\n")
+
+	paths, err := WriteIndex(dir, WriteOptions{Description: "v1.0"})
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(archDir, indexFileName)}, paths)
+
+	files := readTestIndex(t, paths[0])
+	require.Equal(t, []string{"DESCRIPTION", "APKINDEX"}, files.names)
+	assert.Equal(t, "v1.0", files.data["DESCRIPTION"])
+
+	stanzas := strings.Split(strings.TrimSuffix(files.data["APKINDEX"], "\n\n"), "\n\n")
+	require.Len(t, stanzas, 2)
+	assert.True(t, strings.HasPrefix(stanzas[0], "C:"))
+	assert.Contains(t, stanzas[0], "\nP:bar\nV:2.0-r1\nA:x86_64\n")
+	assert.Contains(t, stanzas[0], "\np:cmd:bar=2.0-r1")
+
+	sum := sha1.Sum(fooControl) //nolint:gosec // APK v2 checksums are SHA1
+	stat, err := os.Stat(filepath.Join(archDir, "foo-1.0-r0.apk"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "C:Q1"+base64.StdEncoding.EncodeToString(sum[:])+"\nP:foo\nV:1.0-r0\nA:x86_64\n"+
+		"S:"+strconv.FormatInt(stat.Size(), 10)+"\nI:4096\nT:Foo tool\nL:MIT\n"+
+		"t:1700000000\nD:so:libc.musl-x86_64.so.1 bar", stanzas[1])
+
+	idx := NewIndex()
+	require.NoError(t, idx.ParseIndex(strings.NewReader(files.data["APKINDEX"]), ""))
+
+	pkg, ok := idx.packages["foo"]
+	require.True(t, ok)
+	assert.Equal(t, []string{"so:libc.musl-x86_64.so.1", "bar"}, pkg.Depends)
+}
+
+func TestWriteIndexWithoutPackages(t *testing.T) {
+	_, err := WriteIndex(t.TempDir(), WriteOptions{})
+	require.Error(t, err)
+}
+
+func TestWriteIndexRejectsADB(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "foo-1.0-r0.apk"), []byte("ADBd...."), 0o644))
+
+	_, err := WriteIndex(dir, WriteOptions{})
+	require.Error(t, err)
+}
+
+// writeTestAPK writes an APK v2 package holding pkgInfo, preceded by a
+// signature stream when signed, and returns its control stream.
+func writeTestAPK(t *testing.T, dir, name string, signed bool, pkgInfo string) []byte {
+	t.Helper()
+
+	var apk bytes.Buffer
+
+	if signed {
+		apk.Write(testTarGz(t, ".SIGN.RSA.test.rsa.pub", "signature"))
+	}
+
+	control := testTarGz(t, ".PKGINFO", pkgInfo)
+	apk.Write(control)
+	apk.Write(testTarGz(t, "usr/bin/"+name, "#!/bin/sh\n"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name), apk.Bytes(), 0o644))
+
+	return control
+}
+
+// testTarGz returns a gzip stream holding a tar archive of one file.
+func testTarGz(t *testing.T, name, content string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content))}))
+	_, err := tw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	return buf.Bytes()
+}
+
+type testIndexFiles struct {
+	names []string
+	data  map[string]string
+}
+
+// readTestIndex returns the files of an APKINDEX.tar.gz, in order.
+func readTestIndex(t *testing.T, path string) testIndexFiles {
+	t.Helper()
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+
+	defer func() { _ = file.Close() }()
+
+	gz, err := gzip.NewReader(file)
+	require.NoError(t, err)
+
+	files := testIndexFiles{data: map[string]string{}}
+	tr := tar.NewReader(gz)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		require.NoError(t, err)
+
+		data, err := io.ReadAll(tr)
+		require.NoError(t, err)
+
+		files.names = append(files.names, hdr.Name)
+		files.data[hdr.Name] = string(data)
+	}
+
+	return files
+}
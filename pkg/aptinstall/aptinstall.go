@@ -32,6 +32,7 @@ import (
 	"github.com/M0Rf30/yap/v2/pkg/i18n"
 	"github.com/M0Rf30/yap/v2/pkg/logger"
 	"github.com/M0Rf30/yap/v2/pkg/platform"
+	"github.com/M0Rf30/yap/v2/pkg/triggers"
 )
 
 // Options controls Install's runtime behaviour.
@@ -118,6 +119,8 @@ func InstallWithOptions(ctx context.Context, names []string, opts Options) error
 	defer func() { _ = os.RemoveAll(tmpDir) }()
 
 	// Install packages in dependency order.
+	var tx triggers.Transaction
+
 	for _, p := range pkgs {
 		contents := debMetadata[p.Name]
 
@@ -126,8 +129,14 @@ func InstallWithOptions(ctx context.Context, names []string, opts Options) error
 				WithContext("package", p.Name).
 				WithOperation("Install")
 		}
+
+		recordTriggers(&tx, contents)
 	}
 
+	// Like ldconfig below, process the triggers once for the whole
+	// transaction, after every package is configured.
+	processTriggers(ctx, &tx)
+
 	// Refresh dynamic linker cache exactly once per transaction (vs once
 	// per package), iff requested.
 	if opts.RunLDConfig {
@@ -360,6 +369,8 @@ func installPackage(
 		ctx, "postinst", pkgName, arch, contents, oldVersion,
 	)
 
+	applyAlternatives(pkgName, contents.Scriptlets["postinst"], rootDir)
+
 	finalState := "install ok installed"
 
 	if postinstErr != nil {
@@ -91,6 +91,55 @@ func parseDEB(debPath string) (*debContents, error) {
 	return contents, nil
 }
 
+// ReadControl returns the control file of a .deb. Unlike parseDEB it stops
+// at control.tar, so indexing a package never decompresses its payload.
+func ReadControl(debPath string) (string, error) {
+	file, err := os.Open(debPath) //nolint:gosec
+	if err != nil {
+		return "", errors.Wrap(err, errors.ErrTypeFileSystem, "open DEB").
+			WithOperation("ReadControl").WithContext("path", debPath)
+	}
+
+	defer func() { _ = file.Close() }()
+
+	arReader, err := ar.NewReader(file)
+	if err != nil {
+		return "", errors.Wrap(err, errors.ErrTypeParser, "parse AR archive").
+			WithOperation("ReadControl").WithContext("path", debPath)
+	}
+
+	for {
+		header, err := arReader.Next()
+		if err != nil {
+			if err == io.EOF { //nolint:errorlint
+				break
+			}
+
+			return "", errors.Wrap(err, errors.ErrTypeParser, "read AR header").
+				WithOperation("ReadControl")
+		}
+
+		if !strings.HasPrefix(header.Name, "control.tar") {
+			continue
+		}
+
+		contents := &debContents{Scriptlets: make(map[string]string)}
+		if err := parseControlTar(arReader, header.Name, contents); err != nil {
+			return "", errors.Wrap(err, errors.ErrTypeParser, "parse control.tar").
+				WithOperation("ReadControl")
+		}
+
+		if contents.Control != "" {
+			return contents.Control, nil
+		}
+
+		break
+	}
+
+	return "", errors.New(errors.ErrTypeParser, "control file not found in DEB").
+		WithOperation("ReadControl").WithContext("path", debPath)
+}
+
 // parseControlTar extracts and parses the control.tar member of a .deb.
 //
 // Each entry body is read via an io.LimitReader so a malformed .deb cannot
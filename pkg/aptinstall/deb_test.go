@@ -17,8 +17,6 @@ import (
 
 // createTestDEB creates a minimal but valid .deb file for testing.
 // Returns the path to the created .deb file.
-// Currently unused but kept for future integration tests.
-// nolint:unused
 func createTestDEB(t *testing.T, tmpDir, pkgName, version string) string {
 	t.Helper()
 
@@ -77,6 +75,7 @@ Description: Test package
 	defer func() { _ = debFile.Close() }()
 
 	arWriter := ar.NewWriter(debFile)
+	require.NoError(t, arWriter.WriteGlobalHeader())
 
 	// Write debian-binary.
 	debianBinary := "2.0\n"
@@ -115,6 +114,19 @@ Description: Test package
 	return debPath
 }
 
+// TestReadControl tests reading the control file of a .deb.
+func TestReadControl(t *testing.T) {
+	debPath := createTestDEB(t, t.TempDir(), "testpkg", "1.0-1")
+
+	control, err := aptinstall.ReadControl(debPath)
+	require.NoError(t, err)
+	assert.Contains(t, control, "Package: testpkg\n")
+	assert.Contains(t, control, "Version: 1.0-1\n")
+
+	_, err = aptinstall.ReadControl(filepath.Join(t.TempDir(), "missing.deb"))
+	assert.Error(t, err)
+}
+
 // TestParseControl tests the parseControl function.
 func TestParseControl(t *testing.T) {
 	control := `Package: gcc
@@ -344,3 +344,8 @@ func FilterForeignArchPackagesForTesting(pkgs []*aptcache.PackageInfo) []*aptcac
 func CurrentInstalledVersionForTesting(pkg *aptcache.PackageInfo) string {
 	return currentInstalledVersion(pkg)
 }
+
+// ParseTriggersFileForTesting exposes parseTriggersFile for unit tests.
+func ParseTriggersFileForTesting(content string) (interests, activates []string) {
+	return parseTriggersFile(content)
+}
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/M0Rf30/yap/v2/pkg/alternatives"
 	"github.com/M0Rf30/yap/v2/pkg/errors"
 	"github.com/M0Rf30/yap/v2/pkg/i18n"
 	"github.com/M0Rf30/yap/v2/pkg/logger"
@@ -145,3 +146,18 @@ func scriptletPathForPackage(pkgName, arch, control, scriptName string) string {
 
 	return filepath.Join(dpkgInfoDir, baseName+"."+scriptName)
 }
+
+// applyAlternatives sets up the alternatives the postinst registers with
+// update-alternatives when the install root has none to run, as in minimal
+// build roots. Failures are logged, like postinst failures.
+func applyAlternatives(pkgName, postinst, rootDir string) {
+	alts := alternatives.FromScript(postinst)
+	if len(alts) == 0 || alternatives.Available(rootDir) {
+		return
+	}
+
+	if err := alternatives.Apply(rootDir, alts); err != nil {
+		logger.Warn(i18n.T("logger.aptinstall.warn.failed_to_apply_alternatives"),
+			"package", pkgName, "error", err)
+	}
+}
@@ -0,0 +1,91 @@
+package aptinstall
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/logger"
+	"github.com/M0Rf30/yap/v2/pkg/triggers"
+)
+
+// parseTriggersFile returns the directives of a DEBIAN/triggers file: the
+// triggers the package is interested in and the ones it activates. The
+// await and noawait variants are treated alike, as triggers are processed
+// once at the end of the transaction anyway.
+func parseTriggersFile(content string) (interests, activates []string) {
+	for line := range strings.SplitSeq(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+
+		switch fields[0] {
+		case "interest", "interest-await", "interest-noawait":
+			interests = append(interests, fields[1])
+		case "activate", "activate-await", "activate-noawait":
+			activates = append(activates, fields[1])
+		}
+	}
+
+	return interests, activates
+}
+
+// recordTriggers adds what an installed package contributes to the
+// transaction: its files, which activate the file triggers they fall
+// under, and the named triggers its triggers file activates.
+func recordTriggers(tx *triggers.Transaction, contents *debContents) {
+	tx.Install(contents.Files...)
+
+	_, activates := parseTriggersFile(contents.Triggers)
+	tx.Activate(activates...)
+}
+
+// processTriggers runs "postinst triggered" once for every package with a
+// .triggers file in the dpkg info dir that is interested in triggers the
+// transaction activated, passing them all at once as dpkg does. Failures
+// are logged, like postinst failures.
+func processTriggers(ctx context.Context, tx *triggers.Transaction) {
+	triggerFiles, err := filepath.Glob(filepath.Join(dpkgInfoDir, "*.triggers"))
+	if err != nil {
+		return
+	}
+
+	for _, triggerFile := range triggerFiles {
+		content, err := os.ReadFile(triggerFile) //nolint:gosec // dpkg info dir
+		if err != nil {
+			continue
+		}
+
+		interests, _ := parseTriggersFile(string(content))
+
+		var activated []string
+
+		for _, interest := range interests {
+			if tx.Activated(interest) {
+				activated = append(activated, interest)
+			}
+		}
+
+		if len(activated) == 0 {
+			continue
+		}
+
+		baseName := strings.TrimSuffix(triggerFile, ".triggers")
+		scriptPath := baseName + ".postinst"
+
+		if _, err := os.Stat(scriptPath); err != nil {
+			continue
+		}
+
+		pkgName, _, _ := strings.Cut(filepath.Base(baseName), ":")
+
+		err = runScriptlet(ctx, scriptPath, "postinst", pkgName, "triggered", strings.Join(activated, " "))
+		if err != nil {
+			logger.Warn(i18n.T("logger.aptinstall.warn.trigger_failed"),
+				"package", pkgName, "triggers", activated, "error", err)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package aptinstall_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/M0Rf30/yap/v2/pkg/aptinstall"
+)
+
+func TestParseTriggersFile(t *testing.T) {
+	t.Parallel()
+
+	content := "# icon cache\n" +
+		"interest-noawait /usr/share/icons\n" +
+		"interest ldconfig\n" +
+		"activate-noawait update-menus\n" +
+		"bogus\n"
+
+	interests, activates := aptinstall.ParseTriggersFileForTesting(content)
+
+	if want := []string{"/usr/share/icons", "ldconfig"}; !reflect.DeepEqual(interests, want) {
+		t.Errorf("interests = %v, want %v", interests, want)
+	}
+
+	if want := []string{"update-menus"}; !reflect.DeepEqual(activates, want) {
+		t.Errorf("activates = %v, want %v", activates, want)
+	}
+}
@@ -10,6 +10,9 @@
 // the source's Signed-By target, no key matched in the default trust
 // paths). A signature that is present but fails to verify is always
 // fatal.
+//
+// Publish goes the other way: it writes the pool/ and dists/ tree of a
+// repository serving locally built .deb packages.
 package aptrepo
 
 import (
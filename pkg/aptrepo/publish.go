@@ -0,0 +1,561 @@
+package aptrepo
+
+// This file implements the writing side of an apt repository: it copies
+// .deb packages into a pool/ tree and generates the dists/ indexes apt
+// reads back through fetchRelease and fetchComponentIndex.
+//
+// Layout, as reprepro and aptly write it:
+//
+//	pool/<component>/<prefix>/<source>/<package>.deb
+//	dists/<suite>/<component>/binary-<arch>/Packages{,.gz,.xz}
+//	dists/<suite>/Release, InRelease, Release.gpg
+//
+// Publishing is incremental: the stanzas of the existing Packages files are
+// kept, and a package replaces an existing stanza only when it has the same
+// name, version and architecture. Architecture: all packages are listed
+// under every binary-<arch> directory of the component.
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/md5" //nolint:gosec // Release files still carry MD5Sum
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ulikunitz/xz"
+
+	"github.com/M0Rf30/yap/v2/pkg/aptinstall"
+	"github.com/M0Rf30/yap/v2/pkg/deb822"
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/signing"
+)
+
+const (
+	defaultSuite     = "stable"
+	defaultComponent = "main"
+	archAll          = "all"
+	binaryDirPrefix  = "binary-"
+)
+
+// packagesFiles are the index variants written for every binary-<arch>
+// directory, the plain one first.
+var packagesFiles = []string{"Packages", "Packages.gz", "Packages.xz"}
+
+// PublishOptions configures Publish. The zero value publishes unsigned
+// packages to the main component of the stable suite.
+type PublishOptions struct {
+	// Suite is the dists/ directory to publish to, "stable" when empty.
+	Suite string
+	// Codename is written to the Release file; Suite when empty.
+	Codename string
+	// Component receives the packages, "main" when empty.
+	Component string
+	// Arch adds a binary-<arch> index to the component. It is required
+	// when the repository would otherwise hold only Architecture: all
+	// packages, which are listed under every other architecture.
+	Arch string
+	// Origin and Label are written to the Release file when set.
+	Origin string
+	Label  string
+	// Signer clearsigns InRelease and writes Release.gpg. The repository
+	// is unsigned when nil, and stale signatures are removed.
+	Signer *signing.GPGSigner
+}
+
+// Publish copies the .deb packages into the pool/ tree of the repository
+// at root, rewrites the Packages indexes of the component and the Release
+// file of the suite, and signs it. It returns the path of the Release file.
+func Publish(ctx context.Context, root string, debs []string, opts PublishOptions) (string, error) {
+	if opts.Suite == "" {
+		opts.Suite = defaultSuite
+	}
+
+	if opts.Codename == "" {
+		opts.Codename = opts.Suite
+	}
+
+	if opts.Component == "" {
+		opts.Component = defaultComponent
+	}
+
+	suiteDir := filepath.Join(root, "dists", opts.Suite)
+	componentDir := filepath.Join(suiteDir, opts.Component)
+
+	byArch, all, err := readComponent(componentDir)
+	if err != nil {
+		return "", err
+	}
+
+	for _, deb := range debs {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		key, arch, stanza, err := addToPool(root, opts.Component, deb)
+		if err != nil {
+			return "", err
+		}
+
+		if arch == archAll {
+			all[key] = stanza
+
+			continue
+		}
+
+		if byArch[arch] == nil {
+			byArch[arch] = make(map[string]string)
+		}
+
+		byArch[arch][key] = stanza
+	}
+
+	if opts.Arch != "" && byArch[opts.Arch] == nil {
+		byArch[opts.Arch] = make(map[string]string)
+	}
+
+	if len(byArch) == 0 {
+		return "", errors.New(errors.ErrTypeValidation,
+			"no architecture to list Architecture: all packages under").
+			WithOperation("Publish").
+			WithContext("component", opts.Component)
+	}
+
+	for arch, stanzas := range byArch {
+		merged := maps.Clone(stanzas)
+		maps.Copy(merged, all)
+
+		if err := writePackages(filepath.Join(componentDir, binaryDirPrefix+arch), merged); err != nil {
+			return "", err
+		}
+	}
+
+	releasePath := filepath.Join(suiteDir, "Release")
+	if err := writeRelease(releasePath, opts); err != nil {
+		return "", err
+	}
+
+	if err := signRelease(ctx, releasePath, opts.Signer); err != nil {
+		return "", err
+	}
+
+	return releasePath, nil
+}
+
+// readComponent returns the stanzas of the existing Packages files of a
+// component, by architecture and package key, and the Architecture: all
+// stanzas separately. A missing component yields empty maps.
+func readComponent(componentDir string) (byArch map[string]map[string]string, all map[string]string, err error) {
+	byArch = make(map[string]map[string]string)
+	all = make(map[string]string)
+
+	entries, err := os.ReadDir(componentDir)
+	if os.IsNotExist(err) {
+		return byArch, all, nil
+	}
+
+	if err != nil {
+		return nil, nil, errors.Wrap(err, errors.ErrTypeFileSystem, "read component directory").
+			WithOperation("readComponent").
+			WithContext("path", componentDir)
+	}
+
+	for _, entry := range entries {
+		arch, ok := strings.CutPrefix(entry.Name(), binaryDirPrefix)
+		if !entry.IsDir() || !ok {
+			continue
+		}
+
+		byArch[arch] = make(map[string]string)
+
+		data, err := os.ReadFile(filepath.Join(componentDir, entry.Name(), packagesFiles[0])) //nolint:gosec
+		if os.IsNotExist(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, nil, errors.Wrap(err, errors.ErrTypeFileSystem, "read Packages index").
+				WithOperation("readComponent").
+				WithContext("arch", arch)
+		}
+
+		for stanza := range strings.SplitSeq(strings.TrimSpace(string(data)), "\n\n") {
+			if strings.TrimSpace(stanza) == "" {
+				continue
+			}
+
+			key, stanzaArch := stanzaKey(parseStanza(stanza))
+			if stanzaArch == archAll {
+				all[key] = stanza
+			} else {
+				byArch[arch][key] = stanza
+			}
+		}
+	}
+
+	return byArch, all, nil
+}
+
+// addToPool copies a .deb into the pool and returns its key, architecture
+// and Packages stanza.
+func addToPool(root, component, debPath string) (key, arch, stanza string, err error) {
+	control, err := aptinstall.ReadControl(debPath)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	fields := parseStanza(control)
+
+	key, arch = stanzaKey(fields)
+	if fields["Package"] == "" || fields["Version"] == "" || arch == "" {
+		return "", "", "", errors.New(errors.ErrTypeValidation,
+			"control file lacks Package, Version or Architecture").
+			WithOperation("addToPool").
+			WithContext("path", debPath)
+	}
+
+	source, _, _ := strings.Cut(fields["Source"], " ")
+	if source == "" {
+		source = fields["Package"]
+	}
+
+	filename := path.Join("pool", component, poolPrefix(source), source, filepath.Base(debPath))
+
+	poolPath := filepath.Join(root, filepath.FromSlash(filename))
+	if err := copyFile(debPath, poolPath); err != nil {
+		return "", "", "", err
+	}
+
+	size, md5sum, sha256sum, err := hashFile(poolPath)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	poolFields := fmt.Sprintf("Filename: %s\nSize: %d\nMD5sum: %s\nSHA256: %s",
+		filename, size, md5sum, sha256sum)
+
+	// The pool fields go before Description, as dpkg-scanpackages writes them.
+	control = strings.TrimRight(control, "\n")
+	if i := strings.Index(control, "\nDescription:"); i >= 0 {
+		return key, arch, control[:i+1] + poolFields + control[i:], nil
+	}
+
+	return key, arch, control + "\n" + poolFields, nil
+}
+
+// parseStanza returns the fields of a single deb822 stanza.
+func parseStanza(text string) deb822.Stanza {
+	fields := make(deb822.Stanza)
+
+	_ = deb822.Parse(strings.NewReader(text), func(stanza deb822.Stanza) error {
+		maps.Copy(fields, stanza)
+
+		return nil
+	})
+
+	return fields
+}
+
+// stanzaKey returns the key identifying a package in a Packages index, in
+// the <name>_<version>_<arch> form of its file name, and its architecture.
+func stanzaKey(fields deb822.Stanza) (key, arch string) {
+	arch = fields["Architecture"]
+
+	return fields["Package"] + "_" + fields["Version"] + "_" + arch, arch
+}
+
+// poolPrefix returns the pool subdirectory of a source package: its first
+// letter, or the first four for lib* packages.
+func poolPrefix(source string) string {
+	if strings.HasPrefix(source, "lib") && len(source) > 3 {
+		return source[:4]
+	}
+
+	return source[:1]
+}
+
+// copyFile copies src to dst, unless they are the same file.
+func copyFile(src, dst string) error {
+	if srcInfo, dstInfo := fileInfo(src), fileInfo(dst); srcInfo != nil && dstInfo != nil &&
+		os.SameFile(srcInfo, dstInfo) {
+		return nil
+	}
+
+	in, err := os.Open(src) //nolint:gosec
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeFileSystem, "open package").
+			WithOperation("copyFile").
+			WithContext("path", src)
+	}
+
+	defer func() { _ = in.Close() }()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return errors.Wrap(err, errors.ErrTypeFileSystem, "create pool directory").
+			WithOperation("copyFile").
+			WithContext("path", dst)
+	}
+
+	out, err := os.Create(dst) //nolint:gosec
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeFileSystem, "create pool file").
+			WithOperation("copyFile").
+			WithContext("path", dst)
+	}
+
+	defer func() { _ = out.Close() }()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return errors.Wrap(err, errors.ErrTypeFileSystem, "copy package to pool").
+			WithOperation("copyFile").
+			WithContext("path", dst)
+	}
+
+	return out.Close()
+}
+
+// hashFile returns the size and the hex MD5 and SHA-256 digests of a file.
+func hashFile(path string) (size int64, md5sum, sha256sum string, err error) {
+	file, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return 0, "", "", errors.Wrap(err, errors.ErrTypeFileSystem, "open file to hash").
+			WithOperation("hashFile").
+			WithContext("path", path)
+	}
+
+	defer func() { _ = file.Close() }()
+
+	md5Hash := md5.New() //nolint:gosec
+	sha256Hash := sha256.New()
+
+	size, err = io.Copy(io.MultiWriter(md5Hash, sha256Hash), file)
+	if err != nil {
+		return 0, "", "", errors.Wrap(err, errors.ErrTypeFileSystem, "hash file").
+			WithOperation("hashFile").
+			WithContext("path", path)
+	}
+
+	return size, hex.EncodeToString(md5Hash.Sum(nil)), hex.EncodeToString(sha256Hash.Sum(nil)), nil
+}
+
+// fileInfo returns the FileInfo of path, or nil when it cannot be read.
+func fileInfo(path string) os.FileInfo {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+
+	return info
+}
+
+// writePackages writes the Packages index variants of a binary-<arch>
+// directory, with the stanzas sorted by key.
+func writePackages(dir string, stanzas map[string]string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return errors.Wrap(err, errors.ErrTypeFileSystem, "create index directory").
+			WithOperation("writePackages").
+			WithContext("path", dir)
+	}
+
+	var content strings.Builder
+
+	for _, key := range slices.Sorted(maps.Keys(stanzas)) {
+		content.WriteString(stanzas[key])
+		content.WriteString("\n\n")
+	}
+
+	data := []byte(content.String())
+
+	for _, name := range packagesFiles {
+		if err := writeIndexFile(filepath.Join(dir, name), data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeIndexFile writes data to path, compressed as its extension says.
+func writeIndexFile(path string, data []byte) error {
+	file, err := os.Create(path) //nolint:gosec
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeFileSystem, "create index file").
+			WithOperation("writeIndexFile").
+			WithContext("path", path)
+	}
+
+	defer func() { _ = file.Close() }()
+
+	var writer io.WriteCloser
+
+	switch filepath.Ext(path) {
+	case ".gz":
+		writer = gzip.NewWriter(file)
+	case ".xz":
+		writer, err = xz.NewWriter(file)
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeFileSystem, "create xz writer").
+				WithOperation("writeIndexFile").
+				WithContext("path", path)
+		}
+	}
+
+	if writer == nil {
+		_, err = file.Write(data)
+	} else if _, err = writer.Write(data); err == nil {
+		err = writer.Close()
+	}
+
+	if err == nil {
+		err = file.Close()
+	}
+
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeFileSystem, "write index file").
+			WithOperation("writeIndexFile").
+			WithContext("path", path)
+	}
+
+	return nil
+}
+
+// writeRelease writes the Release file of a suite, listing every
+// component and architecture under it and the hashes of their indexes.
+func writeRelease(releasePath string, opts PublishOptions) error {
+	suiteDir := filepath.Dir(releasePath)
+
+	var (
+		components, archs []string
+		indexes           []string
+	)
+
+	entries, err := os.ReadDir(suiteDir)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeFileSystem, "read suite directory").
+			WithOperation("writeRelease").
+			WithContext("path", suiteDir)
+	}
+
+	for _, component := range entries {
+		if !component.IsDir() {
+			continue
+		}
+
+		components = append(components, component.Name())
+
+		binaries, err := os.ReadDir(filepath.Join(suiteDir, component.Name()))
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeFileSystem, "read component directory").
+				WithOperation("writeRelease").
+				WithContext("component", component.Name())
+		}
+
+		for _, binary := range binaries {
+			arch, ok := strings.CutPrefix(binary.Name(), binaryDirPrefix)
+			if !binary.IsDir() || !ok {
+				continue
+			}
+
+			if !slices.Contains(archs, arch) {
+				archs = append(archs, arch)
+			}
+
+			for _, name := range packagesFiles {
+				index := path.Join(component.Name(), binary.Name(), name)
+				if fileInfo(filepath.Join(suiteDir, filepath.FromSlash(index))) != nil {
+					indexes = append(indexes, index)
+				}
+			}
+		}
+	}
+
+	slices.Sort(archs)
+
+	var md5Lines, sha256Lines strings.Builder
+
+	for _, index := range indexes {
+		size, md5sum, sha256sum, err := hashFile(filepath.Join(suiteDir, filepath.FromSlash(index)))
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(&md5Lines, " %s %16d %s\n", md5sum, size, index)
+		fmt.Fprintf(&sha256Lines, " %s %16d %s\n", sha256sum, size, index)
+	}
+
+	var release strings.Builder
+
+	for _, field := range [][2]string{
+		{"Origin", opts.Origin},
+		{"Label", opts.Label},
+		{"Suite", opts.Suite},
+		{"Codename", opts.Codename},
+		{"Date", time.Now().UTC().Format(time.RFC1123)},
+		{"Architectures", strings.Join(archs, " ")},
+		{"Components", strings.Join(components, " ")},
+	} {
+		if field[1] != "" {
+			fmt.Fprintf(&release, "%s: %s\n", field[0], field[1])
+		}
+	}
+
+	release.WriteString("MD5Sum:\n" + md5Lines.String())
+	release.WriteString("SHA256:\n" + sha256Lines.String())
+
+	//nolint:gosec // repository indexes are public
+	if err := os.WriteFile(releasePath, []byte(release.String()), 0o644); err != nil {
+		return errors.Wrap(err, errors.ErrTypeFileSystem, "write Release file").
+			WithOperation("writeRelease").
+			WithContext("path", releasePath)
+	}
+
+	return nil
+}
+
+// signRelease writes InRelease and Release.gpg next to the Release file,
+// or removes them when the repository is unsigned so that they do not
+// vouch for a stale Release.
+func signRelease(ctx context.Context, releasePath string, signer *signing.GPGSigner) error {
+	inReleasePath := filepath.Join(filepath.Dir(releasePath), "InRelease")
+	gpgPath := releasePath + ".gpg"
+
+	if signer == nil {
+		for _, stale := range []string{inReleasePath, gpgPath} {
+			if err := os.Remove(stale); err != nil && !os.IsNotExist(err) {
+				return errors.Wrap(err, errors.ErrTypeFileSystem, "remove stale signature").
+					WithOperation("signRelease").
+					WithContext("path", stale)
+			}
+		}
+
+		return nil
+	}
+
+	data, err := os.ReadFile(releasePath) //nolint:gosec
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeFileSystem, "read Release file").
+			WithOperation("signRelease").
+			WithContext("path", releasePath)
+	}
+
+	//nolint:gosec // repository indexes are public
+	if err := os.WriteFile(inReleasePath, data, 0o644); err != nil {
+		return errors.Wrap(err, errors.ErrTypeFileSystem, "write InRelease file").
+			WithOperation("signRelease").
+			WithContext("path", inReleasePath)
+	}
+
+	if err := signer.ClearSign(ctx, inReleasePath); err != nil {
+		return err
+	}
+
+	return signer.DetachSign(ctx, releasePath, gpgPath)
+}
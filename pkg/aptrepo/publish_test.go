@@ -0,0 +1,256 @@
+package aptrepo_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/m0rf30/ar"
+
+	"github.com/M0Rf30/yap/v2/pkg/aptrepo"
+	"github.com/M0Rf30/yap/v2/pkg/signing"
+)
+
+func TestPublish(t *testing.T) {
+	root := t.TempDir()
+	artifacts := t.TempDir()
+
+	foo := writeDeb(t, artifacts, "foo_1.0-1_amd64.deb",
+		"Package: foo\nVersion: 1.0-1\nArchitecture: amd64\nDescription: foo\n long description\n")
+	libbar := writeDeb(t, artifacts, "libbar1_2.0-1_arm64.deb",
+		"Package: libbar1\nSource: libbar (2.0-1)\nVersion: 2.0-1\nArchitecture: arm64\n")
+	data := writeDeb(t, artifacts, "foo-data_1.0-1_all.deb",
+		"Package: foo-data\nVersion: 1.0-1\nArchitecture: all\n")
+
+	releasePath, err := aptrepo.Publish(context.Background(), root, []string{foo, libbar, data},
+		aptrepo.PublishOptions{Suite: "stable", Origin: "yap"})
+	if err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	for _, pool := range []string{
+		"pool/main/f/foo/foo_1.0-1_amd64.deb",
+		"pool/main/libb/libbar/libbar1_2.0-1_arm64.deb",
+		"pool/main/f/foo-data/foo-data_1.0-1_all.deb",
+	} {
+		if _, err := os.Stat(filepath.Join(root, pool)); err != nil {
+			t.Errorf("%s not in the pool: %v", pool, err)
+		}
+	}
+
+	packages := readText(t, filepath.Join(root, "dists/stable/main/binary-amd64/Packages"))
+	if !strings.Contains(packages, "Filename: pool/main/f/foo/foo_1.0-1_amd64.deb\n") ||
+		!strings.Contains(packages, "Package: foo-data\n") ||
+		strings.Contains(packages, "libbar1") {
+		t.Errorf("amd64 Packages =\n%s", packages)
+	}
+
+	if !strings.Contains(packages, "\nSHA256: ") ||
+		!strings.HasSuffix(packages, "\nDescription: foo\n long description\n\n") {
+		t.Errorf("Description is not the last field of foo:\n%s", packages)
+	}
+
+	release, err := aptrepo.ParseReleaseBodyForTesting([]byte(readText(t, releasePath)))
+	if err != nil {
+		t.Fatalf("parse Release: %v", err)
+	}
+
+	digest := sha256.Sum256([]byte(packages))
+	if entry, ok := release.SHA256["main/binary-amd64/Packages"]; !ok ||
+		entry.Hash != hex.EncodeToString(digest[:]) || entry.Size != int64(len(packages)) {
+		t.Errorf("Release SHA256 = %v", release.SHA256)
+	}
+
+	for _, index := range []string{"main/binary-arm64/Packages.gz", "main/binary-arm64/Packages.xz"} {
+		if _, ok := release.SHA256[index]; !ok {
+			t.Errorf("Release lacks %s", index)
+		}
+	}
+
+	for _, field := range []string{"Origin: yap\n", "Codename: stable\n", "Architectures: amd64 arm64\n"} {
+		if !strings.Contains(readText(t, releasePath), field) {
+			t.Errorf("Release lacks %q", field)
+		}
+	}
+}
+
+func TestPublishIncremental(t *testing.T) {
+	root := t.TempDir()
+	artifacts := t.TempDir()
+
+	first := writeDeb(t, artifacts, "foo_1.0-1_amd64.deb",
+		"Package: foo\nVersion: 1.0-1\nArchitecture: amd64\n")
+	if _, err := aptrepo.Publish(context.Background(), root, []string{first}, aptrepo.PublishOptions{}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	second := writeDeb(t, artifacts, "foo_1.1-1_amd64.deb",
+		"Package: foo\nVersion: 1.1-1\nArchitecture: amd64\n")
+	if _, err := aptrepo.Publish(context.Background(), root, []string{second, first}, aptrepo.PublishOptions{}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	packages := readText(t, filepath.Join(root, "dists/stable/main/binary-amd64/Packages"))
+	if strings.Count(packages, "Package: foo\n") != 2 {
+		t.Errorf("Packages =\n%s", packages)
+	}
+
+	for _, name := range []string{"InRelease", "Release.gpg"} {
+		if _, err := os.Stat(filepath.Join(root, "dists/stable", name)); err == nil {
+			t.Errorf("unsigned repository has %s", name)
+		}
+	}
+}
+
+func TestPublishArchitectureAll(t *testing.T) {
+	root := t.TempDir()
+	data := writeDeb(t, t.TempDir(), "foo-data_1.0-1_all.deb",
+		"Package: foo-data\nVersion: 1.0-1\nArchitecture: all\n")
+
+	if _, err := aptrepo.Publish(context.Background(), root, []string{data}, aptrepo.PublishOptions{}); err == nil {
+		t.Error("Publish() succeeded without an architecture")
+	}
+
+	if _, err := aptrepo.Publish(context.Background(), root, []string{data},
+		aptrepo.PublishOptions{Arch: "riscv64"}); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	packages := readText(t, filepath.Join(root, "dists/stable/main/binary-riscv64/Packages"))
+	if !strings.Contains(packages, "Package: foo-data\n") {
+		t.Errorf("riscv64 Packages =\n%s", packages)
+	}
+}
+
+func TestPublishSigned(t *testing.T) {
+	root := t.TempDir()
+	entity := makeTestEntity(t, "repo-signer")
+
+	var key bytes.Buffer
+
+	w, err := armor.Encode(&key, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := entity.SerializePrivate(w, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	keyPath := filepath.Join(t.TempDir(), "deb.gpg")
+	if err := os.WriteFile(keyPath, key.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := signing.NewGPGSigner(signing.Config{Enabled: true, KeyPath: keyPath}, signing.FormatDEB)
+	if err != nil {
+		t.Fatalf("NewGPGSigner() error = %v", err)
+	}
+
+	foo := writeDeb(t, t.TempDir(), "foo_1.0-1_amd64.deb",
+		"Package: foo\nVersion: 1.0-1\nArchitecture: amd64\n")
+
+	releasePath, err := aptrepo.Publish(context.Background(), root, []string{foo},
+		aptrepo.PublishOptions{Signer: signer})
+	if err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	keyring := openpgp.EntityList{entity}
+	release := []byte(readText(t, releasePath))
+
+	body, err := aptrepo.VerifyInReleaseOrFallbackForTesting(
+		[]byte(readText(t, filepath.Join(root, "dists/stable/InRelease"))), keyring, nil, false, "file://"+root)
+	if err != nil {
+		t.Fatalf("InRelease verification: %v", err)
+	}
+
+	if !bytes.Equal(bytes.TrimRight(body, "\n"), bytes.TrimRight(release, "\n")) {
+		t.Errorf("InRelease body =\n%s\nwant\n%s", body, release)
+	}
+
+	if _, err := aptrepo.VerifyDetachedOrFallbackForTesting(release,
+		[]byte(readText(t, releasePath+".gpg")), nil, keyring, nil, false, "file://"+root); err != nil {
+		t.Errorf("Release.gpg verification: %v", err)
+	}
+}
+
+// writeDeb writes a .deb holding only a control file.
+func writeDeb(t *testing.T, dir, name, control string) string {
+	t.Helper()
+
+	var controlTar bytes.Buffer
+
+	gz := gzip.NewWriter(&controlTar)
+	tw := tar.NewWriter(gz)
+
+	if err := tw.WriteHeader(&tar.Header{Name: "./control", Mode: 0o644, Size: int64(len(control))}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tw.Write([]byte(control)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, name)
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() { _ = file.Close() }()
+
+	aw := ar.NewWriter(file)
+	if err := aw.WriteGlobalHeader(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, member := range []struct {
+		name string
+		data []byte
+	}{{"debian-binary", []byte("2.0\n")}, {"control.tar.gz", controlTar.Bytes()}} {
+		if err := aw.WriteHeader(&ar.Header{Name: member.name, Mode: 0o644, Size: int64(len(member.data))}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := aw.Write(member.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return path
+}
+
+// readText returns the contents of a text file.
+func readText(t *testing.T, path string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
@@ -37,6 +37,7 @@ import (
 
 	"github.com/M0Rf30/yap/v2/pkg/buffers"
 	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/files"
 	"github.com/M0Rf30/yap/v2/pkg/i18n"
 	"github.com/M0Rf30/yap/v2/pkg/logger"
 	"github.com/M0Rf30/yap/v2/pkg/safepath"
@@ -166,6 +167,21 @@ func CreateTarCompressed(
 	outputFile,
 	compression string,
 	formatGNU bool,
+) error {
+	return CreateTarCompressedWithOwners(ctx, sourceDir, outputFile, compression, formatGNU, nil)
+}
+
+// CreateTarCompressedWithOwners is CreateTarCompressed with ownership
+// overrides, matched against the archive paths with a leading slash.
+// Entries with extended attributes are written in the PAX format whatever
+// formatGNU says.
+func CreateTarCompressedWithOwners(
+	ctx context.Context,
+	sourceDir,
+	outputFile,
+	compression string,
+	formatGNU bool,
+	owners []files.OwnerRule,
 ) error {
 	if compression == "" {
 		compression = "zstd"
@@ -193,7 +209,7 @@ func CreateTarCompressed(
 
 	tw := tar.NewWriter(cw)
 
-	if err := writeTarFromDir(ctx, tw, sourceDir, formatGNU); err != nil {
+	if err := writeTarFromDir(ctx, tw, sourceDir, formatGNU, owners); err != nil {
 		_ = tw.Close()
 		_ = closeCompressor()
 
@@ -255,7 +271,9 @@ func CreateTarZst(ctx context.Context, sourceDir, outputFile string, formatGNU b
 // of archives.FilesFromDisk (FollowSymlinks: false).
 //
 //nolint:gocyclo,cyclop // dir + file + symlink dispatch is inherently branchy
-func writeTarFromDir(ctx context.Context, tw *tar.Writer, sourceDir string, formatGNU bool) error {
+func writeTarFromDir(
+	ctx context.Context, tw *tar.Writer, sourceDir string, formatGNU bool, owners []files.OwnerRule,
+) error {
 	sourceDir = filepath.Clean(sourceDir)
 
 	tarFormat := tar.FormatPAX
@@ -289,12 +307,14 @@ func writeTarFromDir(ctx context.Context, tw *tar.Writer, sourceDir string, form
 			return err
 		}
 
-		hdr, err := buildTarHeader(path, nameInArchive, info)
+		hdr, err := buildTarHeader(path, nameInArchive, info, owners)
 		if err != nil {
 			return err
 		}
 
-		hdr.Format = tarFormat
+		if hdr.Format != tar.FormatPAX {
+			hdr.Format = tarFormat
+		}
 
 		// Pacman expects directory entries to have a trailing slash.
 		if d.IsDir() && !strings.HasSuffix(hdr.Name, "/") {
@@ -317,9 +337,12 @@ func writeTarFromDir(ctx context.Context, tw *tar.Writer, sourceDir string, form
 }
 
 // buildTarHeader constructs a tar.Header for the given on-disk path. Symlinks
-// are recorded with their link target; ownership is forced to root:root for
-// reproducible package builds (mirrors the previous archives.Tar config).
-func buildTarHeader(path, nameInArchive string, info fs.FileInfo) (*tar.Header, error) {
+// are recorded with their link target; ownership and extended attributes
+// come from files.ReadAttributes, which records files as root:root unless
+// they were chowned or match an owners rule.
+func buildTarHeader(
+	path, nameInArchive string, info fs.FileInfo, owners []files.OwnerRule,
+) (*tar.Header, error) {
 	var linkTarget string
 
 	if info.Mode()&os.ModeSymlink != 0 {
@@ -337,10 +360,13 @@ func buildTarHeader(path, nameInArchive string, info fs.FileInfo) (*tar.Header,
 	}
 
 	hdr.Name = nameInArchive
-	hdr.Uid = 0
-	hdr.Gid = 0
-	hdr.Uname = "root"
-	hdr.Gname = "root"
+
+	attrs, err := files.ReadAttributes(path, "/"+nameInArchive, info, owners)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs.ApplyToHeader(hdr)
 
 	return hdr, nil
 }
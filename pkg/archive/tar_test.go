@@ -1,12 +1,17 @@
 package archive_test
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"context"
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/M0Rf30/yap/v2/pkg/archive"
+	"github.com/M0Rf30/yap/v2/pkg/files"
 )
 
 func TestCreateTarZst(t *testing.T) {
@@ -344,3 +349,62 @@ func TestCreateTarCompressedDefaultCompression(t *testing.T) {
 		t.Fatalf("Output file was not created")
 	}
 }
+
+func TestCreateTarCompressedWithOwners(t *testing.T) {
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	outputFile := filepath.Join(tempDir, "test.tar.gz")
+
+	if err := os.MkdirAll(filepath.Join(sourceDir, "var", "lib", "foo"), 0o755); err != nil {
+		t.Fatalf("Failed to create source directory: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(sourceDir, "var", "lib", "foo", "state"),
+		[]byte("state"), 0o644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	owners := []files.OwnerRule{{Pattern: "/var/lib/foo/*", Owner: "yap-test-daemon", Group: "yap-test-daemon"}}
+
+	err := archive.CreateTarCompressedWithOwners(context.Background(), sourceDir, outputFile,
+		"gzip", true, owners)
+	if err != nil {
+		t.Fatalf("CreateTarCompressedWithOwners failed: %v", err)
+	}
+
+	file, err := os.Open(outputFile)
+	if err != nil {
+		t.Fatalf("Failed to open output file: %v", err)
+	}
+
+	defer func() { _ = file.Close() }()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("Failed to open gzip stream: %v", err)
+	}
+
+	owned := make(map[string]string)
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("Failed to read tar entry: %v", err)
+		}
+
+		owned[hdr.Name] = hdr.Uname + ":" + hdr.Gname
+	}
+
+	if got := owned["var/lib/foo/state"]; got != "yap-test-daemon:yap-test-daemon" {
+		t.Errorf("var/lib/foo/state owned by %q, want yap-test-daemon:yap-test-daemon", got)
+	}
+
+	if got := owned["var/lib/foo/"]; got != "root:root" {
+		t.Errorf("var/lib/foo/ owned by %q, want root:root", got)
+	}
+}
@@ -0,0 +1,155 @@
+// Package autodeps derives runtime dependencies from the shared libraries
+// the packaged ELF files link against, as dpkg-shlibdeps, rpm's elfdeps and
+// abuild's scanelf pass do.
+//
+// Every DT_NEEDED soname found under the package directory is mapped to
+// the package providing it for the target architecture, using the package
+// manager's index (aptcache, dnfcache, apkindex or the pacman sync
+// databases) and installed-package database, and merged into the runtime
+// depends.
+// PKGBUILDs opt out with options=(!autodeps).
+package autodeps
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/M0Rf30/yap/v2/pkg/binary"
+	"github.com/M0Rf30/yap/v2/pkg/constants"
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/logger"
+	"github.com/M0Rf30/yap/v2/pkg/pkgbuild"
+)
+
+// Resolver maps a needed library to the dependency providing it.
+type Resolver interface {
+	Resolve(lib binary.Library) (string, bool)
+}
+
+// NewResolver returns the resolver for packageManager ("apt", "yum",
+// "zypper", "apk" or "pacman"), reading installed-package databases under
+// root and the package indexes for arch, a yap architecture such as
+// x86_64. It returns nil for an unknown package manager.
+func NewResolver(packageManager, root, arch string) Resolver {
+	mapping := constants.GetArchMapping()
+
+	switch packageManager {
+	case "apt":
+		return newDpkgResolver(root, mapping.TranslateArch(constants.FormatDEB, arch))
+	case "yum", "zypper":
+		return newRPMResolver()
+	case "apk":
+		return newApkResolver(root)
+	case "pacman":
+		return newPacmanResolver(root, mapping.TranslateArch(constants.FormatPacman, arch))
+	default:
+		return nil
+	}
+}
+
+// noResolverWarning warns, once per process, that the package manager has
+// no resolver, so autodeps adds no dependencies.
+var noResolverWarning sync.Once
+
+// Apply scans the package directory of pkg and appends the providers of
+// its needed libraries to pkg.Depends. Libraries shipped by the package
+// itself, dependencies already declared and the package itself are left
+// out. It is a no-op when the autodeps option is disabled, and only warns
+// when the package manager of the distribution, such as xbps, has no
+// resolver.
+func Apply(pkg *pkgbuild.PKGBUILD) error {
+	if !pkg.AutoDepsEnabled {
+		return nil
+	}
+
+	packageManager := constants.DistroPackageManager[pkg.Distro]
+
+	resolver := NewResolver(packageManager, "/", pkg.GetTargetArchitecture())
+	if resolver == nil {
+		noResolverWarning.Do(func() {
+			logger.Warn(i18n.T("logger.autodeps.warn.no_resolver"), "package_manager", packageManager)
+		})
+
+		return nil
+	}
+
+	libs, err := binary.NeededLibraries(pkg.PackageDir)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeFileSystem,
+			i18n.T("errors.autodeps.failed_to_scan")).
+			WithOperation("Apply").
+			WithContext("path", pkg.PackageDir)
+	}
+
+	deps := Resolve(resolver, libs)
+	added := Merge(pkg, deps)
+
+	if len(added) > 0 {
+		logger.Info(i18n.T("logger.autodeps.info.added"),
+			"package", pkg.PkgName, "libraries", len(libs), "depends", strings.Join(added, " "))
+	}
+
+	return nil
+}
+
+// Resolve maps libs to their providers, in order and without duplicates.
+// Unresolved libraries are warned about and skipped.
+func Resolve(resolver Resolver, libs []binary.Library) []string {
+	seen := make(map[string]bool)
+
+	var deps []string
+
+	for _, lib := range libs {
+		dep, ok := resolver.Resolve(lib)
+		if !ok {
+			logger.Warn(i18n.T("logger.autodeps.warn.unresolved"), "soname", lib.Soname)
+
+			continue
+		}
+
+		logger.Debug(i18n.T("logger.autodeps.debug.resolved"), "soname", lib.Soname, "depends", dep)
+
+		if !seen[dep] {
+			seen[dep] = true
+
+			deps = append(deps, dep)
+		}
+	}
+
+	return deps
+}
+
+// Merge appends to pkg.Depends the entries of deps that are neither the
+// package itself nor already declared, and returns them.
+func Merge(pkg *pkgbuild.PKGBUILD, deps []string) []string {
+	declared := map[string]bool{pkg.PkgName: true}
+	for _, dep := range pkg.Depends {
+		declared[depName(dep)] = true
+	}
+
+	var added []string
+
+	for _, dep := range deps {
+		if declared[depName(dep)] {
+			continue
+		}
+
+		declared[depName(dep)] = true
+
+		added = append(added, dep)
+	}
+
+	pkg.Depends = append(pkg.Depends, added...)
+
+	return added
+}
+
+// depName strips the version constraint from a dependency.
+func depName(dep string) string {
+	if index := strings.IndexAny(dep, "<>="); index >= 0 {
+		return strings.TrimSpace(dep[:index])
+	}
+
+	return strings.TrimSpace(dep)
+}
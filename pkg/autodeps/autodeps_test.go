@@ -0,0 +1,195 @@
+package autodeps
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/M0Rf30/yap/v2/pkg/aptcache"
+	"github.com/M0Rf30/yap/v2/pkg/binary"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/logger"
+	"github.com/M0Rf30/yap/v2/pkg/pkgbuild"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func lib(soname string) binary.Library {
+	return binary.Library{Soname: soname, Is64: true}
+}
+
+func TestDpkgResolver(t *testing.T) {
+	aptcache.StoreGlobal(aptcache.NewEmptyCache())
+
+	root := t.TempDir()
+	info := filepath.Join(root, dpkgInfoDir)
+
+	writeFile(t, filepath.Join(info, "libssl3t64:amd64.shlibs"),
+		"libssl 3 libssl3t64 (>= 3.0.0)\nudeb: libssl 3 libssl3-udeb\n")
+	writeFile(t, filepath.Join(info, "libfoo:amd64.shlibs"), "libfoo 1.2 libfoo0 | libfoo-alt\n")
+	writeFile(t, filepath.Join(info, "zlib1g:amd64.symbols"),
+		"libz.so.1 zlib1g #MINVER#\n* Build-Depends-Package: zlib1g-dev\n deflate@ZLIB_1.2.0 1:1.2.0\n")
+	writeFile(t, filepath.Join(info, "libbar1:amd64.list"),
+		"/.\n/usr/lib/x86_64-linux-gnu\n/usr/lib/x86_64-linux-gnu/libbar.so.1\n")
+
+	resolver := NewResolver("apt", root, "x86_64")
+
+	tests := map[string]string{
+		"libssl.so.3":     "libssl3t64",
+		"libfoo-1.2.so":   "libfoo0",
+		"libz.so.1":       "zlib1g",
+		"libbar.so.1":     "libbar1",
+		"libmissing.so.1": "",
+	}
+
+	for soname, want := range tests {
+		dep, ok := resolver.Resolve(lib(soname))
+		assert.Equal(t, want != "", ok, soname)
+		assert.Equal(t, want, dep, soname)
+	}
+}
+
+func TestDpkgResolverAptIndex(t *testing.T) {
+	cache := aptcache.NewEmptyCache()
+	cache.AddEntry(&aptcache.PackageInfo{Name: "libcurl4t64", Architecture: "arm64", HasCandidate: true})
+	cache.AddEntry(&aptcache.PackageInfo{Name: "libfoo2-1", Architecture: "arm64", HasCandidate: true})
+	cache.AddEntry(&aptcache.PackageInfo{Name: "libbar-1.2", Architecture: "arm64", HasCandidate: true})
+	aptcache.StoreGlobal(cache)
+
+	resolver := NewResolver("apt", t.TempDir(), "aarch64")
+
+	tests := map[string]string{
+		"libcurl.so.4":    "libcurl4t64",
+		"libfoo2.so.1":    "libfoo2-1",
+		"libbar-1.2.so":   "libbar-1.2",
+		"libmissing.so.1": "",
+	}
+
+	for soname, want := range tests {
+		dep, ok := resolver.Resolve(lib(soname))
+		assert.Equal(t, want != "", ok, soname)
+		assert.Equal(t, want, dep, soname)
+	}
+}
+
+func TestApkResolverInstalledDB(t *testing.T) {
+	root := t.TempDir()
+
+	writeFile(t, filepath.Join(root, apkInstalledDB),
+		"C:Q1abc=\nP:zlib\nV:1.3.1-r0\np:so:libz.so.1=1.3.1\n\nP:musl\np:so:libc.musl-x86_64.so.1=1\n")
+
+	resolver := NewResolver("apk", root, "x86_64")
+
+	dep, ok := resolver.Resolve(lib("libz.so.1"))
+	assert.True(t, ok)
+	assert.Equal(t, "zlib", dep)
+
+	dep, ok = resolver.Resolve(lib("libunknown.so.7"))
+	assert.True(t, ok)
+	assert.Equal(t, "so:libunknown.so.7", dep)
+}
+
+func TestPacmanResolver(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, pacmanLocalDir, "zlib-1:1.3.1-2")
+
+	writeFile(t, filepath.Join(dir, "desc"), "%NAME%\nzlib\n\n%VERSION%\n1:1.3.1-2\n")
+	writeFile(t, filepath.Join(dir, "files"),
+		"%FILES%\nusr/\nusr/lib/\nusr/lib/libz.so\nusr/lib/libz.so.1\n\n%BACKUP%\n")
+
+	resolver := NewResolver("pacman", root, "x86_64")
+
+	dep, ok := resolver.Resolve(lib("libz.so.1"))
+	assert.True(t, ok)
+	assert.Equal(t, "zlib", dep)
+
+	_, ok = resolver.Resolve(lib("libunknown.so.7"))
+	assert.False(t, ok)
+}
+
+func TestRPMResolverFallsBackToCapability(t *testing.T) {
+	resolver := NewResolver("yum", t.TempDir(), "x86_64")
+
+	dep, ok := resolver.Resolve(lib("libyap-autodeps-test.so.9"))
+	assert.True(t, ok)
+	assert.Equal(t, "libyap-autodeps-test.so.9()(64bit)", dep)
+
+	dep, ok = resolver.Resolve(binary.Library{Soname: "libyap-autodeps-test.so.9"})
+	assert.True(t, ok)
+	assert.Equal(t, "libyap-autodeps-test.so.9", dep)
+}
+
+func TestNewResolverUnknown(t *testing.T) {
+	assert.Nil(t, NewResolver("unknown", "/", "x86_64"))
+}
+
+type mapResolver map[string]string
+
+func (m mapResolver) Resolve(lib binary.Library) (string, bool) {
+	dep, ok := m[lib.Soname]
+
+	return dep, ok
+}
+
+func TestResolve(t *testing.T) {
+	resolver := mapResolver{"libssl.so.3": "libssl3", "libcrypto.so.3": "libssl3", "libz.so.1": "zlib1g"}
+
+	deps := Resolve(resolver, []binary.Library{
+		lib("libcrypto.so.3"), lib("libmissing.so.1"), lib("libssl.so.3"), lib("libz.so.1"),
+	})
+
+	assert.Equal(t, []string{"libssl3", "zlib1g"}, deps)
+}
+
+func TestMerge(t *testing.T) {
+	pkg := &pkgbuild.PKGBUILD{PkgName: "libfoo1", Depends: []string{"zlib1g>=1.2"}}
+
+	added := Merge(pkg, []string{"zlib1g", "libc6", "libfoo1", "libc6"})
+
+	assert.Equal(t, []string{"libc6"}, added)
+	assert.Equal(t, []string{"zlib1g>=1.2", "libc6"}, pkg.Depends)
+}
+
+func TestApplyDisabled(t *testing.T) {
+	pkg := &pkgbuild.PKGBUILD{
+		Distro:          "ubuntu",
+		PackageDir:      filepath.Join(t.TempDir(), "missing"),
+		AutoDepsEnabled: false,
+	}
+
+	require.NoError(t, Apply(pkg))
+	assert.Empty(t, pkg.Depends)
+}
+
+func TestApplyWithoutResolver(t *testing.T) {
+	var out bytes.Buffer
+
+	logger.SetWriter(&out)
+	defer logger.SetWriter(os.Stderr)
+
+	noResolverWarning = sync.Once{}
+
+	for range 2 {
+		pkg := &pkgbuild.PKGBUILD{
+			Distro:          "void",
+			PackageDir:      filepath.Join(t.TempDir(), "missing"),
+			AutoDepsEnabled: true,
+		}
+
+		require.NoError(t, Apply(pkg))
+		assert.Empty(t, pkg.Depends)
+	}
+
+	assert.Equal(t, 1, strings.Count(out.String(), i18n.T("logger.autodeps.warn.no_resolver")))
+}
@@ -0,0 +1,363 @@
+package autodeps
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/M0Rf30/yap/v2/pkg/apkindex"
+	"github.com/M0Rf30/yap/v2/pkg/aptcache"
+	"github.com/M0Rf30/yap/v2/pkg/binary"
+	"github.com/M0Rf30/yap/v2/pkg/dnfcache"
+	"github.com/M0Rf30/yap/v2/pkg/pacmandb"
+)
+
+const (
+	dpkgInfoDir     = "var/lib/dpkg/info"
+	apkInstalledDB  = "lib/apk/db/installed"
+	pacmanLocalDir  = "var/lib/pacman/local"
+	rpm64BitSuffix  = "()(64bit)"
+	apkSonamePrefix = "so:"
+	debTime64Suffix = "t64"
+)
+
+// dpkgResolver maps sonames through the shlibs and symbols control files
+// of the installed packages, like dpkg-shlibdeps, falling back to the
+// owner of a file named after the soname and then to the package the
+// Debian library naming policy gives the soname in the apt index for the
+// target architecture.
+type dpkgResolver struct {
+	infoDir string
+	arch    string
+	once    sync.Once
+	shlibs  map[string]string // soname → package, from *.shlibs and *.symbols
+	files   map[string]string // library file name → package, from *.list
+}
+
+func newDpkgResolver(root, arch string) *dpkgResolver {
+	return &dpkgResolver{infoDir: filepath.Join(root, dpkgInfoDir), arch: arch}
+}
+
+func (r *dpkgResolver) Resolve(lib binary.Library) (string, bool) {
+	r.once.Do(r.load)
+
+	if pkg, ok := r.shlibs[lib.Soname]; ok {
+		return pkg, true
+	}
+
+	if pkg, ok := r.files[lib.Soname]; ok {
+		return pkg, true
+	}
+
+	return r.resolveIndex(lib.Soname)
+}
+
+// resolveIndex looks up the library package named after soname, or its
+// 64-bit time_t variant, in the apt index for the target architecture,
+// following virtual packages to their provider.
+func (r *dpkgResolver) resolveIndex(soname string) (string, bool) {
+	name := debianLibraryPackage(soname)
+	if name == "" {
+		return "", false
+	}
+
+	cache := aptcache.Load()
+
+	for _, candidate := range []string{name, name + debTime64Suffix} {
+		if info, ok := cache.Lookup(candidate + ":" + r.arch); ok && info.HasCandidate {
+			return info.Name, true
+		}
+
+		if provider := cache.ResolveVirtual(candidate); provider != candidate {
+			return provider, true
+		}
+	}
+
+	return "", false
+}
+
+// debianLibraryPackage returns the package name the Debian policy gives a
+// shared library: libfoo1 for libfoo.so.1, libfoo2-1 for libfoo2.so.1 and
+// libfoo-1.2 for libfoo-1.2.so.
+func debianLibraryPackage(soname string) string {
+	var name string
+
+	switch library, version, ok := strings.Cut(soname, ".so."); {
+	case ok && library != "":
+		name = library + version
+		if last := library[len(library)-1]; last >= '0' && last <= '9' {
+			name = library + "-" + version
+		}
+	case strings.HasSuffix(soname, ".so"):
+		name = strings.TrimSuffix(soname, ".so")
+	}
+
+	return strings.ReplaceAll(strings.ToLower(name), "_", "-")
+}
+
+func (r *dpkgResolver) load() {
+	r.shlibs = make(map[string]string)
+	r.files = make(map[string]string)
+
+	entries, err := os.ReadDir(r.infoDir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		path := filepath.Join(r.infoDir, entry.Name())
+
+		switch filepath.Ext(entry.Name()) {
+		case ".shlibs":
+			forEachLine(path, r.addShlibsLine)
+		case ".symbols":
+			forEachLine(path, r.addSymbolsLine)
+		case ".list":
+			pkg := dpkgPackage(entry.Name())
+
+			forEachLine(path, func(line string) {
+				if name := filepath.Base(line); strings.Contains(name, ".so") {
+					setDefault(r.files, name, pkg)
+				}
+			})
+		}
+	}
+}
+
+// addShlibsLine parses a "[type:] library version dependencies" line. The
+// soname is library.so.version, or library-version.so for libraries that
+// carry their version before the extension.
+func (r *dpkgResolver) addShlibsLine(line string) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || strings.HasPrefix(fields[0], "#") || strings.HasSuffix(fields[0], ":") {
+		return
+	}
+
+	library, version := fields[0], fields[1]
+	pkg := firstDependency(strings.Join(fields[2:], " "))
+
+	setDefault(r.shlibs, library+".so."+version, pkg)
+	setDefault(r.shlibs, library+"-"+version+".so", pkg)
+}
+
+// addSymbolsLine parses the "soname dependencies" header of a symbols
+// file; symbol lines are indented and skipped.
+func (r *dpkgResolver) addSymbolsLine(line string) {
+	if line == "" || strings.ContainsRune(" \t*|#", rune(line[0])) {
+		return
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return
+	}
+
+	setDefault(r.shlibs, fields[0], firstDependency(strings.Join(fields[1:], " ")))
+}
+
+// dpkgPackage returns the package of an info file name such as
+// "zlib1g:amd64.list".
+func dpkgPackage(fileName string) string {
+	name := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	name, _, _ = strings.Cut(name, ":")
+
+	return name
+}
+
+// firstDependency returns the package name of the first alternative of a
+// Debian dependency field, e.g. "libc6" for "libc6 (>= 2.34) | libc6.1".
+func firstDependency(deps string) string {
+	first, _, _ := strings.Cut(deps, ",")
+	first, _, _ = strings.Cut(first, "|")
+
+	fields := strings.Fields(first)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	return fields[0]
+}
+
+// rpmResolver maps sonames to the package providing the matching
+// capability in the dnf repository index. Unknown sonames are returned as
+// the capability itself, which rpm resolves at install time as it does for
+// the requirements rpmbuild generates.
+type rpmResolver struct{}
+
+func newRPMResolver() rpmResolver {
+	return rpmResolver{}
+}
+
+func (rpmResolver) Resolve(lib binary.Library) (string, bool) {
+	capability := lib.Soname
+	if lib.Is64 {
+		capability += rpm64BitSuffix
+	}
+
+	if name := dnfcache.Load().ResolveVirtual(capability); name != capability {
+		return name, true
+	}
+
+	return capability, true
+}
+
+// apkResolver maps sonames to the package providing "so:<soname>" in the
+// APKINDEX or, failing that, in the installed database. Unknown sonames
+// are returned as the so: capability, which abuild emits as well.
+type apkResolver struct {
+	installedPath string
+	once          sync.Once
+	provides      map[string]string // capability → package
+}
+
+func newApkResolver(root string) *apkResolver {
+	return &apkResolver{installedPath: filepath.Join(root, apkInstalledDB)}
+}
+
+func (r *apkResolver) Resolve(lib binary.Library) (string, bool) {
+	capability := apkSonamePrefix + lib.Soname
+
+	if idx := apkindex.Load(); idx != nil {
+		if pkg, ok := idx.ResolveVirtual(capability); ok {
+			return pkg.Name, true
+		}
+	}
+
+	r.once.Do(r.load)
+
+	if pkg, ok := r.provides[capability]; ok {
+		return pkg, true
+	}
+
+	return capability, true
+}
+
+func (r *apkResolver) load() {
+	r.provides = make(map[string]string)
+
+	var pkg string
+
+	forEachLine(r.installedPath, func(line string) {
+		tag, value, found := strings.Cut(line, ":")
+
+		switch {
+		case !found:
+			pkg = ""
+		case tag == "P":
+			pkg = value
+		case tag == "p" && pkg != "":
+			for _, capability := range strings.Fields(value) {
+				name, _, _ := strings.Cut(capability, "=")
+				setDefault(r.provides, name, pkg)
+			}
+		}
+	})
+}
+
+// pacmanResolver maps sonames to the installed package owning a file named
+// after the soname, falling back to the package of the sync databases for
+// the target architecture that provides the library.
+type pacmanResolver struct {
+	localDir  string
+	arch      string
+	once      sync.Once
+	files     map[string]string // library file name → package
+	providers map[string]string // soname-wordsize → package, from the sync databases
+}
+
+func newPacmanResolver(root, arch string) *pacmanResolver {
+	return &pacmanResolver{localDir: filepath.Join(root, pacmanLocalDir), arch: arch}
+}
+
+func (r *pacmanResolver) Resolve(lib binary.Library) (string, bool) {
+	r.once.Do(r.load)
+
+	if pkg, ok := r.files[lib.Soname]; ok {
+		return pkg, true
+	}
+
+	wordSize := "-32"
+	if lib.Is64 {
+		wordSize = "-64"
+	}
+
+	pkg, ok := r.providers[lib.Soname+wordSize]
+
+	return pkg, ok
+}
+
+func (r *pacmanResolver) load() {
+	r.files = make(map[string]string)
+	r.providers = pacmandb.LibraryProviders(r.arch)
+
+	entries, err := os.ReadDir(r.localDir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		dir := filepath.Join(r.localDir, entry.Name())
+
+		names := pacmanSection(filepath.Join(dir, "desc"), "%NAME%")
+		if len(names) == 0 {
+			continue
+		}
+
+		for _, path := range pacmanSection(filepath.Join(dir, "files"), "%FILES%") {
+			if name := filepath.Base(path); strings.Contains(name, ".so") {
+				setDefault(r.files, name, names[0])
+			}
+		}
+	}
+}
+
+// pacmanSection returns the lines of a "%SECTION%" block of a local
+// database file.
+func pacmanSection(path, section string) []string {
+	var (
+		lines  []string
+		inside bool
+	)
+
+	forEachLine(path, func(line string) {
+		switch {
+		case line == section:
+			inside = true
+		case line == "" || strings.HasPrefix(line, "%"):
+			inside = false
+		case inside:
+			lines = append(lines, line)
+		}
+	})
+
+	return lines
+}
+
+// forEachLine calls fn with each line of the file at path, ignoring a
+// missing or unreadable file.
+func forEachLine(path string, fn func(line string)) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return
+	}
+
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fn(scanner.Text())
+	}
+}
+
+// setDefault sets m[key] unless it is already set or value is empty.
+func setDefault(m map[string]string, key, value string) {
+	if _, ok := m[key]; !ok && value != "" {
+		m[key] = value
+	}
+}
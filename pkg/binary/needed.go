@@ -0,0 +1,81 @@
+package binary //nolint:revive // see strip.go
+
+import (
+	"debug/elf"
+	"io/fs"
+	"path/filepath"
+	"sort"
+)
+
+// Library is a shared library an ELF file links against.
+type Library struct {
+	// Soname is the DT_NEEDED entry, e.g. "libz.so.1".
+	Soname string
+	// Is64 reports whether the linking file is ELFCLASS64, which RPM
+	// encodes as a "()(64bit)" capability suffix.
+	Is64 bool
+}
+
+// NeededLibraries returns the DT_NEEDED entries of the ELF files under root,
+// sorted by soname, leaving out the libraries root ships itself (matched
+// by DT_SONAME or file name). Non-ELF files and symlinks are ignored.
+func NeededLibraries(root string) ([]Library, error) {
+	needed := make(map[Library]bool)
+	shipped := make(map[string]bool)
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		shipped[entry.Name()] = true
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		file, err := elf.Open(path)
+		if err != nil {
+			return nil //nolint:nilerr // not an ELF file
+		}
+
+		defer func() { _ = file.Close() }()
+
+		sonames, _ := file.DynString(elf.DT_SONAME)
+		for _, soname := range sonames {
+			shipped[soname] = true
+		}
+
+		libs, _ := file.DynString(elf.DT_NEEDED)
+		for _, soname := range libs {
+			needed[Library{Soname: soname, Is64: file.Class == elf.ELFCLASS64}] = true
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	libs := make([]Library, 0, len(needed))
+
+	for lib := range needed {
+		if !shipped[lib.Soname] {
+			libs = append(libs, lib)
+		}
+	}
+
+	sort.Slice(libs, func(i, j int) bool {
+		if libs[i].Soname != libs[j].Soname {
+			return libs[i].Soname < libs[j].Soname
+		}
+
+		return !libs[i].Is64 && libs[j].Is64
+	})
+
+	return libs, nil
+}
@@ -0,0 +1,152 @@
+package binary_test
+
+import (
+	"debug/elf"
+	enc "encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/M0Rf30/yap/v2/pkg/binary"
+)
+
+// writeDynamicELF writes an ELF64 shared object holding only the .dynstr,
+// .dynamic and .shstrtab sections, enough for DynString to read soname
+// and needed.
+func writeDynamicELF(t *testing.T, path, soname string, needed ...string) {
+	t.Helper()
+
+	le := enc.LittleEndian
+
+	dynstr := []byte{0}
+	addString := func(s string) uint64 {
+		offset := uint64(len(dynstr))
+		dynstr = append(append(dynstr, s...), 0)
+
+		return offset
+	}
+
+	var dynamic []byte
+
+	addEntry := func(tag elf.DynTag, value uint64) {
+		dynamic = le.AppendUint64(dynamic, uint64(tag))
+		dynamic = le.AppendUint64(dynamic, value)
+	}
+
+	for _, lib := range needed {
+		addEntry(elf.DT_NEEDED, addString(lib))
+	}
+
+	if soname != "" {
+		addEntry(elf.DT_SONAME, addString(soname))
+	}
+
+	addEntry(elf.DT_NULL, 0)
+
+	shstrtab := []byte("\x00.dynstr\x00.dynamic\x00.shstrtab\x00")
+
+	align := func(n int) int { return (n + 7) &^ 7 }
+	dynstrOff := 64
+	dynamicOff := align(dynstrOff + len(dynstr))
+	shstrtabOff := dynamicOff + len(dynamic)
+	shOff := align(shstrtabOff + len(shstrtab))
+
+	buf := make([]byte, shOff+4*64)
+	copy(buf, []byte{0x7f, 'E', 'L', 'F', 2, 1, 1}) // ELFCLASS64, little-endian, EV_CURRENT
+	le.PutUint16(buf[16:], uint16(elf.ET_DYN))
+	le.PutUint16(buf[18:], uint16(elf.EM_X86_64))
+	le.PutUint32(buf[20:], 1)
+	le.PutUint64(buf[40:], uint64(shOff))
+	le.PutUint16(buf[52:], 64) // e_ehsize
+	le.PutUint16(buf[58:], 64) // e_shentsize
+	le.PutUint16(buf[60:], 4)  // e_shnum
+	le.PutUint16(buf[62:], 3)  // e_shstrndx
+
+	copy(buf[dynstrOff:], dynstr)
+	copy(buf[dynamicOff:], dynamic)
+	copy(buf[shstrtabOff:], shstrtab)
+
+	section := func(index int, name uint32, typ elf.SectionType, offset, size int, link uint32, entsize uint64) {
+		sh := buf[shOff+index*64:]
+		le.PutUint32(sh[0:], name)
+		le.PutUint32(sh[4:], uint32(typ))
+		le.PutUint64(sh[24:], uint64(offset))
+		le.PutUint64(sh[32:], uint64(size))
+		le.PutUint32(sh[40:], link)
+		le.PutUint64(sh[48:], 1)
+		le.PutUint64(sh[56:], entsize)
+	}
+
+	section(1, 1, elf.SHT_STRTAB, dynstrOff, len(dynstr), 0, 0)
+	section(2, 9, elf.SHT_DYNAMIC, dynamicOff, len(dynamic), 1, 16)
+	section(3, 18, elf.SHT_STRTAB, shstrtabOff, len(shstrtab), 0, 0)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("create dir: %v", err)
+	}
+
+	if err := os.WriteFile(path, buf, 0o755); err != nil {
+		t.Fatalf("write dynamic ELF: %v", err)
+	}
+}
+
+func TestNeededLibraries(t *testing.T) {
+	root := t.TempDir()
+
+	writeDynamicELF(t, filepath.Join(root, "usr/bin/app"), "", "libz.so.1", "libfoo.so.2", "libc.so.6")
+	writeDynamicELF(t, filepath.Join(root, "usr/lib/libfoo.so.2.0.0"), "libfoo.so.2", "libc.so.6", "libbar.so")
+	// Shipped libraries are also matched by file name.
+	writeDynamicELF(t, filepath.Join(root, "usr/lib/libbar.so"), "")
+
+	if err := os.WriteFile(filepath.Join(root, "usr/bin/script"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink("libfoo.so.2.0.0", filepath.Join(root, "usr/lib/libfoo.so")); err != nil {
+		t.Fatal(err)
+	}
+
+	libs, err := binary.NeededLibraries(root)
+	if err != nil {
+		t.Fatalf("NeededLibraries() error = %v", err)
+	}
+
+	want := []binary.Library{
+		{Soname: "libc.so.6", Is64: true},
+		{Soname: "libz.so.1", Is64: true},
+	}
+	if !reflect.DeepEqual(libs, want) {
+		t.Errorf("NeededLibraries() = %v, want %v", libs, want)
+	}
+}
+
+func TestNeededLibrariesMissingRoot(t *testing.T) {
+	if _, err := binary.NeededLibraries(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected an error for a missing root")
+	}
+}
+
+func TestSharedLibraries(t *testing.T) {
+	root := t.TempDir()
+
+	writeDynamicELF(t, filepath.Join(root, "usr/bin/app"), "", "libfoo.so.1")
+	writeDynamicELF(t, filepath.Join(root, "usr/lib/libfoo.so.1.2.3"), "libfoo.so.1", "libc.so.6")
+
+	if err := os.Symlink("libfoo.so.1.2.3", filepath.Join(root, "usr/lib/libfoo.so.1")); err != nil {
+		t.Fatal(err)
+	}
+
+	libs, err := binary.SharedLibraries(root)
+	if err != nil {
+		t.Fatalf("SharedLibraries() error = %v", err)
+	}
+
+	want := []binary.SharedLibrary{{
+		Library: binary.Library{Soname: "libfoo.so.1", Is64: true},
+		Path:    "usr/lib/libfoo.so.1.2.3",
+	}}
+	if !reflect.DeepEqual(libs, want) {
+		t.Errorf("SharedLibraries() = %v, want %v", libs, want)
+	}
+}
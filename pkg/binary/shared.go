@@ -0,0 +1,57 @@
+package binary //nolint:revive // see strip.go
+
+import (
+	"debug/elf"
+	"io/fs"
+	"path/filepath"
+)
+
+// SharedLibrary is a shared library shipped under a root.
+type SharedLibrary struct {
+	Library
+	// Path is the file path relative to the root, e.g. "usr/lib/libz.so.1.3.1".
+	Path string
+}
+
+// SharedLibraries returns the ELF files under root that declare a
+// DT_SONAME, in walk order. Symlinks are ignored, so a library is reported
+// once under its real file name.
+func SharedLibraries(root string) ([]SharedLibrary, error) {
+	var libs []SharedLibrary
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		file, err := elf.Open(path)
+		if err != nil {
+			return nil //nolint:nilerr // not an ELF file
+		}
+
+		defer func() { _ = file.Close() }()
+
+		sonames, _ := file.DynString(elf.DT_SONAME)
+		if len(sonames) == 0 {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		libs = append(libs, SharedLibrary{
+			Library: Library{Soname: sonames[0], Is64: file.Class == elf.ELFCLASS64},
+			Path:    filepath.ToSlash(rel),
+		})
+
+		return nil
+	})
+
+	return libs, err
+}
@@ -0,0 +1,92 @@
+package binary //nolint:revive // see strip.go
+
+import (
+	"debug/dwarf"
+	"debug/elf"
+	"errors"
+	"io"
+	"path/filepath"
+	"slices"
+)
+
+// SourceFiles returns the source files the DWARF line tables of the ELF
+// file at path refer to, as cleaned absolute paths in sorted order. Files
+// without DWARF data have no sources.
+func SourceFiles(path string) ([]string, error) {
+	file, err := elf.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	data, err := file.DWARF()
+	if err != nil {
+		return nil, nil //nolint:nilerr // no debug information
+	}
+
+	seen := make(map[string]bool)
+	reader := data.Reader()
+
+	for {
+		entry, err := reader.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		if entry == nil {
+			break
+		}
+
+		if entry.Tag != dwarf.TagCompileUnit {
+			reader.SkipChildren()
+
+			continue
+		}
+
+		lines, err := data.LineReader(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		if lines != nil {
+			if err := collectLineFiles(lines, seen); err != nil {
+				return nil, err
+			}
+		}
+
+		reader.SkipChildren()
+	}
+
+	sources := make([]string, 0, len(seen))
+	for source := range seen {
+		sources = append(sources, source)
+	}
+
+	slices.Sort(sources)
+
+	return sources, nil
+}
+
+// collectLineFiles adds the absolute file names referenced by the rows of a
+// line table to seen.
+func collectLineFiles(lines *dwarf.LineReader, seen map[string]bool) error {
+	var row dwarf.LineEntry
+
+	for {
+		err := lines.Next(&row)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if row.File != nil && filepath.IsAbs(row.File.Name) {
+			seen[filepath.Clean(row.File.Name)] = true
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package binary_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/M0Rf30/yap/v2/pkg/binary"
+)
+
+func TestSourceFiles(t *testing.T) {
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("no C compiler available")
+	}
+
+	dir := t.TempDir()
+
+	source := filepath.Join(dir, "foo.c")
+	if err := os.WriteFile(source, []byte("int foo(void) { return 42; }\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	object := filepath.Join(dir, "foo.o")
+
+	cmd := exec.CommandContext(t.Context(), cc, "-g", "-c", "foo.c", "-o", object)
+	cmd.Dir = dir
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("cc: %v\n%s", err, out)
+	}
+
+	sources, err := binary.SourceFiles(object)
+	if err != nil {
+		t.Fatalf("SourceFiles() error = %v", err)
+	}
+
+	if !slices.Contains(sources, source) {
+		t.Errorf("SourceFiles() = %v, want it to list %s", sources, source)
+	}
+
+	if _, err := binary.SourceFiles(source); err == nil {
+		t.Error("SourceFiles() on a non-ELF file must fail")
+	}
+}
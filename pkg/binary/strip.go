@@ -237,5 +237,16 @@ func stripWithEnv(path string, env map[string]string, args ...string) error {
 
 	args = append(args, path)
 
-	return shell.Exec(context.Background(), false, "", stripCmd, args...)
+	restore := files.KeepCapabilities(path)
+
+	if err := shell.Exec(context.Background(), false, "", stripCmd, args...); err != nil {
+		return err
+	}
+
+	if err := restore(); err != nil {
+		logger.Warn(i18n.T("logger.binary.warn.failed_to_restore_capabilities"),
+			"binary", path, "error", err)
+	}
+
+	return nil
 }
@@ -2,6 +2,7 @@
 package builder
 
 import (
+	"bytes"
 	"context"
 	"os"
 	"os/exec"
@@ -15,6 +16,7 @@ import (
 	"github.com/M0Rf30/yap/v2/pkg/constants"
 	"github.com/M0Rf30/yap/v2/pkg/errors"
 	"github.com/M0Rf30/yap/v2/pkg/files"
+	"github.com/M0Rf30/yap/v2/pkg/git"
 	"github.com/M0Rf30/yap/v2/pkg/i18n"
 	"github.com/M0Rf30/yap/v2/pkg/logger"
 	"github.com/M0Rf30/yap/v2/pkg/pkgbuild"
@@ -23,16 +25,13 @@ import (
 	"github.com/M0Rf30/yap/v2/pkg/source"
 )
 
-// scriptPrologue mirrors makepkg's run_function(): every PKGBUILD function
-// (prepare/build/check/package) is executed with errexit and xtrace enabled
-// and an implicit `cd "${srcdir}"`, so function bodies can assume they start
-// inside the source directory exactly like under makepkg.
-const scriptPrologue = "  set -e\n  set -x\n  cd \"${srcdir}\"\n"
-
 // Builder maps PKGBUILD to generic functions aimed at artifacts generation.
 type Builder struct {
 	PKGBUILD      *pkgbuild.PKGBUILD
 	SkipHashCheck bool
+	// SkipPGPCheck disables verification of detached source signatures
+	// against the PKGBUILD validpgpkeys.
+	SkipPGPCheck bool
 	// NoCheck skips the check() function, mirroring makepkg's --nocheck.
 	NoCheck bool
 }
@@ -76,6 +75,13 @@ func (builder *Builder) Compile(ctx context.Context, noBuild bool) error {
 			"path", builder.PKGBUILD.SourceDir, "error", err)
 	}
 
+	// pkgver() runs right after the sources are in place, exactly like
+	// makepkg, so every later stage and every packer (artifact names, SBOMs,
+	// changelog) sees the computed version.
+	if err := builder.updatePkgVer(ctx); err != nil {
+		return err
+	}
+
 	if !noBuild {
 		return builder.runBuildStages(ctx)
 	}
@@ -150,7 +156,7 @@ func (builder *Builder) processFunction(ctx context.Context, pkgbuildFunction, m
 	// produce no output on failure — only an exit status).
 	// Pass pkgEnv so the interpreter receives per-package dirs/names without
 	// relying on the (racy) global os environment.
-	err := shell.RunScriptWithPackage(ctx, scriptPrologue+preamble+pkgbuildFunction, pkgName, pkgEnv)
+	err := shell.RunScriptWithPackage(ctx, common.ScriptPrologue+preamble+pkgbuildFunction, pkgName, pkgEnv)
 	if err != nil {
 		return errors.Wrap(err, errors.ErrTypeBuild, i18n.T("errors.build.build_stage_failed")).
 			WithContext("package", pkgName).
@@ -285,7 +291,7 @@ func (builder *Builder) compileSplitPackages(ctx context.Context) error {
 		}
 
 		if err := shell.RunScriptInFakeroot(
-			ctx, scriptPrologue+preamble+funcBody, subName, pkgEnv,
+			ctx, common.ScriptPrologue+preamble+funcBody, subName, pkgEnv,
 		); err != nil {
 			return errors.Wrap(err, errors.ErrTypeBuild, i18n.T("errors.build.build_stage_failed")).
 				WithContext("package", subName).
@@ -337,7 +343,7 @@ func (builder *Builder) processFunctionInFakeroot(ctx context.Context, pkgbuildF
 
 	preamble := builder.PKGBUILD.BuildScriptPreamble()
 
-	err := shell.RunScriptInFakeroot(ctx, scriptPrologue+preamble+pkgbuildFunction, pkgName, pkgEnv)
+	err := shell.RunScriptInFakeroot(ctx, common.ScriptPrologue+preamble+pkgbuildFunction, pkgName, pkgEnv)
 	if err != nil {
 		return errors.Wrap(err, errors.ErrTypeBuild, i18n.T("errors.build.build_stage_failed")).
 			WithContext("package", pkgName).
@@ -368,6 +374,9 @@ func (builder *Builder) getSources(ctx context.Context) error {
 	g, gctx := errgroup.WithContext(ctx)
 	g.SetLimit(maxWorkers)
 
+	// Each worker records the mirror its source came from at its own index.
+	builder.PKGBUILD.SourceDownloads = make([]string, len(builder.PKGBUILD.SourceURI))
+
 	for index, sourceURI := range builder.PKGBUILD.SourceURI {
 		sourceObj := source.Source{
 			StartDir:       builder.PKGBUILD.StartDir,
@@ -378,6 +387,7 @@ func (builder *Builder) getSources(ctx context.Context) error {
 			SrcDir:         builder.PKGBUILD.SourceDir,
 			SourceItemPath: "",
 			SkipHashCheck:  builder.SkipHashCheck,
+			Mirrors:        builder.sourceMirrors(index),
 		}
 
 		g.Go(func() error {
@@ -385,7 +395,7 @@ func (builder *Builder) getSources(ctx context.Context) error {
 				return err
 			}
 
-			if err := sourceObj.Get(); err != nil {
+			if err := sourceObj.Get(gctx); err != nil {
 				return errors.Wrap(err, errors.ErrTypeBuild,
 					i18n.T("errors.build.failed_to_retrieve_source")).
 					WithContext("package", pkgName).
@@ -396,11 +406,84 @@ func (builder *Builder) getSources(ctx context.Context) error {
 					WithOperation("source_processing")
 			}
 
+			builder.PKGBUILD.SourceDownloads[index] = sourceObj.DownloadURL
+
 			return nil
 		})
 	}
 
-	return g.Wait()
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	return builder.verifySourceSignatures()
+}
+
+// sourceMirrors returns the source_mirrors alternatives declared for the
+// source at index.
+func (builder *Builder) sourceMirrors(index int) []string {
+	if index >= len(builder.PKGBUILD.SourceMirrors) || builder.PKGBUILD.SourceMirrors[index] == "" {
+		return nil
+	}
+
+	return strings.Split(builder.PKGBUILD.SourceMirrors[index], "|")
+}
+
+// verifySourceSignatures checks the detached .sig/.sign/.asc signatures
+// shipped in the source array against the PKGBUILD validpgpkeys. It runs
+// once every source is on disk, since a file and its signature may be
+// fetched by different workers.
+func (builder *Builder) verifySourceSignatures() error {
+	if builder.SkipPGPCheck {
+		logger.Info(i18n.T("logger.skip_pgp_check"),
+			"package", builder.PKGBUILD.PkgName)
+
+		return nil
+	}
+
+	return source.VerifySignatures(builder.PKGBUILD.StartDir,
+		builder.PKGBUILD.SourceURI, builder.PKGBUILD.ValidPGPKeys)
+}
+
+// updatePkgVer executes the PKGBUILD pkgver() function, if any, and stores its
+// output as the package version. The function runs with the same prologue,
+// preamble and environment as the other stages; read-only git queries are
+// answered in-process via git.ExecHandler when no git binary is installed.
+func (builder *Builder) updatePkgVer(ctx context.Context) error {
+	if builder.PKGBUILD.PkgVerFunc == "" {
+		return nil
+	}
+
+	pkgName := builder.PKGBUILD.PkgName
+	oldVer := builder.PKGBUILD.PkgVer
+
+	logger.Info(i18n.T("logger.builder.info.updating_pkgver"), "package", pkgName)
+
+	var stdout bytes.Buffer
+
+	err := shell.RunScriptCapture(ctx,
+		common.ScriptPrologue+builder.PKGBUILD.BuildScriptPreamble()+builder.PKGBUILD.PkgVerFunc,
+		pkgName, &stdout, []shell.ExecMiddleware{git.ExecHandler},
+		builder.PKGBUILD.BuildEnvironmentSlice())
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeBuild, i18n.T("errors.build.build_stage_failed")).
+			WithContext("package", pkgName).
+			WithContext("version", oldVer).
+			WithContext("release", builder.PKGBUILD.PkgRel).
+			WithContext("stage", "pkgver").
+			WithOperation("pkgver")
+	}
+
+	if err := builder.PKGBUILD.UpdatePkgVer(stdout.String()); err != nil {
+		return err
+	}
+
+	if builder.PKGBUILD.PkgVer != oldVer {
+		logger.Info(i18n.T("logger.builder.info.pkgver_updated"),
+			"package", pkgName, "from", oldVer, "to", builder.PKGBUILD.PkgVer)
+	}
+
+	return nil
 }
 
 // initDirs creates mandatory fakeroot folders (src, pkg) for a single project.
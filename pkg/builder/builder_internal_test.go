@@ -124,3 +124,109 @@ func TestRunBuildStagesCheckStage(t *testing.T) {
 		}
 	})
 }
+
+// TestUpdatePkgVer asserts that pkgver() runs in "${srcdir}", that its stdout
+// replaces PkgVer, and that later stages see the new value via $pkgver.
+func TestUpdatePkgVer(t *testing.T) {
+	t.Parallel()
+
+	srcDir := t.TempDir()
+	outFile := filepath.Join(t.TempDir(), "pkgver.txt")
+
+	if err := os.WriteFile(filepath.Join(srcDir, "VERSION"), []byte("2.5.1\n"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	b := &Builder{
+		PKGBUILD: &pkgbuild.PKGBUILD{
+			PkgName:    "yap-pkgver-test",
+			PkgVer:     "1.0.0",
+			PkgRel:     "1",
+			SourceDir:  srcDir,
+			PkgVerFunc: "echo \"$(cat VERSION).r$pkgrel\"",
+			Build:      "echo \"$pkgver\" > " + strconv.Quote(outFile),
+		},
+	}
+
+	if err := b.updatePkgVer(context.Background()); err != nil {
+		t.Fatalf("updatePkgVer failed: %v", err)
+	}
+
+	if b.PKGBUILD.PkgVer != "2.5.1.r1" {
+		t.Fatalf("PkgVer = %q, want %q", b.PKGBUILD.PkgVer, "2.5.1.r1")
+	}
+
+	if err := b.processFunction(
+		context.Background(), b.PKGBUILD.Build, "logger.building", "build",
+	); err != nil {
+		t.Fatalf("processFunction failed: %v", err)
+	}
+
+	raw, err := os.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("build() did not run: %v", err)
+	}
+
+	if got := strings.TrimSpace(string(raw)); got != "2.5.1.r1" {
+		t.Fatalf("build() saw pkgver %q, want %q", got, "2.5.1.r1")
+	}
+}
+
+// TestUpdatePkgVerInvalidOutput asserts that a pkgver() printing a
+// makepkg-invalid version fails the build instead of producing bad artifacts.
+func TestUpdatePkgVerInvalidOutput(t *testing.T) {
+	t.Parallel()
+
+	b := &Builder{
+		PKGBUILD: &pkgbuild.PKGBUILD{
+			PkgName:    "yap-pkgver-invalid",
+			PkgVer:     "1.0.0",
+			PkgRel:     "1",
+			SourceDir:  t.TempDir(),
+			PkgVerFunc: "echo 1.0-beta",
+		},
+	}
+
+	if err := b.updatePkgVer(context.Background()); err == nil {
+		t.Fatal("expected error for pkgver containing a hyphen")
+	}
+
+	if b.PKGBUILD.PkgVer != "1.0.0" {
+		t.Fatalf("PkgVer changed to %q on failure", b.PKGBUILD.PkgVer)
+	}
+}
+
+// TestVerifySourceSignaturesSkipPGPCheck asserts that signed sources without
+// validpgpkeys fail the build unless --skip-pgp-check is given.
+func TestVerifySourceSignaturesSkipPGPCheck(t *testing.T) {
+	t.Parallel()
+
+	startDir := t.TempDir()
+	for _, name := range []string{"foo-1.0.tar.gz", "foo-1.0.tar.gz.sig"} {
+		if err := os.WriteFile(filepath.Join(startDir, name), []byte(name), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	newBuilder := func(skip bool) *Builder {
+		return &Builder{
+			PKGBUILD: &pkgbuild.PKGBUILD{
+				PkgName:  "yap-pgp-test",
+				StartDir: startDir,
+				SourceURI: []string{
+					"https://example.com/foo-1.0.tar.gz",
+					"https://example.com/foo-1.0.tar.gz.sig",
+				},
+			},
+			SkipPGPCheck: skip,
+		}
+	}
+
+	if err := newBuilder(false).verifySourceSignatures(); err == nil {
+		t.Fatal("expected error for signed sources without validpgpkeys")
+	}
+
+	if err := newBuilder(true).verifySourceSignatures(); err != nil {
+		t.Fatalf("verifySourceSignatures with --skip-pgp-check failed: %v", err)
+	}
+}
@@ -0,0 +1,488 @@
+package apk
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/M0Rf30/yap/v2/pkg/adb"
+	"github.com/M0Rf30/yap/v2/pkg/constants"
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/files"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/logger"
+)
+
+// uniqueIDSize is the size of the package unique identifier, the leading
+// bytes of the SHA-256 of the ADB block written with a zeroed identifier.
+const uniqueIDSize = 20
+
+// symlinkMode is S_IFLNK, prefixed to the target of symbolic links.
+const symlinkMode = 0o120000
+
+// adbDir is a directory of a v3 package with the files directly inside it.
+type adbDir struct {
+	name  string
+	file  apkFile
+	files []apkFile
+}
+
+// adbCompression maps the compressionApk setting to the ADB compression,
+// deflate by default as apk mkpkg.
+func (a *Apk) adbCompression() string {
+	if a.compression == "" || a.compression == constants.CompressionGzip {
+		return adb.CompressionDeflate
+	}
+
+	return a.compression
+}
+
+// createADBPackage writes an APK v3 package: an ADB block describing the
+// package and its files, followed by one data block per non-empty regular
+// file in the order of the sorted paths. Signature blocks are added later
+// by the RSA signer.
+func (a *Apk) createADBPackage(ctx context.Context, sourceDir, outputFile string) error {
+	fileList, err := walkAPKFiles(sourceDir)
+	if err != nil {
+		return err
+	}
+
+	dirs := a.adbDirs(sourceDir, fileList)
+
+	db := adb.NewDB()
+
+	pathValues := make([]adb.Value, 0, len(dirs))
+	for _, dir := range dirs {
+		value, err := a.adbDirValue(db, dir)
+		if err != nil {
+			return err
+		}
+
+		pathValues = append(pathValues, value)
+	}
+
+	pkg := make([]adb.Value, adb.PkgMax)
+	uniqueID := db.Blob(make([]byte, uniqueIDSize))
+	pkg[adb.PkgInfo] = a.adbPkgInfo(db, uniqueID)
+	pkg[adb.PkgPaths] = db.Array(pathValues)
+	pkg[adb.PkgScripts] = a.adbScripts(db)
+	pkg[adb.PkgTriggers] = adbStrings(db, a.triggerGlobs())
+	db.SetRoot(db.Object(pkg))
+
+	digest := sha256.Sum256(db.Bytes())
+	copy(db.BlobData(uniqueID), digest[:uniqueIDSize])
+
+	cleanFilePath := filepath.Clean(outputFile)
+
+	out, err := os.Create(cleanFilePath)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if closeErr := out.Close(); closeErr != nil {
+			logger.Warn(i18n.T("logger.apk.warn.failed_to_close_output"),
+				"path", cleanFilePath,
+				"error", closeErr)
+		}
+	}()
+
+	writer, err := adb.NewWriter(out, adb.SchemaPackage, a.adbCompression())
+	if err != nil {
+		return err
+	}
+
+	if err := writer.WriteBlock(adb.BlockADB, db.Bytes()); err != nil {
+		return err
+	}
+
+	for i, dir := range dirs {
+		for j, file := range dir.files {
+			if !file.Mode().IsRegular() || file.Size() == 0 {
+				continue
+			}
+
+			if err := writeADBData(ctx, writer, file, i+1, j+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	return writer.Close()
+}
+
+// adbDirs groups the walked files by directory, sorted by name as apk
+// expects: the package root is the directory named "".
+func (a *Apk) adbDirs(sourceDir string, fileList []apkFile) []*adbDir {
+	root := &adbDir{}
+	if info, err := os.Stat(sourceDir); err == nil {
+		root.file = apkFile{FileInfo: info, diskPath: sourceDir}
+	}
+
+	byName := map[string]*adbDir{"": root}
+
+	for _, file := range fileList {
+		if file.IsDir() {
+			dir := byName[file.nameInArchive]
+			if dir == nil {
+				dir = &adbDir{name: file.nameInArchive}
+				byName[file.nameInArchive] = dir
+			}
+
+			dir.file = file
+
+			continue
+		}
+
+		if isControlFile(file.nameInArchive) {
+			continue
+		}
+
+		parent := path.Dir(file.nameInArchive)
+		if parent == "." {
+			parent = ""
+		}
+
+		dir := byName[parent]
+		if dir == nil {
+			dir = &adbDir{name: parent}
+			byName[parent] = dir
+		}
+
+		dir.files = append(dir.files, file)
+	}
+
+	dirs := make([]*adbDir, 0, len(byName))
+	for _, dir := range byName {
+		slices.SortFunc(dir.files, func(x, y apkFile) int {
+			return strings.Compare(path.Base(x.nameInArchive), path.Base(y.nameInArchive))
+		})
+
+		dirs = append(dirs, dir)
+	}
+
+	slices.SortFunc(dirs, func(x, y *adbDir) int {
+		return strings.Compare(x.name, y.name)
+	})
+
+	return dirs
+}
+
+// adbDirValue stores a directory object with its access control and files.
+func (a *Apk) adbDirValue(db *adb.DB, dir *adbDir) (adb.Value, error) {
+	fileValues := make([]adb.Value, 0, len(dir.files))
+
+	for _, file := range dir.files {
+		value, err := a.adbFileValue(db, file)
+		if err != nil {
+			return adb.Null, err
+		}
+
+		fileValues = append(fileValues, value)
+	}
+
+	acl := db.Object([]adb.Value{adb.ACLMode: db.Int(0o755)})
+	if dir.file.FileInfo != nil {
+		var err error
+
+		acl, err = a.adbACL(db, dir.file)
+		if err != nil {
+			return adb.Null, err
+		}
+	}
+
+	fields := make([]adb.Value, adb.DirMax)
+	fields[adb.DirName] = db.String(dir.name)
+	fields[adb.DirACL] = acl
+	fields[adb.DirFiles] = db.Array(fileValues)
+
+	return db.Object(fields), nil
+}
+
+// adbFileValue stores a file object: regular files carry their SHA-256,
+// symbolic links their target prefixed by the file type.
+func (a *Apk) adbFileValue(db *adb.DB, file apkFile) (adb.Value, error) {
+	fields := make([]adb.Value, adb.FileMax)
+	fields[adb.FileName] = db.String(path.Base(file.nameInArchive))
+
+	switch {
+	case file.Mode().IsRegular():
+		hash, err := fileSHA256(file)
+		if err != nil {
+			return adb.Null, errors.Wrap(err, errors.ErrTypePackaging,
+				fmt.Sprintf("file %s: computing checksum", file.nameInArchive)).
+				WithOperation("adbFileValue").
+				WithContext("file", file.nameInArchive)
+		}
+
+		fields[adb.FileHashes] = db.Blob(hash)
+		fields[adb.FileSize] = db.Int(uint64(file.Size())) //nolint:gosec // sizes are non-negative
+	case file.Mode()&fs.ModeSymlink != 0:
+		target := binary.LittleEndian.AppendUint16(nil, symlinkMode)
+		fields[adb.FileTarget] = db.Blob(append(target, file.linkTarget...))
+		fields[adb.FileSize] = db.Int(uint64(len(file.linkTarget)))
+	}
+
+	acl, err := a.adbACL(db, file)
+	if err != nil {
+		return adb.Null, err
+	}
+
+	fields[adb.FileACL] = acl
+	fields[adb.FileMtime] = db.Int(0)
+
+	return db.Object(fields), nil
+}
+
+// adbACL stores the mode, owner and extended attributes of a file.
+func (a *Apk) adbACL(db *adb.DB, file apkFile) (adb.Value, error) {
+	attrs, err := files.ReadAttributes(file.diskPath, "/"+strings.TrimSuffix(file.nameInArchive, "/"),
+		file.FileInfo, a.OwnerRules())
+	if err != nil {
+		return adb.Null, errors.Wrap(err, errors.ErrTypePackaging,
+			fmt.Sprintf("file %s: reading attributes", file.nameInArchive)).
+			WithOperation("adbACL").
+			WithContext("file", file.nameInArchive)
+	}
+
+	xattrs := make([]string, 0, len(attrs.Xattrs))
+	for name, value := range attrs.Xattrs {
+		xattrs = append(xattrs, name+"\x00"+value)
+	}
+
+	slices.Sort(xattrs)
+
+	fields := make([]adb.Value, adb.ACLMax)
+	fields[adb.ACLMode] = db.Int(unixPermissions(file.Mode()))
+	fields[adb.ACLUser] = db.String(attrs.Owner)
+	fields[adb.ACLGroup] = db.String(attrs.Group)
+	fields[adb.ACLXattrs] = adbStrings(db, xattrs)
+
+	return db.Object(fields), nil
+}
+
+// adbPkgInfo stores the package metadata, the v3 counterpart of .PKGINFO.
+func (a *Apk) adbPkgInfo(db *adb.DB, uniqueID adb.Value) adb.Value {
+	pkgBuild := a.PKGBUILD
+
+	version := pkgBuild.PkgVer + "-r" + pkgBuild.PkgRel
+	if pkgBuild.Epoch != "" {
+		version = pkgBuild.Epoch + ":" + version
+	}
+
+	fields := make([]adb.Value, adb.InfoMax)
+	fields[adb.InfoName] = db.String(pkgBuild.PkgName)
+	fields[adb.InfoVersion] = db.String(version)
+	fields[adb.InfoUniqueID] = uniqueID
+	fields[adb.InfoDescription] = db.String(pkgBuild.PkgDesc)
+	fields[adb.InfoArch] = db.String(pkgBuild.ArchComputed)
+	fields[adb.InfoLicense] = db.String(strings.Join(pkgBuild.License, " "))
+	fields[adb.InfoOrigin] = db.String(pkgBuild.Origin)
+	fields[adb.InfoMaintainer] = db.String(pkgBuild.Maintainer)
+	fields[adb.InfoURL] = db.String(pkgBuild.HomepageURL())
+	fields[adb.InfoBuildTime] = db.Int(uint64(max(pkgBuild.BuildDate, 0)))
+	fields[adb.InfoInstalledSize] = db.Int(uint64(max(pkgBuild.InstalledSize, 0)))
+	fields[adb.InfoDepends] = adbDependencies(db, pkgBuild.Depends)
+	fields[adb.InfoProvides] = adbDependencies(db, pkgBuild.Provides)
+	fields[adb.InfoReplaces] = adbDependencies(db, pkgBuild.Replaces)
+
+	if commit, err := hex.DecodeString(pkgBuild.Commit); err == nil {
+		fields[adb.InfoRepoCommit] = db.Blob(commit)
+	}
+
+	if pkgBuild.ProviderPriority > 0 {
+		fields[adb.InfoProviderPriority] = db.Int(uint64(pkgBuild.ProviderPriority))
+	}
+
+	return db.Object(fields)
+}
+
+// adbScripts stores the scripts prepared by PrepareFakeroot.
+func (a *Apk) adbScripts(db *adb.DB) adb.Value {
+	fields := make([]adb.Value, adb.ScriptMax)
+	for i, script := range a.scripts {
+		fields[i] = db.String(script)
+	}
+
+	return db.Object(fields)
+}
+
+// prepareADBScripts keeps the install and trigger scripts for the v3
+// package, where each lifecycle hook is a standalone script rather than a
+// function of the .install file. Upgrades run the install hooks, as in
+// the v2 .install template.
+func (a *Apk) prepareADBScripts(trigger string) {
+	script := func(body string) string {
+		if body == "" {
+			return ""
+		}
+
+		return "#!/bin/sh\n" + strings.TrimRight(body, "\n") + "\n"
+	}
+
+	a.scripts = make([]string, adb.ScriptMax)
+	a.scripts[adb.ScriptTrigger] = trigger
+	a.scripts[adb.ScriptPreInstall] = script(a.PKGBUILD.PreInst)
+	a.scripts[adb.ScriptPostInstall] = script(a.PKGBUILD.PostInst)
+	a.scripts[adb.ScriptPreUpgrade] = script(a.PKGBUILD.PreInst)
+	a.scripts[adb.ScriptPostUpgrade] = script(a.PKGBUILD.PostInst)
+	a.scripts[adb.ScriptPreDeinstall] = script(a.PKGBUILD.PreRm)
+	a.scripts[adb.ScriptPostDeinstall] = script(a.PKGBUILD.PostRm)
+}
+
+// adbDependencies stores dependency strings such as "foo>=1.0" or "!bar"
+// as dependency objects, sorted by name.
+func adbDependencies(db *adb.DB, entries []string) adb.Value {
+	type dependency struct {
+		name, version string
+		match         int
+	}
+
+	deps := make([]dependency, 0, len(entries))
+
+	for _, entry := range entries {
+		dep := dependency{match: adb.MatchAny}
+
+		if strings.HasPrefix(entry, "!") {
+			entry = entry[1:]
+			dep.match |= adb.MatchConflict
+		}
+
+		dep.name = entry
+
+		if i := strings.IndexAny(entry, "<>=~"); i > 0 {
+			op := entry[i:]
+			dep.name = entry[:i]
+			dep.version = strings.TrimLeft(op, "<>=~")
+			dep.match &= adb.MatchConflict
+
+			for _, c := range op[:len(op)-len(dep.version)] {
+				switch c {
+				case '<':
+					dep.match |= adb.MatchLess
+				case '>':
+					dep.match |= adb.MatchGreater
+				case '=':
+					dep.match |= adb.MatchEqual
+				case '~':
+					dep.match |= adb.MatchEqual | adb.MatchFuzzy
+				}
+			}
+		}
+
+		deps = append(deps, dep)
+	}
+
+	slices.SortStableFunc(deps, func(x, y dependency) int {
+		return strings.Compare(x.name, y.name)
+	})
+
+	values := make([]adb.Value, 0, len(deps))
+
+	for _, dep := range deps {
+		fields := make([]adb.Value, adb.DepMax)
+		fields[adb.DepName] = db.String(dep.name)
+
+		if dep.match != adb.MatchAny {
+			fields[adb.DepVersion] = db.String(dep.version)
+		}
+
+		if dep.match != adb.MatchAny && dep.match != adb.MatchEqual {
+			fields[adb.DepMatch] = db.Int(uint64(dep.match))
+		}
+
+		values = append(values, db.Object(fields))
+	}
+
+	return db.Array(values)
+}
+
+// adbStrings stores an array of string blobs.
+func adbStrings(db *adb.DB, items []string) adb.Value {
+	values := make([]adb.Value, 0, len(items))
+	for _, item := range items {
+		values = append(values, db.String(item))
+	}
+
+	return db.Array(values)
+}
+
+// writeADBData writes the data block of a regular file, identified by the
+// 1-based indexes of its directory and of the file within it.
+func writeADBData(ctx context.Context, writer *adb.Writer, file apkFile, pathIndex, fileIndex int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	header := binary.LittleEndian.AppendUint32(nil, uint32(pathIndex))   //nolint:gosec // small index
+	header = binary.LittleEndian.AppendUint32(header, uint32(fileIndex)) //nolint:gosec // small index
+
+	reader, err := file.Open()
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypePackaging,
+			fmt.Sprintf("file %s: opening", file.nameInArchive)).
+			WithOperation("writeADBData").
+			WithContext("file", file.nameInArchive)
+	}
+
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	if err := writer.WriteBlockFrom(adb.BlockData, header, reader, file.Size()); err != nil {
+		return errors.Wrap(err, errors.ErrTypePackaging,
+			fmt.Sprintf("file %s: writing data", file.nameInArchive)).
+			WithOperation("writeADBData").
+			WithContext("file", file.nameInArchive)
+	}
+
+	return nil
+}
+
+// fileSHA256 returns the SHA-256 of a regular file.
+func fileSHA256(file apkFile) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = reader.Close()
+	}()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return nil, err
+	}
+
+	return hash.Sum(nil), nil
+}
+
+// unixPermissions returns the permission and set-id bits of a mode in
+// their Unix encoding.
+func unixPermissions(mode fs.FileMode) uint64 {
+	perm := uint64(mode.Perm())
+
+	if mode&fs.ModeSetuid != 0 {
+		perm |= 0o4000
+	}
+
+	if mode&fs.ModeSetgid != 0 {
+		perm |= 0o2000
+	}
+
+	if mode&fs.ModeSticky != 0 {
+		perm |= 0o1000
+	}
+
+	return perm
+}
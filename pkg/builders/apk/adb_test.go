@@ -0,0 +1,167 @@
+package apk
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/M0Rf30/yap/v2/pkg/adb"
+)
+
+func TestBuildPackageV3(t *testing.T) {
+	tempDir := t.TempDir()
+	pkgDir := filepath.Join(tempDir, "pkg")
+
+	for _, dir := range []string{"usr/bin", "usr/share"} {
+		if err := os.MkdirAll(filepath.Join(pkgDir, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(pkgDir, "usr/bin/tool"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(pkgDir, "usr/share/empty"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink("tool", filepath.Join(pkgDir, "usr/bin/link")); err != nil {
+		t.Fatal(err)
+	}
+
+	pkgBuild := createTestPKGBUILD()
+	pkgBuild.PackageDir = pkgDir
+	pkgBuild.ArchComputed = "x86_64"
+	pkgBuild.Depends = []string{"musl", "foo>=1.2", "!bar"}
+	pkgBuild.PostInst = "echo installed"
+
+	builder := NewBuilder(pkgBuild, "v3", "zstd")
+
+	if err := builder.PrepareFakeroot(context.Background(), tempDir, ""); err != nil {
+		t.Fatalf("PrepareFakeroot() error = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(pkgDir, pkginfoFileName)); !os.IsNotExist(err) {
+		t.Errorf("v3 package dir has a %s file", pkginfoFileName)
+	}
+
+	pkgPath, err := builder.BuildPackage(context.Background(), tempDir, "")
+	if err != nil {
+		t.Fatalf("BuildPackage() error = %v", err)
+	}
+
+	data, err := os.ReadFile(pkgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := adb.Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if file.Schema != adb.SchemaPackage || file.Compression != adb.CompressionZstd {
+		t.Errorf("schema = %x, compression = %s", file.Schema, file.Compression)
+	}
+
+	db := file.ADB()
+	pkg := adb.ReadObject(db, adb.Root(db), adb.PkgMax)
+	info := adb.ReadObject(db, pkg[adb.PkgInfo], adb.InfoMax)
+
+	if name := string(adb.ReadBlob(db, info[adb.InfoName])); name != "test-package" {
+		t.Errorf("name = %q", name)
+	}
+
+	if version := string(adb.ReadBlob(db, info[adb.InfoVersion])); version != "1.0.0-r1" {
+		t.Errorf("version = %q", version)
+	}
+
+	if uniqueID := adb.ReadBlob(db, info[adb.InfoUniqueID]); len(uniqueID) != uniqueIDSize {
+		t.Errorf("unique id has %d bytes", len(uniqueID))
+	}
+
+	var depNames []string
+	for _, dep := range adb.ReadArray(db, info[adb.InfoDepends]) {
+		depNames = append(depNames, string(adb.ReadBlob(db, adb.ReadObject(db, dep, adb.DepMax)[adb.DepName])))
+	}
+
+	if want := []string{"bar", "foo", "musl"}; !reflect.DeepEqual(depNames, want) {
+		t.Errorf("depends = %v, want %v", depNames, want)
+	}
+
+	scripts := adb.ReadObject(db, pkg[adb.PkgScripts], adb.ScriptMax)
+	if script := string(adb.ReadBlob(db, scripts[adb.ScriptPostInstall])); script != "#!/bin/sh\necho installed\n" {
+		t.Errorf("post-install script = %q", script)
+	}
+
+	files := map[string][]string{}
+
+	for _, path := range adb.ReadArray(db, pkg[adb.PkgPaths]) {
+		dir := adb.ReadObject(db, path, adb.DirMax)
+		name := string(adb.ReadBlob(db, dir[adb.DirName]))
+		files[name] = []string{}
+
+		for _, entry := range adb.ReadArray(db, dir[adb.DirFiles]) {
+			fields := adb.ReadObject(db, entry, adb.FileMax)
+			files[name] = append(files[name], string(adb.ReadBlob(db, fields[adb.FileName])))
+		}
+	}
+
+	wantFiles := map[string][]string{
+		"":          {},
+		"usr":       {},
+		"usr/bin":   {"link", "tool"},
+		"usr/share": {"empty"},
+	}
+	if !reflect.DeepEqual(files, wantFiles) {
+		t.Errorf("paths = %v, want %v", files, wantFiles)
+	}
+
+	// Only usr/bin/tool has contents: the symlink and the empty file have no
+	// data block.
+	if len(file.Blocks) != 2 || file.Blocks[1].Type != adb.BlockData {
+		t.Fatalf("blocks = %d, want the ADB block and one data block", len(file.Blocks))
+	}
+
+	block := file.Blocks[1].Data
+	if pathIndex, fileIndex := binary.LittleEndian.Uint32(block), binary.LittleEndian.Uint32(block[4:]); pathIndex != 3 ||
+		fileIndex != 2 || string(block[8:]) != "#!/bin/sh\n" {
+		t.Errorf("data block = %d/%d %q", pathIndex, fileIndex, block[8:])
+	}
+}
+
+func TestADBDependencies(t *testing.T) {
+	db := adb.NewDB()
+	deps := adbDependencies(db, []string{"so:libc.musl-x86_64.so.1", "foo=1.0", "bar<2", "!baz", "qux~1.2"})
+
+	type dependency struct {
+		name, version string
+		match         uint64
+	}
+
+	var got []dependency
+
+	for _, value := range adb.ReadArray(db.Bytes(), deps) {
+		fields := adb.ReadObject(db.Bytes(), value, adb.DepMax)
+		got = append(got, dependency{
+			name:    string(adb.ReadBlob(db.Bytes(), fields[adb.DepName])),
+			version: string(adb.ReadBlob(db.Bytes(), fields[adb.DepVersion])),
+			match:   adb.ReadInt(db.Bytes(), fields[adb.DepMatch]),
+		})
+	}
+
+	want := []dependency{
+		{name: "bar", version: "2", match: adb.MatchLess},
+		{name: "baz", match: adb.MatchAny | adb.MatchConflict},
+		{name: "foo", version: "1.0"},
+		{name: "qux", version: "1.2", match: adb.MatchEqual | adb.MatchFuzzy},
+		{name: "so:libc.musl-x86_64.so.1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("adbDependencies() = %+v, want %+v", got, want)
+	}
+}
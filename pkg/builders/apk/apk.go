@@ -25,6 +25,7 @@ import (
 	"github.com/M0Rf30/yap/v2/pkg/i18n"
 	"github.com/M0Rf30/yap/v2/pkg/logger"
 	"github.com/M0Rf30/yap/v2/pkg/pkgbuild"
+	"github.com/M0Rf30/yap/v2/pkg/triggers"
 )
 
 // apkFile is a tiny replacement for archives.FileInfo, carrying only the
@@ -109,18 +110,31 @@ func walkAPKFiles(sourceDir string) ([]apkFile, error) {
 // It embeds the common.BaseBuilder to inherit shared functionality.
 type Apk struct {
 	*common.BaseBuilder
+
+	format      string
+	compression string
+	scripts     []string
 }
 
-// NewBuilder creates a new APK package builder.
-func NewBuilder(pkgBuild *pkgbuild.PKGBUILD) *Apk {
+// NewBuilder creates a new APK package builder writing the given package
+// format version, "v2" (the default when empty) or "v3". compression only
+// applies to v3 packages and defaults to gzip (deflate).
+func NewBuilder(pkgBuild *pkgbuild.PKGBUILD, format, compression string) *Apk {
+	if format == "" {
+		format = constants.APKFormatV2
+	}
+
 	return &Apk{
 		BaseBuilder: common.NewBaseBuilder(pkgBuild, "apk"),
+		format:      format,
+		compression: compression,
 	}
 }
 
 // BuildPackage creates an APK package without external dependencies.
-// The package is created as a gzip-compressed tar archive containing the package
-// files and metadata (.PKGINFO and optional .install script).
+// A v2 package is a gzip-compressed tar archive containing the package
+// files and metadata (.PKGINFO and optional .install script); a v3 package
+// is an ADB file.
 // Returns the path to the created APK file.
 func (a *Apk) BuildPackage(ctx context.Context, artifactsPath string, targetArch string) (string, error) {
 	a.SetTargetArchitecture(targetArch)
@@ -128,7 +142,19 @@ func (a *Apk) BuildPackage(ctx context.Context, artifactsPath string, targetArch
 	pkgName := a.BuildPackageName(".apk")
 	pkgFilePath := filepath.Join(artifactsPath, pkgName)
 
-	err := a.createTarGzWithChecksums(ctx, a.PKGBUILD.PackageDir, pkgFilePath)
+	var err error
+
+	switch a.format {
+	case constants.APKFormatV2:
+		err = a.createTarGzWithChecksums(ctx, a.PKGBUILD.PackageDir, pkgFilePath)
+	case constants.APKFormatV3:
+		err = a.createADBPackage(ctx, a.PKGBUILD.PackageDir, pkgFilePath)
+	default:
+		err = errors.New(errors.ErrTypeConfiguration, i18n.T("errors.apk.unsupported_format")).
+			WithOperation("BuildPackage").
+			WithContext("format", a.format)
+	}
+
 	if err != nil {
 		return "", err
 	}
@@ -143,6 +169,21 @@ func (a *Apk) BuildPackage(ctx context.Context, artifactsPath string, targetArch
 func (a *Apk) PrepareFakeroot(ctx context.Context, artifactsPath string, targetArch string) error {
 	a.SetTargetArchitecture(targetArch)
 
+	err := a.RemoveGhostFiles()
+	if err != nil {
+		return err
+	}
+
+	err = a.ApplyOptionsWithEnv(a.CrossStripEnvMap(targetArch))
+	if err != nil {
+		return err
+	}
+
+	err = a.ShipAlternatives()
+	if err != nil {
+		return err
+	}
+
 	installedSize, err := files.GetDirSize(a.PKGBUILD.PackageDir)
 	if err != nil {
 		return errors.Wrap(err, errors.ErrTypeFileSystem, "failed to get package dir size").
@@ -162,11 +203,47 @@ func (a *Apk) PrepareFakeroot(ctx context.Context, artifactsPath string, targetA
 		a.PKGBUILD.Commit = git.GetCommitHash(a.PKGBUILD.StartDir)
 	}
 
-	err = a.createPkgInfo()
+	_, err = a.GenerateProvides()
 	if err != nil {
 		return err
 	}
 
+	trigger := a.triggerScript()
+
+	if a.format != constants.APKFormatV3 {
+		err = a.createPkgInfo()
+		if err != nil {
+			return err
+		}
+
+		err = a.createTriggerScript(trigger)
+		if err != nil {
+			return err
+		}
+	}
+
+	system, err := a.SystemScriptlets()
+	if err != nil {
+		return err
+	}
+
+	// Users and directories are set up around the user-written scriptlets
+	// for the duration of the install script rendering.
+	preInst, postInst := a.PKGBUILD.PreInst, a.PKGBUILD.PostInst
+
+	defer func() {
+		a.PKGBUILD.PreInst, a.PKGBUILD.PostInst = preInst, postInst
+	}()
+
+	a.PKGBUILD.PreInst = common.JoinScriptlets(system.PreInst, preInst)
+	a.PKGBUILD.PostInst = common.JoinScriptlets(postInst, system.PostInst)
+
+	if a.format == constants.APKFormatV3 {
+		a.prepareADBScripts(trigger)
+
+		return nil
+	}
+
 	if a.PKGBUILD.PreInst != "" || a.PKGBUILD.PostInst != "" ||
 		a.PKGBUILD.PreRm != "" || a.PKGBUILD.PostRm != "" {
 		err = a.createInstallScript()
@@ -188,7 +265,80 @@ func (a *Apk) createPkgInfo() error {
 
 	pkginfoPath := filepath.Join(a.PKGBUILD.PackageDir, pkginfoFileName)
 
-	return a.PKGBUILD.CreateSpec(pkginfoPath, tmpl)
+	if err := a.PKGBUILD.CreateSpec(pkginfoPath, tmpl); err != nil {
+		return err
+	}
+
+	globs := a.triggerGlobs()
+	if len(globs) == 0 {
+		return nil
+	}
+
+	pkgInfo, err := os.OpenFile(pkginfoPath, os.O_APPEND|os.O_WRONLY, 0) //nolint:gosec // written above
+	if err != nil {
+		return err
+	}
+
+	_, err = pkgInfo.WriteString("triggers = " + strings.Join(globs, " ") + "\n")
+	if closeErr := pkgInfo.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// triggerGlobs returns the directory globs of the path triggers, for the
+// "triggers" .PKGINFO field: each path and the directories below it.
+func (a *Apk) triggerGlobs() []string {
+	var globs []string
+
+	for _, trigger := range a.Triggers() {
+		if trigger.IsPath() {
+			globs = append(globs, trigger.Target, trigger.Target+"/*")
+		}
+	}
+
+	return globs
+}
+
+// triggerScript returns the script apk runs once per transaction, with the
+// matched directories as arguments. apk has no named triggers, so those
+// entries are skipped with a warning.
+func (a *Apk) triggerScript() string {
+	for _, trigger := range a.Triggers() {
+		if !trigger.IsPath() {
+			logger.Warn(i18n.T("logger.apk.warn.named_trigger_unsupported"),
+				"package", a.PKGBUILD.PkgName, "trigger", trigger.Target)
+		}
+	}
+
+	dispatch := a.TriggerDispatch("\"$@\"", func(trigger triggers.Trigger) []string {
+		if !trigger.IsPath() {
+			return nil
+		}
+
+		return []string{trigger.Target, trigger.Target + "/*"}
+	})
+	if dispatch == "" {
+		return ""
+	}
+
+	return "#!/bin/sh\n" + a.PrepareScriptletWithHelpers(dispatch)
+}
+
+// createTriggerScript writes the trigger script as the .trigger file.
+func (a *Apk) createTriggerScript(script string) error {
+	if script == "" {
+		return nil
+	}
+
+	scriptPath := filepath.Join(a.PKGBUILD.PackageDir, ".trigger")
+
+	if err := files.CreateWrite(scriptPath, script); err != nil {
+		return err
+	}
+
+	return files.Chmod(scriptPath, 0o755)
 }
 
 // createInstallScript generates the install script for APK packages.
@@ -431,11 +581,6 @@ func (a *Apk) writeFileWithChecksum(
 		hdr.Name = file.Name()
 	}
 
-	hdr.Uid = 0
-	hdr.Gid = 0
-	hdr.Uname = "root"
-	hdr.Gname = "root"
-
 	hdr.ModTime = time.Unix(0, 0)
 	hdr.ChangeTime = time.Time{}
 	hdr.AccessTime = time.Time{}
@@ -444,6 +589,20 @@ func (a *Apk) writeFileWithChecksum(
 	hdr.Format = tar.FormatPAX
 	hdr.PAXRecords = make(map[string]string)
 
+	attrs := files.Attributes{Owner: "root", Group: "root"}
+
+	if file.diskPath != "" && !isControlFile(hdr.Name) {
+		attrs, err = files.ReadAttributes(file.diskPath, "/"+hdr.Name, file.FileInfo, a.OwnerRules())
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypePackaging,
+				fmt.Sprintf("file %s: reading attributes", file.nameInArchive)).
+				WithOperation("writeFileWithChecksum").
+				WithContext("file", file.nameInArchive)
+		}
+	}
+
+	attrs.ApplyToHeader(hdr)
+
 	// Add standard PAX records for reproducibility
 	hdr.PAXRecords["mtime"] = "0"
 	hdr.PAXRecords["atime"] = "0"
@@ -73,7 +73,7 @@ func TestWalkAPKFilesWithSubdirs(t *testing.T) {
 // createTarGzWithChecksums fails (non-existent PackageDir).
 func TestBuildPackageCreateTarGzError(t *testing.T) {
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	tmpDir := t.TempDir()
 
@@ -88,7 +88,7 @@ func TestBuildPackageCreateTarGzError(t *testing.T) {
 // TestPrepareFakerootGetDirSizeError exercises the GetDirSize error path.
 func TestPrepareFakerootGetDirSizeError(t *testing.T) {
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	tmpDir := t.TempDir()
 
@@ -108,7 +108,7 @@ func TestPrepareFakerootInstallScriptError(t *testing.T) {
 
 	pkgBuild := createTestPKGBUILD()
 	pkgBuild.PreInst = "echo pre"
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	tmpDir := t.TempDir()
 	pkgDir := filepath.Join(tmpDir, "pkg")
@@ -130,7 +130,7 @@ func TestPrepareFakerootInstallScriptError(t *testing.T) {
 // TestCreateTarGzOutputFileError exercises the os.Create failure path.
 func TestCreateTarGzOutputFileError(t *testing.T) {
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	tmpDir := t.TempDir()
 	pkgDir := filepath.Join(tmpDir, "pkg")
@@ -154,7 +154,7 @@ func TestCreateTarGzOutputFileError(t *testing.T) {
 // We create a valid source dir but make the output file read-only after creation.
 func TestCreateTarGzWithDataFile(t *testing.T) {
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	tmpDir := t.TempDir()
 	pkgDir := filepath.Join(tmpDir, "pkg")
@@ -182,7 +182,7 @@ func TestCreateTarGzWithDataFile(t *testing.T) {
 // We pass a FileInfo whose Sys() returns an unexpected type to trigger the error.
 func TestWriteFileWithChecksumBadHeader(t *testing.T) {
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	// Use a symlink apkFile with an empty linkTarget — tar.FileInfoHeader should still work.
 	// To trigger a real header error we need an invalid FileInfo.
@@ -220,7 +220,7 @@ func TestWriteFileWithChecksumBadHeader(t *testing.T) {
 // path for a regular non-control data file.
 func TestWriteFileWithChecksumDataFileChecksumPath(t *testing.T) {
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	tmpDir := t.TempDir()
 	dataFile := filepath.Join(tmpDir, "usr_bin_prog")
@@ -254,7 +254,7 @@ func TestWriteFileWithChecksumDataFileChecksumPath(t *testing.T) {
 // in the SHA1 checksum path (pass 1).
 func TestWriteFileWithChecksumDataFileOpenError(t *testing.T) {
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	// Create a real file, stat it, then remove it so Open() fails during checksum pass.
 	tmpFile, err := os.CreateTemp("", "apkwfc-*")
@@ -291,7 +291,7 @@ func TestWriteFileWithChecksumDataFileOpenError(t *testing.T) {
 // in the control-file (non-SHA1) path.
 func TestWriteFileWithChecksumControlFileOpenError(t *testing.T) {
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	// Create a real file, stat it, then remove it.
 	tmpFile, err := os.CreateTemp("", "apkwfc-*")
@@ -328,7 +328,7 @@ func TestWriteFileWithChecksumControlFileOpenError(t *testing.T) {
 // the output directory doesn't exist.
 func TestCreateTarGzWithChecksumsBadOutputDir(t *testing.T) {
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	tmpDir := t.TempDir()
 	pkgDir := filepath.Join(tmpDir, "pkg")
@@ -344,7 +344,7 @@ func TestCreateTarGzWithChecksumsBadOutputDir(t *testing.T) {
 // error path inside the data pass by injecting a bad file.
 func TestCreateTarGzWithChecksumsDataWriteError(t *testing.T) {
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	tmpDir := t.TempDir()
 	pkgDir := filepath.Join(tmpDir, "pkg")
@@ -377,7 +377,7 @@ func TestCreateTarGzWithChecksumsControlWriteError(t *testing.T) {
 	}
 
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	tmpDir := t.TempDir()
 	pkgDir := filepath.Join(tmpDir, "pkg")
@@ -26,7 +26,7 @@ func createTestPKGBUILD() *pkgbuild.PKGBUILD {
 
 func TestNewBuilder(t *testing.T) {
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	if builder.BaseBuilder == nil {
 		t.Fatal("BaseBuilder is nil")
@@ -35,7 +35,7 @@ func TestNewBuilder(t *testing.T) {
 
 func TestBuildPackage(t *testing.T) {
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	tempDir, err := os.MkdirTemp("", "apk-test")
 	if err != nil {
@@ -78,7 +78,7 @@ func TestBuildPackage(t *testing.T) {
 
 func TestPrepareFakeroot(t *testing.T) {
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	tempDir, err := os.MkdirTemp("", "apk-test")
 	if err != nil {
@@ -118,7 +118,7 @@ func TestPrepareFakerootWithScripts(t *testing.T) {
 	pkgBuild := createTestPKGBUILD()
 	pkgBuild.PreInst = "echo 'pre-install'"
 	pkgBuild.PostInst = "echo 'post-install'"
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	tempDir, err := os.MkdirTemp("", "apk-test")
 	if err != nil {
@@ -156,7 +156,7 @@ func TestPrepare(t *testing.T) {
 	}
 
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	makeDepends := []string{"make", "gcc"}
 	err := builder.Prepare(context.Background(), makeDepends, "")
@@ -173,7 +173,7 @@ func TestPrepareEnvironment(t *testing.T) {
 	}
 
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	err := builder.PrepareEnvironment(context.Background(), false, "")
 	// This will likely fail since apk isn't installed, but we test the method call
@@ -189,7 +189,7 @@ func TestPrepareEnvironmentWithGolang(t *testing.T) {
 	}
 
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	err := builder.PrepareEnvironment(context.Background(), true, "")
 	// This will likely fail since apk isn't installed, but we test the method call
@@ -210,7 +210,7 @@ func TestUpdate(t *testing.T) {
 	}
 
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	err := builder.Update(context.Background())
 	// This will likely fail since apk isn't installed, but we test the method call
@@ -221,7 +221,7 @@ func TestUpdate(t *testing.T) {
 
 func TestCreateAPKPackage(t *testing.T) {
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	tempDir, err := os.MkdirTemp("", "apk-test")
 	if err != nil {
@@ -263,7 +263,7 @@ func TestCreateAPKPackage(t *testing.T) {
 
 func TestCreatePkgInfo(t *testing.T) {
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	tempDir, err := os.MkdirTemp("", "apk-test")
 	if err != nil {
@@ -320,7 +320,7 @@ func TestCreateInstallScript(t *testing.T) {
 	pkgBuild := createTestPKGBUILD()
 	pkgBuild.PreInst = "echo 'pre-install'"
 	pkgBuild.PostInst = "echo 'post-install'"
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	tempDir, err := os.MkdirTemp("", "apk-test")
 	if err != nil {
@@ -612,7 +612,7 @@ func TestWalkAPKFilesEmpty(t *testing.T) {
 
 func TestCreateTarGzWithChecksumsNonExistentDir(t *testing.T) {
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	tmpDir, err := os.MkdirTemp("", "apk-test")
 	if err != nil {
@@ -629,7 +629,7 @@ func TestCreateTarGzWithChecksumsNonExistentDir(t *testing.T) {
 
 func TestWriteFileWithChecksumDirectory(t *testing.T) {
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	tmpDir, err := os.MkdirTemp("", "apkwfc-*")
 	if err != nil {
@@ -666,7 +666,7 @@ func TestCreateTarGzWithChecksumsWithSubdirAndControlFile(t *testing.T) {
 	// Exercises: directory nameInArchive suffix append, isControlFile skip in data pass,
 	// and control file write in control pass.
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	tmpDir, err := os.MkdirTemp("", "apk-test")
 	if err != nil {
@@ -714,7 +714,7 @@ func TestCreateTarGzWithChecksumsWithSubdirAndControlFile(t *testing.T) {
 func TestWriteFileWithChecksumCancelledContext(t *testing.T) {
 	// Exercises the ctx.Err() early-return branch.
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	tmpFile, err := os.CreateTemp("", "apkwfc-*")
 	if err != nil {
@@ -758,7 +758,7 @@ func TestWriteFileWithChecksumCancelledContext(t *testing.T) {
 func TestWriteFileWithChecksumEmptyNameInArchive(t *testing.T) {
 	// Exercises the hdr.Name == "" fallback to file.Name().
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	tmpFile, err := os.CreateTemp("", "apkwfc-*")
 	if err != nil {
@@ -801,7 +801,7 @@ func TestWriteFileWithChecksumControlFileRegular(t *testing.T) {
 	// Exercises the else branch of writeFileWithChecksum: control file that is TypeReg
 	// (isControlFile == true, so no SHA1 checksum, but data is still written).
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	tmpDir, err := os.MkdirTemp("", "apkwfc-*")
 	if err != nil {
@@ -846,7 +846,7 @@ func TestWriteFileWithChecksumControlFileRegular(t *testing.T) {
 
 func TestWriteFileWithChecksumSymlink(t *testing.T) {
 	pkgBuild := createTestPKGBUILD()
-	builder := NewBuilder(pkgBuild)
+	builder := NewBuilder(pkgBuild, "", "")
 
 	tmpDir, err := os.MkdirTemp("", "apkwfc-*")
 	if err != nil {
@@ -8,7 +8,7 @@ const dotPkginfo = `# Generated by yap {{.YAPVersion}}
 pkgname = {{.PkgName}}
 pkgver = {{if .Epoch}}{{.Epoch}}:{{end}}{{.PkgVer}}-r{{.PkgRel}}
 pkgdesc = {{.PkgDesc}}
-url = {{.URL}}
+url = {{.HomepageURL}}
 builddate = {{.BuildDate}}
 {{- if .Maintainer}}
 packager = {{.Maintainer}}
@@ -33,6 +33,9 @@ commit = {{.Commit}}
 {{ range .Provides }}provides = {{ . }}
 {{ end }}
 {{- end }}
+{{- if .ProviderPriority}}
+provider_priority = {{.ProviderPriority}}
+{{- end }}
 {{- if .Depends}}
 {{ range .Depends }}depend = {{ . }}
 {{ end }}
@@ -22,7 +22,7 @@ func TestDotPkginfoTemplate(t *testing.T) {
 	}
 
 	templateVars := []string{
-		"{{.PkgName}}", "{{.PkgVer}}", "{{.PkgDesc}}", "{{.URL}}",
+		"{{.PkgName}}", "{{.PkgVer}}", "{{.PkgDesc}}", "{{.HomepageURL}}",
 		"{{.BuildDate}}", "{{.InstalledSize}}", "{{.ArchComputed}}",
 	}
 
@@ -33,6 +33,35 @@ func TestDotPkginfoTemplate(t *testing.T) {
 	}
 }
 
+func TestDotPkginfoHomepage(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		homepage string
+		want     string
+	}{
+		{"homepage wins over url", "https://example.com/src", "https://example.com", "url = https://example.com\n"},
+		{"url is the fallback", "https://example.com/src", "", "url = https://example.com/src\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkgBuild := createTestPKGBUILD()
+			pkgBuild.URL = tt.url
+			pkgBuild.Homepage = tt.homepage
+
+			var out strings.Builder
+			if err := pkgBuild.RenderSpec(dotPkginfo).Execute(&out, pkgBuild); err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			if !strings.Contains(out.String(), tt.want) {
+				t.Errorf(".PKGINFO =\n%s\nwant line %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
 func TestInstallScriptTemplate(t *testing.T) {
 	if installScript == "" {
 		t.Error("installScript template is empty")
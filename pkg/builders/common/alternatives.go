@@ -0,0 +1,76 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/M0Rf30/yap/v2/pkg/alternatives"
+	"github.com/M0Rf30/yap/v2/pkg/constants"
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/files"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+)
+
+// Alternatives returns the alternatives declared in alternatives=(). The
+// entries are validated with the PKGBUILD, so malformed ones cannot reach
+// the builders.
+func (bb *BaseBuilder) Alternatives() []alternatives.Alternative {
+	alts, _ := alternatives.ParseAll(bb.PKGBUILD.Alternatives)
+
+	return alts
+}
+
+// ShipAlternatives gives formats without update-alternatives their nearest
+// equivalent of the alternatives=() entries: the package ships each link
+// pointing to its alternative and provides the alternative name, so that
+// packages offering the same command exclude one another. Pacman and xbps
+// packages also conflict with the name; apk packages instead rank their
+// providers by provider_priority, the highest alternative priority.
+func (bb *BaseBuilder) ShipAlternatives() error {
+	alts := bb.Alternatives()
+	if len(alts) == 0 {
+		return nil
+	}
+
+	pkgBuild := bb.PKGBUILD
+
+	for _, alt := range alts {
+		link := filepath.Join(pkgBuild.PackageDir, alt.Link)
+
+		if _, err := os.Lstat(link); os.IsNotExist(err) {
+			err = files.ExistsMakeDir(filepath.Dir(link))
+			if err == nil {
+				err = os.Symlink(alt.Path, link)
+			}
+
+			if err != nil {
+				return errors.Wrap(err, errors.ErrTypeFileSystem,
+					i18n.T("errors.alternatives.failed_to_apply")).
+					WithOperation("ShipAlternatives").
+					WithContext("name", alt.Name).
+					WithContext("path", alt.Link)
+			}
+		}
+
+		pkgBuild.Provides = appendMissing(pkgBuild.Provides, alt.Name)
+
+		switch bb.Format {
+		case constants.FormatPacman, constants.FormatXBPS:
+			pkgBuild.Conflicts = appendMissing(pkgBuild.Conflicts, alt.Name)
+		case constants.FormatAPK:
+			pkgBuild.ProviderPriority = max(pkgBuild.ProviderPriority, alt.Priority)
+		}
+	}
+
+	return nil
+}
+
+// appendMissing appends name to list unless it is already there.
+func appendMissing(list []string, name string) []string {
+	if slices.Contains(list, name) {
+		return list
+	}
+
+	return append(list, name)
+}
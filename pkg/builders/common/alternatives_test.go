@@ -0,0 +1,87 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/M0Rf30/yap/v2/pkg/constants"
+	"github.com/M0Rf30/yap/v2/pkg/pkgbuild"
+)
+
+// withAlternatives declares two alternatives of the package, /usr/bin/foo
+// providing editor and vi.
+func withAlternatives(pkg *pkgbuild.PKGBUILD) {
+	pkg.Provides = []string{"editor"}
+	pkg.Alternatives = []string{
+		"/usr/bin/editor:editor:/usr/bin/foo:50",
+		"/usr/bin/vi:vi:/usr/bin/foo:20",
+	}
+}
+
+func TestShipAlternatives(t *testing.T) {
+	tests := []struct {
+		format    string
+		conflicts []string
+		priority  int
+	}{
+		{format: constants.FormatAPK, priority: 50},
+		{format: constants.FormatPacman, conflicts: []string{"editor", "vi"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			bb := newTestBuilder(t, tt.format, withAlternatives)
+
+			if err := bb.ShipAlternatives(); err != nil {
+				t.Fatalf("ShipAlternatives() error = %v", err)
+			}
+
+			target, err := os.Readlink(filepath.Join(bb.PKGBUILD.PackageDir, "usr/bin/editor"))
+			if err != nil || target != "/usr/bin/foo" {
+				t.Errorf("usr/bin/editor -> %q, %v, want /usr/bin/foo", target, err)
+			}
+
+			pkgBuild := bb.PKGBUILD
+			if want := []string{"editor", "vi"}; !reflect.DeepEqual(pkgBuild.Provides, want) {
+				t.Errorf("Provides = %v, want %v", pkgBuild.Provides, want)
+			}
+
+			if !reflect.DeepEqual(pkgBuild.Conflicts, tt.conflicts) {
+				t.Errorf("Conflicts = %v, want %v", pkgBuild.Conflicts, tt.conflicts)
+			}
+
+			if pkgBuild.ProviderPriority != tt.priority {
+				t.Errorf("ProviderPriority = %d, want %d", pkgBuild.ProviderPriority, tt.priority)
+			}
+		})
+	}
+}
+
+func TestSystemScriptletsAlternatives(t *testing.T) {
+	deb, err := newTestBuilder(t, constants.FormatDEB, withAlternatives).SystemScriptlets()
+	if err != nil {
+		t.Fatalf("SystemScriptlets() error = %v", err)
+	}
+
+	want := "update-alternatives --install /usr/bin/editor editor /usr/bin/foo 50"
+	if !strings.Contains(deb.PostInst, want) {
+		t.Errorf("postinst = %q, want it to contain %q", deb.PostInst, want)
+	}
+
+	if want = "update-alternatives --remove vi /usr/bin/foo"; !strings.Contains(deb.PreRm, want) {
+		t.Errorf("prerm = %q, want it to contain %q", deb.PreRm, want)
+	}
+
+	rpm, err := newTestBuilder(t, constants.FormatRPM, withAlternatives).SystemScriptlets()
+	if err != nil {
+		t.Fatalf("SystemScriptlets() error = %v", err)
+	}
+
+	want = "if [ $1 -eq 0 ]; then\n\tupdate-alternatives --remove editor /usr/bin/foo"
+	if !strings.Contains(rpm.PostRm, want) {
+		t.Errorf("%%postun = %q, want it to contain %q", rpm.PostRm, want)
+	}
+}
@@ -0,0 +1,292 @@
+package common
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/M0Rf30/yap/v2/pkg/constants"
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/files"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/shell"
+)
+
+// autosplitSuffixes are the name suffixes of the subpackages autosplit=()
+// generates, per kind and format, following the distribution conventions.
+var autosplitSuffixes = map[string]map[string]string{
+	"dev": {
+		constants.FormatAPK:    "-dev",
+		constants.FormatDEB:    "-dev",
+		constants.FormatPacman: "-dev",
+		constants.FormatRPM:    "-devel",
+		constants.FormatXBPS:   "-devel",
+	},
+	"doc": {
+		constants.FormatAPK:    "-doc",
+		constants.FormatDEB:    "-doc",
+		constants.FormatPacman: "-docs",
+		constants.FormatRPM:    "-doc",
+		constants.FormatXBPS:   "-doc",
+	},
+	"lang": {
+		constants.FormatAPK:    "-lang",
+		constants.FormatDEB:    "-l10n",
+		constants.FormatPacman: "-i18n",
+		constants.FormatRPM:    "-lang",
+		constants.FormatXBPS:   "-lang",
+	},
+}
+
+// autosplitDescriptions describe the subpackages autosplit=() generates.
+var autosplitDescriptions = map[string]string{
+	"dev":  "Development files for ",
+	"doc":  "Documentation for ",
+	"lang": "Translations for ",
+}
+
+// autosplitResets are the overrides the generated subpackages clear, so
+// that they inherit none of the relations, services and scriptlets of the
+// package they are split from.
+var autosplitResets = []string{
+	"optdepends", "provides", "conflicts", "replaces", "backup",
+	"systemd_units", "sysusers", "tmpfiles", "alternatives", "triggers", "ghost", "noverify",
+}
+
+// Autosplit moves the files matching the autosplit=() kinds out of the
+// package directory into generated subpackages and turns the PKGBUILD into
+// a split one, so that they are built like declared package_<name>()
+// functions. Each subpackage depends on the package, the development one
+// on its exact version. Kinds matching no file are skipped and split
+// PKGBUILDs are left alone.
+func (bb *BaseBuilder) Autosplit() error {
+	pkgBuild := bb.PKGBUILD
+	if len(pkgBuild.Autosplit) == 0 || pkgBuild.IsSplitPackage() {
+		return nil
+	}
+
+	moves, err := bb.autosplitMoves()
+	if err != nil || len(moves) == 0 {
+		return err
+	}
+
+	if err := bb.relocatePackageDir(); err != nil {
+		return err
+	}
+
+	name, mainDir := pkgBuild.PkgName, pkgBuild.PackageDir
+
+	for _, kind := range pkgBuild.Autosplit {
+		if len(moves[kind]) == 0 {
+			continue
+		}
+
+		subName := bb.autosplitName(kind)
+
+		pkgBuild.SetPackageDirForSplit(subName)
+
+		if err := moveTree(mainDir, pkgBuild.PackageDir, moves[kind]); err != nil {
+			return err
+		}
+
+		pkgBuild.AddSplitPackage(subName, bb.autosplitFunc(kind))
+	}
+
+	pkgBuild.PkgName, pkgBuild.PackageDir = name, mainDir
+
+	return nil
+}
+
+// autosplitName returns the name of the kind subpackage: the suffix of the
+// format appended to the package name, without the soname version of
+// Debian library packages, so that libfoo1 gets libfoo1-doc but
+// libfoo-dev.
+func (bb *BaseBuilder) autosplitName(kind string) string {
+	name := bb.PKGBUILD.PkgName
+
+	if kind == "dev" && bb.Format == constants.FormatDEB && strings.HasPrefix(name, "lib") {
+		if base := strings.TrimSuffix(strings.TrimRight(name, "0123456789."), "-"); len(base) > len("lib") {
+			name = base
+		}
+	}
+
+	return name + autosplitSuffixes[kind][bb.Format]
+}
+
+// autosplitFunc returns the package_<name>() body of the kind subpackage,
+// holding only its overrides.
+func (bb *BaseBuilder) autosplitFunc(kind string) string {
+	depend := bb.PKGBUILD.PkgName
+	if kind == "dev" {
+		depend += "=" + bb.fullVersion()
+	}
+
+	lines := []string{
+		`pkgdesc="` + autosplitDescriptions[kind] + bb.PKGBUILD.PkgName + `"`,
+		`pkgdesc_long=""`,
+		`install=""`,
+		"depends=(" + shell.SingleQuote(depend) + ")",
+	}
+
+	for _, key := range autosplitResets {
+		lines = append(lines, key+"=()")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// autosplitMoves returns, by kind, the paths of the package directory, relative
+// to it, that autosplit=() moves into a subpackage. Files declared in
+// licensefiles=() stay with the package.
+func (bb *BaseBuilder) autosplitMoves() (map[string][]string, error) {
+	root := bb.PKGBUILD.PackageDir
+	rules := bb.FlagRules()
+	moves := make(map[string][]string)
+
+	err := filepath.WalkDir(root, func(walked string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, walked)
+		if err != nil {
+			return err
+		}
+
+		rel = filepath.ToSlash(rel)
+		if files.MatchFlags(rules, "/"+rel)&files.FlagLicense != 0 {
+			return nil
+		}
+
+		for _, kind := range bb.PKGBUILD.Autosplit {
+			if autosplitMatch(kind, rel, entry.Type()&fs.ModeSymlink != 0) {
+				moves[kind] = append(moves[kind], rel)
+
+				break
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypeFileSystem,
+			i18n.T("errors.common.failed_to_split_package")).
+			WithOperation("Autosplit").
+			WithContext("path", root)
+	}
+
+	return moves, nil
+}
+
+// autosplitMatch reports whether the file at rel, relative to the package
+// root, belongs in the kind subpackage:
+//   - dev: headers, pkg-config and CMake files, static libraries and the
+//     unversioned .so links only used when linking;
+//   - doc: /usr/share/doc, man and info pages;
+//   - lang: message catalogs under /usr/share/locale.
+func autosplitMatch(kind, rel string, symlink bool) bool {
+	dir := path.Dir(rel)
+
+	switch kind {
+	case "dev":
+		switch {
+		case strings.HasPrefix(rel, "usr/include/"):
+			return true
+		case path.Ext(rel) == ".pc":
+			return path.Base(dir) == "pkgconfig"
+		case path.Ext(rel) == ".a":
+			return isLibDir(dir)
+		case path.Ext(rel) == ".so":
+			return symlink && isLibDir(dir)
+		}
+
+		prefix, _, ok := strings.Cut(rel, "/cmake/")
+
+		return ok && (prefix == "usr/share" || isLibDir(prefix))
+	case "doc":
+		return strings.HasPrefix(rel, "usr/share/doc/") ||
+			strings.HasPrefix(rel, "usr/share/man/") ||
+			strings.HasPrefix(rel, "usr/share/info/")
+	case "lang":
+		return strings.HasPrefix(rel, "usr/share/locale/")
+	}
+
+	return false
+}
+
+// isLibDir reports whether dir is a library directory: /lib, /usr/lib and
+// their multilib and multiarch (e.g. usr/lib/x86_64-linux-gnu) variants.
+func isLibDir(dir string) bool {
+	switch dir {
+	case "lib", "lib32", "lib64", "usr/lib", "usr/lib32", "usr/lib64", "usr/libx32":
+		return true
+	}
+
+	parent := path.Dir(dir)
+
+	return (parent == "lib" || parent == "usr/lib") && strings.Contains(path.Base(dir), "-linux-")
+}
+
+// relocatePackageDir moves the package directory to the one of the first
+// package of a split PKGBUILD, which for most formats lies below it.
+func (bb *BaseBuilder) relocatePackageDir() error {
+	pkgBuild := bb.PKGBUILD
+	oldDir := pkgBuild.PackageDir
+
+	pkgBuild.SetPackageDirForSplit(pkgBuild.PkgName)
+
+	newDir := pkgBuild.PackageDir
+	if newDir == oldDir {
+		return nil
+	}
+
+	tmpDir := oldDir + ".autosplit"
+
+	err := os.Rename(oldDir, tmpDir)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(newDir), 0o755)
+	}
+
+	if err == nil {
+		err = os.Rename(tmpDir, newDir)
+	}
+
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeFileSystem,
+			i18n.T("errors.common.failed_to_split_package")).
+			WithOperation("Autosplit").
+			WithContext("path", oldDir)
+	}
+
+	return nil
+}
+
+// moveTree moves the paths, relative to src, into the same place under dst,
+// removing the directories of src they leave empty.
+func moveTree(src, dst string, paths []string) error {
+	for _, rel := range paths {
+		target := filepath.Join(dst, filepath.FromSlash(rel))
+
+		err := os.MkdirAll(filepath.Dir(target), 0o755)
+		if err == nil {
+			err = os.Rename(filepath.Join(src, filepath.FromSlash(rel)), target)
+		}
+
+		if err != nil {
+			return errors.Wrap(err, errors.ErrTypeFileSystem,
+				i18n.T("errors.common.failed_to_split_package")).
+				WithOperation("Autosplit").
+				WithContext("path", rel)
+		}
+
+		for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+			if os.Remove(filepath.Join(src, filepath.FromSlash(dir))) != nil {
+				break
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/M0Rf30/yap/v2/pkg/constants"
+	"github.com/M0Rf30/yap/v2/pkg/pkgbuild"
+)
+
+func TestAutosplitMatch(t *testing.T) {
+	tests := []struct {
+		kind    string
+		rel     string
+		symlink bool
+		want    bool
+	}{
+		{"dev", "usr/include/foo/foo.h", false, true},
+		{"dev", "usr/lib/libfoo.so", true, true},
+		{"dev", "usr/lib/libfoo.so", false, false},
+		{"dev", "usr/lib/libfoo.so.1", true, false},
+		{"dev", "usr/lib/x86_64-linux-gnu/libfoo.a", false, true},
+		{"dev", "usr/lib/foo/plugin.so", true, false},
+		{"dev", "usr/lib64/pkgconfig/foo.pc", false, true},
+		{"dev", "usr/share/pkgconfig/foo.pc", false, true},
+		{"dev", "usr/lib/cmake/Foo/FooConfig.cmake", false, true},
+		{"dev", "usr/share/cmake/Modules/FindFoo.cmake", false, true},
+		{"dev", "usr/share/foo/cmake/data", false, false},
+		{"doc", "usr/share/doc/foo/README", false, true},
+		{"doc", "usr/share/man/man1/foo.1.gz", false, true},
+		{"doc", "usr/share/info/foo.info", false, true},
+		{"doc", "usr/share/locale/it/LC_MESSAGES/foo.mo", false, false},
+		{"lang", "usr/share/locale/it/LC_MESSAGES/foo.mo", false, true},
+		{"lang", "usr/bin/foo", false, false},
+	}
+
+	for _, tt := range tests {
+		if got := autosplitMatch(tt.kind, tt.rel, tt.symlink); got != tt.want {
+			t.Errorf("autosplitMatch(%q, %q, %v) = %v, want %v", tt.kind, tt.rel, tt.symlink, got, tt.want)
+		}
+	}
+}
+
+func TestAutosplitName(t *testing.T) {
+	tests := []struct {
+		format  string
+		pkgName string
+		kind    string
+		want    string
+	}{
+		{constants.FormatDEB, "libfoo1", "dev", "libfoo-dev"},
+		{constants.FormatDEB, "libfoo1", "doc", "libfoo1-doc"},
+		{constants.FormatDEB, "foo", "lang", "foo-l10n"},
+		{constants.FormatRPM, "libfoo1", "dev", "libfoo1-devel"},
+		{constants.FormatAPK, "foo", "dev", "foo-dev"},
+		{constants.FormatPacman, "foo", "doc", "foo-docs"},
+	}
+
+	for _, tt := range tests {
+		bb := &BaseBuilder{PKGBUILD: &pkgbuild.PKGBUILD{PkgName: tt.pkgName}, Format: tt.format}
+
+		if got := bb.autosplitName(tt.kind); got != tt.want {
+			t.Errorf("autosplitName(%q) for %s %s = %q, want %q", tt.kind, tt.format, tt.pkgName, got, tt.want)
+		}
+	}
+}
+
+func TestAutosplit(t *testing.T) {
+	startDir := t.TempDir()
+	pkgBuild := &pkgbuild.PKGBUILD{
+		PkgName:    "foo",
+		PkgVer:     "1.2",
+		PkgRel:     "1",
+		Distro:     "fedora",
+		StartDir:   startDir,
+		PackageDir: filepath.Join(startDir, "pkg-fedora"),
+		Autosplit:  []string{"dev", "doc", "lang"},
+		Provides:   []string{"bar"},
+	}
+	pkgBuild.Init()
+
+	for _, rel := range []string{"usr/bin/foo", "usr/include/foo.h", "usr/lib64/libfoo.so.1", "usr/share/man/man1/foo.1"} {
+		path := filepath.Join(pkgBuild.PackageDir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.Symlink("libfoo.so.1", filepath.Join(pkgBuild.PackageDir, "usr/lib64/libfoo.so")); err != nil {
+		t.Fatal(err)
+	}
+
+	bb := &BaseBuilder{PKGBUILD: pkgBuild, Format: constants.FormatRPM}
+	if err := bb.Autosplit(); err != nil {
+		t.Fatalf("Autosplit() error = %v", err)
+	}
+
+	if want := []string{"foo", "foo-devel", "foo-doc"}; !reflect.DeepEqual(pkgBuild.PkgNames, want) {
+		t.Errorf("PkgNames = %v, want %v", pkgBuild.PkgNames, want)
+	}
+
+	for rel, exists := range map[string]bool{
+		"foo/usr/bin/foo":                  true,
+		"foo/usr/lib64/libfoo.so.1":        true,
+		"foo/usr/lib64/libfoo.so":          false,
+		"foo/usr/include":                  false,
+		"foo/usr/share":                    false,
+		"foo-devel/usr/include/foo.h":      true,
+		"foo-devel/usr/lib64/libfoo.so":    true,
+		"foo-doc/usr/share/man/man1/foo.1": true,
+	} {
+		if _, err := os.Lstat(filepath.Join(startDir, "pkg-fedora", rel)); (err == nil) != exists {
+			t.Errorf("%s exists = %v, want %v", rel, err == nil, exists)
+		}
+	}
+
+	pkgBuild.PkgName = "foo-devel"
+	pkgBuild.RestoreTopLevelOverrides()
+
+	if err := pkgBuild.ParseSplitOverrides(pkgBuild.SplitPackageFuncs["foo-devel"]); err != nil {
+		t.Fatalf("ParseSplitOverrides() error = %v", err)
+	}
+
+	if pkgBuild.PkgDesc != "Development files for foo" || !reflect.DeepEqual(pkgBuild.Depends, []string{"foo=1.2-1"}) {
+		t.Errorf("foo-devel pkgdesc = %q, depends = %v", pkgBuild.PkgDesc, pkgBuild.Depends)
+	}
+
+	if len(pkgBuild.Provides) != 0 {
+		t.Errorf("foo-devel provides = %v, want none", pkgBuild.Provides)
+	}
+}
@@ -0,0 +1,284 @@
+package common
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/M0Rf30/yap/v2/pkg/binary"
+	"github.com/M0Rf30/yap/v2/pkg/constants"
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/files"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/options"
+)
+
+const (
+	debugSymbolsDir   = "usr/lib/debug"
+	debugSourcesDir   = "usr/src/debug"
+	debugSourceSuffix = "-debugsource"
+	debugSection      = "debug"
+)
+
+// debugSuffixes are the name suffixes of the debug-symbol package of each
+// format, following the distribution conventions.
+var debugSuffixes = map[string]string{
+	constants.FormatAPK:    "-dbg",
+	constants.FormatDEB:    "-dbgsym",
+	constants.FormatPacman: "-debug",
+	constants.FormatRPM:    "-debuginfo",
+	constants.FormatXBPS:   "-dbg",
+}
+
+// DebugPackage is a debug package staged from the symbols separated while
+// stripping a package.
+type DebugPackage struct {
+	Name        string
+	Description string
+	// Dir is the package tree, e.g. holding
+	// usr/lib/debug/.build-id/xx/yyyy.debug.
+	Dir      string
+	Depends  []string
+	Provides []string
+	BuildIDs []string
+}
+
+// DebugRoot returns the directory holding the debug package trees of the
+// package: the --debug-dir directory when set, a sibling of the package
+// directory when options=(debug) is, and "" when no debug symbols are
+// separated.
+func (bb *BaseBuilder) DebugRoot() string {
+	if dir := options.DebugDir(); dir != "" {
+		return dir
+	}
+
+	if bb.PKGBUILD.DebugEnabled {
+		return bb.PKGBUILD.PackageDir + ".debug"
+	}
+
+	return ""
+}
+
+// debugSymbolsTree returns the tree of the debug-symbol package of the
+// package under root, or "" when no debug symbols are separated.
+func (bb *BaseBuilder) debugSymbolsTree(root string) string {
+	if root == "" || !bb.PKGBUILD.StripEnabled {
+		return ""
+	}
+
+	return filepath.Join(root, bb.PKGBUILD.PkgName+debugSuffixes[bb.Format])
+}
+
+// debugSourcesTree returns the tree of the rpm -debugsource package.
+func (bb *BaseBuilder) debugSourcesTree(root string) string {
+	return filepath.Join(root, bb.PKGBUILD.PkgName+debugSourceSuffix)
+}
+
+// prepareDebugDir clears the debug package trees of a previous build and
+// returns the directory stripping separates the debug symbols into, or ""
+// when none should be.
+func (bb *BaseBuilder) prepareDebugDir() (string, error) {
+	root := bb.DebugRoot()
+
+	tree := bb.debugSymbolsTree(root)
+	if tree == "" {
+		return "", nil
+	}
+
+	for _, dir := range []string{tree, bb.debugSourcesTree(root)} {
+		if err := os.RemoveAll(dir); err != nil {
+			return "", errors.Wrap(err, errors.ErrTypeFileSystem,
+				i18n.T("errors.common.failed_to_stage_debug_package")).
+				WithOperation("prepareDebugDir").
+				WithContext("path", dir)
+		}
+	}
+
+	return filepath.Join(tree, debugSymbolsDir), nil
+}
+
+// DebugPackages returns the debug packages of the package staged by
+// ApplyOptions: the debug-symbol package, named after debugSuffixes and
+// depending on the exact version of the package, and for rpm a -debugsource
+// package shipping the sources under srcdir the symbols refer to. It
+// returns none when no debug symbols were separated.
+func (bb *BaseBuilder) DebugPackages() ([]DebugPackage, error) {
+	root := bb.DebugRoot()
+
+	tree := bb.debugSymbolsTree(root)
+	if tree == "" {
+		return nil, nil
+	}
+
+	buildIDs := debugBuildIDs(tree)
+	if len(buildIDs) == 0 {
+		return nil, nil
+	}
+
+	name := bb.PKGBUILD.PkgName
+	symbols := DebugPackage{
+		Name:        name + debugSuffixes[bb.Format],
+		Description: "Debug symbols for " + name,
+		Dir:         tree,
+		Depends:     []string{name + "=" + bb.fullVersion()},
+		BuildIDs:    buildIDs,
+	}
+
+	if bb.Format != constants.FormatRPM {
+		return []DebugPackage{symbols}, nil
+	}
+
+	for _, id := range buildIDs {
+		symbols.Provides = append(symbols.Provides, "debuginfo(build-id)="+id)
+	}
+
+	sources := bb.debugSourcesTree(root)
+
+	staged, err := bb.stageDebugSources(tree, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	if !staged {
+		return []DebugPackage{symbols}, nil
+	}
+
+	return []DebugPackage{symbols, {
+		Name:        name + debugSourceSuffix,
+		Description: "Debug sources for " + name,
+		Dir:         sources,
+	}}, nil
+}
+
+// EnterDebugPackage points the PKGBUILD at pkg, so that PrepareFakeroot and
+// BuildPackage produce it, and returns a function restoring the package
+// it was staged from. The debug package keeps the version, architecture
+// and licensing of that package but none of its relations, scriptlets or
+// configuration files, and its tree is packaged as is.
+func (bb *BaseBuilder) EnterDebugPackage(pkg DebugPackage) (restore func()) {
+	saved := *bb.PKGBUILD
+	pkgBuild := bb.PKGBUILD
+
+	pkgBuild.PkgName, pkgBuild.PkgDesc, pkgBuild.PackageDir = pkg.Name, pkg.Description, pkg.Dir
+	pkgBuild.PkgDescLong, pkgBuild.Keywords = "", nil
+	pkgBuild.Depends, pkgBuild.Provides, pkgBuild.BuildIDs = pkg.Depends, pkg.Provides, pkg.BuildIDs
+	pkgBuild.Section, pkgBuild.PkgType = debugSection, debugSection
+
+	pkgBuild.PreDepends, pkgBuild.OptDepends, pkgBuild.Suggests = nil, nil, nil
+	pkgBuild.Enhances, pkgBuild.Supplements, pkgBuild.BuiltUsing = nil, nil, nil
+	pkgBuild.Conflicts, pkgBuild.Breaks, pkgBuild.Replaces = nil, nil, nil
+	pkgBuild.Backup, pkgBuild.FileOwners = nil, nil
+	pkgBuild.SystemdUnits, pkgBuild.SysUsers, pkgBuild.TmpFiles = nil, nil, nil
+	pkgBuild.Alternatives, pkgBuild.Triggers, pkgBuild.ProviderPriority = nil, nil, 0
+	pkgBuild.GhostFiles, pkgBuild.DocFiles, pkgBuild.LicenseFiles, pkgBuild.NoVerifyFiles = nil, nil, nil, nil
+
+	pkgBuild.PreInst, pkgBuild.PostInst, pkgBuild.PreRm, pkgBuild.PostRm = "", "", "", ""
+	pkgBuild.PreTrans, pkgBuild.PostTrans, pkgBuild.PreUpgrade, pkgBuild.PostUpgrade = "", "", "", ""
+	pkgBuild.Install, pkgBuild.DebConfig, pkgBuild.DebTemplate, pkgBuild.Changelog = "", "", "", ""
+
+	pkgBuild.AutoDepsEnabled, pkgBuild.DebugEnabled, pkgBuild.StripEnabled = false, false, false
+	pkgBuild.PurgeEnabled, pkgBuild.ZipManEnabled = false, false
+	pkgBuild.DocsEnabled, pkgBuild.EmptyDirsEnabled = true, true
+	pkgBuild.LibtoolEnabled, pkgBuild.StaticEnabled = true, true
+
+	return func() {
+		*bb.PKGBUILD = saved
+	}
+}
+
+// fullVersion returns the version of the package as its format writes it
+// in dependencies, e.g. "1:2.0-1", "2.0-r1" for apk or "2.0_1" for xbps,
+// which has no epoch.
+func (bb *BaseBuilder) fullVersion() string {
+	separator := "-"
+
+	switch bb.Format {
+	case constants.FormatAPK:
+		separator = "-r"
+	case constants.FormatXBPS:
+		return bb.PKGBUILD.PkgVer + "_" + bb.PKGBUILD.PkgRel
+	}
+
+	version := bb.PKGBUILD.PkgVer + separator + bb.PKGBUILD.PkgRel
+	if bb.PKGBUILD.Epoch != "" {
+		version = bb.PKGBUILD.Epoch + ":" + version
+	}
+
+	return version
+}
+
+// debugBuildIDs returns the build IDs of the debug symbols under tree,
+// laid out as usr/lib/debug/.build-id/xx/yyyy.debug, in sorted order.
+func debugBuildIDs(tree string) []string {
+	matches, _ := filepath.Glob(filepath.Join(tree, debugSymbolsDir, ".build-id", "*", "*.debug"))
+
+	buildIDs := make([]string, 0, len(matches))
+	for _, match := range matches {
+		buildIDs = append(buildIDs,
+			filepath.Base(filepath.Dir(match))+strings.TrimSuffix(filepath.Base(match), ".debug"))
+	}
+
+	slices.Sort(buildIDs)
+
+	return buildIDs
+}
+
+// stageDebugSources copies the files under srcdir that the debug symbols
+// in tree refer to into the sources tree, below
+// usr/src/debug/<name>-<version>-<release>.<arch> as rpm lays them out.
+// Sources that no longer exist, such as removed generated files, are
+// skipped. It reports whether any source was staged.
+func (bb *BaseBuilder) stageDebugSources(tree, sources string) (bool, error) {
+	srcDir := filepath.Clean(bb.PKGBUILD.SourceDir)
+	dest := filepath.Join(sources, debugSourcesDir, fmt.Sprintf("%s-%s-%s.%s",
+		bb.PKGBUILD.PkgName, bb.PKGBUILD.PkgVer, bb.PKGBUILD.PkgRel, bb.PKGBUILD.ArchComputed))
+
+	copied := make(map[string]bool)
+
+	err := filepath.WalkDir(filepath.Join(tree, debugSymbolsDir), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(path) != ".debug" {
+			return err
+		}
+
+		referenced, err := binary.SourceFiles(path)
+		if err != nil {
+			return err
+		}
+
+		for _, source := range referenced {
+			rel, err := filepath.Rel(srcDir, source)
+			if err != nil || !filepath.IsLocal(rel) || copied[rel] {
+				continue
+			}
+
+			data, err := os.ReadFile(source) //nolint:gosec // path under srcdir
+			if err != nil {
+				continue
+			}
+
+			target := filepath.Join(dest, rel)
+			if err := files.ExistsMakeDir(filepath.Dir(target)); err != nil {
+				return err
+			}
+
+			if err := os.WriteFile(target, data, 0o644); err != nil { //nolint:gosec // world-readable sources
+				return err
+			}
+
+			copied[rel] = true
+		}
+
+		return nil
+	})
+	if err != nil {
+		return false, errors.Wrap(err, errors.ErrTypeFileSystem,
+			i18n.T("errors.common.failed_to_stage_debug_package")).
+			WithOperation("stageDebugSources").
+			WithContext("path", sources)
+	}
+
+	return len(copied) > 0, nil
+}
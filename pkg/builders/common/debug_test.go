@@ -0,0 +1,191 @@
+package common
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/M0Rf30/yap/v2/pkg/constants"
+	"github.com/M0Rf30/yap/v2/pkg/pkgbuild"
+)
+
+// withDebugSymbols sets up a stripped package with debug packages enabled.
+func withDebugSymbols(pkg *pkgbuild.PKGBUILD) {
+	pkg.PkgVer = "1.2"
+	pkg.PkgRel = "1"
+	pkg.Epoch = "2"
+	pkg.ArchComputed = "x86_64"
+	pkg.Depends = []string{"libbar"}
+	pkg.Backup = []string{"etc/foo.conf"}
+	pkg.PostInst = "echo installed"
+	pkg.DebugEnabled = true
+	pkg.StripEnabled = true
+}
+
+func writeDebugFile(t *testing.T, tree, buildID string) string {
+	t.Helper()
+
+	path := filepath.Join(tree, debugSymbolsDir, ".build-id", buildID[:2], buildID[2:]+".debug")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestPrepareDebugDir(t *testing.T) {
+	bb := newTestBuilder(t, constants.FormatDEB, withDebugSymbols)
+
+	debugDir, err := bb.prepareDebugDir()
+	if err != nil {
+		t.Fatalf("prepareDebugDir() error = %v", err)
+	}
+
+	tree := filepath.Join(bb.PKGBUILD.PackageDir+".debug", "foo-dbgsym")
+	if want := filepath.Join(tree, debugSymbolsDir); debugDir != want {
+		t.Errorf("prepareDebugDir() = %q, want %q", debugDir, want)
+	}
+
+	stale := writeDebugFile(t, tree, "abcdef")
+
+	if _, err := bb.prepareDebugDir(); err != nil {
+		t.Fatalf("prepareDebugDir() error = %v", err)
+	}
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("symbols of a previous build must be removed")
+	}
+
+	bb.PKGBUILD.StripEnabled = false
+
+	if debugDir, _ := bb.prepareDebugDir(); debugDir != "" {
+		t.Errorf("prepareDebugDir() without strip = %q, want empty", debugDir)
+	}
+}
+
+func TestDebugPackages(t *testing.T) {
+	tests := []struct {
+		format string
+		name   string
+		depend string
+	}{
+		{format: constants.FormatAPK, name: "foo-dbg", depend: "foo=2:1.2-r1"},
+		{format: constants.FormatDEB, name: "foo-dbgsym", depend: "foo=2:1.2-1"},
+		{format: constants.FormatPacman, name: "foo-debug", depend: "foo=2:1.2-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			bb := newTestBuilder(t, tt.format, withDebugSymbols)
+
+			packages, err := bb.DebugPackages()
+			if err != nil || len(packages) != 0 {
+				t.Fatalf("DebugPackages() without symbols = %v, %v, want none", packages, err)
+			}
+
+			tree := filepath.Join(bb.DebugRoot(), tt.name)
+			writeDebugFile(t, tree, "cd0123")
+			writeDebugFile(t, tree, "ab4567")
+
+			packages, err = bb.DebugPackages()
+			if err != nil {
+				t.Fatalf("DebugPackages() error = %v", err)
+			}
+
+			want := []DebugPackage{{
+				Name:        tt.name,
+				Description: "Debug symbols for foo",
+				Dir:         tree,
+				Depends:     []string{tt.depend},
+				BuildIDs:    []string{"ab4567", "cd0123"},
+			}}
+			if !reflect.DeepEqual(packages, want) {
+				t.Errorf("DebugPackages() = %+v, want %+v", packages, want)
+			}
+		})
+	}
+}
+
+func TestDebugPackagesRPM(t *testing.T) {
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("no C compiler available")
+	}
+
+	bb := newTestBuilder(t, constants.FormatRPM, withDebugSymbols)
+
+	if err := os.MkdirAll(bb.PKGBUILD.SourceDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	source := filepath.Join(bb.PKGBUILD.SourceDir, "foo.c")
+	if err := os.WriteFile(source, []byte("int foo(void) { return 42; }\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tree := filepath.Join(bb.DebugRoot(), "foo-debuginfo")
+	debugFile := writeDebugFile(t, tree, "ab4567")
+
+	cmd := exec.CommandContext(t.Context(), cc, "-g", "-c", "foo.c", "-o", debugFile)
+	cmd.Dir = bb.PKGBUILD.SourceDir
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("cc: %v\n%s", err, out)
+	}
+
+	packages, err := bb.DebugPackages()
+	if err != nil {
+		t.Fatalf("DebugPackages() error = %v", err)
+	}
+
+	if len(packages) != 2 {
+		t.Fatalf("DebugPackages() = %+v, want debuginfo and debugsource", packages)
+	}
+
+	if want := []string{"debuginfo(build-id)=ab4567"}; !reflect.DeepEqual(packages[0].Provides, want) {
+		t.Errorf("Provides = %v, want %v", packages[0].Provides, want)
+	}
+
+	if packages[1].Name != "foo-debugsource" || packages[1].Depends != nil {
+		t.Errorf("unexpected debugsource package %+v", packages[1])
+	}
+
+	staged := filepath.Join(packages[1].Dir, debugSourcesDir, "foo-1.2-1.x86_64", "foo.c")
+	if _, err := os.Stat(staged); err != nil {
+		t.Errorf("source not staged: %v", err)
+	}
+}
+
+func TestEnterDebugPackage(t *testing.T) {
+	bb := newTestBuilder(t, constants.FormatDEB, withDebugSymbols)
+	original := *bb.PKGBUILD
+
+	restore := bb.EnterDebugPackage(DebugPackage{
+		Name:     "foo-dbgsym",
+		Dir:      "/tmp/foo-dbgsym",
+		Depends:  []string{"foo=2:1.2-1"},
+		BuildIDs: []string{"ab4567"},
+	})
+
+	pkgBuild := bb.PKGBUILD
+	if pkgBuild.PkgName != "foo-dbgsym" || pkgBuild.PackageDir != "/tmp/foo-dbgsym" ||
+		pkgBuild.Section != debugSection || pkgBuild.PkgVer != "1.2" {
+		t.Errorf("PKGBUILD not pointed at the debug package: %+v", pkgBuild)
+	}
+
+	if pkgBuild.Backup != nil || pkgBuild.PostInst != "" || pkgBuild.StripEnabled || pkgBuild.DebugEnabled {
+		t.Error("debug packages must not inherit conffiles, scriptlets or strip/debug options")
+	}
+
+	restore()
+
+	if !reflect.DeepEqual(*bb.PKGBUILD, original) {
+		t.Errorf("restore() = %+v, want %+v", *bb.PKGBUILD, original)
+	}
+}
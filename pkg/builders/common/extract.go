@@ -52,9 +52,9 @@ func (bb *BaseBuilder) ExtractToRoot(packagePath string) error {
 		// member, silently dropping the data payload. Walk every gzip stream
 		// explicitly and skip control entries.
 		extractErr = extractAPK(packagePath, "/")
-	case constants.FormatPacman:
-		// Pacman packages are plain tar.zst; the generic extractor works.
-		// Clean up metadata files afterwards.
+	case constants.FormatPacman, constants.FormatXBPS:
+		// Pacman and xbps packages are plain tar.zst; the generic extractor
+		// works. Clean up metadata files afterwards.
 		extractErr = archive.Extract(context.Background(), packagePath, "/")
 		if extractErr == nil {
 			cleanupMetadataFiles(bb.Format)
@@ -81,17 +81,25 @@ func (bb *BaseBuilder) ExtractToRoot(packagePath string) error {
 
 // cleanupMetadataFiles removes package metadata files that were extracted to
 // root. APK metadata is handled in-line by extractAPK (which never writes
-// control entries to disk), so this function only handles Pacman.
+// control entries to disk), so this function only handles Pacman and xbps.
 func cleanupMetadataFiles(format string) {
 	var metadataPatterns []string
 
-	if format == constants.FormatPacman {
+	switch format {
+	case constants.FormatPacman:
 		metadataPatterns = []string{
 			"/.PKGINFO",
 			"/.BUILDINFO",
 			"/.MTREE",
 			"/.INSTALL",
 		}
+	case constants.FormatXBPS:
+		metadataPatterns = []string{
+			"/INSTALL",
+			"/REMOVE",
+			"/props.plist",
+			"/files.plist",
+		}
 	}
 
 	for _, pattern := range metadataPatterns {
@@ -0,0 +1,49 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/files"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+)
+
+// FlagRules returns the file flags declared in ghost=(), docfiles=(),
+// licensefiles=() and noverify=(). The entries are validated with the
+// PKGBUILD, so malformed ones cannot reach the builders.
+func (bb *BaseBuilder) FlagRules() []files.FlagRule {
+	rules, _ := bb.PKGBUILD.FileFlagRules()
+
+	return rules
+}
+
+// GhostFiles returns the payload paths declared in ghost=(), with a
+// leading slash.
+func (bb *BaseBuilder) GhostFiles() []string {
+	var ghosts []string
+
+	for _, rule := range bb.FlagRules() {
+		if rule.Flags&files.FlagGhost != 0 {
+			ghosts = append(ghosts, rule.Path)
+		}
+	}
+
+	return ghosts
+}
+
+// RemoveGhostFiles drops the ghost=() paths from the package directory for
+// the formats that cannot list a file without shipping it: the files are
+// created at runtime, so a placeholder would clobber them on upgrade.
+func (bb *BaseBuilder) RemoveGhostFiles() error {
+	for _, ghost := range bb.GhostFiles() {
+		if err := os.RemoveAll(filepath.Join(bb.PKGBUILD.PackageDir, ghost)); err != nil {
+			return errors.Wrap(err, errors.ErrTypeFileSystem,
+				i18n.T("errors.common.failed_to_remove_ghost_file")).
+				WithOperation("RemoveGhostFiles").
+				WithContext("path", ghost)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/M0Rf30/yap/v2/pkg/pkgbuild"
+)
+
+// newTestBuilder returns a format builder for package foo, whose package
+// directory exists as after package(), with mutate applied to its PKGBUILD.
+func newTestBuilder(t *testing.T, format string, mutate func(*pkgbuild.PKGBUILD)) *BaseBuilder {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	pkg := &pkgbuild.PKGBUILD{
+		PkgName:    "foo",
+		StartDir:   dir,
+		SourceDir:  filepath.Join(dir, "src"),
+		PackageDir: filepath.Join(dir, "pkg", "foo"),
+	}
+
+	if err := os.MkdirAll(pkg.PackageDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if mutate != nil {
+		mutate(pkg)
+	}
+
+	return &BaseBuilder{PKGBUILD: pkg, Format: format}
+}
@@ -60,7 +60,7 @@ var depVersionRegex = regexp.MustCompile(`(<=|>=|=|>|<)`)
 // BaseBuilder provides common functionality that can be embedded in concrete builders.
 type BaseBuilder struct {
 	PKGBUILD *pkgbuild.PKGBUILD
-	Format   string // Package format: "apk", "deb", "rpm", "pacman"
+	Format   string // Package format: "apk", "deb", "rpm", "pacman", "xbps"
 }
 
 // NewBaseBuilder creates a new base builder instance.
@@ -100,7 +100,7 @@ func (bb *BaseBuilder) ProcessDependencies(depends []string) []string {
 }
 
 // BuildPackageName constructs standardized package names for different formats.
-// Used by: APK, DEB, Pacman
+// Used by: APK, DEB, Pacman, XBPS
 // Not used by: RPM (RPM does not include epoch in the filename, only in metadata)
 func (bb *BaseBuilder) BuildPackageName(extension string) string {
 	name := fmt.Sprintf("%s-%s-%s", bb.PKGBUILD.PkgName, bb.PKGBUILD.PkgVer, bb.PKGBUILD.PkgRel)
@@ -117,6 +117,9 @@ func (bb *BaseBuilder) BuildPackageName(extension string) string {
 	case constants.ExtDEB:
 		name = fmt.Sprintf("%s_%s-%s_%s", bb.PKGBUILD.PkgName, bb.PKGBUILD.PkgVer,
 			bb.PKGBUILD.PkgRel, bb.PKGBUILD.ArchComputed)
+	case constants.ExtXBPS:
+		name = fmt.Sprintf("%s-%s_%s.%s", bb.PKGBUILD.PkgName, bb.PKGBUILD.PkgVer,
+			bb.PKGBUILD.PkgRel, bb.PKGBUILD.ArchComputed)
 	default:
 		name += "-" + bb.PKGBUILD.ArchComputed
 	}
@@ -171,6 +174,8 @@ func (bb *BaseBuilder) SetupEnvironmentDeps(golang bool) ([]string, error) {
 		deps = buildDeps.RPM
 	case constants.FormatPacman:
 		deps = buildDeps.Pacman
+	case constants.FormatXBPS:
+		deps = buildDeps.XBPS
 	}
 
 	if !golang {
@@ -217,6 +222,8 @@ func (bb *BaseBuilder) SetupEnvironmentDependencies(golang bool) ([]string, erro
 func (bb *BaseBuilder) CreateFileWalker() *files.Walker {
 	walkOpts := files.WalkOptions{
 		BackupFiles: bb.PKGBUILD.Backup,
+		Owners:      bb.OwnerRules(),
+		Flags:       bb.FlagRules(),
 	}
 
 	// Configure format-specific options
@@ -232,6 +239,15 @@ func (bb *BaseBuilder) CreateFileWalker() *files.Walker {
 	return files.NewWalker(bb.PKGBUILD.PackageDir, walkOpts)
 }
 
+// OwnerRules returns the ownership overrides declared in fileowners=().
+// The entries are validated with the PKGBUILD, so malformed ones cannot
+// reach the builders.
+func (bb *BaseBuilder) OwnerRules() []files.OwnerRule {
+	rules, _ := files.ParseOwnerRules(bb.PKGBUILD.FileOwners)
+
+	return rules
+}
+
 // LogPackageCreated logs successful package creation with consistent formatting.
 // When running under sudo, ownership of the artifact is transferred to the
 // original invoking user so downstream consumers (CI agents, etc.) can read it
@@ -315,6 +331,8 @@ func getPackageManager(format string) string {
 		return formatPacman
 	case constants.FormatAPK:
 		return formatApk
+	case constants.FormatXBPS:
+		return "xbps-install"
 	default:
 		return ""
 	}
@@ -331,6 +349,8 @@ func getExtension(format string) string {
 		return constants.ExtPacmanZst
 	case constants.FormatAPK:
 		return constants.ExtAPK
+	case constants.FormatXBPS:
+		return constants.ExtXBPS
 	default:
 		return ""
 	}
@@ -349,6 +369,8 @@ func getUpdateCommand(format string) string {
 		return "-Sy"
 	case constants.FormatAPK:
 		return updateCommand
+	case constants.FormatXBPS:
+		return "-S"
 	default:
 		return ""
 	}
@@ -683,7 +705,14 @@ func (bb *BaseBuilder) ApplyOptions() error {
 // is consulted before os.Getenv for STRIP/OBJCOPY during the strip pass.
 // Pair with BuildCrossStripEnvSlice() (parsed to a map) to scope cross-strip
 // toolchain selection without mutating the global process environment.
+// Debug symbols are separated into the tree of the debug-symbol package
+// (see DebugPackages).
 func (bb *BaseBuilder) ApplyOptionsWithEnv(env map[string]string) error {
+	debugDir, err := bb.prepareDebugDir()
+	if err != nil {
+		return err
+	}
+
 	return options.ApplyWithEnv(bb.PKGBUILD.PackageDir, options.Options{
 		DebugEnabled:     bb.PKGBUILD.DebugEnabled,
 		DocsEnabled:      bb.PKGBUILD.DocsEnabled,
@@ -693,6 +722,7 @@ func (bb *BaseBuilder) ApplyOptionsWithEnv(env map[string]string) error {
 		StaticEnabled:    bb.PKGBUILD.StaticEnabled,
 		StripEnabled:     bb.PKGBUILD.StripEnabled,
 		ZipManEnabled:    bb.PKGBUILD.ZipManEnabled,
+		DebugDir:         debugDir,
 	}, env)
 }
 
@@ -0,0 +1,325 @@
+package common
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/M0Rf30/yap/v2/pkg/binary"
+	"github.com/M0Rf30/yap/v2/pkg/constants"
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/files"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/logger"
+)
+
+// pythonNameRe matches the separator runs PEP 503 folds into "-".
+var pythonNameRe = regexp.MustCompile(`[-_.]+`)
+
+// Module is a named, versioned interface found in a package directory.
+type Module struct {
+	Name    string
+	Version string
+}
+
+// PythonDist is an installed Python distribution (a *.dist-info directory).
+type PythonDist struct {
+	Module
+	// Python is the interpreter version of the site-packages directory,
+	// e.g. "3.12"; empty when the path does not tell.
+	Python string
+}
+
+// Provided is what a package directory offers to other packages.
+type Provided struct {
+	Libraries []binary.SharedLibrary
+	PkgConfig []Module
+	Python    []PythonDist
+}
+
+// ScanProvides inspects packageDir for shared libraries (ELF DT_SONAME),
+// pkg-config modules (*.pc) and Python distributions (*.dist-info).
+func ScanProvides(packageDir string) (*Provided, error) {
+	libs, err := binary.SharedLibraries(packageDir)
+	if err != nil {
+		return nil, err
+	}
+
+	provided := &Provided{Libraries: libs}
+
+	err = filepath.WalkDir(packageDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case !entry.IsDir() && filepath.Ext(path) == ".pc" &&
+			filepath.Base(filepath.Dir(path)) == "pkgconfig":
+			provided.PkgConfig = append(provided.PkgConfig, parsePkgConfig(path))
+		case entry.IsDir() && strings.HasSuffix(entry.Name(), ".dist-info"):
+			if dist, ok := parseDistInfo(path); ok {
+				provided.Python = append(provided.Python, dist)
+			}
+
+			return filepath.SkipDir
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return provided, nil
+}
+
+// Provides returns the provides entries of p in the conventions of format:
+//
+//   - apk: so:libfoo.so.1=1.2.3, pc:foo=1.2, py3.12:foo=1.0
+//   - rpm: libfoo.so.1()(64bit), pkgconfig(foo)=1.2, python3dist(foo)=1.0
+//   - pacman: libfoo.so=1-64
+//
+// deb advertises libraries through a shlibs control file instead (see
+// Shlibs) and gets no entries.
+func (p *Provided) Provides(format string) []string {
+	var provides []string
+
+	switch format {
+	case constants.FormatAPK:
+		for _, lib := range p.Libraries {
+			provides = append(provides, "so:"+lib.Soname+"="+fileVersion(lib))
+		}
+
+		for _, module := range p.PkgConfig {
+			provides = append(provides, versioned("pc:"+module.Name, module.Version))
+		}
+
+		for _, dist := range p.Python {
+			if dist.Python != "" {
+				provides = append(provides,
+					versioned("py"+dist.Python+":"+normalizePythonName(dist.Name), dist.Version))
+			}
+		}
+	case constants.FormatRPM:
+		for _, lib := range p.Libraries {
+			capability := lib.Soname
+			if lib.Is64 {
+				capability += "()(64bit)"
+			}
+
+			provides = append(provides, capability)
+		}
+
+		for _, module := range p.PkgConfig {
+			provides = append(provides, versioned("pkgconfig("+module.Name+")", module.Version))
+		}
+
+		for _, dist := range p.Python {
+			provides = append(provides,
+				versioned("python3dist("+normalizePythonName(dist.Name)+")", dist.Version))
+		}
+	case constants.FormatPacman:
+		for _, lib := range p.Libraries {
+			name, version, ok := strings.Cut(lib.Soname, ".so.")
+			if !ok {
+				continue
+			}
+
+			bits := "32"
+			if lib.Is64 {
+				bits = "64"
+			}
+
+			provides = append(provides, name+".so="+version+"-"+bits)
+		}
+	}
+
+	return provides
+}
+
+// Shlibs returns a Debian shlibs control file mapping each library to
+// pkgName at version or later, or "" when there are no libraries.
+func (p *Provided) Shlibs(pkgName, version string) string {
+	var data strings.Builder
+
+	for _, lib := range p.Libraries {
+		name, soversion, ok := splitSoname(lib.Soname)
+		if !ok {
+			continue
+		}
+
+		fmt.Fprintf(&data, "%s %s %s (>= %s)\n", name, soversion, pkgName, version)
+	}
+
+	return data.String()
+}
+
+// GenerateProvides scans the package directory and appends the provides
+// of its libraries, pkg-config modules and Python distributions to the
+// PKGBUILD provides, skipping names already declared. The scan result is
+// returned for formats that advertise provides elsewhere (deb shlibs).
+func (bb *BaseBuilder) GenerateProvides() (*Provided, error) {
+	provided, err := ScanProvides(bb.PKGBUILD.PackageDir)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypeFileSystem,
+			i18n.T("errors.common.failed_to_scan_provides")).
+			WithOperation("GenerateProvides").
+			WithContext("path", bb.PKGBUILD.PackageDir)
+	}
+
+	declared := make(map[string]bool)
+	for _, provide := range bb.PKGBUILD.Provides {
+		declared[depVersionRegex.Split(provide, -1)[0]] = true
+	}
+
+	var added []string
+
+	for _, provide := range provided.Provides(bb.Format) {
+		name := depVersionRegex.Split(provide, -1)[0]
+		if declared[name] {
+			continue
+		}
+
+		declared[name] = true
+
+		added = append(added, provide)
+	}
+
+	bb.PKGBUILD.Provides = append(bb.PKGBUILD.Provides, added...)
+
+	if len(added) > 0 {
+		logger.Debug(i18n.T("logger.common.debug.generated_provides"),
+			"package", bb.PKGBUILD.PkgName, "provides", strings.Join(added, " "))
+	}
+
+	return provided, nil
+}
+
+// splitSoname splits a soname into the library name and version used by
+// shlibs: "libfoo.so.1" gives ("libfoo", "1") and "libfoo-1.2.so" gives
+// ("libfoo", "1.2").
+func splitSoname(soname string) (name, version string, ok bool) {
+	if name, version, ok := strings.Cut(soname, ".so."); ok {
+		return name, version, true
+	}
+
+	base, ok := strings.CutSuffix(soname, ".so")
+	if !ok {
+		return "", "", false
+	}
+
+	index := strings.LastIndex(base, "-")
+	if index <= 0 {
+		return "", "", false
+	}
+
+	return base[:index], base[index+1:], true
+}
+
+// fileVersion returns the full version of a library file name, e.g.
+// "1.2.3" for libfoo.so.1.2.3, falling back to "0" like abuild.
+func fileVersion(lib binary.SharedLibrary) string {
+	if _, version, ok := strings.Cut(filepath.Base(lib.Path), ".so."); ok && version != "" {
+		return version
+	}
+
+	return "0"
+}
+
+// versioned appends "=version" to name when version is known.
+func versioned(name, version string) string {
+	if version == "" {
+		return name
+	}
+
+	return name + "=" + version
+}
+
+// normalizePythonName applies PEP 503 normalization.
+func normalizePythonName(name string) string {
+	return strings.ToLower(pythonNameRe.ReplaceAllString(name, "-"))
+}
+
+// parsePkgConfig reads the module name and version of a .pc file,
+// expanding the variables it defines.
+func parsePkgConfig(path string) Module {
+	module := Module{Name: strings.TrimSuffix(filepath.Base(path), ".pc")}
+	vars := make(map[string]string)
+
+	forEachFileLine(path, func(line string) bool {
+		if value, ok := strings.CutPrefix(line, "Version:"); ok {
+			module.Version = strings.TrimSpace(os.Expand(value, func(name string) string {
+				return vars[name]
+			}))
+
+			return false
+		}
+
+		if name, value, ok := strings.Cut(line, "="); ok && !strings.ContainsAny(name, " :") {
+			vars[name] = os.Expand(strings.TrimSpace(value), func(name string) string {
+				return vars[name]
+			})
+		}
+
+		return true
+	})
+
+	return module
+}
+
+// parseDistInfo reads the Name and Version headers of a *.dist-info
+// METADATA file.
+func parseDistInfo(dir string) (PythonDist, bool) {
+	var dist PythonDist
+
+	forEachFileLine(filepath.Join(dir, "METADATA"), func(line string) bool {
+		if line == "" {
+			return false
+		}
+
+		if value, ok := strings.CutPrefix(line, "Name:"); ok {
+			dist.Name = strings.TrimSpace(value)
+		} else if value, ok := strings.CutPrefix(line, "Version:"); ok {
+			dist.Version = strings.TrimSpace(value)
+		}
+
+		return true
+	})
+
+	if dist.Name == "" {
+		return PythonDist{}, false
+	}
+
+	parts := strings.Split(filepath.ToSlash(dir), "/")
+	if index := slices.IndexFunc(parts, func(part string) bool {
+		return strings.HasPrefix(part, "python3.")
+	}); index >= 0 {
+		dist.Python = strings.TrimPrefix(parts[index], "python")
+	}
+
+	return dist, true
+}
+
+// forEachFileLine calls fn with each line of the file at path until fn
+// returns false. Unreadable files are ignored.
+func forEachFileLine(path string, fn func(line string) bool) {
+	if !files.Exists(path) {
+		return
+	}
+
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return
+	}
+
+	defer func() { _ = file.Close() }()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() && fn(scanner.Text()) {
+	}
+}
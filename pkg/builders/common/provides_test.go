@@ -0,0 +1,144 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/M0Rf30/yap/v2/pkg/binary"
+	"github.com/M0Rf30/yap/v2/pkg/constants"
+	"github.com/M0Rf30/yap/v2/pkg/pkgbuild"
+)
+
+func writeProvidesFixture(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	fixtures := map[string]string{
+		"usr/lib/pkgconfig/foo.pc": "prefix=/usr\nmajor=1\nversion=${major}.2\n\n" +
+			"Name: foo\nVersion: ${version}\nLibs: -L${prefix}/lib -lfoo\n",
+		"usr/lib/python3.12/site-packages/Foo_Bar-2.0.dist-info/METADATA": "Metadata-Version: 2.1\n" +
+			"Name: Foo_Bar\nVersion: 2.0\n\nName: not-a-header\n",
+		"usr/share/doc/foo/foo.pc": "Version: 9\n",
+	}
+
+	for name, content := range fixtures {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestScanProvides(t *testing.T) {
+	provided, err := ScanProvides(writeProvidesFixture(t))
+	if err != nil {
+		t.Fatalf("ScanProvides() error = %v", err)
+	}
+
+	if len(provided.Libraries) != 0 {
+		t.Errorf("Libraries = %v, want none", provided.Libraries)
+	}
+
+	wantPC := []Module{{Name: "foo", Version: "1.2"}}
+	if !reflect.DeepEqual(provided.PkgConfig, wantPC) {
+		t.Errorf("PkgConfig = %v, want %v", provided.PkgConfig, wantPC)
+	}
+
+	wantPython := []PythonDist{{Module: Module{Name: "Foo_Bar", Version: "2.0"}, Python: "3.12"}}
+	if !reflect.DeepEqual(provided.Python, wantPython) {
+		t.Errorf("Python = %v, want %v", provided.Python, wantPython)
+	}
+}
+
+func TestProvidedProvides(t *testing.T) {
+	provided := &Provided{
+		Libraries: []binary.SharedLibrary{
+			{Library: binary.Library{Soname: "libfoo.so.1", Is64: true}, Path: "usr/lib/libfoo.so.1.2.3"},
+			{Library: binary.Library{Soname: "libbar-2.0.so"}, Path: "usr/lib/libbar-2.0.so"},
+		},
+		PkgConfig: []Module{{Name: "foo", Version: "1.2"}},
+		Python:    []PythonDist{{Module: Module{Name: "Foo_Bar", Version: "2.0"}, Python: "3.12"}},
+	}
+
+	tests := []struct {
+		format string
+		want   []string
+	}{
+		{
+			format: constants.FormatAPK,
+			want:   []string{"so:libfoo.so.1=1.2.3", "so:libbar-2.0.so=0", "pc:foo=1.2", "py3.12:foo-bar=2.0"},
+		},
+		{
+			format: constants.FormatRPM,
+			want:   []string{"libfoo.so.1()(64bit)", "libbar-2.0.so", "pkgconfig(foo)=1.2", "python3dist(foo-bar)=2.0"},
+		},
+		{
+			format: constants.FormatPacman,
+			want:   []string{"libfoo.so=1-64"},
+		},
+		{
+			format: constants.FormatDEB,
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			if got := provided.Provides(tt.format); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Provides(%q) = %v, want %v", tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProvidedShlibs(t *testing.T) {
+	provided := &Provided{
+		Libraries: []binary.SharedLibrary{
+			{Library: binary.Library{Soname: "libfoo.so.1"}},
+			{Library: binary.Library{Soname: "libbar-2.0.so"}},
+			{Library: binary.Library{Soname: "libplugin.so"}},
+		},
+	}
+
+	want := "libfoo 1 libfoo1 (>= 1:1.2)\nlibbar 2.0 libfoo1 (>= 1:1.2)\n"
+	if got := provided.Shlibs("libfoo1", "1:1.2"); got != want {
+		t.Errorf("Shlibs() = %q, want %q", got, want)
+	}
+
+	if got := (&Provided{}).Shlibs("libfoo1", "1.2"); got != "" {
+		t.Errorf("Shlibs() without libraries = %q, want empty", got)
+	}
+}
+
+func TestGenerateProvides(t *testing.T) {
+	bb := &BaseBuilder{
+		PKGBUILD: &pkgbuild.PKGBUILD{
+			PkgName:    "foo",
+			PackageDir: writeProvidesFixture(t),
+			Provides:   []string{"pkgconfig(foo)=1.0"},
+		},
+		Format: constants.FormatRPM,
+	}
+
+	provided, err := bb.GenerateProvides()
+	if err != nil {
+		t.Fatalf("GenerateProvides() error = %v", err)
+	}
+
+	if len(provided.PkgConfig) != 1 {
+		t.Errorf("PkgConfig = %v, want one module", provided.PkgConfig)
+	}
+
+	want := []string{"pkgconfig(foo)=1.0", "python3dist(foo-bar)=2.0"}
+	if !reflect.DeepEqual(bb.PKGBUILD.Provides, want) {
+		t.Errorf("Provides = %v, want %v", bb.PKGBUILD.Provides, want)
+	}
+}
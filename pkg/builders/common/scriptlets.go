@@ -0,0 +1,509 @@
+package common
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/M0Rf30/yap/v2/pkg/alternatives"
+	"github.com/M0Rf30/yap/v2/pkg/constants"
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+)
+
+const (
+	sysusersDir  = "usr/lib/sysusers.d"
+	tmpfilesDir  = "usr/lib/tmpfiles.d"
+	inlineMarker = "SYSTEMD_INLINE_EOF"
+	debConfigure = `if [ "$1" = "configure" ] || [ "$1" = "abort-upgrade" ] || ` +
+		`[ "$1" = "abort-deconfigure" ] || [ "$1" = "abort-remove" ]; then`
+)
+
+// systemdUnitDirs are the directories, relative to the package tree, a unit
+// listed in systemd_units=() is looked up in.
+var systemdUnitDirs = []string{
+	"usr/lib/systemd/system",
+	"lib/systemd/system",
+	"etc/systemd/system",
+}
+
+// Scriptlets holds the maintainer script fragments generated for the
+// systemd units, sysusers.d and tmpfiles.d files a package ships.
+type Scriptlets struct {
+	PreInst  string
+	PostInst string
+	PreRm    string
+	PostRm   string
+}
+
+// systemFile is a sysusers.d or tmpfiles.d file shipped by the package.
+type systemFile struct {
+	Path    string // absolute path on the installed system
+	Content string
+}
+
+// SystemScriptlets returns the scriptlet fragments registering the entries
+// of systemd_units=(), sysusers=() and tmpfiles=() the way the target
+// distribution does: the dh_installsystemd, dh_installsysusers and
+// dh_installtmpfiles snippets for deb, the %systemd_post, %sysusers_create
+// and %tmpfiles_create macros for rpm, and busybox adduser/addgroup and
+// install -d for apk, which has no systemd. Pacman needs none, as the alpm
+// hooks of systemd already act on the shipped files. Every listed file must
+// be shipped by the package. For deb and rpm, the fragments also register
+// the alternatives=() entries with update-alternatives.
+func (bb *BaseBuilder) SystemScriptlets() (Scriptlets, error) {
+	pkgBuild := bb.PKGBUILD
+	if len(pkgBuild.SystemdUnits)+len(pkgBuild.SysUsers)+len(pkgBuild.TmpFiles)+
+		len(pkgBuild.Alternatives) == 0 {
+		return Scriptlets{}, nil
+	}
+
+	for _, unit := range pkgBuild.SystemdUnits {
+		if !bb.shipsUnit(unit) {
+			return Scriptlets{}, bb.missingSystemFile(filepath.Join(systemdUnitDirs[0], unit))
+		}
+	}
+
+	sysusers, err := bb.readSystemFiles(sysusersDir, pkgBuild.SysUsers)
+	if err != nil {
+		return Scriptlets{}, err
+	}
+
+	tmpfiles, err := bb.readSystemFiles(tmpfilesDir, pkgBuild.TmpFiles)
+	if err != nil {
+		return Scriptlets{}, err
+	}
+
+	alts := bb.Alternatives()
+
+	switch bb.Format {
+	case constants.FormatDEB:
+		return debSystemScriptlets(pkgBuild.SystemdUnits, sysusers, tmpfiles, alts), nil
+	case constants.FormatRPM:
+		return rpmSystemScriptlets(pkgBuild.SystemdUnits, sysusers, tmpfiles, alts), nil
+	case constants.FormatAPK:
+		return apkSystemScriptlets(sysusers, tmpfiles), nil
+	default:
+		return Scriptlets{}, nil
+	}
+}
+
+// JoinScriptlets joins the non-empty scriptlet fragments, in order, into a
+// single script.
+func JoinScriptlets(parts ...string) string {
+	var script strings.Builder
+
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+
+		script.WriteString(part)
+
+		if !strings.HasSuffix(part, "\n") {
+			script.WriteByte('\n')
+		}
+	}
+
+	return script.String()
+}
+
+// shipsUnit reports whether the package tree holds the systemd unit.
+func (bb *BaseBuilder) shipsUnit(unit string) bool {
+	for _, dir := range systemdUnitDirs {
+		if _, err := os.Lstat(filepath.Join(bb.PKGBUILD.PackageDir, dir, unit)); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readSystemFiles reads the named files below dir in the package tree.
+func (bb *BaseBuilder) readSystemFiles(dir string, names []string) ([]systemFile, error) {
+	systemFiles := make([]systemFile, 0, len(names))
+
+	for _, name := range names {
+		rel := filepath.Join(dir, name)
+
+		content, err := os.ReadFile(filepath.Join(bb.PKGBUILD.PackageDir, rel))
+		if err != nil {
+			return nil, bb.missingSystemFile(rel)
+		}
+
+		systemFiles = append(systemFiles, systemFile{Path: "/" + rel, Content: string(content)})
+	}
+
+	return systemFiles, nil
+}
+
+func (bb *BaseBuilder) missingSystemFile(rel string) error {
+	return errors.New(errors.ErrTypeValidation, i18n.T("errors.common.system_file_not_packaged")).
+		WithOperation("SystemScriptlets").
+		WithContext("pkgname", bb.PKGBUILD.PkgName).
+		WithContext("path", "/"+rel)
+}
+
+// enabledUnits returns the units that can be enabled and started, leaving
+// out templates such as getty@.service, which need an instance name.
+func enabledUnits(units []string) []string {
+	enabled := make([]string, 0, len(units))
+
+	for _, unit := range units {
+		if !strings.Contains(unit, "@.") {
+			enabled = append(enabled, unit)
+		}
+	}
+
+	return enabled
+}
+
+// quoteUnits returns the units as single-quoted shell words.
+func quoteUnits(units []string) string {
+	quoted := make([]string, len(units))
+	for i, unit := range units {
+		quoted[i] = "'" + unit + "'"
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+// sysusersInline returns the command creating the users and groups of a
+// sysusers.d file before the package is unpacked, passing the file on
+// standard input since it is not installed yet.
+func sysusersInline(file systemFile, root, orTrue string) string {
+	content := file.Content
+	if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+
+	return fmt.Sprintf("systemd-sysusers %s--replace=%s - <<'%s' >/dev/null 2>&1 || %s\n%s%s\n",
+		root, file.Path, inlineMarker, orTrue, content, inlineMarker)
+}
+
+func systemFilePaths(systemFiles []systemFile) string {
+	paths := make([]string, len(systemFiles))
+	for i, file := range systemFiles {
+		paths[i] = file.Path
+	}
+
+	return strings.Join(paths, " ")
+}
+
+// debSystemScriptlets mirrors the snippets dh_installsysusers,
+// dh_installtmpfiles and dh_installsystemd add to the maintainer scripts,
+// and registers the alternatives on configure.
+func debSystemScriptlets(
+	units []string, sysusers, tmpfiles []systemFile, alts []alternatives.Alternative,
+) Scriptlets {
+	var scriptlets Scriptlets
+
+	var preInst, postInst, preRm, postRm strings.Builder
+
+	for _, file := range sysusers {
+		preInst.WriteString(sysusersInline(file, `${DPKG_ROOT:+--root="$DPKG_ROOT"} `, "true"))
+	}
+
+	if len(tmpfiles) > 0 {
+		fmt.Fprintf(&postInst, "%s\n\tsystemd-tmpfiles ${DPKG_ROOT:+--root=\"$DPKG_ROOT\"} "+
+			"--create %s >/dev/null || true\nfi\n", debConfigure, systemFilePaths(tmpfiles))
+	}
+
+	enabled := enabledUnits(units)
+	for _, unit := range enabled {
+		fmt.Fprintf(&postInst, `%[1]s
+	deb-systemd-helper unmask '%[2]s' >/dev/null || true
+	if deb-systemd-helper --quiet was-enabled '%[2]s'; then
+		deb-systemd-helper enable '%[2]s' >/dev/null || true
+	else
+		deb-systemd-helper update-state '%[2]s' >/dev/null || true
+	fi
+fi
+`, debConfigure, unit)
+	}
+
+	if len(enabled) > 0 {
+		fmt.Fprintf(&postInst, `%s
+	if [ -z "${DPKG_ROOT:-}" ] && [ -d /run/systemd/system ]; then
+		systemctl --system daemon-reload >/dev/null || true
+		if [ -n "$2" ]; then
+			_dh_action=restart
+		else
+			_dh_action=start
+		fi
+		deb-systemd-invoke $_dh_action %s >/dev/null || true
+	fi
+fi
+`, debConfigure, quoteUnits(enabled))
+
+		fmt.Fprintf(&preRm, `if [ -z "${DPKG_ROOT:-}" ] && [ "$1" = "remove" ] && [ -d /run/systemd/system ]; then
+	deb-systemd-invoke stop %s >/dev/null || true
+fi
+`, quoteUnits(enabled))
+	}
+
+	if len(units) > 0 {
+		fmt.Fprintf(&postRm, `if [ -d /run/systemd/system ]; then
+	systemctl --system daemon-reload >/dev/null || true
+fi
+if [ "$1" = "remove" ] && [ -x "/usr/bin/deb-systemd-helper" ]; then
+	deb-systemd-helper mask %[1]s >/dev/null || true
+fi
+if [ "$1" = "purge" ] && [ -x "/usr/bin/deb-systemd-helper" ]; then
+	deb-systemd-helper purge %[1]s >/dev/null || true
+	deb-systemd-helper unmask %[1]s >/dev/null || true
+fi
+`, quoteUnits(units))
+	}
+
+	for _, alt := range alts {
+		fmt.Fprintf(&postInst, "%s\n\t%s\nfi\n", debConfigure, alt.InstallCommand())
+		fmt.Fprintf(&preRm, "if [ \"$1\" = \"remove\" ]; then\n\t%s\nfi\n", alt.RemoveCommand())
+	}
+
+	scriptlets.PreInst, scriptlets.PostInst = preInst.String(), postInst.String()
+	scriptlets.PreRm, scriptlets.PostRm = preRm.String(), postRm.String()
+
+	return scriptlets
+}
+
+// rpmSystemScriptlets mirrors the expansions of the %sysusers_create,
+// %tmpfiles_create, %systemd_post, %systemd_preun and
+// %systemd_postun_with_restart macros, and the alternatives scriptlets of
+// the Fedora packaging guidelines.
+func rpmSystemScriptlets(
+	units []string, sysusers, tmpfiles []systemFile, alts []alternatives.Alternative,
+) Scriptlets {
+	var scriptlets Scriptlets
+
+	var preInst, postInst strings.Builder
+
+	for _, file := range sysusers {
+		preInst.WriteString(sysusersInline(file, "", ":"))
+	}
+
+	if len(tmpfiles) > 0 {
+		fmt.Fprintf(&postInst, "systemd-tmpfiles --create %s >/dev/null 2>&1 || :\n", systemFilePaths(tmpfiles))
+	}
+
+	if enabled := enabledUnits(units); len(enabled) > 0 {
+		fmt.Fprintf(&postInst, `if [ $1 -eq 1 ]; then
+	systemctl --no-reload preset %[1]s >/dev/null 2>&1 || :
+fi
+`, quoteUnits(enabled))
+
+		scriptlets.PreRm = fmt.Sprintf(`if [ $1 -eq 0 ]; then
+	systemctl --no-reload disable --now %s >/dev/null 2>&1 || :
+fi
+`, quoteUnits(enabled))
+	}
+
+	if len(units) > 0 {
+		scriptlets.PostRm = "systemctl daemon-reload >/dev/null 2>&1 || :\n"
+	}
+
+	if enabled := enabledUnits(units); len(enabled) > 0 {
+		scriptlets.PostRm += fmt.Sprintf(`if [ $1 -ge 1 ]; then
+	systemctl try-restart %s >/dev/null 2>&1 || :
+fi
+`, quoteUnits(enabled))
+	}
+
+	for _, alt := range alts {
+		fmt.Fprintf(&postInst, "%s >/dev/null 2>&1 || :\n", alt.InstallCommand())
+		scriptlets.PostRm += fmt.Sprintf("if [ $1 -eq 0 ]; then\n\t%s >/dev/null 2>&1 || :\nfi\n",
+			alt.RemoveCommand())
+	}
+
+	scriptlets.PreInst, scriptlets.PostInst = preInst.String(), postInst.String()
+
+	return scriptlets
+}
+
+// apkSystemScriptlets translates the sysusers.d and tmpfiles.d files into
+// the busybox addgroup/adduser and install -d calls Alpine packages run
+// from their pre-install and post-install scripts.
+func apkSystemScriptlets(sysusers, tmpfiles []systemFile) Scriptlets {
+	var preInst, postInst strings.Builder
+
+	for _, file := range sysusers {
+		for _, fields := range configLines(file.Content) {
+			preInst.WriteString(apkSysusersCommand(fields))
+		}
+	}
+
+	for _, file := range tmpfiles {
+		for _, fields := range configLines(file.Content) {
+			postInst.WriteString(apkTmpfilesCommand(fields))
+		}
+	}
+
+	return Scriptlets{PreInst: preInst.String(), PostInst: postInst.String()}
+}
+
+// apkSysusersCommand returns the busybox commands for a sysusers.d line:
+// "u name id gecos home shell", "g name id" or "m user group".
+func apkSysusersCommand(fields []string) string {
+	if len(fields) < 2 {
+		return ""
+	}
+
+	at := func(i int) string {
+		if i < len(fields) && fields[i] != "-" {
+			return fields[i]
+		}
+
+		return ""
+	}
+
+	name := fields[1]
+
+	switch fields[0] {
+	case "g":
+		return addgroupCommand(name, at(2))
+	case "m":
+		if at(2) == "" {
+			return ""
+		}
+
+		return fmt.Sprintf("addgroup %s %s 2>/dev/null || true\n", name, at(2))
+	case "u":
+		uid, gid, _ := strings.Cut(at(2), ":")
+		if strings.HasPrefix(uid, "/") {
+			uid, gid = "", ""
+		}
+
+		group := name
+
+		var command strings.Builder
+
+		if gid != "" && !isNumeric(gid) {
+			group = gid
+		} else {
+			command.WriteString(addgroupCommand(name, gid))
+		}
+
+		home, shell := at(4), at(5)
+		if home == "" {
+			home = "/"
+		}
+
+		if shell == "" {
+			shell = "/sbin/nologin"
+		}
+
+		command.WriteString("adduser -S -D -H -h " + home + " -s " + shell + " -G " + group)
+
+		if uid != "" {
+			command.WriteString(" -u " + uid)
+		}
+
+		if gecos := at(3); gecos != "" {
+			command.WriteString(" -g '" + strings.ReplaceAll(gecos, "'", `'\''`) + "'")
+		}
+
+		command.WriteString(" " + name + " 2>/dev/null || true\n")
+
+		return command.String()
+	default:
+		return ""
+	}
+}
+
+func addgroupCommand(name, gid string) string {
+	if isNumeric(gid) {
+		return fmt.Sprintf("addgroup -S -g %s %s 2>/dev/null || true\n", gid, name)
+	}
+
+	return fmt.Sprintf("addgroup -S %s 2>/dev/null || true\n", name)
+}
+
+// apkTmpfilesCommand returns the install -d call for a tmpfiles.d "d" or
+// "D" line. Other types and lines using specifiers are left out, since
+// busybox has nothing to apply them with.
+func apkTmpfilesCommand(fields []string) string {
+	if len(fields) < 2 || (fields[0] != "d" && fields[0] != "D") || strings.Contains(strings.Join(fields, " "), "%") {
+		return ""
+	}
+
+	at := func(i int, fallback string) string {
+		if i < len(fields) && fields[i] != "-" {
+			return strings.TrimLeft(fields[i], "~:")
+		}
+
+		return fallback
+	}
+
+	return fmt.Sprintf("install -d -m %s -o %s -g %s %s\n",
+		at(2, "0755"), at(3, "root"), at(4, "root"), fields[1])
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// configLines splits a sysusers.d or tmpfiles.d file into the fields of
+// its lines, skipping blank lines and comments. Fields may be quoted with
+// single or double quotes.
+func configLines(content string) [][]string {
+	var lines [][]string
+
+	for line := range strings.SplitSeq(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		lines = append(lines, splitFields(line))
+	}
+
+	return lines
+}
+
+func splitFields(line string) []string {
+	var (
+		fields  []string
+		field   strings.Builder
+		quote   rune
+		inField bool
+	)
+
+	for _, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			field.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote, inField = r, true
+		case r == ' ' || r == '\t':
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+
+				inField = false
+			}
+		default:
+			field.WriteRune(r)
+
+			inField = true
+		}
+	}
+
+	if inField {
+		fields = append(fields, field.String())
+	}
+
+	return fields
+}
@@ -0,0 +1,161 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/M0Rf30/yap/v2/pkg/constants"
+	"github.com/M0Rf30/yap/v2/pkg/pkgbuild"
+)
+
+// withSystemFiles declares the systemd units, sysusers.d and tmpfiles.d
+// files of the package, and ships them in its package directory.
+func withSystemFiles(t *testing.T) func(*pkgbuild.PKGBUILD) {
+	t.Helper()
+
+	return func(pkg *pkgbuild.PKGBUILD) {
+		shipped := map[string]string{
+			"usr/lib/systemd/system/foo.service":  "[Service]\n",
+			"usr/lib/systemd/system/foo@.service": "[Service]\n",
+			"usr/lib/sysusers.d/foo.conf":         "u foo - \"Foo daemon\" /var/lib/foo\n",
+			"usr/lib/tmpfiles.d/foo.conf":         "d /run/foo 0750 foo foo -\n",
+		}
+
+		for name, content := range shipped {
+			path := filepath.Join(pkg.PackageDir, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		pkg.SystemdUnits = []string{"foo.service", "foo@.service"}
+		pkg.SysUsers = []string{"foo.conf"}
+		pkg.TmpFiles = []string{"foo.conf"}
+	}
+}
+
+func TestSystemScriptletsDEB(t *testing.T) {
+	scriptlets, err := newTestBuilder(t, constants.FormatDEB, withSystemFiles(t)).SystemScriptlets()
+	if err != nil {
+		t.Fatalf("SystemScriptlets() error = %v", err)
+	}
+
+	checks := []struct {
+		name, script, want string
+	}{
+		{"preinst", scriptlets.PreInst, "--replace=/usr/lib/sysusers.d/foo.conf - <<'SYSTEMD_INLINE_EOF'"},
+		{"preinst", scriptlets.PreInst, "u foo - \"Foo daemon\" /var/lib/foo\nSYSTEMD_INLINE_EOF\n"},
+		{"postinst", scriptlets.PostInst, "--create /usr/lib/tmpfiles.d/foo.conf"},
+		{"postinst", scriptlets.PostInst, "deb-systemd-helper enable 'foo.service'"},
+		{"postinst", scriptlets.PostInst, "deb-systemd-invoke $_dh_action 'foo.service'"},
+		{"prerm", scriptlets.PreRm, "deb-systemd-invoke stop 'foo.service'"},
+		{"postrm", scriptlets.PostRm, "deb-systemd-helper purge 'foo.service' 'foo@.service'"},
+	}
+
+	for _, check := range checks {
+		if !strings.Contains(check.script, check.want) {
+			t.Errorf("%s = %q, want it to contain %q", check.name, check.script, check.want)
+		}
+	}
+
+	if strings.Contains(scriptlets.PostInst, "enable 'foo@.service'") {
+		t.Error("template units must not be enabled")
+	}
+}
+
+func TestSystemScriptletsRPM(t *testing.T) {
+	scriptlets, err := newTestBuilder(t, constants.FormatRPM, withSystemFiles(t)).SystemScriptlets()
+	if err != nil {
+		t.Fatalf("SystemScriptlets() error = %v", err)
+	}
+
+	checks := []struct {
+		name, script, want string
+	}{
+		{"%pre", scriptlets.PreInst, "systemd-sysusers --replace=/usr/lib/sysusers.d/foo.conf -"},
+		{"%post", scriptlets.PostInst, "systemd-tmpfiles --create /usr/lib/tmpfiles.d/foo.conf"},
+		{"%post", scriptlets.PostInst, "if [ $1 -eq 1 ]; then\n\tsystemctl --no-reload preset 'foo.service'"},
+		{"%preun", scriptlets.PreRm, "if [ $1 -eq 0 ]; then\n\tsystemctl --no-reload disable --now 'foo.service'"},
+		{"%postun", scriptlets.PostRm, "systemctl try-restart 'foo.service'"},
+	}
+
+	for _, check := range checks {
+		if !strings.Contains(check.script, check.want) {
+			t.Errorf("%s = %q, want it to contain %q", check.name, check.script, check.want)
+		}
+	}
+}
+
+func TestSystemScriptletsAPK(t *testing.T) {
+	scriptlets, err := newTestBuilder(t, constants.FormatAPK, withSystemFiles(t)).SystemScriptlets()
+	if err != nil {
+		t.Fatalf("SystemScriptlets() error = %v", err)
+	}
+
+	wantPreInst := "addgroup -S foo 2>/dev/null || true\n" +
+		"adduser -S -D -H -h /var/lib/foo -s /sbin/nologin -G foo -g 'Foo daemon' foo 2>/dev/null || true\n"
+	if scriptlets.PreInst != wantPreInst {
+		t.Errorf("PreInst = %q, want %q", scriptlets.PreInst, wantPreInst)
+	}
+
+	if want := "install -d -m 0750 -o foo -g foo /run/foo\n"; scriptlets.PostInst != want {
+		t.Errorf("PostInst = %q, want %q", scriptlets.PostInst, want)
+	}
+
+	if scriptlets.PreRm != "" || scriptlets.PostRm != "" {
+		t.Error("OpenRC systems have no systemd units to stop")
+	}
+}
+
+func TestSystemScriptletsPacman(t *testing.T) {
+	scriptlets, err := newTestBuilder(t, constants.FormatPacman, withSystemFiles(t)).SystemScriptlets()
+	if err != nil || scriptlets != (Scriptlets{}) {
+		t.Errorf("SystemScriptlets() = %+v, %v, want none: the alpm hooks handle them", scriptlets, err)
+	}
+}
+
+func TestSystemScriptletsMissingFile(t *testing.T) {
+	bb := newTestBuilder(t, constants.FormatDEB, withSystemFiles(t))
+	bb.PKGBUILD.SystemdUnits = []string{"bar.service"}
+
+	if _, err := bb.SystemScriptlets(); err == nil {
+		t.Error("SystemScriptlets() must fail for a unit the package does not ship")
+	}
+}
+
+func TestApkSysusersCommand(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"g foo 123", "addgroup -S -g 123 foo 2>/dev/null || true\n"},
+		{"m foo wheel", "addgroup foo wheel 2>/dev/null || true\n"},
+		{
+			"u foo 100:users - - /bin/sh",
+			"adduser -S -D -H -h / -s /bin/sh -G users -u 100 foo 2>/dev/null || true\n",
+		},
+		{"r - 500-900", ""},
+	}
+
+	for _, tt := range tests {
+		if got := apkSysusersCommand(splitFields(tt.line)); got != tt.want {
+			t.Errorf("apkSysusersCommand(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestJoinScriptlets(t *testing.T) {
+	if got, want := JoinScriptlets("a", "", "b\n"), "a\nb\n"; got != want {
+		t.Errorf("JoinScriptlets() = %q, want %q", got, want)
+	}
+
+	if got := JoinScriptlets("", ""); got != "" {
+		t.Errorf("JoinScriptlets() of empty parts = %q, want empty", got)
+	}
+}
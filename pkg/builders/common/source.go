@@ -0,0 +1,189 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/shell"
+	"github.com/M0Rf30/yap/v2/pkg/source"
+)
+
+// ScriptPrologue mirrors makepkg's run_function(): every PKGBUILD function
+// (prepare/build/check/package) is executed with errexit and xtrace enabled
+// and an implicit `cd "${srcdir}"`, so function bodies can assume they start
+// inside the source directory exactly like under makepkg.
+const ScriptPrologue = "  set -e\n  set -x\n  cd \"${srcdir}\"\n"
+
+// SourceFile is a PKGBUILD source as a source package ships it.
+type SourceFile struct {
+	Name      string // name in startdir and srcdir
+	Path      string // fetched file or VCS checkout, symlinks resolved
+	URL       string // download URL, empty for local files and VCS checkouts
+	Dir       bool   // VCS checkout
+	NoExtract bool   // listed in noextract=()
+}
+
+// PackedName is the name of the tarball shipping a VCS checkout, for the
+// formats whose source packages only hold files.
+func (file SourceFile) PackedName() string {
+	return file.Name + ".tar.gz"
+}
+
+// IsPatch reports whether the source is a patch, which prepare() applies.
+func (file SourceFile) IsPatch() bool {
+	return strings.HasSuffix(file.Name, ".patch") || strings.HasSuffix(file.Name, ".diff")
+}
+
+// sourceArchives map the names of the archives a source package unpacks
+// into srcdir to the command extracting them.
+var sourceArchives = []struct {
+	suffixes []string
+	command  string
+}{
+	{[]string{".tar", ".tar.gz", ".tgz", ".tar.bz2", ".tbz2", ".tar.xz", ".txz", ".tar.zst", ".tzst"}, "tar -xf"},
+	{[]string{".zip"}, "unzip -qo"},
+}
+
+// SourceFiles returns the sources of the PKGBUILD, fetched into startdir
+// by a build run with noBuild.
+func (bb *BaseBuilder) SourceFiles() ([]SourceFile, error) {
+	pkgBuild := bb.PKGBUILD
+	sources := make([]SourceFile, 0, len(pkgBuild.SourceURI))
+
+	for _, entry := range pkgBuild.SourceURI {
+		name, url := source.Locate(entry)
+		path, err := filepath.EvalSymlinks(filepath.Join(pkgBuild.StartDir, name))
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrTypeFileSystem,
+				i18n.T("errors.common.failed_to_create_source_package")).
+				WithOperation("SourceFiles").
+				WithContext("source", entry)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrTypeFileSystem,
+				i18n.T("errors.common.failed_to_create_source_package")).
+				WithOperation("SourceFiles").
+				WithContext("source", entry)
+		}
+
+		sources = append(sources, SourceFile{
+			Name:      name,
+			Path:      path,
+			URL:       url,
+			Dir:       info.IsDir(),
+			NoExtract: slices.Contains(pkgBuild.NoExtract, name),
+		})
+	}
+
+	return sources, nil
+}
+
+// SourcePackageNames returns the packages a source package builds: the
+// split packages, or the package itself.
+func (bb *BaseBuilder) SourcePackageNames() []string {
+	if bb.PKGBUILD.IsSplitPackage() {
+		return bb.PKGBUILD.PkgNames
+	}
+
+	return []string{bb.PKGBUILD.PkgName}
+}
+
+// SourceScripts returns the scripts a source package runs in place of the
+// PKGBUILD functions, keyed by name: "sources" recreates srcdir from the
+// shipped sources, found in the directory given as its argument, then
+// "prepare", "build", "check" and one "package_<name>" per package run
+// the PKGBUILD functions. Scripts of missing functions are omitted. They
+// expect srcdir, pkgdir, startdir and CARCH in the environment. VCS
+// checkouts are shipped as their PackedName tarball when packDirs is set.
+func (bb *BaseBuilder) SourceScripts(sources []SourceFile, packDirs bool) map[string]string {
+	pkgBuild := bb.PKGBUILD
+	scripts := map[string]string{"sources": sourcesScript(sources, packDirs)}
+
+	for stage, body := range map[string]string{
+		"prepare": pkgBuild.Prepare,
+		"build":   pkgBuild.Build,
+		"check":   pkgBuild.Check,
+	} {
+		if body != "" {
+			scripts[stage] = bb.stageScript(pkgBuild.PkgName, body)
+		}
+	}
+
+	for _, name := range bb.SourcePackageNames() {
+		body, ok := pkgBuild.SplitPackageFuncs[name]
+		if !ok {
+			body = pkgBuild.Package
+		}
+
+		scripts["package_"+name] = bb.stageScript(name, body)
+	}
+
+	return scripts
+}
+
+// stageScript returns the script running body, a PKGBUILD function of the
+// package name, as the builder does.
+func (bb *BaseBuilder) stageScript(name, body string) string {
+	pkgBuild := bb.PKGBUILD
+
+	var script strings.Builder
+
+	script.WriteString("#!/bin/bash\n")
+
+	for _, variable := range [][2]string{
+		{"pkgname", name},
+		{"pkgver", pkgBuild.PkgVer},
+		{"pkgrel", pkgBuild.PkgRel},
+	} {
+		script.WriteString("export " + variable[0] + "=" + shell.SingleQuote(variable[1]) + "\n")
+	}
+
+	script.WriteString(ScriptPrologue)
+	script.WriteString(pkgBuild.BuildScriptPreamble())
+	script.WriteString(body)
+	script.WriteString("\n")
+
+	return script.String()
+}
+
+// sourcesScript returns the script linking the sources into srcdir and
+// extracting the archives not listed in noextract=().
+func sourcesScript(sources []SourceFile, packDirs bool) string {
+	var script strings.Builder
+
+	script.WriteString("#!/bin/bash\nset -e\nmkdir -p \"${srcdir}\"\ncd \"${srcdir}\"\n")
+
+	for _, file := range sources {
+		name := shell.SingleQuote(file.Name)
+
+		if file.Dir && packDirs {
+			script.WriteString("tar -xf \"$1\"/" + shell.SingleQuote(file.PackedName()) + "\n")
+
+			continue
+		}
+
+		script.WriteString("ln -sfn \"$1\"/" + name + " " + name + "\n")
+
+		if file.Dir || file.NoExtract {
+			continue
+		}
+
+		for _, archive := range sourceArchives {
+			if slices.ContainsFunc(archive.suffixes, func(suffix string) bool {
+				return strings.HasSuffix(file.Name, suffix)
+			}) {
+				script.WriteString(archive.command + " " + name + "\n")
+
+				break
+			}
+		}
+	}
+
+	return script.String()
+}
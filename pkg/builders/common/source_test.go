@@ -0,0 +1,74 @@
+package common
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/M0Rf30/yap/v2/pkg/pkgbuild"
+)
+
+func TestSourcesScript(t *testing.T) {
+	sources := []SourceFile{
+		{Name: "foo-1.0.tar.gz"},
+		{Name: "bar.zip", NoExtract: true},
+		{Name: "fix.patch"},
+		{Name: "repo", Dir: true},
+	}
+
+	tests := []struct {
+		packDirs bool
+		want     string
+	}{
+		{false, "ln -sfn \"$1\"/'foo-1.0.tar.gz' 'foo-1.0.tar.gz'\ntar -xf 'foo-1.0.tar.gz'\n" +
+			"ln -sfn \"$1\"/'bar.zip' 'bar.zip'\n" +
+			"ln -sfn \"$1\"/'fix.patch' 'fix.patch'\n" +
+			"ln -sfn \"$1\"/'repo' 'repo'\n"},
+		{true, "ln -sfn \"$1\"/'foo-1.0.tar.gz' 'foo-1.0.tar.gz'\ntar -xf 'foo-1.0.tar.gz'\n" +
+			"ln -sfn \"$1\"/'bar.zip' 'bar.zip'\n" +
+			"ln -sfn \"$1\"/'fix.patch' 'fix.patch'\n" +
+			"tar -xf \"$1\"/'repo.tar.gz'\n"},
+	}
+
+	for _, tt := range tests {
+		got := sourcesScript(sources, tt.packDirs)
+		if !strings.HasSuffix(got, "cd \"${srcdir}\"\n"+tt.want) {
+			t.Errorf("sourcesScript(packDirs=%v) =\n%s\nwant it to end with\n%s", tt.packDirs, got, tt.want)
+		}
+	}
+}
+
+func TestSourceScripts(t *testing.T) {
+	pkgBuild := &pkgbuild.PKGBUILD{
+		PkgName:  "foo",
+		PkgVer:   "1.0",
+		PkgRel:   "1",
+		PkgNames: []string{"foo", "foo-doc"},
+		Build:    "make",
+		SplitPackageFuncs: map[string]string{
+			"foo":     "make install",
+			"foo-doc": "make install-doc",
+		},
+	}
+
+	bb := &BaseBuilder{PKGBUILD: pkgBuild, Format: "deb"}
+	scripts := bb.SourceScripts(nil, false)
+
+	for _, name := range []string{"sources", "build", "package_foo", "package_foo-doc"} {
+		if _, ok := scripts[name]; !ok {
+			t.Errorf("SourceScripts() misses %s", name)
+		}
+	}
+
+	for _, name := range []string{"prepare", "check"} {
+		if _, ok := scripts[name]; ok {
+			t.Errorf("SourceScripts() has %s without the function", name)
+		}
+	}
+
+	script := scripts["package_foo-doc"]
+	for _, part := range []string{"export pkgname='foo-doc'\n", ScriptPrologue, "make install-doc\n"} {
+		if !strings.Contains(script, part) {
+			t.Errorf("package_foo-doc script misses %q:\n%s", part, script)
+		}
+	}
+}
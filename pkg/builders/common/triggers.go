@@ -0,0 +1,69 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/M0Rf30/yap/v2/pkg/triggers"
+)
+
+// Triggers returns the triggers declared in triggers=(). The entries are
+// validated with the PKGBUILD, so malformed ones cannot reach the
+// builders.
+func (bb *BaseBuilder) Triggers() []triggers.Trigger {
+	parsed, _ := triggers.ParseAll(bb.PKGBUILD.Triggers)
+
+	return parsed
+}
+
+// TriggerScript returns a standalone shell script running the handler of
+// trigger: its definition and those of the helpers it calls, followed by
+// the call, which receives the script arguments.
+func (bb *BaseBuilder) TriggerScript(trigger triggers.Trigger) string {
+	definition := bb.PKGBUILD.HelperFunctions[trigger.Handler]
+
+	return bb.PKGBUILD.HelperFunctionsPreamble(definition) + trigger.Handler + " \"$@\"\n"
+}
+
+// TriggerDispatch returns a shell fragment running the handlers of the
+// triggers matching the shell words in words, each handler once however
+// many of its triggers match. patterns gives the case patterns matching
+// a trigger; triggers without patterns are left out.
+func (bb *BaseBuilder) TriggerDispatch(words string, patterns func(triggers.Trigger) []string) string {
+	var handlers []string
+
+	handlerPatterns := make(map[string][]string)
+
+	for _, trigger := range bb.Triggers() {
+		matching := patterns(trigger)
+		if len(matching) == 0 {
+			continue
+		}
+
+		if _, ok := handlerPatterns[trigger.Handler]; !ok {
+			handlers = append(handlers, trigger.Handler)
+		}
+
+		handlerPatterns[trigger.Handler] = append(handlerPatterns[trigger.Handler], matching...)
+	}
+
+	if len(handlers) == 0 {
+		return ""
+	}
+
+	var script strings.Builder
+
+	script.WriteString("for _yap_trigger in " + words + "; do\n\tcase \"$_yap_trigger\" in\n")
+
+	for i, handler := range handlers {
+		fmt.Fprintf(&script, "\t%s) _yap_run_%d=1 ;;\n", strings.Join(handlerPatterns[handler], "|"), i)
+	}
+
+	script.WriteString("\tesac\ndone\n")
+
+	for i, handler := range handlers {
+		fmt.Fprintf(&script, "if [ -n \"${_yap_run_%d:-}\" ]; then\n\t%s\nfi\n", i, handler)
+	}
+
+	return script.String()
+}
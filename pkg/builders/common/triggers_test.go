@@ -0,0 +1,58 @@
+package common
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/M0Rf30/yap/v2/pkg/constants"
+	"github.com/M0Rf30/yap/v2/pkg/pkgbuild"
+	"github.com/M0Rf30/yap/v2/pkg/triggers"
+)
+
+// withTriggers declares icon cache and ldconfig triggers whose handlers
+// share a helper function.
+func withTriggers(pkg *pkgbuild.PKGBUILD) {
+	pkg.Triggers = []string{
+		"/usr/share/icons:_update_icons",
+		"/usr/share/pixmaps:_update_icons",
+		"ldconfig:_ldconfig",
+	}
+	pkg.HelperFunctions = map[string]string{
+		"_update_icons": "_update_icons() {\n_log icons\n}",
+		"_log":          "_log() {\necho \"$@\"\n}",
+		"_ldconfig":     "_ldconfig() {\nldconfig\n}",
+	}
+}
+
+func TestTriggerScript(t *testing.T) {
+	bb := newTestBuilder(t, constants.FormatPacman, withTriggers)
+
+	want := "_log() {\necho \"$@\"\n}\n_update_icons() {\n_log icons\n}\n_update_icons \"$@\"\n"
+	if got := bb.TriggerScript(bb.Triggers()[0]); got != want {
+		t.Errorf("TriggerScript() = %q, want %q", got, want)
+	}
+}
+
+func TestTriggerDispatch(t *testing.T) {
+	bb := newTestBuilder(t, constants.FormatDEB, withTriggers)
+
+	got := bb.TriggerDispatch("$2", func(trigger triggers.Trigger) []string {
+		return []string{trigger.Target}
+	})
+
+	for _, want := range []string{
+		"for _yap_trigger in $2; do\n",
+		"\t/usr/share/icons|/usr/share/pixmaps) _yap_run_0=1 ;;\n",
+		"\tldconfig) _yap_run_1=1 ;;\n",
+		"if [ -n \"${_yap_run_0:-}\" ]; then\n\t_update_icons\nfi\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("TriggerDispatch() = %q, want it to contain %q", got, want)
+		}
+	}
+
+	none := bb.TriggerDispatch("\"$@\"", func(triggers.Trigger) []string { return nil })
+	if none != "" {
+		t.Errorf("TriggerDispatch() without patterns = %q, want empty", none)
+	}
+}
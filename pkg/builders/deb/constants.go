@@ -21,6 +21,9 @@ Multi-Arch: foreign
 {{- if .Maintainer}}
 Maintainer: {{.Maintainer}}
 {{- end }}
+{{- if .Vendor}}
+Origin: {{.Vendor}}
+{{- end }}
 {{- if .SourcePkg}}
 Source: {{.SourcePkg}}
 {{- end }}
@@ -55,16 +58,32 @@ Enhances: {{join .}}
 {{- with .BuiltUsing}}
 Built-Using: {{join .}}
 {{- end }}
-{{- if .URL}}
-Homepage: {{.URL}}
+{{- if .HomepageURL}}
+Homepage: {{.HomepageURL}}
+{{- end }}
+{{- if .VcsURL}}
+Vcs-Git: {{.VcsURL}}
+{{- end }}
+{{- if .VcsBrowserURL}}
+Vcs-Browser: {{.VcsBrowserURL}}
 {{- end }}
 {{- if .Bugs}}
 Bugs: {{.Bugs}}
 {{- end }}
+{{- with .BuildIDs}}
+Auto-Built-Package: debug-symbols
+Build-Ids:{{range .}} {{.}}{{end}}
+{{- end }}
 {{- /* Mandatory fields */}}
 Description: {{multiline .PkgDesc}}
+{{- if .PkgDescLong}}
+{{paragraphs .PkgDescLong}}
+{{- end }}
 `
 
+// installHeader starts preinst and postinst, which dpkg executes directly.
+const installHeader = "#!/bin/bash\n"
+
 const removeHeader = `#!/bin/bash
 case $1 in
     purge|remove|abort-install) ;;
@@ -88,9 +107,157 @@ License: {{ . }}{{- end }}
 {{- end }}
 `
 
+// sourceControlFile is debian/control of a source package: the source
+// stanza, followed by one binaryControlStanza per package.
+const sourceControlFile = `Source: {{.Source}}
+{{- if .Section}}
+Section: {{.Section}}
+{{- end }}
+{{- if .Priority}}
+Priority: {{.Priority}}
+{{- end }}
+{{- if .Maintainer}}
+Maintainer: {{.Maintainer}}
+{{- end }}
+Build-Depends: {{join .BuildDepends}}
+Standards-Version: ` + standardsVersion + `
+Rules-Requires-Root: no
+{{- if .HomepageURL}}
+Homepage: {{.HomepageURL}}
+{{- end }}
+{{- if .VcsURL}}
+Vcs-Git: {{.VcsURL}}
+{{- end }}
+{{- if .VcsBrowserURL}}
+Vcs-Browser: {{.VcsBrowserURL}}
+{{- end }}
+`
+
+const binaryControlStanza = `
+Package: {{.PkgName}}
+Architecture: {{.Architecture}}
+{{- if .MultiArch}}
+Multi-Arch: {{.MultiArch}}
+{{- end }}
+{{- with .PreDepends}}
+Pre-Depends: {{join .}}
+{{- end }}
+{{- with .Depends}}
+Depends: {{join .}}
+{{- end }}
+{{- with .Provides}}
+Provides: {{join .}}
+{{- end }}
+{{- with .Conflicts}}
+Conflicts: {{join .}}
+{{- end }}
+{{- with .Breaks}}
+Breaks: {{join .}}
+{{- end }}
+{{- with .Replaces}}
+Replaces: {{join .}}
+{{- end }}
+{{- with .OptDepends}}
+Recommends: {{join .}}
+{{- end }}
+{{- with .Suggests}}
+Suggests: {{join .}}
+{{- end }}
+{{- with .Enhances}}
+Enhances: {{join .}}
+{{- end }}
+Description: {{multiline .PkgDesc}}
+{{- if .PkgDescLong}}
+{{paragraphs .PkgDescLong}}
+{{- end }}
+`
+
+const sourceChangelogFile = `{{.Source}} ({{.Version}}) {{.Distribution}}; urgency=medium
+
+  * Source package generated by yap from the PKGBUILD.
+
+ -- {{.Maintainer}}  {{.Date}}
+`
+
+// sourceRulesFile is debian/rules of a source package, running the
+// PKGBUILD functions with the scripts of debian/yap.
+const sourceRulesFile = `#!/usr/bin/make -f
+
+export srcdir := $(CURDIR)/debian/yap/src
+export startdir := $(CURDIR)
+export CARCH := $(shell dpkg-architecture -qDEB_HOST_GNU_CPU)
+
+build build-arch build-indep: debian/yap/build-stamp
+
+debian/yap/build-stamp:
+	bash debian/yap/sources $(CURDIR)
+{{- range .Stages}}
+{{- if eq . "check"}}
+	$(if $(filter nocheck,$(DEB_BUILD_OPTIONS)),,bash debian/yap/check)
+{{- else}}
+	bash debian/yap/{{.}}
+{{- end }}
+{{- end }}
+	touch $@
+
+binary binary-arch binary-indep: debian/yap/build-stamp
+	dh_testroot
+	dh_prep
+{{- range .Binaries}}
+	mkdir -p debian/{{.}}
+	pkgdir=$(CURDIR)/debian/{{.}} bash debian/yap/package_{{.}}
+{{- end }}
+	dh_installdeb
+	dh_gencontrol
+	dh_md5sums
+	dh_builddeb
+
+clean:
+	dh_clean
+	rm -rf debian/yap/src debian/yap/build-stamp
+
+.PHONY: build build-arch build-indep binary binary-arch binary-indep clean
+`
+
+const dscFile = `Format: ` + sourceFormat + `
+Source: {{.Source}}
+Binary: {{join .Binaries}}
+Architecture: {{.Architecture}}
+Version: {{.Version}}
+{{- if .Maintainer}}
+Maintainer: {{.Maintainer}}
+{{- end }}
+{{- if .HomepageURL}}
+Homepage: {{.HomepageURL}}
+{{- end }}
+Standards-Version: ` + standardsVersion + `
+Build-Depends: {{join .BuildDepends}}
+Checksums-Sha1:
+{{- range .Files}}
+ {{.SHA1}} {{.Size}} {{.Name}}
+{{- end }}
+Checksums-Sha256:
+{{- range .Files}}
+ {{.SHA256}} {{.Size}} {{.Name}}
+{{- end }}
+Files:
+{{- range .Files}}
+ {{.MD5}} {{.Size}} {{.Name}}
+{{- end }}
+`
+
 const (
 	binaryContent   = "2.0\n"
 	binaryFilename  = "debian-binary"
 	controlFilename = "control.tar.zst"
 	dataFilename    = "data.tar.zst"
+
+	// sourceFormat is the format of the source packages, an upstream
+	// tarball with a debian/ tarball.
+	sourceFormat = "3.0 (quilt)"
+	// standardsVersion is the Debian Policy version the generated
+	// source packages declare.
+	standardsVersion = "4.7.0"
+	// debhelperCompat is the debhelper compatibility level of debian/rules.
+	debhelperCompat = "debhelper-compat (= 13)"
 )
@@ -24,6 +24,8 @@ import (
 	"github.com/M0Rf30/yap/v2/pkg/i18n"
 	"github.com/M0Rf30/yap/v2/pkg/logger"
 	"github.com/M0Rf30/yap/v2/pkg/pkgbuild"
+	"github.com/M0Rf30/yap/v2/pkg/shell"
+	"github.com/M0Rf30/yap/v2/pkg/triggers"
 )
 
 // Package represents a Deb package.
@@ -84,8 +86,8 @@ func (d *Package) BuildPackage(ctx context.Context, artifactsPath string, target
 	}
 
 	// Create data archive
-	err = archive.CreateTarCompressed(ctx, d.PKGBUILD.PackageDir,
-		dataArchive, d.compression, true)
+	err = archive.CreateTarCompressedWithOwners(ctx, d.PKGBUILD.PackageDir,
+		dataArchive, d.compression, true, d.OwnerRules())
 	if err != nil {
 		return "", err
 	}
@@ -116,6 +118,11 @@ func (d *Package) PrepareFakeroot(ctx context.Context, _ string, targetArch stri
 		return err
 	}
 
+	err = d.RemoveGhostFiles()
+	if err != nil {
+		return err
+	}
+
 	err = d.createDebResources()
 	if err != nil {
 		return err
@@ -207,11 +214,19 @@ func (d *Package) addScriptlets() error {
 		postrmScript = "postrm"
 	)
 
+	system, err := d.SystemScriptlets()
+	if err != nil {
+		return err
+	}
+
+	// The generated snippets run before the user-written preinst and prerm
+	// and after the postinst and postrm, as debhelper orders them.
+	// "postinst triggered" is answered first, without configuring again.
 	scripts := map[string]string{
-		"preinst":    d.PKGBUILD.PreInst,
-		"postinst":   d.PKGBUILD.PostInst,
-		prermScript:  d.PKGBUILD.PreRm,
-		postrmScript: d.PKGBUILD.PostRm,
+		"preinst":    common.JoinScriptlets(system.PreInst, d.PKGBUILD.PreInst),
+		"postinst":   common.JoinScriptlets(d.triggeredScript(), d.PKGBUILD.PostInst, system.PostInst),
+		prermScript:  common.JoinScriptlets(system.PreRm, d.PKGBUILD.PreRm),
+		postrmScript: common.JoinScriptlets(d.PKGBUILD.PostRm, system.PostRm, d.purgeGhostsScript()),
 	}
 
 	for name, script := range scripts {
@@ -227,11 +242,13 @@ func (d *Package) addScriptlets() error {
 
 		if name == prermScript || name == postrmScript {
 			script = removeHeader + script
+		} else {
+			script = installHeader + script
 		}
 
 		path := filepath.Join(d.debDir, name)
 
-		err := files.CreateWrite(path, script)
+		err = files.CreateWrite(path, script)
 		if err != nil {
 			return err
 		}
@@ -269,6 +286,83 @@ func (d *Package) createConfFiles() error {
 	return files.CreateWrite(path, data.String())
 }
 
+// purgeGhostsScript returns the postrm snippet removing the ghost=() files
+// on purge: dpkg cannot own a file it does not ship, so the package cleans
+// up what it created at runtime, as Debian policy asks.
+func (d *Package) purgeGhostsScript() string {
+	ghosts := d.GhostFiles()
+	if len(ghosts) == 0 {
+		return ""
+	}
+
+	return "if [ \"$1\" = \"purge\" ]; then\n\trm -rf -- " + shell.Join(ghosts) + "\nfi\n"
+}
+
+// createTriggersFile writes the triggers control file, declaring the
+// package interested in the targets of triggers=(). The noawait form lets
+// the packages activating them be configured without waiting for the
+// handlers.
+func (d *Package) createTriggersFile() error {
+	var data strings.Builder
+
+	for _, trigger := range d.Triggers() {
+		data.WriteString("interest-noawait " + trigger.Target + "\n")
+	}
+
+	if data.Len() == 0 {
+		return nil
+	}
+
+	return files.CreateWrite(filepath.Join(d.debDir, "triggers"), data.String())
+}
+
+// triggeredScript returns the postinst prologue handling "postinst
+// triggered", with which dpkg runs the interested packages at the end of
+// the transaction, passing the activated triggers in $2.
+func (d *Package) triggeredScript() string {
+	dispatch := d.TriggerDispatch("$2", func(trigger triggers.Trigger) []string {
+		return []string{trigger.Target}
+	})
+	if dispatch == "" {
+		return ""
+	}
+
+	var script strings.Builder
+
+	script.WriteString("if [ \"$1\" = \"triggered\" ]; then\n")
+
+	for line := range strings.Lines(dispatch) {
+		script.WriteString("\t" + line)
+	}
+
+	script.WriteString("\texit 0\nfi\n")
+
+	return script.String()
+}
+
+// createShlibsFile writes the shlibs control file mapping the shared
+// libraries shipped by the package to a dependency on the package at its
+// current upstream version, so that dpkg-shlibdeps can resolve them in
+// packages linking against them.
+func (d *Package) createShlibsFile() error {
+	provided, err := d.GenerateProvides()
+	if err != nil {
+		return err
+	}
+
+	version := d.PKGBUILD.PkgVer
+	if d.PKGBUILD.Epoch != "" {
+		version = d.PKGBUILD.Epoch + ":" + version
+	}
+
+	data := provided.Shlibs(d.PKGBUILD.PkgName, version)
+	if data == "" {
+		return nil
+	}
+
+	return files.CreateWrite(filepath.Join(d.debDir, "shlibs"), data)
+}
+
 // createCopyrightFile generates a copyright file for the Debian package.
 // It checks if there is a license specified in the PKGBUILD and creates
 // the copyright file accordingly. Returns an error if there was an
@@ -434,6 +528,16 @@ func (d *Package) createDebResources() error {
 		return err
 	}
 
+	err = d.createShlibsFile()
+	if err != nil {
+		return err
+	}
+
+	err = d.createTriggersFile()
+	if err != nil {
+		return err
+	}
+
 	size, err := files.GetDirSize(d.PKGBUILD.PackageDir)
 	if err != nil {
 		return errors.Wrap(err, errors.ErrTypeFileSystem, "failed to get package dir size").
@@ -491,7 +595,8 @@ func (d *Package) createDebResources() error {
 // file into DEBIAN/. Lines are "<hex md5>  <relative path>\n", paths are
 // relative to the filesystem root (i.e. no leading slash), sorted lexically
 // to match dpkg-deb -b output. Symlinks, directories, and special files are
-// skipped per Policy §3.9.
+// skipped per Policy §3.9, and so are the noverify=() files, which
+// dpkg --verify and debsums then leave alone.
 func (d *Package) createMd5sums() error {
 	type entry struct {
 		path string
@@ -501,6 +606,7 @@ func (d *Package) createMd5sums() error {
 	var entries []entry
 
 	root := d.PKGBUILD.PackageDir
+	flagRules := d.FlagRules()
 
 	err := filepath.Walk(root, func(path string, info os.FileInfo, walkErr error) error {
 		if walkErr != nil {
@@ -526,6 +632,10 @@ func (d *Package) createMd5sums() error {
 			return nil
 		}
 
+		if files.MatchFlags(flagRules, "/"+filepath.ToSlash(rel))&files.FlagNoVerify != 0 {
+			return nil
+		}
+
 		sum, sumErr := md5File(path)
 		if sumErr != nil {
 			return sumErr
@@ -358,6 +358,29 @@ func TestCreateDebResources(t *testing.T) {
 	}
 }
 
+func TestCreateDebResourcesDebugSymbols(t *testing.T) {
+	pkgBuild := createTestPKGBUILD()
+	pkgBuild.PkgName = "test-package-dbgsym"
+	pkgBuild.BuildIDs = []string{"ab4567", "cd0123"}
+	pkgBuild.PackageDir = t.TempDir()
+	pkg := NewBuilder(pkgBuild, "")
+
+	if err := pkg.createDebResources(); err != nil {
+		t.Fatalf("createDebResources failed: %v", err)
+	}
+
+	control, err := os.ReadFile(filepath.Join(pkg.debDir, "control"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, field := range []string{"Auto-Built-Package: debug-symbols\n", "Build-Ids: ab4567 cd0123\n"} {
+		if !strings.Contains(string(control), field) {
+			t.Errorf("control file lacks %q:\n%s", field, control)
+		}
+	}
+}
+
 func TestCreateConfFiles(t *testing.T) {
 	pkgBuild := createTestPKGBUILD()
 	pkg := NewBuilder(pkgBuild, "")
@@ -452,6 +475,96 @@ func TestAddScriptlets(t *testing.T) {
 	}
 }
 
+func TestCreateTriggers(t *testing.T) {
+	pkgBuild := createTestPKGBUILD()
+	pkgBuild.Triggers = []string{"/usr/share/icons:_update_icons", "ldconfig:_update_icons"}
+	pkgBuild.HelperFunctions = map[string]string{"_update_icons": "_update_icons() {\ntrue\n}"}
+	pkg := NewBuilder(pkgBuild, "")
+	pkg.debDir = t.TempDir()
+
+	if err := pkg.createTriggersFile(); err != nil {
+		t.Fatalf("createTriggersFile failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(pkg.debDir, "triggers"))
+	if err != nil {
+		t.Fatalf("Failed to read triggers: %v", err)
+	}
+
+	if want := "interest-noawait /usr/share/icons\ninterest-noawait ldconfig\n"; string(content) != want {
+		t.Errorf("triggers = %q, want %q", content, want)
+	}
+
+	if err := pkg.addScriptlets(); err != nil {
+		t.Fatalf("addScriptlets failed: %v", err)
+	}
+
+	postinst, err := os.ReadFile(filepath.Join(pkg.debDir, "postinst"))
+	if err != nil {
+		t.Fatalf("Failed to read postinst: %v", err)
+	}
+
+	want := "if [ \"$1\" = \"triggered\" ]; then\n\tfor _yap_trigger in $2; do\n"
+	if !strings.Contains(string(postinst), want) || !strings.Contains(string(postinst), "_update_icons() {") {
+		t.Errorf("postinst = %q, want the triggered dispatch and its handler", postinst)
+	}
+
+	if strings.Index(string(postinst), "exit 0") > strings.Index(string(postinst), "post-install") {
+		t.Error("postinst triggered must return before configuring the package")
+	}
+}
+
+func TestFileFlags(t *testing.T) {
+	pkgBuild := createTestPKGBUILD()
+	pkgBuild.GhostFiles = []string{"run/foo.pid"}
+	pkgBuild.NoVerifyFiles = []string{"var/lib/foo"}
+	pkgBuild.PackageDir = t.TempDir()
+	pkg := NewBuilder(pkgBuild, "")
+	pkg.debDir = filepath.Join(pkgBuild.PackageDir, "DEBIAN")
+
+	for _, name := range []string{"DEBIAN/control", "usr/bin/foo", "run/foo.pid", "var/lib/foo/state"} {
+		path := filepath.Join(pkgBuild.PackageDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+
+	if err := pkg.RemoveGhostFiles(); err != nil {
+		t.Fatalf("RemoveGhostFiles failed: %v", err)
+	}
+
+	if err := pkg.createMd5sums(); err != nil {
+		t.Fatalf("createMd5sums failed: %v", err)
+	}
+
+	md5sums, err := os.ReadFile(filepath.Join(pkg.debDir, "md5sums"))
+	if err != nil {
+		t.Fatalf("Failed to read md5sums: %v", err)
+	}
+
+	if lines := strings.Split(strings.TrimSpace(string(md5sums)), "\n"); len(lines) != 1 ||
+		!strings.HasSuffix(lines[0], "  usr/bin/foo") {
+		t.Errorf("md5sums = %q, want only usr/bin/foo", md5sums)
+	}
+
+	if err := pkg.addScriptlets(); err != nil {
+		t.Fatalf("addScriptlets failed: %v", err)
+	}
+
+	postrm, err := os.ReadFile(filepath.Join(pkg.debDir, "postrm"))
+	if err != nil {
+		t.Fatalf("Failed to read postrm: %v", err)
+	}
+
+	if !strings.Contains(string(postrm), "if [ \"$1\" = \"purge\" ]; then\n\trm -rf -- '/run/foo.pid'\nfi\n") {
+		t.Errorf("postrm = %q, want the ghost files removed on purge", postrm)
+	}
+}
+
 func TestCreateChangelogFile(t *testing.T) {
 	pkgBuild := createTestPKGBUILD()
 
@@ -533,3 +646,28 @@ func TestCreateChangelogFile_NoChangelog(t *testing.T) {
 		t.Errorf("Doc directory should not be created when changelog is empty")
 	}
 }
+
+func TestControlMetadata(t *testing.T) {
+	pkgBuild := createTestPKGBUILD()
+	pkgBuild.PkgDescLong = "Foo does things.\n\nIt does them well."
+	pkgBuild.URL = "https://example.com"
+	pkgBuild.Homepage = "https://foo.example.com"
+	pkgBuild.VcsURL = "https://example.com/foo"
+	pkgBuild.Vendor = "Example"
+
+	var control strings.Builder
+	if err := pkgBuild.RenderSpec(specFile).Execute(&control, pkgBuild); err != nil {
+		t.Fatalf("Failed to render control: %v", err)
+	}
+
+	for _, want := range []string{
+		"\nOrigin: Example\n",
+		"\nHomepage: https://foo.example.com\n",
+		"\nVcs-Git: https://example.com/foo\nVcs-Browser: https://example.com/foo\n",
+		"\nDescription: Test package description\n Foo does things.\n .\n It does them well.",
+	} {
+		if !strings.Contains(control.String(), want) {
+			t.Errorf("control lacks %q:\n%s", want, control.String())
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package deb
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"  //nolint:gosec // The Files field of .dsc files holds MD5 sums.
+	"crypto/sha1" //nolint:gosec // Checksums-Sha1 is still part of the .dsc format.
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"text/template"
+	"time"
+
+	"github.com/otiai10/copy"
+
+	"github.com/M0Rf30/yap/v2/pkg/archive"
+	"github.com/M0Rf30/yap/v2/pkg/constants"
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/files"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/logger"
+	"github.com/M0Rf30/yap/v2/pkg/pkgbuild"
+)
+
+// sourceData is the data the templates of a source package are rendered
+// with. Its fields shadow the PKGBUILD ones where the format differs.
+type sourceData struct {
+	*pkgbuild.PKGBUILD
+	Source       string
+	Version      string
+	Architecture string
+	Distribution string
+	Date         string
+	BuildDepends []string
+	Depends      []string
+	OptDepends   []string
+	Binaries     []string
+	Stages       []string
+	Files        []sourceDigest
+}
+
+// sourceDigest is a file listed in a .dsc file.
+type sourceDigest struct {
+	Name   string
+	Size   int64
+	MD5    string
+	SHA1   string
+	SHA256 string
+}
+
+// BuildSourcePackage writes the Debian source package of the PKGBUILD to
+// artifactsPath: the sources in the .orig.tar.xz upstream tarball, a
+// generated debian/ tree in the .debian.tar.xz tarball and the .dsc file
+// describing them. debian/rules runs the PKGBUILD functions, so that
+// dpkg-buildpackage builds the packages yap would. It returns the path of
+// the .dsc file, the one to sign.
+func (d *Package) BuildSourcePackage(ctx context.Context, artifactsPath string) ([]string, error) {
+	sources, err := d.SourceFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	stageDir, err := os.MkdirTemp(d.PKGBUILD.SourceDir, "tmp")
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := os.RemoveAll(stageDir); err != nil {
+			logger.Warn(i18n.T("logger.deb.warn.failed_to_remove_temporary"),
+				"path", stageDir, "error", err)
+		}
+	}()
+
+	data := d.sourceTemplateData()
+	upstreamDir := filepath.Join(stageDir, "orig", data.Source+"-"+d.PKGBUILD.PkgVer)
+
+	if err := files.ExistsMakeDir(upstreamDir); err != nil {
+		return nil, err
+	}
+
+	for _, file := range sources {
+		if err := copy.Copy(file.Path, filepath.Join(upstreamDir, file.Name)); err != nil {
+			return nil, errors.Wrap(err, errors.ErrTypeFileSystem,
+				i18n.T("errors.common.failed_to_create_source_package")).
+				WithOperation("BuildSourcePackage").
+				WithContext("source", file.Name)
+		}
+	}
+
+	scripts := d.SourceScripts(sources, false)
+
+	for _, stage := range []string{"prepare", "build", "check"} {
+		if _, ok := scripts[stage]; ok {
+			data.Stages = append(data.Stages, stage)
+		}
+	}
+
+	debianDir := filepath.Join(stageDir, "debian", "debian")
+	if err := d.createSourceDebianDir(debianDir, data, scripts); err != nil {
+		return nil, err
+	}
+
+	baseName := data.Source + "_" + d.PKGBUILD.PkgVer
+	tarballs := []struct{ dir, name string }{
+		{filepath.Join(stageDir, "orig"), baseName + ".orig.tar.xz"},
+		{filepath.Join(stageDir, "debian"), baseName + "-" + d.PKGBUILD.PkgRel + ".debian.tar.xz"},
+	}
+
+	for _, tarball := range tarballs {
+		tarballPath := filepath.Join(artifactsPath, tarball.name)
+
+		if err := archive.CreateTarCompressed(ctx, tarball.dir, tarballPath, constants.CompressionXz, false); err != nil {
+			return nil, err
+		}
+
+		digest, err := digestSourceFile(tarballPath)
+		if err != nil {
+			return nil, err
+		}
+
+		data.Files = append(data.Files, digest)
+	}
+
+	dscPath := filepath.Join(artifactsPath, baseName+"-"+d.PKGBUILD.PkgRel+".dsc")
+	if err := renderSourceFile(dscPath, d.PKGBUILD.RenderSpec(dscFile), data, 0o644); err != nil {
+		return nil, err
+	}
+
+	d.LogPackageCreated(dscPath)
+
+	return []string{dscPath}, nil
+}
+
+// sourceTemplateData returns the template data of the source package,
+// with the build dependencies of every package.
+func (d *Package) sourceTemplateData() *sourceData {
+	pkgBuild := d.PKGBUILD
+
+	data := &sourceData{
+		PKGBUILD:     pkgBuild,
+		Source:       pkgBuild.SourcePkg,
+		Version:      pkgBuild.PkgVer + "-" + pkgBuild.PkgRel,
+		Architecture: "any",
+		Distribution: pkgBuild.Codename,
+		Date:         files.SourceDateEpochFromEnv().Format(time.RFC1123Z),
+		BuildDepends: []string{debhelperCompat},
+		Binaries:     d.SourcePackageNames(),
+	}
+
+	if data.Source == "" {
+		data.Source = pkgBuild.EffectivePkgBase()
+	}
+
+	if pkgBuild.Epoch != "" {
+		data.Version = pkgBuild.Epoch + ":" + data.Version
+	}
+
+	if pkgBuild.ArchComputed == pkgbuild.ArchAny {
+		data.Architecture = "all"
+	}
+
+	if data.Distribution == "" {
+		data.Distribution = "unstable"
+	}
+
+	// makepkg installs depends=() along with makedepends=() and
+	// checkdepends=() before building.
+	for _, deps := range [][]string{pkgBuild.Depends, pkgBuild.MakeDepends, pkgBuild.CheckDepends} {
+		for _, dep := range d.ProcessDependencies(deps) {
+			if !slices.Contains(data.BuildDepends, dep) {
+				data.BuildDepends = append(data.BuildDepends, dep)
+			}
+		}
+	}
+
+	return data
+}
+
+// createSourceDebianDir writes the debian/ tree of the source package to
+// debianDir, with the scripts in debian/yap.
+func (d *Package) createSourceDebianDir(debianDir string, data *sourceData, scripts map[string]string) error {
+	pkgBuild := d.PKGBUILD
+
+	if err := files.ExistsMakeDir(filepath.Join(debianDir, "source")); err != nil {
+		return err
+	}
+
+	if err := files.ExistsMakeDir(filepath.Join(debianDir, "yap")); err != nil {
+		return err
+	}
+
+	control, err := d.sourceControl(data)
+	if err != nil {
+		return err
+	}
+
+	if err := files.CreateWrite(filepath.Join(debianDir, "control"), control); err != nil {
+		return err
+	}
+
+	if err := files.CreateWrite(filepath.Join(debianDir, "source", "format"), sourceFormat+"\n"); err != nil {
+		return err
+	}
+
+	for name, script := range scripts {
+		if err := os.WriteFile(filepath.Join(debianDir, "yap", name), []byte(script), 0o755); err != nil { //nolint:gosec
+			return err
+		}
+	}
+
+	err = renderSourceFile(filepath.Join(debianDir, "changelog"), pkgBuild.RenderSpec(sourceChangelogFile), data, 0o644)
+	if err != nil {
+		return err
+	}
+
+	err = renderSourceFile(filepath.Join(debianDir, "rules"), pkgBuild.RenderSpec(sourceRulesFile), data, 0o755)
+	if err != nil {
+		return err
+	}
+
+	if len(pkgBuild.License) == 0 {
+		return nil
+	}
+
+	return pkgBuild.CreateSpec(filepath.Join(debianDir, "copyright"), pkgBuild.RenderSpec(copyrightFile))
+}
+
+// sourceControl returns debian/control: the source stanza, then one
+// stanza per package with the overrides of its package_<name>() function.
+func (d *Package) sourceControl(data *sourceData) (string, error) {
+	pkgBuild := d.PKGBUILD
+	name := pkgBuild.PkgName
+
+	defer func() {
+		pkgBuild.PkgName = name
+		pkgBuild.RestoreTopLevelOverrides()
+	}()
+
+	var control bytes.Buffer
+
+	if err := pkgBuild.RenderSpec(sourceControlFile).Execute(&control, data); err != nil {
+		return "", err
+	}
+
+	stanza := pkgBuild.RenderSpec(binaryControlStanza)
+
+	for _, binary := range data.Binaries {
+		if pkgBuild.IsSplitPackage() {
+			pkgBuild.PkgName = binary
+			pkgBuild.RestoreTopLevelOverrides()
+
+			if err := pkgBuild.ParseSplitOverrides(pkgBuild.SplitPackageFuncs[binary]); err != nil {
+				return "", err
+			}
+		}
+
+		binaryData := *data
+		binaryData.Depends = d.ProcessDependencies(pkgBuild.Depends)
+		binaryData.OptDepends = d.ProcessDependencies(pkgBuild.OptDepends)
+
+		if err := stanza.Execute(&control, &binaryData); err != nil {
+			return "", err
+		}
+	}
+
+	return control.String(), nil
+}
+
+// renderSourceFile renders tmpl with data to path.
+func renderSourceFile(path string, tmpl *template.Template, data *sourceData, mode os.FileMode) error {
+	var content bytes.Buffer
+
+	if err := tmpl.Execute(&content, data); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, content.Bytes(), mode)
+}
+
+// digestSourceFile returns the size and checksums of the file at path.
+func digestSourceFile(path string) (sourceDigest, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return sourceDigest{}, err
+	}
+
+	defer func() {
+		if err := file.Close(); err != nil {
+			logger.Warn(i18n.T("logger.deb.warn.failed_to_close_debian"), "error", err)
+		}
+	}()
+
+	md5Hash, sha1Hash, sha256Hash := md5.New(), sha1.New(), sha256.New() //nolint:gosec
+
+	size, err := io.Copy(io.MultiWriter(md5Hash, sha1Hash, sha256Hash), file)
+	if err != nil {
+		return sourceDigest{}, err
+	}
+
+	sum := func(h hash.Hash) string { return hex.EncodeToString(h.Sum(nil)) }
+
+	return sourceDigest{
+		Name:   filepath.Base(path),
+		Size:   size,
+		MD5:    sum(md5Hash),
+		SHA1:   sum(sha1Hash),
+		SHA256: sum(sha256Hash),
+	}, nil
+}
@@ -0,0 +1,122 @@
+package deb
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildSourcePackage(t *testing.T) {
+	startDir := t.TempDir()
+	pkgBuild := createTestPKGBUILD()
+	pkgBuild.StartDir = startDir
+	pkgBuild.SourceDir = filepath.Join(startDir, "src")
+	pkgBuild.SourceURI = []string{"data.txt"}
+	pkgBuild.Epoch = "2"
+	pkgBuild.Build = "make"
+	pkgBuild.Package = "make DESTDIR=\"${pkgdir}\" install"
+
+	if err := os.MkdirAll(pkgBuild.SourceDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(startDir, "data.txt"), []byte("data\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	artifactsDir := t.TempDir()
+	pkg := NewBuilder(pkgBuild, "")
+
+	paths, err := pkg.BuildSourcePackage(context.Background(), artifactsDir)
+	if err != nil {
+		t.Fatalf("BuildSourcePackage() error = %v", err)
+	}
+
+	dscPath := filepath.Join(artifactsDir, "test-package_1.0.0-1.dsc")
+	if !reflect.DeepEqual(paths, []string{dscPath}) {
+		t.Fatalf("BuildSourcePackage() = %v, want %v", paths, []string{dscPath})
+	}
+
+	dsc, err := os.ReadFile(dscPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{
+		"Format: 3.0 (quilt)",
+		"Source: test-package",
+		"Binary: test-package",
+		"Architecture: any",
+		"Version: 2:1.0.0-1",
+		"Build-Depends: debhelper-compat (= 13), dependency1 (>= 1.0)",
+	} {
+		if !strings.Contains(string(dsc), line) {
+			t.Errorf(".dsc file misses %q:\n%s", line, dsc)
+		}
+	}
+
+	for _, name := range []string{"test-package_1.0.0.orig.tar.xz", "test-package_1.0.0-1.debian.tar.xz"} {
+		if !strings.Contains(string(dsc), " "+name+"\n") {
+			t.Errorf(".dsc file does not list %s:\n%s", name, dsc)
+		}
+	}
+
+	if _, err := exec.LookPath("dpkg-source"); err != nil {
+		t.Skip("dpkg-source not available")
+	}
+
+	extractDir := filepath.Join(t.TempDir(), "test-package")
+
+	cmd := exec.Command("dpkg-source", "-x", dscPath, extractDir)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("dpkg-source -x failed: %v\n%s", err, output)
+	}
+
+	for _, name := range []string{"data.txt", "debian/rules", "debian/yap/build", "debian/yap/package_test-package"} {
+		if _, err := os.Stat(filepath.Join(extractDir, name)); err != nil {
+			t.Errorf("extracted source package misses %s: %v", name, err)
+		}
+	}
+}
+
+func TestSourceControlSplitPackage(t *testing.T) {
+	pkgBuild := createTestPKGBUILD()
+	pkgBuild.PkgBase = "test"
+	pkgBuild.ArchComputed = "any"
+	pkgBuild.Init()
+	pkgBuild.AddSplitPackage("test-doc", "pkgdesc='Documentation'\ndepends=()\n")
+
+	pkg := NewBuilder(pkgBuild, "")
+	data := pkg.sourceTemplateData()
+
+	if data.Source != "test" || data.Architecture != "all" {
+		t.Errorf("Source = %q, Architecture = %q, want test and all", data.Source, data.Architecture)
+	}
+
+	control, err := pkg.sourceControl(data)
+	if err != nil {
+		t.Fatalf("sourceControl() error = %v", err)
+	}
+
+	stanzas := strings.Split(control, "\n\n")
+	if len(stanzas) != 3 {
+		t.Fatalf("debian/control has %d stanzas, want 3:\n%s", len(stanzas), control)
+	}
+
+	if !strings.Contains(stanzas[1], "Package: test-package\n") ||
+		!strings.Contains(stanzas[1], "Depends: dependency1 (>= 1.0)") {
+		t.Errorf("test-package stanza:\n%s", stanzas[1])
+	}
+
+	if !strings.Contains(stanzas[2], "Package: test-doc\n") || strings.Contains(stanzas[2], "Depends:") {
+		t.Errorf("test-doc stanza:\n%s", stanzas[2])
+	}
+
+	if pkgBuild.PkgName != "test-package" || len(pkgBuild.Depends) != 2 {
+		t.Errorf("sourceControl() did not restore the PKGBUILD: %s %v", pkgBuild.PkgName, pkgBuild.Depends)
+	}
+}
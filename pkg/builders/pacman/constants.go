@@ -1,6 +1,13 @@
 // Package pacman provides Arch Linux package building functionality and constants.
 package pacman
 
+const (
+	// hooksDir holds the alpm hooks pacman runs around transactions.
+	hooksDir = "usr/share/libalpm/hooks"
+	// hookScriptsDir holds the scripts the hooks execute.
+	hookScriptsDir = "usr/share/libalpm/scripts"
+)
+
 // Template constants - these are pacman-specific templates that should remain here
 const dotBuildinfo = `format = 2
 pkgname = {{.PkgName}}
@@ -31,9 +38,18 @@ const dotPkginfo = `# Generated by yap {{.YAPVersion}}
 pkgname = {{.PkgName}}
 pkgbase = {{.EffectivePkgBase}}
 xdata = pkgtype={{.PkgType}}
+{{- if .Vendor}}
+xdata = vendor={{.Vendor}}
+{{- end }}
+{{- if .VcsURL}}
+xdata = vcs={{.VcsURL}}
+{{- end }}
+{{- with .Keywords}}
+xdata = keywords={{join .}}
+{{- end }}
 pkgver = {{if .Epoch}}{{.Epoch}}:{{end}}{{.PkgVer}}-{{.PkgRel}}
 pkgdesc = {{.PkgDesc}}
-url = {{.URL}}
+url = {{.HomepageURL}}
 builddate = {{.BuildDate}}
 {{- if .Maintainer}}
 packager = {{.Maintainer}}
@@ -83,16 +99,18 @@ const dotMtree = `#mtree
 /set type=file uid=0 gid=0 mode=644
 {{- range . }}
 {{- if eq .Type "dir" }}
-.{{ .Destination }} time={{ .ModTime.Unix }}.0 mode={{ printf "%o" .Mode }} type=dir
+.{{ .Destination }} time={{ .ModTime.Unix }}.0 mode={{ printf "%o" .Mode }}{{ if .UID }} uid={{ .UID }}{{ end }}{{ if .GID }} gid={{ .GID }}{{ end }} type=dir
 {{- else if eq .Type "symlink" }}
-.{{ .Destination }} time={{ .ModTime.Unix }}.0 mode={{ printf "%o" .Mode }} type=link link={{ .LinkTarget }}
+.{{ .Destination }} time={{ .ModTime.Unix }}.0 mode={{ printf "%o" .Mode }}{{ if .UID }} uid={{ .UID }}{{ end }}{{ if .GID }} gid={{ .GID }}{{ end }} type=link link={{ .LinkTarget }}
 {{- else }}
 {{- if eq .Destination "/.BUILDINFO" }}
 .{{ .Destination }} time={{ .ModTime.Unix }}.0 size={{ .Size }} sha256digest={{ printf "%x" .SHA256 }}
 {{- else if eq .Destination "/.PKGINFO" }}
 .{{ .Destination }} time={{ .ModTime.Unix }}.0 size={{ .Size }} sha256digest={{ printf "%x" .SHA256 }}
+{{- else if .IsNoVerify }}
+.{{ .Destination }} mode={{ printf "%o" .Mode }}{{ if .UID }} uid={{ .UID }}{{ end }}{{ if .GID }} gid={{ .GID }}{{ end }}
 {{- else }}
-.{{ .Destination }} time={{ .ModTime.Unix }}.0 mode={{ printf "%o" .Mode }} size={{ .Size }} sha256digest={{ printf "%x" .SHA256 }}
+.{{ .Destination }} time={{ .ModTime.Unix }}.0 mode={{ printf "%o" .Mode }}{{ if .UID }} uid={{ .UID }}{{ end }}{{ if .GID }} gid={{ .GID }}{{ end }} size={{ .Size }} sha256digest={{ printf "%x" .SHA256 }}
 {{- end }}
 {{- end }}
 {{- end }}
@@ -22,6 +22,7 @@ import (
 	"github.com/M0Rf30/yap/v2/pkg/i18n"
 	"github.com/M0Rf30/yap/v2/pkg/logger"
 	"github.com/M0Rf30/yap/v2/pkg/pkgbuild"
+	"github.com/M0Rf30/yap/v2/pkg/triggers"
 )
 
 // Pkg represents a package manager for the Pkg distribution.
@@ -52,7 +53,8 @@ func (m *Pkg) BuildPackage(ctx context.Context, artifactsPath string, targetArch
 	pkgName := m.BuildPackageName(constants.ExtPacmanZst)
 	pkgFilePath := filepath.Join(artifactsPath, pkgName)
 
-	err := archive.CreateTarZst(ctx, m.PKGBUILD.PackageDir, pkgFilePath, false)
+	err := archive.CreateTarCompressedWithOwners(ctx, m.PKGBUILD.PackageDir, pkgFilePath,
+		"zstd", false, m.OwnerRules())
 	if err != nil {
 		return "", err
 	}
@@ -74,10 +76,26 @@ func (m *Pkg) PrepareFakeroot(ctx context.Context, artifactsPath string, targetA
 	// Note: Don't override ArchComputed here - it should remain the native architecture
 	// The targetArch is used for package naming in BuildPackage method
 
+	if err := m.RemoveGhostFiles(); err != nil {
+		return err
+	}
+
+	if err := m.ShipAlternatives(); err != nil {
+		return err
+	}
+
+	if err := m.writeHooks(); err != nil {
+		return err
+	}
+
 	if err := m.computeBuildMetadata(artifactsPath); err != nil {
 		return err
 	}
 
+	if _, err := m.GenerateProvides(); err != nil {
+		return err
+	}
+
 	if err := m.renderPKGBUILDFile(); err != nil {
 		return err
 	}
@@ -121,7 +139,9 @@ func (m *Pkg) computeBuildMetadata(artifactsPath string) error {
 
 	m.PKGBUILD.BuildDate = sourceDateEpoch.Unix()
 	m.PKGBUILD.PkgDest, _ = filepath.Abs(artifactsPath)
-	m.PKGBUILD.PkgType = pkgTypeDefault // can be pkg, split, debug, src
+	if m.PKGBUILD.PkgType == "" {
+		m.PKGBUILD.PkgType = pkgTypeDefault // can be pkg, split, debug, src
+	}
 	m.PKGBUILD.YAPVersion = constants.YAPVersion
 
 	return nil
@@ -180,6 +200,96 @@ func (m *Pkg) writeMTREE() error {
 	return createMTREEGzip(mtreeFile, filepath.Join(m.PKGBUILD.PackageDir, ".MTREE"))
 }
 
+// writeHooks ships the triggers=() entries as alpm hooks: each handler
+// gets a script under usr/share/libalpm/scripts and a hook running it once
+// after the transaction, with the matched paths or packages on stdin.
+// Path triggers also fire on removal, as dpkg file triggers do.
+func (m *Pkg) writeHooks() error {
+	var handlers []string
+
+	grouped := make(map[string][]triggers.Trigger)
+
+	for _, trigger := range m.Triggers() {
+		if _, ok := grouped[trigger.Handler]; !ok {
+			handlers = append(handlers, trigger.Handler)
+		}
+
+		grouped[trigger.Handler] = append(grouped[trigger.Handler], trigger)
+	}
+
+	for _, handler := range handlers {
+		name := m.PKGBUILD.PkgName + "-" + strings.TrimLeft(handler, "_")
+		scriptPath := filepath.Join(m.PKGBUILD.PackageDir, hookScriptsDir, name)
+		hookPath := filepath.Join(m.PKGBUILD.PackageDir, hooksDir, name+".hook")
+
+		if err := files.ExistsMakeDir(filepath.Dir(scriptPath)); err != nil {
+			return err
+		}
+
+		if err := files.ExistsMakeDir(filepath.Dir(hookPath)); err != nil {
+			return err
+		}
+
+		script := "#!/bin/sh\n" + m.TriggerScript(grouped[handler][0])
+		if err := files.CreateWrite(scriptPath, script); err != nil {
+			return err
+		}
+
+		if err := files.Chmod(scriptPath, 0o755); err != nil {
+			return err
+		}
+
+		hook := renderHook(grouped[handler], "/"+filepath.Join(hookScriptsDir, name))
+		if err := files.CreateWrite(hookPath, hook); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// renderHook returns an alpm hook running exec for the triggers of one
+// handler.
+func renderHook(group []triggers.Trigger, exec string) string {
+	var paths, packages []string
+
+	for _, trigger := range group {
+		if trigger.IsPath() {
+			paths = append(paths, strings.TrimPrefix(trigger.Target, "/")+"/*")
+		} else {
+			packages = append(packages, trigger.Target)
+		}
+	}
+
+	var hook strings.Builder
+
+	writeTrigger := func(kind string, operations, targets []string) {
+		if len(targets) == 0 {
+			return
+		}
+
+		hook.WriteString("[Trigger]\nType = " + kind + "\n")
+
+		for _, operation := range operations {
+			hook.WriteString("Operation = " + operation + "\n")
+		}
+
+		for _, target := range targets {
+			hook.WriteString("Target = " + target + "\n")
+		}
+
+		hook.WriteString("\n")
+	}
+
+	writeTrigger("Path", []string{"Install", "Upgrade", "Remove"}, paths)
+	writeTrigger("Package", []string{"Install", "Upgrade"}, packages)
+
+	hook.WriteString("[Action]\nDescription = Running " + group[0].Handler + "...\n")
+	hook.WriteString("When = PostTransaction\nExec = " + exec + "\nNeedsTargets\n")
+
+	return hook.String()
+}
+
 // writeInstallScriptIfNeeded writes the <pkgname>.install file when the
 // PKGBUILD declares any of the six scriptlet hooks.
 func (m *Pkg) writeInstallScriptIfNeeded() error {
@@ -362,6 +362,43 @@ func TestUpdate(t *testing.T) {
 	}
 }
 
+func TestWriteHooks(t *testing.T) {
+	pkgBuild := createTestPKGBUILD()
+	pkgBuild.PackageDir = t.TempDir()
+	pkgBuild.Triggers = []string{"/usr/share/icons:_update_icons", "gtk3:_update_icons"}
+	pkgBuild.HelperFunctions = map[string]string{"_update_icons": "_update_icons() {\ntrue\n}"}
+
+	pkg := NewBuilder(pkgBuild)
+	if err := pkg.writeHooks(); err != nil {
+		t.Fatalf("writeHooks() error = %v", err)
+	}
+
+	hook, err := os.ReadFile(filepath.Join(pkgBuild.PackageDir, hooksDir, "test-package-update_icons.hook"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"[Trigger]\nType = Path\nOperation = Install\nOperation = Upgrade\nOperation = Remove\n" +
+			"Target = usr/share/icons/*\n",
+		"[Trigger]\nType = Package\nOperation = Install\nOperation = Upgrade\nTarget = gtk3\n",
+		"When = PostTransaction\nExec = /usr/share/libalpm/scripts/test-package-update_icons\nNeedsTargets\n",
+	} {
+		if !strings.Contains(string(hook), want) {
+			t.Errorf("hook = %q, want it to contain %q", hook, want)
+		}
+	}
+
+	script, err := os.ReadFile(filepath.Join(pkgBuild.PackageDir, hookScriptsDir, "test-package-update_icons"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "#!/bin/sh\n_update_icons() {\ntrue\n}\n_update_icons \"$@\"\n"; string(script) != want {
+		t.Errorf("hook script = %q, want %q", script, want)
+	}
+}
+
 func TestRenderMtree(t *testing.T) {
 	// Create test entries
 	entries := []*files.Entry{
@@ -679,3 +716,56 @@ func TestPrepareFakeroot_WithChangelog(t *testing.T) {
 		t.Errorf("Changelog content mismatch. Expected %q, got %q", changelogContent, string(content))
 	}
 }
+
+func TestRenderMtreeOwnership(t *testing.T) {
+	entries := []*files.Entry{
+		{
+			Destination: "/var/lib/foo",
+			Type:        "dir",
+			Mode:        0o750,
+			ModTime:     time.Unix(0, 0),
+			Attributes:  files.Attributes{UID: 2, GID: 4, Owner: "daemon", Group: "adm"},
+		},
+		{
+			Destination: "/usr/bin/foo",
+			Type:        "file",
+			Mode:        0o755,
+			ModTime:     time.Unix(0, 0),
+		},
+	}
+
+	result, err := renderMtree(entries)
+	if err != nil {
+		t.Fatalf("renderMtree failed: %v", err)
+	}
+
+	if !strings.Contains(result, "./var/lib/foo time=0.0 mode=750 uid=2 gid=4 type=dir") {
+		t.Errorf("renderMtree result lacks the directory owner:\n%s", result)
+	}
+
+	if strings.Contains(result, "./usr/bin/foo time=0.0 mode=755 uid=") {
+		t.Errorf("root-owned files must use the /set defaults:\n%s", result)
+	}
+}
+
+func TestRenderMtreeNoVerify(t *testing.T) {
+	entries := []*files.Entry{
+		{
+			Destination: "/var/lib/foo/state",
+			Type:        "file",
+			Mode:        0o644,
+			ModTime:     time.Unix(0, 0),
+			Size:        5,
+			Flags:       files.FlagNoVerify,
+		},
+	}
+
+	result, err := renderMtree(entries)
+	if err != nil {
+		t.Fatalf("renderMtree failed: %v", err)
+	}
+
+	if !strings.Contains(result, "\n./var/lib/foo/state mode=644\n") {
+		t.Errorf("noverify files must only record their mode:\n%s", result)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -18,11 +20,60 @@ import (
 	"github.com/M0Rf30/yap/v2/pkg/i18n"
 	"github.com/M0Rf30/yap/v2/pkg/logger"
 	"github.com/M0Rf30/yap/v2/pkg/pkgbuild"
+	"github.com/M0Rf30/yap/v2/pkg/triggers"
 
 	rpmpack "github.com/M0Rf30/rpmpack"
 )
 
-const rootOwner = "root"
+const (
+	rootOwner = "root"
+
+	// tagFileCaps is RPMTAG_FILECAPS, the per-file capabilities in libcap
+	// text form, parallel to the file list.
+	tagFileCaps = 5010
+	// tagVCS is RPMTAG_VCS, the upstream repository of the package.
+	tagVCS = 5034
+	// tagFileVerifyFlags is RPMTAG_FILEVERIFYFLAGS, the attributes rpm -V
+	// checks for each file, parallel to the file list.
+	tagFileVerifyFlags = 1045
+	// verifyAll and verifyNone are the verify flags of %verify and
+	// %verify(not md5 size link user group mtime mode rdev caps).
+	verifyAll  = -1
+	verifyNone = 0
+	// tagTransFileTriggerPriorities is RPMTAG_TRANSFILETRIGGERPRIORITIES,
+	// the order of the %transfiletriggerin scripts, parallel to them.
+	tagTransFileTriggerPriorities = 5085
+	// defaultTriggerPriority is the priority rpmbuild gives file triggers
+	// declared without -P.
+	defaultTriggerPriority = 1000000
+	// rpmsenseTriggerIn is RPMSENSE_TRIGGERIN, the condition flag of
+	// %triggerin and %transfiletriggerin.
+	rpmsenseTriggerIn = 1 << 16
+)
+
+// triggerTags are the header tags of one kind of trigger: the scripts with
+// their interpreters, and the conditions with the script each one runs.
+type triggerTags struct {
+	scripts  int
+	progs    int
+	names    int
+	versions int
+	flags    int
+	index    int
+}
+
+var (
+	// packageTriggerTags hold %triggerin scripts, run when the named
+	// packages are installed.
+	packageTriggerTags = triggerTags{
+		scripts: 1065, progs: 1092, names: 1066, versions: 1067, flags: 1068, index: 1069,
+	}
+	// fileTriggerTags hold %transfiletriggerin scripts, run once per
+	// transaction with the installed paths under their prefixes on stdin.
+	fileTriggerTags = triggerTags{
+		scripts: 5073, progs: 5074, names: 5076, versions: 5078, flags: 5079, index: 5077,
+	}
+)
 
 // RPM represents a RPM package.
 //
@@ -64,6 +115,11 @@ func (r *RPM) BuildPackage(ctx context.Context, artifactsPath string, targetArch
 
 	license := strings.Join(r.PKGBUILD.License, " ")
 
+	description := r.PKGBUILD.PkgDescLong
+	if description == "" {
+		description = r.PKGBUILD.PkgDesc
+	}
+
 	pkgFilePath := filepath.Join(artifactsPath, pkgName)
 
 	// Pre-compute all dependency relations before creating RPM metadata
@@ -75,12 +131,13 @@ func (r *RPM) BuildPackage(ctx context.Context, artifactsPath string, targetArch
 	rpm, err := rpmpack.NewRPM(rpmpack.RPMMetaData{
 		Name:        r.PKGBUILD.PkgName,
 		Summary:     r.PKGBUILD.PkgDesc,
-		Description: r.PKGBUILD.PkgDesc,
+		Description: description,
 		Epoch:       uint32(epoch),
 		Version:     r.PKGBUILD.PkgVer,
 		Release:     r.PKGBUILD.PkgRel,
 		Arch:        r.PKGBUILD.ArchComputed,
-		URL:         r.PKGBUILD.URL,
+		URL:         r.PKGBUILD.HomepageURL(),
+		Vendor:      r.PKGBUILD.Vendor,
 		Packager:    r.PKGBUILD.Maintainer,
 		Group:       r.PKGBUILD.Section,
 		Compressor:  r.compression,
@@ -116,10 +173,19 @@ func (r *RPM) BuildPackage(ctx context.Context, artifactsPath string, targetArch
 		return "", err
 	}
 
-	r.addScriptlets(rpm)
+	err = r.addScriptlets(rpm)
+	if err != nil {
+		return "", err
+	}
+
+	r.addTriggers(rpm)
 
 	r.addChangelog(rpm)
 
+	if r.PKGBUILD.VcsURL != "" {
+		rpm.AddCustomTag(tagVCS, rpmpack.EntryString(r.PKGBUILD.VcsURL))
+	}
+
 	cleanFilePath := filepath.Clean(pkgFilePath)
 
 	rpmFile, err := os.Create(cleanFilePath)
@@ -157,6 +223,10 @@ func (r *RPM) PrepareFakeroot(ctx context.Context, _ string, targetArch string)
 	r.LogCrossCompilation(targetArch)
 	r.SetTargetArchitecture(targetArch)
 
+	if _, err := r.GenerateProvides(); err != nil {
+		return err
+	}
+
 	return r.ApplyOptionsWithEnv(r.CrossStripEnvMap(targetArch))
 }
 
@@ -182,10 +252,40 @@ func (r *RPM) createFilesInsideRPM(rpm *rpmpack.RPM) error {
 	var contents []*files.Entry
 
 	contents = append(contents, entries...)
+	contents = append(contents, r.missingGhosts(entries)...)
 
 	return addContentsToRPM(contents, rpm)
 }
 
+// missingGhosts returns entries for the ghost=() files that package()
+// did not create, which %ghost lists as empty regular files.
+func (r *RPM) missingGhosts(entries []*files.Entry) []*files.Entry {
+	walked := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		walked[entry.Destination] = true
+	}
+
+	rules := r.FlagRules()
+
+	var ghosts []*files.Entry
+
+	for _, ghost := range r.GhostFiles() {
+		if walked[ghost] {
+			continue
+		}
+
+		ghosts = append(ghosts, &files.Entry{
+			Destination: ghost,
+			Type:        files.TypeFile,
+			Mode:        0o644,
+			ModTime:     files.SourceDateEpochFromEnv(),
+			Flags:       files.MatchFlags(rules, ghost),
+		})
+	}
+
+	return ghosts
+}
+
 // addContentsToRPM adds a slice of Entry objects to the specified RPM object.
 // It creates RPMFile objects from the Entry and adds them to the RPM.
 // Intermediate directories (those that are ancestors of other entries) are
@@ -195,6 +295,8 @@ func addContentsToRPM(contents []*files.Entry, rpm *rpmpack.RPM) error {
 	// A directory is "intermediate" if any other entry's destination
 	// starts with that directory path + "/".
 	dirHasChildren := make(map[string]bool)
+	fileCaps := make(map[string]string)
+	verifyFlags := make(map[string]int32)
 
 	for _, content := range contents {
 		if content.Type != files.TypeDir {
@@ -222,44 +324,218 @@ func addContentsToRPM(contents []*files.Entry, rpm *rpmpack.RPM) error {
 		}
 
 		file.Name = filepath.Clean(file.Name)
+		file.Type |= rpmFileFlags(content.Flags)
+
+		// Ghost files are listed in the header but left out of the payload.
+		if content.IsGhost() {
+			file.Body = nil
+		}
+
 		rpm.AddFile(*file)
+
+		fileCaps[file.Name] = content.Capabilities
+
+		verifyFlags[file.Name] = verifyAll
+		if content.IsNoVerify() {
+			verifyFlags[file.Name] = verifyNone
+		}
 	}
 
+	addFileCaps(rpm, fileCaps)
+	addFileVerifyFlags(rpm, verifyFlags)
+
 	return nil
 }
 
+// rpmFileFlags maps the file flags onto the rpm %ghost, %doc and %license
+// attributes. noverify=() is carried by the verify flags instead.
+func rpmFileFlags(flags files.FileFlags) rpmpack.FileType {
+	var fileType rpmpack.FileType
+
+	if flags&files.FlagGhost != 0 {
+		fileType |= rpmpack.GhostFile
+	}
+
+	if flags&files.FlagDoc != 0 {
+		fileType |= rpmpack.DocFile
+	}
+
+	if flags&files.FlagLicense != 0 {
+		fileType |= rpmpack.LicenceFile
+	}
+
+	return fileType
+}
+
+// addFileCaps sets the FILECAPS tag when any file carries capabilities.
+func addFileCaps(rpm *rpmpack.RPM, fileCaps map[string]string) {
+	caps := inFileOrder(fileCaps)
+	if !slices.ContainsFunc(caps, func(c string) bool { return c != "" }) {
+		return
+	}
+
+	rpm.AddCustomTag(tagFileCaps, rpmpack.EntryStringSlice(caps))
+}
+
+// addFileVerifyFlags overrides the FILEVERIFYFLAGS rpmpack writes, which
+// verify everything, when noverify=() exempts any file.
+func addFileVerifyFlags(rpm *rpmpack.RPM, verifyFlags map[string]int32) {
+	flags := inFileOrder(verifyFlags)
+	if !slices.Contains(flags, verifyNone) {
+		return
+	}
+
+	rpm.AddCustomTag(tagFileVerifyFlags, rpmpack.EntryInt32(flags))
+}
+
+// inFileOrder returns the per-file values in the order of the file list,
+// which rpmpack writes sorted by name, for the tags parallel to it.
+func inFileOrder[T any](perFile map[string]T) []T {
+	names := make([]string, 0, len(perFile))
+	for name := range perFile {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	values := make([]T, len(names))
+	for i, name := range names {
+		values[i] = perFile[name]
+	}
+
+	return values
+}
+
+// fileOwnership returns the owner and group recorded for entry, root for
+// entries without attributes.
+func fileOwnership(entry *files.Entry) (owner, group string) {
+	owner, group = entry.Owner, entry.Group
+	if owner == "" {
+		owner = rootOwner
+	}
+
+	if group == "" {
+		group = rootOwner
+	}
+
+	return owner, group
+}
+
 // addScriptlets adds pre-install, post-install, pre-remove and post-remove
-// scripts from the PKGBUILD to the RPM package if they are defined.
+// scripts from the PKGBUILD to the RPM package if they are defined,
+// composed with the ones generated for systemd_units, sysusers and tmpfiles.
 //
 // It takes a pointer to the rpmpack.RPM instance as a parameter.
-func (r *RPM) addScriptlets(rpm *rpmpack.RPM) {
+func (r *RPM) addScriptlets(rpm *rpmpack.RPM) error {
 	// This string is appended to preun and postun directives
 	// to have a similar behaviour between deb and rpm.
 	onlyOnUninstall := "if [ $1 -ne 0 ]; then exit 0; fi\n"
 
+	system, err := r.SystemScriptlets()
+	if err != nil {
+		return err
+	}
+
+	preRm, postRm := r.PKGBUILD.PreRm, r.PKGBUILD.PostRm
+	if preRm != "" {
+		preRm = onlyOnUninstall + preRm
+	}
+
+	if postRm != "" {
+		postRm = onlyOnUninstall + postRm
+	}
+
 	if r.PKGBUILD.PreTrans != "" {
 		rpm.AddPretrans(r.PrepareScriptletWithHelpers(r.PKGBUILD.PreTrans))
 	}
 
-	if r.PKGBUILD.PreInst != "" {
-		rpm.AddPrein(r.PrepareScriptletWithHelpers(r.PKGBUILD.PreInst))
+	if preInst := common.JoinScriptlets(system.PreInst, r.PKGBUILD.PreInst); preInst != "" {
+		rpm.AddPrein(r.PrepareScriptletWithHelpers(preInst))
 	}
 
-	if r.PKGBUILD.PostInst != "" {
-		rpm.AddPostin(r.PrepareScriptletWithHelpers(r.PKGBUILD.PostInst))
+	if postInst := common.JoinScriptlets(r.PKGBUILD.PostInst, system.PostInst); postInst != "" {
+		rpm.AddPostin(r.PrepareScriptletWithHelpers(postInst))
 	}
 
-	if r.PKGBUILD.PreRm != "" {
-		rpm.AddPreun(r.PrepareScriptletWithHelpers(onlyOnUninstall + r.PKGBUILD.PreRm))
+	// The generated fragments go before the uninstall guard, as they
+	// handle upgrades themselves.
+	if preRm = common.JoinScriptlets(system.PreRm, preRm); preRm != "" {
+		rpm.AddPreun(r.PrepareScriptletWithHelpers(preRm))
 	}
 
-	if r.PKGBUILD.PostRm != "" {
-		rpm.AddPostun(r.PrepareScriptletWithHelpers(onlyOnUninstall + r.PKGBUILD.PostRm))
+	if postRm = common.JoinScriptlets(system.PostRm, postRm); postRm != "" {
+		rpm.AddPostun(r.PrepareScriptletWithHelpers(postRm))
 	}
 
 	if r.PKGBUILD.PostTrans != "" {
 		rpm.AddPosttrans(r.PrepareScriptletWithHelpers(r.PKGBUILD.PostTrans))
 	}
+
+	return nil
+}
+
+// addTriggers renders triggers=(): path triggers become %transfiletriggerin
+// scripts and named triggers %triggerin scripts on the package of that
+// name. Targets sharing a handler share its script, so that it runs once.
+func (r *RPM) addTriggers(rpm *rpmpack.RPM) {
+	var byPath, byName []triggers.Trigger
+
+	for _, trigger := range r.Triggers() {
+		if trigger.IsPath() {
+			byPath = append(byPath, trigger)
+		} else {
+			byName = append(byName, trigger)
+		}
+	}
+
+	r.addTriggerTags(rpm, packageTriggerTags, byName)
+
+	if count := r.addTriggerTags(rpm, fileTriggerTags, byPath); count > 0 {
+		priorities := make([]int32, count)
+		for i := range priorities {
+			priorities[i] = defaultTriggerPriority
+		}
+
+		rpm.AddCustomTag(tagTransFileTriggerPriorities, rpmpack.EntryInt32(priorities))
+	}
+}
+
+// addTriggerTags sets the tags of one kind of trigger and returns the
+// number of scripts added.
+func (r *RPM) addTriggerTags(rpm *rpmpack.RPM, tags triggerTags, list []triggers.Trigger) int {
+	if len(list) == 0 {
+		return 0
+	}
+
+	var (
+		scripts, progs, names, versions []string
+		flags, index                    []int32
+	)
+
+	for _, trigger := range list {
+		script := r.TriggerScript(trigger)
+
+		idx := slices.Index(scripts, script)
+		if idx < 0 {
+			scripts = append(scripts, script)
+			progs = append(progs, "/bin/sh")
+			idx = len(scripts) - 1
+		}
+
+		names = append(names, trigger.Target)
+		versions = append(versions, "")
+		flags = append(flags, rpmsenseTriggerIn)
+		index = append(index, int32(idx)) //nolint:gosec // bounded by the triggers=() entries
+	}
+
+	rpm.AddCustomTag(tags.scripts, rpmpack.EntryStringSlice(scripts))
+	rpm.AddCustomTag(tags.progs, rpmpack.EntryStringSlice(progs))
+	rpm.AddCustomTag(tags.names, rpmpack.EntryStringSlice(names))
+	rpm.AddCustomTag(tags.versions, rpmpack.EntryStringSlice(versions))
+	rpm.AddCustomTag(tags.flags, rpmpack.EntryInt32(flags))
+	rpm.AddCustomTag(tags.index, rpmpack.EntryInt32(index))
+
+	return len(scripts)
 }
 
 // addChangelog adds changelog entries to the RPM package if a changelog is
@@ -298,14 +574,16 @@ func asRPMDirectory(entry *files.Entry) (*rpmpack.RPMFile, error) {
 		return nil, err
 	}
 
+	owner, group := fileOwnership(entry)
+
 	// Create and return an RPMFile object for the directory.
 	return &rpmpack.RPMFile{
 		Name: entry.Destination, // Set the destination name.
 		// Set the mode to indicate it's a directory.
 		Mode:  uint(fileInfo.Mode()) | files.TagDirectory,
-		MTime: mTime,     // Set the modification time.
-		Owner: rootOwner, // Set the owner to "root".
-		Group: rootOwner, // Set the group to "root".
+		MTime: mTime, // Set the modification time.
+		Owner: owner, // Set the recorded owner.
+		Group: group, // Set the recorded group.
 	}, nil
 }
 
@@ -335,14 +613,16 @@ func asRPMFile(
 		return nil, err
 	}
 
+	owner, group := fileOwnership(entry)
+
 	// Create and return an RPMFile object for the regular file.
 	return &rpmpack.RPMFile{
 		Name:  entry.Destination,     // Set the destination name.
 		Body:  data,                  // Set the file data.
 		Mode:  uint(fileInfo.Mode()), // Set the file mode.
 		MTime: mTime,                 // Set the modification time.
-		Owner: rootOwner,             // Set the owner to "root".
-		Group: rootOwner,             // Set the group to "root".
+		Owner: owner,                 // Set the recorded owner.
+		Group: group,                 // Set the recorded group.
 		Type:  fileType,              // Set the file type.
 	}, nil
 }
@@ -372,20 +652,48 @@ func asRPMSymlink(entry *files.Entry) (*rpmpack.RPMFile, error) {
 		return nil, err
 	}
 
+	owner, group := fileOwnership(entry)
+
 	// Create and return an RPMFile object for the symlink.
 	return &rpmpack.RPMFile{
 		Name:  entry.Destination,   // Set the destination name.
 		Body:  []byte(body),        // Set the target of the symlink as the body.
 		Mode:  uint(files.TagLink), // Set the mode to indicate it's a symlink.
 		MTime: mTime,               // Set the modification time.
-		Owner: rootOwner,           // Set the owner to "root".
-		Group: rootOwner,           // Set the group to "root".
+		Owner: owner,               // Set the recorded owner.
+		Group: group,               // Set the recorded group.
+	}, nil
+}
+
+// asRPMGhost creates an RPMFile object for a ghost file that package() did
+// not create: an empty regular file owned by root.
+func asRPMGhost(entry *files.Entry) (*rpmpack.RPMFile, error) {
+	mTime := entry.ModTime.Unix()
+	if mTime < 0 || mTime > int64(^uint32(0)) {
+		return nil, errors.New(errors.ErrTypePackaging,
+			i18n.T("errors.rpm.modification_time_out_of_range")).
+			WithOperation("asRPMGhost").
+			WithContext("time", mTime)
+	}
+
+	return &rpmpack.RPMFile{
+		Name:  entry.Destination,
+		Mode:  uint(entry.Mode),
+		MTime: uint32(mTime), //nolint:gosec // range validated above
+		Owner: rootOwner,
+		Group: rootOwner,
+		Type:  rpmpack.GhostFile,
 	}, nil
 }
 
 // createRPMFile converts an Entry object into an RPMFile object based on its type.
 // It returns the created RPMFile and any error encountered during the conversion.
 func createRPMFile(entry *files.Entry) (*rpmpack.RPMFile, error) {
+	// Ghost files missing from the package directory have no source.
+	if entry.Source == "" && entry.IsGhost() {
+		return asRPMGhost(entry)
+	}
+
 	var file *rpmpack.RPMFile
 
 	var err error
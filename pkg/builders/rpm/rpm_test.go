@@ -402,11 +402,10 @@ func TestAddScriptlets(t *testing.T) {
 		Arch:    "x86_64",
 	})
 
-	rpm.addScriptlets(rpmObj)
-
-	// We can't easily verify that scriptlets were added without accessing private fields,
-	// but we can verify the method doesn't panic
-	t.Log("addScriptlets completed without error")
+	err := rpm.addScriptlets(rpmObj)
+	if err != nil {
+		t.Errorf("addScriptlets failed: %v", err)
+	}
 }
 
 func TestAsRPMDirectory(t *testing.T) {
@@ -449,6 +448,30 @@ func TestAsRPMDirectory(t *testing.T) {
 	}
 }
 
+func TestAsRPMFileOwnership(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "foo")
+
+	if err := os.WriteFile(testFile, []byte("foo"), 0o755); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	entry := &files.Entry{
+		Source:      testFile,
+		Destination: "/usr/bin/foo",
+		Type:        files.TypeFile,
+		Attributes:  files.Attributes{UID: 1, GID: 4, Owner: "daemon", Group: "adm"},
+	}
+
+	rpmFile, err := asRPMFile(entry, 0)
+	if err != nil {
+		t.Fatalf("asRPMFile failed: %v", err)
+	}
+
+	if rpmFile.Owner != "daemon" || rpmFile.Group != "adm" {
+		t.Errorf("Expected daemon:adm, got %s:%s", rpmFile.Owner, rpmFile.Group)
+	}
+}
+
 func TestAsRPMFile(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "rpm-test")
 	if err != nil {
@@ -584,6 +607,56 @@ func TestCreateRPMFile(t *testing.T) {
 	}
 }
 
+func TestRPMFileFlags(t *testing.T) {
+	tests := map[files.FileFlags]rpmpack.FileType{
+		0:                                    rpmpack.GenericFile,
+		files.FlagGhost:                      rpmpack.GhostFile,
+		files.FlagDoc | files.FlagLicense:    rpmpack.DocFile | rpmpack.LicenceFile,
+		files.FlagGhost | files.FlagNoVerify: rpmpack.GhostFile,
+	}
+
+	for flags, want := range tests {
+		if got := rpmFileFlags(flags); got != want {
+			t.Errorf("rpmFileFlags(%b) = %v, want %v", flags, got, want)
+		}
+	}
+}
+
+func TestMissingGhosts(t *testing.T) {
+	pkgBuild := createTestPKGBUILD()
+	pkgBuild.GhostFiles = []string{"run/foo.pid", "/var/log/foo.log"}
+	pkgBuild.NoVerifyFiles = []string{"var/log"}
+	r := &RPM{BaseBuilder: common.NewBaseBuilder(pkgBuild, "rpm")}
+
+	ghosts := r.missingGhosts([]*files.Entry{{Destination: "/run/foo.pid"}})
+	if len(ghosts) != 1 {
+		t.Fatalf("missingGhosts returned %d entries, want 1", len(ghosts))
+	}
+
+	ghost := ghosts[0]
+	if ghost.Destination != "/var/log/foo.log" || ghost.Flags != files.FlagGhost|files.FlagNoVerify {
+		t.Errorf("missingGhosts = %+v, want /var/log/foo.log flagged ghost and noverify", ghost)
+	}
+
+	file, err := createRPMFile(ghost)
+	if err != nil {
+		t.Fatalf("createRPMFile failed for ghost: %v", err)
+	}
+
+	if file.Type != rpmpack.GhostFile || file.Body != nil || file.Owner != rootOwner {
+		t.Errorf("createRPMFile = %+v, want an empty root-owned ghost", file)
+	}
+}
+
+func TestInFileOrder(t *testing.T) {
+	got := inFileOrder(map[string]int32{"/usr/bin/foo": verifyAll, "/etc/foo": verifyNone, "/var/foo": verifyAll})
+
+	want := []int32{verifyNone, verifyAll, verifyAll}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("inFileOrder = %v, want %v", got, want)
+	}
+}
+
 func TestExtractFileModTimeUint32(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "rpm-test")
 	if err != nil {
@@ -0,0 +1,383 @@
+package rpm
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/otiai10/copy"
+
+	"github.com/M0Rf30/yap/v2/pkg/archive"
+	"github.com/M0Rf30/yap/v2/pkg/builders/common"
+	"github.com/M0Rf30/yap/v2/pkg/constants"
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/files"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/logger"
+)
+
+// specFile is the template of the spec file of a source RPM. Its sections
+// run the PKGBUILD functions through the scripts shipped as sources, with
+// the environment makepkg gives them, and each package lists the files its
+// package() function installed.
+const specFile = `%global debug_package %{nil}
+%global yap_env srcdir="$PWD/src" startdir="%{_sourcedir}" CARCH="%{_target_cpu}"
+
+Name:           {{.Name}}
+Version:        {{.Version}}
+Release:        {{.Release}}
+{{- with .Epoch}}
+Epoch:          {{.}}
+{{- end}}
+Summary:        {{.Summary}}
+License:        {{.License}}
+{{- with .URL}}
+URL:            {{.}}
+{{- end}}
+{{- if eq .Arch "noarch"}}
+BuildArch:      noarch
+{{- end}}
+{{- range $index, $source := .Sources}}
+Source{{$index}}:{{if lt $index 10}} {{end}}       {{$source}}
+{{- end}}
+{{- range $index, $patch := .Patches}}
+Patch{{$index}}:{{if lt $index 10}} {{end}}        {{$patch}}
+{{- end}}
+{{- range .BuildRequires}}
+BuildRequires:  {{.}}
+{{- end}}
+{{- range .Relations}}
+{{.}}
+{{- end}}
+
+%description
+{{.Description}}
+{{- range .Subpackages}}
+
+%package -n {{.Name}}
+Summary:        {{.Summary}}
+{{- range .Relations}}
+{{.}}
+{{- end}}
+
+%description -n {{.Name}}
+{{.Description}}
+{{- end}}
+
+%prep
+%setup -q -c -T
+env %{yap_env} bash %{_sourcedir}/yap-sources.sh %{_sourcedir}
+{{- if .Stages.prepare}}
+env %{yap_env} bash %{_sourcedir}/yap-prepare.sh
+{{- end}}
+{{- if .Stages.build}}
+
+%build
+env %{yap_env} bash %{_sourcedir}/yap-build.sh
+{{- end}}
+
+%install
+rm -rf pkg
+mkdir -p %{buildroot}
+{{- range .Packages}}
+mkdir -p pkg/{{.Name}}
+env %{yap_env} pkgdir="$PWD/pkg/{{.Name}}" bash %{_sourcedir}/yap-package_{{.Name}}.sh
+(cd pkg/{{.Name}} && find . -mindepth 1 \( -type d -empty -printf '%%%%dir "/%%P"\n' \) -o \( ! -type d -printf '"/%%P"\n' \)) > {{.Name}}.files
+cp -a pkg/{{.Name}}/. %{buildroot}/
+{{- end}}
+{{- if .Stages.check}}
+
+%check
+env %{yap_env} bash %{_sourcedir}/yap-check.sh
+{{- end}}
+{{- range .Packages}}
+
+%files{{if .Split}} -n {{.Name}}{{end}} -f {{.Name}}.files
+{{- end}}
+`
+
+// specData is the data the spec file is rendered with. Its text fields
+// shadow the header ones, with the macros escaped.
+type specData struct {
+	srpmMetadata
+	Summary     string
+	Description string
+	Relations   []string
+	Subpackages []*specPackage
+	Packages    []*specPackage
+	Stages      map[string]bool
+}
+
+// specPackage is a package built by the spec file.
+type specPackage struct {
+	Name        string
+	Split       bool // declared with %package -n
+	Summary     string
+	Description string
+	Relations   []string
+}
+
+// specRelations map spec tags to the PKGBUILD arrays they are filled from.
+var specRelations = []struct {
+	tag  string
+	deps func(r *RPM) []string
+}{
+	{"Requires", func(r *RPM) []string { return r.PKGBUILD.Depends }},
+	{"Provides", func(r *RPM) []string { return r.PKGBUILD.Provides }},
+	{"Conflicts", func(r *RPM) []string { return r.PKGBUILD.Conflicts }},
+	{"Obsoletes", func(r *RPM) []string { return r.PKGBUILD.Replaces }},
+	{"Recommends", func(r *RPM) []string { return r.PKGBUILD.OptDepends }},
+	{"Suggests", func(r *RPM) []string { return r.PKGBUILD.Suggests }},
+	{"Enhances", func(r *RPM) []string { return r.PKGBUILD.Enhances }},
+	{"Supplements", func(r *RPM) []string { return r.PKGBUILD.Supplements }},
+}
+
+// BuildSourcePackage writes the source RPM of the PKGBUILD to
+// artifactsPath: a generated spec file, whose sections run the PKGBUILD
+// functions, with the sources and the scripts it runs. VCS checkouts are
+// shipped as tarballs. It returns the path of the source RPM, the one to
+// sign.
+func (r *RPM) BuildSourcePackage(ctx context.Context, artifactsPath string) ([]string, error) {
+	r.getGroup()
+	r.getRelease()
+	r.SetTargetArchitecture("")
+
+	sources, err := r.SourceFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	stageDir, err := os.MkdirTemp(r.PKGBUILD.SourceDir, "tmp")
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err := os.RemoveAll(stageDir); err != nil {
+			logger.Warn(i18n.T("logger.rpm.warn.failed_to_remove_temporary"),
+				"path", stageDir, "error", err)
+		}
+	}()
+
+	data, err := r.specTemplateData(sources)
+	if err != nil {
+		return nil, err
+	}
+
+	specName := data.Name + ".spec"
+	srpmFiles := []srpmFile{{name: specName, path: filepath.Join(stageDir, specName), spec: true}}
+
+	for index, file := range sources {
+		if !file.Dir {
+			srpmFiles = append(srpmFiles, srpmFile{name: file.Name, path: file.Path})
+
+			continue
+		}
+
+		packedPath, err := packSourceDir(ctx, stageDir, index, file)
+		if err != nil {
+			return nil, err
+		}
+
+		srpmFiles = append(srpmFiles, srpmFile{name: file.PackedName(), path: packedPath})
+	}
+
+	scripts := r.SourceScripts(sources, true)
+	names := make([]string, 0, len(scripts))
+
+	for name := range scripts {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	for _, name := range names {
+		scriptName := "yap-" + name + ".sh"
+		scriptPath := filepath.Join(stageDir, scriptName)
+
+		if err := os.WriteFile(scriptPath, []byte(scripts[name]), 0o644); err != nil { //nolint:gosec
+			return nil, err
+		}
+
+		data.Sources = append(data.Sources, scriptName)
+		srpmFiles = append(srpmFiles, srpmFile{name: scriptName, path: scriptPath})
+	}
+
+	var spec bytes.Buffer
+
+	if err := r.PKGBUILD.RenderSpec(specFile).Execute(&spec, data); err != nil {
+		return nil, err
+	}
+
+	if err := files.CreateWrite(srpmFiles[0].path, spec.String()); err != nil {
+		return nil, err
+	}
+
+	srpmPath := filepath.Join(artifactsPath,
+		data.Name+"-"+data.Version+"-"+data.Release+".src.rpm")
+
+	if err := writeSRPM(srpmPath, &data.srpmMetadata, srpmFiles); err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypePackaging,
+			i18n.T("errors.common.failed_to_create_source_package")).
+			WithOperation("BuildSourcePackage").
+			WithContext("path", srpmPath)
+	}
+
+	r.LogPackageCreated(srpmPath)
+
+	return []string{srpmPath}, nil
+}
+
+// specTemplateData returns the template data of the spec file, with the
+// build dependencies of every package and the overrides of the package_*()
+// functions of split packages.
+func (r *RPM) specTemplateData(sources []common.SourceFile) (*specData, error) {
+	pkgBuild := r.PKGBUILD
+	name := pkgBuild.PkgName
+
+	defer func() {
+		pkgBuild.PkgName = name
+		pkgBuild.RestoreTopLevelOverrides()
+	}()
+
+	data := &specData{
+		srpmMetadata: srpmMetadata{
+			Name:        pkgBuild.EffectivePkgBase(),
+			Version:     pkgBuild.PkgVer,
+			Release:     pkgBuild.PkgRel,
+			Epoch:       pkgBuild.Epoch,
+			Summary:     pkgBuild.PkgDesc,
+			Description: specDescription(pkgBuild.PkgDescLong, pkgBuild.PkgDesc),
+			License:     strings.Join(pkgBuild.License, " "),
+			Group:       pkgBuild.Section,
+			URL:         pkgBuild.HomepageURL(),
+			Packager:    pkgBuild.Maintainer,
+			Arch:        pkgBuild.ArchComputed,
+			BuildTime:   files.SourceDateEpochFromEnv(),
+		},
+		Summary:     escapeSpec(pkgBuild.PkgDesc),
+		Description: escapeSpec(specDescription(pkgBuild.PkgDescLong, pkgBuild.PkgDesc)),
+		Stages: map[string]bool{
+			"prepare": pkgBuild.Prepare != "",
+			"build":   pkgBuild.Build != "",
+			"check":   pkgBuild.Check != "",
+		},
+	}
+
+	if !pkgBuild.IsSplitPackage() {
+		data.Name = pkgBuild.PkgName
+	}
+
+	if epoch, err := strconv.ParseUint(pkgBuild.Epoch, 10, 32); err != nil || epoch == 0 {
+		data.Epoch = ""
+	}
+
+	for _, file := range sources {
+		entry := file.Name
+		if file.Dir {
+			entry = file.PackedName()
+		} else if file.URL != "" {
+			entry = file.URL
+			if path.Base(file.URL) != file.Name {
+				entry += "#/" + file.Name
+			}
+		}
+
+		if file.IsPatch() {
+			data.Patches = append(data.Patches, entry)
+		} else {
+			data.Sources = append(data.Sources, entry)
+		}
+	}
+
+	// makepkg installs depends=() along with makedepends=() and
+	// checkdepends=() before building.
+	for _, deps := range [][]string{pkgBuild.Depends, pkgBuild.MakeDepends, pkgBuild.CheckDepends} {
+		for _, dep := range r.ProcessDependencies(deps) {
+			if !slices.Contains(data.BuildRequires, dep) {
+				data.BuildRequires = append(data.BuildRequires, dep)
+			}
+		}
+	}
+
+	for _, binary := range r.SourcePackageNames() {
+		pkg := &specPackage{Name: binary}
+
+		if pkgBuild.IsSplitPackage() {
+			pkgBuild.PkgName = binary
+			pkgBuild.RestoreTopLevelOverrides()
+
+			if err := pkgBuild.ParseSplitOverrides(pkgBuild.SplitPackageFuncs[binary]); err != nil {
+				return nil, err
+			}
+		}
+
+		pkg.Summary = escapeSpec(pkgBuild.PkgDesc)
+		pkg.Description = escapeSpec(specDescription(pkgBuild.PkgDescLong, pkgBuild.PkgDesc))
+
+		for _, relation := range specRelations {
+			for _, dep := range r.ProcessDependencies(relation.deps(r)) {
+				pkg.Relations = append(pkg.Relations, relation.tag+":"+
+					strings.Repeat(" ", max(1, 16-len(relation.tag)-1))+dep)
+			}
+		}
+
+		data.Packages = append(data.Packages, pkg)
+	}
+
+	// The package named like the spec file is the main one, the others
+	// are declared with %package -n.
+	for _, pkg := range data.Packages {
+		if pkg.Name != data.Name {
+			pkg.Split = true
+			data.Subpackages = append(data.Subpackages, pkg)
+
+			continue
+		}
+
+		data.Summary, data.Description, data.Relations = pkg.Summary, pkg.Description, pkg.Relations
+	}
+
+	return data, nil
+}
+
+// packSourceDir packs the VCS checkout file into its PackedName tarball in
+// stageDir, returning the tarball path.
+func packSourceDir(ctx context.Context, stageDir string, index int, file common.SourceFile) (string, error) {
+	packDir := filepath.Join(stageDir, "dirs", strconv.Itoa(index))
+
+	if err := copy.Copy(file.Path, filepath.Join(packDir, file.Name)); err != nil {
+		return "", errors.Wrap(err, errors.ErrTypeFileSystem,
+			i18n.T("errors.common.failed_to_create_source_package")).
+			WithOperation("packSourceDir").
+			WithContext("source", file.Name)
+	}
+
+	packedPath := filepath.Join(stageDir, file.PackedName())
+
+	if err := archive.CreateTarCompressed(ctx, packDir, packedPath,
+		constants.CompressionGzip, false); err != nil {
+		return "", err
+	}
+
+	return packedPath, nil
+}
+
+// specDescription returns the long description, or the short one.
+func specDescription(long, short string) string {
+	if long != "" {
+		return long
+	}
+
+	return short
+}
+
+// escapeSpec escapes the macros in text copied into the spec file.
+func escapeSpec(text string) string {
+	return strings.ReplaceAll(text, "%", "%%")
+}
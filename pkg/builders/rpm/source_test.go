@@ -0,0 +1,142 @@
+package rpm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	rpmutils "github.com/sassoftware/go-rpmutils"
+
+	"github.com/M0Rf30/yap/v2/pkg/builders/common"
+)
+
+func TestBuildSourcePackage(t *testing.T) {
+	startDir := t.TempDir()
+	pkgBuild := createTestPKGBUILD()
+	pkgBuild.StartDir = startDir
+	pkgBuild.SourceDir = filepath.Join(startDir, "src")
+	pkgBuild.SourceURI = []string{"data.txt", "fix.patch"}
+	pkgBuild.PkgDesc = "Test package at 100%"
+	pkgBuild.Build = "make"
+	pkgBuild.Package = "make DESTDIR=\"${pkgdir}\" install"
+
+	if err := os.MkdirAll(pkgBuild.SourceDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range pkgBuild.SourceURI {
+		if err := os.WriteFile(filepath.Join(startDir, name), []byte(name+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	artifactsDir := t.TempDir()
+	rpm := &RPM{BaseBuilder: common.NewBaseBuilder(pkgBuild, "rpm")}
+
+	paths, err := rpm.BuildSourcePackage(context.Background(), artifactsDir)
+	if err != nil {
+		t.Fatalf("BuildSourcePackage() error = %v", err)
+	}
+
+	want := filepath.Join(artifactsDir, "test-package-1.0.0-"+pkgBuild.PkgRel+".src.rpm")
+	if !reflect.DeepEqual(paths, []string{want}) {
+		t.Fatalf("BuildSourcePackage() = %v, want %v", paths, []string{want})
+	}
+
+	file, err := os.Open(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() { _ = file.Close() }()
+
+	// Verify checks the header and payload digests of the signature header.
+	hdr, _, err := rpmutils.Verify(file, nil)
+	if err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	if sources, _ := hdr.GetStrings(rpmutils.PATCH); !reflect.DeepEqual(sources, []string{"fix.patch"}) {
+		t.Errorf("patches = %v, want [fix.patch]", sources)
+	}
+
+	requires, _ := hdr.GetStrings(rpmutils.REQUIRENAME)
+	for _, name := range []string{"dependency1", "make", "gcc", "rpmlib(FileDigests)"} {
+		if !strings.Contains(strings.Join(requires, " "), name) {
+			t.Errorf("requires = %v, missing %s", requires, name)
+		}
+	}
+
+	fileInfos, err := hdr.GetFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, info := range fileInfos {
+		names = append(names, info.Name())
+	}
+
+	wantNames := []string{
+		"test-package.spec", "data.txt", "fix.patch",
+		"yap-build.sh", "yap-package_test-package.sh", "yap-sources.sh",
+	}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("files = %v, want %v", names, wantNames)
+	}
+
+	if _, err := file.Seek(0, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	srpm, err := rpmutils.ReadRpm(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expandDir := t.TempDir()
+	if err := srpm.ExpandPayload(expandDir); err != nil {
+		t.Fatalf("ExpandPayload() error = %v", err)
+	}
+
+	spec, err := os.ReadFile(filepath.Join(expandDir, "test-package.spec"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range []string{
+		"Name:           test-package",
+		"Epoch:          1",
+		"Summary:        Test package at 100%%",
+		"Source0:        data.txt",
+		"Patch0:         fix.patch",
+		"BuildRequires:  dependency1 >= 1.0",
+		"Requires:       dependency1 >= 1.0",
+		"Obsoletes:      legacy-rpm-package",
+		"%files -f test-package.files",
+	} {
+		if !strings.Contains(string(spec), line+"\n") {
+			t.Errorf("spec file misses %q:\n%s", line, spec)
+		}
+	}
+
+	if strings.Contains(string(spec), "%check") {
+		t.Errorf("spec file has a %%check section without check():\n%s", spec)
+	}
+}
+
+func TestSourceNames(t *testing.T) {
+	got := sourceNames([]string{
+		"foo.patch",
+		"https://example.com/v1.0.tar.gz#/foo-1.0.tar.gz",
+		"https://example.com/foo-1.0.tar.gz",
+	})
+
+	want := []string{"foo.patch", "foo-1.0.tar.gz", "foo-1.0.tar.gz"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sourceNames() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,572 @@
+package rpm
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/md5" //nolint:gosec // RPMSIGTAG_MD5 is part of the v4 package format.
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	rpmutils "github.com/sassoftware/go-rpmutils"
+
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/logger"
+)
+
+const (
+	// tagSignatures and tagImmutable are the region tags of the signature
+	// header and of the main header.
+	tagSignatures = 62
+	tagImmutable  = 63
+	// tagHeaderI18nTable is RPMTAG_HEADERI18NTABLE, the locales of the
+	// i18n strings.
+	tagHeaderI18nTable = 100
+	// tagFileLangs is RPMTAG_FILELANGS, the language of each file.
+	tagFileLangs = 1097
+	// tagSourcePackage is RPMTAG_SOURCEPACKAGE, set in source RPMs.
+	tagSourcePackage = 1106
+	// tagPayloadFlags is RPMTAG_PAYLOADFLAGS, the compression level.
+	tagPayloadFlags = 1126
+	// sigTagSize, sigTagMD5 and sigTagPayloadSize are the signature header
+	// tags holding the size and the MD5 of the header plus the compressed
+	// payload, and the size of the uncompressed payload.
+	sigTagSize        = 1000
+	sigTagMD5         = 1004
+	sigTagPayloadSize = 1007
+	// digestSHA256 is the PGPHASHALGO_SHA256 value of the digest algorithm
+	// tags.
+	digestSHA256 = 8
+	// fileFlagSpec is RPMFILE_SPECFILE, the flag of the spec file.
+	fileFlagSpec = 1 << 5
+	// rpmsenseLess, rpmsenseGreater and rpmsenseEqual are the comparison
+	// flags of dependencies, rpmsenseRPMLib the one of rpmlib() features.
+	rpmsenseLess    = 1 << 1
+	rpmsenseGreater = 1 << 2
+	rpmsenseEqual   = 1 << 3
+	rpmsenseRPMLib  = 1 << 24
+	// srpmFileMode is the mode of the files of a source RPM.
+	srpmFileMode = 0o100644
+)
+
+var (
+	// headerMagic starts every header structure.
+	headerMagic = []byte{0x8e, 0xad, 0xe8, 0x01, 0, 0, 0, 0}
+	// rpmsenseOperators map dependency operators to their flags.
+	rpmsenseOperators = map[string]int32{
+		"<":  rpmsenseLess,
+		"<=": rpmsenseLess | rpmsenseEqual,
+		"=":  rpmsenseEqual,
+		">=": rpmsenseGreater | rpmsenseEqual,
+		">":  rpmsenseGreater,
+	}
+	// srpmFeatures are the rpmlib() features every source RPM requires:
+	// split file names and SHA-256 file digests.
+	srpmFeatures = [][2]string{
+		{"rpmlib(CompressedFileNames)", "3.0.4-1"},
+		{"rpmlib(FileDigests)", "4.6.0-1"},
+	}
+)
+
+// srpmFile is a file shipped in a source RPM.
+type srpmFile struct {
+	name string // bare name in the payload
+	path string // file to read it from
+	spec bool
+}
+
+// srpmMetadata is the metadata of a source RPM.
+type srpmMetadata struct {
+	Name          string
+	Version       string
+	Release       string
+	Epoch         string
+	Summary       string
+	Description   string
+	License       string
+	Group         string
+	URL           string
+	Packager      string
+	Arch          string
+	Sources       []string
+	Patches       []string
+	BuildRequires []string // "name", or "name op version"
+	BuildTime     time.Time
+}
+
+// headerEntry is a tag of a header structure.
+type headerEntry struct {
+	tag   int32
+	kind  int32
+	count int32
+	data  []byte
+}
+
+// header builds a header structure.
+type header []headerEntry
+
+func (h *header) add(tag, kind int32, count int, data []byte) {
+	*h = append(*h, headerEntry{tag: tag, kind: kind, count: int32(count), data: data}) //nolint:gosec
+}
+
+func (h *header) addString(tag int32, value string) {
+	h.add(tag, rpmutils.RPM_STRING_TYPE, 1, append([]byte(value), 0))
+}
+
+func (h *header) addStrings(tag, kind int32, values []string) {
+	var data []byte
+
+	for _, value := range values {
+		data = append(append(data, value...), 0)
+	}
+
+	h.add(tag, kind, len(values), data)
+}
+
+func (h *header) addInt32(tag int32, values ...int32) {
+	data := make([]byte, 0, 4*len(values))
+
+	for _, value := range values {
+		data = binary.BigEndian.AppendUint32(data, uint32(value)) //nolint:gosec
+	}
+
+	h.add(tag, rpmutils.RPM_INT32_TYPE, len(values), data)
+}
+
+func (h *header) addInt16(tag int32, values ...int16) {
+	data := make([]byte, 0, 2*len(values))
+
+	for _, value := range values {
+		data = binary.BigEndian.AppendUint16(data, uint16(value)) //nolint:gosec
+	}
+
+	h.add(tag, rpmutils.RPM_INT16_TYPE, len(values), data)
+}
+
+// bytes encodes the header with its region tag: entries sorted by tag,
+// integers aligned, and the region trailer at the end of the data.
+func (h header) bytes(regionTag int32) []byte {
+	sort.SliceStable(h, func(i, j int) bool { return h[i].tag < h[j].tag })
+
+	var data bytes.Buffer
+
+	offsets := make([]int32, len(h))
+
+	for i, entry := range h {
+		align := 1
+
+		switch entry.kind {
+		case rpmutils.RPM_INT16_TYPE:
+			align = 2
+		case rpmutils.RPM_INT32_TYPE:
+			align = 4
+		}
+
+		data.Write(make([]byte, (align-data.Len()%align)%align))
+
+		offsets[i] = int32(data.Len()) //nolint:gosec
+		data.Write(entry.data)
+	}
+
+	count := int32(len(h) + 1)        //nolint:gosec
+	regionOffset := int32(data.Len()) //nolint:gosec
+
+	_ = binary.Write(&data, binary.BigEndian,
+		[]int32{regionTag, rpmutils.RPM_BIN_TYPE, -16 * count, 16})
+
+	var out bytes.Buffer
+
+	out.Write(headerMagic)
+	_ = binary.Write(&out, binary.BigEndian, []int32{count, int32(data.Len())}) //nolint:gosec
+	_ = binary.Write(&out, binary.BigEndian, []int32{regionTag, rpmutils.RPM_BIN_TYPE, regionOffset, 16})
+
+	for i, entry := range h {
+		_ = binary.Write(&out, binary.BigEndian, []int32{entry.tag, entry.kind, offsets[i], entry.count})
+	}
+
+	out.Write(data.Bytes())
+
+	return out.Bytes()
+}
+
+// srpmPayload is the digested content of the files of a source RPM.
+type srpmPayload struct {
+	sizes   []int32
+	digests []string
+	size    int64 // uncompressed
+	digest  string
+}
+
+// writeSRPM writes the source RPM shipping files to path: the lead, the
+// signature header with the digests rpm checks, the header and the
+// gzip-compressed cpio payload, staged in a temporary file next to path.
+func writeSRPM(path string, meta *srpmMetadata, srpmFiles []srpmFile) error {
+	payloadFile, err := os.CreateTemp(filepath.Dir(path), ".payload-*")
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := os.Remove(payloadFile.Name()); err != nil {
+			logger.Warn(i18n.T("logger.rpm.warn.failed_to_remove_temporary"),
+				"path", payloadFile.Name(), "error", err)
+		}
+	}()
+
+	defer func() {
+		_ = payloadFile.Close()
+	}()
+
+	mtime := int32(meta.BuildTime.Unix()) //nolint:gosec
+
+	payload, err := writeSRPMPayload(payloadFile, srpmFiles, mtime)
+	if err != nil {
+		return err
+	}
+
+	payloadSize, err := payloadFile.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+
+	mainHeader := srpmHeader(meta, srpmFiles, payload, mtime).bytes(tagImmutable)
+
+	if _, err := payloadFile.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	md5Hash := md5.New() //nolint:gosec
+	md5Hash.Write(mainHeader)
+
+	if _, err := io.Copy(md5Hash, payloadFile); err != nil {
+		return err
+	}
+
+	headerDigest := sha256.Sum256(mainHeader)
+
+	var signature header
+
+	signature.addString(rpmutils.SIG_SHA256, hex.EncodeToString(headerDigest[:]))
+	signature.addInt32(sigTagSize, int32(int64(len(mainHeader))+payloadSize)) //nolint:gosec
+	signature.add(sigTagMD5, rpmutils.RPM_BIN_TYPE, md5.Size, md5Hash.Sum(nil))
+	signature.addInt32(sigTagPayloadSize, int32(payload.size)) //nolint:gosec
+
+	signatureHeader := signature.bytes(tagSignatures)
+	signatureHeader = append(signatureHeader, make([]byte, (8-len(signatureHeader)%8)%8)...)
+
+	if _, err := payloadFile.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	out, err := os.Create(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err := out.Close(); err != nil {
+			logger.Warn(i18n.T("logger.rpm.warn.failed_to_close_rpm"), "path", path, "error", err)
+		}
+	}()
+
+	writer := bufio.NewWriter(out)
+
+	for _, part := range [][]byte{srpmLead(meta), signatureHeader, mainHeader} {
+		if _, err := writer.Write(part); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.Copy(writer, payloadFile); err != nil {
+		return err
+	}
+
+	return writer.Flush()
+}
+
+// writeSRPMPayload writes the gzip-compressed cpio archive of srpmFiles to
+// w, digesting each file and the archive itself.
+func writeSRPMPayload(w io.Writer, srpmFiles []srpmFile, mtime int32) (*srpmPayload, error) {
+	payload := &srpmPayload{}
+	archiveHash := sha256.New()
+
+	compressor, err := gzip.NewWriterLevel(io.MultiWriter(w, archiveHash), gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+
+	archive := &cpioWriter{w: compressor}
+
+	for index, file := range srpmFiles {
+		size, digest, err := archive.writeFile(file, index+1, mtime)
+		if err != nil {
+			return nil, err
+		}
+
+		payload.sizes = append(payload.sizes, int32(size)) //nolint:gosec
+		payload.digests = append(payload.digests, digest)
+	}
+
+	if err := archive.writeTrailer(); err != nil {
+		return nil, err
+	}
+
+	if err := compressor.Close(); err != nil {
+		return nil, err
+	}
+
+	payload.size = archive.size
+	payload.digest = hex.EncodeToString(archiveHash.Sum(nil))
+
+	return payload, nil
+}
+
+// srpmHeader returns the main header of a source RPM.
+func srpmHeader(meta *srpmMetadata, srpmFiles []srpmFile, payload *srpmPayload, mtime int32) header {
+	var hdr header
+
+	hdr.addStrings(tagHeaderI18nTable, rpmutils.RPM_STRING_ARRAY_TYPE, []string{"C"})
+	hdr.addString(rpmutils.NAME, meta.Name)
+	hdr.addString(rpmutils.VERSION, meta.Version)
+	hdr.addString(rpmutils.RELEASE, meta.Release)
+
+	if epoch, err := strconv.ParseInt(meta.Epoch, 10, 32); err == nil {
+		hdr.addInt32(rpmutils.EPOCH, int32(epoch))
+	}
+
+	hdr.addStrings(rpmutils.SUMMARY, rpmutils.RPM_I18NSTRING_TYPE, []string{meta.Summary})
+	hdr.addStrings(rpmutils.DESCRIPTION, rpmutils.RPM_I18NSTRING_TYPE, []string{meta.Description})
+	hdr.addInt32(rpmutils.BUILDTIME, mtime)
+	hdr.addString(rpmutils.BUILDHOST, "yap")
+
+	var size int32
+	for _, fileSize := range payload.sizes {
+		size += fileSize
+	}
+
+	hdr.addInt32(rpmutils.SIZE, size)
+	hdr.addString(rpmutils.LICENSE, meta.License)
+
+	if meta.Group != "" {
+		hdr.addStrings(rpmutils.GROUP, rpmutils.RPM_I18NSTRING_TYPE, []string{meta.Group})
+	}
+
+	if meta.Packager != "" {
+		hdr.addString(rpmutils.PACKAGER, meta.Packager)
+	}
+
+	if meta.URL != "" {
+		hdr.addString(rpmutils.URL, meta.URL)
+	}
+
+	hdr.addString(rpmutils.OS, "linux")
+	hdr.addString(rpmutils.ARCH, meta.Arch)
+
+	if len(meta.Sources) > 0 {
+		hdr.addStrings(rpmutils.SOURCE, rpmutils.RPM_STRING_ARRAY_TYPE, sourceNames(meta.Sources))
+	}
+
+	if len(meta.Patches) > 0 {
+		hdr.addStrings(rpmutils.PATCH, rpmutils.RPM_STRING_ARRAY_TYPE, sourceNames(meta.Patches))
+	}
+
+	count := len(srpmFiles)
+	names := make([]string, count)
+	modes, rdevs := make([]int16, count), make([]int16, count)
+	mtimes, flags := make([]int32, count), make([]int32, count)
+	verifyFlags, devices := make([]int32, count), make([]int32, count)
+	inodes, dirIndexes := make([]int32, count), make([]int32, count)
+	owners, empty := make([]string, count), make([]string, count)
+
+	mode := uint16(srpmFileMode)
+
+	for index, file := range srpmFiles {
+		names[index] = file.name
+		modes[index] = int16(mode) //nolint:gosec // the mode bits are stored as is
+		mtimes[index] = mtime
+		verifyFlags[index] = verifyAll
+		devices[index] = 1
+		inodes[index] = int32(index + 1) //nolint:gosec
+		owners[index] = rootOwner
+
+		if file.spec {
+			flags[index] = fileFlagSpec
+		}
+	}
+
+	hdr.addInt32(rpmutils.FILESIZES, payload.sizes...)
+	hdr.addInt16(rpmutils.FILEMODES, modes...)
+	hdr.addInt16(rpmutils.FILERDEVS, rdevs...)
+	hdr.addInt32(rpmutils.FILEMTIMES, mtimes...)
+	hdr.addStrings(rpmutils.FILEDIGESTS, rpmutils.RPM_STRING_ARRAY_TYPE, payload.digests)
+	hdr.addStrings(rpmutils.FILELINKTOS, rpmutils.RPM_STRING_ARRAY_TYPE, empty)
+	hdr.addInt32(rpmutils.FILEFLAGS, flags...)
+	hdr.addStrings(rpmutils.FILEUSERNAME, rpmutils.RPM_STRING_ARRAY_TYPE, owners)
+	hdr.addStrings(rpmutils.FILEGROUPNAME, rpmutils.RPM_STRING_ARRAY_TYPE, owners)
+	hdr.addInt32(rpmutils.FILEVERIFYFLAGS, verifyFlags...)
+	hdr.addInt32(rpmutils.FILEDEVICES, devices...)
+	hdr.addInt32(rpmutils.FILEINODES, inodes...)
+	hdr.addStrings(tagFileLangs, rpmutils.RPM_STRING_ARRAY_TYPE, empty)
+	hdr.addInt32(rpmutils.DIRINDEXES, dirIndexes...)
+	hdr.addStrings(rpmutils.BASENAMES, rpmutils.RPM_STRING_ARRAY_TYPE, names)
+	hdr.addStrings(rpmutils.DIRNAMES, rpmutils.RPM_STRING_ARRAY_TYPE, []string{""})
+	hdr.addInt32(rpmutils.FILEDIGESTALGO, digestSHA256)
+
+	var requireNames, requireVersions []string
+
+	var requireFlags []int32
+
+	for _, require := range meta.BuildRequires {
+		fields := strings.Fields(require)
+		if len(fields) == 3 {
+			requireNames = append(requireNames, fields[0])
+			requireVersions = append(requireVersions, fields[2])
+			requireFlags = append(requireFlags, rpmsenseOperators[fields[1]])
+
+			continue
+		}
+
+		requireNames = append(requireNames, require)
+		requireVersions = append(requireVersions, "")
+		requireFlags = append(requireFlags, 0)
+	}
+
+	for _, feature := range srpmFeatures {
+		requireNames = append(requireNames, feature[0])
+		requireVersions = append(requireVersions, feature[1])
+		requireFlags = append(requireFlags, rpmsenseRPMLib|rpmsenseLess|rpmsenseEqual)
+	}
+
+	hdr.addInt32(rpmutils.REQUIREFLAGS, requireFlags...)
+	hdr.addStrings(rpmutils.REQUIRENAME, rpmutils.RPM_STRING_ARRAY_TYPE, requireNames)
+	hdr.addStrings(rpmutils.REQUIREVERSION, rpmutils.RPM_STRING_ARRAY_TYPE, requireVersions)
+	hdr.addInt32(tagSourcePackage, 1)
+	hdr.addString(rpmutils.PAYLOADFORMAT, "cpio")
+	hdr.addString(rpmutils.PAYLOADCOMPRESSOR, "gzip")
+	hdr.addString(tagPayloadFlags, "9")
+	hdr.addStrings(rpmutils.PAYLOADDIGEST, rpmutils.RPM_STRING_ARRAY_TYPE, []string{payload.digest})
+	hdr.addInt32(rpmutils.PAYLOADDIGESTALGO, digestSHA256)
+
+	return hdr
+}
+
+// sourceNames returns the file names of Source and Patch entries, which
+// are either names or URLs, optionally renamed by a #/name fragment.
+func sourceNames(entries []string) []string {
+	names := make([]string, len(entries))
+
+	for index, entry := range entries {
+		if _, name, ok := strings.Cut(entry, "#/"); ok {
+			names[index] = name
+		} else {
+			names[index] = path.Base(entry)
+		}
+	}
+
+	return names
+}
+
+// srpmLead returns the lead of a source RPM, kept for the tools reading
+// the legacy format.
+func srpmLead(meta *srpmMetadata) []byte {
+	name := make([]byte, 66)
+	copy(name[:65], meta.Name+"-"+meta.Version+"-"+meta.Release)
+
+	lead := []byte{0xed, 0xab, 0xee, 0xdb, 3, 0, 0, 1, 0, 1}
+	lead = append(lead, name...)
+	lead = append(lead, 0, 1, 0, 5)
+
+	return append(lead, make([]byte, 16)...)
+}
+
+// cpioWriter writes a cpio archive in the new ASCII format rpm expects.
+type cpioWriter struct {
+	w    io.Writer
+	size int64
+}
+
+// writeFile appends file to the archive, returning its size and SHA-256.
+func (cw *cpioWriter) writeFile(file srpmFile, inode int, mtime int32) (int64, string, error) {
+	source, err := os.Open(filepath.Clean(file.path))
+	if err != nil {
+		return 0, "", err
+	}
+
+	defer func() {
+		_ = source.Close()
+	}()
+
+	info, err := source.Stat()
+	if err != nil {
+		return 0, "", err
+	}
+
+	if info.Size() > math.MaxUint32 {
+		return 0, "", errors.New(errors.ErrTypePackaging,
+			i18n.T("errors.common.failed_to_create_source_package")).
+			WithOperation("writeSRPM").
+			WithContext("file", file.name).
+			WithContext("size", info.Size())
+	}
+
+	if err := cw.writeHeader(file.name, inode, srpmFileMode, mtime, info.Size()); err != nil {
+		return 0, "", err
+	}
+
+	digest := sha256.New()
+
+	size, err := io.Copy(io.MultiWriter(cw, digest), source)
+	if err != nil {
+		return 0, "", err
+	}
+
+	if err := cw.pad(); err != nil {
+		return 0, "", err
+	}
+
+	return size, hex.EncodeToString(digest.Sum(nil)), nil
+}
+
+// writeTrailer ends the archive.
+func (cw *cpioWriter) writeTrailer() error {
+	return cw.writeHeader("TRAILER!!!", 0, 0, 0, 0)
+}
+
+func (cw *cpioWriter) writeHeader(name string, inode, mode int, mtime int32, size int64) error {
+	nlink := 1
+
+	entry := fmt.Sprintf("070701%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%s\x00",
+		inode, mode, 0, 0, nlink, mtime, size, 0, 0, 0, 0, len(name)+1, 0, name)
+
+	if _, err := io.WriteString(cw, entry); err != nil {
+		return err
+	}
+
+	return cw.pad()
+}
+
+func (cw *cpioWriter) Write(data []byte) (int, error) {
+	n, err := cw.w.Write(data)
+	cw.size += int64(n)
+
+	return n, err
+}
+
+// pad aligns the archive to 4 bytes.
+func (cw *cpioWriter) pad() error {
+	_, err := cw.Write(make([]byte, (4-cw.size%4)%4))
+
+	return err
+}
@@ -4,7 +4,7 @@ package testhelpers
 import "github.com/M0Rf30/yap/v2/pkg/pkgbuild"
 
 // BasePKGBUILD returns a minimal *pkgbuild.PKGBUILD suitable for unit tests.
-// It covers the fields common to all four builder formats (APK, DEB, RPM, Pacman).
+// It covers the fields common to all the builder formats (APK, DEB, RPM, Pacman, XBPS).
 // Use the returned pointer directly or apply format-specific overrides before use.
 func BasePKGBUILD() *pkgbuild.PKGBUILD {
 	return &pkgbuild.PKGBUILD{
@@ -0,0 +1,91 @@
+// Package xbps provides Void Linux package building functionality and constants.
+package xbps
+
+// Metadata entries of an xbps package, stored ahead of the payload in this
+// order: xbps reads files.plist before unpacking any file.
+const (
+	installFileName = "INSTALL"
+	removeFileName  = "REMOVE"
+	propsFileName   = "props.plist"
+	filesFileName   = "files.plist"
+)
+
+// buildDateLayout is the layout of the build-date property.
+const buildDateLayout = "2006-01-02 15:04 MST"
+
+// installScript is the template for the INSTALL script, run by xbps as
+// "INSTALL pre|post <pkgname> <version> <update> [<conf_file> <arch>]".
+// Upgrades run the upgrade hooks, falling back to the install ones as in
+// the pacman .install template.
+const installScript = `#
+# Generated by yap {{.YAPVersion}}
+ACTION="$1"
+PKGNAME="$2"
+VERSION="$3"
+UPDATE="$4"
+
+case "${ACTION}" in
+pre)
+	if [ "${UPDATE}" = "yes" ]; then
+		:
+{{- if .PreUpgrade}}
+{{.PreUpgrade}}
+{{- else if .PreInst}}
+{{.PreInst}}
+{{- end }}
+	else
+		:
+{{- if .PreInst}}
+{{.PreInst}}
+{{- end }}
+	fi
+	;;
+post)
+	if [ "${UPDATE}" = "yes" ]; then
+		:
+{{- if .PostUpgrade}}
+{{.PostUpgrade}}
+{{- else if .PostInst}}
+{{.PostInst}}
+{{- end }}
+	else
+		:
+{{- if .PostInst}}
+{{.PostInst}}
+{{- end }}
+	fi
+	;;
+esac
+
+exit 0
+`
+
+// removeScript is the template for the REMOVE script, run by xbps as
+// "REMOVE pre|post|purge <pkgname> <version> <update>". The remove hooks
+// are skipped when the package is being replaced by an upgrade.
+const removeScript = `#
+# Generated by yap {{.YAPVersion}}
+ACTION="$1"
+PKGNAME="$2"
+VERSION="$3"
+UPDATE="$4"
+
+[ "${UPDATE}" = "yes" ] && exit 0
+
+case "${ACTION}" in
+pre)
+	:
+{{- if .PreRm}}
+{{.PreRm}}
+{{- end }}
+	;;
+post)
+	:
+{{- if .PostRm}}
+{{.PostRm}}
+{{- end }}
+	;;
+esac
+
+exit 0
+`
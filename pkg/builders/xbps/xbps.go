@@ -0,0 +1,483 @@
+package xbps
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/M0Rf30/yap/v2/pkg/builders/common"
+	"github.com/M0Rf30/yap/v2/pkg/constants"
+	"github.com/M0Rf30/yap/v2/pkg/crypto"
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/files"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/logger"
+	"github.com/M0Rf30/yap/v2/pkg/pkgbuild"
+	"github.com/M0Rf30/yap/v2/pkg/plist"
+)
+
+// Xbps represents the Void Linux package builder.
+// It embeds the common.BaseBuilder to inherit shared functionality.
+type Xbps struct {
+	*common.BaseBuilder
+
+	install string
+	remove  string
+	shlibs  []string
+}
+
+// NewBuilder creates a new xbps package builder.
+func NewBuilder(pkgBuild *pkgbuild.PKGBUILD) *Xbps {
+	return &Xbps{
+		BaseBuilder: common.NewBaseBuilder(pkgBuild, constants.FormatXBPS),
+	}
+}
+
+// BuildPackage writes the xbps package: a zstd-compressed tar archive
+// holding the INSTALL and REMOVE scripts, props.plist and files.plist,
+// followed by the package files.
+// Returns the path to the created package file.
+func (x *Xbps) BuildPackage(ctx context.Context, artifactsPath string, targetArch string) (string, error) {
+	x.SetTargetArchitecture(targetArch)
+
+	entries, err := x.CreateFileWalker().Walk()
+	if err != nil {
+		return "", err
+	}
+
+	props, err := plist.Marshal(x.props())
+	if err != nil {
+		return "", err
+	}
+
+	filesDict, err := filesPlist(entries)
+	if err != nil {
+		return "", err
+	}
+
+	filesData, err := plist.Marshal(filesDict)
+	if err != nil {
+		return "", err
+	}
+
+	pkgFilePath := filepath.Join(artifactsPath, x.BuildPackageName(constants.ExtXBPS))
+
+	metadata := []struct {
+		name string
+		mode int64
+		data []byte
+	}{
+		{installFileName, 0o755, []byte(x.install)},
+		{removeFileName, 0o755, []byte(x.remove)},
+		{propsFileName, 0o644, props},
+		{filesFileName, 0o644, filesData},
+	}
+
+	err = x.writeArchive(ctx, pkgFilePath, func(tw *tar.Writer) error {
+		modTime := time.Unix(x.PKGBUILD.BuildDate, 0)
+
+		for _, file := range metadata {
+			if len(file.data) == 0 {
+				continue
+			}
+
+			if err := writeTarFile(tw, file.name, file.mode, modTime, file.data); err != nil {
+				return err
+			}
+		}
+
+		for _, entry := range entries {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			if err := writeTarEntry(tw, entry); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	x.LogPackageCreated(pkgFilePath)
+
+	return pkgFilePath, nil
+}
+
+// PrepareFakeroot sets up the xbps package metadata: the installed size,
+// build date and shared libraries recorded in props.plist, and the INSTALL
+// and REMOVE scripts.
+func (x *Xbps) PrepareFakeroot(ctx context.Context, artifactsPath string, targetArch string) error {
+	if err := x.RemoveGhostFiles(); err != nil {
+		return err
+	}
+
+	if err := x.ShipAlternatives(); err != nil {
+		return err
+	}
+
+	if err := x.ApplyOptionsWithEnv(x.CrossStripEnvMap(targetArch)); err != nil {
+		return err
+	}
+
+	installedSize, err := files.GetDirSize(x.PKGBUILD.PackageDir)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeFileSystem, "failed to get package dir size").
+			WithOperation("PrepareFakeroot")
+	}
+
+	sourceDateEpoch, err := files.ResolveSourceDateEpoch(x.PKGBUILD.Home)
+	if err != nil {
+		return err
+	}
+
+	x.PKGBUILD.InstalledSize = installedSize
+	x.PKGBUILD.BuildDate = sourceDateEpoch.Unix()
+	x.PKGBUILD.PkgDest, _ = filepath.Abs(artifactsPath)
+	x.PKGBUILD.YAPVersion = constants.YAPVersion
+
+	provided, err := x.GenerateProvides()
+	if err != nil {
+		return err
+	}
+
+	x.shlibs = nil
+	for _, lib := range provided.Libraries {
+		x.shlibs = append(x.shlibs, lib.Soname)
+	}
+
+	// xbps has no trigger mechanism a package can hook into.
+	for _, trigger := range x.Triggers() {
+		logger.Warn(i18n.T("logger.xbps.warn.trigger_unsupported"),
+			"package", x.PKGBUILD.PkgName, "trigger", trigger.Target)
+	}
+
+	x.install, x.remove = "", ""
+
+	if x.PKGBUILD.PreInst != "" || x.PKGBUILD.PostInst != "" ||
+		x.PKGBUILD.PreUpgrade != "" || x.PKGBUILD.PostUpgrade != "" {
+		if x.install, err = x.renderScript(installScript); err != nil {
+			return err
+		}
+	}
+
+	if x.PKGBUILD.PreRm != "" || x.PKGBUILD.PostRm != "" {
+		if x.remove, err = x.renderScript(removeScript); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// renderScript renders an INSTALL or REMOVE script template, with the
+// helper functions the scriptlets call.
+func (x *Xbps) renderScript(script string) (string, error) {
+	var buf bytes.Buffer
+
+	if err := x.PKGBUILD.RenderSpec(script).Execute(&buf, x.PKGBUILD); err != nil {
+		return "", err
+	}
+
+	return "#!/bin/sh\n" + x.PrepareScriptletWithHelpers(buf.String()), nil
+}
+
+// version returns the xbps version of the package, "<pkgver>_<pkgrel>".
+// xbps has no epochs, so the PKGBUILD epoch is dropped.
+func (x *Xbps) version() string {
+	return x.PKGBUILD.PkgVer + "_" + x.PKGBUILD.PkgRel
+}
+
+// props returns the props.plist dictionary of the package.
+func (x *Xbps) props() plist.Dict {
+	pkgBuild := x.PKGBUILD
+
+	props := plist.Dict{
+		"architecture":   pkgBuild.ArchComputed,
+		"build-date":     time.Unix(pkgBuild.BuildDate, 0).UTC().Format(buildDateLayout),
+		"installed_size": pkgBuild.InstalledSize,
+		"packaged-with":  "yap-" + strings.TrimPrefix(pkgBuild.YAPVersion, "v"),
+		"pkgname":        pkgBuild.PkgName,
+		"pkgver":         pkgBuild.PkgName + "-" + x.version(),
+		"short_desc":     pkgBuild.PkgDesc,
+		"version":        x.version(),
+	}
+
+	optional := map[string]string{
+		"homepage":   pkgBuild.HomepageURL(),
+		"license":    strings.Join(pkgBuild.License, ", "),
+		"long_desc":  pkgBuild.PkgDescLong,
+		"maintainer": pkgBuild.Maintainer,
+		"tags":       strings.Join(pkgBuild.Keywords, " "),
+	}
+
+	for key, value := range optional {
+		if value != "" {
+			props[key] = value
+		}
+	}
+
+	lists := map[string][]string{
+		"conf_files":     x.PrepareBackupFilePaths(),
+		"conflicts":      patterns(pkgBuild.Conflicts),
+		"provides":       x.provides(),
+		"replaces":       patterns(pkgBuild.Replaces),
+		"run_depends":    patterns(pkgBuild.Depends),
+		"shlib-provides": x.shlibs,
+	}
+
+	for key, value := range lists {
+		if len(value) > 0 {
+			props[key] = value
+		}
+	}
+
+	return props
+}
+
+// provides returns the provides of the package as the "<name>-<version>"
+// package versions xbps expects. Unversioned entries get the version of
+// the package itself.
+func (x *Xbps) provides() []string {
+	provides := make([]string, 0, len(x.PKGBUILD.Provides))
+
+	for _, provide := range x.PKGBUILD.Provides {
+		name, version, ok := strings.Cut(provide, "=")
+		if !ok {
+			provides = append(provides, name+"-"+x.version())
+
+			continue
+		}
+
+		provides = append(provides, name+"-"+xbpsVersion(version, "1"))
+	}
+
+	return provides
+}
+
+// patterns converts PKGBUILD dependencies to xbps package patterns:
+// unversioned names match any version ("foo>=0"), exact versions become
+// package versions ("foo-1.0_1", or "foo-1.0_*" for any revision) and
+// the other comparisons keep their operator.
+func patterns(deps []string) []string {
+	result := make([]string, 0, len(deps))
+
+	for _, dep := range deps {
+		i := strings.IndexAny(dep, "<>=")
+		if i <= 0 {
+			result = append(result, dep+">=0")
+
+			continue
+		}
+
+		name, rest := dep[:i], dep[i:]
+		version := strings.TrimLeft(rest, "<>=")
+		operator := rest[:len(rest)-len(version)]
+
+		if operator == "=" {
+			result = append(result, name+"-"+xbpsVersion(version, "*"))
+
+			continue
+		}
+
+		result = append(result, name+operator+xbpsVersion(version, ""))
+	}
+
+	return result
+}
+
+// xbpsVersion converts a PKGBUILD version, "[epoch:]pkgver[-pkgrel]", to
+// an xbps one, "pkgver_revision". revision is used when there is no pkgrel;
+// an empty one leaves the version without a revision.
+func xbpsVersion(version, revision string) string {
+	if _, rest, ok := strings.Cut(version, ":"); ok {
+		version = rest
+	}
+
+	if i := strings.LastIndex(version, "-"); i > 0 {
+		return version[:i] + "_" + version[i+1:]
+	}
+
+	if revision == "" {
+		return version
+	}
+
+	return version + "_" + revision
+}
+
+// filesPlist returns the files.plist dictionary listing the package
+// contents: regular files with their SHA-256, configuration files,
+// symbolic links and directories.
+func filesPlist(entries []*files.Entry) (plist.Dict, error) {
+	var regular, confFiles, links, dirs []plist.Dict
+
+	for _, entry := range entries {
+		switch {
+		case entry.IsDirectory():
+			dirs = append(dirs, plist.Dict{"file": entry.Destination})
+		case entry.IsSymlink():
+			links = append(links, plist.Dict{"file": entry.Destination, "target": entry.LinkTarget})
+		case entry.IsRegularFile():
+			sha256 := entry.SHA256
+			if sha256 == nil {
+				var err error
+
+				// The walker skips the checksum of configuration files.
+				if sha256, err = crypto.CalculateSHA256(entry.Source); err != nil {
+					return nil, errors.Wrap(err, errors.ErrTypePackaging,
+						fmt.Sprintf("file %s: computing checksum", entry.Destination)).
+						WithOperation("filesPlist").
+						WithContext("file", entry.Destination)
+				}
+			}
+
+			file := plist.Dict{
+				"file":   entry.Destination,
+				"sha256": hex.EncodeToString(sha256),
+				"size":   entry.Size,
+				"mtime":  entry.ModTime.Unix(),
+			}
+
+			if entry.IsConfigFile() {
+				confFiles = append(confFiles, file)
+			} else {
+				regular = append(regular, file)
+			}
+		}
+	}
+
+	dict := plist.Dict{}
+
+	for key, list := range map[string][]plist.Dict{
+		"files": regular, "conf_files": confFiles, "links": links, "dirs": dirs,
+	} {
+		if len(list) > 0 {
+			dict[key] = list
+		}
+	}
+
+	return dict, nil
+}
+
+// writeArchive creates the zstd-compressed tar archive at outputFile and
+// fills it with write.
+func (x *Xbps) writeArchive(ctx context.Context, outputFile string, write func(*tar.Writer) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	cleanFilePath := filepath.Clean(outputFile)
+
+	out, err := os.Create(cleanFilePath)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if closeErr := out.Close(); closeErr != nil {
+			logger.Warn(i18n.T("logger.xbps.warn.failed_to_close_output"),
+				"path", cleanFilePath,
+				"error", closeErr)
+		}
+	}()
+
+	zw, err := zstd.NewWriter(out)
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(zw)
+
+	if err := write(tw); err != nil {
+		_ = tw.Close()
+		_ = zw.Close()
+
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		_ = zw.Close()
+
+		return err
+	}
+
+	return zw.Close()
+}
+
+// writeTarFile writes a metadata file generated in memory.
+func writeTarFile(tw *tar.Writer, name string, mode int64, modTime time.Time, data []byte) error {
+	hdr := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     "./" + name,
+		Mode:     mode,
+		Size:     int64(len(data)),
+		ModTime:  modTime,
+		Uname:    "root",
+		Gname:    "root",
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+
+	_, err := tw.Write(data)
+
+	return err
+}
+
+// writeTarEntry writes a package file with its ownership and extended
+// attributes, named "./<path>" as xbps-create does.
+func writeTarEntry(tw *tar.Writer, entry *files.Entry) error {
+	info, err := os.Lstat(entry.Source)
+	if err != nil {
+		return err
+	}
+
+	hdr, err := tar.FileInfoHeader(info, entry.LinkTarget)
+	if err != nil {
+		return err
+	}
+
+	hdr.Name = "." + entry.Destination
+	if entry.IsDirectory() {
+		hdr.Name += "/"
+	}
+
+	entry.Attributes.ApplyToHeader(hdr)
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return errors.Wrap(err, errors.ErrTypePackaging,
+			fmt.Sprintf("file %s: writing header", entry.Destination)).
+			WithOperation("writeTarEntry").
+			WithContext("file", entry.Destination)
+	}
+
+	if !entry.IsRegularFile() {
+		return nil
+	}
+
+	file, err := os.Open(filepath.Clean(entry.Source))
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	_, err = io.Copy(tw, file)
+
+	return err
+}
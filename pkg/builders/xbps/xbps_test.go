@@ -0,0 +1,192 @@
+package xbps
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/M0Rf30/yap/v2/pkg/builders/testhelpers"
+	"github.com/M0Rf30/yap/v2/pkg/plist"
+)
+
+func TestBuildPackage(t *testing.T) {
+	tempDir := t.TempDir()
+	pkgDir := filepath.Join(tempDir, "pkg")
+
+	for _, dir := range []string{"usr/bin", "etc"} {
+		if err := os.MkdirAll(filepath.Join(pkgDir, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(pkgDir, "usr/bin/tool"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(pkgDir, "etc/tool.conf"), []byte("key=value\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.Symlink("tool", filepath.Join(pkgDir, "usr/bin/link")); err != nil {
+		t.Fatal(err)
+	}
+
+	pkgBuild := testhelpers.BasePKGBUILD()
+	pkgBuild.Home = tempDir
+	pkgBuild.PackageDir = pkgDir
+	pkgBuild.Depends = []string{"glibc", "foo>=1.2-3"}
+	pkgBuild.Backup = []string{"etc/tool.conf"}
+	pkgBuild.PostInst = "echo installed"
+
+	builder := NewBuilder(pkgBuild)
+
+	if err := builder.PrepareFakeroot(context.Background(), tempDir, ""); err != nil {
+		t.Fatalf("PrepareFakeroot() error = %v", err)
+	}
+
+	pkgPath, err := builder.BuildPackage(context.Background(), tempDir, "")
+	if err != nil {
+		t.Fatalf("BuildPackage() error = %v", err)
+	}
+
+	if filepath.Base(pkgPath) != "test-package-1.0.0_1.x86_64.xbps" {
+		t.Errorf("package name = %s", filepath.Base(pkgPath))
+	}
+
+	names, contents := readPackage(t, pkgPath)
+
+	wantNames := []string{
+		"./INSTALL", "./props.plist", "./files.plist",
+		"./etc/", "./etc/tool.conf", "./usr/", "./usr/bin/", "./usr/bin/link", "./usr/bin/tool",
+	}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("entries = %v, want %v", names, wantNames)
+	}
+
+	if install := contents["./INSTALL"]; !strings.HasPrefix(install, "#!/bin/sh\n") ||
+		!strings.Contains(install, "echo installed") {
+		t.Errorf("INSTALL = %q", install)
+	}
+
+	props, err := plist.Unmarshal([]byte(contents["./props.plist"]))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]any{
+		"pkgname":      "test-package",
+		"pkgver":       "test-package-1.0.0_1",
+		"architecture": "x86_64",
+		"run_depends":  []any{"glibc>=0", "foo>=1.2_3"},
+		"conf_files":   []any{"/etc/tool.conf"},
+	} {
+		if !reflect.DeepEqual(props[key], want) {
+			t.Errorf("props[%s] = %v, want %v", key, props[key], want)
+		}
+	}
+
+	filesDict, err := plist.Unmarshal([]byte(contents["./files.plist"]))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	confFiles, _ := filesDict["conf_files"].([]any)
+	regular, _ := filesDict["files"].([]any)
+	links, _ := filesDict["links"].([]any)
+
+	if len(confFiles) != 1 || len(regular) != 1 || len(links) != 1 {
+		t.Fatalf("files.plist = %v", filesDict)
+	}
+
+	digest := sha256.Sum256([]byte("#!/bin/sh\n"))
+	if file := regular[0].(plist.Dict); file["file"] != "/usr/bin/tool" ||
+		file["sha256"] != hex.EncodeToString(digest[:]) || file["size"] != int64(10) {
+		t.Errorf("files = %v", file)
+	}
+
+	if link := links[0].(plist.Dict); link["target"] != "tool" {
+		t.Errorf("links = %v", link)
+	}
+}
+
+func TestPatterns(t *testing.T) {
+	got := patterns([]string{"foo", "bar>=1.0", "baz<2:3.0-1", "qux=1.0-2", "quux=1.0"})
+	want := []string{"foo>=0", "bar>=1.0", "baz<3.0_1", "qux-1.0_2", "quux-1.0_*"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("patterns() = %v, want %v", got, want)
+	}
+}
+
+func TestProvides(t *testing.T) {
+	pkgBuild := testhelpers.BasePKGBUILD()
+	pkgBuild.Provides = []string{"foo", "bar=2.0", "baz=1.0-3"}
+
+	got := NewBuilder(pkgBuild).provides()
+	want := []string{"foo-1.0.0_1", "bar-2.0_1", "baz-1.0_3"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("provides() = %v, want %v", got, want)
+	}
+}
+
+// readPackage returns the entry names of an xbps package, in order, and the
+// contents of its regular files.
+func readPackage(t *testing.T, path string) ([]string, map[string]string) {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	zr, err := zstd.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer zr.Close()
+
+	var names []string
+
+	contents := map[string]string{}
+	tr := tar.NewReader(zr)
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		names = append(names, hdr.Name)
+
+		if hdr.Typeflag == tar.TypeReg {
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			contents[hdr.Name] = string(data)
+		}
+	}
+
+	return names, contents
+}
@@ -23,6 +23,7 @@ package constants
 //   - DEB: amd64, i386, arm64, armhf, armel
 //   - RPM: x86_64, i686, aarch64, armv7hl
 //   - Pacman: x86_64, i686, aarch64, armv7h, armv6h
+//   - XBPS: x86_64, i686, aarch64, armv7l, armv6l
 const (
 	// ArchX86_64 represents 64-bit x86 architecture (canonical name).
 	ArchX86_64 = "x86_64"
@@ -73,7 +74,7 @@ const (
 	ArchAny = "any"
 	// ArchAll is the Debian/APK alias for "any".
 	ArchAll = "all"
-	// ArchNoarch is the RPM alias for "any".
+	// ArchNoarch is the RPM and XBPS alias for "any".
 	ArchNoarch = "noarch"
 	// ArchX8664Dash is the dashed alias for x86_64 (used by some tools).
 	ArchX8664Dash = "x86-64"
@@ -127,6 +128,7 @@ type ArchitectureMapping struct {
 	DEB    map[string]string
 	RPM    map[string]string
 	Pacman map[string]string
+	XBPS   map[string]string
 }
 
 // GetArchMapping returns the unified architecture mappings for all package formats.
@@ -170,6 +172,18 @@ func GetArchMapping() *ArchitectureMapping {
 			ArchArmv7h:  ArchArmv7h,
 			ArchAny:     ArchAny,
 		},
+		XBPS: map[string]string{
+			ArchX86_64:  ArchX86_64,
+			ArchI686:    ArchI686,
+			ArchAarch64: ArchAarch64,
+			ArchArmv7:   ArchArmv7l,
+			ArchArmv7h:  ArchArmv7l,
+			ArchArmv6:   ArchArmv6l,
+			ArchArmv6h:  ArchArmv6l,
+			ArchArm:     ArchArmv6l,
+			ArchPpc64le: ArchPpc64le,
+			ArchAny:     ArchNoarch,
+		},
 	}
 }
 
@@ -186,6 +200,8 @@ func (am *ArchitectureMapping) TranslateArch(format, arch string) string {
 		mapping = am.RPM
 	case FormatPacman:
 		mapping = am.Pacman
+	case FormatXBPS:
+		mapping = am.XBPS
 	default:
 		return arch // Return original if format unknown
 	}
@@ -288,6 +304,8 @@ func GetReverseMapping(format string) map[string]string {
 		forwardMap = archMapping.RPM
 	case FormatPacman:
 		forwardMap = archMapping.Pacman
+	case FormatXBPS:
+		forwardMap = archMapping.XBPS
 	default:
 		return make(map[string]string)
 	}
@@ -54,6 +54,9 @@ func TestArchitectureMapping_TranslateArch(t *testing.T) {
 		{"Pacman x86_64", "pacman", "x86_64", "x86_64"},
 		{"Pacman any", "pacman", "any", "any"},
 
+		{"XBPS armv7", "xbps", "armv7", "armv7l"},
+		{"XBPS any", "xbps", "any", "noarch"},
+
 		{"Unknown format", "unknown", "x86_64", "x86_64"},
 		{"Unknown arch", "deb", "unknown_arch", "unknown_arch"},
 	}
@@ -561,3 +564,28 @@ func TestNormalizeAndTranslateRoundTrip(t *testing.T) {
 		})
 	}
 }
+
+// TestGetReverseMappingXBPS tests reverse mapping for XBPS format.
+func TestGetReverseMappingXBPS(t *testing.T) {
+	t.Parallel()
+
+	reverseMap := GetReverseMapping("xbps")
+
+	tests := []struct {
+		formatSpecific string
+		canonical      string
+	}{
+		{"x86_64", "x86_64"},
+		{"armv7l", "armv7"},
+		{"armv6l", "armv6"},
+		{"noarch", "any"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.formatSpecific, func(t *testing.T) {
+			if result := reverseMap[tt.formatSpecific]; result != tt.canonical {
+				t.Errorf("GetReverseMapping('xbps')[%q] = %q, want %q", tt.formatSpecific, result, tt.canonical)
+			}
+		})
+	}
+}
@@ -48,6 +48,7 @@ var (
 		DistroRhel,
 		DistroRocky,
 		DistroUbuntu,
+		DistroVoid,
 	}
 
 	// DistroToPackageManager maps distribution names to their package managers.
@@ -67,6 +68,7 @@ var (
 		DistroRhel:               PMYum,
 		DistroRocky:              PMYum,
 		DistroUbuntu:             PMApt,
+		DistroVoid:               PMXbps,
 	}
 
 	// Packers defines the supported package managers for different distributions.
@@ -74,6 +76,7 @@ var (
 		PMApk,
 		PMApt,
 		PMPacman,
+		PMXbps,
 		PMYum,
 		PMZypper,
 	}
@@ -39,7 +39,7 @@ func TestConstants(t *testing.T) {
 func TestReleases(t *testing.T) {
 	expectedReleases := []string{
 		"almalinux", "alpine", "amzn", "arch", "centos",
-		"debian", "fedora", "linuxmint", "opensuse-leap", "opensuse-tumbleweed", "ol", "pop", "rhel", "rocky", "ubuntu",
+		"debian", "fedora", "linuxmint", "opensuse-leap", "opensuse-tumbleweed", "ol", "pop", "rhel", "rocky", "ubuntu", "void",
 	}
 
 	if len(Releases) == 0 {
@@ -82,6 +82,7 @@ func TestDistroToPackageManager(t *testing.T) {
 		"rhel":                "yum",
 		"rocky":               "yum",
 		"ubuntu":              "apt",
+		"void":                "xbps",
 	}
 
 	if len(DistroToPackageManager) == 0 {
@@ -98,7 +99,7 @@ func TestDistroToPackageManager(t *testing.T) {
 
 	// Test that all package managers are valid
 	validPackagers := map[string]bool{
-		"apk": true, "apt": true, "pacman": true, "yum": true, "zypper": true,
+		"apk": true, "apt": true, "pacman": true, "xbps": true, "yum": true, "zypper": true,
 	}
 
 	for distro, pkgManager := range DistroToPackageManager {
@@ -109,7 +110,7 @@ func TestDistroToPackageManager(t *testing.T) {
 }
 
 func TestPackers(t *testing.T) {
-	expectedPackers := []string{"apk", "apt", "pacman", "yum", "zypper"}
+	expectedPackers := []string{"apk", "apt", "pacman", "xbps", "yum", "zypper"}
 
 	if len(Packers) == 0 {
 		t.Fatal("Packers array is empty")
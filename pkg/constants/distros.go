@@ -37,6 +37,8 @@ const (
 	DistroRocky = "rocky"
 	// DistroUbuntu is the os-release ID for Ubuntu.
 	DistroUbuntu = "ubuntu"
+	// DistroVoid is the os-release ID for Void Linux.
+	DistroVoid = "void"
 )
 
 // Package manager command name constants.
@@ -50,6 +52,8 @@ const (
 	PMApt = "apt"
 	// PMPacman is the Arch Linux package manager.
 	PMPacman = "pacman"
+	// PMXbps is the Void Linux package manager.
+	PMXbps = "xbps"
 	// PMDnf is the modern RPM-based package manager (Fedora, Rocky, RHEL).
 	PMDnf = "dnf"
 	// PMYum is the legacy RPM-based package manager.
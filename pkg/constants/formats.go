@@ -12,6 +12,7 @@ const (
 	FormatDEB    = "deb"
 	FormatRPM    = "rpm"
 	FormatPacman = "pacman"
+	FormatXBPS   = "xbps"
 )
 
 const (
@@ -48,6 +49,8 @@ const (
 	ExtRPM = ".rpm"
 	// ExtPacmanZst is the Arch Linux zstd-compressed package file extension.
 	ExtPacmanZst = ".pkg.tar.zst"
+	// ExtXBPS is the Void Linux package file extension.
+	ExtXBPS = ".xbps"
 )
 
 // Package compression algorithm constants accepted by the DEB and RPM builders.
@@ -73,12 +76,47 @@ func IsSupportedCompression(algo string) bool {
 	return slices.Contains(SupportedCompressions, algo)
 }
 
+// APK package format versions accepted by the APK builder: the v2
+// concatenated gzip archives and the apk-tools 3 ADB packages.
+const (
+	APKFormatV2 = "v2"
+	APKFormatV3 = "v3"
+)
+
+// SupportedAPKFormats is the canonical set of APK package format versions.
+var SupportedAPKFormats = []string{APKFormatV2, APKFormatV3}
+
+// IsSupportedAPKFormat reports whether format is a supported APK package
+// format version. The empty string selects the v2 default.
+func IsSupportedAPKFormat(format string) bool {
+	if format == "" {
+		return true
+	}
+
+	return slices.Contains(SupportedAPKFormats, format)
+}
+
+// SupportedAPKCompressions is the set of compression algorithms of APK v3
+// packages: gzip selects the deflate compression of apk mkpkg.
+var SupportedAPKCompressions = []string{CompressionGzip, CompressionZstd}
+
+// IsSupportedAPKCompression reports whether algo is a supported APK v3
+// compression algorithm. The empty string selects gzip.
+func IsSupportedAPKCompression(algo string) bool {
+	if algo == "" {
+		return true
+	}
+
+	return slices.Contains(SupportedAPKCompressions, algo)
+}
+
 // BuildEnvironmentDeps provides build environment dependencies for each package manager.
 type BuildEnvironmentDeps struct {
 	APK    []string
 	DEB    []string
 	RPM    []string
 	Pacman []string
+	XBPS   []string
 }
 
 // GetBuildDeps returns the build environment dependencies for all package formats.
@@ -178,6 +216,20 @@ func GetBuildDeps() *BuildEnvironmentDeps {
 			pkgWhich,
 			pkgXz,
 		},
+		// base-devel pulls gcc/make/patch/autotools/libtool/m4/pkg-config;
+		// Void defaults to bsdtar, so GNU tar is named explicitly.
+		XBPS: []string{
+			"base-devel",
+			pkgBzip2,
+			pkgCcache,
+			pkgDiffutils,
+			pkgFindutils,
+			pkgGzip,
+			pkgPerl,
+			pkgTar,
+			pkgWhich,
+			pkgXz,
+		},
 	}
 }
 
@@ -199,6 +251,7 @@ var distroFormatMap = map[string]string{
 	DistroRhel:               FormatRPM,
 	DistroRocky:              FormatRPM,
 	DistroUbuntu:             FormatDEB,
+	DistroVoid:               FormatXBPS,
 	// Legacy aliases kept for backward compatibility
 	"alma":     FormatRPM,
 	"opensuse": FormatRPM,
@@ -224,6 +277,8 @@ var SudoAllowedCommands = map[string]bool{
 	FormatRPM: true,
 	"makepkg": true,
 	PMZypper:  true,
+	// xbps-install is the Void Linux installer; "xbps" names no binary.
+	"xbps-install": true,
 }
 
 // GetInstallArgs returns the package manager install arguments.
@@ -249,6 +304,8 @@ func GetInstallArgs(format string) []string {
 		return []string{"-y", installArg}
 	case FormatPacman:
 		return []string{"-S", "--noconfirm", "--needed"}
+	case FormatXBPS:
+		return []string{"-S", "--yes"}
 	default:
 		return []string{}
 	}
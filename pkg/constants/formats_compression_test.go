@@ -36,3 +36,19 @@ func TestSupportedCompressionsContents(t *testing.T) {
 		}
 	}
 }
+
+func TestIsSupportedAPKOptions(t *testing.T) {
+	formats := map[string]bool{"": true, "v2": true, "v3": true, "v4": false, "V3": false}
+	for in, want := range formats {
+		if got := IsSupportedAPKFormat(in); got != want {
+			t.Errorf("IsSupportedAPKFormat(%q) = %v, want %v", in, got, want)
+		}
+	}
+
+	compressions := map[string]bool{"": true, "gzip": true, "zstd": true, "xz": false, "none": false}
+	for in, want := range compressions {
+		if got := IsSupportedAPKCompression(in); got != want {
+			t.Errorf("IsSupportedAPKCompression(%q) = %v, want %v", in, got, want)
+		}
+	}
+}
@@ -15,6 +15,7 @@ func TestFormatConstants(t *testing.T) {
 		{"DEB format", FormatDEB, "deb"},
 		{"RPM format", FormatRPM, "rpm"},
 		{"Pacman format", FormatPacman, "pacman"},
+		{"XBPS format", FormatXBPS, "xbps"},
 	}
 
 	for _, tt := range tests {
@@ -76,6 +77,7 @@ func TestGetInstallArgs(t *testing.T) {
 		{"DEB install args", FormatDEB, []string{"--allow-downgrades", "--allow-unauthenticated", "--assume-yes", "--no-install-recommends", "install"}},
 		{"RPM install args", FormatRPM, []string{"-y", "install"}},
 		{"Pacman install args", FormatPacman, []string{"-S", "--noconfirm", "--needed"}},
+		{"XBPS install args", FormatXBPS, []string{"-S", "--yes"}},
 		{"Unknown format", "unknown", []string{}},
 	}
 
@@ -96,7 +98,7 @@ func TestBuildDepsConsistency(t *testing.T) {
 		t.Error("RPM should have more build deps than DEB (it typically requires more packages)")
 	}
 
-	for _, depList := range [][]string{deps.APK, deps.DEB, deps.RPM, deps.Pacman} {
+	for _, depList := range [][]string{deps.APK, deps.DEB, deps.RPM, deps.Pacman, deps.XBPS} {
 		for _, dep := range depList {
 			if dep == "" {
 				t.Error("Build dependency list contains empty string")
@@ -134,6 +136,8 @@ func TestDistroFormat(t *testing.T) {
 		{"suse", FormatRPM},
 		// Pacman
 		{"arch", FormatPacman},
+		// XBPS
+		{"void", FormatXBPS},
 		// Unknown
 		{"unknown", ""},
 		{"", ""},
@@ -3,10 +3,12 @@ package container
 import (
 	"context"
 	"io"
+	"os"
 	"sort"
 
 	"github.com/M0Rf30/yap/v2/pkg/constants"
 	"github.com/M0Rf30/yap/v2/pkg/shell"
+	"github.com/M0Rf30/yap/v2/pkg/srccache"
 )
 
 // Shared CLI flag/path constants used across cliRuntime invocations.
@@ -41,10 +43,13 @@ func (r *cliRuntime) RunShell(distro, workDir, shellCmd string) error {
 		"-e", envInContainer,
 		"-v", workDir + ":" + containerWorkdir + ":z",
 		"-w", containerWorkdir,
+	}
+	runArgs = append(runArgs, sourceCacheFlags()...)
+	runArgs = append(runArgs,
 		"--user", "root",
-		constants.DockerOrg + distro,
+		constants.DockerOrg+distro,
 		"-c", shellCmd,
-	}
+	)
 
 	return shell.Exec(context.Background(), false, "", r.bin, runArgs...)
 }
@@ -69,6 +74,9 @@ func (r *cliRuntime) RunShellCapture(ctx context.Context, distro, workDir, shell
 	runArgs = append(runArgs,
 		"-v", workDir+":"+containerWorkdir+":z",
 		"-w", containerWorkdir,
+	)
+	runArgs = append(runArgs, sourceCacheFlags()...)
+	runArgs = append(runArgs,
 		"--user", "root",
 		constants.DockerOrg+distro,
 		"-c", shellCmd,
@@ -77,6 +85,26 @@ func (r *cliRuntime) RunShellCapture(ctx context.Context, distro, workDir, shell
 	return shell.ExecCapture(ctx, out, "", r.bin, runArgs...)
 }
 
+// sourceCacheFlags mounts the host's shared source cache at
+// srccache.ContainerDir and points the inner yap at it, so that container
+// builds reuse and populate the same cache as native ones. It returns nil
+// when the cache is disabled or cannot be created.
+func sourceCacheFlags() []string {
+	dir := srccache.Dir()
+	if dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil
+	}
+
+	return []string{
+		"-v", dir + ":" + srccache.ContainerDir + ":z",
+		"-e", srccache.DirEnv + "=" + srccache.ContainerDir,
+	}
+}
+
 // envFlags renders an env map into a sorted slice of `-e KEY=VALUE` argv
 // fragments. Sorting makes the output stable for tests; values are passed
 // verbatim — callers MUST NOT trust them to escape shell metacharacters
@@ -117,6 +145,7 @@ func (r *cliRuntime) Run(distro, workDir string, args []string) error {
 		"-v", workDir + ":" + containerWorkdir + ":z",
 		"-w", containerWorkdir,
 	}
+	runArgs = append(runArgs, sourceCacheFlags()...)
 
 	// Run as root so the container process can write to the bind-mounted
 	// workspace and run privileged package manager operations (apt-get, dpkg).
@@ -16,6 +16,7 @@ import (
 	"github.com/M0Rf30/yap/v2/pkg/errors"
 	"github.com/M0Rf30/yap/v2/pkg/i18n"
 	"github.com/M0Rf30/yap/v2/pkg/logger"
+	"github.com/M0Rf30/yap/v2/pkg/srccache"
 )
 
 const (
@@ -193,6 +194,9 @@ func execInRootfs(rootfs, workDir string, args []string) error {
 		}
 	}
 
+	// Share the host source cache with the inner yap (best-effort).
+	mountSourceCache(rootfs)
+
 	// Provide working DNS inside the rootfs (best-effort).
 	setupResolvConf(rootfs)
 
@@ -225,6 +229,34 @@ func execInRootfs(rootfs, workDir string, args []string) error {
 	return syscall.Exec(bin, args, os.Environ()) //nolint:gosec
 }
 
+// mountSourceCache bind-mounts the host's shared source cache at
+// srccache.ContainerDir inside rootfs and points the inner yap at it. On
+// failure the inner build simply runs without the shared cache.
+func mountSourceCache(rootfs string) {
+	cacheDir := srccache.Dir()
+	if cacheDir == "" {
+		return
+	}
+
+	target := filepath.Join(rootfs, srccache.ContainerDir)
+
+	for _, dir := range []string{cacheDir, target} {
+		if err := os.MkdirAll(dir, 0o755); err != nil { //nolint:gosec // yap-managed paths
+			logger.Warn(i18n.T("logger.rootless.warn.bind_mount_failed"), "dir", dir, "error", err)
+
+			return
+		}
+	}
+
+	if err := bindMount(cacheDir, target); err != nil {
+		logger.Warn(i18n.T("logger.rootless.warn.bind_mount_failed"), "dir", cacheDir, "error", err)
+
+		return
+	}
+
+	_ = os.Setenv(srccache.DirEnv, srccache.ContainerDir)
+}
+
 // pivotOrChroot switches the process root to newRoot.
 // Tries pivot_root first (preferred), falls back to chroot.
 func pivotOrChroot(newRoot string) error {
@@ -12,6 +12,7 @@ var PackageManagerConfigs = map[string]*Config{
 	constants.FormatAPK:    {},
 	constants.PMApt:        {},
 	constants.FormatPacman: {},
+	constants.PMXbps:       {},
 	constants.PMYum:        {},
 	constants.PMZypper:     {},
 }
@@ -12,6 +12,7 @@ func TestGetConfig(t *testing.T) {
 		{"apk", false},
 		{"apt", false},
 		{"pacman", false},
+		{"xbps", false},
 		{"yum", false},
 		{"zypper", false},
 		{"nonexistent", true},
@@ -6,6 +6,9 @@
 // virtual-package (Provides) handling, and concurrent SHA256-verified
 // downloads.
 //
+// WriteRepodata goes the other way: it writes the repodata/ metadata of a
+// directory of RPMs, as createrepo_c does, for publishing a repository.
+//
 // Typical use:
 //
 //	if err := dnfcache.Update(ctx); err != nil {
@@ -0,0 +1,863 @@
+package dnfcache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/gzip"
+	rpmutils "github.com/sassoftware/go-rpmutils"
+
+	apperrors "github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/signing"
+)
+
+// XML namespaces of the createrepo metadata documents.
+const (
+	nsCommon    = "http://linux.duke.edu/metadata/common"
+	nsRPM       = "http://linux.duke.edu/metadata/rpm"
+	nsFilelists = "http://linux.duke.edu/metadata/filelists"
+	nsOther     = "http://linux.duke.edu/metadata/other"
+	nsRepo      = "http://linux.duke.edu/metadata/repo"
+)
+
+// Header tags go-rpmutils does not define: the weak dependencies and
+// RPMTAG_SOURCEPACKAGE, set in source RPMs.
+const (
+	tagSourcePackage    = 1106
+	tagRecommendName    = 5046
+	tagRecommendVersion = 5047
+	tagRecommendFlags   = 5048
+	tagSuggestName      = 5049
+	tagSuggestVersion   = 5050
+	tagSuggestFlags     = 5051
+	tagSupplementName   = 5052
+	tagSupplementVer    = 5053
+	tagSupplementFlags  = 5054
+	tagEnhanceName      = 5055
+	tagEnhanceVersion   = 5056
+	tagEnhanceFlags     = 5057
+)
+
+// Dependency flags: the comparison bits behind the flags attribute and the
+// bits marking a dependency of the install scriptlets, reported as pre="1".
+const (
+	senseCompareMask = rpmutils.RPMSENSE_LESS | rpmutils.RPMSENSE_GREATER | rpmutils.RPMSENSE_EQUAL
+	sensePreReq      = 1 << 6
+	senseScriptPre   = 1 << 9
+	senseScriptPost  = 1 << 10
+)
+
+// RepodataOptions configures WriteRepodata.
+type RepodataOptions struct {
+	// Update reuses the metadata of the existing repodata for every package
+	// whose file is unchanged (same location, size and mtime), as
+	// createrepo_c --update does, instead of reading its header again.
+	Update bool
+	// SQLite also writes the primary, filelists and other sqlite databases
+	// older yum releases prefer over the XML documents.
+	SQLite bool
+	// Signer, when set, signs repomd.xml into repomd.xml.asc.
+	Signer *signing.GPGSigner
+}
+
+// ---- repodata XML structs ----
+//
+// Elements of the rpm namespace are spelled with their conventional "rpm:"
+// prefix because libsolv and createrepo_c match them literally; the
+// existing documents are read back through prefixedTokens for --update.
+
+type repodataPackage struct {
+	XMLName     xml.Name        `xml:"package"`
+	Type        string          `xml:"type,attr"`
+	Name        string          `xml:"name"`
+	Arch        string          `xml:"arch"`
+	Version     repodataVersion `xml:"version"`
+	Checksum    repodataPkgID   `xml:"checksum"`
+	Summary     string          `xml:"summary"`
+	Description string          `xml:"description"`
+	Packager    string          `xml:"packager"`
+	URL         string          `xml:"url"`
+	Time        repodataTime    `xml:"time"`
+	Size        repodataSize    `xml:"size"`
+	Location    repoMDLocation  `xml:"location"`
+	Format      repodataFormat  `xml:"format"`
+}
+
+type repodataVersion struct {
+	Epoch string `xml:"epoch,attr"`
+	Ver   string `xml:"ver,attr"`
+	Rel   string `xml:"rel,attr"`
+}
+
+type repodataPkgID struct {
+	Type  string `xml:"type,attr"`
+	PkgID string `xml:"pkgid,attr"`
+	Value string `xml:",chardata"`
+}
+
+type repodataTime struct {
+	File  int64 `xml:"file,attr"`
+	Build int64 `xml:"build,attr"`
+}
+
+type repodataSize struct {
+	Package   int64 `xml:"package,attr"`
+	Installed int64 `xml:"installed,attr"`
+	Archive   int64 `xml:"archive,attr"`
+}
+
+type repodataFormat struct {
+	License     string              `xml:"rpm:license"`
+	Vendor      string              `xml:"rpm:vendor"`
+	Group       string              `xml:"rpm:group"`
+	BuildHost   string              `xml:"rpm:buildhost"`
+	SourceRPM   string              `xml:"rpm:sourcerpm"`
+	HeaderRange repodataHeaderRange `xml:"rpm:header-range"`
+	Provides    *repodataDeps       `xml:"rpm:provides,omitempty"`
+	Requires    *repodataDeps       `xml:"rpm:requires,omitempty"`
+	Conflicts   *repodataDeps       `xml:"rpm:conflicts,omitempty"`
+	Obsoletes   *repodataDeps       `xml:"rpm:obsoletes,omitempty"`
+	Suggests    *repodataDeps       `xml:"rpm:suggests,omitempty"`
+	Enhances    *repodataDeps       `xml:"rpm:enhances,omitempty"`
+	Recommends  *repodataDeps       `xml:"rpm:recommends,omitempty"`
+	Supplements *repodataDeps       `xml:"rpm:supplements,omitempty"`
+	Files       []repodataFile      `xml:"file"`
+}
+
+type repodataHeaderRange struct {
+	Start int `xml:"start,attr"`
+	End   int `xml:"end,attr"`
+}
+
+type repodataDeps struct {
+	Entries []repodataEntry `xml:"rpm:entry"`
+}
+
+// list returns the entries of d, which may be nil.
+func (d *repodataDeps) list() []repodataEntry {
+	if d == nil {
+		return nil
+	}
+
+	return d.Entries
+}
+
+type repodataEntry struct {
+	Name  string `xml:"name,attr"`
+	Flags string `xml:"flags,attr,omitempty"`
+	Epoch string `xml:"epoch,attr,omitempty"`
+	Ver   string `xml:"ver,attr,omitempty"`
+	Rel   string `xml:"rel,attr,omitempty"`
+	Pre   string `xml:"pre,attr,omitempty"`
+}
+
+type repodataFile struct {
+	Type string `xml:"type,attr,omitempty"`
+	Path string `xml:",chardata"`
+}
+
+type filelistsPackage struct {
+	XMLName xml.Name        `xml:"package"`
+	PkgID   string          `xml:"pkgid,attr"`
+	Name    string          `xml:"name,attr"`
+	Arch    string          `xml:"arch,attr"`
+	Version repodataVersion `xml:"version"`
+	Files   []repodataFile  `xml:"file"`
+}
+
+type otherPackage struct {
+	XMLName    xml.Name            `xml:"package"`
+	PkgID      string              `xml:"pkgid,attr"`
+	Name       string              `xml:"name,attr"`
+	Arch       string              `xml:"arch,attr"`
+	Version    repodataVersion     `xml:"version"`
+	Changelogs []repodataChangelog `xml:"changelog"`
+}
+
+type repodataChangelog struct {
+	Author string `xml:"author,attr"`
+	Date   int64  `xml:"date,attr"`
+	Text   string `xml:",chardata"`
+}
+
+type repomdDocument struct {
+	XMLName  xml.Name       `xml:"repomd"`
+	Xmlns    string         `xml:"xmlns,attr"`
+	XmlnsRPM string         `xml:"xmlns:rpm,attr"`
+	Revision int64          `xml:"revision"`
+	Data     []repomdRecord `xml:"data"`
+}
+
+type repomdRecord struct {
+	Type            string         `xml:"type,attr"`
+	Checksum        repoMDChecksum `xml:"checksum"`
+	OpenChecksum    repoMDChecksum `xml:"open-checksum"`
+	Location        repoMDLocation `xml:"location"`
+	Timestamp       int64          `xml:"timestamp"`
+	Size            int64          `xml:"size"`
+	OpenSize        int64          `xml:"open-size"`
+	DatabaseVersion int            `xml:"database_version,omitempty"`
+}
+
+// repodataPackageSet is the metadata of one package across the three
+// documents.
+type repodataPackageSet struct {
+	primary   repodataPackage
+	filelists filelistsPackage
+	other     otherPackage
+}
+
+// WriteRepodata writes the repodata/ directory of the .rpm packages found
+// under dir, as createrepo_c does: repomd.xml indexing the gzip-compressed
+// primary, filelists and other documents (and their sqlite databases with
+// opts.SQLite), stored under checksum-prefixed names. Metadata files the
+// previous repomd.xml listed are removed once replaced. It returns the path
+// of repomd.xml.
+func WriteRepodata(ctx context.Context, dir string, opts RepodataOptions) (string, error) {
+	repodata := filepath.Join(dir, "repodata")
+	repomdPath := filepath.Join(repodata, "repomd.xml")
+
+	previous, cached := readPreviousRepodata(repodata, opts.Update)
+
+	packages, err := collectRepodataPackages(ctx, dir, cached)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(repodata, 0o755); err != nil { //nolint:gosec
+		return "", apperrors.Wrap(err, apperrors.ErrTypeFileSystem, "create repodata directory").
+			WithOperation("WriteRepodata").
+			WithContext("path", repodata)
+	}
+
+	now := time.Now().Unix()
+
+	records, err := writeRepodataDocuments(repodata, packages, now)
+	if err != nil {
+		return "", err
+	}
+
+	if opts.SQLite {
+		databases, err := writeRepodataDatabases(repodata, packages, records)
+		if err != nil {
+			return "", err
+		}
+
+		records = append(records, databases...)
+	}
+
+	repomd := repomdDocument{Xmlns: nsRepo, XmlnsRPM: nsRPM, Revision: now, Data: records}
+
+	data, err := xml.MarshalIndent(repomd, "", "  ")
+	if err != nil {
+		return "", apperrors.Wrap(err, apperrors.ErrTypeParser, "encode repomd.xml").
+			WithOperation("WriteRepodata")
+	}
+
+	data = append([]byte(xml.Header), append(data, '\n')...)
+
+	if err := os.WriteFile(repomdPath, data, 0o644); err != nil { //nolint:gosec
+		return "", apperrors.Wrap(err, apperrors.ErrTypeFileSystem, "write repomd.xml").
+			WithOperation("WriteRepodata").
+			WithContext("path", repomdPath)
+	}
+
+	removeStaleRepodata(dir, previous, records)
+
+	if err := signRepomd(ctx, repomdPath, opts.Signer); err != nil {
+		return "", err
+	}
+
+	return repomdPath, nil
+}
+
+// collectRepodataPackages returns the metadata of the .rpm files under dir
+// sorted by location, reusing the cached entries of unchanged files.
+func collectRepodataPackages(ctx context.Context, dir string,
+	cached map[string]*repodataPackageSet,
+) ([]*repodataPackageSet, error) {
+	var packages []*repodataPackageSet
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if path != dir && (entry.Name() == "repodata" || strings.HasPrefix(entry.Name(), ".")) {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !strings.HasSuffix(entry.Name(), ".rpm") {
+			return nil
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+
+		href := filepath.ToSlash(rel)
+
+		if set, ok := cached[href]; ok &&
+			set.primary.Size.Package == info.Size() && set.primary.Time.File == info.ModTime().Unix() {
+			packages = append(packages, set)
+
+			return nil
+		}
+
+		set, err := readRepodataPackage(path, href, info)
+		if err != nil {
+			return err
+		}
+
+		packages = append(packages, set)
+
+		return nil
+	})
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrTypeFileSystem, "scan RPM packages").
+			WithOperation("WriteRepodata").
+			WithContext("path", dir)
+	}
+
+	if len(packages) == 0 {
+		return nil, apperrors.New(apperrors.ErrTypeValidation, "no RPM packages to index").
+			WithOperation("WriteRepodata").
+			WithContext("path", dir)
+	}
+
+	return packages, nil
+}
+
+// readRepodataPackage reads the header of the package at path and hashes
+// the whole file in the same pass.
+func readRepodataPackage(path, href string, info fs.FileInfo) (*repodataPackageSet, error) {
+	file, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	hash := sha256.New()
+
+	hdr, err := rpmutils.ReadHeader(io.TeeReader(file, hash))
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrTypeParser, "read RPM header").
+			WithOperation("WriteRepodata").
+			WithContext("path", path)
+	}
+
+	if _, err := io.Copy(hash, file); err != nil {
+		return nil, err
+	}
+
+	nevra, err := hdr.GetNEVRA()
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrTypeParser, "read RPM header").
+			WithOperation("WriteRepodata").
+			WithContext("path", path)
+	}
+
+	arch := nevra.Arch
+	if hdr.HasTag(tagSourcePackage) {
+		arch = "src"
+	}
+
+	version := repodataVersion{Epoch: nevra.Epoch, Ver: nevra.Version, Rel: nevra.Release}
+	pkgID := hex.EncodeToString(hash.Sum(nil))
+	installed, _ := hdr.InstalledSize()
+	archive, _ := hdr.PayloadSize()
+	headerRange := hdr.GetRange()
+
+	var buildTime int64
+	if times, err := hdr.GetUint64s(rpmutils.BUILDTIME); err == nil && len(times) > 0 {
+		buildTime = int64(times[0]) //nolint:gosec
+	}
+
+	files, primaryFiles := headerFiles(hdr)
+
+	return &repodataPackageSet{
+		primary: repodataPackage{
+			Type:        "rpm",
+			Name:        nevra.Name,
+			Arch:        arch,
+			Version:     version,
+			Checksum:    repodataPkgID{Type: "sha256", PkgID: "YES", Value: pkgID},
+			Summary:     headerString(hdr, rpmutils.SUMMARY),
+			Description: headerString(hdr, rpmutils.DESCRIPTION),
+			Packager:    headerString(hdr, rpmutils.PACKAGER),
+			URL:         headerString(hdr, rpmutils.URL),
+			Time:        repodataTime{File: info.ModTime().Unix(), Build: buildTime},
+			Size:        repodataSize{Package: info.Size(), Installed: max(installed, 0), Archive: max(archive, 0)},
+			Location:    repoMDLocation{Href: href},
+			Format: repodataFormat{
+				License:     headerString(hdr, rpmutils.LICENSE),
+				Vendor:      headerString(hdr, rpmutils.VENDOR),
+				Group:       headerString(hdr, rpmutils.GROUP),
+				BuildHost:   headerString(hdr, rpmutils.BUILDHOST),
+				SourceRPM:   headerString(hdr, rpmutils.SOURCERPM),
+				HeaderRange: repodataHeaderRange{Start: headerRange.Start, End: headerRange.End},
+				Provides: headerEntries(hdr,
+					rpmutils.PROVIDENAME, rpmutils.PROVIDEFLAGS, rpmutils.PROVIDEVERSION),
+				Requires: headerEntries(hdr,
+					rpmutils.REQUIRENAME, rpmutils.REQUIREFLAGS, rpmutils.REQUIREVERSION),
+				Conflicts: headerEntries(hdr,
+					rpmutils.CONFLICTNAME, rpmutils.CONFLICTFLAGS, rpmutils.CONFLICTVERSION),
+				Obsoletes: headerEntries(hdr,
+					rpmutils.OBSOLETENAME, rpmutils.OBSOLETEFLAGS, rpmutils.OBSOLETEVERSION),
+				Suggests:    headerEntries(hdr, tagSuggestName, tagSuggestFlags, tagSuggestVersion),
+				Enhances:    headerEntries(hdr, tagEnhanceName, tagEnhanceFlags, tagEnhanceVersion),
+				Recommends:  headerEntries(hdr, tagRecommendName, tagRecommendFlags, tagRecommendVersion),
+				Supplements: headerEntries(hdr, tagSupplementName, tagSupplementFlags, tagSupplementVer),
+				Files:       primaryFiles,
+			},
+		},
+		filelists: filelistsPackage{
+			PkgID: pkgID, Name: nevra.Name, Arch: arch, Version: version, Files: files,
+		},
+		other: otherPackage{
+			PkgID: pkgID, Name: nevra.Name, Arch: arch, Version: version, Changelogs: headerChangelogs(hdr),
+		},
+	}, nil
+}
+
+// headerString returns a string tag of hdr, or "" when it is missing.
+func headerString(hdr *rpmutils.RpmHeader, tag int) string {
+	value, err := hdr.GetString(tag)
+	if err != nil {
+		return ""
+	}
+
+	return value
+}
+
+// headerEntries reads a dependency list (names, flags, versions) from hdr,
+// dropping rpmlib() capabilities and duplicates. It returns nil when no
+// entry is left, which omits the element.
+func headerEntries(hdr *rpmutils.RpmHeader, nameTag, flagsTag, versionTag int) *repodataDeps {
+	names, _ := hdr.GetStrings(nameTag)
+	if len(names) == 0 {
+		return nil
+	}
+
+	flags, _ := hdr.GetUint32s(flagsTag)
+	versions, _ := hdr.GetStrings(versionTag)
+
+	var entries []repodataEntry
+
+	for i, name := range names {
+		if strings.HasPrefix(name, "rpmlib(") {
+			continue
+		}
+
+		entry := repodataEntry{Name: name}
+
+		var flag uint32
+		if i < len(flags) {
+			flag = flags[i]
+		}
+
+		if i < len(versions) && versions[i] != "" {
+			entry.Flags = senseFlags(flag)
+			entry.Epoch, entry.Ver, entry.Rel = splitEVR(versions[i])
+		}
+
+		if flag&(sensePreReq|senseScriptPre|senseScriptPost) != 0 {
+			entry.Pre = "1"
+		}
+
+		if !slices.Contains(entries, entry) {
+			entries = append(entries, entry)
+		}
+	}
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	return &repodataDeps{Entries: entries}
+}
+
+// senseFlags maps the comparison bits of a dependency to the flags
+// attribute of primary.xml.
+func senseFlags(flag uint32) string {
+	switch flag & senseCompareMask {
+	case rpmutils.RPMSENSE_LESS:
+		return "LT"
+	case rpmutils.RPMSENSE_GREATER:
+		return "GT"
+	case rpmutils.RPMSENSE_EQUAL:
+		return "EQ"
+	case rpmutils.RPMSENSE_LESS | rpmutils.RPMSENSE_EQUAL:
+		return "LE"
+	case rpmutils.RPMSENSE_GREATER | rpmutils.RPMSENSE_EQUAL:
+		return "GE"
+	}
+
+	return ""
+}
+
+// splitEVR splits an [epoch:]version[-release] string. The epoch defaults
+// to 0, as createrepo_c reports it.
+func splitEVR(evr string) (epoch, version, release string) {
+	epoch = "0"
+
+	if e, rest, ok := strings.Cut(evr, ":"); ok {
+		epoch, evr = e, rest
+	}
+
+	version = evr
+
+	if i := strings.LastIndex(evr, "-"); i >= 0 {
+		version, release = evr[:i], evr[i+1:]
+	}
+
+	return epoch, version, release
+}
+
+// headerFiles returns every file of hdr, typed for filelists.xml, and the
+// subset primary.xml carries: /etc, the bin directories and sendmail.
+func headerFiles(hdr *rpmutils.RpmHeader) (files, primary []repodataFile) {
+	infos, _ := hdr.GetFiles()
+
+	for _, info := range infos {
+		file := repodataFile{Path: info.Name()}
+
+		switch {
+		case info.Flags()&rpmutils.RPMFILE_GHOST != 0:
+			file.Type = "ghost"
+		case info.Mode()&0o170000 == 0o040000:
+			file.Type = "dir"
+		}
+
+		files = append(files, file)
+
+		if strings.HasPrefix(file.Path, "/etc/") || strings.Contains(file.Path, "bin/") ||
+			file.Path == "/usr/lib/sendmail" {
+			primary = append(primary, file)
+		}
+	}
+
+	return files, primary
+}
+
+// headerChangelogs returns the changelog of hdr oldest first, the order
+// of other.xml.
+func headerChangelogs(hdr *rpmutils.RpmHeader) []repodataChangelog {
+	times, _ := hdr.GetUint64s(rpmutils.CHANGELOGTIME)
+	authors, _ := hdr.GetStrings(rpmutils.CHANGELOGNAME)
+	texts, _ := hdr.GetStrings(rpmutils.CHANGELOGTEXT)
+
+	count := min(len(times), len(authors), len(texts))
+	changelogs := make([]repodataChangelog, 0, count)
+
+	for i := count - 1; i >= 0; i-- {
+		changelogs = append(changelogs, repodataChangelog{
+			Author: authors[i], Date: int64(times[i]), Text: texts[i], //nolint:gosec
+		})
+	}
+
+	return changelogs
+}
+
+// writeRepodataDocuments writes primary, filelists and other under
+// repodata and returns their repomd records.
+func writeRepodataDocuments(repodata string, packages []*repodataPackageSet,
+	now int64,
+) ([]repomdRecord, error) {
+	documents := []struct {
+		kind, root, namespaces string
+		entry                  func(*repodataPackageSet) any
+	}{
+		{"primary", "metadata", `xmlns="` + nsCommon + `" xmlns:rpm="` + nsRPM + `"`,
+			func(set *repodataPackageSet) any { return set.primary }},
+		{"filelists", "filelists", `xmlns="` + nsFilelists + `"`,
+			func(set *repodataPackageSet) any { return set.filelists }},
+		{"other", "otherdata", `xmlns="` + nsOther + `"`,
+			func(set *repodataPackageSet) any { return set.other }},
+	}
+
+	records := make([]repomdRecord, 0, len(documents))
+
+	for _, doc := range documents {
+		var buf bytes.Buffer
+
+		buf.WriteString(xml.Header)
+		buf.WriteString("<" + doc.root + " " + doc.namespaces + ` packages="`)
+		buf.WriteString(strconv.Itoa(len(packages)) + "\">\n")
+
+		for _, set := range packages {
+			data, err := xml.MarshalIndent(doc.entry(set), "", "  ")
+			if err != nil {
+				return nil, apperrors.Wrap(err, apperrors.ErrTypeParser, "encode "+doc.kind+".xml").
+					WithOperation("WriteRepodata")
+			}
+
+			buf.Write(data)
+			buf.WriteByte('\n')
+		}
+
+		buf.WriteString("</" + doc.root + ">\n")
+
+		record, err := writeRepodataFile(repodata, doc.kind, doc.kind+".xml.gz", buf.Bytes(), now)
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// writeRepodataFile gzips data into repodata/<sha256>-<name> and returns
+// its repomd record.
+func writeRepodataFile(repodata, kind, name string, data []byte, now int64) (repomdRecord, error) {
+	var compressed bytes.Buffer
+
+	gz := gzip.NewWriter(&compressed)
+
+	if _, err := gz.Write(data); err != nil {
+		return repomdRecord{}, err
+	}
+
+	if err := gz.Close(); err != nil {
+		return repomdRecord{}, err
+	}
+
+	openSum := sha256.Sum256(data)
+	sum := sha256.Sum256(compressed.Bytes())
+	href := "repodata/" + hex.EncodeToString(sum[:]) + "-" + name
+	target := filepath.Join(repodata, path.Base(href))
+
+	if err := os.WriteFile(target, compressed.Bytes(), 0o644); err != nil { //nolint:gosec
+		return repomdRecord{}, apperrors.Wrap(err, apperrors.ErrTypeFileSystem, "write repodata file").
+			WithOperation("WriteRepodata").
+			WithContext("path", target)
+	}
+
+	return repomdRecord{
+		Type:         kind,
+		Checksum:     repoMDChecksum{Type: "sha256", Value: hex.EncodeToString(sum[:])},
+		OpenChecksum: repoMDChecksum{Type: "sha256", Value: hex.EncodeToString(openSum[:])},
+		Location:     repoMDLocation{Href: href},
+		Timestamp:    now,
+		Size:         int64(compressed.Len()),
+		OpenSize:     int64(len(data)),
+	}, nil
+}
+
+// readPreviousRepodata returns the metadata locations of the existing
+// repomd.xml in repodata and, with update, its package entries keyed by
+// location. Unreadable or missing metadata just means nothing is reused.
+func readPreviousRepodata(repodata string, update bool) ([]string, map[string]*repodataPackageSet) {
+	data, err := os.ReadFile(filepath.Join(repodata, "repomd.xml")) //nolint:gosec
+	if err != nil {
+		return nil, nil
+	}
+
+	var repomd repoMD
+	if err := xml.Unmarshal(data, &repomd); err != nil {
+		return nil, nil
+	}
+
+	locations := make([]string, 0, len(repomd.Data))
+	documents := map[string]string{}
+
+	for _, record := range repomd.Data {
+		locations = append(locations, record.Location.Href)
+		documents[record.Type] = filepath.Join(filepath.Dir(repodata), filepath.FromSlash(record.Location.Href))
+	}
+
+	if !update {
+		return locations, nil
+	}
+
+	primaries := map[string]*repodataPackage{}
+	if err := decodeRepodata(documents["primary"], func(d *xml.Decoder, start *xml.StartElement) error {
+		var pkg repodataPackage
+		if err := d.DecodeElement(&pkg, start); err != nil {
+			return err
+		}
+
+		primaries[pkg.Checksum.Value] = &pkg
+
+		return nil
+	}); err != nil {
+		return locations, nil
+	}
+
+	filelists := map[string]*filelistsPackage{}
+	if err := decodeRepodata(documents["filelists"], func(d *xml.Decoder, start *xml.StartElement) error {
+		var pkg filelistsPackage
+		if err := d.DecodeElement(&pkg, start); err != nil {
+			return err
+		}
+
+		filelists[pkg.PkgID] = &pkg
+
+		return nil
+	}); err != nil {
+		return locations, nil
+	}
+
+	others := map[string]*otherPackage{}
+	if err := decodeRepodata(documents["other"], func(d *xml.Decoder, start *xml.StartElement) error {
+		var pkg otherPackage
+		if err := d.DecodeElement(&pkg, start); err != nil {
+			return err
+		}
+
+		others[pkg.PkgID] = &pkg
+
+		return nil
+	}); err != nil {
+		return locations, nil
+	}
+
+	cached := map[string]*repodataPackageSet{}
+
+	for pkgID, primary := range primaries {
+		filelist, ok := filelists[pkgID]
+		if !ok {
+			continue
+		}
+
+		other, ok := others[pkgID]
+		if !ok {
+			continue
+		}
+
+		cached[primary.Location.Href] = &repodataPackageSet{
+			primary: *primary, filelists: *filelist, other: *other,
+		}
+	}
+
+	return locations, cached
+}
+
+// decodeRepodata calls fn for every <package> element of the compressed
+// metadata document at path.
+func decodeRepodata(path string, fn func(*xml.Decoder, *xml.StartElement) error) error {
+	if path == "" {
+		return os.ErrNotExist
+	}
+
+	r, closer, err := openCompressed(path)
+	if err != nil {
+		return err
+	}
+
+	defer closer()
+
+	d := xml.NewTokenDecoder(prefixedTokens{xml.NewDecoder(r)})
+
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local == "package" {
+			if err := fn(d, &start); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// prefixedTokens reads raw tokens, keeping the namespace prefix in the
+// local name ("rpm:entry") so the prefixed struct tags of the repodata
+// structs match on decode as they do on encode.
+type prefixedTokens struct {
+	d *xml.Decoder
+}
+
+// Token implements xml.TokenReader.
+func (p prefixedTokens) Token() (xml.Token, error) {
+	tok, err := p.d.RawToken()
+
+	switch t := tok.(type) {
+	case xml.StartElement:
+		if t.Name.Space != "" {
+			t.Name = xml.Name{Local: t.Name.Space + ":" + t.Name.Local}
+		}
+
+		return t, err
+	case xml.EndElement:
+		if t.Name.Space != "" {
+			t.Name = xml.Name{Local: t.Name.Space + ":" + t.Name.Local}
+		}
+
+		return t, err
+	case xml.CharData:
+		return t.Copy(), err
+	}
+
+	return tok, err
+}
+
+// removeStaleRepodata deletes the metadata files the previous repomd.xml
+// listed which the new one does not.
+func removeStaleRepodata(dir string, previous []string, records []repomdRecord) {
+	for _, href := range previous {
+		if !strings.HasPrefix(href, "repodata/") || strings.Contains(href, "..") {
+			continue
+		}
+
+		if slices.ContainsFunc(records, func(record repomdRecord) bool { return record.Location.Href == href }) {
+			continue
+		}
+
+		_ = os.Remove(filepath.Join(dir, filepath.FromSlash(href)))
+	}
+}
+
+// signRepomd writes the detached armored signature repomd.xml.asc, or
+// removes a stale one when the repository is no longer signed.
+func signRepomd(ctx context.Context, repomdPath string, signer *signing.GPGSigner) error {
+	sigPath := repomdPath + ".asc"
+
+	if signer == nil {
+		if err := os.Remove(sigPath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+
+		return nil
+	}
+
+	return signer.DetachSign(ctx, repomdPath, sigPath)
+}
@@ -0,0 +1,284 @@
+package dnfcache
+
+import (
+	"cmp"
+	"database/sql"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	_ "modernc.org/sqlite" // CGO-free SQLite driver
+
+	apperrors "github.com/M0Rf30/yap/v2/pkg/errors"
+)
+
+// repodataDBVersion is the createrepo sqlite schema version yum checks.
+const repodataDBVersion = 10
+
+// Schemas of the createrepo_c sqlite databases, keyed by document.
+var repodataSchemas = map[string][]string{
+	"primary": {
+		`CREATE TABLE db_info (dbversion INTEGER, checksum TEXT)`,
+		`CREATE TABLE packages (pkgKey INTEGER PRIMARY KEY, pkgId TEXT, name TEXT, arch TEXT,
+			version TEXT, epoch TEXT, release TEXT, summary TEXT, description TEXT, url TEXT,
+			time_file INTEGER, time_build INTEGER, rpm_license TEXT, rpm_vendor TEXT,
+			rpm_group TEXT, rpm_buildhost TEXT, rpm_sourcerpm TEXT, rpm_header_start INTEGER,
+			rpm_header_end INTEGER, rpm_packager TEXT, size_package INTEGER,
+			size_installed INTEGER, size_archive INTEGER, location_href TEXT,
+			location_base TEXT, checksum_type TEXT)`,
+		`CREATE TABLE files (name TEXT, type TEXT, pkgKey INTEGER)`,
+		`CREATE TABLE requires (name TEXT, flags TEXT, epoch TEXT, version TEXT, release TEXT,
+			pkgKey INTEGER, pre BOOLEAN DEFAULT FALSE)`,
+		`CREATE TABLE provides (name TEXT, flags TEXT, epoch TEXT, version TEXT, release TEXT, pkgKey INTEGER)`,
+		`CREATE TABLE conflicts (name TEXT, flags TEXT, epoch TEXT, version TEXT, release TEXT, pkgKey INTEGER)`,
+		`CREATE TABLE obsoletes (name TEXT, flags TEXT, epoch TEXT, version TEXT, release TEXT, pkgKey INTEGER)`,
+		`CREATE TABLE suggests (name TEXT, flags TEXT, epoch TEXT, version TEXT, release TEXT, pkgKey INTEGER)`,
+		`CREATE TABLE enhances (name TEXT, flags TEXT, epoch TEXT, version TEXT, release TEXT, pkgKey INTEGER)`,
+		`CREATE TABLE recommends (name TEXT, flags TEXT, epoch TEXT, version TEXT, release TEXT, pkgKey INTEGER)`,
+		`CREATE TABLE supplements (name TEXT, flags TEXT, epoch TEXT, version TEXT, release TEXT, pkgKey INTEGER)`,
+		`CREATE INDEX packagename ON packages (name)`,
+		`CREATE INDEX packageId ON packages (pkgId)`,
+		`CREATE INDEX filenames ON files (name)`,
+		`CREATE INDEX pkgfiles ON files (pkgKey)`,
+		`CREATE INDEX pkgrequires ON requires (pkgKey)`,
+		`CREATE INDEX requiresname ON requires (name)`,
+		`CREATE INDEX pkgprovides ON provides (pkgKey)`,
+		`CREATE INDEX providesname ON provides (name)`,
+		`CREATE INDEX pkgconflicts ON conflicts (pkgKey)`,
+		`CREATE INDEX pkgobsoletes ON obsoletes (pkgKey)`,
+	},
+	"filelists": {
+		`CREATE TABLE db_info (dbversion INTEGER, checksum TEXT)`,
+		`CREATE TABLE packages (pkgKey INTEGER PRIMARY KEY, pkgId TEXT)`,
+		`CREATE TABLE filelist (pkgKey INTEGER, dirname TEXT, filenames TEXT, filetypes TEXT)`,
+		`CREATE INDEX keyfile ON filelist (pkgKey)`,
+		`CREATE INDEX pkgId ON packages (pkgId)`,
+		`CREATE INDEX dirnames ON filelist (dirname)`,
+	},
+	"other": {
+		`CREATE TABLE db_info (dbversion INTEGER, checksum TEXT)`,
+		`CREATE TABLE packages (pkgKey INTEGER PRIMARY KEY, pkgId TEXT)`,
+		`CREATE TABLE changelog (pkgKey INTEGER, author TEXT, date INTEGER, changelog TEXT)`,
+		`CREATE INDEX keychange ON changelog (pkgKey)`,
+		`CREATE INDEX pkgId ON packages (pkgId)`,
+	},
+}
+
+// writeRepodataDatabases writes the <kind>_db sqlite flavour of every XML
+// document in records and returns their repomd records. Each database
+// records the checksum of the document it mirrors, as yum expects.
+func writeRepodataDatabases(repodata string, packages []*repodataPackageSet,
+	records []repomdRecord,
+) ([]repomdRecord, error) {
+	databases := make([]repomdRecord, 0, len(records))
+
+	for _, record := range records {
+		data, err := buildRepodataDatabase(repodata, record, packages)
+		if err != nil {
+			return nil, apperrors.Wrap(err, apperrors.ErrTypeFileSystem, "write "+record.Type+" sqlite database").
+				WithOperation("WriteRepodata")
+		}
+
+		database, err := writeRepodataFile(repodata, record.Type+"_db", record.Type+".sqlite.gz",
+			data, record.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+
+		database.DatabaseVersion = repodataDBVersion
+		databases = append(databases, database)
+	}
+
+	return databases, nil
+}
+
+// buildRepodataDatabase fills a scratch sqlite file with the packages of
+// the document behind record and returns its contents.
+func buildRepodataDatabase(repodata string, record repomdRecord,
+	packages []*repodataPackageSet,
+) ([]byte, error) {
+	scratch, err := os.CreateTemp(repodata, ".db-*")
+	if err != nil {
+		return nil, err
+	}
+
+	scratchPath := scratch.Name()
+	_ = scratch.Close()
+
+	defer func() {
+		_ = os.Remove(scratchPath)
+	}()
+
+	db, err := sql.Open("sqlite", scratchPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := fillRepodataDatabase(db, record, packages); err != nil {
+		_ = db.Close()
+
+		return nil, err
+	}
+
+	if err := db.Close(); err != nil {
+		return nil, err
+	}
+
+	return os.ReadFile(filepath.Clean(scratchPath))
+}
+
+// fillRepodataDatabase creates the schema of the record's document in db
+// and inserts every package in one transaction.
+func fillRepodataDatabase(db *sql.DB, record repomdRecord, packages []*repodataPackageSet) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	for _, stmt := range repodataSchemas[record.Type] {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`INSERT INTO db_info (dbversion, checksum) VALUES (?, ?)`,
+		repodataDBVersion, record.Checksum.Value); err != nil {
+		return err
+	}
+
+	for i, set := range packages {
+		key := int64(i + 1)
+
+		switch record.Type {
+		case "primary":
+			err = insertPrimaryRow(tx, key, &set.primary)
+		case "filelists":
+			err = insertFilelistsRows(tx, key, &set.filelists)
+		case "other":
+			err = insertOtherRows(tx, key, &set.other)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// insertPrimaryRow inserts a package of primary.xml with its files and
+// dependencies.
+func insertPrimaryRow(tx *sql.Tx, key int64, pkg *repodataPackage) error {
+	format := &pkg.Format
+
+	if _, err := tx.Exec(`INSERT INTO packages VALUES
+		(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		key, pkg.Checksum.Value, pkg.Name, pkg.Arch, pkg.Version.Ver, pkg.Version.Epoch,
+		pkg.Version.Rel, pkg.Summary, pkg.Description, pkg.URL, pkg.Time.File, pkg.Time.Build,
+		format.License, format.Vendor, format.Group, format.BuildHost, format.SourceRPM,
+		format.HeaderRange.Start, format.HeaderRange.End, pkg.Packager, pkg.Size.Package,
+		pkg.Size.Installed, pkg.Size.Archive, pkg.Location.Href, nil, pkg.Checksum.Type); err != nil {
+		return err
+	}
+
+	for _, file := range format.Files {
+		if _, err := tx.Exec(`INSERT INTO files VALUES (?, ?, ?)`,
+			file.Path, cmp.Or(file.Type, "file"), key); err != nil {
+			return err
+		}
+	}
+
+	for _, dep := range format.Requires.list() {
+		if _, err := tx.Exec(`INSERT INTO requires VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			dep.Name, dep.Flags, dep.Epoch, dep.Ver, dep.Rel, key, dep.Pre == "1"); err != nil {
+			return err
+		}
+	}
+
+	for table, deps := range map[string]*repodataDeps{
+		"provides": format.Provides, "conflicts": format.Conflicts, "obsoletes": format.Obsoletes,
+		"suggests": format.Suggests, "enhances": format.Enhances, "recommends": format.Recommends,
+		"supplements": format.Supplements,
+	} {
+		for _, dep := range deps.list() {
+			if _, err := tx.Exec(`INSERT INTO `+table+` VALUES (?, ?, ?, ?, ?, ?)`, //nolint:gosec
+				dep.Name, dep.Flags, dep.Epoch, dep.Ver, dep.Rel, key); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// insertFilelistsRows inserts a package of filelists.xml, its files grouped
+// by directory into "/"-joined names and one type letter per file.
+func insertFilelistsRows(tx *sql.Tx, key int64, pkg *filelistsPackage) error {
+	if _, err := tx.Exec(`INSERT INTO packages VALUES (?, ?)`, key, pkg.PkgID); err != nil {
+		return err
+	}
+
+	var dirs []string
+
+	names := map[string][]string{}
+	types := map[string]string{}
+
+	for _, file := range pkg.Files {
+		dir, name := path.Split(file.Path)
+		dir = strings.TrimSuffix(dir, "/")
+
+		if dir == "" {
+			dir = "/"
+		}
+
+		if _, ok := names[dir]; !ok {
+			dirs = append(dirs, dir)
+		}
+
+		names[dir] = append(names[dir], name)
+		types[dir] += fileType(file.Type)
+	}
+
+	for _, dir := range dirs {
+		if _, err := tx.Exec(`INSERT INTO filelist VALUES (?, ?, ?, ?)`,
+			key, dir, strings.Join(names[dir], "/"), types[dir]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// insertOtherRows inserts a package of other.xml with its changelog.
+func insertOtherRows(tx *sql.Tx, key int64, pkg *otherPackage) error {
+	if _, err := tx.Exec(`INSERT INTO packages VALUES (?, ?)`, key, pkg.PkgID); err != nil {
+		return err
+	}
+
+	for _, changelog := range pkg.Changelogs {
+		if _, err := tx.Exec(`INSERT INTO changelog VALUES (?, ?, ?, ?)`,
+			key, changelog.Author, changelog.Date, changelog.Text); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fileType maps the type attribute of a file element to the single-letter
+// type of the sqlite databases.
+func fileType(kind string) string {
+	switch kind {
+	case "dir":
+		return "d"
+	case "ghost":
+		return "g"
+	}
+
+	return "f"
+}
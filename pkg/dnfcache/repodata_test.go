@@ -0,0 +1,234 @@
+//nolint:testpackage
+package dnfcache
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/M0Rf30/rpmpack"
+	"github.com/klauspost/compress/gzip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteRepodata(t *testing.T) {
+	dir := t.TempDir()
+	foo := writeRepodataRPM(t, dir, "foo", "1.0", "glibc >= 2.17")
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "noarch"), 0o755))
+	writeRepodataRPM(t, filepath.Join(dir, "noarch"), "bar", "2.0")
+
+	repomdPath, err := WriteRepodata(context.Background(), dir, RepodataOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "repodata", "repomd.xml"), repomdPath)
+
+	documents := readRepomdRecords(t, dir)
+	assert.Len(t, documents, 3)
+
+	var packages []repodataPackage
+
+	require.NoError(t, decodeRepodata(documents["primary"], func(d *xml.Decoder, start *xml.StartElement) error {
+		var pkg repodataPackage
+		if err := d.DecodeElement(&pkg, start); err != nil {
+			return err
+		}
+
+		packages = append(packages, pkg)
+
+		return nil
+	}))
+	require.Len(t, packages, 2)
+
+	data, err := os.ReadFile(foo)
+	require.NoError(t, err)
+
+	sum := sha256.Sum256(data)
+
+	pkg := packages[0]
+	assert.Equal(t, "foo", pkg.Name)
+	assert.Equal(t, "foo-1.0-1.x86_64.rpm", pkg.Location.Href)
+	assert.Equal(t, hex.EncodeToString(sum[:]), pkg.Checksum.Value)
+	assert.Equal(t, int64(len(data)), pkg.Size.Package)
+	assert.Positive(t, pkg.Format.HeaderRange.End)
+	assert.Contains(t, pkg.Format.Files, repodataFile{Path: "/usr/bin/foo"})
+	assert.Contains(t, pkg.Format.Requires.list(),
+		repodataEntry{Name: "glibc", Flags: "GE", Epoch: "0", Ver: "2.17"})
+	assert.Equal(t, "noarch/bar-2.0-1.x86_64.rpm", packages[1].Location.Href)
+
+	_, err = os.Stat(repomdPath + ".asc")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestWriteRepodataUpdate(t *testing.T) {
+	dir := t.TempDir()
+	writeRepodataRPM(t, dir, "foo", "1.0")
+
+	_, err := WriteRepodata(context.Background(), dir, RepodataOptions{})
+	require.NoError(t, err)
+
+	first := readRepomdRecords(t, dir)
+
+	_, cached := readPreviousRepodata(filepath.Join(dir, "repodata"), true)
+	require.Contains(t, cached, "foo-1.0-1.x86_64.rpm")
+	assert.Equal(t, "foo", cached["foo-1.0-1.x86_64.rpm"].filelists.Name)
+
+	writeRepodataRPM(t, dir, "bar", "2.0")
+
+	_, err = WriteRepodata(context.Background(), dir, RepodataOptions{Update: true})
+	require.NoError(t, err)
+
+	second := readRepomdRecords(t, dir)
+	assert.NotEqual(t, first["primary"], second["primary"])
+
+	_, err = os.Stat(first["primary"])
+	assert.True(t, os.IsNotExist(err), "the replaced primary.xml.gz was not removed")
+
+	_, cached = readPreviousRepodata(filepath.Join(dir, "repodata"), true)
+	assert.Len(t, cached, 2)
+}
+
+func TestWriteRepodataSQLite(t *testing.T) {
+	dir := t.TempDir()
+	writeRepodataRPM(t, dir, "foo", "1.0", "glibc >= 2.17")
+
+	_, err := WriteRepodata(context.Background(), dir, RepodataOptions{SQLite: true})
+	require.NoError(t, err)
+
+	documents := readRepomdRecords(t, dir)
+	require.Contains(t, documents, "primary_db")
+	assert.Contains(t, documents, "filelists_db")
+	assert.Contains(t, documents, "other_db")
+
+	r, closer, err := openCompressed(documents["primary_db"])
+	require.NoError(t, err)
+
+	dbPath := filepath.Join(t.TempDir(), "primary.sqlite")
+	file, err := os.Create(dbPath)
+	require.NoError(t, err)
+
+	_, err = file.ReadFrom(r)
+	closer()
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	db, err := sql.Open("sqlite", dbPath)
+	require.NoError(t, err)
+
+	defer func() { _ = db.Close() }()
+
+	var name, version string
+	require.NoError(t, db.QueryRow(`SELECT name, version FROM packages`).Scan(&name, &version))
+	assert.Equal(t, "foo", name)
+	assert.Equal(t, "1.0", version)
+
+	var flags string
+	require.NoError(t, db.QueryRow(`SELECT flags FROM requires WHERE name = 'glibc'`).Scan(&flags))
+	assert.Equal(t, "GE", flags)
+}
+
+func TestWriteRepodataEmpty(t *testing.T) {
+	_, err := WriteRepodata(context.Background(), t.TempDir(), RepodataOptions{})
+	require.Error(t, err)
+}
+
+func TestSplitEVR(t *testing.T) {
+	cases := []struct {
+		in, epoch, version, release string
+	}{
+		{"1.0", "0", "1.0", ""},
+		{"1.0-1", "0", "1.0", "1"},
+		{"2:2.34-1.el9", "2", "2.34", "1.el9"},
+		{"1.0-rc1-2", "0", "1.0-rc1", "2"},
+	}
+
+	for _, c := range cases {
+		epoch, version, release := splitEVR(c.in)
+		assert.Equal(t, []string{c.epoch, c.version, c.release}, []string{epoch, version, release}, c.in)
+	}
+}
+
+func TestSenseFlags(t *testing.T) {
+	assert.Equal(t, "EQ", senseFlags(8))
+	assert.Equal(t, "LT", senseFlags(2))
+	assert.Equal(t, "GE", senseFlags(12))
+	assert.Equal(t, "LE", senseFlags(10|senseScriptPre))
+	assert.Empty(t, senseFlags(0))
+}
+
+// writeRepodataRPM writes <name>-<version>-1.x86_64.rpm owning /usr/bin/<name>
+// and returns its path.
+func writeRepodataRPM(t *testing.T, dir, name, version string, requires ...string) string {
+	t.Helper()
+
+	relations := rpmpack.Relations{}
+	for _, req := range requires {
+		require.NoError(t, relations.Set(req))
+	}
+
+	rpm, err := rpmpack.NewRPM(rpmpack.RPMMetaData{
+		Name:       name,
+		Version:    version,
+		Release:    "1",
+		Arch:       "x86_64",
+		Summary:    name + " test package",
+		Licence:    "MIT",
+		Compressor: "gzip",
+		BuildTime:  time.Now(),
+		Requires:   relations,
+	})
+	require.NoError(t, err)
+
+	rpm.AddFile(rpmpack.RPMFile{
+		Name:  "/usr/bin/" + name,
+		Body:  []byte("#!/bin/sh\n"),
+		Mode:  0o755,
+		MTime: uint32(time.Now().Unix()),
+	})
+
+	path := filepath.Join(dir, name+"-"+version+"-1.x86_64.rpm")
+	file, err := os.Create(path)
+	require.NoError(t, err)
+
+	defer func() { _ = file.Close() }()
+
+	require.NoError(t, rpm.Write(file))
+
+	return path
+}
+
+// readRepomdRecords returns the metadata files listed by dir/repodata/repomd.xml
+// keyed by type.
+func readRepomdRecords(t *testing.T, dir string) map[string]string {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(dir, "repodata", "repomd.xml"))
+	require.NoError(t, err)
+
+	var repomd repomdDocument
+	require.NoError(t, xml.Unmarshal(data, &repomd))
+
+	documents := map[string]string{}
+
+	for _, record := range repomd.Data {
+		path := filepath.Join(dir, filepath.FromSlash(record.Location.Href))
+
+		file, err := os.Open(path)
+		require.NoError(t, err)
+
+		gz, err := gzip.NewReader(file)
+		require.NoError(t, err, record.Type)
+
+		_ = gz.Close()
+		_ = file.Close()
+
+		documents[record.Type] = path
+	}
+
+	return documents
+}
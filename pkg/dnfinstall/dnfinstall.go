@@ -148,12 +148,16 @@ func InstallFile(ctx context.Context, rpmPath string, opts Options) error {
 	}
 
 	// Extract the RPM to rootDir
-	if err := installPackage(ctx, rpmPath, rootDir, opts); err != nil {
+	var tx transaction
+
+	if err := installPackage(ctx, rpmPath, rootDir, opts, &tx); err != nil {
 		return errors.Wrap(err, errors.ErrTypeBuild, "failed to install RPM").
 			WithOperation("InstallFile").
 			WithContext("path", rpmPath)
 	}
 
+	tx.fire(ctx, rootDir, opts)
+
 	logger.Info(i18n.T("logger.dnfinstall.info.installed_rpm_file"), "path", rpmPath)
 
 	return nil
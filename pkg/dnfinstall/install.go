@@ -66,16 +66,21 @@ func downloadAndInstall(
 	}
 
 	// Install packages in dependency order.
+	var tx transaction
+
 	for _, pkg := range resolved {
 		rpmPath := rpmPaths[pkg.Name]
 
-		if err := installPackage(ctx, rpmPath, rootDir, opts); err != nil {
+		if err := installPackage(ctx, rpmPath, rootDir, opts, &tx); err != nil {
 			return errors.Wrap(err, errors.ErrTypeBuild, "failed to install package").
 				WithOperation("downloadAndInstall").
 				WithContext("package", pkg.Name)
 		}
 	}
 
+	// Fire the triggers once, with everything the transaction installed.
+	tx.fire(ctx, rootDir, opts)
+
 	return nil
 }
 
@@ -99,9 +104,11 @@ func downloadRPM(ctx context.Context, _ *dnfcache.Cache, pkg *dnfcache.PackageIn
 
 // installPackage extracts a single RPM file to rootDir.
 // Implements the full install sequence: verify → %pretrans → %pre → extract → %post → yapdb → rpmdb → %posttrans.
+// The package is recorded in tx, whose triggers the caller fires at the end
+// of the transaction.
 //
 //nolint:gocyclo,cyclop // sequential install phases each guarded by a strict/non-strict scriptlet branch
-func installPackage(ctx context.Context, rpmPath, rootDir string, opts Options) (retErr error) {
+func installPackage(ctx context.Context, rpmPath, rootDir string, opts Options, tx *transaction) (retErr error) {
 	// Acquire install lock to prevent concurrent modifications.
 	release, err := acquireLock(ctx, rootDir)
 	if err != nil {
@@ -184,6 +191,9 @@ func installPackage(ctx context.Context, rpmPath, rootDir string, opts Options)
 			"package", pkgName, "error", err.Error())
 	}
 
+	applyAlternatives(rpm, rootDir, opts)
+	tx.add(rpm, entry, rootDir)
+
 	logger.Info(i18n.T("logger.dnfinstall.info.installed_rpm_package"),
 		"path", filepath.Base(rpmPath), "files", len(entry.Files))
 
@@ -11,6 +11,7 @@ import (
 
 	rpmutils "github.com/sassoftware/go-rpmutils"
 
+	"github.com/M0Rf30/yap/v2/pkg/alternatives"
 	"github.com/M0Rf30/yap/v2/pkg/errors"
 	"github.com/M0Rf30/yap/v2/pkg/i18n"
 	"github.com/M0Rf30/yap/v2/pkg/logger"
@@ -145,8 +146,6 @@ func hasEnvKey(env []string, key string) bool {
 // runScriptlet executes a single scriptlet from the RPM header.
 // Returns nil if no scriptlet body for the given kind, or if SkipScriptlets is set.
 // arg = "1" for fresh install (always 1 for v1 — no upgrades).
-//
-//nolint:gocyclo,cyclop // scriptlet orchestration (env, interpreter, chroot, output) is inherently branchy
 func runScriptlet(
 	ctx context.Context,
 	kind scriptletKind,
@@ -168,19 +167,35 @@ func runScriptlet(
 		return nil
 	}
 
-	// Read interpreter from header. PROG tags are commonly stored as
-	// STRING_ARRAY (e.g. ["<lua>"], ["/sbin/ldconfig"], ["/bin/sh", "-e"]),
-	// so use GetStrings and take the first non-empty entry.
+	// PROG tags are commonly stored as STRING_ARRAY (e.g. ["<lua>"],
+	// ["/sbin/ldconfig"], ["/bin/sh", "-e"]).
+	progs, _ := rpm.Header.GetStrings(tags.progTag)
+
+	return execScriptlet(ctx, rpm, tags.kindName, body, progs, "", rootDir)
+}
+
+// execScriptlet runs a scriptlet body of rpm with the interpreter named by
+// its PROG tag entries, /bin/sh when there are none. The body is fed on
+// stdin, unless input is given: then input goes to stdin, as rpm feeds the
+// matching paths to file triggers, and the body is passed with -c.
+//
+//nolint:gocyclo,cyclop // scriptlet orchestration (env, interpreter, chroot, output) is inherently branchy
+func execScriptlet(
+	ctx context.Context,
+	rpm *rpmutils.Rpm,
+	kindName, body string,
+	progs []string,
+	input, rootDir string,
+) error {
+	// Take the interpreter from the first non-empty PROG entry.
 	interpreter := interpSh
 
 	var interpreterArgs []string
 
-	if progs, err := rpm.Header.GetStrings(tags.progTag); err == nil && len(progs) > 0 {
-		if progs[0] != "" {
-			interpreter = progs[0]
-			if len(progs) > 1 {
-				interpreterArgs = progs[1:]
-			}
+	if len(progs) > 0 && progs[0] != "" {
+		interpreter = progs[0]
+		if len(progs) > 1 {
+			interpreterArgs = progs[1:]
 		}
 	}
 
@@ -192,7 +207,7 @@ func runScriptlet(
 	// so also heuristically detect Lua syntax in the body when the
 	// declared interpreter is the default /bin/sh fallback.
 	if interpreter == "<lua>" || strings.HasPrefix(interpreter, "<lua>") || looksLikeLua(body) {
-		logger.Warn(i18n.T("logger.dnfinstall.warn.skipping_lua_scriptlet"), "kind", tags.kindName,
+		logger.Warn(i18n.T("logger.dnfinstall.warn.skipping_lua_scriptlet"), "kind", kindName,
 			"package", pkgName,
 			"interpreter", interpreter)
 
@@ -200,7 +215,7 @@ func runScriptlet(
 	}
 
 	logger.Debug(i18n.T("logger.dnfinstall.debug.running_rpm_scriptlet"), "package", pkgName,
-		"kind", tags.kindName,
+		"kind", kindName,
 		"interpreter", interpreter)
 
 	// Create a context with timeout.
@@ -218,8 +233,14 @@ func runScriptlet(
 		args = []string{"-e"}
 	}
 
+	stdin := body
+	if input != "" {
+		args = append(args, "-c", body)
+		stdin = input
+	}
+
 	cmd := exec.CommandContext(ctx, interpreter, args...)
-	cmd.Stdin = strings.NewReader(body)
+	cmd.Stdin = strings.NewReader(stdin)
 
 	// Set up environment.
 	cmd.Env = append(filterScriptletEnv(),
@@ -245,7 +266,7 @@ func runScriptlet(
 			cmd.Dir = "/"
 		} else {
 			// Not root: log debug and run anyway (container build scenario).
-			logger.Debug(i18n.T("logger.dnfinstall.debug.skipping_chroot_not_running"), "kind", tags.kindName,
+			logger.Debug(i18n.T("logger.dnfinstall.debug.skipping_chroot_not_running"), "kind", kindName,
 				"package", pkgName,
 				"rootDir", rootDir)
 			cmd.Dir = rootDir
@@ -259,11 +280,11 @@ func runScriptlet(
 	cmd.Stderr = &stderr
 
 	// Run the scriptlet.
-	err = cmd.Run()
+	err := cmd.Run()
 
 	// Log output.
 	if stdout.Len() > 0 {
-		logger.Debug(i18n.T("logger.dnfinstall.debug.scriptlet_stdout"), "kind", tags.kindName,
+		logger.Debug(i18n.T("logger.dnfinstall.debug.scriptlet_stdout"), "kind", kindName,
 			"package", pkgName,
 			"output", strings.TrimRight(stdout.String(), "\n"))
 	}
@@ -275,7 +296,7 @@ func runScriptlet(
 		}
 
 		level("scriptlet stderr",
-			"kind", tags.kindName,
+			"kind", kindName,
 			"package", pkgName,
 			"output", strings.TrimRight(stderr.String(), "\n"))
 	}
@@ -284,10 +305,33 @@ func runScriptlet(
 	if err != nil {
 		return errors.Wrap(err, errors.ErrTypeBuild, "scriptlet execution failed").
 			WithOperation("runScriptlet").
-			WithContext("kind", tags.kindName).
+			WithContext("kind", kindName).
 			WithContext("package", pkgName).
 			WithContext("interpreter", interpreter)
 	}
 
 	return nil
 }
+
+// applyAlternatives sets up the alternatives the %post scriptlet registers
+// with update-alternatives when it did not run, or ran in a root without
+// update-alternatives, as in minimal build roots. Failures are logged,
+// like %post failures.
+func applyAlternatives(rpm *rpmutils.Rpm, rootDir string, opts Options) {
+	body, err := rpm.Header.GetString(rpmutils.POSTIN)
+	if err != nil {
+		return
+	}
+
+	alts := alternatives.FromScript(body)
+	if len(alts) == 0 || (!opts.SkipScriptlets && alternatives.Available(rootDir)) {
+		return
+	}
+
+	if err := alternatives.Apply(rootDir, alts); err != nil {
+		pkgName, _ := rpm.Header.GetString(rpmutils.NAME)
+
+		logger.Warn(i18n.T("logger.dnfinstall.warn.failed_to_apply_alternatives"),
+			"package", pkgName, "error", err)
+	}
+}
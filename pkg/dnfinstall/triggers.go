@@ -0,0 +1,171 @@
+package dnfinstall
+
+import (
+	"context"
+	"strings"
+
+	rpmutils "github.com/sassoftware/go-rpmutils"
+
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/logger"
+	"github.com/M0Rf30/yap/v2/pkg/triggers"
+)
+
+// rpmsenseTriggerIn is RPMSENSE_TRIGGERIN, the trigger condition flag of
+// %triggerin and %transfiletriggerin.
+const rpmsenseTriggerIn = 1 << 16
+
+// triggerTags are the header tags describing one kind of trigger: the
+// scripts with their interpreters, and the conditions with the index of
+// the script each one runs.
+type triggerTags struct {
+	kindName string
+	scripts  int
+	progs    int
+	names    int
+	index    int
+	flags    int
+}
+
+var (
+	// packageTriggerTags describe %triggerin scripts, run when the named
+	// packages are installed.
+	packageTriggerTags = triggerTags{
+		kindName: "triggerin",
+		scripts:  1065, // RPMTAG_TRIGGERSCRIPTS
+		progs:    1092, // RPMTAG_TRIGGERSCRIPTPROG
+		names:    1066, // RPMTAG_TRIGGERNAME
+		index:    1069, // RPMTAG_TRIGGERINDEX
+		flags:    1068, // RPMTAG_TRIGGERFLAGS
+	}
+	// fileTriggerTags describe %transfiletriggerin scripts, run once per
+	// transaction with the installed paths under their prefixes on stdin.
+	fileTriggerTags = triggerTags{
+		kindName: "transfiletriggerin",
+		scripts:  5073, // RPMTAG_TRANSFILETRIGGERSCRIPTS
+		progs:    5074, // RPMTAG_TRANSFILETRIGGERSCRIPTPROG
+		names:    5076, // RPMTAG_TRANSFILETRIGGERNAME
+		index:    5077, // RPMTAG_TRANSFILETRIGGERINDEX
+		flags:    5079, // RPMTAG_TRANSFILETRIGGERFLAGS
+	}
+)
+
+// rpmTrigger is a trigger script of an installed package with the targets
+// activating it: package names or path prefixes.
+type rpmTrigger struct {
+	rpm     *rpmutils.Rpm
+	tags    triggerTags
+	body    string
+	prog    string
+	targets []string
+}
+
+// transaction collects what an install transaction puts in place, so
+// that the triggers of its packages fire once when it is over rather
+// than once per package.
+type transaction struct {
+	triggers.Transaction
+
+	pending []rpmTrigger
+}
+
+// add records an installed package: its files, its name, which activates
+// the %triggerin scripts naming it, and its own trigger scripts.
+func (tx *transaction) add(rpm *rpmutils.Rpm, entry *rpmEntry, rootDir string) {
+	for _, f := range entry.Files {
+		if !f.IsDir {
+			tx.Install(strings.TrimPrefix(f.Path, rootDir))
+		}
+	}
+
+	if name, err := rpm.Header.GetString(rpmutils.NAME); err == nil {
+		tx.Activate(name)
+	}
+
+	tx.pending = append(tx.pending, readTriggers(rpm, packageTriggerTags)...)
+	tx.pending = append(tx.pending, readTriggers(rpm, fileTriggerTags)...)
+}
+
+// readTriggers returns the trigger scripts of one kind in the header of
+// rpm, with the install conditions of each.
+func readTriggers(rpm *rpmutils.Rpm, tags triggerTags) []rpmTrigger {
+	scripts, err := rpm.Header.GetStrings(tags.scripts)
+	if err != nil || len(scripts) == 0 {
+		return nil
+	}
+
+	progs, _ := rpm.Header.GetStrings(tags.progs)
+	names, _ := rpm.Header.GetStrings(tags.names)
+	index, _ := rpm.Header.GetInts(tags.index)
+	flags, _ := rpm.Header.GetInts(tags.flags)
+
+	found := make([]rpmTrigger, len(scripts))
+	for i, script := range scripts {
+		found[i] = rpmTrigger{rpm: rpm, tags: tags, body: script}
+		if i < len(progs) {
+			found[i].prog = progs[i]
+		}
+	}
+
+	for i, name := range names {
+		if i >= len(index) || i >= len(flags) || flags[i]&rpmsenseTriggerIn == 0 ||
+			index[i] < 0 || index[i] >= len(found) {
+			continue
+		}
+
+		found[index[i]].targets = append(found[index[i]].targets, name)
+	}
+
+	var triggerIn []rpmTrigger
+
+	for _, trigger := range found {
+		if len(trigger.targets) > 0 {
+			triggerIn = append(triggerIn, trigger)
+		}
+	}
+
+	return triggerIn
+}
+
+// fire runs each trigger script the transaction activated, once. File
+// triggers get the installed paths under their prefixes on stdin. Like
+// %post, a failing trigger is logged rather than aborting the install.
+func (tx *transaction) fire(ctx context.Context, rootDir string, opts Options) {
+	if opts.SkipScriptlets {
+		return
+	}
+
+	for _, trigger := range tx.pending {
+		var (
+			activated bool
+			paths     []string
+		)
+
+		for _, target := range trigger.targets {
+			if trigger.tags == fileTriggerTags {
+				paths = append(paths, tx.Paths(target)...)
+				activated = activated || len(paths) > 0
+			} else {
+				activated = activated || tx.Activated(target)
+			}
+		}
+
+		if !activated {
+			continue
+		}
+
+		input := ""
+		if len(paths) > 0 {
+			input = strings.Join(paths, "\n") + "\n"
+		}
+
+		err := execScriptlet(ctx, trigger.rpm, trigger.tags.kindName, trigger.body,
+			[]string{trigger.prog}, input, rootDir)
+		if err != nil {
+			pkgName, _ := trigger.rpm.Header.GetString(rpmutils.NAME)
+
+			logger.Warn(i18n.T("logger.dnfinstall.warn.trigger_failed_continuing"),
+				"package", pkgName, "kind", trigger.tags.kindName, "error", err.Error())
+		}
+	}
+}
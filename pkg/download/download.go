@@ -179,6 +179,58 @@ func WithResumeContext(
 		"WithResumeContext", "logger.download.info.retrying_download_2")
 }
 
+// WithMirrorsContext downloads destination from the first of uris that
+// succeeds and returns the URI it was fetched from. Every mirror gets the
+// full retry budget of WithResumeContext; the next one is tried only when
+// the failure is one a different server can fix (see shouldTryNextMirror).
+// Callers still verify the checksum of the file, whichever mirror served it.
+func WithMirrorsContext(
+	destination string,
+	uris []string,
+	maxRetries int,
+	packageName, sourceName string,
+	writer io.Writer,
+) (string, error) {
+	var lastErr error
+
+	for index, uri := range uris {
+		if index > 0 {
+			logger.Warn(i18n.T("logger.download.warn.trying_next_mirror"),
+				"failed", uris[index-1],
+				"next", uri,
+				"error", lastErr)
+
+			// The partial file belongs to another server: never resume it.
+			_ = os.Remove(destination)
+		}
+
+		lastErr = WithResumeContext(destination, uri, maxRetries, packageName, sourceName, writer)
+		if lastErr == nil {
+			return uri, nil
+		}
+
+		if !shouldTryNextMirror(lastErr) {
+			break
+		}
+	}
+
+	return "", lastErr
+}
+
+// shouldTryNextMirror reports whether a download failure may not happen on
+// another mirror: transient errors classified by IsRetryableGrabError and
+// any HTTP error status, since a 404 or 403 usually means a mirror that is
+// out of sync rather than a wrong URL. Cancellation and local errors stop
+// the failover.
+func shouldTryNextMirror(err error) bool {
+	var statusErr grab.StatusCodeError
+	if stderrors.As(err, &statusErr) {
+		return true
+	}
+
+	return IsRetryableGrabError(err)
+}
+
 // retryDownload is the attempt loop shared by WithResume and
 // WithResumeContext: exponential backoff between attempts, retry only on
 // transient errors, and partial-file cleanup when the partial cannot be
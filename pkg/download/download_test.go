@@ -597,3 +597,86 @@ func TestRetryDownloadHonorsMaxRetries(t *testing.T) {
 		})
 	}
 }
+
+// TestWithMirrorsContextFailsOver verifies that a mirror answering 404 is
+// skipped and the URI of the mirror that served the file is returned.
+func TestWithMirrorsContextFailsOver(t *testing.T) {
+	var staleHits atomic.Int32
+
+	stale := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		staleHits.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer stale.Close()
+
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("mirrored content"))
+	}))
+	defer good.Close()
+
+	destination := filepath.Join(t.TempDir(), "out.txt")
+
+	uri, err := WithMirrorsContext(destination, []string{stale.URL, good.URL}, 2, "", "", nil)
+	if err != nil {
+		t.Fatalf("WithMirrorsContext failed: %v", err)
+	}
+
+	if uri != good.URL {
+		t.Errorf("expected mirror %q, got %q", good.URL, uri)
+	}
+
+	if got := staleHits.Load(); got != 1 {
+		t.Errorf("expected 1 attempt on the stale mirror, got %d", got)
+	}
+
+	data, err := os.ReadFile(destination) //nolint:gosec
+	if err != nil {
+		t.Fatalf("read destination: %v", err)
+	}
+
+	if string(data) != "mirrored content" {
+		t.Errorf("unexpected content: %q", data)
+	}
+}
+
+// TestWithMirrorsContextAllFail verifies that the error of the last mirror
+// is returned when none of them serves the file.
+func TestWithMirrorsContextAllFail(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	destination := filepath.Join(t.TempDir(), "out.txt")
+
+	uri, err := WithMirrorsContext(destination, []string{server.URL + "/a", server.URL + "/b"}, 0, "", "", nil)
+	if err == nil {
+		t.Fatal("expected error when every mirror fails")
+	}
+
+	if uri != "" {
+		t.Errorf("expected no mirror, got %q", uri)
+	}
+}
+
+func TestShouldTryNextMirror(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "not found", err: grab.StatusCodeError(http.StatusNotFound), want: true},
+		{name: "server error", err: grab.StatusCodeError(http.StatusBadGateway), want: true},
+		{name: "connection reset", err: errors.New("read: connection reset by peer"), want: true},
+		{name: "cancelled", err: context.Canceled, want: false},
+		{name: "local error", err: errors.New("permission denied"), want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := shouldTryNextMirror(test.err); got != test.want {
+				t.Errorf("shouldTryNextMirror(%v) = %v, want %v", test.err, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,375 @@
+package files
+
+import (
+	"archive/tar"
+	"encoding/binary"
+	"io/fs"
+	"os"
+	"os/user"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+)
+
+const (
+	rootName = "root"
+
+	// XattrCapability is the extended attribute holding file capabilities.
+	XattrCapability = "security.capability"
+	xattrUserPrefix = "user."
+	paxXattrPrefix  = "SCHILY.xattr."
+
+	vfsCapRevisionMask = 0xff000000
+	vfsCapRevision1    = 0x01000000
+	vfsCapRevision2    = 0x02000000
+	vfsCapRevision3    = 0x03000000
+	vfsCapEffective    = 0x000001
+)
+
+// capabilityNames are the libcap names of the Linux capabilities, indexed
+// by capability number.
+var capabilityNames = []string{
+	"cap_chown", "cap_dac_override", "cap_dac_read_search", "cap_fowner",
+	"cap_fsetid", "cap_kill", "cap_setgid", "cap_setuid", "cap_setpcap",
+	"cap_linux_immutable", "cap_net_bind_service", "cap_net_broadcast",
+	"cap_net_admin", "cap_net_raw", "cap_ipc_lock", "cap_ipc_owner",
+	"cap_sys_module", "cap_sys_rawio", "cap_sys_chroot", "cap_sys_ptrace",
+	"cap_sys_pacct", "cap_sys_admin", "cap_sys_boot", "cap_sys_nice",
+	"cap_sys_resource", "cap_sys_time", "cap_sys_tty_config", "cap_mknod",
+	"cap_lease", "cap_audit_write", "cap_audit_control", "cap_setfcap",
+	"cap_mac_override", "cap_mac_admin", "cap_syslog", "cap_wake_alarm",
+	"cap_block_suspend", "cap_audit_read", "cap_perfmon", "cap_bpf",
+	"cap_checkpoint_restore",
+}
+
+// Attributes are the ownership, extended attributes and file capabilities
+// recorded for an entry of a package payload.
+type Attributes struct {
+	UID   int
+	GID   int
+	Owner string
+	Group string
+	// Xattrs maps the user.* and security.capability extended attributes
+	// to their raw values. File capabilities are normalized to revision 2,
+	// dropping the namespace root ID a user-namespaced setcap records.
+	Xattrs map[string]string
+	// Capabilities is the libcap text form of the file capabilities, e.g.
+	// "cap_net_bind_service=ep", as RPM stores them.
+	Capabilities string
+}
+
+// OwnerRule assigns an owner and group to the payload paths matching a
+// shell glob pattern, for files whose ownership cannot be set by package()
+// inside the user-namespace fakeroot.
+type OwnerRule struct {
+	Pattern string
+	Owner   string
+	Group   string
+}
+
+// ParseOwnerRules parses fileowners=() entries of the form
+// "owner[:group] pattern", e.g. "daemon:daemon /var/lib/foo/*". The group
+// defaults to the owner.
+func ParseOwnerRules(specs []string) ([]OwnerRule, error) {
+	rules := make([]OwnerRule, 0, len(specs))
+
+	for _, spec := range specs {
+		fields := strings.Fields(spec)
+		if len(fields) != 2 || !strings.HasPrefix(fields[1], "/") {
+			return nil, errors.New(errors.ErrTypeValidation,
+				i18n.T("errors.files.invalid_owner_rule")).
+				WithOperation("ParseOwnerRules").
+				WithContext("rule", spec)
+		}
+
+		owner, group, _ := strings.Cut(fields[0], ":")
+		if group == "" {
+			group = owner
+		}
+
+		if owner == "" {
+			return nil, errors.New(errors.ErrTypeValidation,
+				i18n.T("errors.files.invalid_owner_rule")).
+				WithOperation("ParseOwnerRules").
+				WithContext("rule", spec)
+		}
+
+		if _, err := path.Match(fields[1], "/"); err != nil {
+			return nil, errors.Wrap(err, errors.ErrTypeValidation,
+				i18n.T("errors.files.invalid_owner_rule")).
+				WithOperation("ParseOwnerRules").
+				WithContext("rule", spec)
+		}
+
+		rules = append(rules, OwnerRule{Pattern: fields[1], Owner: owner, Group: group})
+	}
+
+	return rules, nil
+}
+
+// ReadAttributes returns the attributes of the file at filePath, packaged
+// as destination. Files owned by the build user, which is what chown-free
+// files are inside the fakeroot, and files whose owner has no name on the
+// build host are recorded as root; the last rule matching destination
+// overrides the ownership.
+func ReadAttributes(
+	filePath, destination string, info fs.FileInfo, rules []OwnerRule,
+) (Attributes, error) {
+	attrs := Attributes{Owner: rootName, Group: rootName}
+
+	if uid, gid, ok := fileOwner(info); ok {
+		if name, ok := lookupUser(uid); ok && !isBuildUser(uid, "SUDO_UID", os.Getuid()) {
+			attrs.UID, attrs.Owner = uid, name
+		}
+
+		if name, ok := lookupGroup(gid); ok && !isBuildUser(gid, "SUDO_GID", os.Getgid()) {
+			attrs.GID, attrs.Group = gid, name
+		}
+	}
+
+	for _, rule := range rules {
+		if matched, _ := path.Match(rule.Pattern, destination); matched {
+			attrs.setOwner(rule.Owner, rule.Group)
+		}
+	}
+
+	xattrs, err := readXattrs(filePath)
+	if err != nil {
+		return Attributes{}, err
+	}
+
+	for name, value := range xattrs {
+		if name == XattrCapability {
+			caps, ok := parseCapabilities(value)
+			if !ok {
+				continue
+			}
+
+			value = caps.encode()
+			attrs.Capabilities = caps.String()
+		} else if !strings.HasPrefix(name, xattrUserPrefix) {
+			continue
+		}
+
+		if attrs.Xattrs == nil {
+			attrs.Xattrs = make(map[string]string)
+		}
+
+		attrs.Xattrs[name] = value
+	}
+
+	return attrs, nil
+}
+
+// ApplyToHeader records the ownership and extended attributes in hdr.
+// Extended attributes need PAX records, so hdr is switched to the PAX
+// format when there are any.
+func (a Attributes) ApplyToHeader(hdr *tar.Header) {
+	hdr.Uid, hdr.Gid = a.UID, a.GID
+	hdr.Uname, hdr.Gname = a.Owner, a.Group
+
+	if len(a.Xattrs) == 0 {
+		return
+	}
+
+	if hdr.PAXRecords == nil {
+		hdr.PAXRecords = make(map[string]string)
+	}
+
+	for name, value := range a.Xattrs {
+		hdr.PAXRecords[paxXattrPrefix+name] = value
+	}
+
+	hdr.Format = tar.FormatPAX
+}
+
+// setOwner sets the owner and group names, resolving their IDs on the
+// build host. Names unknown to the host keep ID 0: dpkg, rpm, apk and
+// pacman resolve the names on the target system.
+func (a *Attributes) setOwner(owner, group string) {
+	a.Owner, a.Group = owner, group
+	a.UID, a.GID = 0, 0
+
+	if u, err := user.Lookup(owner); err == nil {
+		a.UID, _ = strconv.Atoi(u.Uid)
+	}
+
+	if g, err := user.LookupGroup(group); err == nil {
+		a.GID, _ = strconv.Atoi(g.Gid)
+	}
+}
+
+// isBuildUser reports whether id is the unprivileged user running yap,
+// directly or through sudo (env names the SUDO_UID/SUDO_GID variable).
+func isBuildUser(id int, env string, current int) bool {
+	if current != 0 && id == current {
+		return true
+	}
+
+	sudoID, err := strconv.Atoi(os.Getenv(env))
+
+	return err == nil && sudoID != 0 && id == sudoID
+}
+
+var (
+	idNamesMu  sync.Mutex
+	userNames  = make(map[int]string)
+	groupNames = make(map[int]string)
+)
+
+// lookupUser returns the name of uid on the build host.
+func lookupUser(uid int) (string, bool) {
+	return lookupName(userNames, uid, func(id string) (string, error) {
+		u, err := user.LookupId(id)
+		if err != nil {
+			return "", err
+		}
+
+		return u.Username, nil
+	})
+}
+
+// lookupGroup returns the name of gid on the build host.
+func lookupGroup(gid int) (string, bool) {
+	return lookupName(groupNames, gid, func(id string) (string, error) {
+		g, err := user.LookupGroupId(id)
+		if err != nil {
+			return "", err
+		}
+
+		return g.Name, nil
+	})
+}
+
+// lookupName resolves id through lookup, caching the result in names
+// (an empty name caches a failed lookup).
+func lookupName(names map[int]string, id int, lookup func(string) (string, error)) (string, bool) {
+	if id == 0 {
+		return rootName, true
+	}
+
+	idNamesMu.Lock()
+	defer idNamesMu.Unlock()
+
+	name, ok := names[id]
+	if !ok {
+		name, _ = lookup(strconv.Itoa(id))
+		names[id] = name
+	}
+
+	return name, name != ""
+}
+
+// fileCapabilities is a decoded security.capability value.
+type fileCapabilities struct {
+	permitted   uint64
+	inheritable uint64
+	effective   bool
+}
+
+// parseCapabilities decodes a vfs_cap_data value of any revision.
+func parseCapabilities(value string) (fileCapabilities, bool) {
+	data := []byte(value)
+	if len(data) < 4 {
+		return fileCapabilities{}, false
+	}
+
+	magic := binary.LittleEndian.Uint32(data)
+
+	words := 2
+
+	switch magic & vfsCapRevisionMask {
+	case vfsCapRevision1:
+		words = 1
+	case vfsCapRevision2, vfsCapRevision3:
+	default:
+		return fileCapabilities{}, false
+	}
+
+	if len(data) < 4+8*words {
+		return fileCapabilities{}, false
+	}
+
+	caps := fileCapabilities{effective: magic&vfsCapEffective != 0}
+
+	for i := range words {
+		offset := 4 + 8*i
+		caps.permitted |= uint64(binary.LittleEndian.Uint32(data[offset:])) << (32 * i)
+		caps.inheritable |= uint64(binary.LittleEndian.Uint32(data[offset+4:])) << (32 * i)
+	}
+
+	return caps, true
+}
+
+// encode returns c as a revision 2 vfs_cap_data value.
+func (c fileCapabilities) encode() string {
+	data := make([]byte, 20)
+
+	magic := uint32(vfsCapRevision2)
+	if c.effective {
+		magic |= vfsCapEffective
+	}
+
+	binary.LittleEndian.PutUint32(data, magic)
+
+	for i := range 2 {
+		offset := 4 + 8*i
+		binary.LittleEndian.PutUint32(data[offset:], uint32(c.permitted>>(32*i)))
+		binary.LittleEndian.PutUint32(data[offset+4:], uint32(c.inheritable>>(32*i)))
+	}
+
+	return string(data)
+}
+
+// String returns c in the libcap text form, grouping capabilities that
+// share the same flags, e.g. "cap_chown,cap_kill=ep cap_net_raw=p".
+func (c fileCapabilities) String() string {
+	var (
+		order  []string
+		groups = make(map[string][]string)
+	)
+
+	for bit := range 64 {
+		mask := uint64(1) << bit
+		if (c.permitted|c.inheritable)&mask == 0 {
+			continue
+		}
+
+		var flags strings.Builder
+
+		if c.effective {
+			flags.WriteByte('e')
+		}
+
+		if c.inheritable&mask != 0 {
+			flags.WriteByte('i')
+		}
+
+		if c.permitted&mask != 0 {
+			flags.WriteByte('p')
+		}
+
+		name := "cap_" + strconv.Itoa(bit)
+		if bit < len(capabilityNames) {
+			name = capabilityNames[bit]
+		}
+
+		key := flags.String()
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+
+		groups[key] = append(groups[key], name)
+	}
+
+	clauses := make([]string, 0, len(order))
+	for _, flags := range order {
+		clauses = append(clauses, strings.Join(groups[flags], ",")+"="+flags)
+	}
+
+	return strings.Join(clauses, " ")
+}
@@ -0,0 +1,98 @@
+//go:build linux
+
+package files
+
+import (
+	stderrors "errors"
+	"io/fs"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// fileOwner returns the numeric owner and group of info.
+func fileOwner(info fs.FileInfo) (uid, gid int, ok bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+
+	return int(stat.Uid), int(stat.Gid), true
+}
+
+// readXattrs returns the extended attributes of path, without following
+// symlinks. Filesystems without xattr support yield none.
+func readXattrs(path string) (map[string]string, error) {
+	size, err := unix.Llistxattr(path, nil)
+	if err != nil || size == 0 {
+		return nil, ignoreUnsupported(err)
+	}
+
+	buf := make([]byte, size)
+
+	size, err = unix.Llistxattr(path, buf)
+	if err != nil {
+		return nil, ignoreUnsupported(err)
+	}
+
+	xattrs := make(map[string]string)
+
+	for name := range strings.SplitSeq(strings.TrimRight(string(buf[:size]), "\x00"), "\x00") {
+		if name == "" {
+			continue
+		}
+
+		valueSize, err := unix.Lgetxattr(path, name, nil)
+		if err != nil {
+			if stderrors.Is(err, unix.ENODATA) {
+				continue
+			}
+
+			return nil, err
+		}
+
+		value := make([]byte, valueSize)
+
+		valueSize, err = unix.Lgetxattr(path, name, value)
+		if err != nil {
+			return nil, err
+		}
+
+		xattrs[name] = string(value[:valueSize])
+	}
+
+	return xattrs, nil
+}
+
+// ignoreUnsupported drops the errors of filesystems and files that do not
+// support extended attributes.
+func ignoreUnsupported(err error) error {
+	if stderrors.Is(err, unix.ENOTSUP) || stderrors.Is(err, unix.EPERM) {
+		return nil
+	}
+
+	return err
+}
+
+// KeepCapabilities saves the file capabilities of path and returns a
+// function restoring them, for tools such as strip that replace the file
+// and drop its extended attributes.
+func KeepCapabilities(path string) (restore func() error) {
+	value := make([]byte, 64)
+
+	size, err := unix.Lgetxattr(path, XattrCapability, value)
+	if err != nil {
+		return func() error { return nil }
+	}
+
+	value = value[:size]
+
+	return func() error {
+		if _, err := unix.Lgetxattr(path, XattrCapability, nil); err == nil {
+			return nil
+		}
+
+		return unix.Lsetxattr(path, XattrCapability, value, 0)
+	}
+}
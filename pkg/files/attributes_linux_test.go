@@ -0,0 +1,45 @@
+//go:build linux
+
+package files
+
+import (
+	stderrors "errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestReadAttributesXattrs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "foo")
+	if err := os.WriteFile(path, []byte("foo"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := unix.Lsetxattr(path, "user.yap.test", []byte("value"), 0); err != nil {
+		if stderrors.Is(err, unix.ENOTSUP) || stderrors.Is(err, unix.EPERM) {
+			t.Skipf("user xattrs not supported: %v", err)
+		}
+
+		t.Fatal(err)
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attrs, err := ReadAttributes(path, "/foo", info, nil)
+	if err != nil {
+		t.Fatalf("ReadAttributes() error = %v", err)
+	}
+
+	if attrs.Xattrs["user.yap.test"] != "value" {
+		t.Errorf("Xattrs = %v, want user.yap.test=value", attrs.Xattrs)
+	}
+
+	if attrs.Capabilities != "" {
+		t.Errorf("Capabilities = %q, want none", attrs.Capabilities)
+	}
+}
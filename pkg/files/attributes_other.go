@@ -0,0 +1,21 @@
+//go:build !linux
+
+package files
+
+import "io/fs"
+
+// fileOwner is unavailable outside Linux; every file is recorded as root.
+func fileOwner(_ fs.FileInfo) (uid, gid int, ok bool) {
+	return 0, 0, false
+}
+
+// readXattrs is unavailable outside Linux; no extended attributes are
+// recorded.
+func readXattrs(_ string) (map[string]string, error) {
+	return nil, nil //nolint:nilnil // no xattrs is not an error
+}
+
+// KeepCapabilities is a no-op outside Linux.
+func KeepCapabilities(_ string) (restore func() error) {
+	return func() error { return nil }
+}
@@ -0,0 +1,178 @@
+package files
+
+import (
+	"archive/tar"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// vfsCapData builds a security.capability value of the given revision.
+func vfsCapData(revision uint32, effective bool, permitted, inheritable uint64, rootID uint32) string {
+	magic := revision
+	if effective {
+		magic |= vfsCapEffective
+	}
+
+	data := binary.LittleEndian.AppendUint32(nil, magic)
+
+	words := 2
+	if revision == vfsCapRevision1 {
+		words = 1
+	}
+
+	for i := range words {
+		data = binary.LittleEndian.AppendUint32(data, uint32(permitted>>(32*i)))
+		data = binary.LittleEndian.AppendUint32(data, uint32(inheritable>>(32*i)))
+	}
+
+	if revision == vfsCapRevision3 {
+		data = binary.LittleEndian.AppendUint32(data, rootID)
+	}
+
+	return string(data)
+}
+
+func TestParseCapabilities(t *testing.T) {
+	const netBindService = 1 << 10
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+		ok    bool
+	}{
+		{
+			name:  "revision 2",
+			value: vfsCapData(vfsCapRevision2, true, netBindService, 0, 0),
+			want:  "cap_net_bind_service=ep",
+			ok:    true,
+		},
+		{
+			name:  "revision 3 from a user namespace",
+			value: vfsCapData(vfsCapRevision3, true, netBindService, 0, 1000),
+			want:  "cap_net_bind_service=ep",
+			ok:    true,
+		},
+		{
+			name:  "revision 1",
+			value: vfsCapData(vfsCapRevision1, false, 1<<0|1<<5, 1<<13, 0),
+			want:  "cap_chown,cap_kill=p cap_net_raw=i",
+			ok:    true,
+		},
+		{
+			name:  "high capability",
+			value: vfsCapData(vfsCapRevision2, false, 1<<39|1<<50, 0, 0),
+			want:  "cap_bpf,cap_50=p",
+			ok:    true,
+		},
+		{name: "truncated", value: "\x00\x00\x00\x02\x00", ok: false},
+		{name: "unknown revision", value: vfsCapData(0x04000000, false, 1, 0, 0), ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caps, ok := parseCapabilities(tt.value)
+			if ok != tt.ok {
+				t.Fatalf("parseCapabilities() ok = %v, want %v", ok, tt.ok)
+			}
+
+			if !ok {
+				return
+			}
+
+			if got := caps.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+
+			roundTrip, ok := parseCapabilities(caps.encode())
+			if !ok || roundTrip != caps {
+				t.Errorf("encode() does not round-trip: %+v, want %+v", roundTrip, caps)
+			}
+
+			if len(caps.encode()) != 20 {
+				t.Errorf("encode() length = %d, want 20", len(caps.encode()))
+			}
+		})
+	}
+}
+
+func TestParseOwnerRules(t *testing.T) {
+	rules, err := ParseOwnerRules([]string{"daemon:adm /var/log/foo", "nobody /srv/foo/*"})
+	if err != nil {
+		t.Fatalf("ParseOwnerRules() error = %v", err)
+	}
+
+	want := []OwnerRule{
+		{Pattern: "/var/log/foo", Owner: "daemon", Group: "adm"},
+		{Pattern: "/srv/foo/*", Owner: "nobody", Group: "nobody"},
+	}
+	if !reflect.DeepEqual(rules, want) {
+		t.Errorf("ParseOwnerRules() = %+v, want %+v", rules, want)
+	}
+
+	for _, spec := range []string{"daemon", "daemon var/log", ":adm /var/log", "daemon /var/[log", "a b c"} {
+		if _, err := ParseOwnerRules([]string{spec}); err == nil {
+			t.Errorf("ParseOwnerRules(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestReadAttributes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "foo")
+	if err := os.WriteFile(path, []byte("foo"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attrs, err := ReadAttributes(path, "/usr/bin/foo", info, nil)
+	if err != nil {
+		t.Fatalf("ReadAttributes() error = %v", err)
+	}
+
+	if attrs.Owner != rootName || attrs.Group != rootName || attrs.UID != 0 || attrs.GID != 0 {
+		t.Errorf("files of the build user must be recorded as root, got %+v", attrs)
+	}
+
+	rules := []OwnerRule{
+		{Pattern: "/usr/bin/*", Owner: "root", Group: "root"},
+		{Pattern: "/usr/bin/foo", Owner: "yap-test-missing", Group: "yap-test-missing"},
+	}
+
+	attrs, err = ReadAttributes(path, "/usr/bin/foo", info, rules)
+	if err != nil {
+		t.Fatalf("ReadAttributes() error = %v", err)
+	}
+
+	if attrs.Owner != "yap-test-missing" || attrs.Group != "yap-test-missing" || attrs.UID != 0 {
+		t.Errorf("the last matching rule must win, got %+v", attrs)
+	}
+}
+
+func TestAttributesApplyToHeader(t *testing.T) {
+	hdr := &tar.Header{Name: "usr/bin/foo", Format: tar.FormatGNU}
+
+	Attributes{UID: 2, GID: 4, Owner: "daemon", Group: "adm"}.ApplyToHeader(hdr)
+
+	if hdr.Uid != 2 || hdr.Gid != 4 || hdr.Uname != "daemon" || hdr.Gname != "adm" {
+		t.Errorf("ownership not applied: %+v", hdr)
+	}
+
+	if hdr.Format != tar.FormatGNU || hdr.PAXRecords != nil {
+		t.Errorf("headers without xattrs must keep their format, got %v", hdr.Format)
+	}
+
+	Attributes{Owner: rootName, Group: rootName, Xattrs: map[string]string{
+		XattrCapability: "caps",
+	}}.ApplyToHeader(hdr)
+
+	if hdr.Format != tar.FormatPAX || hdr.PAXRecords["SCHILY.xattr.security.capability"] != "caps" {
+		t.Errorf("xattrs must be PAX records, got %v %v", hdr.Format, hdr.PAXRecords)
+	}
+}
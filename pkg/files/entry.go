@@ -31,6 +31,9 @@ type Entry struct {
 	LinkTarget  string      // Target path for symlinks
 	SHA256      []byte      // SHA256 hash for regular files
 	IsBackup    bool        // Whether this file should be treated as a config backup
+	Flags       FileFlags   // Packaging flags (ghost, doc, license, noverify)
+
+	Attributes // Ownership, extended attributes and file capabilities
 }
 
 // IsRegularFile returns true if this entry represents a regular file.
@@ -48,6 +51,16 @@ func (e *Entry) IsSymlink() bool {
 	return e.Mode&os.ModeSymlink != 0
 }
 
+// IsGhost returns true if this entry is owned by the package but not shipped.
+func (e *Entry) IsGhost() bool {
+	return e.Flags&FlagGhost != 0
+}
+
+// IsNoVerify returns true if this entry is exempt from package verification.
+func (e *Entry) IsNoVerify() bool {
+	return e.Flags&FlagNoVerify != 0
+}
+
 // IsConfigFile returns true if this entry represents a configuration file.
 func (e *Entry) IsConfigFile() bool {
 	return e.Type == TypeConfig || e.Type == TypeConfigNoReplace
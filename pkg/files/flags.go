@@ -0,0 +1,70 @@
+package files
+
+import (
+	"path"
+	"strings"
+
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+)
+
+// FileFlags are the packaging attributes of an entry beyond its type,
+// declared with the ghost=(), docfiles=(), licensefiles=() and noverify=()
+// PKGBUILD arrays.
+type FileFlags uint8
+
+// File flags. They map onto the RPM %ghost, %doc, %license and %verify
+// attributes; the other formats get their closest equivalent.
+const (
+	// FlagGhost marks a file created at runtime: the package owns it but
+	// does not ship it.
+	FlagGhost FileFlags = 1 << iota
+	// FlagDoc marks documentation, skipped by rpm --excludedocs.
+	FlagDoc
+	// FlagLicense marks license texts, installed even with --excludedocs.
+	FlagLicense
+	// FlagNoVerify marks files whose content legitimately changes after
+	// installation, exempt from package verification.
+	FlagNoVerify
+)
+
+// FlagRule applies flags to the payload path Path and, when it is a
+// directory, to everything below it.
+type FlagRule struct {
+	Path  string
+	Flags FileFlags
+}
+
+// ParseFlagRules turns the entries of a file flag array into rules. Paths
+// are taken relative to the package root, with or without a leading "/",
+// and must be clean.
+func ParseFlagRules(flags FileFlags, paths []string) ([]FlagRule, error) {
+	rules := make([]FlagRule, 0, len(paths))
+
+	for _, entry := range paths {
+		dest := "/" + strings.TrimPrefix(entry, "/")
+		if dest == "/" || path.Clean(dest) != dest {
+			return nil, errors.New(errors.ErrTypeValidation,
+				i18n.T("errors.files.invalid_flag_path")).
+				WithOperation("ParseFlagRules").
+				WithContext("path", entry)
+		}
+
+		rules = append(rules, FlagRule{Path: dest, Flags: flags})
+	}
+
+	return rules, nil
+}
+
+// MatchFlags returns the flags the rules apply to destination.
+func MatchFlags(rules []FlagRule, destination string) FileFlags {
+	var flags FileFlags
+
+	for _, rule := range rules {
+		if destination == rule.Path || strings.HasPrefix(destination, rule.Path+"/") {
+			flags |= rule.Flags
+		}
+	}
+
+	return flags
+}
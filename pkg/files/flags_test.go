@@ -0,0 +1,45 @@
+package files
+
+import "testing"
+
+func TestParseFlagRules(t *testing.T) {
+	rules, err := ParseFlagRules(FlagGhost, []string{"run/foo.pid", "/var/log/foo"})
+	if err != nil {
+		t.Fatalf("ParseFlagRules failed: %v", err)
+	}
+
+	want := []FlagRule{{Path: "/run/foo.pid", Flags: FlagGhost}, {Path: "/var/log/foo", Flags: FlagGhost}}
+	if len(rules) != len(want) || rules[0] != want[0] || rules[1] != want[1] {
+		t.Errorf("ParseFlagRules = %+v, want %+v", rules, want)
+	}
+
+	for _, entry := range []string{"", "/", "usr/share/doc/", "usr/../etc/foo", "/usr//share"} {
+		if _, err := ParseFlagRules(FlagDoc, []string{entry}); err == nil {
+			t.Errorf("ParseFlagRules(%q) should fail", entry)
+		}
+	}
+}
+
+func TestMatchFlags(t *testing.T) {
+	rules := []FlagRule{
+		{Path: "/usr/share/doc/foo", Flags: FlagDoc},
+		{Path: "/usr/share/doc/foo/COPYING", Flags: FlagLicense},
+		{Path: "/var/log/foo.log", Flags: FlagGhost | FlagNoVerify},
+	}
+
+	tests := map[string]FileFlags{
+		"/usr/share/doc/foo":          FlagDoc,
+		"/usr/share/doc/foo/README":   FlagDoc,
+		"/usr/share/doc/foo/COPYING":  FlagDoc | FlagLicense,
+		"/usr/share/doc/foobar":       0,
+		"/var/log/foo.log":            FlagGhost | FlagNoVerify,
+		"/var/log/foo.log.1":          0,
+		"/usr/share/doc/foo/examples": FlagDoc,
+	}
+
+	for destination, want := range tests {
+		if got := MatchFlags(rules, destination); got != want {
+			t.Errorf("MatchFlags(%q) = %b, want %b", destination, got, want)
+		}
+	}
+}
@@ -17,9 +17,11 @@ import (
 
 // WalkOptions configures the behavior of directory walking.
 type WalkOptions struct {
-	SkipDotFiles bool     // Skip files starting with '.'
-	BackupFiles  []string // List of backup/config files
-	SkipPatterns []string // File patterns to skip
+	SkipDotFiles bool        // Skip files starting with '.'
+	BackupFiles  []string    // List of backup/config files
+	SkipPatterns []string    // File patterns to skip
+	Owners       []OwnerRule // Ownership overrides (fileowners=())
+	Flags        []FlagRule  // File flags (ghost=(), docfiles=(), licensefiles=(), noverify=())
 }
 
 // Walker provides unified directory walking functionality for all package managers.
@@ -100,6 +102,12 @@ func (w *Walker) createEntry(path string, dirEntry fs.DirEntry) (*Entry, error)
 		Size:        fileInfo.Size(),
 		ModTime:     fileInfo.ModTime(),
 		IsBackup:    w.isBackupFile(destination),
+		Flags:       MatchFlags(w.Options.Flags, destination),
+	}
+
+	entry.Attributes, err = ReadAttributes(path, destination, fileInfo, w.Options.Owners)
+	if err != nil {
+		return nil, err
 	}
 
 	// Determine file type and handle special cases
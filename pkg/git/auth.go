@@ -0,0 +1,71 @@
+package git
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+
+	"github.com/M0Rf30/yap/v2/pkg/constants"
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/files"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/logger"
+)
+
+// sshKeyNames are the private keys looked up in ~/.ssh, in the same order
+// OpenSSH tries its default identities.
+var sshKeyNames = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// sshUser returns the user of an ssh:// URI, defaulting to "git".
+func sshUser(sourceItemURI string) string {
+	sourceURL, err := url.Parse(sourceItemURI)
+	if err != nil || sourceURL.User == nil || sourceURL.User.Username() == "" {
+		return constants.Git
+	}
+
+	return sourceURL.User.Username()
+}
+
+// sshAuth returns the SSH authentication for user. The running ssh-agent
+// is preferred when SSH_AUTH_SOCK is set; otherwise the first default
+// private key found in ~/.ssh is loaded, decrypted with sshPassword.
+func sshAuth(user, sshPassword string) (transport.AuthMethod, error) {
+	if os.Getenv("SSH_AUTH_SOCK") != "" {
+		auth, err := ssh.NewSSHAgentAuth(user)
+		if err == nil {
+			return auth, nil
+		}
+
+		logger.Debug(i18n.T("logger.git.debug.ssh_agent_unavailable"), "error", err)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range sshKeyNames {
+		keyPath := filepath.Join(home, ".ssh", name)
+		if !files.Exists(keyPath) {
+			continue
+		}
+
+		publicKey, err := ssh.NewPublicKeysFromFile(user, keyPath, sshPassword)
+		if err != nil {
+			logger.Error(i18n.T("logger.git.error.failed_to_load_ssh"))
+			logger.Warn(i18n.T("logger.git.warn.try_to_use_an"))
+
+			return nil, err
+		}
+
+		return publicKey, nil
+	}
+
+	return nil, errors.New(errors.ErrTypeValidation,
+		i18n.T("errors.git.no_ssh_credentials")).
+		WithOperation("sshAuth").
+		WithContext("user", user)
+}
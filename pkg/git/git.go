@@ -2,16 +2,21 @@
 package git
 
 import (
+	"context"
+	stderrors "errors"
 	"fmt"
 	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	ggit "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
-	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"mvdan.cc/sh/v3/interp"
 
 	"github.com/M0Rf30/yap/v2/pkg/constants"
 	"github.com/M0Rf30/yap/v2/pkg/errors"
@@ -22,6 +27,11 @@ import (
 )
 
 // Clone clones a Git repository from the given sourceItemURI to the specified dloadFilePath.
+// ssh:// URIs authenticate through the running ssh-agent or a key in ~/.ssh,
+// and local repositories (bare or not), given as file:// URIs or absolute
+// paths, are copied in-process. When dloadFilePath already holds a clone,
+// only the new objects are fetched before checking out the requested
+// reference.
 //
 // Parameters:
 // - sourceItemURI: the URI of the Git repository to clone.
@@ -37,6 +47,13 @@ func Clone(dloadFilePath, sourceItemURI, sshPassword string,
 			i18n.T("errors.git.empty_download_path")).
 			WithOperation("Clone")
 	}
+
+	if localPath, ok := localRepoPath(sourceItemURI); ok {
+		logger.Info(i18n.T("logger.git.info.cloning"), "repo", sourceItemURI)
+
+		return cloneLocal(dloadFilePath, localPath, referenceName, commitHash)
+	}
+
 	// Start multiprinter for consistent output handling
 	_, err := shell.MultiPrinter.Start()
 	if err != nil {
@@ -55,6 +72,15 @@ func Clone(dloadFilePath, sourceItemURI, sshPassword string,
 		URL:      sourceItemURI,
 	}
 
+	if strings.HasPrefix(sourceItemURI, "ssh://") {
+		auth, err := sshAuth(sshUser(sourceItemURI), sshPassword)
+		if err != nil {
+			return err
+		}
+
+		cloneOptions.Auth = auth
+	}
+
 	// If a specific branch or tag is requested, set it as the reference to clone
 	if referenceName != "" {
 		cloneOptions.ReferenceName = referenceName
@@ -69,19 +95,16 @@ func Clone(dloadFilePath, sourceItemURI, sshPassword string,
 	logger.Info(i18n.T("logger.git.info.cloning"), "repo", sourceItemURI)
 
 	if files.Exists(dloadFilePath) {
-		return handleExistingRepo(dloadFilePath, referenceName, plainOpenOptions)
+		return handleExistingRepo(dloadFilePath, cloneOptions.Auth, referenceName, commitHash,
+			plainOpenOptions)
 	}
 
 	repo, err := ggit.PlainClone(dloadFilePath, false, cloneOptions)
-	if err != nil && strings.Contains(err.Error(), "authentication required") {
+	if err != nil && cloneOptions.Auth == nil && strings.Contains(err.Error(), "authentication required") {
 		sourceURL, _ := url.Parse(sourceItemURI)
-		sshKeyPath := os.Getenv("HOME") + "/.ssh/id_rsa"
 
-		publicKey, err := ssh.NewPublicKeysFromFile("git", sshKeyPath, sshPassword)
+		publicKey, err := sshAuth(constants.Git, sshPassword)
 		if err != nil {
-			logger.Error(i18n.T("logger.git.error.failed_to_load_ssh"))
-			logger.Warn(i18n.T("logger.git.warn.try_to_use_an"))
-
 			return err
 		}
 
@@ -110,6 +133,25 @@ func Clone(dloadFilePath, sourceItemURI, sshPassword string,
 	return nil
 }
 
+// localRepoPath returns the path of the repository on disk that
+// sourceItemURI points to: a file:// URI or an absolute path, as git itself
+// accepts for a clone.
+func localRepoPath(sourceItemURI string) (string, bool) {
+	if localPath, ok := strings.CutPrefix(sourceItemURI, "file://"); ok {
+		return localPath, true
+	}
+
+	if strings.Contains(sourceItemURI, "://") {
+		return "", false
+	}
+
+	if filepath.IsAbs(sourceItemURI) {
+		return sourceItemURI, true
+	}
+
+	return "", false
+}
+
 // ExtractFromBare creates a working copy from a bare/mirror git repository,
 // then checks out the requested reference. This mirrors makepkg's extract_git()
 // behavior: open the bare cache, init a new repo in srcdir, fetch objects, and
@@ -148,48 +190,132 @@ func ExtractFromBare(barePath, srcDir, refKey, refValue string) error {
 		return err
 	}
 
-	// Copy all objects from the bare repo's object store
-	bareStorer := bareRepo.Storer
-	workStorer := repo.Storer
+	if err := copyRepository(bareRepo, repo); err != nil {
+		return err
+	}
 
-	refs, err := bareStorer.IterReferences()
+	workTree, err := repo.Worktree()
 	if err != nil {
 		return err
 	}
 
-	err = refs.ForEach(func(ref *plumbing.Reference) error {
-		return workStorer.SetReference(ref)
+	return workTree.Checkout(&ggit.CheckoutOptions{
+		Hash: targetHash,
+	})
+}
+
+// cloneLocal mirrors a repository on the local filesystem (bare or not)
+// into dloadFilePath. Like ExtractFromBare it copies objects and references
+// in-process instead of going through go-git's file:// transport; a copy
+// left by a previous build only receives the objects it is missing.
+func cloneLocal(dloadFilePath, repoPath string, referenceName plumbing.ReferenceName,
+	commitHash string,
+) error {
+	srcRepo, err := ggit.PlainOpenWithOptions(repoPath, &ggit.PlainOpenOptions{
+		EnableDotGitCommonDir: true,
 	})
 	if err != nil {
 		return err
 	}
 
-	objIter, err := bareRepo.Objects()
+	var repo *ggit.Repository
+	if files.Exists(dloadFilePath) {
+		repo, err = ggit.PlainOpen(dloadFilePath)
+	} else {
+		repo, err = ggit.PlainInit(dloadFilePath, false)
+	}
+
 	if err != nil {
 		return err
 	}
 
-	err = objIter.ForEach(func(obj object.Object) error {
-		eo, encErr := bareStorer.EncodedObject(obj.Type(), obj.ID())
-		if encErr != nil {
-			return encErr
+	if err := copyRepository(srcRepo, repo); err != nil {
+		return err
+	}
+
+	var targetHash plumbing.Hash
+
+	switch {
+	case commitHash != "":
+		targetHash = plumbing.NewHash(commitHash)
+	case referenceName.IsTag():
+		targetHash, err = resolveRef(repo, "tag", referenceName.Short())
+	case referenceName.IsBranch():
+		targetHash, err = resolveRef(repo, "branch", referenceName.Short())
+	default:
+		targetHash, err = resolveRef(srcRepo, "", "")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	workTree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	return workTree.Checkout(localCheckoutOptions(srcRepo, referenceName, commitHash, targetHash))
+}
+
+// localCheckoutOptions returns how cloneLocal checks out targetHash: on the
+// requested branch, or on the branch HEAD points to upstream when no
+// reference is given, like a network clone; detached for tags and commits.
+func localCheckoutOptions(srcRepo *ggit.Repository, referenceName plumbing.ReferenceName,
+	commitHash string, targetHash plumbing.Hash,
+) *ggit.CheckoutOptions {
+	if commitHash == "" && referenceName.IsBranch() {
+		return &ggit.CheckoutOptions{Branch: referenceName, Force: true}
+	}
+
+	if commitHash == "" && referenceName == "" {
+		head, err := srcRepo.Storer.Reference(plumbing.HEAD)
+		if err == nil && head.Type() == plumbing.SymbolicReference && head.Target().IsBranch() {
+			return &ggit.CheckoutOptions{Branch: head.Target(), Force: true}
 		}
+	}
 
-		_, setErr := workStorer.SetEncodedObject(eo)
+	return &ggit.CheckoutOptions{Hash: targetHash, Force: true}
+}
 
-		return setErr
+// copyRepository copies every reference and every object missing from dst
+// out of src, so that dst can check out any revision known to src.
+func copyRepository(src, dst *ggit.Repository) error {
+	refs, err := src.Storer.IterReferences()
+	if err != nil {
+		return err
+	}
+
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		// HEAD belongs to the destination working copy.
+		if ref.Name() == plumbing.HEAD {
+			return nil
+		}
+
+		return dst.Storer.SetReference(ref)
 	})
 	if err != nil {
 		return err
 	}
 
-	workTree, err := repo.Worktree()
+	objIter, err := src.Objects()
 	if err != nil {
 		return err
 	}
 
-	return workTree.Checkout(&ggit.CheckoutOptions{
-		Hash: targetHash,
+	return objIter.ForEach(func(obj object.Object) error {
+		if dst.Storer.HasEncodedObject(obj.ID()) == nil {
+			return nil
+		}
+
+		eo, encErr := src.Storer.EncodedObject(obj.Type(), obj.ID())
+		if encErr != nil {
+			return encErr
+		}
+
+		_, setErr := dst.Storer.SetEncodedObject(eo)
+
+		return setErr
 	})
 }
 
@@ -204,6 +330,16 @@ func resolveRef(repo *ggit.Repository, refKey, refValue string) (plumbing.Hash,
 			return plumbing.ZeroHash, err
 		}
 
+		// Annotated tags point at a tag object; peel it to the commit.
+		if tagObj, err := repo.TagObject(ref.Hash()); err == nil {
+			commit, err := tagObj.Commit()
+			if err != nil {
+				return plumbing.ZeroHash, err
+			}
+
+			return commit.Hash, nil
+		}
+
 		return ref.Hash(), nil
 	case "branch":
 		ref, err := repo.Reference(plumbing.NewBranchReferenceName(refValue), true)
@@ -249,9 +385,12 @@ func checkoutCommit(repo *ggit.Repository, hash string) error {
 	})
 }
 
-// handleExistingRepo handles the case where a git repository already exists
-// and potentially needs to checkout a specific branch or tag.
-func handleExistingRepo(dloadFilePath string, referenceName plumbing.ReferenceName,
+// handleExistingRepo refreshes a clone left by a previous build, mirroring
+// makepkg's SRCDEST handling: only the new objects are fetched, a failed
+// fetch (e.g. offline) keeps the cached revision, and the working tree is
+// then moved to the requested commit, reference or upstream branch head.
+func handleExistingRepo(dloadFilePath string, auth transport.AuthMethod,
+	referenceName plumbing.ReferenceName, commitHash string,
 	plainOpenOptions *ggit.PlainOpenOptions,
 ) error {
 	repo, err := ggit.PlainOpenWithOptions(dloadFilePath, plainOpenOptions)
@@ -259,11 +398,52 @@ func handleExistingRepo(dloadFilePath string, referenceName plumbing.ReferenceNa
 		return err
 	}
 
-	if referenceName == "" {
-		return nil
+	err = repo.Fetch(&ggit.FetchOptions{Auth: auth, Tags: ggit.AllTags})
+	if err != nil && !stderrors.Is(err, ggit.NoErrAlreadyUpToDate) {
+		logger.Warn(i18n.T("logger.git.warn.fetch_failed_using_cache"),
+			"path", dloadFilePath, "error", err)
+	}
+
+	switch {
+	case commitHash != "":
+		return checkoutCommit(repo, commitHash)
+	case referenceName.IsBranch():
+		return syncBranch(repo, referenceName)
+	case referenceName != "":
+		return checkoutReference(repo, referenceName)
 	}
 
-	return checkoutReference(repo, referenceName)
+	head, err := repo.Head()
+	if err != nil || !head.Name().IsBranch() {
+		return nil //nolint:nilerr // detached or unborn HEAD: keep the cached checkout
+	}
+
+	return syncBranch(repo, head.Name())
+}
+
+// syncBranch moves the local branch to its freshly fetched origin
+// counterpart and checks it out, discarding changes left in the cached
+// working tree by a previous build.
+func syncBranch(repo *ggit.Repository, branch plumbing.ReferenceName) error {
+	remoteRef, err := repo.Reference(plumbing.NewRemoteReferenceName("origin", branch.Short()), true)
+	if err != nil {
+		return checkoutReference(repo, branch)
+	}
+
+	err = repo.Storer.SetReference(plumbing.NewHashReference(branch, remoteRef.Hash()))
+	if err != nil {
+		return err
+	}
+
+	workTree, err := repo.Worktree()
+	if err != nil {
+		return err
+	}
+
+	return workTree.Checkout(&ggit.CheckoutOptions{
+		Branch: branch,
+		Force:  true,
+	})
 }
 
 // checkoutReference attempts to checkout the specified reference,
@@ -276,10 +456,6 @@ func checkoutReference(repo *ggit.Repository, referenceName plumbing.ReferenceNa
 
 	branchName := referenceName.Short()
 
-	// First, try to fetch the latest changes from remote
-	fetchOptions := &ggit.FetchOptions{}
-	_ = repo.Fetch(fetchOptions) // Ignore fetch errors
-
 	// Try to checkout the specified reference directly first
 	checkoutOptions := &ggit.CheckoutOptions{
 		Branch: referenceName,
@@ -344,3 +520,139 @@ func GetCommitHash(repoPath string) string {
 
 	return head.Hash().String()
 }
+
+// defaultShortHashLength matches git's default --short abbreviation.
+const defaultShortHashLength = 7
+
+// GetShortCommitHash returns the HEAD commit hash of the repository at
+// repoPath abbreviated to length characters (7 when length <= 0).
+// Returns empty string if not a git repository or on error.
+func GetShortCommitHash(repoPath string, length int) string {
+	hash := GetCommitHash(repoPath)
+	if hash == "" {
+		return ""
+	}
+
+	if length <= 0 {
+		length = defaultShortHashLength
+	}
+
+	return hash[:min(length, len(hash))]
+}
+
+// GetCommitCount returns the number of commits reachable from HEAD, the
+// in-process equivalent of `git rev-list --count HEAD`.
+func GetCommitCount(repoPath string) (int, error) {
+	plainOpenOptions := &ggit.PlainOpenOptions{
+		DetectDotGit:          true,
+		EnableDotGitCommonDir: true,
+	}
+
+	repo, err := ggit.PlainOpenWithOptions(repoPath, plainOpenOptions)
+	if err != nil {
+		return 0, err
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return 0, err
+	}
+
+	commits, err := repo.Log(&ggit.LogOptions{From: head.Hash()})
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+
+	err = commits.ForEach(func(*object.Commit) error {
+		count++
+
+		return nil
+	})
+
+	return count, err
+}
+
+// ExecHandler returns an interp exec middleware that answers the read-only
+// git queries typically found in PKGBUILD pkgver() functions in-process
+// when no git binary is available on PATH (e.g. minimal build containers).
+// When git is installed, or the invocation is not one of the supported
+// forms, the call falls through to the next handler unchanged.
+//
+// Supported command forms (optionally prefixed by `-C <dir>`):
+//
+//	git rev-parse HEAD
+//	git rev-parse --short[=N] HEAD
+//	git rev-list --count HEAD
+func ExecHandler(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(ctx context.Context, args []string) error {
+		if len(args) == 0 || filepath.Base(args[0]) != constants.Git {
+			return next(ctx, args)
+		}
+
+		if _, err := exec.LookPath(args[0]); err == nil {
+			return next(ctx, args)
+		}
+
+		hc := interp.HandlerCtx(ctx)
+
+		out, ok := queryRepo(hc.Dir, args[1:])
+		if !ok {
+			return next(ctx, args)
+		}
+
+		_, err := fmt.Fprintln(hc.Stdout, out)
+
+		return err
+	}
+}
+
+// queryRepo evaluates a supported git query against the repository found at
+// or above dir. It reports false when the query is not supported or cannot
+// be answered, so the caller can fall through to the real git binary.
+func queryRepo(dir string, args []string) (string, bool) {
+	if len(args) >= 2 && args[0] == "-C" {
+		if filepath.IsAbs(args[1]) {
+			dir = args[1]
+		} else {
+			dir = filepath.Join(dir, args[1])
+		}
+
+		args = args[2:]
+	}
+
+	switch {
+	case len(args) == 2 && args[0] == "rev-parse" && args[1] == "HEAD":
+		hash := GetCommitHash(dir)
+
+		return hash, hash != ""
+
+	case len(args) == 3 && args[0] == "rev-parse" && args[2] == "HEAD" &&
+		strings.HasPrefix(args[1], "--short"):
+		length := defaultShortHashLength
+
+		if value, ok := strings.CutPrefix(args[1], "--short="); ok {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return "", false
+			}
+
+			length = n
+		}
+
+		hash := GetShortCommitHash(dir, length)
+
+		return hash, hash != ""
+
+	case len(args) == 3 && args[0] == "rev-list" && args[1] == "--count" && args[2] == "HEAD":
+		count, err := GetCommitCount(dir)
+		if err != nil {
+			return "", false
+		}
+
+		return strconv.Itoa(count), true
+	}
+
+	return "", false
+}
@@ -207,6 +207,52 @@ func TestGetCommitHash_SubdirectoryOfRepo(t *testing.T) {
 	assert.Equal(t, expectedHash, hash)
 }
 
+// ─── GetShortCommitHash / GetCommitCount ─────────────────────────────────────
+
+func TestGetShortCommitHash(t *testing.T) {
+	dir := t.TempDir()
+	_, fullHash := initRepoWithCommit(t, dir)
+
+	assert.Equal(t, fullHash[:7], git.GetShortCommitHash(dir, 0), "default length should be 7")
+	assert.Equal(t, fullHash[:12], git.GetShortCommitHash(dir, 12))
+	assert.Equal(t, fullHash, git.GetShortCommitHash(dir, 100), "length is capped at the full hash")
+	assert.Empty(t, git.GetShortCommitHash(t.TempDir(), 7), "non-git directory should return empty string")
+}
+
+func TestGetCommitCount(t *testing.T) {
+	dir := t.TempDir()
+	repo, _ := initRepoWithCommit(t, dir)
+
+	count, err := git.GetCommitCount(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+
+	err = os.WriteFile(filepath.Join(dir, "second.txt"), []byte("second"), 0o644)
+	require.NoError(t, err)
+
+	_, err = w.Add("second.txt")
+	require.NoError(t, err)
+
+	_, err = w.Commit("second commit", &ggit.CommitOptions{
+		Author: &object.Signature{
+			Name:  "yap-test",
+			Email: "yap@test.local",
+			When:  time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+	})
+	require.NoError(t, err)
+
+	count, err = git.GetCommitCount(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	_, err = git.GetCommitCount(t.TempDir())
+	assert.Error(t, err, "non-git directory should return an error")
+}
+
 // ─── Clone error paths ───────────────────────────────────────────────────────
 
 func TestClone_EmptyDownloadPath(t *testing.T) {
@@ -720,3 +766,90 @@ func TestClone_WithBothReferenceAndCommit(t *testing.T) {
 	_, err = os.Stat(secondFileInClone)
 	assert.True(t, os.IsNotExist(err), "second.txt should not exist when checking out first commit")
 }
+
+// ─── file:// sources ─────────────────────────────────────────────────────────
+
+func TestClone_FileURI(t *testing.T) {
+	upstream := t.TempDir()
+	upstreamRepo, firstHash := initRepoWithCommit(t, upstream)
+
+	clonePath := filepath.Join(t.TempDir(), "repo")
+	err := git.Clone(clonePath, "file://"+upstream, "", "", "")
+	require.NoError(t, err, "Clone from a file:// URI should succeed")
+	assert.Equal(t, firstHash, git.GetCommitHash(clonePath))
+
+	// A second commit upstream must reach the cached copy on the next build.
+	w, err := upstreamRepo.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(upstream, "second.txt"), []byte("more"), 0o644))
+	_, err = w.Add("second.txt")
+	require.NoError(t, err)
+
+	secondHash, err := w.Commit("second commit", &ggit.CommitOptions{
+		Author: &object.Signature{
+			Name:  "yap-test",
+			Email: "yap@test.local",
+			When:  time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+	})
+	require.NoError(t, err)
+
+	err = git.Clone(clonePath, "file://"+upstream, "", "", "")
+	require.NoError(t, err, "refreshing a cached file:// clone should succeed")
+	assert.Equal(t, secondHash.String(), git.GetCommitHash(clonePath))
+	assert.FileExists(t, filepath.Join(clonePath, "second.txt"))
+
+	// Pinning the first commit moves the cached copy back.
+	err = git.Clone(clonePath, "file://"+upstream, "", "", firstHash)
+	require.NoError(t, err)
+	assert.Equal(t, firstHash, git.GetCommitHash(clonePath))
+}
+
+func TestClone_FileURIBareTag(t *testing.T) {
+	upstream := t.TempDir()
+	upstreamRepo, commitHash := initRepoWithCommit(t, upstream)
+
+	head, err := upstreamRepo.Head()
+	require.NoError(t, err)
+
+	_, err = upstreamRepo.CreateTag("v1.0.0", head.Hash(), &ggit.CreateTagOptions{
+		Tagger: &object.Signature{
+			Name:  "yap-test",
+			Email: "yap@test.local",
+			When:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		Message: "v1.0.0",
+	})
+	require.NoError(t, err)
+
+	bareDir := t.TempDir()
+	_, err = ggit.PlainClone(bareDir, true, &ggit.CloneOptions{URL: upstream})
+	require.NoError(t, err)
+
+	clonePath := filepath.Join(t.TempDir(), "repo")
+	err = git.Clone(clonePath, "file://"+bareDir, "", plumbing.NewTagReferenceName("v1.0.0"), "")
+	require.NoError(t, err, "Clone of an annotated tag from a bare file:// repo should succeed")
+	assert.Equal(t, commitHash, git.GetCommitHash(clonePath))
+}
+
+func TestClone_BarePath(t *testing.T) {
+	upstream := t.TempDir()
+	_, commitHash := initRepoWithCommit(t, upstream)
+
+	bareDir := filepath.Join(t.TempDir(), "foo.git")
+	_, err := ggit.PlainClone(bareDir, true, &ggit.CloneOptions{URL: upstream})
+	require.NoError(t, err)
+
+	clonePath := filepath.Join(t.TempDir(), "repo")
+	err = git.Clone(clonePath, bareDir, "", "", "")
+	require.NoError(t, err, "Clone of a bare repo by plain path should succeed")
+	assert.Equal(t, commitHash, git.GetCommitHash(clonePath))
+}
+
+func TestClone_SSHWithoutCredentials(t *testing.T) {
+	t.Setenv("SSH_AUTH_SOCK", "")
+	t.Setenv("HOME", t.TempDir())
+
+	err := git.Clone(filepath.Join(t.TempDir(), "repo"), "ssh://git@example.com/repo.git", "", "", "")
+	assert.Error(t, err, "ssh:// clone without agent or key should fail before any network access")
+}
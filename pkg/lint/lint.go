@@ -0,0 +1,383 @@
+// Package lint implements namcap-style static analysis of PKGBUILDs.
+//
+// Rules run over two views of the same file: the mvdan.cc/sh syntax tree,
+// which gives every finding a line and column and lets a rule inspect every
+// arch and distro override at once, and the pkgbuild.PKGBUILD parsed for the
+// requested distro, which holds the values a build would actually use.
+//
+// A finding is suppressed by a comment naming its rule, either trailing the
+// offending line or on its own line right before the offending statement:
+//
+//	# yap-lint: disable=skip-checksum,insecure-source
+//
+// The same comment placed before the first statement applies to the whole
+// file; "all" matches every rule.
+package lint
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/parser"
+	"github.com/M0Rf30/yap/v2/pkg/pkgbuild"
+)
+
+// Severity is the importance of a finding.
+type Severity string
+
+// Severities, from the most to the least important.
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Options selects the distro and architecture context the PKGBUILD is
+// parsed for, as the build command would.
+type Options struct {
+	Distro     string
+	Release    string
+	TargetArch string
+}
+
+// Finding is a rule violation. Line and Column are 1-based; findings about
+// the file as a whole (e.g. a missing variable) have a zero Line.
+type Finding struct {
+	RuleID   string   `json:"ruleId"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	File     string   `json:"file"`
+	Line     uint     `json:"line,omitempty"`
+	Column   uint     `json:"column,omitempty"`
+}
+
+// Rule is a single check.
+type Rule struct {
+	// ID names the rule in reports and in suppression comments.
+	ID string
+	// Severity is assigned to every finding of the rule.
+	Severity Severity
+	// Description is the i18n message ID of the one-line rule summary.
+	Description string
+	check       func(in *input, report func(pos syntax.Pos, message string))
+}
+
+// input is what a rule inspects.
+type input struct {
+	file    *syntax.File
+	pkg     *pkgbuild.PKGBUILD
+	opts    Options
+	assigns []*syntax.Assign   // top-level assignments, in file order
+	funcs   []*syntax.FuncDecl // top-level function declarations
+	vars    map[string]string  // top-level scalar values, for expansion
+}
+
+// suppressRe matches a "# yap-lint: disable=ID[,ID...]" comment.
+var suppressRe = regexp.MustCompile(`#\s*yap-lint:\s*disable=([\w,-]+)`)
+
+// Rules returns every rule, sorted by ID.
+func Rules() []Rule {
+	rules := slices.Clone(allRules)
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+
+	return rules
+}
+
+// Lint runs every rule over the PKGBUILD at path, which may be the file
+// itself or the directory containing it. Findings are sorted by position.
+func Lint(path string, opts Options) ([]Finding, error) {
+	dir := path
+	if filepath.Base(path) == "PKGBUILD" {
+		dir = filepath.Dir(path)
+	}
+
+	pkgbuildPath := filepath.Join(dir, "PKGBUILD")
+
+	raw, err := os.ReadFile(filepath.Clean(pkgbuildPath))
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypeFileSystem,
+			i18n.T("errors.lint.failed_to_read_pkgbuild")).
+			WithOperation("Lint").
+			WithContext("path", pkgbuildPath)
+	}
+
+	file, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).
+		Parse(bytes.NewReader(raw), pkgbuildPath)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypeParser,
+			i18n.T("errors.lint.failed_to_parse_pkgbuild")).
+			WithOperation("Lint").
+			WithContext("path", pkgbuildPath)
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg, err := parser.ParseFile(opts.Distro, opts.Release, absDir, absDir, opts.TargetArch)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypeParser,
+			i18n.T("errors.lint.failed_to_parse_pkgbuild")).
+			WithOperation("Lint").
+			WithContext("path", pkgbuildPath)
+	}
+
+	in := newInput(file, pkg, opts)
+	suppressions := newSuppressions(file, raw)
+
+	var findings []Finding
+
+	for _, rule := range allRules {
+		rule.check(in, func(pos syntax.Pos, message string) {
+			if suppressions.suppressed(rule.ID, pos.Line()) {
+				return
+			}
+
+			findings = append(findings, Finding{
+				RuleID:   rule.ID,
+				Severity: rule.Severity,
+				Message:  message,
+				File:     pkgbuildPath,
+				Line:     pos.Line(),
+				Column:   pos.Col(),
+			})
+		})
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Line != findings[j].Line {
+			return findings[i].Line < findings[j].Line
+		}
+
+		if findings[i].Column != findings[j].Column {
+			return findings[i].Column < findings[j].Column
+		}
+
+		return findings[i].RuleID < findings[j].RuleID
+	})
+
+	return findings, nil
+}
+
+// Count returns the number of findings with severity.
+func Count(findings []Finding, severity Severity) int {
+	count := 0
+
+	for _, finding := range findings {
+		if finding.Severity == severity {
+			count++
+		}
+	}
+
+	return count
+}
+
+// newInput indexes the top level of file. Like the parser, it does not
+// descend into function bodies, whose assignments are local.
+func newInput(file *syntax.File, pkg *pkgbuild.PKGBUILD, opts Options) *input {
+	in := &input{
+		file: file,
+		pkg:  pkg,
+		opts: opts,
+		vars: make(map[string]string),
+	}
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		switch node := node.(type) {
+		case *syntax.FuncDecl:
+			in.funcs = append(in.funcs, node)
+
+			return false
+		case *syntax.Assign:
+			if node.Name == nil {
+				return true
+			}
+
+			in.assigns = append(in.assigns, node)
+
+			if node.Array == nil {
+				in.vars[node.Name.Value] = in.expand(node.Value)
+			}
+		}
+
+		return true
+	})
+
+	return in
+}
+
+// expand returns the value of word with top-level PKGBUILD variables
+// substituted. Words that cannot be expanded statically (command
+// substitutions, ...) are returned as written.
+func (in *input) expand(word *syntax.Word) string {
+	if word == nil {
+		return ""
+	}
+
+	value, err := expand.Literal(&expand.Config{
+		Env: expand.FuncEnviron(func(name string) string { return in.vars[name] }),
+	}, word)
+	if err != nil {
+		var out strings.Builder
+
+		_ = syntax.NewPrinter().Print(&out, word)
+
+		return out.String()
+	}
+
+	return value
+}
+
+// variants returns the assignments of key and of its arch and distro
+// overrides (key_x86_64, key__ubuntu, key_aarch64__apt, ...).
+func (in *input) variants(key string) []*syntax.Assign {
+	var assigns []*syntax.Assign
+
+	for _, assign := range in.assigns {
+		if _, ok := overrideSuffix(assign.Name.Value, key); ok {
+			assigns = append(assigns, assign)
+		}
+	}
+
+	return assigns
+}
+
+// lookup returns the assignment named name, if any.
+func (in *input) lookup(name string) *syntax.Assign {
+	var found *syntax.Assign
+
+	for _, assign := range in.assigns {
+		if assign.Name.Value == name {
+			found = assign
+		}
+	}
+
+	return found
+}
+
+// overrideSuffix returns the part of name following key ("", "_x86_64",
+// "__ubuntu", ...) and whether name is key or one of its overrides.
+func overrideSuffix(name, key string) (string, bool) {
+	suffix, ok := strings.CutPrefix(name, key)
+	if !ok {
+		return "", false
+	}
+
+	if suffix == "" || strings.HasPrefix(suffix, "__") {
+		return suffix, true
+	}
+
+	arch, _, _ := strings.Cut(strings.TrimPrefix(suffix, "_"), "__")
+
+	return suffix, strings.HasPrefix(suffix, "_") && pkgbuild.IsValidArchitecture(arch)
+}
+
+// suppressions records the "# yap-lint: disable=" comments of a file.
+type suppressions struct {
+	file  map[string]bool
+	lines map[uint]map[string]bool
+}
+
+// newSuppressions maps each comment to the lines it covers: its own line
+// for a trailing comment, the whole next statement for a comment on its
+// own line, and the whole file for a comment above the first statement.
+func newSuppressions(file *syntax.File, raw []byte) *suppressions {
+	sup := &suppressions{
+		file:  make(map[string]bool),
+		lines: make(map[uint]map[string]bool),
+	}
+
+	// End line of the outermost statement starting on each line.
+	stmtEnds := make(map[uint]uint)
+	firstStmt := uint(0)
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if stmt, ok := node.(*syntax.Stmt); ok {
+			start := stmt.Pos().Line()
+			stmtEnds[start] = max(stmtEnds[start], stmt.End().Line())
+
+			if firstStmt == 0 || start < firstStmt {
+				firstStmt = start
+			}
+		}
+
+		return true
+	})
+
+	lines := strings.Split(string(raw), "\n")
+
+	for index, line := range lines {
+		match := suppressRe.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		lineNo := uint(index + 1)
+		ids := strings.Split(match[1], ",")
+
+		if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			sup.add(lineNo, lineNo, ids)
+
+			continue
+		}
+
+		if firstStmt == 0 || lineNo < firstStmt {
+			for _, id := range ids {
+				sup.file[id] = true
+			}
+
+			continue
+		}
+
+		// Skip the blank and comment lines up to the next statement.
+		next := index + 1
+		for next < len(lines) && isBlankOrComment(lines[next]) {
+			next++
+		}
+
+		start := uint(next + 1)
+		sup.add(start, max(start, stmtEnds[start]), ids)
+	}
+
+	return sup
+}
+
+// add suppresses ids on the lines from start to end.
+func (sup *suppressions) add(start, end uint, ids []string) {
+	for line := start; line <= end; line++ {
+		if sup.lines[line] == nil {
+			sup.lines[line] = make(map[string]bool)
+		}
+
+		for _, id := range ids {
+			sup.lines[line][id] = true
+		}
+	}
+}
+
+// suppressed reports whether rule is disabled on line.
+func (sup *suppressions) suppressed(rule string, line uint) bool {
+	if sup.file[rule] || sup.file["all"] {
+		return true
+	}
+
+	return sup.lines[line][rule] || sup.lines[line]["all"]
+}
+
+// isBlankOrComment reports whether line holds no shell code.
+func isBlankOrComment(line string) bool {
+	trimmed := strings.TrimSpace(line)
+
+	return trimmed == "" || strings.HasPrefix(trimmed, "#")
+}
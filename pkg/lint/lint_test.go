@@ -0,0 +1,313 @@
+package lint_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/M0Rf30/yap/v2/pkg/lint"
+)
+
+const basePKGBUILD = `pkgname=foo
+pkgver=1.0.0
+pkgrel=1
+pkgdesc="Foo"
+arch=('x86_64')
+license=('MIT')
+depends=('glibc')
+makedepends=('gcc')
+source=("https://example.com/foo-${pkgver}.tar.gz")
+sha256sums=('0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef')
+
+package() {
+  install -Dm755 foo "${pkgdir}/usr/bin/foo"
+}
+`
+
+func writePKGBUILD(t *testing.T, content string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "PKGBUILD"), []byte(content), 0o600))
+
+	return dir
+}
+
+func lintContent(t *testing.T, content string, opts lint.Options) []lint.Finding {
+	t.Helper()
+
+	findings, err := lint.Lint(writePKGBUILD(t, content), opts)
+	require.NoError(t, err)
+
+	return findings
+}
+
+// byRule returns the findings of rule.
+func byRule(findings []lint.Finding, rule string) []lint.Finding {
+	var matched []lint.Finding
+
+	for _, finding := range findings {
+		if finding.RuleID == rule {
+			matched = append(matched, finding)
+		}
+	}
+
+	return matched
+}
+
+func TestLint_Clean(t *testing.T) {
+	findings := lintContent(t, basePKGBUILD, lint.Options{Distro: "ubuntu"})
+	assert.Empty(t, findings)
+}
+
+func TestLint_Rules(t *testing.T) {
+	tests := []struct {
+		name    string
+		old     string
+		new     string
+		opts    lint.Options
+		rule    string
+		wantHit bool
+		line    uint
+	}{
+		{
+			name: "SKIP on a downloaded source", rule: "skip-checksum", wantHit: true, line: 10,
+			old: "sha256sums=('0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef')",
+			new: "sha256sums=('SKIP')",
+		},
+		{
+			name: "SKIP on a VCS source", rule: "skip-checksum",
+			old: `source=("https://example.com/foo-${pkgver}.tar.gz")` + "\nsha256sums=('0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef')",
+			new: `source=("git+https://example.com/foo.git")` + "\nsha256sums=('SKIP')",
+		},
+		{
+			name: "plain http source", rule: "insecure-source", wantHit: true, line: 9,
+			old: `source=("https://`, new: `source=("http://`,
+		},
+		{
+			name: "http mirror", rule: "insecure-source", wantHit: true, line: 9,
+			old: `.tar.gz")`, new: `.tar.gz|http://mirror.example.com/foo.tar.gz")`,
+		},
+		{
+			name: "unknown distro override", rule: "unknown-override", wantHit: true, line: 8,
+			old: "makedepends=('gcc')", new: "depends__foobar=('bar')",
+		},
+		{
+			name: "known distro codename override", rule: "unknown-override",
+			old: "makedepends=('gcc')", new: "depends__ubuntu_noble=('bar')",
+		},
+		{
+			name: "makedepends repeats depends", rule: "depends-in-makedepends", wantHit: true, line: 8,
+			old: "makedepends=('gcc')", new: "makedepends=('glibc>=2.0')",
+		},
+		{
+			name: "srcdir at top level", rule: "srcdir-outside-function", wantHit: true, line: 4,
+			old: `pkgdesc="Foo"`, new: `_data="${srcdir}/data"`,
+		},
+		{
+			name: "invalid SPDX license", rule: "invalid-license", wantHit: true, line: 6,
+			old: "license=('MIT')", new: "license=('NotALicense')",
+		},
+		{
+			name: "SPDX expression", rule: "invalid-license",
+			old: "license=('MIT')", new: "license=('MIT OR Apache-2.0')",
+		},
+		{
+			name: "pkgver with a dash", rule: "invalid-version", wantHit: true, line: 2,
+			old: "pkgver=1.0.0", new: "pkgver=1.0-1",
+		},
+		{
+			name: "non-numeric pkgrel", rule: "invalid-version", wantHit: true, line: 3,
+			old: "pkgrel=1", new: "pkgrel=abc",
+		},
+		{
+			name: "missing arch", rule: "missing-arch", wantHit: true,
+			old: "arch=('x86_64')\n", new: "",
+		},
+		{
+			name: "cross target not in arch", rule: "missing-arch", wantHit: true, line: 5,
+			old: "arch=('x86_64')", new: "arch=('x86_64')", opts: lint.Options{TargetArch: "aarch64"},
+		},
+		{
+			name: "cross target in arch", rule: "missing-arch",
+			old: "arch=('x86_64')", new: "arch=('x86_64' 'aarch64')", opts: lint.Options{TargetArch: "aarch64"},
+		},
+		{
+			name: "checksum count mismatch", rule: "checksum-count", wantHit: true, line: 10,
+			old: "sha256sums=('", new: "sha256sums=('SKIP' '",
+		},
+		{
+			name: "missing pkgdesc", rule: "missing-variable", wantHit: true,
+			old: "pkgdesc=\"Foo\"\n", new: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Contains(t, basePKGBUILD, tt.old)
+
+			if tt.opts.Distro == "" {
+				tt.opts.Distro = "ubuntu"
+			}
+
+			content := strings.Replace(basePKGBUILD, tt.old, tt.new, 1)
+			findings := byRule(lintContent(t, content, tt.opts), tt.rule)
+
+			if !tt.wantHit {
+				assert.Empty(t, findings)
+
+				return
+			}
+
+			require.Len(t, findings, 1)
+			assert.Equal(t, tt.line, findings[0].Line)
+			assert.NotEmpty(t, findings[0].Message)
+		})
+	}
+}
+
+func TestLint_Suppressions(t *testing.T) {
+	skip := strings.Replace(basePKGBUILD,
+		"sha256sums=('0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef')",
+		"sha256sums=('SKIP')", 1)
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "trailing comment",
+			content: strings.Replace(skip, "sha256sums=('SKIP')", "sha256sums=('SKIP') # yap-lint: disable=skip-checksum", 1),
+		},
+		{
+			name:    "comment on the previous line",
+			content: strings.Replace(skip, "sha256sums=('SKIP')", "# yap-lint: disable=skip-checksum\nsha256sums=('SKIP')", 1),
+		},
+		{
+			name:    "multi-line statement",
+			content: strings.Replace(skip, "sha256sums=('SKIP')", "# yap-lint: disable=all\nsha256sums=(\n  'SKIP'\n)", 1),
+		},
+		{
+			name:    "file header",
+			content: "# yap-lint: disable=insecure-source,skip-checksum\n\n" + skip,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := lintContent(t, tt.content, lint.Options{Distro: "ubuntu"})
+			assert.Empty(t, byRule(findings, "skip-checksum"))
+		})
+	}
+
+	t.Run("other rule is not suppressed", func(t *testing.T) {
+		content := strings.Replace(skip, "sha256sums=('SKIP')",
+			"sha256sums=('SKIP') # yap-lint: disable=insecure-source", 1)
+
+		findings := lintContent(t, content, lint.Options{Distro: "ubuntu"})
+		assert.Len(t, byRule(findings, "skip-checksum"), 1)
+	})
+}
+
+func TestLint_MissingPKGBUILD(t *testing.T) {
+	_, err := lint.Lint(t.TempDir(), lint.Options{})
+	require.Error(t, err)
+}
+
+func TestRules(t *testing.T) {
+	rules := lint.Rules()
+	require.NotEmpty(t, rules)
+
+	for i, rule := range rules {
+		assert.NotEmpty(t, rule.Description, rule.ID)
+
+		if i > 0 {
+			assert.Less(t, rules[i-1].ID, rule.ID)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	findings := []lint.Finding{
+		{
+			RuleID: "skip-checksum", Severity: lint.SeverityWarning, Message: "skipped",
+			File: "/tmp/PKGBUILD", Line: 10, Column: 13,
+		},
+		{
+			RuleID: "missing-arch", Severity: lint.SeverityWarning, Message: "no arch",
+			File: "/tmp/PKGBUILD",
+		},
+	}
+
+	t.Run("text", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, lint.Write(&buf, lint.FormatText, findings))
+		assert.Equal(t, "/tmp/PKGBUILD:10:13: warning: skipped [skip-checksum]\n"+
+			"/tmp/PKGBUILD: warning: no arch [missing-arch]\n", buf.String())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, lint.Write(&buf, lint.FormatJSON, findings))
+
+		var decoded []lint.Finding
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+		assert.Equal(t, findings, decoded)
+	})
+
+	t.Run("json without findings", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, lint.Write(&buf, lint.FormatJSON, nil))
+		assert.Equal(t, "[]\n", buf.String())
+	})
+
+	t.Run("sarif", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, lint.Write(&buf, lint.FormatSARIF, findings))
+
+		var log struct {
+			Version string `json:"version"`
+			Runs    []struct {
+				Tool struct {
+					Driver struct {
+						Name  string `json:"name"`
+						Rules []struct {
+							ID string `json:"id"`
+						} `json:"rules"`
+					} `json:"driver"`
+				} `json:"tool"`
+				Results []struct {
+					RuleID    string `json:"ruleId"`
+					Level     string `json:"level"`
+					Locations []struct {
+						PhysicalLocation struct {
+							Region *struct {
+								StartLine uint `json:"startLine"`
+							} `json:"region"`
+						} `json:"physicalLocation"`
+					} `json:"locations"`
+				} `json:"results"`
+			} `json:"runs"`
+		}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+
+		assert.Equal(t, "2.1.0", log.Version)
+		require.Len(t, log.Runs, 1)
+		assert.Equal(t, "yap", log.Runs[0].Tool.Driver.Name)
+		assert.Len(t, log.Runs[0].Tool.Driver.Rules, len(lint.Rules()))
+		require.Len(t, log.Runs[0].Results, 2)
+		assert.Equal(t, "warning", log.Runs[0].Results[0].Level)
+		assert.Equal(t, uint(10), log.Runs[0].Results[0].Locations[0].PhysicalLocation.Region.StartLine)
+		assert.Nil(t, log.Runs[0].Results[1].Locations[0].PhysicalLocation.Region)
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		require.Error(t, lint.Write(&bytes.Buffer{}, "xml", findings))
+	})
+}
@@ -0,0 +1,191 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/M0Rf30/yap/v2/pkg/buildinfo"
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+)
+
+// Format is the report output format.
+type Format string
+
+const (
+	// FormatText is one "file:line:column: severity: message [rule]" line
+	// per finding.
+	FormatText Format = "text"
+	// FormatJSON is a JSON array of findings.
+	FormatJSON Format = "json"
+	// FormatSARIF is a SARIF 2.1.0 log, as consumed by code scanning UIs.
+	FormatSARIF Format = "sarif"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	yapURI       = "https://github.com/M0Rf30/yap"
+)
+
+// Write renders findings to w in format.
+func Write(w io.Writer, format Format, findings []Finding) error {
+	switch format {
+	case FormatText:
+		return writeText(w, findings)
+	case FormatJSON:
+		if findings == nil {
+			findings = []Finding{}
+		}
+
+		return writeJSON(w, findings)
+	case FormatSARIF:
+		return writeJSON(w, newSARIF(findings))
+	default:
+		return errors.New(errors.ErrTypeValidation,
+			i18n.T("errors.lint.unknown_format")).
+			WithOperation("Write").
+			WithContext("format", format)
+	}
+}
+
+func writeText(w io.Writer, findings []Finding) error {
+	for _, finding := range findings {
+		location := finding.File
+		if finding.Line > 0 {
+			location = fmt.Sprintf("%s:%d:%d", finding.File, finding.Line, finding.Column)
+		}
+
+		if _, err := fmt.Fprintf(w, "%s: %s: %s [%s]\n",
+			location, finding.Severity, finding.Message, finding.RuleID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeJSON(w io.Writer, value any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(value)
+}
+
+// SARIF 2.1.0 subset written by FormatSARIF.
+type (
+	sarifLog struct {
+		Schema  string     `json:"$schema"`
+		Version string     `json:"version"`
+		Runs    []sarifRun `json:"runs"`
+	}
+
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		Version        string      `json:"version"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	}
+
+	sarifRule struct {
+		ID                   string             `json:"id"`
+		ShortDescription     sarifMessage       `json:"shortDescription"`
+		DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+	}
+
+	sarifConfiguration struct {
+		Level string `json:"level"`
+	}
+
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations"`
+	}
+
+	sarifLocation struct {
+		PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+	}
+
+	sarifPhysicalLocation struct {
+		ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+		Region           *sarifRegion          `json:"region,omitempty"`
+	}
+
+	sarifArtifactLocation struct {
+		URI string `json:"uri"`
+	}
+
+	sarifRegion struct {
+		StartLine   uint `json:"startLine"`
+		StartColumn uint `json:"startColumn,omitempty"`
+	}
+)
+
+// sarifLevel maps a severity to its SARIF level.
+func sarifLevel(severity Severity) string {
+	if severity == SeverityInfo {
+		return "note"
+	}
+
+	return string(severity)
+}
+
+func newSARIF(findings []Finding) sarifLog {
+	rules := Rules()
+	driver := sarifDriver{
+		Name:           "yap",
+		Version:        buildinfo.Version,
+		InformationURI: yapURI,
+		Rules:          make([]sarifRule, 0, len(rules)),
+	}
+
+	for _, rule := range rules {
+		driver.Rules = append(driver.Rules, sarifRule{
+			ID:                   rule.ID,
+			ShortDescription:     sarifMessage{Text: i18n.T(rule.Description)},
+			DefaultConfiguration: sarifConfiguration{Level: sarifLevel(rule.Severity)},
+		})
+	}
+
+	results := make([]sarifResult, 0, len(findings))
+
+	for _, finding := range findings {
+		location := sarifPhysicalLocation{
+			ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(finding.File)},
+		}
+
+		if finding.Line > 0 {
+			location.Region = &sarifRegion{StartLine: finding.Line, StartColumn: finding.Column}
+		}
+
+		results = append(results, sarifResult{
+			RuleID:    finding.RuleID,
+			Level:     sarifLevel(finding.Severity),
+			Message:   sarifMessage{Text: finding.Message},
+			Locations: []sarifLocation{{PhysicalLocation: location}},
+		})
+	}
+
+	return sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}
+}
@@ -0,0 +1,444 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+
+	"mvdan.cc/sh/v3/syntax"
+
+	"github.com/M0Rf30/yap/v2/pkg/constants"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/pkgbuild"
+)
+
+const (
+	archKey    = "arch"
+	dependsKey = "depends"
+	licenseKey = "license"
+	sourceKey  = "source"
+	skipValue  = "SKIP"
+)
+
+// checksumKeys are the checksum arrays paired with a source array.
+var checksumKeys = []string{
+	"sha256sums", "sha512sums", "b2sums", "sha224sums", "sha384sums",
+	"sha1sums", "md5sums", "cksums",
+}
+
+// vcsPrefixes are the "<vcs>+" source prefixes.
+var vcsPrefixes = []string{constants.Git, "svn", "hg", "bzr", "fossil"}
+
+var (
+	// pkgrelRe is makepkg's pkgrel format.
+	pkgrelRe = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+	// epochRe is makepkg's epoch format.
+	epochRe = regexp.MustCompile(`^[0-9]+$`)
+)
+
+var allRules = []Rule{
+	{
+		ID:          "checksum-count",
+		Severity:    SeverityError,
+		Description: "lint.rules.checksum_count",
+		check:       checkChecksumCount,
+	},
+	{
+		ID:          "depends-in-makedepends",
+		Severity:    SeverityWarning,
+		Description: "lint.rules.depends_in_makedepends",
+		check:       checkDependsInMakedepends,
+	},
+	{
+		ID:          "insecure-source",
+		Severity:    SeverityWarning,
+		Description: "lint.rules.insecure_source",
+		check:       checkInsecureSource,
+	},
+	{
+		ID:          "invalid-license",
+		Severity:    SeverityError,
+		Description: "lint.rules.invalid_license",
+		check:       checkInvalidLicense,
+	},
+	{
+		ID:          "invalid-version",
+		Severity:    SeverityError,
+		Description: "lint.rules.invalid_version",
+		check:       checkInvalidVersion,
+	},
+	{
+		ID:          "missing-arch",
+		Severity:    SeverityWarning,
+		Description: "lint.rules.missing_arch",
+		check:       checkMissingArch,
+	},
+	{
+		ID:          "missing-variable",
+		Severity:    SeverityError,
+		Description: "lint.rules.missing_variable",
+		check:       checkMissingVariable,
+	},
+	{
+		ID:          "skip-checksum",
+		Severity:    SeverityWarning,
+		Description: "lint.rules.skip_checksum",
+		check:       checkSkipChecksum,
+	},
+	{
+		ID:          "srcdir-outside-function",
+		Severity:    SeverityError,
+		Description: "lint.rules.srcdir_outside_function",
+		check:       checkSrcdirOutsideFunction,
+	},
+	{
+		ID:          "unknown-override",
+		Severity:    SeverityWarning,
+		Description: "lint.rules.unknown_override",
+		check:       checkUnknownOverride,
+	},
+}
+
+// element is an expanded array element.
+type element struct {
+	pos   syntax.Pos
+	value string
+}
+
+// elements returns the expanded elements of an array assignment, or its
+// value for a scalar one.
+func (in *input) elements(assign *syntax.Assign) []element {
+	if assign.Array == nil {
+		return []element{{pos: assign.Pos(), value: in.expand(assign.Value)}}
+	}
+
+	elems := make([]element, 0, len(assign.Array.Elems))
+
+	for _, elem := range assign.Array.Elems {
+		elems = append(elems, element{pos: elem.Pos(), value: in.expand(elem.Value)})
+	}
+
+	return elems
+}
+
+// counterpart returns the key array paired with an array carrying suffix:
+// the one with the same suffix or, for a distro override, the array it
+// overrides.
+func (in *input) counterpart(key, suffix string) *syntax.Assign {
+	if assign := in.lookup(key + suffix); assign != nil {
+		return assign
+	}
+
+	if arch, _, found := strings.Cut(suffix, "__"); found {
+		return in.lookup(key + arch)
+	}
+
+	return nil
+}
+
+// sourceURIs returns the URLs of a source entry: the primary one followed
+// by its "|"-separated mirrors.
+func sourceURIs(entry string) []string {
+	if _, uri, found := strings.Cut(entry, "::"); found {
+		entry = uri
+	}
+
+	return strings.Split(entry, "|")
+}
+
+// sourceScheme returns the VCS (empty for plain downloads) and transport
+// of a source URL, e.g. ("git", "https") for "git+https://...".
+func sourceScheme(uri string) (vcs, transport string) {
+	scheme, _, found := strings.Cut(uri, "://")
+	if !found {
+		return "", ""
+	}
+
+	scheme = strings.ToLower(scheme)
+
+	if prefix, rest, found := strings.Cut(scheme, "+"); found && slices.Contains(vcsPrefixes, prefix) {
+		return prefix, rest
+	}
+
+	if scheme == constants.Git {
+		return constants.Git, constants.Git
+	}
+
+	return "", scheme
+}
+
+// isSignature reports whether a source entry is a detached signature.
+func isSignature(entry string) bool {
+	name, _, found := strings.Cut(entry, "::")
+	if !found {
+		name = entry
+	}
+
+	for _, ext := range []string{".sig", ".sign", ".asc"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// depName strips the version constraint and description from a dependency.
+func depName(dep string) string {
+	name, _, _ := strings.Cut(dep, ":")
+
+	return strings.TrimSpace(strings.FieldsFunc(name+" ", func(r rune) bool {
+		return r == '<' || r == '>' || r == '='
+	})[0])
+}
+
+// checkChecksumCount reports checksum arrays whose length differs from
+// their source array, and source arrays without any checksum array.
+func checkChecksumCount(in *input, report func(syntax.Pos, string)) {
+	for _, source := range in.variants(sourceKey) {
+		suffix, _ := overrideSuffix(source.Name.Value, sourceKey)
+		entries := in.elements(source)
+		found := false
+
+		for _, key := range checksumKeys {
+			sums := in.counterpart(key, suffix)
+			if sums == nil {
+				continue
+			}
+
+			found = true
+
+			if count := len(in.elements(sums)); count != len(entries) {
+				report(sums.Pos(), fmt.Sprintf(i18n.T("lint.messages.checksum_count"),
+					sums.Name.Value, count, source.Name.Value, len(entries)))
+			}
+		}
+
+		if !found && len(entries) > 0 {
+			report(source.Pos(), fmt.Sprintf(i18n.T("lint.messages.checksum_missing"),
+				source.Name.Value))
+		}
+	}
+}
+
+// checkSkipChecksum reports SKIP checksums of downloaded sources. VCS
+// checkouts, detached signatures and local files are exempt.
+func checkSkipChecksum(in *input, report func(syntax.Pos, string)) {
+	for _, source := range in.variants(sourceKey) {
+		suffix, _ := overrideSuffix(source.Name.Value, sourceKey)
+		entries := in.elements(source)
+
+		for _, key := range checksumKeys {
+			sums := in.counterpart(key, suffix)
+			if sums == nil {
+				continue
+			}
+
+			for index, sum := range in.elements(sums) {
+				if sum.value != skipValue || index >= len(entries) {
+					continue
+				}
+
+				entry := entries[index].value
+				uri := sourceURIs(entry)[0]
+
+				if vcs, _ := sourceScheme(uri); vcs != "" || !strings.Contains(uri, "://") ||
+					isSignature(entry) {
+					continue
+				}
+
+				report(sum.pos, fmt.Sprintf(i18n.T("lint.messages.skip_checksum"), uri))
+			}
+		}
+	}
+}
+
+// checkInsecureSource reports sources fetched over plain-text protocols.
+func checkInsecureSource(in *input, report func(syntax.Pos, string)) {
+	for _, source := range in.variants(sourceKey) {
+		for _, entry := range in.elements(source) {
+			for _, uri := range sourceURIs(entry.value) {
+				switch _, transport := sourceScheme(uri); transport {
+				case "http", "ftp", constants.Git:
+					report(entry.pos, fmt.Sprintf(i18n.T("lint.messages.insecure_source"), uri))
+				}
+			}
+		}
+	}
+}
+
+// checkUnknownOverride reports variables and functions whose "__" suffix
+// matches no supported distro or package manager, and is therefore never
+// used.
+func checkUnknownOverride(in *input, report func(syntax.Pos, string)) {
+	check := func(pos syntax.Pos, name string) {
+		_, target, found := strings.Cut(name, "__")
+		if !found || knownOverride(target) {
+			return
+		}
+
+		report(pos, fmt.Sprintf(i18n.T("lint.messages.unknown_override"), target, name))
+	}
+
+	for _, assign := range in.assigns {
+		check(assign.Pos(), assign.Name.Value)
+	}
+
+	for _, fn := range in.funcs {
+		check(fn.Pos(), fn.Name.Value)
+	}
+}
+
+// knownOverride reports whether target is a distro, a package manager or
+// a distro_codename pair.
+func knownOverride(target string) bool {
+	if constants.DistrosSet.Contains(target) || constants.PackagersSet.Contains(target) {
+		return true
+	}
+
+	distro, codename, found := strings.Cut(target, "_")
+
+	return found && codename != "" && constants.DistrosSet.Contains(distro)
+}
+
+// checkDependsInMakedepends reports build-time dependencies that are
+// already runtime dependencies, and hence always installed.
+func checkDependsInMakedepends(in *input, report func(syntax.Pos, string)) {
+	for _, key := range []string{"makedepends", "checkdepends"} {
+		for _, assign := range in.variants(key) {
+			suffix, _ := overrideSuffix(assign.Name.Value, key)
+
+			depends := in.counterpart(dependsKey, suffix)
+			if depends == nil {
+				continue
+			}
+
+			runtime := make(map[string]bool)
+			for _, dep := range in.elements(depends) {
+				runtime[depName(dep.value)] = true
+			}
+
+			for _, dep := range in.elements(assign) {
+				if name := depName(dep.value); runtime[name] {
+					report(dep.pos, fmt.Sprintf(i18n.T("lint.messages.depends_in_makedepends"),
+						name, assign.Name.Value, depends.Name.Value))
+				}
+			}
+		}
+	}
+}
+
+// checkSrcdirOutsideFunction reports $srcdir and $pkgdir used outside
+// functions, where they are not defined yet.
+func checkSrcdirOutsideFunction(in *input, report func(syntax.Pos, string)) {
+	syntax.Walk(in.file, func(node syntax.Node) bool {
+		switch node := node.(type) {
+		case *syntax.FuncDecl:
+			return false
+		case *syntax.ParamExp:
+			if node.Param == nil {
+				return true
+			}
+
+			switch node.Param.Value {
+			case "srcdir", "pkgdir":
+				report(node.Pos(), fmt.Sprintf(i18n.T("lint.messages.srcdir_outside_function"),
+					node.Param.Value))
+			}
+		}
+
+		return true
+	})
+}
+
+// checkInvalidLicense reports license entries that are neither SPDX
+// expressions nor the PROPRIETARY and CUSTOM placeholders.
+func checkInvalidLicense(in *input, report func(syntax.Pos, string)) {
+	for _, assign := range in.variants(licenseKey) {
+		for _, license := range in.elements(assign) {
+			if !pkgbuild.IsValidLicense(license.value) {
+				report(license.pos, fmt.Sprintf(i18n.T("lint.messages.invalid_license"),
+					license.value))
+			}
+		}
+	}
+}
+
+// checkInvalidVersion reports pkgver, pkgrel and epoch values makepkg
+// would reject. Values that cannot be expanded statically are skipped.
+func checkInvalidVersion(in *input, report func(syntax.Pos, string)) {
+	checks := []struct {
+		key       string
+		messageID string
+		valid     func(string) bool
+	}{
+		{key: "pkgver", messageID: "lint.messages.invalid_pkgver", valid: validPkgVer},
+		{key: "pkgrel", messageID: "lint.messages.invalid_pkgrel", valid: pkgrelRe.MatchString},
+		{key: "epoch", messageID: "lint.messages.invalid_epoch", valid: epochRe.MatchString},
+	}
+
+	for _, check := range checks {
+		for _, assign := range in.variants(check.key) {
+			if assign.Array != nil {
+				continue
+			}
+
+			value := in.expand(assign.Value)
+			if strings.Contains(value, "$") || check.valid(value) {
+				continue
+			}
+
+			report(assign.Pos(), fmt.Sprintf(i18n.T(check.messageID), value))
+		}
+	}
+}
+
+// validPkgVer reports whether pkgver is non-empty and free of the
+// characters makepkg reserves as separators.
+func validPkgVer(pkgver string) bool {
+	return pkgver != "" && !strings.ContainsFunc(pkgver, func(r rune) bool {
+		return r == ':' || r == '/' || r == '-' || unicode.IsSpace(r)
+	})
+}
+
+// checkMissingArch reports a PKGBUILD without an arch array and, for cross
+// builds, an arch array that does not cover the target architecture.
+func checkMissingArch(in *input, report func(syntax.Pos, string)) {
+	assigns := in.variants(archKey)
+	if len(assigns) == 0 {
+		report(syntax.Pos{}, i18n.T("lint.messages.missing_arch"))
+
+		return
+	}
+
+	if in.opts.TargetArch == "" {
+		return
+	}
+
+	target := constants.NormalizeArchitecture(in.opts.TargetArch)
+
+	for _, arch := range in.pkg.Arch {
+		if arch == pkgbuild.ArchAny || constants.NormalizeArchitecture(arch) == target {
+			return
+		}
+	}
+
+	report(assigns[len(assigns)-1].Pos(), fmt.Sprintf(i18n.T("lint.messages.target_arch_not_declared"),
+		in.opts.TargetArch))
+}
+
+// checkMissingVariable reports missing mandatory variables and a missing
+// package() function.
+func checkMissingVariable(in *input, report func(syntax.Pos, string)) {
+	for _, key := range []string{"pkgname", "pkgver", "pkgrel", "pkgdesc"} {
+		if len(in.variants(key)) == 0 {
+			report(syntax.Pos{}, fmt.Sprintf(i18n.T("lint.messages.missing_variable"), key))
+		}
+	}
+
+	if in.pkg.Package == "" && !in.pkg.IsSplitPackage() {
+		report(syntax.Pos{}, i18n.T("lint.messages.missing_package_function"))
+	}
+}
@@ -156,6 +156,7 @@ func appendBoolFlags(c []string, a *buildArgs) []string {
 		{a.NoMakeDeps, "--no-make-deps"},
 		{a.NoBuild, "--no-build"},
 		{a.SkipHashCheck, "--skip-hash-check"},
+		{a.SkipPGPCheck, "--skip-pgp-check"},
 		{a.NoCheck, "--nocheck"},
 		{a.SkipToolchainValidation, "--skip-toolchain-validation"},
 		{a.Zap, "--zap"},
@@ -182,6 +183,8 @@ func appendStringFlags(c []string, a *buildArgs) []string {
 		{a.SBOMFormat, "--sbom-format"},
 		{a.CompressionDeb, "--compression-deb"},
 		{a.CompressionRpm, "--compression-rpm"},
+		{a.CompressionApk, "--compression-apk"},
+		{a.APKFormat, "--apk-format"},
 		{a.FromPkgName, "--from"},
 		{a.ToPkgName, "--to"},
 		{a.OnlyPkgNames, "--only"},
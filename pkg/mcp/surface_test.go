@@ -26,6 +26,7 @@ var wantTools = []string{
 	"graph",
 	"inspect",
 	"install",
+	"lint",
 	"list_artifacts",
 	"list_distros",
 	"list_images",
@@ -29,10 +29,11 @@ const buildStateUnknown = "unknown"
 // by the install switch. Extracted as constants so goconst doesn't flag the
 // repeated literals across switch/case bodies.
 const (
-	artifactFormatDeb = "deb"
-	artifactFormatRPM = "rpm"
-	artifactFormatAPK = "apk"
-	artifactFormatPkg = "pkg"
+	artifactFormatDeb  = "deb"
+	artifactFormatRPM  = "rpm"
+	artifactFormatAPK  = "apk"
+	artifactFormatPkg  = "pkg"
+	artifactFormatXbps = "xbps"
 )
 
 func registerArtifactTools(srv *mcpsdk.Server) {
@@ -55,7 +56,7 @@ var errNotConfirmed = errors.New("destructive operation requires confirm: true")
 // ----- inspect -------------------------------------------------------
 
 type inspectArgs struct {
-	Artifact string `json:"artifact" jsonschema:"path to a built package (.deb/.rpm/.apk/.pkg.tar.*)"`
+	Artifact string `json:"artifact" jsonschema:"path to a built package (.deb/.rpm/.apk/.pkg.tar.*/.xbps)"`
 }
 
 type inspectResult struct {
@@ -102,7 +103,7 @@ func registerInspect(srv *mcpsdk.Server) {
 			res.HasSPDX = true
 		}
 
-		for _, suf := range []string{".asc", ".sig"} {
+		for _, suf := range []string{".asc", ".sig", ".sig2"} {
 			if _, err := os.Stat(abs + suf); err == nil {
 				res.HasSig = true
 				break
@@ -125,6 +126,8 @@ func detectArtifactFormat(path string) string {
 		return artifactFormatAPK
 	case strings.Contains(lower, ".pkg.tar."):
 		return artifactFormatPkg
+	case strings.HasSuffix(lower, ".xbps"):
+		return artifactFormatXbps
 	default:
 		return artifactFormatUnknown
 	}
@@ -68,7 +68,7 @@ func registerPrepare(srv *mcpsdk.Server) {
 	) (*mcpsdk.CallToolResult, prepareResult, error) {
 		distro, release := command.ResolveDistroRelease(args.Distro, args.Release, "")
 
-		pm, err := packer.GetPackageManager(&pkgbuild.PKGBUILD{}, distro, "", "")
+		pm, err := packer.GetPackageManager(&pkgbuild.PKGBUILD{}, distro, packer.Options{})
 		if err != nil {
 			return nil, prepareResult{Distro: distro, Release: release, Error: err.Error()}, nil //nolint:nilerr
 		}
@@ -142,6 +142,7 @@ type buildArgs struct {
 	SkipSyncDeps            bool     `json:"skipSyncDeps,omitempty" jsonschema:"skip pkg-manager update before makedeps"`
 	NoMakeDeps              bool     `json:"noMakeDeps,omitempty" jsonschema:"skip makedeps installation entirely"`
 	SkipHashCheck           bool     `json:"skipHashCheck,omitempty" jsonschema:"disable sha verification of sources"`
+	SkipPGPCheck            bool     `json:"skipPgpCheck,omitempty" jsonschema:"disable validpgpkeys check of signed sources"`
 	NoCheck                 bool     `json:"noCheck,omitempty" jsonschema:"skip the PKGBUILD check() function"`
 	SkipToolchainValidation bool     `json:"skipToolchainValidation,omitempty" jsonschema:"skip cross toolchain checks"`
 	SkipDeps                []string `json:"skipDeps,omitempty" jsonschema:"pkgs to omit from makedeps"`
@@ -156,6 +157,8 @@ type buildArgs struct {
 	SBOMFormat              string   `json:"sbomFormat,omitempty" jsonschema:"sbom format: cyclonedx, spdx, or both"`
 	CompressionDeb          string   `json:"compressionDeb,omitempty" jsonschema:"deb compression: zstd, gzip, or xz"`
 	CompressionRpm          string   `json:"compressionRpm,omitempty" jsonschema:"rpm compression: zstd, gzip, or xz"`
+	CompressionApk          string   `json:"compressionApk,omitempty" jsonschema:"apk v3 compression: gzip or zstd"`
+	APKFormat               string   `json:"apkFormat,omitempty" jsonschema:"apk package format: v2 or v3"`
 	Sign                    bool     `json:"sign,omitempty" jsonschema:"sign produced artifacts"`
 	SignKey                 string   `json:"signKey,omitempty" jsonschema:"signing key path"`
 	SignPassphrase          string   `json:"signPassphrase,omitempty" jsonschema:"passphrase for the signing key"`
@@ -198,6 +201,10 @@ func registerBuildAndStatus(srv *mcpsdk.Server) {
 			return nil, buildStartResult{}, err
 		}
 
+		if err := validateBuildAPK(args.APKFormat, args.CompressionApk); err != nil {
+			return nil, buildStartResult{}, err
+		}
+
 		// Run cross-distro builds inside the matching yap image when invoked
 		// from a host shell; container handlers fall through to the native path.
 		if !command.IsInsideContainer() {
@@ -212,6 +219,8 @@ func registerBuildAndStatus(srv *mcpsdk.Server) {
 			Opts:           opts,
 			CompressionDeb: args.CompressionDeb,
 			CompressionRpm: args.CompressionRpm,
+			CompressionApk: args.CompressionApk,
+			APKFormat:      args.APKFormat,
 			SBOM:           args.SBOM,
 			SBOMFormat:     args.SBOMFormat,
 		}
@@ -333,6 +342,7 @@ func buildOptionsFromArgs(args *buildArgs) project.BuildOptions {
 		SkipDeps:                args.SkipDeps,
 		SkipToolchainValidation: args.SkipToolchainValidation,
 		SkipHashCheck:           args.SkipHashCheck,
+		SkipPGPCheck:            args.SkipPGPCheck,
 		NoCheck:                 args.NoCheck,
 		Zap:                     args.Zap,
 		Parallel:                args.Parallel,
@@ -420,3 +430,32 @@ func validateBuildCompression(deb, rpm string) error {
 
 	return check("rpm", rpm)
 }
+
+// validateBuildAPK validates the APK package format version and the APK v3
+// compression algorithm against the canonical sets in pkg/constants.
+func validateBuildAPK(format, compression string) error {
+	if !constants.IsSupportedAPKFormat(format) {
+		return errors.New(errors.ErrTypeValidation,
+			"unsupported apk format "+format+" (want "+
+				strings.Join(constants.SupportedAPKFormats, ", ")+")").
+			WithOperation(toolNameBuild).
+			WithContext("format", format)
+	}
+
+	if !constants.IsSupportedAPKCompression(compression) {
+		return errors.New(errors.ErrTypeValidation,
+			"unsupported apk compression "+compression+" (want "+
+				strings.Join(constants.SupportedAPKCompressions, ", ")+")").
+			WithOperation(toolNameBuild).
+			WithContext("compression", compression)
+	}
+
+	if compression != "" && format != constants.APKFormatV3 {
+		return errors.New(errors.ErrTypeValidation,
+			"apk compression "+compression+" requires apk format "+constants.APKFormatV3).
+			WithOperation(toolNameBuild).
+			WithContext("compression", compression)
+	}
+
+	return nil
+}
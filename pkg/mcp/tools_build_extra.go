@@ -398,7 +398,7 @@ type listArtifactsArgs struct {
 
 type artifactInfo struct {
 	Path         string `json:"path"`
-	Format       string `json:"format"        jsonschema:"deb, rpm, apk, pkg, or unknown"`
+	Format       string `json:"format"        jsonschema:"deb, rpm, apk, pkg, xbps, or unknown"`
 	SizeBytes    int64  `json:"sizeBytes"`
 	HasCycloneDX bool   `json:"hasCycloneDX"`
 	HasSPDX      bool   `json:"hasSPDX"`
@@ -413,7 +413,7 @@ type listArtifactsResult struct {
 func registerListArtifacts(srv *mcpsdk.Server) {
 	mcpsdk.AddTool(srv, &mcpsdk.Tool{
 		Name:        toolNameListArtifacts,
-		Description: "List built package artifacts (.deb/.rpm/.apk/.pkg.tar.*) plus sibling SBOM/sig presence.",
+		Description: "List built package artifacts (.deb/.rpm/.apk/.pkg.tar.*/.xbps) plus sibling SBOM/sig presence.",
 		Annotations: &mcpsdk.ToolAnnotations{ReadOnlyHint: true},
 	}, func(_ context.Context, _ *mcpsdk.CallToolRequest, args listArtifactsArgs,
 	) (*mcpsdk.CallToolResult, listArtifactsResult, error) {
@@ -526,7 +526,7 @@ func scanArtifacts(dir string) ([]artifactInfo, error) {
 			info.HasSPDX = true
 		}
 
-		for _, suf := range []string{".asc", ".sig"} {
+		for _, suf := range []string{".asc", ".sig", ".sig2"} {
 			if _, err := os.Stat(full + suf); err == nil {
 				info.HasSig = true
 				break
@@ -13,6 +13,7 @@ import (
 	"github.com/M0Rf30/yap/v2/pkg/errors"
 	"github.com/M0Rf30/yap/v2/pkg/graph"
 	graphloader "github.com/M0Rf30/yap/v2/pkg/graph/loader"
+	"github.com/M0Rf30/yap/v2/pkg/lint"
 	"github.com/M0Rf30/yap/v2/pkg/parser"
 	"github.com/M0Rf30/yap/v2/pkg/pkgbuild"
 )
@@ -21,6 +22,7 @@ func registerReadonlyTools(srv *mcpsdk.Server) {
 	registerResolveDistro(srv)
 	registerParsePkgbuild(srv)
 	registerValidatePkgbuild(srv)
+	registerLint(srv)
 	registerGraph(srv)
 	registerListImages(srv)
 }
@@ -159,14 +161,21 @@ type pkgbuildSummary struct {
 	PkgBase     string   `json:"pkgBase,omitempty"`
 	PkgNames    []string `json:"pkgNames,omitempty"`
 	PkgVer      string   `json:"pkgVer"`
+	HasPkgVerFn bool     `json:"hasPkgverFunc,omitempty"`
 	PkgRel      string   `json:"pkgRel"`
 	Epoch       string   `json:"epoch,omitempty"`
 	PkgDesc     string   `json:"pkgDesc"`
+	PkgDescLong string   `json:"pkgDescLong,omitempty"`
 	URL         string   `json:"url,omitempty"`
+	Homepage    string   `json:"homepage,omitempty"`
+	VcsURL      string   `json:"vcsUrl,omitempty"`
+	Vendor      string   `json:"vendor,omitempty"`
+	Keywords    []string `json:"keywords,omitempty"`
 	License     []string `json:"license,omitempty"`
 	Arch        []string `json:"arch,omitempty"`
 	Depends     []string `json:"depends,omitempty"`
 	MakeDepends []string `json:"makeDepends,omitempty"`
+	CheckDeps   []string `json:"checkDepends,omitempty"`
 	OptDepends  []string `json:"optDepends,omitempty"`
 	Provides    []string `json:"provides,omitempty"`
 	Conflicts   []string `json:"conflicts,omitempty"`
@@ -185,14 +194,21 @@ func summarisePkgbuild(p *pkgbuild.PKGBUILD) pkgbuildSummary {
 		PkgBase:     p.PkgBase,
 		PkgNames:    p.PkgNames,
 		PkgVer:      p.PkgVer,
+		HasPkgVerFn: p.PkgVerFunc != "",
 		PkgRel:      p.PkgRel,
 		Epoch:       p.Epoch,
 		PkgDesc:     p.PkgDesc,
+		PkgDescLong: p.PkgDescLong,
 		URL:         p.URL,
+		Homepage:    p.Homepage,
+		VcsURL:      p.VcsURL,
+		Vendor:      p.Vendor,
+		Keywords:    p.Keywords,
 		License:     p.License,
 		Arch:        p.Arch,
 		Depends:     p.Depends,
 		MakeDepends: p.MakeDepends,
+		CheckDeps:   p.CheckDepends,
 		OptDepends:  p.OptDepends,
 		Provides:    p.Provides,
 		Conflicts:   p.Conflicts,
@@ -315,6 +331,57 @@ func registerValidatePkgbuild(srv *mcpsdk.Server) {
 	})
 }
 
+// ----- lint ----------------------------------------------------------
+
+type lintArgs struct {
+	Path       string `json:"path"                 jsonschema:"path to PKGBUILD or its directory"`
+	Distro     string `json:"distro,omitempty"     jsonschema:"distro context"`
+	Release    string `json:"release,omitempty"    jsonschema:"release/codename context"`
+	TargetArch string `json:"targetArch,omitempty" jsonschema:"cross-compilation target arch"`
+}
+
+type lintResult struct {
+	Findings []lint.Finding `json:"findings"`
+	Errors   int            `json:"errors"   jsonschema:"number of error-severity findings"`
+	Warnings int            `json:"warnings" jsonschema:"number of warning-severity findings"`
+}
+
+func registerLint(srv *mcpsdk.Server) {
+	mcpsdk.AddTool(srv, &mcpsdk.Tool{
+		Name: "lint",
+		Description: "Lint a PKGBUILD: rule-based findings with IDs, severities and line positions " +
+			"(SKIP checksums, insecure sources, unknown overrides, invalid SPDX licenses, ...).",
+		Annotations: &mcpsdk.ToolAnnotations{ReadOnlyHint: true, IdempotentHint: true},
+	}, func(_ context.Context, _ *mcpsdk.CallToolRequest, args lintArgs,
+	) (*mcpsdk.CallToolResult, lintResult, error) {
+		dir, err := resolvePkgbuildDir(args.Path)
+		if err != nil {
+			return nil, lintResult{}, err
+		}
+
+		distro, release := command.ResolveDistroRelease(args.Distro, args.Release, "")
+
+		findings, err := lint.Lint(dir, lint.Options{
+			Distro:     distro,
+			Release:    release,
+			TargetArch: args.TargetArch,
+		})
+		if err != nil {
+			return nil, lintResult{}, err
+		}
+
+		if findings == nil {
+			findings = []lint.Finding{}
+		}
+
+		return nil, lintResult{
+			Findings: findings,
+			Errors:   lint.Count(findings, lint.SeverityError),
+			Warnings: lint.Count(findings, lint.SeverityWarning),
+		}, nil
+	})
+}
+
 // ----- graph ---------------------------------------------------------
 
 type graphArgs struct {
@@ -13,6 +13,7 @@ func TestDetectArtifactFormat(t *testing.T) {
 		"foo.apk":                "apk",
 		"foo-1.0-1.pkg.tar.zst":  "pkg",
 		"foo-1.0-1.pkg.tar.xz":   "pkg",
+		"foo-1.0_1.x86_64.xbps":  "xbps",
 		"foo.tar.gz":             "unknown",
 		"":                       "unknown",
 		"/path/to/foo.deb":       "deb",
@@ -61,6 +62,24 @@ func TestValidateBuildCompression(t *testing.T) {
 	}
 }
 
+func TestValidateBuildAPK(t *testing.T) {
+	if err := validateBuildAPK("v3", "zstd"); err != nil {
+		t.Errorf("validateBuildAPK(v3, zstd) unexpected error: %v", err)
+	}
+
+	if err := validateBuildAPK("v4", ""); err == nil {
+		t.Error("expected error for unsupported apk format")
+	}
+
+	if err := validateBuildAPK("v3", "xz"); err == nil {
+		t.Error("expected error for unsupported apk compression")
+	}
+
+	if err := validateBuildAPK("", "zstd"); err == nil {
+		t.Error("expected error for apk compression without apk format v3")
+	}
+}
+
 func TestBuildOptionsFromArgsPassthrough(t *testing.T) {
 	args := &buildArgs{
 		Verbose:         true,
@@ -10,6 +10,9 @@ type Options struct {
 	StaticEnabled    bool
 	StripEnabled     bool
 	ZipManEnabled    bool
+	// DebugDir receives the debug symbols separated while stripping; empty
+	// falls back to the directory set through SetDebugDir.
+	DebugDir string
 }
 
 // Apply runs all enabled/disabled option handlers against packageDir in the
@@ -25,7 +28,12 @@ func Apply(packageDir string, o Options) error {
 // global process environment.
 func ApplyWithEnv(packageDir string, o Options, env map[string]string) error {
 	if o.StripEnabled {
-		if err := StripWithEnv(packageDir, env); err != nil {
+		debugDir := o.DebugDir
+		if debugDir == "" {
+			debugDir = DebugDir()
+		}
+
+		if err := StripWithDebugDir(packageDir, debugDir, env); err != nil {
 			return err
 		}
 	}
@@ -67,9 +75,9 @@ func ApplyWithEnv(packageDir string, o Options, env map[string]string) error {
 		}
 	}
 
-	// NOTE: DebugEnabled is intentionally not handled here. Debug symbol separation
-	// is handled separately via the --debug-dir flag and the Strip() function in
-	// strip.go.
+	// NOTE: DebugEnabled is intentionally not handled here. The builders turn
+	// it (or --debug-dir) into a DebugDir and package the separated symbols
+	// themselves.
 
 	return nil
 }
@@ -30,6 +30,15 @@ func SetDebugDir(dir string) {
 	debugDir = dir
 }
 
+// DebugDir returns the output directory for debug symbols set through
+// SetDebugDir, or "" when debug symbols are not separated.
+func DebugDir() string {
+	debugDirMu.RLock()
+	defer debugDirMu.RUnlock()
+
+	return debugDir
+}
+
 // Strip walks through the directory to process each file. STRIP/OBJCOPY are
 // read from the process environment.
 func Strip(packageDir string) error {
@@ -41,10 +50,18 @@ func Strip(packageDir string) error {
 // safe: each goroutine can pass its own toolchain env without process-env
 // mutation.
 func StripWithEnv(packageDir string, env map[string]string) error {
+	return StripWithDebugDir(packageDir, DebugDir(), env)
+}
+
+// StripWithDebugDir is the variant of StripWithEnv separating the debug
+// symbols into debugDir, laid out as debugDir/.build-id/xx/yyyy.debug, in
+// place of the directory set through SetDebugDir. An empty debugDir
+// strips without separating.
+func StripWithDebugDir(packageDir, debugDir string, env map[string]string) error {
 	logger.Info(i18n.T("logger.options.info.stripping_binaries"))
 
 	return filepath.WalkDir(packageDir, func(p string, d fs.DirEntry, err error) error {
-		return processFileWithEnv(p, d, err, env)
+		return stripFile(p, d, err, debugDir, env)
 	})
 }
 
@@ -56,6 +73,12 @@ func processFile(binary string, dirEntry fs.DirEntry, err error) error {
 // processFileWithEnv processes a single file with an optional env overlay for
 // STRIP/OBJCOPY. env=nil falls back to os.Getenv (legacy behavior).
 func processFileWithEnv(binary string, dirEntry fs.DirEntry, err error, env map[string]string) error {
+	return stripFile(binary, dirEntry, err, DebugDir(), env)
+}
+
+// stripFile strips a single file, separating its debug info into debugDir
+// first when debugDir is set.
+func stripFile(binary string, dirEntry fs.DirEntry, err error, debugDir string, env map[string]string) error {
 	if err != nil {
 		return err
 	}
@@ -94,15 +117,8 @@ func processFileWithEnv(binary string, dirEntry fs.DirEntry, err error, env map[
 	}
 
 	// Separate debug info before stripping, if a debug directory is configured.
-	// Read debugDir under lock to prevent races with SetDebugDir in parallel builds.
-	debugDirMu.RLock()
-
-	localDebugDir := debugDir
-
-	debugDirMu.RUnlock()
-
-	if localDebugDir != "" {
-		debugFile, sepErr := binutil.SeparateDebugInfoWithEnv(binary, localDebugDir, env)
+	if debugDir != "" {
+		debugFile, sepErr := binutil.SeparateDebugInfoWithEnv(binary, debugDir, env)
 		if sepErr != nil {
 			logger.Warn(i18n.T("logger.options.warn.failed_separate_debug_info"), "binary", binary, "error", sepErr)
 		} else if debugFile != "" {
@@ -4,11 +4,14 @@ package options
 import (
 	"io/fs"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	binutil "github.com/M0Rf30/yap/v2/pkg/binary"
 )
 
 func TestDetermineStripFlags(t *testing.T) {
@@ -652,3 +655,35 @@ func TestStripIntegration(t *testing.T) {
 		assert.NoError(t, err, "File %s should still exist", filePath)
 	}
 }
+
+func TestStripWithDebugDir(t *testing.T) {
+	t.Parallel()
+
+	cc, err := exec.LookPath("cc")
+	if err != nil {
+		t.Skip("no C compiler available")
+	}
+
+	if _, err := exec.LookPath("objcopy"); err != nil {
+		t.Skip("objcopy not available")
+	}
+
+	packageDir := t.TempDir()
+	debugDir := t.TempDir()
+
+	source := filepath.Join(t.TempDir(), "foo.c")
+	require.NoError(t, os.WriteFile(source, []byte("int main(void) { return 0; }\n"), 0o600))
+
+	binary := filepath.Join(packageDir, "foo")
+	out, err := exec.CommandContext(t.Context(), cc, "-g", "-Wl,--build-id", source, "-o", binary).
+		CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	buildID := binutil.ReadBuildID(binary)
+	require.NotEmpty(t, buildID)
+
+	require.NoError(t, StripWithDebugDir(packageDir, debugDir, nil))
+
+	_, err = os.Stat(filepath.Join(debugDir, ".build-id", buildID[:2], buildID[2:]+".debug"))
+	assert.NoError(t, err, "debug symbols should be separated into the given directory")
+}
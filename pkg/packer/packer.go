@@ -5,9 +5,11 @@ import (
 	"context"
 
 	"github.com/M0Rf30/yap/v2/pkg/builders/apk"
+	"github.com/M0Rf30/yap/v2/pkg/builders/common"
 	"github.com/M0Rf30/yap/v2/pkg/builders/deb"
 	"github.com/M0Rf30/yap/v2/pkg/builders/pacman"
 	"github.com/M0Rf30/yap/v2/pkg/builders/rpm"
+	"github.com/M0Rf30/yap/v2/pkg/builders/xbps"
 	"github.com/M0Rf30/yap/v2/pkg/constants"
 	"github.com/M0Rf30/yap/v2/pkg/core"
 	"github.com/M0Rf30/yap/v2/pkg/errors"
@@ -22,6 +24,31 @@ type InstallOrExtractor interface {
 	InstallOrExtract(artifactsPath, buildDir, targetArch string) error
 }
 
+// DebugPackager is implemented by package builders that package the debug
+// symbols separated while stripping. DebugPackages must be called after
+// PrepareFakeroot; EnterDebugPackage points the builder at one of them
+// until the returned function is called.
+type DebugPackager interface {
+	DebugRoot() string
+	DebugPackages() ([]common.DebugPackage, error)
+	EnterDebugPackage(pkg common.DebugPackage) (restore func())
+}
+
+// AutoSplitter is implemented by package builders that split the files
+// matching autosplit=() into generated subpackages. Autosplit must be
+// called after package() has run and before PrepareFakeroot.
+type AutoSplitter interface {
+	Autosplit() error
+}
+
+// SourcePackager is implemented by package builders that write a source
+// package rebuilding the PKGBUILD with the native tools of the format.
+// BuildSourcePackage must be called once the sources are fetched; it
+// returns the paths of the files to sign.
+type SourcePackager interface {
+	BuildSourcePackage(ctx context.Context, output string) ([]string, error)
+}
+
 // Packer is the common interface implemented by all package managers.
 type Packer interface {
 	// BuildPackage starts the package building process and writes the final artifact
@@ -51,19 +78,26 @@ type CrossDepsExtractor interface {
 	DownloadAndExtractCrossDeps(ctx context.Context, deps []string, targetArch string) error
 }
 
+// Options configures the package builders returned by GetPackageManager.
+// Empty fields select the defaults of each builder.
+type Options struct {
+	// CompressionDeb is the compression algorithm of DEB packages.
+	CompressionDeb string
+	// CompressionRpm is the compression algorithm of RPM packages.
+	CompressionRpm string
+	// CompressionApk is the compression algorithm of APK v3 packages.
+	CompressionApk string
+	// APKFormat is the APK package format version, "v2" (the default) or "v3".
+	APKFormat string
+}
+
 // GetPackageManager returns a Packer interface based on the given package build and distribution.
 //
 // pkgBuild: A pointer to a pkgbuild.PKGBUILD struct.
 // distro: A string representing the distribution.
-// compressionDeb: Compression algorithm for DEB packages (empty string uses default).
-// compressionRpm: Compression algorithm for RPM packages (empty string uses default).
+// opts: The compression and format options of the builders.
 // Returns a Packer interface and an error if any issues occur.
-func GetPackageManager(
-	pkgBuild *pkgbuild.PKGBUILD,
-	distro string,
-	compressionDeb string,
-	compressionRpm string,
-) (Packer, error) {
+func GetPackageManager(pkgBuild *pkgbuild.PKGBUILD, distro string, opts Options) (Packer, error) {
 	pkgManager := constants.DistroToPackageManager[distro]
 
 	// Get configuration for the package manager
@@ -77,13 +111,15 @@ func GetPackageManager(
 
 	switch pkgManager {
 	case "apk":
-		return apk.NewBuilder(pkgBuild), nil
+		return apk.NewBuilder(pkgBuild, opts.APKFormat, opts.CompressionApk), nil
 	case "apt":
-		return deb.NewBuilder(pkgBuild, compressionDeb), nil
+		return deb.NewBuilder(pkgBuild, opts.CompressionDeb), nil
 	case "pacman":
 		return pacman.NewBuilder(pkgBuild), nil
+	case "xbps":
+		return xbps.NewBuilder(pkgBuild), nil
 	case "yum", "zypper":
-		return rpm.NewBuilder(pkgBuild, compressionRpm), nil
+		return rpm.NewBuilder(pkgBuild, opts.CompressionRpm), nil
 	default:
 		return nil, errors.New(errors.ErrTypeConfiguration,
 			i18n.T("errors.packer.unsupported_linux_distro")).
@@ -7,6 +7,7 @@ import (
 	"github.com/M0Rf30/yap/v2/pkg/builders/deb"
 	"github.com/M0Rf30/yap/v2/pkg/builders/pacman"
 	"github.com/M0Rf30/yap/v2/pkg/builders/rpm"
+	"github.com/M0Rf30/yap/v2/pkg/builders/xbps"
 	"github.com/M0Rf30/yap/v2/pkg/pkgbuild"
 )
 
@@ -16,7 +17,7 @@ func TestGetPackageManager_APK(t *testing.T) {
 		PkgVer:  "1.0.0",
 	}
 
-	packer, err := GetPackageManager(pkgBuild, "alpine", "", "")
+	packer, err := GetPackageManager(pkgBuild, "alpine", Options{})
 	if err != nil {
 		t.Fatalf("GetPackageManager returned error for alpine: %v", err)
 	}
@@ -41,7 +42,7 @@ func TestGetPackageManager_DEB(t *testing.T) {
 
 	for _, distro := range debDistros {
 		t.Run(distro, func(t *testing.T) {
-			packer, err := GetPackageManager(pkgBuild, distro, "", "")
+			packer, err := GetPackageManager(pkgBuild, distro, Options{})
 			if err != nil {
 				t.Fatalf("GetPackageManager returned error for %s: %v", distro, err)
 			}
@@ -64,7 +65,7 @@ func TestGetPackageManager_PKG(t *testing.T) {
 		PkgVer:  "1.0.0",
 	}
 
-	packer, err := GetPackageManager(pkgBuild, "arch", "", "")
+	packer, err := GetPackageManager(pkgBuild, "arch", Options{})
 	if err != nil {
 		t.Fatalf("GetPackageManager returned error for arch: %v", err)
 	}
@@ -79,6 +80,22 @@ func TestGetPackageManager_PKG(t *testing.T) {
 	}
 }
 
+func TestGetPackageManager_XBPS(t *testing.T) {
+	pkgBuild := &pkgbuild.PKGBUILD{
+		PkgName: "test-package",
+		PkgVer:  "1.0.0",
+	}
+
+	packer, err := GetPackageManager(pkgBuild, "void", Options{})
+	if err != nil {
+		t.Fatalf("GetPackageManager returned error for void: %v", err)
+	}
+
+	if _, ok := packer.(*xbps.Xbps); !ok {
+		t.Error("GetPackageManager did not return xbps.Xbps for void")
+	}
+}
+
 func TestGetPackageManager_RPM_YUM(t *testing.T) {
 	pkgBuild := &pkgbuild.PKGBUILD{
 		PkgName: "test-package",
@@ -89,7 +106,7 @@ func TestGetPackageManager_RPM_YUM(t *testing.T) {
 
 	for _, distro := range yumDistros {
 		t.Run(distro, func(t *testing.T) {
-			packer, err := GetPackageManager(pkgBuild, distro, "", "")
+			packer, err := GetPackageManager(pkgBuild, distro, Options{})
 			if err != nil {
 				t.Fatalf("GetPackageManager returned error for %s: %v", distro, err)
 			}
@@ -112,7 +129,7 @@ func TestGetPackageManager_RPM_Zypper(t *testing.T) {
 		PkgVer:  "1.0.0",
 	}
 
-	packer, err := GetPackageManager(pkgBuild, "opensuse-leap", "", "")
+	packer, err := GetPackageManager(pkgBuild, "opensuse-leap", Options{})
 	if err != nil {
 		t.Fatalf("GetPackageManager returned error for opensuse-leap: %v", err)
 	}
@@ -149,7 +166,7 @@ func TestGetPackageManager_Integration(t *testing.T) {
 
 	for distro, expectedType := range testCases {
 		t.Run(distro, func(t *testing.T) {
-			packer, err := GetPackageManager(pkgBuild, distro, "", "")
+			packer, err := GetPackageManager(pkgBuild, distro, Options{})
 			if err != nil {
 				t.Fatalf("GetPackageManager returned error for %s: %v", distro, err)
 			}
@@ -209,7 +226,7 @@ func TestGetPackageManager_ValidatesInterface(t *testing.T) {
 
 	for _, distro := range distros {
 		t.Run(distro, func(t *testing.T) {
-			packer, err := GetPackageManager(pkgBuild, distro, "", "")
+			packer, err := GetPackageManager(pkgBuild, distro, Options{})
 			if err != nil {
 				t.Fatalf("GetPackageManager returned error for %s: %v", distro, err)
 			}
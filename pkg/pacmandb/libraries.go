@@ -0,0 +1,233 @@
+package pacmandb
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// filesDBSuffix and syncDBSuffix name the databases Sync and pacman -Fy
+	// download, <repo>.files and <repo>.db.
+	filesDBSuffix = ".files"
+	syncDBSuffix  = ".db"
+	// libProvidePrefix marks the library provides of pacman 7, such as
+	// lib:libz.so.1.
+	libProvidePrefix = "lib:"
+)
+
+// gzipMagic starts a gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// syncPackage holds the desc and files entries of a package of a sync
+// database.
+type syncPackage struct {
+	desc, files []byte
+}
+
+// LibraryProviders returns the packages of the sync databases that provide
+// shared libraries for arch, a pacman architecture such as x86_64. Keys are
+// sonames followed by their word size, as in libz.so.1-64, from the library
+// provides (lib:libz.so.1 or libz.so=1-64) and, for the repositories with a
+// files database, the libraries under usr/lib and usr/lib32. Repositories
+// are read in name order and the first provider wins. Missing or unreadable
+// databases are skipped.
+func LibraryProviders(arch string) map[string]string {
+	return libraryProviders(pacmanSyncDir, arch)
+}
+
+func libraryProviders(syncDir, arch string) map[string]string {
+	providers := make(map[string]string)
+	native := "-" + wordSize(arch)
+
+	matches, _ := filepath.Glob(filepath.Join(syncDir, "*"+syncDBSuffix))
+	slices.Sort(matches)
+
+	for _, dbPath := range matches {
+		base := strings.TrimSuffix(dbPath, syncDBSuffix)
+
+		entries, err := readSyncFile(base + filesDBSuffix)
+		if err != nil {
+			if entries, err = readSyncFile(dbPath); err != nil {
+				continue
+			}
+		}
+
+		names := make([]string, 0, len(entries))
+		for name := range entries {
+			names = append(names, name)
+		}
+
+		slices.Sort(names)
+
+		for _, name := range names {
+			entry := entries[name]
+			if descArch := descSection(entry.desc, "%ARCH%"); len(descArch) > 0 &&
+				descArch[0] != arch && descArch[0] != "any" {
+				continue
+			}
+
+			for _, provide := range descSection(entry.desc, "%PROVIDES%") {
+				if key := libraryProvideKey(provide, native); key != "" {
+					setProvider(providers, key, name)
+				}
+			}
+
+			for _, file := range descSection(entry.files, "%FILES%") {
+				dir, soname := path.Split(file)
+				if !strings.Contains(soname, ".so") {
+					continue
+				}
+
+				switch dir {
+				case "usr/lib/":
+					setProvider(providers, soname+native, name)
+				case "usr/lib32/":
+					setProvider(providers, soname+"-32", name)
+				}
+			}
+		}
+	}
+
+	return providers
+}
+
+// readSyncFile returns the packages of the sync or files database at
+// dbPath, keyed by package name.
+func readSyncFile(dbPath string) (map[string]*syncPackage, error) {
+	data, err := os.ReadFile(filepath.Clean(dbPath))
+	if err != nil {
+		return nil, err
+	}
+
+	return readSyncDatabase(data)
+}
+
+// readSyncDatabase returns the packages of a gzip- or zstd-compressed sync
+// or files database, keyed by package name.
+func readSyncDatabase(data []byte) (map[string]*syncPackage, error) {
+	r, err := decompressDatabase(data)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = r.Close() }()
+
+	byDir := make(map[string]*syncPackage)
+	tr := tar.NewReader(r)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		dir, file := path.Split(hdr.Name)
+		if hdr.Typeflag != tar.TypeReg || (file != "desc" && file != "files") {
+			continue
+		}
+
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+
+		if byDir[dir] == nil {
+			byDir[dir] = &syncPackage{}
+		}
+
+		if file == "desc" {
+			byDir[dir].desc = content
+		} else {
+			byDir[dir].files = content
+		}
+	}
+
+	packages := make(map[string]*syncPackage, len(byDir))
+
+	for _, pkg := range byDir {
+		if name := descSection(pkg.desc, "%NAME%"); len(name) > 0 {
+			packages[name[0]] = pkg
+		}
+	}
+
+	return packages, nil
+}
+
+// decompressDatabase returns the tar stream of a database: yap writes zstd,
+// while the sync databases of Arch mirrors are gzip-compressed.
+func decompressDatabase(data []byte) (io.ReadCloser, error) {
+	if bytes.HasPrefix(data, gzipMagic) {
+		return gzip.NewReader(bytes.NewReader(data))
+	}
+
+	zr, err := zstd.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	return zr.IOReadCloser(), nil
+}
+
+// libraryProvideKey returns the key of a library provide, libz.so.1-64 for
+// lib:libz.so.1 or libz.so=1-64, and "" for other provides. native is the
+// word size suffix of the lib: provides.
+func libraryProvideKey(provide, native string) string {
+	if soname, ok := strings.CutPrefix(provide, libProvidePrefix); ok {
+		soname, _, _ = strings.Cut(soname, "=")
+
+		return soname + native
+	}
+
+	library, version, ok := strings.Cut(provide, "=")
+	if !ok || !strings.HasSuffix(library, ".so") || !strings.Contains(version, "-") {
+		return ""
+	}
+
+	return library + "." + version
+}
+
+// descSection returns the lines of a "%SECTION%" block of a desc or files
+// entry.
+func descSection(content []byte, section string) []string {
+	_, rest, ok := strings.Cut(string(content), section+"\n")
+	if !ok {
+		return nil
+	}
+
+	block, _, _ := strings.Cut(rest, "\n\n")
+	if block == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(block, "\n"), "\n")
+}
+
+// wordSize returns "32" for the 32-bit pacman architectures and "64"
+// otherwise.
+func wordSize(arch string) string {
+	switch arch {
+	case i686Arch, armv7hArch, "armv6h", "pentium4":
+		return "32"
+	default:
+		return "64"
+	}
+}
+
+// setProvider sets providers[key] unless it is already set.
+func setProvider(providers map[string]string, key, name string) {
+	if _, ok := providers[key]; !ok {
+		providers[key] = name
+	}
+}
@@ -0,0 +1,88 @@
+package pacmandb //nolint:testpackage
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLibraryProviders(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestSyncDB(t, filepath.Join(dir, "core.db"), false, map[string]string{
+		"zlib-1:1.3.1-2/desc": "%NAME%\nzlib\n\n%ARCH%\nx86_64\n\n%PROVIDES%\nlibz.so=1-64\n",
+		"curl-8.9.1-2/desc":   "%NAME%\ncurl\n\n%ARCH%\nx86_64\n\n%PROVIDES%\nlib:libcurl.so.4\n",
+		"zlib-1.3-1/desc":     "%NAME%\nzlib-arm\n\n%ARCH%\naarch64\n\n%PROVIDES%\nlib:libz.so.1\n",
+	})
+	writeTestSyncDB(t, filepath.Join(dir, "multilib.db"), true, map[string]string{
+		"lib32-zlib-1.3.1-2/desc": "%NAME%\nlib32-zlib\n\n%ARCH%\nx86_64\n\n%PROVIDES%\nlibz.so=1-32\n",
+	})
+	writeTestSyncDB(t, filepath.Join(dir, "extra.files"), true, map[string]string{
+		"bar-1.0-1/desc":  "%NAME%\nbar\n\n%ARCH%\nx86_64\n",
+		"bar-1.0-1/files": "%FILES%\nusr/\nusr/lib/\nusr/lib/libbar.so.2\nusr/lib32/libbar.so.2\nusr/share/bar.so.txt\n",
+	})
+	writeTestSyncDB(t, filepath.Join(dir, "extra.db"), false, map[string]string{
+		"bar-1.0-1/desc": "%NAME%\nbar-from-db\n\n%ARCH%\nx86_64\n",
+	})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.db"), []byte("not a database"), 0o644))
+
+	assert.Equal(t, map[string]string{
+		"libz.so.1-64":    "zlib",
+		"libz.so.1-32":    "lib32-zlib",
+		"libcurl.so.4-64": "curl",
+		"libbar.so.2-64":  "bar",
+		"libbar.so.2-32":  "bar",
+	}, libraryProviders(dir, "x86_64"))
+}
+
+func TestLibraryProviderKey(t *testing.T) {
+	assert.Equal(t, "libz.so.1-64", libraryProvideKey("lib:libz.so.1", "-64"))
+	assert.Equal(t, "libz.so.1-32", libraryProvideKey("lib:libz.so.1", "-32"))
+	assert.Equal(t, "libz.so.1-64", libraryProvideKey("libz.so=1-64", "-32"))
+	assert.Empty(t, libraryProvideKey("sh", "-64"))
+	assert.Empty(t, libraryProvideKey("python=3.12", "-64"))
+	assert.Empty(t, libraryProvideKey("libz.so=1", "-64"))
+}
+
+// writeTestSyncDB writes a database holding files, compressed with zstd or,
+// like the databases of Arch mirrors, gzip.
+func writeTestSyncDB(t *testing.T, dbPath string, useZstd bool, files map[string]string) {
+	t.Helper()
+
+	var tarball bytes.Buffer
+
+	tw := tar.NewWriter(&tarball)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+
+	var compressed bytes.Buffer
+
+	if useZstd {
+		zw, err := zstd.NewWriter(&compressed)
+		require.NoError(t, err)
+		_, err = zw.Write(tarball.Bytes())
+		require.NoError(t, err)
+		require.NoError(t, zw.Close())
+	} else {
+		gw := gzip.NewWriter(&compressed)
+		_, err := gw.Write(tarball.Bytes())
+		require.NoError(t, err)
+		require.NoError(t, gw.Close())
+	}
+
+	require.NoError(t, os.WriteFile(dbPath, compressed.Bytes(), 0o644))
+}
@@ -4,6 +4,10 @@
 // resolves $repo/$arch placeholders, fetches each <repo>.db file with
 // multi-mirror failover, and writes the result atomically to
 // /var/lib/pacman/sync/.
+//
+// WriteRepo, AddPackages and RemovePackages go the other way: they write the
+// <repo>.db and <repo>.files databases of a directory of packages, as
+// repo-add and repo-remove do, for publishing a repository.
 package pacmandb
 
 import (
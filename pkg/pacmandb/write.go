@@ -0,0 +1,543 @@
+package pacmandb
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/md5" //nolint:gosec // pacman databases record MD5 sums
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/pkginfo"
+	"github.com/M0Rf30/yap/v2/pkg/signing"
+)
+
+const (
+	// dbSuffix names the sync database, <repo>.db.tar.zst.
+	dbSuffix = ".db.tar.zst"
+	// filesSuffix names the files database, <repo>.files.tar.zst.
+	filesSuffix = ".files.tar.zst"
+	// packageSuffix names the packages a repository is made of.
+	packageSuffix = ".pkg.tar.zst"
+	// sigSuffix names the binary detached signature of a file.
+	sigSuffix = ".sig"
+)
+
+// RepoOptions configures WriteRepo, AddPackages and RemovePackages.
+type RepoOptions struct {
+	// Signer signs both databases into binary detached .sig files. When nil
+	// the databases are unsigned and stale signatures are removed.
+	Signer *signing.GPGSigner
+}
+
+// repoEntry is a package of a repository database: the <name>-<version>
+// directory holding it and the contents of its desc and files entries.
+type repoEntry struct {
+	name      string
+	dir       string
+	desc      []byte
+	files     []byte
+	buildDate int64
+}
+
+// WriteRepo writes the <name>.db.tar.zst and <name>.files.tar.zst databases
+// of the .pkg.tar.zst packages in dir, as repo-add does for a new
+// repository, and returns their paths. When a package name appears twice,
+// the most recent build wins.
+func WriteRepo(ctx context.Context, dir, name string, opts RepoOptions) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*"+packageSuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(matches) == 0 {
+		return nil, errors.New(errors.ErrTypeValidation, "no pacman packages to index").
+			WithOperation("WriteRepo").
+			WithContext("dir", dir)
+	}
+
+	entries := make(map[string]*repoEntry, len(matches))
+
+	for _, pkgPath := range matches {
+		entry, err := readPackageEntry(pkgPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if existing, ok := entries[entry.name]; !ok || entry.buildDate > existing.buildDate {
+			entries[entry.name] = entry
+		}
+	}
+
+	return writeDatabases(ctx, filepath.Join(dir, name), entries, opts)
+}
+
+// AddPackages adds the packages to the repository whose sync database is
+// dbPath, <repo>.db or <repo>.db.tar.zst, as repo-add does: both databases
+// are created when missing and an entry of the same package name is
+// replaced. It returns the paths of the databases.
+func AddPackages(ctx context.Context, dbPath string, pkgPaths []string, opts RepoOptions) ([]string, error) {
+	base, err := repoBase(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := readDatabases(base)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pkgPath := range pkgPaths {
+		entry, err := readPackageEntry(pkgPath)
+		if err != nil {
+			return nil, err
+		}
+
+		entries[entry.name] = entry
+	}
+
+	return writeDatabases(ctx, base, entries, opts)
+}
+
+// RemovePackages removes the named packages from the repository whose sync
+// database is dbPath, as repo-remove does, and returns the paths of the
+// databases. Removing a package the repository does not list is an error.
+func RemovePackages(ctx context.Context, dbPath string, names []string, opts RepoOptions) ([]string, error) {
+	base, err := repoBase(dbPath)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := readDatabases(base)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		if _, ok := entries[name]; !ok {
+			return nil, errors.New(errors.ErrTypeValidation, "package not found in repository database").
+				WithOperation("RemovePackages").
+				WithContext("package", name).
+				WithContext("database", dbPath)
+		}
+
+		delete(entries, name)
+	}
+
+	return writeDatabases(ctx, base, entries, opts)
+}
+
+// repoBase returns the path of a repository without the database suffix,
+// e.g. "repo/custom" for "repo/custom.db.tar.zst".
+func repoBase(dbPath string) (string, error) {
+	for _, suffix := range []string{dbSuffix, ".db"} {
+		if base, ok := strings.CutSuffix(dbPath, suffix); ok && filepath.Base(base) != "" {
+			return base, nil
+		}
+	}
+
+	return "", errors.New(errors.ErrTypeValidation, "repository database must end in .db or .db.tar.zst").
+		WithOperation("repoBase").
+		WithContext("database", dbPath)
+}
+
+// readPackageEntry reads the .PKGINFO and file list of a package and
+// returns its desc and files entries.
+func readPackageEntry(pkgPath string) (*repoEntry, error) {
+	data, err := os.ReadFile(filepath.Clean(pkgPath))
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypeFileSystem, "failed to read package").
+			WithOperation("readPackageEntry").
+			WithContext("path", pkgPath)
+	}
+
+	pkgInfo, files, err := readPackageArchive(data)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypeParser, "failed to read package archive").
+			WithOperation("readPackageEntry").
+			WithContext("path", pkgPath)
+	}
+
+	info := pkginfo.Parse(pkgInfo)
+
+	name, version := info.First("pkgname"), info.First("pkgver")
+	if name == "" || version == "" {
+		return nil, errors.New(errors.ErrTypeParser, ".PKGINFO lacks pkgname or pkgver").
+			WithOperation("readPackageEntry").
+			WithContext("path", pkgPath)
+	}
+
+	md5sum := md5.Sum(data) //nolint:gosec // pacman databases record MD5 sums
+	sha256sum := sha256.Sum256(data)
+
+	var pgpsig string
+	if sig, err := os.ReadFile(filepath.Clean(pkgPath + sigSuffix)); err == nil {
+		pgpsig = base64.StdEncoding.EncodeToString(sig)
+	}
+
+	var desc bytes.Buffer
+
+	for _, field := range []struct {
+		key    string
+		values []string
+	}{
+		{"FILENAME", []string{filepath.Base(pkgPath)}},
+		{"NAME", []string{name}},
+		{"BASE", info["pkgbase"]},
+		{"VERSION", []string{version}},
+		{"DESC", info["pkgdesc"]},
+		{"GROUPS", info["group"]},
+		{"CSIZE", []string{strconv.Itoa(len(data))}},
+		{"ISIZE", info["size"]},
+		{"MD5SUM", []string{hex.EncodeToString(md5sum[:])}},
+		{"SHA256SUM", []string{hex.EncodeToString(sha256sum[:])}},
+		{"PGPSIG", []string{pgpsig}},
+		{"URL", info["url"]},
+		{"LICENSE", info["license"]},
+		{"ARCH", info["arch"]},
+		{"BUILDDATE", info["builddate"]},
+		{"PACKAGER", info["packager"]},
+		{"REPLACES", info["replaces"]},
+		{"CONFLICTS", info["conflict"]},
+		{"PROVIDES", info["provides"]},
+		{"DEPENDS", info["depend"]},
+		{"OPTDEPENDS", info["optdepend"]},
+		{"MAKEDEPENDS", info["makedepend"]},
+		{"CHECKDEPENDS", info["checkdepend"]},
+	} {
+		writeDescField(&desc, field.key, field.values)
+	}
+
+	fileList := bytes.NewBufferString("%FILES%\n")
+	for _, file := range files {
+		fileList.WriteString(file + "\n")
+	}
+
+	buildDate, _ := strconv.ParseInt(info.First("builddate"), 10, 64)
+
+	return &repoEntry{
+		name:      name,
+		dir:       name + "-" + version,
+		desc:      desc.Bytes(),
+		files:     fileList.Bytes(),
+		buildDate: buildDate,
+	}, nil
+}
+
+// readPackageArchive returns the .PKGINFO of a zstd-compressed package and
+// its sorted file list, directories ending in a slash, leaving out the
+// dot-files of its top directory as repo-add does.
+func readPackageArchive(data []byte) (pkgInfo string, files []string, err error) {
+	zr, err := zstd.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", nil, err
+	}
+	defer zr.Close()
+
+	tr := tar.NewReader(zr)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return "", nil, err
+		}
+
+		name := strings.TrimPrefix(hdr.Name, "./")
+
+		if name == ".PKGINFO" {
+			content, err := io.ReadAll(io.LimitReader(tr, 1<<20)) // 1 MiB cap
+			if err != nil {
+				return "", nil, err
+			}
+
+			pkgInfo = string(content)
+
+			continue
+		}
+
+		if name == "" || strings.HasPrefix(name, ".") {
+			continue
+		}
+
+		if hdr.Typeflag == tar.TypeDir && !strings.HasSuffix(name, "/") {
+			name += "/"
+		}
+
+		files = append(files, name)
+	}
+
+	if pkgInfo == "" {
+		return "", nil, errors.New(errors.ErrTypeParser, "package has no .PKGINFO").
+			WithOperation("readPackageArchive")
+	}
+
+	slices.Sort(files)
+
+	return pkgInfo, slices.Compact(files), nil
+}
+
+// writeDescField writes a %KEY% section of a desc entry, one value per line.
+// Empty sections are left out.
+func writeDescField(desc *bytes.Buffer, key string, values []string) {
+	values = slices.DeleteFunc(slices.Clone(values), func(v string) bool { return v == "" })
+	if len(values) == 0 {
+		return
+	}
+
+	desc.WriteString("%" + key + "%\n")
+
+	for _, value := range values {
+		desc.WriteString(value + "\n")
+	}
+
+	desc.WriteString("\n")
+}
+
+// readDatabases returns the entries of the repository at base, keyed by
+// package name, and none when it does not exist yet. The files database is
+// preferred as it holds both the desc and files entries.
+func readDatabases(base string) (map[string]*repoEntry, error) {
+	entries := make(map[string]*repoEntry)
+
+	for _, dbPath := range []string{base + filesSuffix, base + dbSuffix} {
+		data, err := os.ReadFile(filepath.Clean(dbPath))
+		if os.IsNotExist(err) {
+			continue
+		}
+
+		if err == nil {
+			err = readDatabase(data, entries)
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrTypeParser, "failed to read repository database").
+				WithOperation("readDatabases").
+				WithContext("database", dbPath)
+		}
+
+		break
+	}
+
+	return entries, nil
+}
+
+// readDatabase adds the entries of a database to entries. Databases written
+// by repo-add are gzip-compressed, yap's are zstd-compressed.
+func readDatabase(data []byte, entries map[string]*repoEntry) error {
+	r, err := decompressDatabase(data)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.Close() }()
+
+	byDir := make(map[string]*repoEntry)
+	tr := tar.NewReader(r)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		dir, file := path.Split(hdr.Name)
+		if hdr.Typeflag != tar.TypeReg || (file != "desc" && file != "files") {
+			continue
+		}
+
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+
+		dir = strings.TrimSuffix(dir, "/")
+		if byDir[dir] == nil {
+			byDir[dir] = &repoEntry{dir: dir}
+		}
+
+		if file == "desc" {
+			byDir[dir].desc = content
+		} else {
+			byDir[dir].files = content
+		}
+	}
+
+	for _, entry := range byDir {
+		entry.name = descName(entry.desc)
+		if entry.name != "" {
+			entries[entry.name] = entry
+		}
+	}
+
+	return nil
+}
+
+// descName returns the %NAME% of a desc entry.
+func descName(desc []byte) string {
+	_, rest, ok := strings.Cut(string(desc), "%NAME%\n")
+	if !ok {
+		return ""
+	}
+
+	name, _, _ := strings.Cut(rest, "\n")
+
+	return name
+}
+
+// writeDatabases writes the sync and files databases of entries at base,
+// each with the <repo>.db and <repo>.files symlinks pacman downloads, and
+// signs them when opts has a signer.
+func writeDatabases(ctx context.Context, base string, entries map[string]*repoEntry,
+	opts RepoOptions,
+) ([]string, error) {
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	paths := make([]string, 0, 2)
+
+	for _, db := range []struct {
+		suffix, link string
+		files        bool
+	}{{dbSuffix, ".db", false}, {filesSuffix, ".files", true}} {
+		dbPath := base + db.suffix
+
+		data, err := buildDatabase(entries, names, db.files)
+		if err == nil {
+			//nolint:gosec // repository databases are public
+			err = os.WriteFile(dbPath, data, 0o644)
+		}
+
+		if err == nil {
+			err = replaceSymlink(filepath.Base(dbPath), base+db.link)
+		}
+
+		if err == nil {
+			err = signDatabase(ctx, dbPath, base+db.link, opts.Signer)
+		}
+
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrTypeFileSystem, "failed to write repository database").
+				WithOperation("writeDatabases").
+				WithContext("database", dbPath)
+		}
+
+		paths = append(paths, dbPath)
+	}
+
+	return paths, nil
+}
+
+// buildDatabase returns a zstd-compressed tar archive with a directory per
+// package holding its desc entry, and its files entry too when files is set.
+func buildDatabase(entries map[string]*repoEntry, names []string, files bool) ([]byte, error) {
+	var buf bytes.Buffer
+
+	zw, err := zstd.NewWriter(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	type dbFile struct {
+		name string
+		data []byte
+	}
+
+	tw := tar.NewWriter(zw)
+	modTime := time.Now()
+
+	for _, name := range names {
+		entry := entries[name]
+
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeDir,
+			Name:     entry.dir + "/",
+			Mode:     0o755,
+			ModTime:  modTime,
+		}); err != nil {
+			return nil, err
+		}
+
+		contents := []dbFile{{"desc", entry.desc}}
+		if files && entry.files != nil {
+			contents = append(contents, dbFile{"files", entry.files})
+		}
+
+		for _, content := range contents {
+			if err := tw.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeReg,
+				Name:     entry.dir + "/" + content.name,
+				Mode:     0o644,
+				Size:     int64(len(content.data)),
+				ModTime:  modTime,
+			}); err != nil {
+				return nil, err
+			}
+
+			if _, err := tw.Write(content.data); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// replaceSymlink points link at target, replacing whatever link was.
+func replaceSymlink(target, link string) error {
+	if err := os.Remove(link); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return os.Symlink(target, link)
+}
+
+// signDatabase signs the database at dbPath into dbPath.sig, linked from
+// link.sig, or removes both when signer is nil.
+func signDatabase(ctx context.Context, dbPath, link string, signer *signing.GPGSigner) error {
+	if signer == nil {
+		for _, stale := range []string{dbPath + sigSuffix, link + sigSuffix} {
+			if err := os.Remove(stale); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	if err := signer.Sign(ctx, dbPath); err != nil {
+		return err
+	}
+
+	return replaceSymlink(filepath.Base(dbPath)+sigSuffix, link+sigSuffix)
+}
@@ -0,0 +1,218 @@
+package pacmandb //nolint:testpackage
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteRepo(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPackage(t, dir, "foo", "1.0-1", "1700000000", "depend = glibc\ndepend = bash>=5\n")
+	writeTestPackage(t, dir, "bar", "2.0-1", "1700000000", "")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bar-2.0-1-x86_64.pkg.tar.zst.sig"), []byte("sig"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "custom.db.tar.zst.sig"), []byte("stale"), 0o644))
+
+	paths, err := WriteRepo(context.Background(), dir, "custom", RepoOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "custom.db.tar.zst"),
+		filepath.Join(dir, "custom.files.tar.zst"),
+	}, paths)
+
+	target, err := os.Readlink(filepath.Join(dir, "custom.db"))
+	require.NoError(t, err)
+	assert.Equal(t, "custom.db.tar.zst", target)
+
+	_, err = os.Stat(filepath.Join(dir, "custom.db.tar.zst.sig"))
+	assert.True(t, os.IsNotExist(err), "the stale signature was not removed")
+
+	db := readTestDatabase(t, paths[0])
+	assert.Equal(t, []string{"bar-2.0-1/", "bar-2.0-1/desc", "foo-1.0-1/", "foo-1.0-1/desc"}, db.names)
+
+	desc := db.data["foo-1.0-1/desc"]
+	assert.True(t, strings.HasPrefix(desc,
+		"%FILENAME%\nfoo-1.0-1-x86_64.pkg.tar.zst\n\n%NAME%\nfoo\n\n%BASE%\nfoo\n\n%VERSION%\n1.0-1\n\n"), desc)
+	assert.Contains(t, desc, "%ISIZE%\n2048\n\n%MD5SUM%\n")
+	assert.Contains(t, desc, "%ARCH%\nx86_64\n\n%BUILDDATE%\n1700000000\n\n")
+	assert.True(t, strings.HasSuffix(desc, "%DEPENDS%\nglibc\nbash>=5\n\n"), desc)
+	assert.NotContains(t, desc, "%PGPSIG%")
+	assert.Contains(t, db.data["bar-2.0-1/desc"], "%PGPSIG%\nc2ln\n\n")
+
+	files := readTestDatabase(t, paths[1])
+	assert.Equal(t, db.data["foo-1.0-1/desc"], files.data["foo-1.0-1/desc"])
+	assert.Equal(t, "%FILES%\nusr/\nusr/bin/\nusr/bin/foo\n", files.data["foo-1.0-1/files"])
+}
+
+func TestWriteRepoNewestBuildWins(t *testing.T) {
+	dir := t.TempDir()
+	writeTestPackage(t, dir, "foo", "1.0-1", "1700000000", "")
+	writeTestPackage(t, dir, "foo", "1.1-1", "1700000100", "")
+
+	paths, err := WriteRepo(context.Background(), dir, "custom", RepoOptions{})
+	require.NoError(t, err)
+
+	db := readTestDatabase(t, paths[0])
+	assert.Equal(t, []string{"foo-1.1-1/", "foo-1.1-1/desc"}, db.names)
+}
+
+func TestWriteRepoWithoutPackages(t *testing.T) {
+	_, err := WriteRepo(context.Background(), t.TempDir(), "custom", RepoOptions{})
+	require.Error(t, err)
+}
+
+func TestAddAndRemovePackages(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "custom.db")
+	foo := writeTestPackage(t, dir, "foo", "1.0-1", "1700000000", "")
+
+	_, err := AddPackages(context.Background(), dbPath, []string{foo}, RepoOptions{})
+	require.NoError(t, err)
+
+	bar := writeTestPackage(t, dir, "bar", "2.0-1", "1700000000", "")
+	fooUpdate := writeTestPackage(t, dir, "foo", "1.1-1", "1700000100", "")
+
+	paths, err := AddPackages(context.Background(), dbPath, []string{bar, fooUpdate}, RepoOptions{})
+	require.NoError(t, err)
+
+	files := readTestDatabase(t, paths[1])
+	assert.Equal(t, []string{
+		"bar-2.0-1/", "bar-2.0-1/desc", "bar-2.0-1/files",
+		"foo-1.1-1/", "foo-1.1-1/desc", "foo-1.1-1/files",
+	}, files.names)
+
+	paths, err = RemovePackages(context.Background(), filepath.Join(dir, "custom.db.tar.zst"),
+		[]string{"bar"}, RepoOptions{})
+	require.NoError(t, err)
+
+	db := readTestDatabase(t, paths[0])
+	assert.Equal(t, []string{"foo-1.1-1/", "foo-1.1-1/desc"}, db.names)
+
+	files = readTestDatabase(t, paths[1])
+	assert.Equal(t, "%FILES%\nusr/\nusr/bin/\nusr/bin/foo\n", files.data["foo-1.1-1/files"])
+
+	_, err = RemovePackages(context.Background(), dbPath, []string{"bar"}, RepoOptions{})
+	require.Error(t, err)
+}
+
+func TestReadDatabaseGzip(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "custom.db.tar.gz")
+	writeTestSyncDB(t, dbPath, false, map[string]string{
+		"foo-1.0-1/desc": "%NAME%\nfoo\n\n%VERSION%\n1.0-1\n",
+	})
+
+	data, err := os.ReadFile(dbPath)
+	require.NoError(t, err)
+
+	entries := make(map[string]*repoEntry)
+	require.NoError(t, readDatabase(data, entries), "repo-add databases are gzip-compressed")
+	require.Contains(t, entries, "foo")
+	assert.Equal(t, "foo-1.0-1", entries["foo"].dir)
+}
+
+func TestRepoBase(t *testing.T) {
+	base, err := repoBase("repo/custom.db.tar.zst")
+	require.NoError(t, err)
+	assert.Equal(t, "repo/custom", base)
+
+	base, err = repoBase("repo/custom.db")
+	require.NoError(t, err)
+	assert.Equal(t, "repo/custom", base)
+
+	_, err = repoBase("repo/custom.tar")
+	require.Error(t, err)
+}
+
+// writeTestPackage writes <name>-<version>-x86_64.pkg.tar.zst owning
+// /usr/bin/<name> and returns its path.
+func writeTestPackage(t *testing.T, dir, name, version, buildDate, extra string) string {
+	t.Helper()
+
+	pkgInfo := "# Generated by yap\npkgname = " + name + "\npkgbase = " + name + "\npkgver = " + version +
+		"\npkgdesc = " + name + " tool\nurl = https://example.com\nbuilddate = " + buildDate +
+		"\nsize = 2048\narch = x86_64\nlicense = MIT\n" + extra
+
+	var buf bytes.Buffer
+
+	zw, err := zstd.NewWriter(&buf)
+	require.NoError(t, err)
+
+	tw := tar.NewWriter(zw)
+
+	for _, file := range []struct {
+		name    string
+		content string
+		dir     bool
+	}{
+		{".PKGINFO", pkgInfo, false},
+		{".MTREE", "mtree", false},
+		{"usr", "", true},
+		{"usr/bin", "", true},
+		{"usr/bin/" + name, "#!/bin/sh\n", false},
+	} {
+		hdr := &tar.Header{Name: file.name, Mode: 0o644, Size: int64(len(file.content))}
+		if file.dir {
+			hdr.Typeflag = tar.TypeDir
+			hdr.Mode = 0o755
+		}
+
+		require.NoError(t, tw.WriteHeader(hdr))
+		_, err := tw.Write([]byte(file.content))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, tw.Close())
+	require.NoError(t, zw.Close())
+
+	path := filepath.Join(dir, name+"-"+version+"-x86_64.pkg.tar.zst")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o644))
+
+	return path
+}
+
+type testDatabase struct {
+	names []string
+	data  map[string]string
+}
+
+// readTestDatabase returns the entries of a repository database, in order.
+func readTestDatabase(t *testing.T, path string) testDatabase {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	zr, err := zstd.NewReader(bytes.NewReader(data))
+	require.NoError(t, err)
+
+	defer zr.Close()
+
+	db := testDatabase{data: map[string]string{}}
+	tr := tar.NewReader(zr)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		require.NoError(t, err)
+
+		content, err := io.ReadAll(tr)
+		require.NoError(t, err)
+
+		db.names = append(db.names, hdr.Name)
+		db.data[hdr.Name] = string(content)
+	}
+
+	return db
+}
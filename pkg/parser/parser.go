@@ -73,8 +73,10 @@ func ParseFile(distro, release, startDir, home, targetArch string) (*pkgbuild.PK
 		pkgBuild.PkgRel = OverridePkgRel
 	}
 
+	// An explicit version override wins over a dynamic pkgver() function.
 	if OverridePkgVer != "" {
 		pkgBuild.PkgVer = OverridePkgVer
+		pkgBuild.PkgVerFunc = ""
 	}
 
 	return pkgBuild, err
@@ -41,6 +41,9 @@ var scalarHandlers = map[string]scalarHandler{
 	pkgdescKey: {
 		apply: func(p *PKGBUILD, v string) { p.PkgDesc = v },
 	},
+	pkgdescLongKey: {
+		apply: func(p *PKGBUILD, v string) { p.PkgDescLong = v },
+	},
 	"maintainer": {
 		apply: func(p *PKGBUILD, v string) { p.Maintainer = v },
 	},
@@ -53,6 +56,15 @@ var scalarHandlers = map[string]scalarHandler{
 	"url": {
 		apply: func(p *PKGBUILD, v string) { p.URL = v },
 	},
+	homepageKey: {
+		apply: func(p *PKGBUILD, v string) { p.Homepage = v },
+	},
+	"vcs_url": {
+		apply: func(p *PKGBUILD, v string) { p.VcsURL = v },
+	},
+	"vendor": {
+		apply: func(p *PKGBUILD, v string) { p.Vendor = v },
+	},
 	"origin": {
 		apply: func(p *PKGBUILD, v string) { p.Origin = v },
 	},
@@ -104,6 +116,9 @@ var arrayHandlers = map[string]arrayHandler{
 	"copyright": func(p *PKGBUILD, v []string, _ int) {
 		p.Copyright = v
 	},
+	keywordsKey: func(p *PKGBUILD, v []string, _ int) {
+		p.Keywords = v
+	},
 	licenseKey: func(p *PKGBUILD, v []string, _ int) {
 		p.License = v
 	},
@@ -120,6 +135,9 @@ var arrayHandlers = map[string]arrayHandler{
 	makedependsKey: func(p *PKGBUILD, v []string, _ int) {
 		p.MakeDepends = v
 	},
+	checkdependsKey: func(p *PKGBUILD, v []string, _ int) {
+		p.CheckDepends = v
+	},
 	"provides": func(p *PKGBUILD, v []string, _ int) {
 		p.Provides = v
 	},
@@ -155,12 +173,51 @@ var arrayHandlers = map[string]arrayHandler{
 			p.SourceURI = v
 		}
 	},
+	sourceMirrorsKey: func(p *PKGBUILD, v []string, _ int) {
+		p.SourceMirrors = v
+	},
 	noextractKey: func(p *PKGBUILD, v []string, _ int) {
 		p.NoExtract = v
 	},
+	validpgpkeysKey: func(p *PKGBUILD, v []string, _ int) {
+		p.ValidPGPKeys = v
+	},
 	"backup": func(p *PKGBUILD, v []string, _ int) {
 		p.Backup = v
 	},
+	"fileowners": func(p *PKGBUILD, v []string, _ int) {
+		p.FileOwners = v
+	},
+	systemdUnitsKey: func(p *PKGBUILD, v []string, _ int) {
+		p.SystemdUnits = v
+	},
+	sysusersKey: func(p *PKGBUILD, v []string, _ int) {
+		p.SysUsers = v
+	},
+	tmpfilesKey: func(p *PKGBUILD, v []string, _ int) {
+		p.TmpFiles = v
+	},
+	alternativesKey: func(p *PKGBUILD, v []string, _ int) {
+		p.Alternatives = v
+	},
+	triggersKey: func(p *PKGBUILD, v []string, _ int) {
+		p.Triggers = v
+	},
+	ghostKey: func(p *PKGBUILD, v []string, _ int) {
+		p.GhostFiles = v
+	},
+	docFilesKey: func(p *PKGBUILD, v []string, _ int) {
+		p.DocFiles = v
+	},
+	licenseFilesKey: func(p *PKGBUILD, v []string, _ int) {
+		p.LicenseFiles = v
+	},
+	noVerifyKey: func(p *PKGBUILD, v []string, _ int) {
+		p.NoVerifyFiles = v
+	},
+	autosplitKey: func(p *PKGBUILD, v []string, _ int) {
+		p.Autosplit = v
+	},
 	pkgnameKey: func(p *PKGBUILD, v []string, _ int) {
 		// Split-package form: pkgname=('foo' 'bar')
 		// Store the list; PkgName is set to the first entry so single-package
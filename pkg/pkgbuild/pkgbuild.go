@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"text/template"
@@ -14,6 +15,7 @@ import (
 	mvdanshell "mvdan.cc/sh/v3/shell"
 	"mvdan.cc/sh/v3/syntax"
 
+	"github.com/M0Rf30/yap/v2/pkg/alternatives"
 	"github.com/M0Rf30/yap/v2/pkg/apkindex"
 	"github.com/M0Rf30/yap/v2/pkg/aptcache"
 	"github.com/M0Rf30/yap/v2/pkg/aptinstall"
@@ -21,12 +23,14 @@ import (
 	"github.com/M0Rf30/yap/v2/pkg/constants"
 	"github.com/M0Rf30/yap/v2/pkg/dnfcache"
 	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/files"
 	"github.com/M0Rf30/yap/v2/pkg/i18n"
 	"github.com/M0Rf30/yap/v2/pkg/logger"
 	"github.com/M0Rf30/yap/v2/pkg/pacmandb"
 	"github.com/M0Rf30/yap/v2/pkg/platform"
 	"github.com/M0Rf30/yap/v2/pkg/set"
 	"github.com/M0Rf30/yap/v2/pkg/shell"
+	"github.com/M0Rf30/yap/v2/pkg/triggers"
 )
 
 // Architecture constants.
@@ -54,13 +58,29 @@ const (
 	s390xArch         = "s390x"
 	riscv64Arch       = "riscv64"
 	pkgdescKey        = "pkgdesc"
+	pkgdescLongKey    = "pkgdesc_long"
+	homepageKey       = "homepage"
+	keywordsKey       = "keywords"
 	pkgbaseKey        = "pkgbase"
 	pkgnameKey        = "pkgname"
 	pkgrelKey         = "pkgrel"
 	pkgverKey         = "pkgver"
 	makedependsKey    = "makedepends"
+	checkdependsKey   = "checkdepends"
+	validpgpkeysKey   = "validpgpkeys"
 	noextractKey      = "noextract"
 	sourceKey         = "source"
+	sourceMirrorsKey  = "source_mirrors"
+	systemdUnitsKey   = "systemd_units"
+	sysusersKey       = "sysusers"
+	tmpfilesKey       = "tmpfiles"
+	alternativesKey   = "alternatives"
+	triggersKey       = "triggers"
+	ghostKey          = "ghost"
+	docFilesKey       = "docfiles"
+	licenseFilesKey   = "licensefiles"
+	noVerifyKey       = "noverify"
+	autosplitKey      = "autosplit"
 	b2sumsKey         = "b2sums"
 	customKey         = "CUSTOM"
 	armv7hArch        = "armv7h"
@@ -110,6 +130,7 @@ type PKGBUILD struct {
 	BuildDate       int64
 	Changelog       string `json:"changelog,omitempty"`
 	Check           string
+	CheckDepends    []string
 	Checksum        string
 	Codename        string
 	Commit          string
@@ -118,6 +139,7 @@ type PKGBUILD struct {
 	PreDepends      []string
 	Suggests        []string
 	BuiltUsing      []string
+	BuildIDs        []string // ELF build IDs whose symbols a debug package ships
 	MultiArch       string
 	SourcePkg       string
 	Bugs            string
@@ -132,18 +154,31 @@ type PKGBUILD struct {
 	Enhances        []string
 	Supplements     []string
 	Epoch           string
+	FileOwners      []string
+	SystemdUnits    []string // systemd_units — units enabled/started by the generated scriptlets
+	SysUsers        []string // sysusers — sysusers.d files applied before the package is unpacked
+	TmpFiles        []string // tmpfiles — tmpfiles.d files applied after the package is installed
+	Alternatives    []string // alternatives — "link:name:path:priority" entries
+	Triggers        []string // triggers — "path:handler" or "name:handler" entries
+	GhostFiles      []string // ghost — runtime-created files owned but not shipped
+	DocFiles        []string // docfiles — documentation paths
+	LicenseFiles    []string // licensefiles — license text paths
+	NoVerifyFiles   []string // noverify — paths exempt from package verification
+	Autosplit       []string // autosplit — dev, doc and lang subpackages split off the package
 	Files           []string
 	FullDistroName  string
 	Group           string
 	HashSums        []string
 	HelperFunctions map[string]string
 	Home            string
+	Homepage        string // homepage — project home page, overriding url in package metadata
 	HostArch        string // Host architecture for cross-compilation (where package will run)
 	// RepoDir is the git repository root. Walks up from the yap.json directory
 	// to find a .git dir; falls back to the parent of the yap.json directory.
 	RepoDir           string
 	Install           string
 	InstalledSize     int64
+	Keywords          []string // keywords — search terms, shown where the format carries them
 	License           []string
 	Maintainer        string
 	MakeDepends       []string
@@ -151,10 +186,12 @@ type PKGBUILD struct {
 	Options           []string
 	NoExtract         []string
 	Origin            string
+	ProviderPriority  int // apk provider_priority, from the alternatives=() priorities
 	Package           string
 	PackageDir        string
 	PkgBase           string // pkgbase — shared base name for split packages; equals PkgName when not a split package
 	PkgDesc           string
+	PkgDescLong       string // pkgdesc_long — multi-line description following the pkgdesc summary
 	PkgDest           string
 	PkgName           string
 	PkgNames          []string          // pkgname array — populated for split packages; empty for single packages
@@ -162,6 +199,7 @@ type PKGBUILD struct {
 	PkgRel            string
 	PkgType           string
 	PkgVer            string
+	PkgVerFunc        string // pkgver() body — computes PkgVer from the fetched sources (VCS packages)
 	PostInst          string
 	PostRm            string
 	PostTrans         string
@@ -181,9 +219,15 @@ type PKGBUILD struct {
 	Section           string
 	SourceDir         string
 	SourceURI         []string
+	SourceMirrors     []string // source_mirrors — "|"-separated alternative URLs, parallel to SourceURI
+	SourceDownloads   []string // URL each source was downloaded from, filled by the builder; "" when not downloaded
 	StartDir          string
 	TargetArch        string // Target architecture for cross-compilation (what we're building for)
 	URL               string
+	ValidPGPKeys      []string // validpgpkeys — fingerprints trusted to sign detached source signatures
+	VcsURL            string   // vcs_url — upstream repository
+	Vendor            string
+	AutoDepsEnabled   bool
 	DebugEnabled      bool
 	DocsEnabled       bool
 	EmptyDirsEnabled  bool
@@ -546,9 +590,11 @@ func (pkgBuild *PKGBUILD) Finalize() {
 // may override, matching makepkg's pkgbuild_schema_package_overrides.
 // The __distro and _arch suffix variants are handled automatically by AddItem/parseDirective.
 var splitOverrideKeys = map[string]struct{}{
-	pkgdescKey: {}, archDistro: {}, "url": {}, licenseKey: {}, "groups": {},
+	pkgdescKey: {}, pkgdescLongKey: {}, archDistro: {}, "url": {}, homepageKey: {}, licenseKey: {}, "groups": {},
 	dependsKey: {}, "optdepends": {}, "provides": {}, "conflicts": {}, "replaces": {},
-	"backup": {}, "options": {}, "install": {}, "changelog": {},
+	"backup": {}, "options": {}, "install": {}, "changelog": {}, "fileowners": {},
+	systemdUnitsKey: {}, sysusersKey: {}, tmpfilesKey: {}, alternativesKey: {},
+	triggersKey: {}, ghostKey: {}, docFilesKey: {}, licenseFilesKey: {}, noVerifyKey: {},
 }
 
 // copySplitOverrideFields copies the scalar and slice fields that
@@ -557,7 +603,9 @@ var splitOverrideKeys = map[string]struct{}{
 // This is the single source of truth for which fields are overrideable.
 func copySplitOverrideFields(dst, src *PKGBUILD) {
 	dst.PkgDesc = src.PkgDesc
+	dst.PkgDescLong = src.PkgDescLong
 	dst.URL = src.URL
+	dst.Homepage = src.Homepage
 	dst.License = append([]string(nil), src.License...)
 	dst.Depends = append([]string(nil), src.Depends...)
 	dst.OptDepends = append([]string(nil), src.OptDepends...)
@@ -565,7 +613,18 @@ func copySplitOverrideFields(dst, src *PKGBUILD) {
 	dst.Conflicts = append([]string(nil), src.Conflicts...)
 	dst.Replaces = append([]string(nil), src.Replaces...)
 	dst.Backup = append([]string(nil), src.Backup...)
+	dst.FileOwners = append([]string(nil), src.FileOwners...)
+	dst.SystemdUnits = append([]string(nil), src.SystemdUnits...)
+	dst.SysUsers = append([]string(nil), src.SysUsers...)
+	dst.TmpFiles = append([]string(nil), src.TmpFiles...)
+	dst.Alternatives = append([]string(nil), src.Alternatives...)
+	dst.Triggers = append([]string(nil), src.Triggers...)
+	dst.GhostFiles = append([]string(nil), src.GhostFiles...)
+	dst.DocFiles = append([]string(nil), src.DocFiles...)
+	dst.LicenseFiles = append([]string(nil), src.LicenseFiles...)
+	dst.NoVerifyFiles = append([]string(nil), src.NoVerifyFiles...)
 	dst.Options = append([]string(nil), src.Options...)
+	dst.ProviderPriority = src.ProviderPriority
 
 	// Restore the priority entries for overrideable keys so that AddItem
 	// will accept the next sub-package's overrides rather than treating
@@ -651,9 +710,10 @@ func (pkgBuild *PKGBUILD) applyOverrideAssign(node syntax.Node) {
 	name := assign.Name.Value
 
 	// Strip any __distro or _arch suffix to get the base key,
-	// then check if it's a recognized override var.
+	// then check if it's a recognized override var. Keys containing an
+	// underscore themselves (systemd_units, pkgdesc_long) are matched as is.
 	baseKey, _, hasDistro := strings.Cut(name, "__")
-	if !hasDistro {
+	if _, known := splitOverrideKeys[baseKey]; !hasDistro && !known {
 		// No __ separator — try single _ for arch suffix (e.g. depends_x86_64).
 		// Use the last underscore as the split point.
 		if idx := strings.LastIndex(name, "_"); idx != -1 {
@@ -689,6 +749,50 @@ func (pkgBuild *PKGBUILD) IsSplitPackage() bool {
 	return len(pkgBuild.PkgNames) > 1
 }
 
+// AddSplitPackage appends a generated sub-package with the given
+// package_<name>() body, turning a single package into a split one whose
+// first package is the original. The top-level overrides are captured on
+// the first call, as Finalize does for declared split packages.
+func (pkgBuild *PKGBUILD) AddSplitPackage(name, funcBody string) {
+	if pkgBuild.topLevelSnap == nil {
+		snap := pkgBuild.SnapshotSplitOverrides()
+		pkgBuild.topLevelSnap = &snap
+	}
+
+	if len(pkgBuild.PkgNames) == 0 {
+		pkgBuild.PkgNames = []string{pkgBuild.PkgName}
+	}
+
+	if pkgBuild.SplitPackageFuncs == nil {
+		pkgBuild.SplitPackageFuncs = make(map[string]string)
+	}
+
+	pkgBuild.PkgNames = append(pkgBuild.PkgNames, name)
+	pkgBuild.SplitPackageFuncs[name] = funcBody
+}
+
+// HomepageURL returns the project home page: homepage when set, url
+// otherwise.
+func (pkgBuild *PKGBUILD) HomepageURL() string {
+	if pkgBuild.Homepage != "" {
+		return pkgBuild.Homepage
+	}
+
+	return pkgBuild.URL
+}
+
+// VcsBrowserURL returns vcs_url when it can be opened in a browser, an
+// http(s) address other than a clone URL ending in ".git".
+func (pkgBuild *PKGBUILD) VcsBrowserURL() string {
+	vcsURL := pkgBuild.VcsURL
+	if !strings.HasPrefix(vcsURL, "https://") && !strings.HasPrefix(vcsURL, "http://") ||
+		strings.HasSuffix(vcsURL, ".git") {
+		return ""
+	}
+
+	return vcsURL
+}
+
 // EffectivePkgBase returns the base name for this package. For split packages
 // this is PkgBase (set from the pkgbase= directive). For single packages it
 // falls back to PkgName.
@@ -808,6 +912,8 @@ func (pkgBuild *PKGBUILD) HelperFunctionsPreamble(scriptletBody string) string {
 //	while also trimming any leading or trailing spaces.
 //	"multiline": Takes a string and replaces newline characters with a newline followed by a space,
 //	effectively formatting the string for better readability in multi-line contexts.
+//	"paragraphs": Takes a multi-line text and indents every line by a space, writing empty
+//	lines as " .", the form of the extended description of a Debian control file.
 //
 // The method returns the parsed template, which can be used for rendering with data.
 func (pkgBuild *PKGBUILD) RenderSpec(script string) *template.Template {
@@ -820,6 +926,20 @@ func (pkgBuild *PKGBUILD) RenderSpec(script string) *template.Template {
 
 			return strings.Trim(ret, " \n")
 		},
+		"paragraphs": func(text string) string {
+			var ret strings.Builder
+
+			for line := range strings.Lines(strings.Trim(text, "\n")) {
+				line = strings.TrimRight(line, " \t\n")
+				if line == "" {
+					line = "."
+				}
+
+				ret.WriteString(" " + line + "\n")
+			}
+
+			return strings.TrimSuffix(ret.String(), "\n")
+		},
 	})
 
 	template.Must(tmpl.Parse(script))
@@ -901,6 +1021,25 @@ func (pkgBuild *PKGBUILD) BuildEnvironmentSlice() []string {
 	return env
 }
 
+// UpdatePkgVer replaces PkgVer with the output of the pkgver() function.
+// Surrounding whitespace is trimmed and the result is validated with the same
+// rules makepkg applies: the version must be non-empty and must not contain
+// colons, hyphens, slashes or whitespace.
+func (pkgBuild *PKGBUILD) UpdatePkgVer(newVer string) error {
+	newVer = strings.TrimSpace(newVer)
+
+	if newVer == "" || strings.ContainsAny(newVer, ":-/ \t\n") {
+		return errors.New(errors.ErrTypeValidation,
+			fmt.Sprintf(i18n.T("errors.pkgbuild.invalid_pkgver"), newVer)).
+			WithContext("pkgname", pkgBuild.PkgName).
+			WithOperation("UpdatePkgVer")
+	}
+
+	pkgBuild.PkgVer = newVer
+
+	return nil
+}
+
 // ValidateGeneral checks that mandatory items are correctly provided by the PKGBUILD
 // file.
 func (pkgBuild *PKGBUILD) ValidateGeneral() error {
@@ -924,6 +1063,55 @@ func (pkgBuild *PKGBUILD) ValidateGeneral() error {
 			"pkgname", pkgBuild.PkgName)
 	}
 
+	if len(pkgBuild.SourceMirrors) > len(pkgBuild.SourceURI) {
+		checkErrors = append(checkErrors, "source-mirrors mismatch")
+
+		logger.Error(i18n.T("logger.pkgbuild.error.too_many_source_mirrors"),
+			"pkgname", pkgBuild.PkgName)
+	}
+
+	if _, err := files.ParseOwnerRules(pkgBuild.FileOwners); err != nil {
+		checkErrors = append(checkErrors, "fileowners")
+
+		logger.Error(i18n.T("logger.pkgbuild.error.invalid_fileowners"),
+			"pkgname", pkgBuild.PkgName, "error", err)
+	}
+
+	if _, err := pkgBuild.FileFlagRules(); err != nil {
+		checkErrors = append(checkErrors, "file flags")
+
+		logger.Error(i18n.T("logger.pkgbuild.error.invalid_file_flags"),
+			"pkgname", pkgBuild.PkgName, "error", err)
+	}
+
+	if _, err := alternatives.ParseAll(pkgBuild.Alternatives); err != nil {
+		checkErrors = append(checkErrors, "alternatives")
+
+		logger.Error(i18n.T("logger.pkgbuild.error.invalid_alternatives"),
+			"pkgname", pkgBuild.PkgName, "error", err)
+	}
+
+	if entry := pkgBuild.invalidAutosplit(); entry != "" {
+		checkErrors = append(checkErrors, "autosplit")
+
+		logger.Error(i18n.T("logger.pkgbuild.error.invalid_autosplit"),
+			"pkgname", pkgBuild.PkgName, "entry", entry)
+	}
+
+	if entry := pkgBuild.invalidTrigger(); entry != "" {
+		checkErrors = append(checkErrors, "triggers")
+
+		logger.Error(i18n.T("logger.pkgbuild.error.invalid_trigger"),
+			"pkgname", pkgBuild.PkgName, "entry", entry)
+	}
+
+	if entry := pkgBuild.invalidSystemFile(); entry != "" {
+		checkErrors = append(checkErrors, "system files")
+
+		logger.Error(i18n.T("logger.pkgbuild.error.invalid_system_file"),
+			"pkgname", pkgBuild.PkgName, "entry", entry)
+	}
+
 	// Check for package() function — not required for split packages, which use
 	// package_<name>() functions instead (detected by PkgNames being non-empty).
 	if pkgBuild.Package == "" && !pkgBuild.IsSplitPackage() {
@@ -1024,6 +1212,8 @@ func (pkgBuild *PKGBUILD) mapFunctions(key string, data any) {
 		pkgBuild.Check = body
 	case "package":
 		pkgBuild.Package = body
+	case pkgverKey:
+		pkgBuild.PkgVerFunc = body
 	case "preinst":
 		pkgBuild.PreInst = body
 	case "prepare":
@@ -1234,9 +1424,113 @@ func (pkgBuild *PKGBUILD) checkLicense() bool {
 	return isValid
 }
 
+// IsValidLicense reports whether license is a valid SPDX expression or one
+// of the PROPRIETARY and CUSTOM placeholders.
+func IsValidLicense(license string) bool {
+	if license == proprietaryKey || license == customKey {
+		return true
+	}
+
+	isValid, _ := spdxexp.ValidateLicenses([]string{license})
+
+	return isValid
+}
+
+// systemdUnitSuffixes are the unit types systemd_units=() may list.
+var systemdUnitSuffixes = []string{
+	".automount", ".mount", ".path", ".service", ".slice",
+	".socket", ".swap", ".target", ".timer",
+}
+
+// invalidSystemFile returns the first entry of systemd_units=(), sysusers=()
+// or tmpfiles=() that is not a plain file name of the expected kind: a unit
+// for the former, a .conf file for the others. It returns "" when all are
+// valid.
+func (pkgBuild *PKGBUILD) invalidSystemFile() string {
+	isName := func(name string) bool {
+		return name != "" && !strings.Contains(name, "/") && !strings.HasPrefix(name, ".")
+	}
+
+	for _, unit := range pkgBuild.SystemdUnits {
+		if !isName(unit) || !slices.ContainsFunc(systemdUnitSuffixes, func(suffix string) bool {
+			return strings.HasSuffix(unit, suffix)
+		}) {
+			return unit
+		}
+	}
+
+	for _, name := range slices.Concat(pkgBuild.SysUsers, pkgBuild.TmpFiles) {
+		if !isName(name) || !strings.HasSuffix(name, ".conf") {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// FileFlagRules returns the file flags declared in ghost=(), docfiles=(),
+// licensefiles=() and noverify=().
+func (pkgBuild *PKGBUILD) FileFlagRules() ([]files.FlagRule, error) {
+	var rules []files.FlagRule
+
+	for _, declared := range []struct {
+		flags files.FileFlags
+		paths []string
+	}{
+		{files.FlagGhost, pkgBuild.GhostFiles},
+		{files.FlagDoc, pkgBuild.DocFiles},
+		{files.FlagLicense, pkgBuild.LicenseFiles},
+		{files.FlagNoVerify, pkgBuild.NoVerifyFiles},
+	} {
+		parsed, err := files.ParseFlagRules(declared.flags, declared.paths)
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, parsed...)
+	}
+
+	return rules, nil
+}
+
+// autosplitKinds are the subpackages autosplit=() can generate.
+var autosplitKinds = map[string]bool{"dev": true, "doc": true, "lang": true}
+
+// invalidAutosplit returns the first autosplit=() entry that is not a known
+// subpackage kind, or the first entry when the PKGBUILD already defines
+// split packages. It returns "" when all are valid.
+func (pkgBuild *PKGBUILD) invalidAutosplit() string {
+	for _, entry := range pkgBuild.Autosplit {
+		if !autosplitKinds[entry] || pkgBuild.IsSplitPackage() {
+			return entry
+		}
+	}
+
+	return ""
+}
+
+// invalidTrigger returns the first triggers=() entry that is malformed or
+// whose handler is not a function of the PKGBUILD. It returns "" when all
+// are valid.
+func (pkgBuild *PKGBUILD) invalidTrigger() string {
+	for _, entry := range pkgBuild.Triggers {
+		trigger, err := triggers.Parse(entry)
+		if err != nil {
+			return entry
+		}
+
+		if _, ok := pkgBuild.HelperFunctions[trigger.Handler]; !ok {
+			return entry
+		}
+	}
+
+	return ""
+}
+
 // optionDefaults maps each makepkg option name to its default enabled state.
 // Options not listed here are ignored. Negated form ("!name") always inverts.
 var optionDefaults = map[string]bool{
+	"autodeps":   true, // yap: runtime depends from ELF DT_NEEDED entries
 	"debug":      false,
 	"docs":       true,
 	"emptydirs":  true,
@@ -1249,6 +1543,7 @@ var optionDefaults = map[string]bool{
 
 func (pkgBuild *PKGBUILD) processOptions() {
 	// Apply makepkg defaults.
+	pkgBuild.AutoDepsEnabled = optionDefaults["autodeps"]
 	pkgBuild.DebugEnabled = optionDefaults["debug"]
 	pkgBuild.DocsEnabled = optionDefaults["docs"]
 	pkgBuild.EmptyDirsEnabled = optionDefaults["emptydirs"]
@@ -1273,6 +1568,8 @@ func (pkgBuild *PKGBUILD) processOptions() {
 // applyOption sets the PKGBUILD flag corresponding to the given option name.
 func (pkgBuild *PKGBUILD) applyOption(name string, enabled bool) {
 	switch name {
+	case "autodeps":
+		pkgBuild.AutoDepsEnabled = enabled
 	case "debug":
 		pkgBuild.DebugEnabled = enabled
 	case "docs":
@@ -1294,6 +1591,12 @@ func (pkgBuild *PKGBUILD) applyOption(name string, enabled bool) {
 
 // isValidArchitecture checks if the provided architecture string is a valid architecture.
 func (pkgBuild *PKGBUILD) isValidArchitecture(arch string) bool {
+	return IsValidArchitecture(arch)
+}
+
+// IsValidArchitecture reports whether arch can qualify a PKGBUILD directive,
+// as in source_x86_64 or depends_aarch64__ubuntu.
+func IsValidArchitecture(arch string) bool {
 	validArchitectures := []string{
 		x86_64Arch, i686Arch, ArchAarch64, armv7hArch, "armv6h", "armv5",
 		"ppc64", ppc64leArch, s390xArch, mipsArch, "mipsle", riscv64Arch,
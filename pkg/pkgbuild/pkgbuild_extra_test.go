@@ -307,6 +307,16 @@ func TestRestoreTopLevelOverrides_MultipleRestores(t *testing.T) {
 	assert.Equal(t, originalDesc, p.PkgDesc)
 }
 
+func TestRestoreTopLevelOverrides_ResetsProviderPriority(t *testing.T) {
+	p := newTestPKGBUILD()
+	p.Finalize()
+
+	// ShipAlternatives raises the priority of the first sub-package only.
+	p.ProviderPriority = 50
+	p.RestoreTopLevelOverrides()
+	assert.Zero(t, p.ProviderPriority, "provider_priority must not leak into the next sub-package")
+}
+
 // ---------------------------------------------------------------------------
 // helpers
 // ---------------------------------------------------------------------------
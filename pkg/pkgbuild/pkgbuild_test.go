@@ -394,6 +394,20 @@ func TestPKGBUILD_mapArrays(t *testing.T) {
 		t.Errorf("Expected Depends ['glibc', 'gcc'], got %v", pb.Depends)
 	}
 
+	// Test mapping checkdepends
+	pb.mapArrays("checkdepends", []string{"gtest", "valgrind"}, priorityBase)
+
+	if len(pb.CheckDepends) != 2 || pb.CheckDepends[0] != "gtest" || pb.CheckDepends[1] != "valgrind" {
+		t.Errorf("Expected CheckDepends ['gtest', 'valgrind'], got %v", pb.CheckDepends)
+	}
+
+	// Test mapping validpgpkeys
+	pb.mapArrays("validpgpkeys", []string{"ABCDEF0123456789ABCDEF0123456789ABCDEF01"}, priorityBase)
+
+	if len(pb.ValidPGPKeys) != 1 || pb.ValidPGPKeys[0] != "ABCDEF0123456789ABCDEF0123456789ABCDEF01" {
+		t.Errorf("Expected ValidPGPKeys ['ABCDEF0123456789ABCDEF0123456789ABCDEF01'], got %v", pb.ValidPGPKeys)
+	}
+
 	// Test mapping source
 	pb.mapArrays("source", []string{"https://example.com/source.tar.gz"}, priorityBase)
 
@@ -401,6 +415,13 @@ func TestPKGBUILD_mapArrays(t *testing.T) {
 		t.Errorf("Expected SourceURI ['https://example.com/source.tar.gz'], got %v", pb.SourceURI)
 	}
 
+	// Test mapping source_mirrors
+	pb.mapArrays("source_mirrors", []string{"https://mirror.example.com/source.tar.gz"}, priorityBase)
+
+	if len(pb.SourceMirrors) != 1 || pb.SourceMirrors[0] != "https://mirror.example.com/source.tar.gz" {
+		t.Errorf("Expected SourceMirrors ['https://mirror.example.com/source.tar.gz'], got %v", pb.SourceMirrors)
+	}
+
 	// Test mapping sha256sums
 	pb.mapArrays("sha256sums", []string{"abcd1234"}, priorityBase)
 
@@ -444,6 +465,18 @@ func TestPKGBUILD_mapFunctions(t *testing.T) {
 		t.Error("check() must not be stored as a helper function")
 	}
 
+	// Test mapping pkgver function — must land in PkgVerFunc, not PkgVer
+	pb.PkgVer = "1.0"
+	pb.mapFunctions("pkgver", FuncBody("git describe --tags"))
+
+	if pb.PkgVerFunc != "git describe --tags" {
+		t.Errorf("Expected PkgVerFunc 'git describe --tags', got '%s'", pb.PkgVerFunc)
+	}
+
+	if pb.PkgVer != "1.0" {
+		t.Errorf("pkgver() must not overwrite the static PkgVer, got '%s'", pb.PkgVer)
+	}
+
 	// Test mapping scriptlets
 	pb.mapFunctions("preinst", FuncBody("echo pre-install"))
 
@@ -1955,6 +1988,75 @@ func TestEffectivePkgBase_FallbackToPkgName(t *testing.T) {
 	}
 }
 
+func TestAddItem_Metadata(t *testing.T) {
+	pb := &PKGBUILD{Distro: "ubuntu", Codename: "noble"}
+	pb.Init()
+
+	items := map[string]any{
+		"pkgdesc_long":         "Generic description.",
+		"pkgdesc_long__ubuntu": "Ubuntu description.",
+		"url":                  "https://example.com",
+		"vcs_url":              "https://example.com/foo",
+		"vendor":               "Example",
+		"keywords":             []string{"foo", "bar"},
+	}
+
+	for key, value := range items {
+		if err := pb.AddItem(key, value); err != nil {
+			t.Fatalf("AddItem(%s) error: %v", key, err)
+		}
+	}
+
+	if pb.PkgDescLong != "Ubuntu description." {
+		t.Errorf("PkgDescLong = %q, want the ubuntu override", pb.PkgDescLong)
+	}
+
+	if pb.VcsURL != "https://example.com/foo" || pb.Vendor != "Example" || len(pb.Keywords) != 2 {
+		t.Errorf("metadata not mapped: %q %q %v", pb.VcsURL, pb.Vendor, pb.Keywords)
+	}
+
+	if got := pb.HomepageURL(); got != "https://example.com" {
+		t.Errorf("HomepageURL() = %q, want the url fallback", got)
+	}
+
+	if err := pb.AddItem("homepage", "https://foo.example.com"); err != nil {
+		t.Fatalf("AddItem(homepage) error: %v", err)
+	}
+
+	if got := pb.HomepageURL(); got != "https://foo.example.com" {
+		t.Errorf("HomepageURL() = %q, want homepage", got)
+	}
+}
+
+func TestVcsBrowserURL(t *testing.T) {
+	tests := map[string]string{
+		"https://github.com/foo/bar":     "https://github.com/foo/bar",
+		"https://github.com/foo/bar.git": "",
+		"git://example.com/foo":          "",
+		"":                               "",
+	}
+
+	for vcsURL, want := range tests {
+		pb := &PKGBUILD{VcsURL: vcsURL}
+		if got := pb.VcsBrowserURL(); got != want {
+			t.Errorf("VcsBrowserURL(%q) = %q, want %q", vcsURL, got, want)
+		}
+	}
+}
+
+func TestRenderSpec_Paragraphs(t *testing.T) {
+	pb := &PKGBUILD{PkgDescLong: "\nFirst paragraph\nwrapped.\n\nSecond paragraph.\n"}
+
+	var out strings.Builder
+	if err := pb.RenderSpec("{{paragraphs .PkgDescLong}}").Execute(&out, pb); err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+
+	if want := " First paragraph\n wrapped.\n .\n Second paragraph."; out.String() != want {
+		t.Errorf("paragraphs = %q, want %q", out.String(), want)
+	}
+}
+
 func TestAddItem_PkgBase(t *testing.T) {
 	pb := &PKGBUILD{}
 	pb.Init()
@@ -2074,6 +2176,92 @@ func TestValidateGeneral_SinglePackageMissingPackageFunc(t *testing.T) {
 	}
 }
 
+func TestValidateGeneral_TooManySourceMirrors(t *testing.T) {
+	// source_mirrors is parallel to source and cannot be longer.
+	pb := &PKGBUILD{
+		PkgName:       "foo",
+		PkgDesc:       "A test package",
+		PkgVer:        "1.0",
+		PkgRel:        "1",
+		License:       []string{"MIT"},
+		Package:       "true",
+		SourceURI:     []string{"https://example.com/foo.tar.gz"},
+		HashSums:      []string{"SKIP"},
+		SourceMirrors: []string{"https://mirror.example.com/foo.tar.gz", "https://mirror.example.com/bar.tar.gz"},
+	}
+	pb.Init()
+
+	if err := pb.ValidateGeneral(); err == nil {
+		t.Error("ValidateGeneral() should fail when source_mirrors is longer than source")
+	}
+}
+
+func TestValidateGeneral_InvalidFileOwners(t *testing.T) {
+	pb := &PKGBUILD{
+		PkgName:    "foo",
+		PkgDesc:    "A test package",
+		PkgVer:     "1.0",
+		PkgRel:     "1",
+		License:    []string{"MIT"},
+		Package:    "true",
+		FileOwners: []string{"daemon:daemon /var/lib/foo"},
+	}
+	pb.Init()
+
+	if err := pb.ValidateGeneral(); err != nil {
+		t.Errorf("ValidateGeneral() error = %v for a valid fileowners entry", err)
+	}
+
+	pb.mapArrays("fileowners", []string{"daemon:daemon"}, priorityBase)
+
+	if err := pb.ValidateGeneral(); err == nil {
+		t.Error("ValidateGeneral() should fail for a fileowners entry without a path")
+	}
+}
+
+func TestValidateGeneral_InvalidSystemFiles(t *testing.T) {
+	tests := []struct {
+		key   string
+		entry string
+		valid bool
+	}{
+		{systemdUnitsKey, "foo.service", true},
+		{systemdUnitsKey, "getty@.service", true},
+		{systemdUnitsKey, "foo.conf", false},
+		{systemdUnitsKey, "system/foo.service", false},
+		{sysusersKey, "foo.conf", true},
+		{tmpfilesKey, "foo", false},
+		{tmpfilesKey, ".conf", false},
+		{alternativesKey, "/usr/bin/editor:editor:/usr/bin/foo:50", true},
+		{alternativesKey, "/usr/bin/editor:editor:/usr/bin/foo", false},
+		{triggersKey, "/usr/share/icons:_update_icons", true},
+		{triggersKey, "ldconfig:_update_icons", true},
+		{triggersKey, "/usr/share/icons:_missing", false},
+		{triggersKey, "usr/share/icons:_update_icons", false},
+		{ghostKey, "run/foo.pid", true},
+		{docFilesKey, "/usr/share/doc/foo", true},
+		{licenseFilesKey, "usr/share/licenses/foo/", false},
+		{noVerifyKey, "var/lib/../foo", false},
+		{autosplitKey, "dev", true},
+		{autosplitKey, "devel", false},
+	}
+
+	for _, tt := range tests {
+		pb := &PKGBUILD{
+			PkgName: "foo",
+			License: []string{"MIT"},
+			Package: "true",
+		}
+		pb.Init()
+		pb.HelperFunctions["_update_icons"] = "_update_icons() {\ntrue\n}"
+		pb.mapArrays(tt.key, []string{tt.entry}, priorityBase)
+
+		if err := pb.ValidateGeneral(); (err == nil) != tt.valid {
+			t.Errorf("ValidateGeneral() with %s=(%s) error = %v, want valid %v", tt.key, tt.entry, err, tt.valid)
+		}
+	}
+}
+
 func TestMapFunctions_SplitPackageFuncs(t *testing.T) {
 	pb := &PKGBUILD{}
 	pb.Init()
@@ -2156,6 +2344,41 @@ func TestParseSplitOverrides_DistroSuffix(t *testing.T) {
 	}
 }
 
+func TestParseSplitOverrides_UnderscoreKeys(t *testing.T) {
+	pb := &PKGBUILD{Distro: "ubuntu"}
+	pb.Init()
+	pb.SystemdUnits = []string{"foo.service"}
+
+	if err := pb.ParseSplitOverrides("systemd_units=()\npkgdesc_long=\"Longer\"\n"); err != nil {
+		t.Fatalf("ParseSplitOverrides() error: %v", err)
+	}
+
+	if len(pb.SystemdUnits) != 0 || pb.PkgDescLong != "Longer" {
+		t.Errorf("SystemdUnits = %v, PkgDescLong = %q", pb.SystemdUnits, pb.PkgDescLong)
+	}
+}
+
+func TestAddSplitPackage(t *testing.T) {
+	pb := &PKGBUILD{PkgName: "foo", PkgDesc: "Foo"}
+	pb.Init()
+
+	pb.AddSplitPackage("foo-dev", "pkgdesc=\"Development files for foo\"")
+
+	if !pb.IsSplitPackage() || len(pb.PkgNames) != 2 || pb.PkgNames[0] != "foo" || pb.PkgNames[1] != "foo-dev" {
+		t.Fatalf("PkgNames = %v, want [foo foo-dev]", pb.PkgNames)
+	}
+
+	if err := pb.ParseSplitOverrides(pb.SplitPackageFuncs["foo-dev"]); err != nil {
+		t.Fatalf("ParseSplitOverrides() error: %v", err)
+	}
+
+	pb.RestoreTopLevelOverrides()
+
+	if pb.PkgDesc != "Foo" {
+		t.Errorf("PkgDesc = %q after RestoreTopLevelOverrides, want %q", pb.PkgDesc, "Foo")
+	}
+}
+
 func TestParseSplitOverrides_NonOverrideIgnored(t *testing.T) {
 	pb := &PKGBUILD{}
 	pb.Init()
@@ -2759,3 +2982,71 @@ func TestFilterInstalledPacman(t *testing.T) {
 		}
 	})
 }
+
+func TestPKGBUILD_UpdatePkgVer(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "vcs revision", input: "r42.abc1234\n", want: "r42.abc1234"},
+		{name: "dotted", input: "  1.2.3  ", want: "1.2.3"},
+		{name: "empty", input: "\n", wantErr: true},
+		{name: "hyphen", input: "1.2-3", wantErr: true},
+		{name: "colon", input: "1:2.3", wantErr: true},
+		{name: "slash", input: "1/2", wantErr: true},
+		{name: "inner space", input: "1 2", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pb := &PKGBUILD{PkgName: "test", PkgVer: "0.0.1"}
+
+			err := pb.UpdatePkgVer(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("UpdatePkgVer(%q) expected error", tt.input)
+				}
+
+				if pb.PkgVer != "0.0.1" {
+					t.Errorf("PkgVer must be unchanged on error, got '%s'", pb.PkgVer)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("UpdatePkgVer(%q) unexpected error: %v", tt.input, err)
+			}
+
+			if pb.PkgVer != tt.want {
+				t.Errorf("Expected PkgVer '%s', got '%s'", tt.want, pb.PkgVer)
+			}
+		})
+	}
+}
+
+func TestPKGBUILD_AddItem_CheckDependsDistroOverride(t *testing.T) {
+	pb := &PKGBUILD{
+		Distro:   "ubuntu",
+		Codename: "noble",
+	}
+	pb.Init()
+
+	if err := pb.AddItem("checkdepends", []string{"gtest"}); err != nil {
+		t.Fatalf("AddItem() returned error: %v", err)
+	}
+
+	if err := pb.AddItem("checkdepends__ubuntu", []string{"googletest"}); err != nil {
+		t.Fatalf("AddItem() returned error: %v", err)
+	}
+
+	if err := pb.AddItem("checkdepends__fedora", []string{"gtest-devel"}); err != nil {
+		t.Fatalf("AddItem() returned error: %v", err)
+	}
+
+	if len(pb.CheckDepends) != 1 || pb.CheckDepends[0] != "googletest" {
+		t.Errorf("Expected distro-specific CheckDepends ['googletest'], got %v", pb.CheckDepends)
+	}
+}
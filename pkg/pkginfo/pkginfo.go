@@ -0,0 +1,39 @@
+// Package pkginfo parses the .PKGINFO metadata of pacman and APK packages:
+// "key = value" lines, where keys such as depend may repeat.
+package pkginfo
+
+import "strings"
+
+// Info maps every .PKGINFO key to its values, in file order.
+type Info map[string][]string
+
+// Parse parses the "key = value" lines of a .PKGINFO. Comment lines and
+// lines without "=" are skipped; repeated keys keep every value in order.
+func Parse(content string) Info {
+	info := make(Info)
+
+	for line := range strings.SplitSeq(content, "\n") {
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		key = strings.TrimSpace(key)
+		info[key] = append(info[key], strings.TrimSpace(value))
+	}
+
+	return info
+}
+
+// First returns the first value of key, or "" when it is missing.
+func (info Info) First(key string) string {
+	if values := info[key]; len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
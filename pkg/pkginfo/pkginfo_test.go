@@ -0,0 +1,20 @@
+package pkginfo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/M0Rf30/yap/v2/pkg/pkginfo"
+)
+
+func TestParse(t *testing.T) {
+	info := pkginfo.Parse("# Generated by yap\npkgname = foo\npkgver = 1.0-1\n" +
+		"depend = glibc\ndepend = bash>=5\npkgdesc = a = b\nnot a field\n")
+
+	assert.Equal(t, "foo", info.First("pkgname"))
+	assert.Equal(t, []string{"glibc", "bash>=5"}, info["depend"])
+	assert.Equal(t, "a = b", info.First("pkgdesc"))
+	assert.Empty(t, info.First("url"))
+	assert.NotContains(t, info, "# Generated by yap")
+}
@@ -0,0 +1,301 @@
+// Package plist reads and writes the XML property lists of the xbps package
+// and repository metadata, in the layout proplib writes them.
+//
+// Dictionaries decode to Dict, arrays to []any, strings to string, integers
+// to int64 (uint64 when they overflow it), booleans to bool and data to
+// []byte. Encoding also accepts []string, []Dict, int and uint64 values.
+package plist
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+)
+
+// header starts every property list proplib writes.
+const header = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple Computer//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+`
+
+// Dict is a property list dictionary.
+type Dict map[string]any
+
+// Marshal returns the XML property list of dict, with its keys sorted.
+func Marshal(dict Dict) ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteString(header)
+
+	if err := writeValue(&buf, dict, 0); err != nil {
+		return nil, err
+	}
+
+	buf.WriteString("</plist>\n")
+
+	return buf.Bytes(), nil
+}
+
+// writeValue writes value indented by depth tabs.
+//
+//nolint:gocyclo,cyclop // one case per property list type
+func writeValue(buf *bytes.Buffer, value any, depth int) error {
+	indent := strings.Repeat("\t", depth)
+
+	switch v := value.(type) {
+	case Dict:
+		return writeDict(buf, v, depth)
+	case map[string]any:
+		return writeDict(buf, v, depth)
+	case []any:
+		return writeArray(buf, v, depth)
+	case []string:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+
+		return writeArray(buf, items, depth)
+	case []Dict:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+
+		return writeArray(buf, items, depth)
+	case string:
+		buf.WriteString(indent + "<string>")
+		_ = xml.EscapeText(buf, []byte(v))
+		buf.WriteString("</string>\n")
+	case int:
+		buf.WriteString(indent + "<integer>" + strconv.Itoa(v) + "</integer>\n")
+	case int64:
+		buf.WriteString(indent + "<integer>" + strconv.FormatInt(v, 10) + "</integer>\n")
+	case uint64:
+		buf.WriteString(indent + "<integer>" + strconv.FormatUint(v, 10) + "</integer>\n")
+	case bool:
+		buf.WriteString(indent + "<" + strconv.FormatBool(v) + "/>\n")
+	case []byte:
+		buf.WriteString(indent + "<data>" + base64.StdEncoding.EncodeToString(v) + "</data>\n")
+	default:
+		return errors.New(errors.ErrTypeValidation, i18n.T("errors.plist.unsupported_value")).
+			WithOperation("Marshal")
+	}
+
+	return nil
+}
+
+// writeDict writes a dictionary with its keys sorted.
+func writeDict[M ~map[string]any](buf *bytes.Buffer, dict M, depth int) error {
+	indent := strings.Repeat("\t", depth)
+	keys := make([]string, 0, len(dict))
+
+	for key := range dict {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	buf.WriteString(indent + "<dict>\n")
+
+	for _, key := range keys {
+		buf.WriteString(indent + "\t<key>")
+		_ = xml.EscapeText(buf, []byte(key))
+		buf.WriteString("</key>\n")
+
+		if err := writeValue(buf, dict[key], depth+1); err != nil {
+			return err
+		}
+	}
+
+	buf.WriteString(indent + "</dict>\n")
+
+	return nil
+}
+
+// writeArray writes an array.
+func writeArray(buf *bytes.Buffer, items []any, depth int) error {
+	indent := strings.Repeat("\t", depth)
+
+	buf.WriteString(indent + "<array>\n")
+
+	for _, item := range items {
+		if err := writeValue(buf, item, depth+1); err != nil {
+			return err
+		}
+	}
+
+	buf.WriteString(indent + "</array>\n")
+
+	return nil
+}
+
+// Unmarshal parses an XML property list whose root is a dictionary.
+func Unmarshal(data []byte) (Dict, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, invalid(err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local == "plist" {
+			continue
+		}
+
+		value, err := readValue(decoder, start)
+		if err != nil {
+			return nil, err
+		}
+
+		dict, ok := value.(Dict)
+		if !ok {
+			return nil, invalid(nil)
+		}
+
+		return dict, nil
+	}
+}
+
+// readValue reads the value started by start.
+//
+//nolint:gocyclo,cyclop // one case per property list type
+func readValue(decoder *xml.Decoder, start xml.StartElement) (any, error) {
+	switch start.Name.Local {
+	case "dict":
+		return readDict(decoder)
+	case "array":
+		var items []any
+
+		for {
+			element, err := nextElement(decoder)
+			if err != nil {
+				return nil, err
+			}
+
+			if element == nil {
+				return items, nil
+			}
+
+			item, err := readValue(decoder, *element)
+			if err != nil {
+				return nil, err
+			}
+
+			items = append(items, item)
+		}
+	case "true", "false":
+		if err := decoder.Skip(); err != nil {
+			return nil, invalid(err)
+		}
+
+		return start.Name.Local == "true", nil
+	}
+
+	var text string
+	if err := decoder.DecodeElement(&text, &start); err != nil {
+		return nil, invalid(err)
+	}
+
+	switch start.Name.Local {
+	case "string":
+		return text, nil
+	case "integer":
+		text = strings.TrimSpace(text)
+
+		if n, err := strconv.ParseInt(text, 0, 64); err == nil {
+			return n, nil
+		}
+
+		n, err := strconv.ParseUint(text, 0, 64)
+		if err != nil {
+			return nil, invalid(err)
+		}
+
+		return n, nil
+	case "data":
+		decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), ""))
+		if err != nil {
+			return nil, invalid(err)
+		}
+
+		return decoded, nil
+	default:
+		return nil, invalid(nil)
+	}
+}
+
+// readDict reads the key and value pairs of a dictionary up to its end.
+func readDict(decoder *xml.Decoder) (Dict, error) {
+	dict := Dict{}
+
+	for {
+		element, err := nextElement(decoder)
+		if err != nil {
+			return nil, err
+		}
+
+		if element == nil {
+			return dict, nil
+		}
+
+		if element.Name.Local != "key" {
+			return nil, invalid(nil)
+		}
+
+		var key string
+		if err := decoder.DecodeElement(&key, element); err != nil {
+			return nil, invalid(err)
+		}
+
+		valueStart, err := nextElement(decoder)
+		if err != nil {
+			return nil, err
+		}
+
+		if valueStart == nil {
+			return nil, invalid(nil)
+		}
+
+		if dict[key], err = readValue(decoder, *valueStart); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// nextElement returns the next child element, or nil at the end of the
+// enclosing one.
+func nextElement(decoder *xml.Decoder) (*xml.StartElement, error) {
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, invalid(err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			return &t, nil
+		case xml.EndElement:
+			return nil, nil
+		}
+	}
+}
+
+// invalid returns the error of a malformed property list.
+func invalid(cause error) error {
+	if cause == nil {
+		cause = io.ErrUnexpectedEOF
+	}
+
+	return errors.Wrap(cause, errors.ErrTypeValidation, i18n.T("errors.plist.invalid")).
+		WithOperation("Unmarshal")
+}
@@ -0,0 +1,85 @@
+package plist_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/M0Rf30/yap/v2/pkg/plist"
+)
+
+func TestMarshal(t *testing.T) {
+	data, err := plist.Marshal(plist.Dict{
+		"pkgver":         "foo-1.0_1",
+		"installed_size": int64(42),
+		"run_depends":    []string{"bar>=0"},
+		"preserve":       true,
+	})
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	want := `<dict>
+	<key>installed_size</key>
+	<integer>42</integer>
+	<key>pkgver</key>
+	<string>foo-1.0_1</string>
+	<key>preserve</key>
+	<true/>
+	<key>run_depends</key>
+	<array>
+		<string>bar&gt;=0</string>
+	</array>
+</dict>
+</plist>
+`
+	if !strings.HasPrefix(string(data), "<?xml") || !strings.HasSuffix(string(data), want) {
+		t.Errorf("Marshal() = %s", data)
+	}
+
+	if _, err := plist.Marshal(plist.Dict{"size": 1.5}); err == nil {
+		t.Error("Marshal() accepted a float")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	dict := plist.Dict{
+		"architecture": "x86_64",
+		"size":         int64(-1),
+		"huge":         uint64(1) << 63,
+		"public-key":   []byte("-----BEGIN PUBLIC KEY-----\n"),
+		"preserve":     false,
+		"conf_files":   []any{"/etc/foo.conf"},
+		"foo": plist.Dict{
+			"pkgver": "foo-1.0_1",
+			"files":  []any{plist.Dict{"file": "/usr/bin/foo & bar"}},
+		},
+	}
+
+	data, err := plist.Marshal(dict)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := plist.Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(got, dict) {
+		t.Errorf("Unmarshal() = %#v, want %#v", got, dict)
+	}
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	for _, data := range []string{
+		"",
+		"<plist><array></array></plist>",
+		"<plist><dict><key>a</key></dict></plist>",
+		"<plist><dict><key>a</key><integer>x</integer></dict></plist>",
+	} {
+		if _, err := plist.Unmarshal([]byte(data)); err == nil {
+			t.Errorf("Unmarshal(%q) succeeded", data)
+		}
+	}
+}
@@ -47,6 +47,11 @@ func (mpc *MultipleProject) displayPackageDependencyInfo(
 		mpc.displayDependencies("Build Dependencies", proj.Builder.PKGBUILD.MakeDepends, packageMap)
 	}
 
+	// Show check dependencies, when check() runs
+	if checkDeps := mpc.checkDeps(proj); len(checkDeps) > 0 {
+		mpc.displayDependencies("Check Dependencies", checkDeps, packageMap)
+	}
+
 	// Show installation flag
 	shouldInstall := proj.HasToInstall || runtimeDependencyMap[pkgName]
 
@@ -7,12 +7,15 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
 
 	"github.com/M0Rf30/yap/v2/pkg/aptcache"
+	"github.com/M0Rf30/yap/v2/pkg/autodeps"
 	"github.com/M0Rf30/yap/v2/pkg/builder"
+	"github.com/M0Rf30/yap/v2/pkg/builders/common"
 	yerrors "github.com/M0Rf30/yap/v2/pkg/errors"
 	"github.com/M0Rf30/yap/v2/pkg/files"
 	"github.com/M0Rf30/yap/v2/pkg/i18n"
@@ -70,10 +73,16 @@ type BuildOptions struct {
 	// SKIP in the PKGBUILD. Useful during development when iterating on
 	// sources before finalising checksums.
 	SkipHashCheck bool
+	// SkipPGPCheck disables OpenPGP verification of detached source
+	// signatures (.sig/.sign/.asc) against the PKGBUILD validpgpkeys.
+	SkipPGPCheck bool
 	// NoCheck skips the PKGBUILD check() function, mirroring makepkg's
 	// --nocheck. Useful when test suites are slow or require resources
 	// unavailable in the build environment.
 	NoCheck bool
+	// SourcePackage fetches the sources and writes a source package (a
+	// Debian .dsc or an SRPM) instead of building the binary packages.
+	SourcePackage bool
 }
 
 // extractPackageName extracts the package name from a dependency string,
@@ -134,11 +143,14 @@ type MultipleProject struct {
 	Projects       []*Project      `json:"projects"       validate:"required,dive,required"`
 	CompressionDeb string          `json:"compressionDeb" validate:""`
 	CompressionRpm string          `json:"compressionRpm" validate:""`
+	CompressionApk string          `json:"compressionApk,omitempty"`
+	APKFormat      string          `json:"apkFormat,omitempty"`
 	Signing        *signing.Config `json:"signing,omitempty"`
 	Repos          []repo.Repo     `json:"repos,omitempty" validate:"omitempty,dive"`
 	SkipDeps       []string        `json:"skipDeps,omitempty"`
 	TargetArch     string          `json:"targetArch,omitempty"`
 	DebugDir       string          `json:"debugDir,omitempty"`
+	SourceCache    string          `json:"sourceCache,omitempty"`
 	Parallel       bool            `json:"parallel,omitempty"`
 	SBOM           bool            `json:"sbom,omitempty"`
 	SBOMFormat     string          `json:"sbomFormat,omitempty"`
@@ -368,7 +380,7 @@ func (mpc *MultipleProject) MultiProject(distro, release, path string) error {
 		return err
 	}
 
-	mpc.packageManager, err = packer.GetPackageManager(&pkgbuild.PKGBUILD{}, distro, "", "")
+	mpc.packageManager, err = packer.GetPackageManager(&pkgbuild.PKGBUILD{}, distro, packer.Options{})
 	if err != nil {
 		return err
 	}
@@ -481,6 +493,14 @@ func (mpc *MultipleProject) createPackage(proj *Project) error {
 		options.SetDebugDir(absDebugDir)
 	}
 
+	// Move the autosplit=() files into generated subpackages, which are then
+	// built like those of a split PKGBUILD.
+	if splitter, ok := proj.PackageManager.(packer.AutoSplitter); ok {
+		if err := splitter.Autosplit(); err != nil {
+			return err
+		}
+	}
+
 	if proj.Builder.PKGBUILD.IsSplitPackage() {
 		return mpc.createSplitPackages(proj)
 	}
@@ -505,6 +525,10 @@ func (mpc *MultipleProject) createSinglePackage(proj *Project) error {
 		}
 	}()
 
+	if err := autodeps.Apply(proj.Builder.PKGBUILD); err != nil {
+		return err
+	}
+
 	if err := proj.PackageManager.PrepareFakeroot(ctx, mpc.Output, mpc.Opts.TargetArch); err != nil {
 		return err
 	}
@@ -519,7 +543,11 @@ func (mpc *MultipleProject) createSinglePackage(proj *Project) error {
 		return err
 	}
 
-	return mpc.runPostBuildHooks(proj, artifactPath)
+	if err := mpc.runPostBuildHooks(proj, artifactPath); err != nil {
+		return err
+	}
+
+	return mpc.createDebugPackages(ctx, proj)
 }
 
 // createSplitPackages iterates over each sub-package produced by a split PKGBUILD
@@ -544,6 +572,10 @@ func (mpc *MultipleProject) createSplitPackages(proj *Project) error {
 			}
 		}
 
+		if err := autodeps.Apply(proj.Builder.PKGBUILD); err != nil {
+			return err
+		}
+
 		if err := proj.PackageManager.PrepareFakeroot(ctx, mpc.Output, mpc.Opts.TargetArch); err != nil {
 			return err
 		}
@@ -562,6 +594,10 @@ func (mpc *MultipleProject) createSplitPackages(proj *Project) error {
 			return err
 		}
 
+		if err := mpc.createDebugPackages(ctx, proj); err != nil {
+			return err
+		}
+
 		// Clean up this sub-package's install tree.
 		if err := os.RemoveAll(pkgDir); err != nil {
 			logger.Warn(i18n.T("logger.failed_to_remove_package_directory"),
@@ -572,6 +608,104 @@ func (mpc *MultipleProject) createSplitPackages(proj *Project) error {
 	return nil
 }
 
+// createDebugPackages builds the debug packages staged while stripping the
+// current (sub-)package, signing them and generating their SBOMs like the
+// package itself. Without --debug-dir the staged trees are removed
+// afterwards.
+func (mpc *MultipleProject) createDebugPackages(ctx context.Context, proj *Project) error {
+	debugPackager, ok := proj.PackageManager.(packer.DebugPackager)
+	if !ok {
+		return nil
+	}
+
+	if root := debugPackager.DebugRoot(); root != "" && mpc.Opts.DebugDir == "" {
+		defer func() {
+			if err := os.RemoveAll(root); err != nil {
+				logger.Warn(i18n.T("logger.failed_to_remove_package_directory"),
+					"path", root, "error", err)
+			}
+		}()
+	}
+
+	debugPackages, err := debugPackager.DebugPackages()
+	if err != nil {
+		return err
+	}
+
+	for _, debugPackage := range debugPackages {
+		if err := mpc.createDebugPackage(ctx, proj, debugPackager, debugPackage); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// createDebugPackage runs PrepareFakeroot → BuildPackage → post-build hooks
+// for one debug package, restoring the package it was staged from after.
+func (mpc *MultipleProject) createDebugPackage(
+	ctx context.Context, proj *Project, debugPackager packer.DebugPackager, debugPackage common.DebugPackage,
+) error {
+	restore := debugPackager.EnterDebugPackage(debugPackage)
+	defer restore()
+
+	if err := proj.PackageManager.PrepareFakeroot(ctx, mpc.Output, mpc.Opts.TargetArch); err != nil {
+		return err
+	}
+
+	logger.Info(i18n.T("logger.building_resulting_package"),
+		"package", debugPackage.Name,
+		"version", proj.Builder.PKGBUILD.PkgVer,
+		"release", proj.Builder.PKGBUILD.PkgRel)
+
+	artifactPath, err := proj.PackageManager.BuildPackage(ctx, mpc.Output, mpc.Opts.TargetArch)
+	if err != nil {
+		return err
+	}
+
+	return mpc.runPostBuildHooks(proj, artifactPath)
+}
+
+// createSourcePackage writes the source package of a project whose sources
+// have been fetched, signing the files the builder returns. Formats without
+// source packages are skipped with a warning.
+func (mpc *MultipleProject) createSourcePackage(proj *Project) error {
+	sourcePackager, ok := proj.PackageManager.(packer.SourcePackager)
+	if !ok {
+		logger.Warn(i18n.T("logger.source_package_unsupported"),
+			"package", proj.Builder.PKGBUILD.PkgName)
+
+		return nil
+	}
+
+	if err := files.ExistsMakeDir(mpc.Output); err != nil {
+		return err
+	}
+
+	if err := platform.PreserveOwnership(mpc.Output); err != nil {
+		logger.Warn(i18n.T("logger.common.warn.failed_to_get_original"),
+			"path", mpc.Output,
+			"error", err)
+	}
+
+	artifactPaths, err := sourcePackager.BuildSourcePackage(context.Background(), mpc.Output)
+	if err != nil {
+		return err
+	}
+
+	if proj.Signing == nil || !proj.Signing.Enabled {
+		return nil
+	}
+
+	for _, artifactPath := range artifactPaths {
+		if err := mpc.signArtifact(proj, artifactPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // installPackage installs a single package or extracts it for cross-compilation.
 func (mpc *MultipleProject) installPackage(proj *Project) error {
 	pkgName := proj.Builder.PKGBUILD.PkgName
@@ -589,7 +723,10 @@ func (mpc *MultipleProject) installPackage(proj *Project) error {
 // getMakeDeps retrieves the make dependencies for the MultipleProject.
 //
 // It iterates over each child project and collects their make dependencies,
-// ensuring no duplicates are included. Returns the collected dependencies.
+// ensuring no duplicates are included. Check dependencies (checkdepends) are
+// collected too, but only for projects whose check() function will actually
+// run — i.e. it is defined and --nocheck was not given. Returns the collected
+// dependencies.
 func (mpc *MultipleProject) getMakeDeps() []string {
 	// Use a map to track unique dependencies and prevent duplicates
 	uniqueDeps := make(map[string]bool)
@@ -597,7 +734,7 @@ func (mpc *MultipleProject) getMakeDeps() []string {
 	var result []string
 
 	for _, child := range mpc.Projects {
-		for _, dep := range child.Builder.PKGBUILD.MakeDepends {
+		for _, dep := range slices.Concat(child.Builder.PKGBUILD.MakeDepends, mpc.checkDeps(child)) {
 			depName := extractPackageName(dep)
 			if !uniqueDeps[depName] {
 				uniqueDeps[depName] = true
@@ -610,6 +747,22 @@ func (mpc *MultipleProject) getMakeDeps() []string {
 	return result
 }
 
+// checkDeps returns the check dependencies (checkdepends) of proj when its
+// check() function will run.
+func (mpc *MultipleProject) checkDeps(proj *Project) []string {
+	if !mpc.runsCheck(proj) {
+		return nil
+	}
+
+	return proj.Builder.PKGBUILD.CheckDepends
+}
+
+// runsCheck reports whether the check() function of proj runs: it is
+// defined and --nocheck was not given.
+func (mpc *MultipleProject) runsCheck(proj *Project) bool {
+	return !mpc.Opts.NoCheck && proj.Builder.PKGBUILD.Check != ""
+}
+
 // getRuntimeDeps retrieves the runtime dependencies for the MultipleProject.
 // It filters out internal dependencies (packages within the project) and only
 // collects external dependencies that need to be installed via package manager.
@@ -19,6 +19,7 @@ import (
 	"github.com/M0Rf30/yap/v2/pkg/packer"
 	"github.com/M0Rf30/yap/v2/pkg/parser"
 	"github.com/M0Rf30/yap/v2/pkg/repo"
+	"github.com/M0Rf30/yap/v2/pkg/srccache"
 )
 
 // readProject reads the project file at the specified path
@@ -76,6 +77,39 @@ func (mpc *MultipleProject) readProject(path string) error {
 	return err
 }
 
+// ApplySourceCache applies the sourceCache setting of the yap.json in
+// path, if any. Container builds are dispatched before the project is
+// loaded, so the host reads it early to mount the cache the build is
+// configured to use, or none when it is "off". The setting is exported
+// through srccache.DirEnv, which also reaches the re-executed rootless
+// runtime; a DirEnv set by the user still wins.
+func ApplySourceCache(path string) error {
+	if os.Getenv(srccache.DirEnv) != "" {
+		return nil
+	}
+
+	prjContent, err := os.ReadFile(filepath.Join(path, "yap.json")) //nolint:gosec // user project
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var cfg struct {
+		SourceCache string `json:"sourceCache"`
+	}
+
+	if err := json.Unmarshal(prjContent, &cfg); err != nil {
+		return err
+	}
+
+	if cfg.SourceCache == "" {
+		return nil
+	}
+
+	return os.Setenv(srccache.DirEnv, cfg.SourceCache)
+}
+
 // setSingleProject reads the PKGBUILD file at the given path and updates the
 // MultipleProject instance.
 func (mpc *MultipleProject) setSingleProject(path string) {
@@ -148,8 +182,12 @@ func (mpc *MultipleProject) populateProjects(distro, release, path string) error
 			return err
 		}
 
-		mpc.packageManager, err = packer.GetPackageManager(pkgbuildFile, distro,
-			mpc.CompressionDeb, mpc.CompressionRpm)
+		mpc.packageManager, err = packer.GetPackageManager(pkgbuildFile, distro, packer.Options{
+			CompressionDeb: mpc.CompressionDeb,
+			CompressionRpm: mpc.CompressionRpm,
+			CompressionApk: mpc.CompressionApk,
+			APKFormat:      mpc.APKFormat,
+		})
 		if err != nil {
 			return err
 		}
@@ -159,6 +197,7 @@ func (mpc *MultipleProject) populateProjects(distro, release, path string) error
 			Builder: &builder.Builder{
 				PKGBUILD:      pkgbuildFile,
 				SkipHashCheck: mpc.Opts.SkipHashCheck,
+				SkipPGPCheck:  mpc.Opts.SkipPGPCheck,
 				NoCheck:       mpc.Opts.NoCheck,
 			},
 			PackageManager: mpc.packageManager,
@@ -221,7 +260,7 @@ func (mpc *MultipleProject) filterProjects(names string, keep bool) {
 
 // copyProjects copies PKGBUILD directories for all projects, creating the
 // target directory if it doesn't exist.
-// It skips files with extensions: .apk, .deb, .pkg.tar.zst, and .rpm,
+// It skips files with extensions: .apk, .deb, .pkg.tar.zst, .rpm and .xbps,
 // as well as symlinks. Uses hardlinks when possible to reduce disk usage.
 // Returns an error if any operation fails; otherwise, returns nil.
 func (mpc *MultipleProject) copyProjects() error {
@@ -266,7 +305,7 @@ func shouldSkipFile(info os.FileInfo, src, dest string) (bool, error) {
 	// entries when checked in next to the PKGBUILD, and must be copied
 	// into StartDir so validateSource() can hash them.
 	skipExtensions := []string{
-		".apk", ".deb", ".pkg.tar.zst", ".rpm",
+		".apk", ".deb", ".pkg.tar.zst", ".rpm", ".xbps",
 	}
 	for _, ext := range skipExtensions {
 		if strings.HasSuffix(src, ext) {
@@ -342,6 +381,10 @@ func (mpc *MultipleProject) applyJSONDefaults() error {
 		mpc.Opts.DebugDir = mpc.DebugDir
 	}
 
+	if mpc.SourceCache != "" {
+		srccache.SetDir(mpc.SourceCache)
+	}
+
 	if !mpc.Opts.Parallel && mpc.Parallel {
 		mpc.Opts.Parallel = mpc.Parallel
 	}
@@ -55,7 +55,7 @@ func signingFormatForArtifact(artifactPath string) (signing.Format, bool) {
 	switch {
 	case strings.HasSuffix(lower, ".apk"):
 		return signing.FormatAPK, true
-	case strings.HasSuffix(lower, ".deb"):
+	case strings.HasSuffix(lower, ".deb"), strings.HasSuffix(lower, ".dsc"):
 		return signing.FormatDEB, true
 	case strings.HasSuffix(lower, ".rpm"):
 		return signing.FormatRPM, true
@@ -63,6 +63,8 @@ func signingFormatForArtifact(artifactPath string) (signing.Format, bool) {
 		strings.HasSuffix(lower, ".pkg.tar.xz"),
 		strings.HasSuffix(lower, ".pkg.tar.gz"):
 		return signing.FormatPacman, true
+	case strings.HasSuffix(lower, ".xbps"):
+		return signing.FormatXBPS, true
 	}
 
 	return "", false
@@ -88,7 +90,7 @@ func (mpc *MultipleProject) generateSBOM(proj *Project, artifactPath string) err
 			WithContext("format", mpc.Opts.SBOMFormat)
 	}
 
-	opts := sbom.Options{Formats: formats}
+	opts := sbom.Options{Formats: formats, Check: mpc.runsCheck(proj)}
 
 	_, err := sbom.Generate(proj.Builder.PKGBUILD, artifactPath, opts)
 	if err != nil {
@@ -13,6 +13,7 @@ import (
 	"github.com/M0Rf30/yap/v2/pkg/builder"
 	"github.com/M0Rf30/yap/v2/pkg/pkgbuild"
 	"github.com/M0Rf30/yap/v2/pkg/project"
+	"github.com/M0Rf30/yap/v2/pkg/srccache"
 )
 
 const examplePkgbuild = `
@@ -1515,3 +1516,20 @@ package() {
 		assert.Equal(t, "pkga", mpc.Projects[0].Name)
 	})
 }
+
+func TestApplySourceCache(t *testing.T) {
+	t.Setenv(srccache.DirEnv, "")
+
+	dir := t.TempDir()
+	require.NoError(t, project.ApplySourceCache(dir), "a missing yap.json is not an error")
+	assert.NotEmpty(t, srccache.Dir())
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "yap.json"),
+		[]byte(`{"name": "test", "sourceCache": "off"}`), 0o600))
+	require.NoError(t, project.ApplySourceCache(dir))
+	assert.Empty(t, srccache.Dir(), "sourceCache off must disable the cache before dispatch")
+
+	t.Setenv(srccache.DirEnv, "/from/env")
+	require.NoError(t, project.ApplySourceCache(dir))
+	assert.Equal(t, "/from/env", srccache.Dir(), "YAP_SOURCE_CACHE still wins")
+}
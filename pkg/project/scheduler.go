@@ -11,6 +11,12 @@ import (
 	"github.com/M0Rf30/yap/v2/pkg/logger"
 )
 
+// compileOnly reports whether builds stop once the sources are fetched and
+// pkgver() has run: with --no-build, or to write source packages instead.
+func (mpc *MultipleProject) compileOnly() bool {
+	return mpc.Opts.NoBuild || mpc.Opts.SourcePackage
+}
+
 // buildProjectsParallel builds multiple projects in parallel for better performance.
 // If shouldInstall is true, each package is installed immediately after building
 // (Arch Linux style), making it available for other packages building in parallel.
@@ -39,11 +45,17 @@ func (mpc *MultipleProject) buildProjectsParallel(projects []*Project, maxWorker
 			}
 
 			// Build the package
-			if err := proj.Builder.Compile(gctx, mpc.Opts.NoBuild); err != nil {
+			if err := proj.Builder.Compile(gctx, mpc.compileOnly()); err != nil {
 				return err
 			}
 
-			if !mpc.Opts.NoBuild {
+			if mpc.Opts.SourcePackage && !mpc.Opts.NoBuild {
+				if err := mpc.createSourcePackage(proj); err != nil {
+					return err
+				}
+			}
+
+			if !mpc.compileOnly() {
 				// Create the package file
 				if err := mpc.createPackage(proj); err != nil {
 					return err
@@ -81,11 +93,17 @@ func (mpc *MultipleProject) buildProjectsSequential(ctx context.Context, project
 			"version", proj.Builder.PKGBUILD.PkgVer,
 			"release", proj.Builder.PKGBUILD.PkgRel)
 
-		if err := proj.Builder.Compile(ctx, mpc.Opts.NoBuild); err != nil {
+		if err := proj.Builder.Compile(ctx, mpc.compileOnly()); err != nil {
 			return err
 		}
 
-		if !mpc.Opts.NoBuild {
+		if mpc.Opts.SourcePackage && !mpc.Opts.NoBuild {
+			if err := mpc.createSourcePackage(proj); err != nil {
+				return err
+			}
+		}
+
+		if !mpc.compileOnly() {
 			if err := mpc.createPackage(proj); err != nil {
 				return err
 			}
@@ -227,7 +245,7 @@ func (mpc *MultipleProject) buildAndInstallRegularPackages(
 
 	// Install packages that are marked for installation
 	for _, proj := range regularPackages {
-		if !mpc.Opts.NoBuild && proj.HasToInstall {
+		if !mpc.compileOnly() && proj.HasToInstall {
 			err := mpc.installPackage(proj)
 			if err != nil {
 				return err
@@ -187,6 +187,14 @@ func (mpc *MultipleProject) resolveDependencies(projects []*Project) ([][]*Proje
 			totalDeps++
 		})
 
+		// Add check dependencies, needed only when check() runs
+		processDependencies(mpc.checkDeps(proj), projectMap, func(depName string) {
+			dependsOn[pkgName] = append(dependsOn[pkgName], depName)
+			dependedBy[depName] = append(dependedBy[depName], pkgName)
+			packageDeps = append(packageDeps, depName+" (check)")
+			totalDeps++
+		})
+
 		if len(packageDeps) > 0 {
 			logger.Info(i18n.T("logger.package_dependencies_found"),
 				"package", pkgName,
@@ -213,7 +221,7 @@ func (mpc *MultipleProject) calculateDependencyPopularity() map[string]int {
 		popularity[proj.Builder.PKGBUILD.PkgName] = 0
 	}
 
-	// Count how many packages depend on each package (both runtime and make dependencies)
+	// Count how many packages depend on each package (runtime, make and check dependencies)
 	for _, proj := range mpc.Projects {
 		// Count runtime dependencies
 		processDependencies(proj.Builder.PKGBUILD.Depends, packageMap, func(depName string) {
@@ -223,6 +231,10 @@ func (mpc *MultipleProject) calculateDependencyPopularity() map[string]int {
 		processDependencies(proj.Builder.PKGBUILD.MakeDepends, packageMap, func(depName string) {
 			popularity[depName]++
 		})
+		// Count check dependencies
+		processDependencies(mpc.checkDeps(proj), packageMap, func(depName string) {
+			popularity[depName]++
+		})
 	}
 
 	return popularity
@@ -299,6 +311,19 @@ func (mpc *MultipleProject) addRuntimeDependencies(proj *Project, pkgName string
 				"dependency", depName)
 		}
 	}
+
+	// Add check dependencies, needed only when check() runs
+	for _, dep := range mpc.checkDeps(proj) {
+		depName := extractPackageName(dep)
+		if _, exists := runtimeProjectMap[depName]; exists {
+			runtimeDependsOn[pkgName] = append(runtimeDependsOn[pkgName], depName)
+			runtimeDependedBy[depName] = append(runtimeDependedBy[depName], pkgName)
+			logger.Debug(
+				i18n.T("logger.project.check_dependency_found"),
+				"dependent", pkgName,
+				"dependency", depName)
+		}
+	}
 }
 
 // buildPackageMap returns a map from package name to project for the given projects.
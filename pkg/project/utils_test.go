@@ -290,6 +290,116 @@ func TestGetMakeDepsDeduplication(t *testing.T) {
 	}
 }
 
+func TestGetMakeDepsCheckDepends(t *testing.T) {
+	newMPC := func(noCheck bool) *MultipleProject {
+		return &MultipleProject{
+			Opts: BuildOptions{NoCheck: noCheck},
+			Projects: []*Project{
+				{
+					Builder: &builder.Builder{
+						PKGBUILD: &pkgbuild.PKGBUILD{
+							PkgName:      "with-check",
+							MakeDepends:  []string{"gcc"},
+							CheckDepends: []string{"gtest", "gcc"},
+							Check:        "make test",
+						},
+					},
+				},
+				{
+					Builder: &builder.Builder{
+						PKGBUILD: &pkgbuild.PKGBUILD{
+							PkgName:      "without-check",
+							MakeDepends:  []string{"make"},
+							CheckDepends: []string{"valgrind"},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("check deps included when check() runs", func(t *testing.T) {
+		got := newMPC(false).getMakeDeps()
+		want := []string{"gcc", "gtest", "make"}
+
+		if len(got) != len(want) {
+			t.Fatalf("getMakeDeps() = %v, want %v", got, want)
+		}
+
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("getMakeDeps()[%d] = %q, want %q", i, got[i], want[i])
+			}
+		}
+	})
+
+	t.Run("check deps skipped with nocheck", func(t *testing.T) {
+		got := newMPC(true).getMakeDeps()
+		want := []string{"gcc", "make"}
+
+		if len(got) != len(want) {
+			t.Fatalf("getMakeDeps() = %v, want %v", got, want)
+		}
+
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("getMakeDeps()[%d] = %q, want %q", i, got[i], want[i])
+			}
+		}
+	})
+}
+
+func TestResolveDependenciesCheckDepends(t *testing.T) {
+	newProjects := func() []*Project {
+		return []*Project{
+			{
+				Builder: &builder.Builder{
+					PKGBUILD: &pkgbuild.PKGBUILD{
+						PkgName:      "app",
+						CheckDepends: []string{"test-harness"},
+						Check:        "make test",
+					},
+				},
+			},
+			{
+				Builder: &builder.Builder{
+					PKGBUILD: &pkgbuild.PKGBUILD{
+						PkgName: "test-harness",
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("check deps order the build when check() runs", func(t *testing.T) {
+		projects := newProjects()
+		mpc := &MultipleProject{Projects: projects}
+
+		batches, err := mpc.resolveDependencies(projects)
+		if err != nil {
+			t.Fatalf("resolveDependencies() error = %v", err)
+		}
+
+		if len(batches) != 2 || batches[0][0].Builder.PKGBUILD.PkgName != "test-harness" {
+			t.Fatalf("expected test-harness to be built before app, got %d batches", len(batches))
+		}
+	})
+
+	t.Run("check deps ignored with nocheck", func(t *testing.T) {
+		projects := newProjects()
+		mpc := &MultipleProject{Opts: BuildOptions{NoCheck: true}, Projects: projects}
+
+		batches, err := mpc.resolveDependencies(projects)
+		if err != nil {
+			t.Fatalf("resolveDependencies() error = %v", err)
+		}
+
+		if len(batches) != 1 {
+			t.Fatalf("expected a single batch with --nocheck, got %d", len(batches))
+		}
+	})
+}
+
 func TestGetRuntimeDepsDeduplication(t *testing.T) {
 	// Create test projects with duplicate runtime dependencies
 	mpc := &MultipleProject{
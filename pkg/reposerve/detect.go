@@ -0,0 +1,210 @@
+package reposerve
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/M0Rf30/yap/v2/pkg/deb822"
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+)
+
+// Repository formats reported by Detect.
+const (
+	FormatDeb    = "deb"
+	FormatRPM    = "rpm"
+	FormatAPK    = "apk"
+	FormatPacman = "pacman"
+)
+
+// unsafeNameChars are the characters replaced in repository names, which
+// end up in file names such as /etc/apt/sources.list.d/yap-<name>.sources.
+var unsafeNameChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// Tree is a package repository found under a served directory.
+type Tree struct {
+	// Format is one of FormatDeb, FormatRPM, FormatAPK or FormatPacman.
+	Format string
+	// Path is the slash-separated location of the repository below the
+	// served directory, "." for the directory itself: the parent of dists/
+	// for APT, of repodata/ for RPM, of the <arch>/ directories for APK,
+	// and the directory of the databases for pacman.
+	Path string
+	// Name names the repository: the database name for pacman, the last
+	// element of Path (and the suite, for APT) otherwise.
+	Name string
+	// Suite and Components describe an APT suite.
+	Suite      string
+	Components []string
+}
+
+// Detect returns the repositories under root, sorted by path.
+func Detect(root string) ([]Tree, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var trees []Tree
+
+	err = filepath.WalkDir(abs, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if p != abs && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		rel, err := filepath.Rel(abs, p)
+		if err != nil {
+			return err
+		}
+
+		tree, ok, err := detectTree(abs, filepath.ToSlash(rel))
+		if ok && !slices.ContainsFunc(trees, func(t Tree) bool { return t.Path == tree.Path && t.Name == tree.Name }) {
+			trees = append(trees, tree)
+		}
+
+		return err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrTypeFileSystem, i18n.T("errors.reposerve.scan_failed")).
+			WithOperation("Detect").
+			WithContext("dir", root)
+	}
+
+	slices.SortFunc(trees, func(a, b Tree) int {
+		return strings.Compare(a.Path+"\x00"+a.Name, b.Path+"\x00"+b.Name)
+	})
+
+	return trees, nil
+}
+
+// detectTree reports the repository whose index is the file at rel, a
+// slash-separated path below root.
+func detectTree(root, rel string) (Tree, bool, error) {
+	dir, base := path.Split(rel)
+	dir = path.Clean(dir)
+
+	switch {
+	case base == "Release" && path.Base(path.Dir(dir)) == "dists":
+		repoPath, suite := path.Dir(path.Dir(dir)), path.Base(dir)
+
+		components, err := releaseComponents(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil {
+			return Tree{}, false, err
+		}
+
+		return Tree{
+			Format:     FormatDeb,
+			Path:       repoPath,
+			Name:       repoName(root, repoPath, suite),
+			Suite:      suite,
+			Components: components,
+		}, true, nil
+	case rel == "repodata/repomd.xml" || strings.HasSuffix(rel, "/repodata/repomd.xml"):
+		repoPath := path.Dir(dir)
+
+		return Tree{Format: FormatRPM, Path: repoPath, Name: repoName(root, repoPath, "")}, true, nil
+	case base == "APKINDEX.tar.gz" && dir != ".":
+		repoPath := path.Dir(dir)
+
+		return Tree{Format: FormatAPK, Path: repoPath, Name: repoName(root, repoPath, "")}, true, nil
+	case strings.Contains(base, ".db.tar."):
+		name, _, _ := strings.Cut(base, ".db.tar.")
+
+		return Tree{Format: FormatPacman, Path: dir, Name: name}, true, nil
+	}
+
+	return Tree{}, false, nil
+}
+
+// releaseComponents returns the Components of an apt Release file.
+func releaseComponents(releasePath string) ([]string, error) {
+	file, err := os.Open(filepath.Clean(releasePath))
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = file.Close() }()
+
+	var components []string
+
+	err = deb822.Parse(file, func(stanza deb822.Stanza) error {
+		if components == nil {
+			components = strings.Fields(stanza["Components"])
+		}
+
+		return nil
+	})
+
+	return components, err
+}
+
+// repoName names a repository after its directory, the served directory
+// itself for ".", followed by the suite when set.
+func repoName(root, repoPath, suite string) string {
+	name := path.Base(repoPath)
+	if repoPath == "." {
+		name = filepath.Base(root)
+	}
+
+	if suite != "" {
+		name += "-" + suite
+	}
+
+	name = strings.Trim(unsafeNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if name == "" {
+		return "local"
+	}
+
+	return name
+}
+
+// URL returns the URL of the repository when the served directory is at
+// baseURL.
+func (t Tree) URL(baseURL string) string {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	if t.Path == "." {
+		return baseURL
+	}
+
+	return baseURL + "/" + t.Path
+}
+
+// Spec returns what points a client at the repository served at baseURL:
+// a --repo flag for APT and RPM repositories, in the syntax of
+// repo.ParseFlags, and the client configuration lines for APK and pacman,
+// which yap does not register itself.
+func (t Tree) Spec(baseURL string) string {
+	url := t.URL(baseURL)
+
+	switch t.Format {
+	case FormatDeb:
+		components := t.Components
+		if len(components) == 0 {
+			components = []string{"main"}
+		}
+
+		return "--repo name=" + t.Name + ",url=" + url + ",suite=" + t.Suite +
+			",components=" + strings.Join(components, "+") + ",format=deb"
+	case FormatRPM:
+		return "--repo name=" + t.Name + ",url=" + url + ",format=rpm"
+	case FormatAPK:
+		return "# /etc/apk/repositories\n" + url
+	case FormatPacman:
+		return "# /etc/pacman.conf\n[" + t.Name + "]\nSigLevel = Optional TrustAll\nServer = " + url
+	}
+
+	return ""
+}
@@ -0,0 +1,159 @@
+// Package reposerve serves local package repository trees over HTTP, so
+// build containers and air-gapped hosts can install from the repositories
+// yap repo writes without a separate web server.
+//
+// Files are served with the content types package managers expect and with
+// range request support, so interrupted downloads resume. Detect finds the
+// APT, RPM, APK and pacman repositories of a directory and Tree.Spec tells
+// how to point a client at each of them.
+package reposerve
+
+import (
+	"context"
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+)
+
+const (
+	// readHeaderTimeout bounds how long a client may take to send headers.
+	readHeaderTimeout = 10 * time.Second
+	// shutdownTimeout bounds how long in-flight downloads may take to
+	// finish once Serve is cancelled.
+	shutdownTimeout = 5 * time.Second
+	// octetStream is the content type of opaque package files.
+	octetStream = "application/octet-stream"
+	// plainText is the content type of apt's uncompressed indexes.
+	plainText = "text/plain; charset=utf-8"
+)
+
+// contentTypes maps file extensions to the content type they are served
+// with. They take precedence over the system MIME database, which for
+// instance maps .apk to Android packages.
+var contentTypes = map[string]string{
+	".apk":    octetStream,
+	".asc":    "application/pgp-signature",
+	".bz2":    "application/x-bzip2",
+	".db":     octetStream,
+	".deb":    "application/vnd.debian.binary-package",
+	".dsc":    plainText,
+	".files":  octetStream,
+	".gpg":    "application/pgp-signature",
+	".gz":     "application/gzip",
+	".json":   "application/json",
+	".pub":    plainText,
+	".rpm":    "application/x-rpm",
+	".sig":    "application/pgp-signature",
+	".sig2":   octetStream,
+	".sqlite": octetStream,
+	".udeb":   "application/vnd.debian.binary-package",
+	".xbps":   octetStream,
+	".xml":    "application/xml",
+	".xz":     "application/x-xz",
+	".zst":    "application/zstd",
+}
+
+// plainTextFiles are the extension-less apt indexes served as text.
+var plainTextFiles = map[string]bool{
+	"InRelease": true,
+	"Packages":  true,
+	"Release":   true,
+	"Sources":   true,
+}
+
+// Options configures Handler and Serve.
+type Options struct {
+	// Username and Password, when Username is set, are required from every
+	// client through HTTP basic authentication.
+	Username string
+	Password string
+}
+
+// Handler returns an http.Handler serving the files under root, with
+// directory listings, range requests and package content types.
+func Handler(root string, opts Options) http.Handler {
+	files := http.FileServer(http.Dir(root))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if opts.Username != "" && !authorized(r, opts) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="yap", charset="UTF-8"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+
+			return
+		}
+
+		if contentType := ContentType(r.URL.Path); contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+
+		files.ServeHTTP(w, r)
+	})
+}
+
+// ContentType returns the content type a repository file is served with,
+// or "" to let net/http pick one, as it does for directory listings.
+func ContentType(name string) string {
+	base := path.Base(name)
+	if strings.HasSuffix(name, "/") || base == "/" || base == "." {
+		return ""
+	}
+
+	if plainTextFiles[base] {
+		return plainText
+	}
+
+	return contentTypes[path.Ext(base)]
+}
+
+// authorized reports whether r carries the basic-auth credentials of opts.
+func authorized(r *http.Request, opts Options) bool {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+
+	userMatch := subtle.ConstantTimeCompare([]byte(username), []byte(opts.Username))
+	passwordMatch := subtle.ConstantTimeCompare([]byte(password), []byte(opts.Password))
+
+	return userMatch&passwordMatch == 1
+}
+
+// Serve serves root on ln until ctx is cancelled, then lets in-flight
+// requests finish for a few seconds before closing.
+func Serve(ctx context.Context, ln net.Listener, root string, opts Options) error {
+	server := &http.Server{
+		Handler:           Handler(root, opts),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- server.Serve(ln)
+	}()
+
+	select {
+	case err := <-done:
+		return errors.Wrap(err, errors.ErrTypeNetwork, i18n.T("errors.reposerve.server_stopped")).
+			WithOperation("Serve").
+			WithContext("address", ln.Addr().String())
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return errors.Wrap(err, errors.ErrTypeNetwork, i18n.T("errors.reposerve.stop_failed")).
+			WithOperation("Serve").
+			WithContext("address", ln.Addr().String())
+	}
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package reposerve //nolint:testpackage
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlerContentTypes(t *testing.T) {
+	root := writeTestTree(t)
+	server := httptest.NewServer(Handler(root, Options{}))
+
+	defer server.Close()
+
+	for file, contentType := range map[string]string{
+		"/apt/dists/stable/Release":                plainText,
+		"/apt/pool/main/f/foo/foo_1.0-1_amd64.deb": "application/vnd.debian.binary-package",
+		"/rpm/repodata/repomd.xml":                 "application/xml",
+		"/rpm/foo-1.0-1.x86_64.rpm":                "application/x-rpm",
+		"/alpine/x86_64/APKINDEX.tar.gz":           "application/gzip",
+		"/alpine/x86_64/foo-1.0-r0.apk":            octetStream,
+		"/arch/custom.db":                          octetStream,
+		"/arch/custom.db.tar.zst":                  "application/zstd",
+		"/arch/foo-1.0-1-x86_64.pkg.tar.zst.sig":   "application/pgp-signature",
+	} {
+		resp, err := http.Get(server.URL + file)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode, file)
+		assert.Equal(t, contentType, resp.Header.Get("Content-Type"), file)
+	}
+}
+
+func TestHandlerRange(t *testing.T) {
+	root := writeTestTree(t)
+	server := httptest.NewServer(Handler(root, Options{}))
+
+	defer server.Close()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet,
+		server.URL+"/rpm/foo-1.0-1.x86_64.rpm", http.NoBody)
+	require.NoError(t, err)
+	req.Header.Set("Range", "bytes=4-")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	assert.Equal(t, "bytes 4-9/10", resp.Header.Get("Content-Range"))
+	assert.Equal(t, "456789", string(body))
+}
+
+func TestHandlerBasicAuth(t *testing.T) {
+	root := writeTestTree(t)
+	server := httptest.NewServer(Handler(root, Options{Username: "ci", Password: "secret"}))
+
+	defer server.Close()
+
+	for _, tc := range []struct {
+		username, password string
+		status             int
+	}{
+		{"", "", http.StatusUnauthorized},
+		{"ci", "wrong", http.StatusUnauthorized},
+		{"ci", "secret", http.StatusOK},
+	} {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet,
+			server.URL+"/rpm/repodata/repomd.xml", http.NoBody)
+		require.NoError(t, err)
+
+		if tc.username != "" {
+			req.SetBasicAuth(tc.username, tc.password)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+
+		assert.Equal(t, tc.status, resp.StatusCode, tc.password)
+
+		if tc.status == http.StatusUnauthorized {
+			assert.NotEmpty(t, resp.Header.Get("WWW-Authenticate"))
+		}
+	}
+}
+
+func TestServe(t *testing.T) {
+	root := writeTestTree(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	go func() {
+		done <- Serve(ctx, ln, root, Options{})
+	}()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/rpm/repodata/repomd.xml")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	cancel()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("Serve did not return after cancellation")
+	}
+}
+
+func TestDetect(t *testing.T) {
+	root := writeTestTree(t)
+
+	trees, err := Detect(root)
+	require.NoError(t, err)
+
+	assert.Equal(t, []Tree{
+		{Format: FormatAPK, Path: "alpine", Name: "alpine"},
+		{Format: FormatDeb, Path: "apt", Name: "apt-stable", Suite: "stable", Components: []string{"main", "contrib"}},
+		{Format: FormatPacman, Path: "arch", Name: "custom"},
+		{Format: FormatRPM, Path: "rpm", Name: "rpm"},
+	}, trees)
+}
+
+func TestTreeSpec(t *testing.T) {
+	base := "http://10.0.0.2:8080/"
+
+	assert.Equal(t,
+		"--repo name=apt-stable,url=http://10.0.0.2:8080/apt,suite=stable,components=main+contrib,format=deb",
+		Tree{Format: FormatDeb, Path: "apt", Name: "apt-stable", Suite: "stable",
+			Components: []string{"main", "contrib"}}.Spec(base))
+	assert.Equal(t, "--repo name=local,url=http://10.0.0.2:8080,format=rpm",
+		Tree{Format: FormatRPM, Path: ".", Name: "local"}.Spec(base))
+	assert.Equal(t, "# /etc/apk/repositories\nhttp://10.0.0.2:8080/alpine",
+		Tree{Format: FormatAPK, Path: "alpine", Name: "alpine"}.Spec(base))
+	assert.Equal(t, "# /etc/pacman.conf\n[custom]\nSigLevel = Optional TrustAll\nServer = http://10.0.0.2:8080/arch",
+		Tree{Format: FormatPacman, Path: "arch", Name: "custom"}.Spec(base))
+}
+
+func TestRepoName(t *testing.T) {
+	assert.Equal(t, "my-repo-jammy", repoName("/srv", "nested/My Repo", "jammy"))
+	assert.Equal(t, "srv", repoName("/srv", ".", ""))
+	assert.Equal(t, "local", repoName("/", ".", ""))
+}
+
+// writeTestTree writes one repository of each format and returns its root.
+func writeTestTree(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+
+	for name, content := range map[string]string{
+		"apt/dists/stable/Release": "Origin: yap\nSuite: stable\nCodename: stable\n" +
+			"Architectures: amd64\nComponents: main contrib\n",
+		"apt/dists/stable/main/binary-amd64/Release": "Archive: stable\nComponent: main\n",
+		"apt/pool/main/f/foo/foo_1.0-1_amd64.deb":    "deb",
+		"rpm/repodata/repomd.xml":                    "<repomd/>",
+		"rpm/foo-1.0-1.x86_64.rpm":                   "0123456789",
+		"alpine/x86_64/APKINDEX.tar.gz":              "index",
+		"alpine/x86_64/foo-1.0-r0.apk":               "apk",
+		"alpine/aarch64/APKINDEX.tar.gz":             "index",
+		"arch/custom.db.tar.zst":                     "db",
+		"arch/custom.files.tar.zst":                  "files",
+		"arch/foo-1.0-1-x86_64.pkg.tar.zst.sig":      "sig",
+		".git/dists/stable/Release":                  "Suite: ignored\n",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	require.NoError(t, os.Symlink("custom.db.tar.zst", filepath.Join(root, "arch", "custom.db")))
+
+	return root
+}
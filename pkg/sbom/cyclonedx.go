@@ -71,7 +71,7 @@ type CycloneDXDependency struct {
 }
 
 // generateCycloneDX generates a CycloneDX 1.5 SBOM for the given package.
-func generateCycloneDX(pkg *pkgbuild.PKGBUILD) *CycloneDXBOM {
+func generateCycloneDX(pkg *pkgbuild.PKGBUILD, check bool) *CycloneDXBOM {
 	bom := &CycloneDXBOM{
 		BOMFormat:    "CycloneDX",
 		SpecVersion:  "1.5",
@@ -98,7 +98,7 @@ func generateCycloneDX(pkg *pkgbuild.PKGBUILD) *CycloneDXBOM {
 	}
 
 	// Add external references for source URLs
-	for _, sourceURL := range pkg.SourceURI {
+	for _, sourceURL := range sourceLocations(pkg) {
 		mainComponent.ExternalReferences = append(
 			mainComponent.ExternalReferences,
 			&CycloneDXExtRef{
@@ -131,8 +131,8 @@ func generateCycloneDX(pkg *pkgbuild.PKGBUILD) *CycloneDXBOM {
 		bom.Components = append(bom.Components, component)
 	}
 
-	// Add make dependencies as optional components
-	for _, dep := range pkg.MakeDepends {
+	// Add make dependencies, and check (test) dependencies when check() ran, as optional components
+	for _, dep := range buildDepends(pkg, check) {
 		depName := extractDepName(dep)
 		if depName == "" || depComponents[depName] != nil {
 			continue
@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/M0Rf30/yap/v2/pkg/i18n"
 	"github.com/M0Rf30/yap/v2/pkg/logger"
@@ -27,6 +29,9 @@ type Options struct {
 	// Formats is a list of SBOM formats to generate.
 	// Empty list means no SBOM generation.
 	Formats []Format
+	// Check reports whether the PKGBUILD check() function ran. Check
+	// dependencies (checkdepends) are only listed when it did.
+	Check bool
 }
 
 // Generate writes one SBOM sidecar per requested format next to artifactPath.
@@ -49,10 +54,10 @@ func Generate(pkg *pkgbuild.PKGBUILD, artifactPath string,
 		switch format {
 		case FormatCycloneDX:
 			sbomPath = artifactPath + ".cdx.json"
-			sbomData = generateCycloneDX(pkg)
+			sbomData = generateCycloneDX(pkg, opts.Check)
 		case FormatSPDX:
 			sbomPath = artifactPath + ".spdx.json"
-			sbomData = generateSPDX(pkg)
+			sbomData = generateSPDX(pkg, opts.Check)
 		default:
 			logger.Warn(i18n.T("logger.sbom.warn.unknown_sbom_format"), "format", format)
 
@@ -85,3 +90,33 @@ func Generate(pkg *pkgbuild.PKGBUILD, artifactPath string,
 
 	return generatedFiles, nil
 }
+
+// buildDepends returns the make dependencies of pkg, plus its check
+// dependencies when check() ran.
+func buildDepends(pkg *pkgbuild.PKGBUILD, check bool) []string {
+	if !check {
+		return pkg.MakeDepends
+	}
+
+	return slices.Concat(pkg.MakeDepends, pkg.CheckDepends)
+}
+
+// sourceLocations returns the download location of every source: the
+// mirror it was fetched from when the build recorded one, otherwise the
+// declared entry without its "|"-separated mirror alternatives.
+func sourceLocations(pkg *pkgbuild.PKGBUILD) []string {
+	locations := make([]string, 0, len(pkg.SourceURI))
+
+	for index, sourceURI := range pkg.SourceURI {
+		if index < len(pkg.SourceDownloads) && pkg.SourceDownloads[index] != "" {
+			locations = append(locations, pkg.SourceDownloads[index])
+
+			continue
+		}
+
+		location, _, _ := strings.Cut(sourceURI, "|")
+		locations = append(locations, location)
+	}
+
+	return locations
+}
@@ -31,7 +31,7 @@ func TestGenerateCycloneDXNoDeps(t *testing.T) {
 		License: []string{"MIT"},
 	}
 
-	bom := generateCycloneDX(pkg)
+	bom := generateCycloneDX(pkg, false)
 	require.NotNil(t, bom)
 
 	assert.Empty(t, bom.Components)
@@ -52,7 +52,7 @@ func TestGenerateCycloneDXNoSourceURI(t *testing.T) {
 		// No SourceURI
 	}
 
-	bom := generateCycloneDX(pkg)
+	bom := generateCycloneDX(pkg, false)
 	require.NotNil(t, bom)
 	require.NotNil(t, bom.Metadata)
 	require.NotNil(t, bom.Metadata.Component)
@@ -69,7 +69,7 @@ func TestGenerateCycloneDXNoLicense(t *testing.T) {
 		License: []string{},
 	}
 
-	bom := generateCycloneDX(pkg)
+	bom := generateCycloneDX(pkg, false)
 	require.NotNil(t, bom)
 	require.NotNil(t, bom.Metadata)
 	require.NotNil(t, bom.Metadata.Component)
@@ -88,7 +88,7 @@ func TestGenerateSPDXWithURL(t *testing.T) {
 		License: []string{"GPL-2.0"},
 	}
 
-	doc := generateSPDX(pkg)
+	doc := generateSPDX(pkg, false)
 	require.NotNil(t, doc)
 	require.NotEmpty(t, doc.Packages)
 
@@ -106,7 +106,7 @@ func TestGenerateSPDXNoDeps(t *testing.T) {
 		License: []string{"MIT"},
 	}
 
-	doc := generateSPDX(pkg)
+	doc := generateSPDX(pkg, false)
 	require.NotNil(t, doc)
 
 	// Only the main package
@@ -126,7 +126,7 @@ func TestGenerateSPDXMultipleLicenses(t *testing.T) {
 		License: []string{"MIT", "Apache-2.0", "GPL-2.0"},
 	}
 
-	doc := generateSPDX(pkg)
+	doc := generateSPDX(pkg, false)
 	require.NotNil(t, doc)
 	require.NotEmpty(t, doc.Packages)
 
@@ -146,7 +146,7 @@ func TestGenerateSPDXEmptyDepName(t *testing.T) {
 		MakeDepends: []string{">=2.0"},
 	}
 
-	doc := generateSPDX(pkg)
+	doc := generateSPDX(pkg, false)
 	require.NotNil(t, doc)
 
 	// Only the main package — empty-name deps are skipped
@@ -65,7 +65,7 @@ func TestGenerateCycloneDX(t *testing.T) {
 		MakeDepends: []string{"git", "cmake"},
 	}
 
-	bom := generateCycloneDX(pkg)
+	bom := generateCycloneDX(pkg, false)
 	require.NotNil(t, bom)
 
 	// Verify BOM structure
@@ -124,7 +124,7 @@ func TestGenerateSPDX(t *testing.T) {
 		MakeDepends: []string{"git"},
 	}
 
-	doc := generateSPDX(pkg)
+	doc := generateSPDX(pkg, false)
 	require.NotNil(t, doc)
 
 	// Verify document structure
@@ -172,6 +172,38 @@ func TestGetDownloadLocation(t *testing.T) {
 		pkg := &pkgbuild.PKGBUILD{}
 		assert.Equal(t, "NOASSERTION", getDownloadLocation(pkg))
 	})
+
+	t.Run("mirror alternatives are dropped", func(t *testing.T) {
+		pkg := &pkgbuild.PKGBUILD{
+			SourceURI: []string{"https://a.example.com/pkg-1.0.tar.gz|https://b.example.com/pkg-1.0.tar.gz"},
+		}
+		assert.Equal(t, "https://a.example.com/pkg-1.0.tar.gz", getDownloadLocation(pkg))
+	})
+
+	t.Run("recorded mirror wins", func(t *testing.T) {
+		pkg := &pkgbuild.PKGBUILD{
+			SourceURI:       []string{"https://a.example.com/pkg-1.0.tar.gz|https://b.example.com/pkg-1.0.tar.gz"},
+			SourceDownloads: []string{"https://b.example.com/pkg-1.0.tar.gz"},
+		}
+		assert.Equal(t, "https://b.example.com/pkg-1.0.tar.gz", getDownloadLocation(pkg))
+	})
+}
+
+func TestSourceLocations(t *testing.T) {
+	pkg := &pkgbuild.PKGBUILD{
+		SourceURI: []string{
+			"https://a.example.com/foo.tar.gz|https://b.example.com/foo.tar.gz",
+			"local.patch",
+			"bar.tar.gz::https://a.example.com/bar.tar.gz",
+		},
+		SourceDownloads: []string{"https://b.example.com/foo.tar.gz", "", ""},
+	}
+
+	assert.Equal(t, []string{
+		"https://b.example.com/foo.tar.gz",
+		"local.patch",
+		"bar.tar.gz::https://a.example.com/bar.tar.gz",
+	}, sourceLocations(pkg))
 }
 
 func TestGenerateCycloneDXEmptyDepName(t *testing.T) {
@@ -183,7 +215,7 @@ func TestGenerateCycloneDXEmptyDepName(t *testing.T) {
 		MakeDepends: []string{">=2.0"},
 	}
 
-	bom := generateCycloneDX(pkg)
+	bom := generateCycloneDX(pkg, false)
 	require.NotNil(t, bom)
 
 	// No components should have been added for the empty-name deps.
@@ -201,7 +233,7 @@ func TestGenerateSPDXNoLicense(t *testing.T) {
 		License: []string{}, // empty — should produce NOASSERTION
 	}
 
-	doc := generateSPDX(pkg)
+	doc := generateSPDX(pkg, false)
 	require.NotNil(t, doc)
 	require.NotEmpty(t, doc.Packages)
 
@@ -219,7 +251,7 @@ func TestGenerateSPDXMakeDepsDeduplicated(t *testing.T) {
 		MakeDepends: []string{"gcc", "cmake"},
 	}
 
-	doc := generateSPDX(pkg)
+	doc := generateSPDX(pkg, false)
 	require.NotNil(t, doc)
 
 	// Count packages named "gcc".
@@ -244,3 +276,39 @@ func TestGenerateSPDXMakeDepsDeduplicated(t *testing.T) {
 
 	assert.True(t, cmakeFound)
 }
+
+func TestGenerateCheckDependsOnlyWhenCheckRan(t *testing.T) {
+	pkg := &pkgbuild.PKGBUILD{
+		PkgName:      "testpkg",
+		PkgVer:       "1.0.0",
+		MakeDepends:  []string{"cmake"},
+		CheckDepends: []string{"python-pytest"},
+	}
+
+	tests := []struct {
+		name  string
+		check bool
+		want  []string
+	}{
+		{"check skipped", false, []string{"cmake"}},
+		{"check ran", true, []string{"cmake", "python-pytest"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var components []string
+			for _, component := range generateCycloneDX(pkg, tt.check).Components {
+				components = append(components, component.Name)
+			}
+
+			assert.Equal(t, tt.want, components)
+
+			var packages []string
+			for _, p := range generateSPDX(pkg, tt.check).Packages[1:] {
+				packages = append(packages, p.Name)
+			}
+
+			assert.Equal(t, tt.want, packages)
+		})
+	}
+}
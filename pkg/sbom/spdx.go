@@ -63,7 +63,7 @@ const (
 )
 
 // generateSPDX generates an SPDX 2.3 SBOM for the given package.
-func generateSPDX(pkg *pkgbuild.PKGBUILD) *SPDXDocument {
+func generateSPDX(pkg *pkgbuild.PKGBUILD, check bool) *SPDXDocument {
 	doc := &SPDXDocument{
 		SPDXVersion:       "SPDX-2.3",
 		DataLicense:       "CC0-1.0",
@@ -97,7 +97,7 @@ func generateSPDX(pkg *pkgbuild.PKGBUILD) *SPDXDocument {
 	}
 
 	// Add external references for source URLs
-	for _, sourceURL := range pkg.SourceURI {
+	for _, sourceURL := range sourceLocations(pkg) {
 		mainPkg.ExternalReferences = append(
 			mainPkg.ExternalReferences,
 			&SPDXExternalRef{
@@ -132,8 +132,8 @@ func generateSPDX(pkg *pkgbuild.PKGBUILD) *SPDXDocument {
 		doc.Packages = append(doc.Packages, depPkg)
 	}
 
-	// Add make dependencies as packages
-	for _, dep := range pkg.MakeDepends {
+	// Add make dependencies, and check (test) dependencies when check() ran, as packages
+	for _, dep := range buildDepends(pkg, check) {
 		depName := extractDepName(dep)
 		if depName == "" || depPackages[depName] != nil {
 			continue
@@ -183,10 +183,11 @@ func generateDocumentNamespace(pkg *pkgbuild.PKGBUILD) string {
 }
 
 // getDownloadLocation returns the download location for the package.
-// Uses the first source URL if available, otherwise returns NOASSERTION.
+// Uses the location of the first source if available, otherwise returns
+// NOASSERTION.
 func getDownloadLocation(pkg *pkgbuild.PKGBUILD) string {
-	if len(pkg.SourceURI) > 0 {
-		return pkg.SourceURI[0]
+	if locations := sourceLocations(pkg); len(locations) > 0 {
+		return locations[0]
 	}
 
 	if pkg.URL != "" {
@@ -403,9 +403,34 @@ func RunScript(cmds string) error {
 // or extends the inherited process environment for this script invocation only,
 // without mutating os.Environ().  This makes the function safe to call concurrently
 // from parallel build goroutines.
-//
-//nolint:gocyclo,cyclop // RunScriptWithPackage handles multiple script edge cases inline
 func RunScriptWithPackage(ctx context.Context, cmds, packageName string, extraEnv ...[]string) error {
+	return runShellScript(ctx, "RunScriptWithPackage", cmds, packageName, nil, nil, extraEnv)
+}
+
+// ExecMiddleware is an interp exec handler middleware that may intercept a
+// command spawned by a build script before it reaches the next handler.
+type ExecMiddleware = func(next interp.ExecHandlerFunc) interp.ExecHandlerFunc
+
+// RunScriptCapture executes a shell script like RunScriptWithPackage but writes
+// the script's stdout to stdout instead of the decorated console writer, so
+// that callers can consume it (e.g. the output of a PKGBUILD pkgver()
+// function). Stderr, including set -x traces, is still decorated and printed.
+// Additional exec middlewares run after the archive interceptor.
+func RunScriptCapture(ctx context.Context, cmds, packageName string, stdout io.Writer,
+	middlewares []ExecMiddleware, extraEnv ...[]string,
+) error {
+	return runShellScript(ctx, "RunScriptCapture", cmds, packageName, stdout, middlewares, extraEnv)
+}
+
+// runShellScript parses and runs cmds for RunScriptWithPackage, RunScriptCapture
+// and RunScriptInFakeroot. The script's stderr, and its stdout too when stdout is
+// nil, goes to the decorated console writer; middlewares run after the
+// archive interceptor.
+//
+//nolint:gocyclo,cyclop // runShellScript handles multiple script edge cases inline
+func runShellScript(ctx context.Context, operation, cmds, packageName string, stdout io.Writer,
+	middlewares []ExecMiddleware, extraEnv [][]string,
+) error {
 	start := time.Now()
 
 	if packageName != "" {
@@ -424,13 +449,13 @@ func RunScriptWithPackage(ctx context.Context, cmds, packageName string, extraEn
 	script, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(cmds), "")
 	if err != nil {
 		return errors.Wrap(err, errors.ErrTypeBuild, i18n.T("errors.shell.failed_to_parse_script")).
-			WithOperation("RunScriptWithPackage")
+			WithOperation(operation)
 	}
 
 	_, err = MultiPrinter.Start()
 	if err != nil {
 		return errors.Wrap(err, errors.ErrTypeBuild, i18n.T("errors.shell.failed_to_start_multiprinter")).
-			WithOperation("RunScriptWithPackage")
+			WithOperation(operation)
 	}
 
 	writer := MultiPrinter.Writer
@@ -453,11 +478,36 @@ func RunScriptWithPackage(ctx context.Context, cmds, packageName string, extraEn
 	var outputBuf bytes.Buffer
 
 	teeWriter := io.MultiWriter(writer, &outputBuf)
+	if stdout == nil {
+		stdout = teeWriter
+	}
 
-	// Build the effective environment: start with the inherited process env, then
-	// overlay any per-package overrides supplied by the caller.  Using a map-then-slice
-	// approach guarantees that package-specific values (e.g. pkgdir, srcdir) shadow
-	// the global ones without touching os.Setenv, making this safe for parallel builds.
+	handlers := append([]ExecMiddleware{archiveExecHandler}, middlewares...)
+
+	runner, err := interp.New(
+		interp.Env(expand.ListEnviron(mergeEnv(extraEnv)...)),
+		interp.StdIO(nil, stdout, teeWriter),
+		interp.ExecHandlers(handlers...),
+	)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeBuild, i18n.T("errors.shell.failed_to_create_script_runner")).
+			WithOperation(operation)
+	}
+
+	logger.Debug(i18n.T("logger.shell.debug.starting_script_execution"))
+
+	err = runner.Run(ctx, script)
+	duration := time.Since(start)
+
+	return logScriptResult(err, packageName, duration, &outputBuf, operation)
+}
+
+// mergeEnv builds the effective environment of a script: the inherited process
+// env, overlaid with the per-package overrides supplied by the caller.  Using a
+// map-then-slice approach guarantees that package-specific values (e.g. pkgdir,
+// srcdir) shadow the global ones without touching os.Setenv, making this safe
+// for parallel builds.
+func mergeEnv(extraEnv [][]string) []string {
 	envMap := make(map[string]string)
 
 	for _, kv := range os.Environ() {
@@ -480,22 +530,7 @@ func RunScriptWithPackage(ctx context.Context, cmds, packageName string, extraEn
 		mergedEnv = append(mergedEnv, k+"="+v)
 	}
 
-	runner, err := interp.New(
-		interp.Env(expand.ListEnviron(mergedEnv...)),
-		interp.StdIO(nil, teeWriter, teeWriter),
-		interp.ExecHandlers(archiveExecHandler),
-	)
-	if err != nil {
-		return errors.Wrap(err, errors.ErrTypeBuild, i18n.T("errors.shell.failed_to_create_script_runner")).
-			WithOperation("RunScriptWithPackage")
-	}
-
-	logger.Debug(i18n.T("logger.shell.debug.starting_script_execution"))
-
-	err = runner.Run(ctx, script)
-	duration := time.Since(start)
-
-	return logScriptResult(err, packageName, duration, &outputBuf, "RunScriptWithPackage")
+	return mergedEnv
 }
 
 // fakerootExecHandler returns an interp.ExecHandlerFunc middleware that applies Linux
@@ -621,88 +656,9 @@ func fakerootExecEnv(env expand.Environ) []string {
 // but wraps every subprocess in a Linux user-namespace fakeroot so that ownership
 // operations (install -o root, chown, etc.) succeed without real root privileges.
 // Use this for the package() stage of PKGBUILD execution.
-//
-//nolint:gocyclo,cyclop // mirrors RunScriptWithPackage complexity
 func RunScriptInFakeroot(ctx context.Context, cmds, packageName string, extraEnv ...[]string) error {
-	start := time.Now()
-
-	if packageName != "" {
-		logger.Info(i18n.T("logger.shell.info.exec_script"), "package", packageName)
-	} else {
-		logger.Info(i18n.T("logger.shell.info.executing_shell_script"))
-	}
-
-	if cmds != "" {
-		logScriptContent(cmds)
-	}
-
-	script, err := syntax.NewParser(syntax.Variant(syntax.LangBash)).Parse(strings.NewReader(cmds), "")
-	if err != nil {
-		return errors.Wrap(err, errors.ErrTypeBuild, i18n.T("errors.shell.failed_to_parse_script")).
-			WithOperation("RunScriptInFakeroot")
-	}
-
-	_, err = MultiPrinter.Start()
-	if err != nil {
-		return errors.Wrap(err, errors.ErrTypeBuild, i18n.T("errors.shell.failed_to_start_multiprinter")).
-			WithOperation("RunScriptInFakeroot")
-	}
-
-	writer := MultiPrinter.Writer
-
-	var decoratedWriter *PackageDecoratedWriter
-	if packageName != "" {
-		decoratedWriter = NewPackageDecoratedWriter(MultiPrinter.Writer, packageName)
-
-		defer func() {
-			_ = decoratedWriter.Close()
-		}()
-
-		writer = decoratedWriter
-	}
-
-	var outputBuf bytes.Buffer
-
-	teeWriter := io.MultiWriter(writer, &outputBuf)
-
-	envMap := make(map[string]string)
-
-	for _, kv := range os.Environ() {
-		if k, v, ok := strings.Cut(kv, "="); ok {
-			envMap[k] = v
-		}
-	}
-
-	for _, extra := range extraEnv {
-		for _, kv := range extra {
-			if k, v, ok := strings.Cut(kv, "="); ok {
-				envMap[k] = v
-			}
-		}
-	}
-
-	mergedEnv := make([]string, 0, len(envMap))
-
-	for k, v := range envMap {
-		mergedEnv = append(mergedEnv, k+"="+v)
-	}
-
-	runner, err := interp.New(
-		interp.Env(expand.ListEnviron(mergedEnv...)),
-		interp.StdIO(nil, teeWriter, teeWriter),
-		interp.ExecHandlers(archiveExecHandler, fakerootExecHandler(0)),
-	)
-	if err != nil {
-		return errors.Wrap(err, errors.ErrTypeBuild, i18n.T("errors.shell.failed_to_create_script_runner")).
-			WithOperation("RunScriptInFakeroot")
-	}
-
-	logger.Debug(i18n.T("logger.shell.debug.starting_script_execution"))
-
-	err = runner.Run(ctx, script)
-	duration := time.Since(start)
-
-	return logScriptResult(err, packageName, duration, &outputBuf, "RunScriptInFakeroot")
+	return runShellScript(ctx, "RunScriptInFakeroot", cmds, packageName, nil,
+		[]ExecMiddleware{fakerootExecHandler(0)}, extraEnv)
 }
 
 // extractErrorLines filters a captured script output buffer down to lines that
@@ -388,6 +388,20 @@ func TestRunScriptInFakerootInvalidSyntax(t *testing.T) {
 	}
 }
 
+func TestRunScriptCapture(t *testing.T) {
+	var stdout bytes.Buffer
+
+	err := RunScriptCapture(context.Background(), "echo \"$CAPTURE_VALUE\"; echo ignored >&2", "test-package",
+		&stdout, nil, []string{"CAPTURE_VALUE=1.2.3"})
+	if err != nil {
+		t.Fatalf("RunScriptCapture failed: %v", err)
+	}
+
+	if stdout.String() != "1.2.3\n" {
+		t.Fatalf("Expected captured stdout '1.2.3\\n', got %q", stdout.String())
+	}
+}
+
 func TestApplyFakeroot(t *testing.T) {
 	// applyFakeroot should set CLONE_NEWUSER and UID/GID mappings when not root.
 	cmd := &exec.Cmd{}
@@ -7,6 +7,7 @@ import (
 // NewSigner constructs the appropriate concrete Signer for the format.
 //
 // For FormatAPK, returns an RSASigner (PKCS#1 v1.5 SHA1).
+// For FormatXBPS, returns an XBPSSigner (PKCS#1 v1.5 SHA-256).
 // For FormatDEB, FormatRPM, FormatPacman, returns a GPGSigner (OpenPGP).
 //
 // If signing is disabled (cfg.Enabled == false), a NoopSigner is returned.
@@ -29,6 +30,11 @@ func NewSigner(format Format, cfg Config) (Signer, error) {
 		return NewRSASigner(cfg)
 	}
 
+	// XBPS RSA signing (PKCS#1 v1.5 SHA-256, detached .sig2)
+	if format == FormatXBPS {
+		return NewXBPSSigner(cfg)
+	}
+
 	// DEB/RPM/Pacman GPG signing (OpenPGP)
 	if format == FormatDEB || format == FormatRPM || format == FormatPacman {
 		return NewGPGSigner(cfg, format)
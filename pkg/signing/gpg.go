@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/clearsign"
 
 	"github.com/M0Rf30/yap/v2/pkg/errors"
 	"github.com/M0Rf30/yap/v2/pkg/i18n"
@@ -16,7 +19,8 @@ import (
 )
 
 // GPGSigner produces detached OpenPGP signatures for DEB, RPM, and Pacman formats.
-// - DEB: writes <package>.deb.asc (ASCII-armored detached signature)
+// - DEB: writes <package>.deb.asc (ASCII-armored detached signature), and
+// clearsigns Debian source control (.dsc) files in place
 // - RPM: provides signing function for rpmpack.SetPGPSigner (binary signature)
 // - Pacman: writes <package>.pkg.tar.zst.sig (binary detached signature)
 type GPGSigner struct {
@@ -105,7 +109,7 @@ func (s *GPGSigner) writeSignatureSidecar(
 // Sign signs the artifact and writes a detached signature file.
 // Output convention by format:
 //
-//	FormatDEB    -> <artifactPath>.asc (ASCII-armored)
+//	FormatDEB    -> <artifactPath>.asc (ASCII-armored), .dsc clearsigned in place
 //	FormatRPM    -> returns a signing function for rpmpack.SetPGPSigner
 //	FormatPacman -> <artifactPath>.sig (binary, NOT armored)
 func (s *GPGSigner) Sign(ctx context.Context, artifactPath string) error {
@@ -113,6 +117,10 @@ func (s *GPGSigner) Sign(ctx context.Context, artifactPath string) error {
 		return err
 	}
 
+	if s.format == FormatDEB && strings.HasSuffix(artifactPath, ".dsc") {
+		return s.ClearSign(ctx, artifactPath)
+	}
+
 	// Read the artifact
 	artifactData, err := os.ReadFile(artifactPath) //nolint:gosec
 	if err != nil {
@@ -230,3 +238,83 @@ func (s *GPGSigner) createBinaryDetachedSignature(
 
 	return nil
 }
+
+// DetachSign writes the ASCII-armored detached signature of the file at
+// path to sigPath, as apt repositories sign Release into Release.gpg.
+func (s *GPGSigner) DetachSign(ctx context.Context, path, sigPath string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeFileSystem,
+			"failed to read file for signing").
+			WithOperation("DetachSign").
+			WithContext("path", path)
+	}
+
+	signatureBuf := bytes.NewBuffer(nil)
+
+	if err := s.createArmoredDetachedSignature(data, signatureBuf); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(sigPath, signatureBuf.Bytes(), 0o644); err != nil { //nolint:gosec
+		return errors.Wrap(err, errors.ErrTypeFileSystem,
+			"failed to write signature file").
+			WithOperation("DetachSign").
+			WithContext("signature_path", sigPath)
+	}
+
+	return nil
+}
+
+// ClearSign replaces the text file at path with its OpenPGP cleartext
+// signed version, as Debian source control (.dsc) files are signed.
+func (s *GPGSigner) ClearSign(ctx context.Context, path string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeFileSystem,
+			"failed to read file for signing").
+			WithOperation("ClearSign").
+			WithContext("path", path)
+	}
+
+	signingKey := s.entity.PrivateKey
+	if key, ok := s.entity.SigningKey(time.Now()); ok {
+		signingKey = key.PrivateKey
+	}
+
+	signed := bytes.NewBuffer(nil)
+
+	plaintext, err := clearsign.Encode(signed, signingKey, nil)
+	if err == nil {
+		_, err = plaintext.Write(data)
+		if closeErr := plaintext.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypePackaging,
+			"failed to create cleartext signature").
+			WithOperation("ClearSign").
+			WithContext("path", path)
+	}
+
+	if err := os.WriteFile(path, signed.Bytes(), 0o644); err != nil { //nolint:gosec
+		return errors.Wrap(err, errors.ErrTypeFileSystem,
+			"failed to write signed file").
+			WithOperation("ClearSign").
+			WithContext("path", path)
+	}
+
+	logger.Info(i18n.T("logger.signing.info.file_clearsigned_successfully"), "path", path)
+
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/clearsign"
 
 	"github.com/M0Rf30/yap/v2/pkg/signing"
 )
@@ -149,6 +150,104 @@ func TestGPGSignerSignDEB(t *testing.T) {
 	}
 }
 
+// TestGPGSignerSignDSC tests that Debian source control files are
+// clearsigned in place.
+func TestGPGSignerSignDSC(t *testing.T) {
+	tmpDir := t.TempDir()
+	keyPath := filepath.Join(tmpDir, "test.gpg")
+	dscPath := filepath.Join(tmpDir, "test_1.0-1.dsc")
+
+	keyPEM := generateTestGPGKey(t)
+	if err := os.WriteFile(keyPath, keyPEM, 0o600); err != nil {
+		t.Fatalf("Failed to write key file: %v", err)
+	}
+
+	dscData := []byte("Format: 3.0 (quilt)\nSource: test\nVersion: 1.0-1\n")
+	if err := os.WriteFile(dscPath, dscData, 0o644); err != nil {
+		t.Fatalf("Failed to write DSC file: %v", err)
+	}
+
+	signer, err := signing.NewGPGSigner(signing.Config{Enabled: true, KeyPath: keyPath}, signing.FormatDEB)
+	if err != nil {
+		t.Fatalf("NewGPGSigner() error = %v", err)
+	}
+
+	if err := signer.Sign(context.Background(), dscPath); err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	if _, err := os.Stat(dscPath + ".asc"); err == nil {
+		t.Errorf("Detached signature written for a DSC file")
+	}
+
+	signed, err := os.ReadFile(dscPath)
+	if err != nil {
+		t.Fatalf("Failed to read DSC file: %v", err)
+	}
+
+	block, _ := clearsign.Decode(signed)
+	if block == nil {
+		t.Fatalf("DSC file is not clearsigned")
+	}
+
+	if !bytes.Equal(block.Plaintext, dscData) {
+		t.Errorf("Plaintext = %q, want %q", block.Plaintext, dscData)
+	}
+
+	keyRing, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(keyPEM))
+	if err != nil {
+		t.Fatalf("Failed to read key ring: %v", err)
+	}
+
+	if _, err := block.VerifySignature(keyRing, nil); err != nil {
+		t.Errorf("VerifySignature() error = %v", err)
+	}
+}
+
+// TestGPGSignerDetachSign tests that DetachSign writes an armored
+// signature to the requested path.
+func TestGPGSignerDetachSign(t *testing.T) {
+	tmpDir := t.TempDir()
+	keyPath := filepath.Join(tmpDir, "test.gpg")
+	releasePath := filepath.Join(tmpDir, "Release")
+	sigPath := filepath.Join(tmpDir, "Release.gpg")
+
+	keyPEM := generateTestGPGKey(t)
+	if err := os.WriteFile(keyPath, keyPEM, 0o600); err != nil {
+		t.Fatalf("Failed to write key file: %v", err)
+	}
+
+	releaseData := []byte("Suite: stable\nCodename: stable\n")
+	if err := os.WriteFile(releasePath, releaseData, 0o644); err != nil {
+		t.Fatalf("Failed to write Release file: %v", err)
+	}
+
+	signer, err := signing.NewGPGSigner(signing.Config{Enabled: true, KeyPath: keyPath}, signing.FormatDEB)
+	if err != nil {
+		t.Fatalf("NewGPGSigner() error = %v", err)
+	}
+
+	if err := signer.DetachSign(context.Background(), releasePath, sigPath); err != nil {
+		t.Fatalf("DetachSign() error = %v", err)
+	}
+
+	sigData, err := os.ReadFile(sigPath)
+	if err != nil {
+		t.Fatalf("Failed to read signature file: %v", err)
+	}
+
+	keyRing, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(keyPEM))
+	if err != nil {
+		t.Fatalf("Failed to read key ring: %v", err)
+	}
+
+	if _, err := openpgp.CheckArmoredDetachedSignature(
+		keyRing, bytes.NewReader(releaseData), bytes.NewReader(sigData), nil,
+	); err != nil {
+		t.Errorf("CheckArmoredDetachedSignature() error = %v", err)
+	}
+}
+
 // TestGPGSignerSignPacman tests signing a Pacman package.
 func TestGPGSignerSignPacman(t *testing.T) {
 	tmpDir := t.TempDir()
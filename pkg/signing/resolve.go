@@ -331,7 +331,7 @@ func findDefaultKey(format Format) (string, bool) {
 // algorithmForFormat returns the signing algorithm for a given format.
 func algorithmForFormat(format Format) Algorithm {
 	switch format {
-	case FormatAPK:
+	case FormatAPK, FormatXBPS:
 		return AlgorithmRSA
 	case FormatDEB, FormatRPM, FormatPacman:
 		return AlgorithmGPG
@@ -18,6 +18,7 @@ import (
 
 	"github.com/klauspost/compress/gzip"
 
+	"github.com/M0Rf30/yap/v2/pkg/adb"
 	"github.com/M0Rf30/yap/v2/pkg/errors"
 	"github.com/M0Rf30/yap/v2/pkg/i18n"
 	"github.com/M0Rf30/yap/v2/pkg/logger"
@@ -25,7 +26,8 @@ import (
 
 // RSASigner signs APK packages with PKCS#1 v1.5 SHA1, matching Alpine abuild-sign.
 // The signature is computed over the control.tar.gz bytes and prepended as a
-// third gzip stream in the final APK file.
+// third gzip stream in the final APK file. APK v3 (ADB) packages get a
+// SHA-512 signature block instead.
 type RSASigner struct {
 	cfg Config
 	key *rsa.PrivateKey
@@ -107,6 +109,7 @@ func parseRSAPrivateKey(keyData []byte, keyPath string) (*rsa.PrivateKey, error)
 //  3. data.tar.gz — unchanged from original
 //
 // The signature is computed as PKCS#1 v1.5 SHA1(control.tar.gz bytes).
+// APK v3 packages, recognized by their ADB magic, are signed by signADB.
 func (s *RSASigner) Sign(ctx context.Context, artifactPath string) error {
 	if err := ctx.Err(); err != nil {
 		return err
@@ -121,6 +124,10 @@ func (s *RSASigner) Sign(ctx context.Context, artifactPath string) error {
 			WithContext("artifact_path", artifactPath)
 	}
 
+	if bytes.HasPrefix(apkData, []byte("ADB")) {
+		return s.signADB(artifactPath, apkData)
+	}
+
 	// Find where the first gzip stream (control.tar.gz) ends
 	dataStart, err := extractFirstGzipStream(apkData)
 	if err != nil {
@@ -206,6 +213,95 @@ func (s *RSASigner) Sign(ctx context.Context, artifactPath string) error {
 	return nil
 }
 
+// SignIndex signs the APKINDEX.tar.gz at indexPath the way abuild-sign
+// does: the PKCS#1 v1.5 SHA1 signature covers the whole unsigned index, a
+// single gzip stream, and the signature stream is prepended to it.
+func (s *RSASigner) SignIndex(ctx context.Context, indexPath string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	indexData, err := os.ReadFile(indexPath) //nolint:gosec
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeFileSystem,
+			"failed to read APKINDEX").
+			WithOperation("SignIndex").
+			WithContext("index_path", indexPath)
+	}
+
+	hash := sha1.Sum(indexData) //nolint:gosec
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA1, hash[:])
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypePackaging,
+			"failed to sign APKINDEX").
+			WithOperation("SignIndex").
+			WithContext("index_path", indexPath)
+	}
+
+	signatureTarGz, err := s.createSignatureTar(signature)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypePackaging,
+			"failed to create signature tar").
+			WithOperation("SignIndex").
+			WithContext("index_path", indexPath)
+	}
+
+	tmpPath := indexPath + ".tmp"
+	if err := os.WriteFile(tmpPath, append(signatureTarGz, indexData...), 0o644); err != nil { //nolint:gosec
+		return errors.Wrap(err, errors.ErrTypeFileSystem,
+			"failed to write signed APKINDEX").
+			WithOperation("SignIndex").
+			WithContext("index_path", tmpPath)
+	}
+
+	if err := os.Rename(tmpPath, indexPath); err != nil {
+		_ = os.Remove(tmpPath) // Best effort cleanup
+
+		return errors.Wrap(err, errors.ErrTypeFileSystem,
+			"failed to replace APKINDEX with signed version").
+			WithOperation("SignIndex").
+			WithContext("index_path", indexPath)
+	}
+
+	return nil
+}
+
+// signADB signs an APK v3 package, adding a PKCS#1 v1.5 SHA-512 signature
+// block after its ADB block as apk-tools 3 expects. The key is identified
+// by its public key hash rather than by name.
+func (s *RSASigner) signADB(artifactPath string, apkData []byte) error {
+	signedAPK, err := adb.Sign(apkData, s.key)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypePackaging,
+			"failed to sign ADB package").
+			WithOperation("Sign").
+			WithContext("artifact_path", artifactPath)
+	}
+
+	tmpPath := artifactPath + ".tmp"
+	if err := os.WriteFile(tmpPath, signedAPK, 0o644); err != nil { //nolint:gosec
+		return errors.Wrap(err, errors.ErrTypeFileSystem,
+			"failed to write signed APK").
+			WithOperation("Sign").
+			WithContext("artifact_path", tmpPath)
+	}
+
+	if err := os.Rename(tmpPath, artifactPath); err != nil {
+		_ = os.Remove(tmpPath) // Best effort cleanup
+
+		return errors.Wrap(err, errors.ErrTypeFileSystem,
+			"failed to replace APK with signed version").
+			WithOperation("Sign").
+			WithContext("artifact_path", artifactPath)
+	}
+
+	logger.Info(i18n.T("logger.signing.info.apk_package_signed_successfully"), "artifact_path", artifactPath,
+		"key_name", s.cfg.KeyName)
+
+	return nil
+}
+
 // createSignatureTar creates a gzip-compressed tar archive containing a single
 // entry: .SIGN.RSA.<keyname>.rsa.pub with the signature bytes as contents.
 func (s *RSASigner) createSignatureTar(signature []byte) ([]byte, error) {
@@ -238,3 +238,33 @@ func TestRSASignerSignatureValidation(t *testing.T) {
 		t.Logf("Hash: %x", hash[:])
 	}
 }
+
+// TestRSASignerSignIndex tests that the signature stream prepended to an
+// APKINDEX covers the whole unsigned index.
+func TestRSASignerSignIndex(t *testing.T) {
+	tmpDir := t.TempDir()
+	keyPath := filepath.Join(tmpDir, "test.key")
+	indexPath := filepath.Join(tmpDir, "APKINDEX.tar.gz")
+
+	privKey, keyPEM := generateTestRSAKey(t)
+	require.NoError(t, os.WriteFile(keyPath, keyPEM, 0o600))
+
+	// An unsigned APKINDEX.tar.gz is a single gzip stream.
+	_, indexData, _ := createFakeAPK(t)
+	require.NoError(t, os.WriteFile(indexPath, indexData, 0o644))
+
+	signer, err := NewRSASigner(Config{Enabled: true, KeyPath: keyPath, KeyName: "testkey"})
+	require.NoError(t, err)
+	require.NoError(t, signer.SignIndex(context.Background(), indexPath))
+
+	signedData, err := os.ReadFile(indexPath)
+	require.NoError(t, err)
+	require.True(t, bytes.HasSuffix(signedData, indexData))
+
+	signature, err := extractSignatureFromAPK(t, signedData)
+	require.NoError(t, err)
+
+	// nolint:gosec // SHA1 required by APK format
+	hash := sha1.Sum(indexData)
+	require.NoError(t, rsa.VerifyPKCS1v15(&privKey.PublicKey, crypto.SHA1, hash[:], signature))
+}
@@ -14,6 +14,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/M0Rf30/yap/v2/pkg/adb"
 	"github.com/M0Rf30/yap/v2/pkg/signing"
 )
 
@@ -204,6 +205,80 @@ func TestRSASignerSign(t *testing.T) {
 	}
 }
 
+// TestRSASignerSignADB tests that APK v3 packages get a signature block
+// after their ADB block, verifiable with the public key.
+func TestRSASignerSignADB(t *testing.T) {
+	tmpDir := t.TempDir()
+	keyPath := filepath.Join(tmpDir, "test.key")
+	apkPath := filepath.Join(tmpDir, "test.apk")
+
+	keyPEM := generateTestRSAKey(t)
+	if err := os.WriteFile(keyPath, keyPEM, 0o600); err != nil {
+		t.Fatalf("Failed to write key file: %v", err)
+	}
+
+	db := adb.NewDB()
+	db.SetRoot(db.Object([]adb.Value{adb.PkgInfo: db.String("test")}))
+
+	var apkData bytes.Buffer
+
+	writer, err := adb.NewWriter(&apkData, adb.SchemaPackage, adb.CompressionDeflate)
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+
+	if err := writer.WriteBlock(adb.BlockADB, db.Bytes()); err != nil {
+		t.Fatalf("WriteBlock() error = %v", err)
+	}
+
+	if err := writer.WriteBlock(adb.BlockData, []byte("data")); err != nil {
+		t.Fatalf("WriteBlock() error = %v", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if err := os.WriteFile(apkPath, apkData.Bytes(), 0o644); err != nil {
+		t.Fatalf("Failed to write APK file: %v", err)
+	}
+
+	signer, err := signing.NewRSASigner(signing.Config{Enabled: true, KeyPath: keyPath})
+	if err != nil {
+		t.Fatalf("NewRSASigner() error = %v", err)
+	}
+
+	if err := signer.Sign(context.Background(), apkPath); err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+
+	signedData, err := os.ReadFile(apkPath)
+	if err != nil {
+		t.Fatalf("Failed to read signed APK: %v", err)
+	}
+
+	file, err := adb.Parse(signedData)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if file.Compression != adb.CompressionDeflate || len(file.Blocks) != 3 ||
+		file.Blocks[1].Type != adb.BlockSig || file.Blocks[2].Type != adb.BlockData {
+		t.Fatalf("signed package blocks = %+v, want ADB, signature and data", file.Blocks)
+	}
+
+	block, _ := pem.Decode(keyPEM)
+
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !adb.VerifySignature(file.Schema, file.ADB(), file.Blocks[1].Data, &key.PublicKey) {
+		t.Error("signature block does not verify")
+	}
+}
+
 // TestRSASignerDefaultKeyName tests that default key name is derived from file.
 func TestRSASignerDefaultKeyName(t *testing.T) {
 	tmpDir := t.TempDir()
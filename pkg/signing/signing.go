@@ -1,11 +1,12 @@
 // Package signing provides package signing infrastructure for YAP.
 //
 // This package establishes the foundation for signing packages across all
-// supported formats (APK, DEB, RPM, Pacman). It defines the Signer interface,
-// signing configuration, and resolution logic for keys and passphrases.
+// supported formats (APK, DEB, RPM, Pacman, XBPS). It defines the Signer
+// interface, signing configuration, and resolution logic for keys and
+// passphrases.
 //
-// Signing implementations are provided for APK (RSA PKCS#1 v1.5 SHA1) and
-// DEB/RPM/Pacman (OpenPGP).
+// Signing implementations are provided for APK (RSA PKCS#1 v1.5 SHA1), XBPS
+// (RSA PKCS#1 v1.5 SHA-256) and DEB/RPM/Pacman (OpenPGP).
 package signing
 
 import (
@@ -25,13 +26,15 @@ const (
 	FormatRPM Format = "rpm"
 	// FormatPacman represents Arch Linux Pacman packages.
 	FormatPacman Format = "pacman"
+	// FormatXBPS represents Void Linux XBPS packages.
+	FormatXBPS Format = "xbps"
 )
 
 // Algorithm names a signing algorithm/key family.
 type Algorithm string
 
 const (
-	// AlgorithmRSA uses PKCS#1 v1.5 SHA1, used by APK.
+	// AlgorithmRSA uses PKCS#1 v1.5 RSA keys, used by APK and XBPS.
 	AlgorithmRSA Algorithm = "rsa"
 	// AlgorithmGPG uses OpenPGP, used by DEB/RPM/Pacman.
 	AlgorithmGPG Algorithm = "gpg"
@@ -86,6 +89,7 @@ func (c *Config) Clear() {
 //   - DEB: appends _gpgorigin to the ar archive (dpkg-sig style)
 //   - RPM: writes signature into the RPM lead/header (delegated to rpmpack)
 //   - Pacman: writes detached <artifactPath>.sig
+//   - XBPS: writes detached <artifactPath>.sig2
 type Signer interface {
 	// Sign signs the artifact at artifactPath.
 	Sign(ctx context.Context, artifactPath string) error
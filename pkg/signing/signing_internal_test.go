@@ -61,6 +61,7 @@ func TestAlgorithmForFormat(t *testing.T) {
 		{FormatDEB, AlgorithmGPG},
 		{FormatRPM, AlgorithmGPG},
 		{FormatPacman, AlgorithmGPG},
+		{FormatXBPS, AlgorithmRSA},
 	}
 
 	for _, tt := range tests {
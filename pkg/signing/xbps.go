@@ -0,0 +1,91 @@
+package signing
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/logger"
+)
+
+// XBPSSignatureExt is the extension of the detached signatures of xbps
+// packages.
+const XBPSSignatureExt = ".sig2"
+
+// XBPSSigner signs Void Linux packages as xbps-rindex --sign-pkg does: a
+// detached <artifactPath>.sig2 holds the PKCS#1 v1.5 SHA-256 signature of
+// the SHA-256 digest of the package.
+type XBPSSigner struct {
+	cfg Config
+	key *rsa.PrivateKey
+}
+
+// NewXBPSSigner loads the private key from cfg.KeyPath, with the same
+// requirements as NewRSASigner.
+func NewXBPSSigner(cfg Config) (*XBPSSigner, error) {
+	rsaSigner, err := NewRSASigner(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &XBPSSigner{cfg: cfg, key: rsaSigner.key}, nil
+}
+
+// PublicKey returns the public key a repository index records for the
+// packages signed by s.
+func (s *XBPSSigner) PublicKey() *rsa.PublicKey {
+	return &s.key.PublicKey
+}
+
+// Sign writes the detached signature of the package at artifactPath.
+func (s *XBPSSigner) Sign(ctx context.Context, artifactPath string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	file, err := os.Open(filepath.Clean(artifactPath))
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeFileSystem, "failed to read xbps package").
+			WithOperation("Sign").
+			WithContext("artifact_path", artifactPath)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return errors.Wrap(err, errors.ErrTypeFileSystem, "failed to read xbps package").
+			WithOperation("Sign").
+			WithContext("artifact_path", artifactPath)
+	}
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, hash.Sum(nil))
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypePackaging, "failed to sign xbps package").
+			WithOperation("Sign").
+			WithContext("artifact_path", artifactPath)
+	}
+
+	sigPath := artifactPath + XBPSSignatureExt
+
+	//nolint:gosec // signatures are public
+	if err := os.WriteFile(sigPath, signature, 0o644); err != nil {
+		return errors.Wrap(err, errors.ErrTypeFileSystem, "failed to write xbps signature").
+			WithOperation("Sign").
+			WithContext("signature_path", sigPath)
+	}
+
+	logger.Info(i18n.T("logger.signing.info.xbps_package_signed_successfully"), "artifact_path", artifactPath,
+		"signature_path", sigPath)
+
+	return nil
+}
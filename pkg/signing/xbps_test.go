@@ -0,0 +1,53 @@
+package signing_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/M0Rf30/yap/v2/pkg/signing"
+)
+
+// TestXBPSSignerSign verifies that the .sig2 signature verifies against the
+// SHA-256 digest of the package.
+func TestXBPSSignerSign(t *testing.T) {
+	tmpDir := t.TempDir()
+	keyPath := filepath.Join(tmpDir, "test.key")
+	pkgPath := filepath.Join(tmpDir, "foo-1.0_1.x86_64.xbps")
+
+	require.NoError(t, os.WriteFile(keyPath, generateTestRSAKey(t), 0o600))
+	require.NoError(t, os.WriteFile(pkgPath, []byte("package contents"), 0o644))
+
+	signer, err := signing.NewSigner(signing.FormatXBPS, signing.Config{Enabled: true, KeyPath: keyPath})
+	require.NoError(t, err)
+
+	xbpsSigner, ok := signer.(*signing.XBPSSigner)
+	require.True(t, ok, "FormatXBPS should route to an XBPSSigner")
+
+	require.NoError(t, xbpsSigner.Sign(context.Background(), pkgPath))
+
+	signature, err := os.ReadFile(pkgPath + signing.XBPSSignatureExt)
+	require.NoError(t, err)
+
+	digest := sha256.Sum256([]byte("package contents"))
+	assert.NoError(t, rsa.VerifyPKCS1v15(xbpsSigner.PublicKey(), crypto.SHA256, digest[:], signature))
+}
+
+// TestXBPSSignerMissingPackage verifies that signing a missing package fails.
+func TestXBPSSignerMissingPackage(t *testing.T) {
+	tmpDir := t.TempDir()
+	keyPath := filepath.Join(tmpDir, "test.key")
+	require.NoError(t, os.WriteFile(keyPath, generateTestRSAKey(t), 0o600))
+
+	signer, err := signing.NewXBPSSigner(signing.Config{Enabled: true, KeyPath: keyPath})
+	require.NoError(t, err)
+
+	assert.Error(t, signer.Sign(context.Background(), filepath.Join(tmpDir, "missing.xbps")))
+}
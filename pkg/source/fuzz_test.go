@@ -73,7 +73,7 @@ func FuzzParseURI(f *testing.F) {
 }
 
 // FuzzGetProtocol tests protocol detection with arbitrary URIs.
-// Must never panic, must return one of: "http", "https", "ftp", "git", "file", or "".
+// Must never panic, must return one of: "http", "https", "ftp", "git", "svn", "hg", "file", or "".
 func FuzzGetProtocol(f *testing.F) {
 	// Seed corpus
 	f.Add("https://example.com/file.tar.gz")
@@ -89,6 +89,10 @@ func FuzzGetProtocol(f *testing.F) {
 	f.Add("ftp://")
 	f.Add("git+http://example.com/repo.git")
 	f.Add("git+ftp://example.com/repo.git")
+	f.Add("git+ssh://git@example.com/repo.git")
+	f.Add("git+file:///srv/git/repo.git")
+	f.Add("svn+https://svn.example.com/repo/trunk")
+	f.Add("hg+https://hg.example.com/repo")
 	f.Add("unknown://example.com/file")
 	f.Add("http")
 	f.Add("https")
@@ -110,6 +114,8 @@ func FuzzGetProtocol(f *testing.F) {
 			"https": true,
 			"ftp":   true,
 			"git":   true,
+			"svn":   true,
+			"hg":    true,
 			"file":  true,
 			"":      true,
 		}
@@ -0,0 +1,248 @@
+package source
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/logger"
+	"github.com/M0Rf30/yap/v2/pkg/signing"
+)
+
+// pgpKeysDir is the directory, relative to the PKGBUILD, holding the
+// ASCII-armored public keys referenced by validpgpkeys, one
+// <fingerprint>.asc file per key (same layout as makepkg's keys/pgp).
+var pgpKeysDir = filepath.Join("keys", "pgp")
+
+// signatureExtensions are the detached signature suffixes recognised in
+// a source array, matching makepkg's verify_signatures().
+var signatureExtensions = []string{".sig", ".sign", ".asc"}
+
+// maxPGPKeySize caps the size of a key file read from keys/pgp.
+const maxPGPKeySize = 16 << 20
+
+// signaturePair links a detached signature to the source file it signs.
+type signaturePair struct {
+	dataPath      string
+	signaturePath string
+	sourceURI     string
+}
+
+// VerifySignatures verifies every detached signature declared in sourceURIs
+// against the source item it signs. A "foo.tar.gz.sig" (or .sign/.asc)
+// entry is paired with a "foo.tar.gz" entry of the same array; signatures
+// without a matching item are ignored. Only the keys listed in
+// validPGPKeys, loaded from startDir/keys/pgp/<fingerprint>.asc, are
+// trusted.
+//
+// It must run after every source has been downloaded into startDir.
+// It returns an ErrTypeValidation error if signatures are present but
+// validpgpkeys is empty, if a key is missing or if a signature does not
+// verify.
+func VerifySignatures(startDir string, sourceURIs, validPGPKeys []string) error {
+	pairs := pairSignatures(startDir, sourceURIs)
+	if len(pairs) == 0 {
+		return nil
+	}
+
+	if len(validPGPKeys) == 0 {
+		return errors.New(errors.ErrTypeValidation, i18n.T("errors.source.no_validpgpkeys")).
+			WithOperation("VerifySignatures").
+			WithContext("signatures", len(pairs))
+	}
+
+	keyring, err := loadValidPGPKeys(filepath.Join(startDir, pgpKeysDir), validPGPKeys)
+	if err != nil {
+		return err
+	}
+
+	for _, pair := range pairs {
+		if err := verifyDetachedSignature(pair, keyring); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// pairSignatures returns the signature/data pairs found in sourceURIs,
+// resolving each item to its download path inside startDir.
+func pairSignatures(startDir string, sourceURIs []string) []signaturePair {
+	items := make(map[string]string, len(sourceURIs))
+
+	for _, uri := range sourceURIs {
+		src := Source{SourceItemURI: uri}
+		src.parseURI()
+		items[src.SourceItemPath] = uri
+	}
+
+	var pairs []signaturePair
+
+	for _, uri := range sourceURIs {
+		src := Source{SourceItemURI: uri}
+		src.parseURI()
+
+		for _, ext := range signatureExtensions {
+			dataName, ok := strings.CutSuffix(src.SourceItemPath, ext)
+			if !ok {
+				continue
+			}
+
+			dataURI, found := items[dataName]
+			if !found {
+				continue
+			}
+
+			pairs = append(pairs, signaturePair{
+				dataPath:      filepath.Join(startDir, dataName),
+				signaturePath: filepath.Join(startDir, src.SourceItemPath),
+				sourceURI:     dataURI,
+			})
+
+			break
+		}
+	}
+
+	return pairs
+}
+
+// normalizeFingerprint strips whitespace and upper-cases a fingerprint so
+// that "abcd ef01 ..." and "ABCDEF01..." refer to the same key.
+func normalizeFingerprint(fingerprint string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(fingerprint), ""))
+}
+
+// isFingerprint reports whether a normalized fingerprint is a v4 (40 hex
+// characters) or v5/v6 (64 hex characters) key fingerprint.
+func isFingerprint(fingerprint string) bool {
+	if len(fingerprint) != 40 && len(fingerprint) != 64 {
+		return false
+	}
+
+	_, err := hex.DecodeString(fingerprint)
+
+	return err == nil
+}
+
+// loadValidPGPKeys reads keysDir/<fingerprint>.asc for every entry of
+// validPGPKeys and returns the matching entities. A key file that is
+// missing, unreadable or does not contain the declared fingerprint is an
+// error: the PKGBUILD asked for that trust anchor and we cannot provide it.
+func loadValidPGPKeys(keysDir string, validPGPKeys []string) (openpgp.EntityList, error) {
+	keyring := make(openpgp.EntityList, 0, len(validPGPKeys))
+
+	for _, declared := range validPGPKeys {
+		fingerprint := normalizeFingerprint(declared)
+		if !isFingerprint(fingerprint) {
+			return nil, errors.New(errors.ErrTypeValidation,
+				fmt.Sprintf(i18n.T("errors.source.invalid_pgp_fingerprint"), declared)).
+				WithOperation("loadValidPGPKeys").
+				WithContext("fingerprint", declared)
+		}
+
+		keyPath := filepath.Join(keysDir, fingerprint+".asc")
+
+		entities, err := readKeyFile(keyPath)
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrTypeValidation,
+				i18n.T("errors.source.failed_to_load_pgp_key")).
+				WithOperation("loadValidPGPKeys").
+				WithContext("fingerprint", fingerprint).
+				WithContext("path", keyPath)
+		}
+
+		found := false
+
+		for _, entity := range entities {
+			if strings.ToUpper(hex.EncodeToString(entity.PrimaryKey.Fingerprint)) == fingerprint {
+				keyring = append(keyring, entity)
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return nil, errors.New(errors.ErrTypeValidation,
+				i18n.T("errors.source.pgp_key_fingerprint_mismatch")).
+				WithOperation("loadValidPGPKeys").
+				WithContext("fingerprint", fingerprint).
+				WithContext("path", keyPath)
+		}
+	}
+
+	return keyring, nil
+}
+
+// readKeyFile parses an armored or binary OpenPGP public key file.
+func readKeyFile(path string) (openpgp.EntityList, error) {
+	f, err := os.Open(path) //nolint:gosec // path is built from the PKGBUILD dir
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() { _ = f.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(f, maxPGPKeySize))
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.Contains(data, []byte("-----BEGIN PGP")) {
+		return openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	}
+
+	return openpgp.ReadKeyRing(bytes.NewReader(data))
+}
+
+// verifyDetachedSignature checks pair.signaturePath, armored or binary,
+// against pair.dataPath using only the trusted keyring.
+func verifyDetachedSignature(pair signaturePair, keyring openpgp.EntityList) error {
+	signature, err := os.ReadFile(pair.signaturePath)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeFileSystem,
+			i18n.T("errors.source.failed_to_open_signed_file")).
+			WithOperation("verifyDetachedSignature").
+			WithContext("path", pair.signaturePath)
+	}
+
+	data, err := os.Open(pair.dataPath)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeFileSystem,
+			i18n.T("errors.source.failed_to_open_signed_file")).
+			WithOperation("verifyDetachedSignature").
+			WithContext("path", pair.dataPath)
+	}
+
+	defer func() { _ = data.Close() }()
+
+	var signer *openpgp.Entity
+
+	if bytes.Contains(signature, []byte("-----BEGIN PGP SIGNATURE")) {
+		signer, err = openpgp.CheckArmoredDetachedSignature(keyring, data, bytes.NewReader(signature), nil)
+	} else {
+		signer, err = openpgp.CheckDetachedSignature(keyring, data, bytes.NewReader(signature), nil)
+	}
+
+	if err != nil {
+		return errors.Wrap(err, errors.ErrTypeValidation,
+			i18n.T("errors.source.pgp_verification_failed")).
+			WithOperation("verifyDetachedSignature").
+			WithContext("source", pair.sourceURI).
+			WithContext("signature", filepath.Base(pair.signaturePath))
+	}
+
+	logger.Info(i18n.T("logger.source.info.pgp_signature_verified"),
+		"source", filepath.Base(pair.dataPath),
+		"signer", signing.SignerName(signer))
+
+	return nil
+}
@@ -0,0 +1,247 @@
+//nolint:testpackage // Internal testing of signature pairing helpers
+package source
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+)
+
+// newPGPTestEntity builds a small RSA identity; security is irrelevant for
+// test data.
+func newPGPTestEntity(t *testing.T) *openpgp.Entity {
+	t.Helper()
+
+	entity, err := openpgp.NewEntity("Upstream", "", "upstream@example.com", &packet.Config{
+		DefaultHash: crypto.SHA256,
+		RSABits:     1024,
+		Rand:        rand.Reader,
+		Time:        time.Now,
+	})
+	require.NoError(t, err)
+
+	return entity
+}
+
+// writePGPKey stores the armored public key of entity under
+// startDir/keys/pgp/<fingerprint>.asc and returns the fingerprint.
+func writePGPKey(t *testing.T, startDir string, entity *openpgp.Entity) string {
+	t.Helper()
+
+	fingerprint := strings.ToUpper(hex.EncodeToString(entity.PrimaryKey.Fingerprint))
+	keysDir := filepath.Join(startDir, "keys", "pgp")
+	require.NoError(t, os.MkdirAll(keysDir, 0o755))
+
+	var buf bytes.Buffer
+
+	w, err := armor.Encode(&buf, openpgp.PublicKeyType, nil)
+	require.NoError(t, err)
+	require.NoError(t, entity.Serialize(w))
+	require.NoError(t, w.Close())
+	require.NoError(t, os.WriteFile(filepath.Join(keysDir, fingerprint+".asc"), buf.Bytes(), 0o600))
+
+	return fingerprint
+}
+
+// writeSignedSource writes data to startDir/name and a detached signature
+// to startDir/name+ext, armored when ext is ".asc".
+func writeSignedSource(t *testing.T, startDir, name, ext string, data []byte, signer *openpgp.Entity) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(filepath.Join(startDir, name), data, 0o600))
+
+	var sig bytes.Buffer
+	if ext == ".asc" {
+		require.NoError(t, openpgp.ArmoredDetachSign(&sig, signer, bytes.NewReader(data), nil))
+	} else {
+		require.NoError(t, openpgp.DetachSign(&sig, signer, bytes.NewReader(data), nil))
+	}
+
+	require.NoError(t, os.WriteFile(filepath.Join(startDir, name+ext), sig.Bytes(), 0o600))
+}
+
+func TestPairSignatures(t *testing.T) {
+	t.Parallel()
+
+	pairs := pairSignatures("/start", []string{
+		"https://example.com/foo-1.0.tar.gz",
+		"https://example.com/foo-1.0.tar.gz.sig",
+		"bar.tar.xz::https://example.com/download?id=1",
+		"bar.tar.xz.asc::https://example.com/download?id=2",
+		"https://example.com/orphan.tar.gz.sign",
+	})
+
+	require.Len(t, pairs, 2)
+	assert.Equal(t, "/start/foo-1.0.tar.gz", pairs[0].dataPath)
+	assert.Equal(t, "/start/foo-1.0.tar.gz.sig", pairs[0].signaturePath)
+	assert.Equal(t, "/start/bar.tar.xz", pairs[1].dataPath)
+	assert.Equal(t, "/start/bar.tar.xz.asc", pairs[1].signaturePath)
+}
+
+func TestNormalizeFingerprint(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "ABCDEF0123", normalizeFingerprint(" abcd ef01 23 "))
+}
+
+func TestVerifySignatures(t *testing.T) {
+	t.Parallel()
+
+	signer := newPGPTestEntity(t)
+	data := []byte("upstream tarball contents")
+
+	tests := []struct {
+		name    string
+		ext     string
+		setup   func(t *testing.T, dir string) []string
+		tamper  bool
+		wantErr bool
+	}{
+		{
+			name: "binary signature",
+			ext:  ".sig",
+			setup: func(t *testing.T, dir string) []string {
+				t.Helper()
+
+				return []string{writePGPKey(t, dir, signer)}
+			},
+		},
+		{
+			name: "armored signature with lowercase fingerprint",
+			ext:  ".asc",
+			setup: func(t *testing.T, dir string) []string {
+				t.Helper()
+
+				return []string{strings.ToLower(writePGPKey(t, dir, signer))}
+			},
+		},
+		{
+			name: "tampered data",
+			ext:  ".sig",
+			setup: func(t *testing.T, dir string) []string {
+				t.Helper()
+
+				return []string{writePGPKey(t, dir, signer)}
+			},
+			tamper:  true,
+			wantErr: true,
+		},
+		{
+			name: "signer not in validpgpkeys",
+			ext:  ".sig",
+			setup: func(t *testing.T, dir string) []string {
+				t.Helper()
+
+				return []string{writePGPKey(t, dir, newPGPTestEntity(t))}
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing key file",
+			ext:  ".sig",
+			setup: func(t *testing.T, _ string) []string {
+				t.Helper()
+
+				return []string{"ABCDEF0123456789ABCDEF0123456789ABCDEF01"}
+			},
+			wantErr: true,
+		},
+		{
+			name: "no validpgpkeys",
+			ext:  ".sig",
+			setup: func(t *testing.T, _ string) []string {
+				t.Helper()
+
+				return nil
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			writeSignedSource(t, dir, "foo-1.0.tar.gz", tt.ext, data, signer)
+
+			if tt.tamper {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "foo-1.0.tar.gz"), []byte("evil"), 0o600))
+			}
+
+			validKeys := tt.setup(t, dir)
+			sources := []string{
+				"https://example.com/foo-1.0.tar.gz",
+				"https://example.com/foo-1.0.tar.gz" + tt.ext,
+			}
+
+			err := VerifySignatures(dir, sources, validKeys)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			require.Error(t, err)
+
+			var yapErr *errors.YapError
+			require.ErrorAs(t, err, &yapErr)
+			assert.Equal(t, errors.ErrTypeValidation, yapErr.Type)
+		})
+	}
+}
+
+func TestLoadValidPGPKeysFingerprintMismatch(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	fingerprint := writePGPKey(t, dir, newPGPTestEntity(t))
+	keysDir := filepath.Join(dir, "keys", "pgp")
+
+	// A key file whose name claims a different fingerprint must not be trusted.
+	const declared = "ABCDEF0123456789ABCDEF0123456789ABCDEF01"
+
+	require.NoError(t, os.Rename(
+		filepath.Join(keysDir, fingerprint+".asc"),
+		filepath.Join(keysDir, declared+".asc")))
+
+	_, err := loadValidPGPKeys(keysDir, []string{declared})
+	require.Error(t, err)
+}
+
+func TestLoadValidPGPKeysInvalidFingerprint(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	keysDir := filepath.Join(dir, "keys", "pgp")
+
+	// A key outside keys/pgp must not be reachable through validpgpkeys.
+	fingerprint := writePGPKey(t, dir, newPGPTestEntity(t))
+	require.NoError(t, os.Rename(
+		filepath.Join(keysDir, fingerprint+".asc"),
+		filepath.Join(dir, "foo.asc")))
+
+	for _, declared := range []string{"../../foo", "ABCDEF01", fingerprint[:39] + "G"} {
+		_, err := loadValidPGPKeys(keysDir, []string{declared})
+		require.Error(t, err, declared)
+
+		var yapErr *errors.YapError
+		require.ErrorAs(t, err, &yapErr)
+		assert.Equal(t, errors.ErrTypeValidation, yapErr.Type)
+		assert.Contains(t, err.Error(), declared)
+	}
+}
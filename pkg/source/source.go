@@ -27,16 +27,28 @@ import (
 	"github.com/M0Rf30/yap/v2/pkg/i18n"
 	"github.com/M0Rf30/yap/v2/pkg/logger"
 	"github.com/M0Rf30/yap/v2/pkg/shell"
+	"github.com/M0Rf30/yap/v2/pkg/srccache"
 )
 
 const (
 	fileProtocol = "file"
+	svnProtocol  = "svn"
+	hgProtocol   = "hg"
 	branchKey    = "branch"
 	commitKey    = "commit"
+	revisionKey  = "revision"
 	tagKey       = "tag"
 	skipValue    = "SKIP"
 )
 
+// vcsTransports lists the transports accepted after each "<vcs>+" source
+// prefix, e.g. "git+ssh://" or "svn+https://".
+var vcsTransports = map[string][]string{
+	constants.Git: {"http", "https", "ssh", "file"},
+	svnProtocol:   {"http", "https", "svn", "ssh", "file"},
+	hgProtocol:    {"http", "https", "ssh", "file"},
+}
+
 // Global variables for source handling
 var (
 	// sshPassword contains the SSH password for authentication.
@@ -89,6 +101,13 @@ type Source struct {
 	// SkipHashCheck disables sha256/sha512 integrity verification for this
 	// source item. Equivalent to setting the checksum to SKIP in the PKGBUILD.
 	SkipHashCheck bool
+	// Mirrors are alternative URLs tried in order when SourceItemURI fails to
+	// download. parseURI appends the "|"-separated alternatives of the source
+	// entry, i.e: "https://a.example.com/x.tgz|https://b.example.com/x.tgz".
+	Mirrors []string
+	// DownloadURL is the URL the source item was downloaded from. It is empty
+	// when nothing was downloaded (local files, VCS checkouts, cached files).
+	DownloadURL string
 }
 
 // Get retrieves the source file from the specified URI.
@@ -96,16 +115,18 @@ type Source struct {
 // It parses the URI and determines the source file path and type.
 // If the source file does not exist, it retrieves it from the specified URI.
 // It validates the source file and symlinks any additional source files.
-// Finally, it extracts the source file if necessary.
+// Finally, it extracts the source file if necessary. Cancelling ctx stops
+// svn and hg checkouts.
 //
 // Returns an error if any step fails.
-func (src *Source) Get() error {
+func (src *Source) Get(ctx context.Context) error {
 	src.parseURI()
 	sourceFilePath := filepath.Join(src.StartDir, src.SourceItemPath)
 	sourceType := src.getProtocol()
+	restored := false
 
 	switch sourceType {
-	case "http", "https", "ftp", constants.Git:
+	case "http", "https", "ftp", constants.Git, svnProtocol, hgProtocol:
 		// For git sources, if the path contains a bare/mirror repo (e.g. from
 		// a CI stash or makepkg-style cache), create a working copy from it
 		// matching makepkg's extract_git() behavior. If extraction fails
@@ -123,20 +144,31 @@ func (src *Source) Get() error {
 			_ = os.RemoveAll(filepath.Join(src.SrcDir, filepath.Base(sourceFilePath)))
 		}
 
-		// Use singleflight to prevent duplicate downloads of the same file
-		if !files.Exists(sourceFilePath) {
-			_, err, _ := downloadGroup.Do(sourceFilePath, func() (any, error) {
+		// Use singleflight to prevent duplicate downloads of the same file.
+		// VCS checkouts are refreshed even when cached, so that repeated
+		// builds only fetch the new revisions (makepkg's SRCDEST behaviour).
+		if !files.Exists(sourceFilePath) || isVCS(sourceType) {
+			// The result reports whether the file was restored from the
+			// shared source cache, so that every caller sharing the
+			// download sees it, not only the one that ran it.
+			result, err, _ := downloadGroup.Do(sourceFilePath, func() (any, error) {
 				// Double-check after acquiring the group slot
-				if files.Exists(sourceFilePath) {
-					//nolint:nilnil // Returning (nil, nil) is valid when file exists
-					return nil, nil
+				if files.Exists(sourceFilePath) && !isVCS(sourceType) {
+					return false, nil
 				}
 
-				return nil, src.getURL(sourceType, sourceFilePath, sshPassword)
+				// The shared source cache is consulted before any network access.
+				if !isVCS(sourceType) && src.restoreFromCache(sourceFilePath) {
+					return true, nil
+				}
+
+				return false, src.getURL(ctx, sourceType, sourceFilePath, sshPassword)
 			})
 			if err != nil {
 				return err
 			}
+
+			restored, _ = result.(bool)
 		}
 	case fileProtocol:
 	default:
@@ -147,9 +179,17 @@ func (src *Source) Get() error {
 
 	err := src.validateSource(sourceFilePath)
 	if err != nil {
+		if restored {
+			src.evictFromCache(sourceFilePath)
+		}
+
 		return err
 	}
 
+	if !restored {
+		src.storeInCache(sourceType, sourceFilePath)
+	}
+
 	err = src.symlinkSources(sourceFilePath)
 	if err != nil {
 		return err
@@ -181,6 +221,11 @@ func (src *Source) getReferenceType() plumbing.ReferenceName {
 
 // getProtocol returns the protocol of the source item URI.
 func (src *Source) getProtocol() string {
+	if vcs, path, found := strings.Cut(src.SourceItemURI, "+"); found && isVCS(vcs) &&
+		!strings.Contains(path, "://") {
+		return vcs
+	}
+
 	if !strings.Contains(src.SourceItemURI, "://") {
 		return fileProtocol
 	}
@@ -190,11 +235,42 @@ func (src *Source) getProtocol() string {
 		strings.HasPrefix(src.SourceItemURI, "https://"),
 		strings.HasPrefix(src.SourceItemURI, "ftp://"):
 		return strings.Split(src.SourceItemURI, "://")[0]
-	case strings.HasPrefix(src.SourceItemURI, constants.Git+"+https://"):
+	case strings.HasPrefix(src.SourceItemURI, constants.Git+"://"):
 		return constants.Git
-	default:
-		return ""
 	}
+
+	scheme, _, _ := strings.Cut(src.SourceItemURI, "://")
+
+	vcs, transport, found := strings.Cut(scheme, "+")
+	if found && slices.Contains(vcsTransports[vcs], transport) {
+		return vcs
+	}
+
+	return ""
+}
+
+// localVCSPath returns the repository path of a "<vcs>+" source given as a
+// plain path, such as "git+/srv/git/foo.git", rather than a URI. Relative
+// paths are resolved against StartDir, next to the PKGBUILD.
+func (src *Source) localVCSPath(vcs string) (string, bool) {
+	path, found := strings.CutPrefix(src.SourceItemURI, vcs+"+")
+	if !found || strings.Contains(path, "://") {
+		return "", false
+	}
+
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(src.StartDir, path)
+	}
+
+	return path, true
+}
+
+// isVCS reports whether protocol is a version control system whose
+// checkout is a directory refreshed on every build.
+func isVCS(protocol string) bool {
+	_, ok := vcsTransports[protocol]
+
+	return ok
 }
 
 // getURL is a function that retrieves a URL based on the provided protocol and
@@ -203,7 +279,7 @@ func (src *Source) getProtocol() string {
 // Parameters:
 // - protocol: a string representing the protocol for the URL.
 // - dloadFilePath: a string representing the file path for the downloaded file.
-func (src *Source) getURL(protocol, dloadFilePath, sshPassword string) error {
+func (src *Source) getURL(ctx context.Context, protocol, dloadFilePath, sshPassword string) error {
 	normalizedURI := strings.TrimPrefix(src.SourceItemURI, constants.Git+"+")
 
 	switch protocol {
@@ -215,7 +291,15 @@ func (src *Source) getURL(protocol, dloadFilePath, sshPassword string) error {
 			commitHash = src.RefValue
 		}
 
+		if path, ok := src.localVCSPath(constants.Git); ok {
+			normalizedURI = path
+		}
+
 		return git.Clone(dloadFilePath, normalizedURI, sshPassword, referenceName, commitHash)
+	case svnProtocol:
+		return src.fetchSubversion(ctx, dloadFilePath)
+	case hgProtocol:
+		return src.fetchMercurial(ctx, dloadFilePath)
 	default:
 		// Use enhanced download with resume capability and the configured
 		// retry budget, with context information
@@ -224,23 +308,30 @@ func (src *Source) getURL(protocol, dloadFilePath, sshPassword string) error {
 			return err
 		}
 
-		return download.WithResumeContext(
+		uri, err := download.WithMirrorsContext(
 			dloadFilePath,
-			normalizedURI,
+			append([]string{normalizedURI}, src.Mirrors...),
 			download.MaxRetries(),
 			src.PkgName,
 			src.SourceItemPath,
 			shell.MultiPrinter.Writer)
+		if err != nil {
+			return err
+		}
+
+		src.DownloadURL = uri
+
+		return nil
 	}
 }
 
 // parseURI parses the URI of the Source and updates the SourceItemPath,
-// SourceItemURI, RefKey, and RefValue fields accordingly.
+// SourceItemURI, Mirrors, RefKey, and RefValue fields accordingly.
 //
 // No parameters.
 // No return types.
 func (src *Source) parseURI() {
-	src.SourceItemPath = filepath.Base(src.SourceItemURI)
+	src.SourceItemPath = ""
 
 	if strings.Contains(src.SourceItemURI, "::") {
 		split := strings.SplitN(src.SourceItemURI, "::", 2)
@@ -248,6 +339,17 @@ func (src *Source) parseURI() {
 		src.SourceItemURI = split[1]
 	}
 
+	// Mirror alternatives follow the primary URL, separated by "|".
+	if strings.Contains(src.SourceItemURI, "|") {
+		split := strings.Split(src.SourceItemURI, "|")
+		src.SourceItemURI = split[0]
+		src.Mirrors = append(slices.Clone(split[1:]), src.Mirrors...)
+	}
+
+	if src.SourceItemPath == "" {
+		src.SourceItemPath = filepath.Base(src.SourceItemURI)
+	}
+
 	if strings.Contains(src.SourceItemURI, "#") {
 		split := strings.SplitN(src.SourceItemURI, "#", 2)
 		src.SourceItemURI = split[0]
@@ -263,6 +365,21 @@ func (src *Source) parseURI() {
 	}
 }
 
+// Locate returns the name under which the source array entry is fetched
+// into startdir and linked into srcdir, and the URL it is downloaded from,
+// empty for local files and VCS checkouts.
+func Locate(entry string) (name, url string) {
+	src := Source{SourceItemURI: entry}
+	src.parseURI()
+
+	switch src.getProtocol() {
+	case "http", "https", "ftp":
+		url = src.SourceItemURI
+	}
+
+	return src.SourceItemPath, url
+}
+
 // symlinkSources creates a symbolic link from symlinkSource to symLinkTarget.
 //
 // It returns an error if the symlink creation fails.
@@ -304,8 +421,8 @@ func (src *Source) validateSource(sourceFilePath string) error {
 	// If it's a directory, handle based on protocol (like makepkg)
 	if info.IsDir() {
 		protocol := src.getProtocol()
-		// For VCS protocols (git), directories are expected (cloned repos)
-		if protocol == constants.Git {
+		// For VCS protocols (git, svn, hg), directories are expected (checkouts)
+		if isVCS(protocol) {
 			logger.Info(i18n.T("logger.skip_integrity_check_for"),
 				"source", src.SourceItemURI)
 
@@ -401,3 +518,57 @@ func extractIfArchive(sourceFilePath, destDir string) error {
 
 	return err
 }
+
+// restoreFromCache copies the shared source cache entry matching the
+// declared checksum to sourceFilePath. It reports whether an entry was
+// restored; cache errors are not fatal and fall back to a download.
+func (src *Source) restoreFromCache(sourceFilePath string) bool {
+	if src.SkipHashCheck || !srccache.Cacheable(src.Hash) {
+		return false
+	}
+
+	ok, err := srccache.Restore(src.Hash, sourceFilePath)
+	if err != nil {
+		logger.Warn(i18n.T("logger.source.warn.cache_restore_failed"),
+			"source", src.SourceItemPath, "error", err)
+
+		return false
+	}
+
+	if ok {
+		logger.Info(i18n.T("logger.source.info.restored_from_cache"),
+			"source", src.SourceItemPath)
+	}
+
+	return ok
+}
+
+// storeInCache adds a freshly downloaded and verified source file to the
+// shared source cache. Local files and VCS checkouts are never cached.
+func (src *Source) storeInCache(sourceType, sourceFilePath string) {
+	switch sourceType {
+	case "http", "https", "ftp":
+	default:
+		return
+	}
+
+	if src.SkipHashCheck || !srccache.Cacheable(src.Hash) {
+		return
+	}
+
+	if err := srccache.Store(src.Hash, sourceFilePath); err != nil {
+		logger.Warn(i18n.T("logger.source.warn.cache_store_failed"),
+			"source", src.SourceItemPath, "error", err)
+	}
+}
+
+// evictFromCache drops a cache entry that failed verification, together
+// with the copy restored from it, so that the next attempt downloads the
+// source again.
+func (src *Source) evictFromCache(sourceFilePath string) {
+	logger.Warn(i18n.T("logger.source.warn.cache_entry_corrupted"),
+		"source", src.SourceItemPath)
+
+	_ = srccache.Remove(src.Hash)
+	_ = os.Remove(sourceFilePath)
+}
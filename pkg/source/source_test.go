@@ -4,16 +4,22 @@ package source
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	ggit "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/M0Rf30/yap/v2/pkg/constants"
 	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/srccache"
 )
 
 func TestSource_parseURI(t *testing.T) {
@@ -63,6 +69,18 @@ func TestSource_parseURI(t *testing.T) {
 			expectedRefKey:   "branch",
 			expectedRefValue: "develop",
 		},
+		{
+			name:          "Mirror alternatives",
+			sourceItemURI: "https://a.example.com/file.tar.gz|https://b.example.com/file.tar.gz",
+			expectedPath:  "file.tar.gz",
+			expectedURI:   "https://a.example.com/file.tar.gz",
+		},
+		{
+			name:          "Custom filename with mirror alternatives",
+			sourceItemURI: "custom.tar.gz::https://a.example.com/dl?id=1|https://b.example.com/dl?id=1",
+			expectedPath:  "custom.tar.gz",
+			expectedURI:   "https://a.example.com/dl?id=1",
+		},
 	}
 
 	for _, testCase := range tests {
@@ -108,6 +126,51 @@ func TestSource_getProtocol(t *testing.T) {
 			sourceURI:     "git+https://github.com/example/repo.git",
 			expectedProto: constants.Git,
 		},
+		{
+			name:          "Git over SSH",
+			sourceURI:     "git+ssh://git@example.com/example/repo.git",
+			expectedProto: constants.Git,
+		},
+		{
+			name:          "Git local repository",
+			sourceURI:     "git+file:///srv/git/repo.git",
+			expectedProto: constants.Git,
+		},
+		{
+			name:          "Git local repository by path",
+			sourceURI:     "git+/srv/git/repo.git",
+			expectedProto: constants.Git,
+		},
+		{
+			name:          "Mercurial local repository by relative path",
+			sourceURI:     "hg+../repo",
+			expectedProto: "hg",
+		},
+		{
+			name:          "Native git protocol",
+			sourceURI:     "git://example.com/repo.git",
+			expectedProto: constants.Git,
+		},
+		{
+			name:          "Git over unsupported transport",
+			sourceURI:     "git+ftp://example.com/repo.git",
+			expectedProto: "",
+		},
+		{
+			name:          "Subversion over HTTPS",
+			sourceURI:     "svn+https://svn.example.com/repo/trunk",
+			expectedProto: "svn",
+		},
+		{
+			name:          "Subversion over SSH",
+			sourceURI:     "svn+ssh://svn.example.com/repo/trunk",
+			expectedProto: "svn",
+		},
+		{
+			name:          "Mercurial over HTTPS",
+			sourceURI:     "hg+https://hg.example.com/repo",
+			expectedProto: "hg",
+		},
 		{
 			name:          "Local file (no protocol)",
 			sourceURI:     "localfile.tar.gz",
@@ -425,7 +488,7 @@ func TestSource_Get_FileProtocol(t *testing.T) {
 		StartDir:      tempDir,
 	}
 
-	err = src.Get()
+	err = src.Get(t.Context())
 	require.NoError(t, err)
 
 	// Verify symlink was created
@@ -434,6 +497,57 @@ func TestSource_Get_FileProtocol(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestSource_Get_GitLocalPath(t *testing.T) {
+	upstream := t.TempDir()
+
+	repo, err := ggit.PlainInit(upstream, false)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(upstream, "README"), []byte("hello"), 0o600))
+
+	w, err := repo.Worktree()
+	require.NoError(t, err)
+
+	_, err = w.Add("README")
+	require.NoError(t, err)
+
+	_, err = w.Commit("initial commit", &ggit.CommitOptions{
+		Author: &object.Signature{Name: "yap-test", Email: "yap@test.local", When: time.Now()},
+	})
+	require.NoError(t, err)
+
+	tempDir := t.TempDir()
+	srcDir := filepath.Join(tempDir, "src")
+	require.NoError(t, os.MkdirAll(srcDir, 0o755))
+
+	src := &Source{
+		Hash:          "SKIP",
+		PkgName:       "test-pkg",
+		SourceItemURI: "foo::git+" + upstream,
+		SrcDir:        srcDir,
+		StartDir:      tempDir,
+	}
+
+	require.NoError(t, src.Get(t.Context()))
+
+	data, err := os.ReadFile(filepath.Join(srcDir, "foo", "README"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(data))
+}
+
+func TestSource_localVCSPath(t *testing.T) {
+	t.Parallel()
+
+	src := &Source{SourceItemURI: "git+repos/foo.git", StartDir: "/build"}
+	path, ok := src.localVCSPath(constants.Git)
+	assert.True(t, ok)
+	assert.Equal(t, "/build/repos/foo.git", path)
+
+	src.SourceItemURI = "git+file:///srv/git/foo.git"
+	_, ok = src.localVCSPath(constants.Git)
+	assert.False(t, ok)
+}
+
 func TestSource_Get_UnsupportedProtocol(t *testing.T) {
 	// Initialize i18n for test
 	_ = i18n.Init("en")
@@ -447,7 +561,7 @@ func TestSource_Get_UnsupportedProtocol(t *testing.T) {
 		SrcDir:        filepath.Join(tempDir, "src"),
 	}
 
-	err := src.Get()
+	err := src.Get(t.Context())
 	require.Error(t, err)
 	// The error should contain the translated message
 	assert.Contains(t, err.Error(), i18n.T("errors.source.unsupported_source_type"))
@@ -474,10 +588,81 @@ func TestSource_Get_WithSKIPHash(t *testing.T) {
 		StartDir:      tempDir,
 	}
 
-	err = src.Get()
+	err = src.Get(t.Context())
 	assert.NoError(t, err)
 }
 
+func TestSource_parseURIMirrors(t *testing.T) {
+	t.Parallel()
+
+	src := &Source{
+		SourceItemURI: "https://a.example.com/file.tar.gz|https://b.example.com/file.tar.gz",
+		Mirrors:       []string{"https://c.example.com/file.tar.gz"},
+	}
+	src.parseURI()
+
+	assert.Equal(t, []string{
+		"https://b.example.com/file.tar.gz",
+		"https://c.example.com/file.tar.gz",
+	}, src.Mirrors)
+}
+
+func TestLocate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		entry string
+		name  string
+		url   string
+	}{
+		{"foo.patch", "foo.patch", ""},
+		{"foo-1.0.tar.gz::https://a.example.com/v1.0.tar.gz|https://b.example.com/v1.0.tar.gz",
+			"foo-1.0.tar.gz", "https://a.example.com/v1.0.tar.gz"},
+		{"git+https://example.com/foo.git#tag=v1.0", "foo.git", ""},
+	}
+
+	for _, tt := range tests {
+		name, url := Locate(tt.entry)
+		assert.Equal(t, tt.name, name, tt.entry)
+		assert.Equal(t, tt.url, url, tt.entry)
+	}
+}
+
+func TestSource_Get_MirrorFailover(t *testing.T) {
+	t.Setenv(srccache.DirEnv, srccache.Disabled)
+
+	content := []byte("mirrored tarball")
+	hash := sha256.Sum256(content)
+
+	stale := httptest.NewServer(http.NotFoundHandler())
+	defer stale.Close()
+
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(content)
+	}))
+	defer good.Close()
+
+	tempDir := t.TempDir()
+	srcDir := filepath.Join(tempDir, "src")
+	require.NoError(t, os.MkdirAll(srcDir, 0o755))
+
+	src := &Source{
+		Hash:          hex.EncodeToString(hash[:]),
+		PkgName:       "test-pkg",
+		SourceItemURI: "file.txt::" + stale.URL + "/file.txt|" + good.URL + "/file.txt",
+		SrcDir:        srcDir,
+		StartDir:      tempDir,
+		NoExtract:     []string{"file.txt"},
+	}
+
+	require.NoError(t, src.Get(t.Context()))
+	assert.Equal(t, good.URL+"/file.txt", src.DownloadURL)
+
+	data, err := os.ReadFile(filepath.Join(tempDir, "file.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+}
+
 func TestFilename(t *testing.T) {
 	t.Parallel()
 
@@ -573,7 +758,7 @@ func TestSource_Integration(t *testing.T) {
 
 	// Process all sources
 	for _, src := range sources {
-		err := src.Get()
+		err := src.Get(t.Context())
 		require.NoError(t, err)
 
 		// Verify symlink exists
@@ -644,3 +829,21 @@ func TestShouldSkipExtract(t *testing.T) {
 		})
 	}
 }
+
+func TestSource_VCSUnsupportedFragment(t *testing.T) {
+	t.Parallel()
+
+	_ = i18n.Init("en")
+
+	dir := t.TempDir()
+
+	svnSrc := &Source{SourceItemURI: "svn+https://svn.example.com/repo/trunk", RefKey: branchKey, RefValue: "main"}
+	err := svnSrc.fetchSubversion(t.Context(), filepath.Join(dir, "trunk"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), i18n.T("errors.source.unsupported_vcs_fragment"))
+
+	hgSrc := &Source{SourceItemURI: "hg+https://hg.example.com/repo", RefKey: commitKey, RefValue: "abc"}
+	err = hgSrc.fetchMercurial(t.Context(), filepath.Join(dir, "repo"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), i18n.T("errors.source.unsupported_vcs_fragment"))
+}
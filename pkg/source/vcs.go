@@ -0,0 +1,94 @@
+package source
+
+import (
+	"context"
+	"strings"
+
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/files"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/logger"
+	"github.com/M0Rf30/yap/v2/pkg/shell"
+)
+
+// fetchSubversion checks out an svn+ source into dloadFilePath, or updates
+// the checkout left there by a previous build. A #revision= fragment pins
+// the revision, HEAD is used otherwise. Like makepkg, a failed update of a
+// cached checkout is only a warning.
+func (src *Source) fetchSubversion(ctx context.Context, dloadFilePath string) error {
+	revision := "HEAD"
+
+	switch src.RefKey {
+	case "":
+	case revisionKey:
+		revision = src.RefValue
+	default:
+		return src.unsupportedFragment()
+	}
+
+	// svn+ssh:// is a native svn scheme, any other svn+ prefix is ours.
+	uri := src.SourceItemURI
+	if !strings.HasPrefix(uri, svnProtocol+"+ssh://") {
+		uri = strings.TrimPrefix(uri, svnProtocol+"+")
+	}
+
+	if path, ok := src.localVCSPath(svnProtocol); ok {
+		uri = "file://" + path
+	}
+
+	if !files.Exists(dloadFilePath) {
+		return shell.Exec(ctx, false, "", svnProtocol, "checkout", "--revision", revision,
+			uri, dloadFilePath)
+	}
+
+	if err := shell.Exec(ctx, false, dloadFilePath, svnProtocol, "update",
+		"--revision", revision); err != nil {
+		logger.Warn(i18n.T("logger.source.warn.vcs_update_failed"),
+			"source", uri, "error", err)
+	}
+
+	return nil
+}
+
+// fetchMercurial clones an hg+ source into dloadFilePath, or pulls the new
+// changesets into the clone left there by a previous build, then updates
+// the working directory to the #branch=, #tag= or #revision= fragment
+// ("default" otherwise).
+func (src *Source) fetchMercurial(ctx context.Context, dloadFilePath string) error {
+	ref := "default"
+
+	switch src.RefKey {
+	case "":
+	case branchKey, tagKey, revisionKey:
+		ref = src.RefValue
+	default:
+		return src.unsupportedFragment()
+	}
+
+	uri := strings.TrimPrefix(src.SourceItemURI, hgProtocol+"+")
+	if path, ok := src.localVCSPath(hgProtocol); ok {
+		uri = path
+	}
+
+	if files.Exists(dloadFilePath) {
+		if err := shell.Exec(ctx, false, dloadFilePath, hgProtocol, "pull"); err != nil {
+			logger.Warn(i18n.T("logger.source.warn.vcs_update_failed"),
+				"source", uri, "error", err)
+		}
+	} else if err := shell.Exec(ctx, false, "", hgProtocol, "clone", "--noupdate",
+		uri, dloadFilePath); err != nil {
+		return err
+	}
+
+	return shell.Exec(ctx, false, dloadFilePath, hgProtocol, "update", "--clean", "--rev", ref)
+}
+
+// unsupportedFragment reports a #key=value fragment the source's VCS does
+// not understand.
+func (src *Source) unsupportedFragment() error {
+	return errors.New(errors.ErrTypeValidation,
+		i18n.T("errors.source.unsupported_vcs_fragment")).
+		WithOperation("Get").
+		WithContext("source_uri", src.SourceItemURI).
+		WithContext("fragment", src.RefKey)
+}
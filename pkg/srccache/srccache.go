@@ -0,0 +1,323 @@
+// Package srccache implements the persistent, content-addressed source cache
+// shared by every project (makepkg's SRCDEST).
+//
+// Entries are keyed by the checksum declared in the PKGBUILD, so two
+// projects downloading the same tarball share a single copy and a cached
+// file is only ever reused for the exact content it was validated against:
+//
+//	<dir>/sha256/<hex>
+//	<dir>/sha512/<hex>
+//
+// The directory is a bucket chosen by checksum length, not by the PKGBUILD
+// array the checksum came from: source validation verifies every 64 and
+// 128 hex character checksum as SHA-256 and SHA-512, so a b2sums entry
+// never passes verification and is never stored.
+//
+// Files are stored only after they pass integrity verification and are
+// written through a temporary file plus rename, so concurrent builds never
+// observe a partial entry. The modification time of an entry records its
+// last use and drives Prune.
+package srccache
+
+import (
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+)
+
+// DirEnv overrides the cache location. It takes precedence over yap.json
+// so that container runtimes can point the inner yap at the mounted cache.
+const DirEnv = "YAP_SOURCE_CACHE"
+
+// Disabled is the DirEnv / yap.json value that turns the cache off.
+const Disabled = "off"
+
+// ContainerDir is where container runtimes mount the host cache.
+const ContainerDir = "/var/cache/yap/sources"
+
+var (
+	dirMu         sync.RWMutex
+	configuredDir string
+)
+
+// Entry describes a cached source file.
+type Entry struct {
+	// Algorithm is the checksum length bucket (sha256 or sha512).
+	Algorithm string
+	// Checksum is the hex digest the entry is keyed by.
+	Checksum string
+	// Path is the absolute path of the cached file.
+	Path string
+	// Size is the file size in bytes.
+	Size int64
+	// LastUsed is the last time the entry was stored or restored.
+	LastUsed time.Time
+}
+
+// SetDir sets the cache location declared in yap.json. An empty dir
+// restores the default; Disabled turns the cache off. DirEnv, when set,
+// still wins.
+func SetDir(dir string) {
+	dirMu.Lock()
+	defer dirMu.Unlock()
+
+	configuredDir = dir
+}
+
+// Dir returns the cache location, or "" when the cache is disabled.
+// Resolution order is DirEnv, then SetDir, then $XDG_CACHE_HOME/yap/sources
+// (~/.cache/yap/sources).
+func Dir() string {
+	dir := os.Getenv(DirEnv)
+
+	if dir == "" {
+		dirMu.RLock()
+		dir = configuredDir
+		dirMu.RUnlock()
+	}
+
+	if dir == Disabled {
+		return ""
+	}
+
+	if dir != "" {
+		return dir
+	}
+
+	cacheRoot, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(cacheRoot, "yap", "sources")
+}
+
+// algorithm maps a hex checksum to the bucket its length implies, which
+// is the algorithm source validation verifies it with.
+func algorithm(checksum string) (string, bool) {
+	if _, err := hex.DecodeString(checksum); err != nil {
+		return "", false
+	}
+
+	switch len(checksum) {
+	case 64:
+		return "sha256", true
+	case 128:
+		return "sha512", true
+	default:
+		return "", false
+	}
+}
+
+// entryPath returns the cache path for checksum, or false when the cache
+// is disabled or the checksum cannot key an entry (SKIP, cksums, ...).
+func entryPath(checksum string) (string, bool) {
+	dir := Dir()
+	if dir == "" {
+		return "", false
+	}
+
+	algo, ok := algorithm(checksum)
+	if !ok {
+		return "", false
+	}
+
+	return filepath.Join(dir, algo, checksum), true
+}
+
+// Cacheable reports whether a source with checksum can use the cache.
+func Cacheable(checksum string) bool {
+	_, ok := entryPath(checksum)
+
+	return ok
+}
+
+// Restore copies the entry for checksum to dest. It returns false, without
+// error, when there is no such entry.
+func Restore(checksum, dest string) (bool, error) {
+	path, ok := entryPath(checksum)
+	if !ok {
+		return false, nil
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return false, nil //nolint:nilerr // a missing entry is a cache miss
+	}
+
+	if err := atomicCopy(path, dest); err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+
+	return true, nil
+}
+
+// Store adds src to the cache under checksum. The caller must have verified
+// that src matches checksum. Storing an existing entry only refreshes its
+// last-use time.
+func Store(checksum, src string) error {
+	path, ok := entryPath(checksum)
+	if !ok {
+		return nil
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		now := time.Now()
+
+		return os.Chtimes(path, now, now)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return errors.Wrap(err, errors.ErrTypeFileSystem,
+			i18n.T("errors.srccache.failed_to_store")).
+			WithOperation("Store").
+			WithContext("path", path)
+	}
+
+	if err := atomicCopy(src, path); err != nil {
+		return errors.Wrap(err, errors.ErrTypeFileSystem,
+			i18n.T("errors.srccache.failed_to_store")).
+			WithOperation("Store").
+			WithContext("path", path)
+	}
+
+	return nil
+}
+
+// Remove deletes the entry for checksum, if any.
+func Remove(checksum string) error {
+	path, ok := entryPath(checksum)
+	if !ok {
+		return nil
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// List returns every cache entry, least recently used first.
+func List() ([]Entry, error) {
+	dir := Dir()
+	if dir == "" {
+		return nil, nil
+	}
+
+	var entries []Entry
+
+	for _, algo := range []string{"sha256", "sha512"} {
+		items, err := os.ReadDir(filepath.Join(dir, algo))
+		if os.IsNotExist(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			if _, ok := algorithm(item.Name()); !ok || item.IsDir() {
+				continue
+			}
+
+			info, err := item.Info()
+			if err != nil {
+				continue
+			}
+
+			entries = append(entries, Entry{
+				Algorithm: algo,
+				Checksum:  item.Name(),
+				Path:      filepath.Join(dir, algo, item.Name()),
+				Size:      info.Size(),
+				LastUsed:  info.ModTime(),
+			})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastUsed.Before(entries[j].LastUsed)
+	})
+
+	return entries, nil
+}
+
+// Prune removes the entries not used for longer than olderThan and returns
+// them. A zero olderThan removes every entry.
+func Prune(olderThan time.Duration) ([]Entry, error) {
+	entries, err := List()
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-olderThan)
+
+	var removed []Entry
+
+	for _, entry := range entries {
+		if entry.LastUsed.After(cutoff) {
+			continue
+		}
+
+		if err := os.Remove(entry.Path); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+
+		removed = append(removed, entry)
+	}
+
+	return removed, nil
+}
+
+// atomicCopy copies src to dst through a temporary file in dst's directory
+// renamed into place, so readers never see a partially written dst.
+func atomicCopy(src, dst string) error {
+	in, err := os.Open(src) //nolint:gosec // cache and source paths are built by yap
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = in.Close() }()
+
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".yap-srccache-*")
+	if err != nil {
+		return err
+	}
+
+	tmpName := tmp.Name()
+
+	defer func() { _ = os.Remove(tmpName) }()
+
+	if _, err := io.Copy(tmp, in); err != nil {
+		_ = tmp.Close()
+
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmpName, 0o644); err != nil { //nolint:gosec // source tarballs are not secret
+		return err
+	}
+
+	return os.Rename(tmpName, dst)
+}
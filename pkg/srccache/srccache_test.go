@@ -0,0 +1,137 @@
+package srccache_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/M0Rf30/yap/v2/pkg/srccache"
+)
+
+// writeSource writes data to a temp file and returns its path and sha256.
+func writeSource(t *testing.T, data string) (path, checksum string) {
+	t.Helper()
+
+	path = filepath.Join(t.TempDir(), "source.tar.gz")
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	sum := sha256.Sum256([]byte(data))
+
+	return path, hex.EncodeToString(sum[:])
+}
+
+func TestDirResolution(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", "/xdg")
+	t.Setenv(srccache.DirEnv, "")
+
+	srccache.SetDir("")
+	assert.Equal(t, "/xdg/yap/sources", srccache.Dir())
+
+	srccache.SetDir("/from/yap.json")
+	assert.Equal(t, "/from/yap.json", srccache.Dir())
+
+	t.Setenv(srccache.DirEnv, "/from/env")
+	assert.Equal(t, "/from/env", srccache.Dir(), "the environment wins over yap.json")
+
+	t.Setenv(srccache.DirEnv, srccache.Disabled)
+	assert.Empty(t, srccache.Dir())
+
+	srccache.SetDir("")
+}
+
+func TestStoreRestore(t *testing.T) {
+	t.Setenv(srccache.DirEnv, t.TempDir())
+
+	src, checksum := writeSource(t, "tarball contents")
+	assert.True(t, srccache.Cacheable(checksum))
+
+	dest := filepath.Join(t.TempDir(), "restored.tar.gz")
+
+	ok, err := srccache.Restore(checksum, dest)
+	require.NoError(t, err)
+	assert.False(t, ok, "empty cache must miss")
+
+	require.NoError(t, srccache.Store(checksum, src))
+
+	ok, err = srccache.Restore(checksum, dest)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	data, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, "tarball contents", string(data))
+
+	require.NoError(t, srccache.Remove(checksum))
+
+	ok, err = srccache.Restore(checksum, filepath.Join(t.TempDir(), "again"))
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestCacheableChecksums(t *testing.T) {
+	t.Setenv(srccache.DirEnv, t.TempDir())
+
+	tests := []struct {
+		checksum string
+		want     bool
+	}{
+		{"SKIP", false},
+		{"1234567890 2048576", false},
+		{strings.Repeat("z", 64), false},
+		{hex.EncodeToString(make([]byte, 32)), true},
+		{hex.EncodeToString(make([]byte, 64)), true},
+		{hex.EncodeToString(make([]byte, 28)), false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, srccache.Cacheable(tt.checksum), tt.checksum)
+	}
+
+	t.Setenv(srccache.DirEnv, srccache.Disabled)
+	assert.False(t, srccache.Cacheable(hex.EncodeToString(make([]byte, 32))))
+}
+
+func TestListAndPrune(t *testing.T) {
+	t.Setenv(srccache.DirEnv, t.TempDir())
+
+	oldSrc, oldSum := writeSource(t, "old")
+	newSrc, newSum := writeSource(t, "new")
+
+	require.NoError(t, srccache.Store(oldSum, oldSrc))
+	require.NoError(t, srccache.Store(newSum, newSrc))
+
+	entries, err := srccache.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	stale := time.Now().Add(-48 * time.Hour)
+
+	for _, entry := range entries {
+		if entry.Checksum == oldSum {
+			require.NoError(t, os.Chtimes(entry.Path, stale, stale))
+		}
+	}
+
+	removed, err := srccache.Prune(24 * time.Hour)
+	require.NoError(t, err)
+	require.Len(t, removed, 1)
+	assert.Equal(t, oldSum, removed[0].Checksum)
+
+	entries, err = srccache.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, newSum, entries[0].Checksum)
+	assert.Equal(t, "sha256", entries[0].Algorithm)
+	assert.Equal(t, int64(3), entries[0].Size)
+
+	removed, err = srccache.Prune(0)
+	require.NoError(t, err)
+	assert.Len(t, removed, 1)
+}
@@ -0,0 +1,156 @@
+// Package triggers handles the triggers=() PKGBUILD entries, which run a
+// package's handler when other packages install files under a path it
+// watches (icon themes, man pages, fonts) or activate a named trigger.
+// The builders render them as dpkg triggers, rpm file and package
+// triggers, alpm hooks and apk triggers.
+//
+// The in-process installers record what a transaction installs in a
+// Transaction and fire the interested triggers once at its end, rather
+// than once per package.
+package triggers
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+)
+
+// Trigger is a triggers=() entry of the form "target:handler". A target
+// starting with "/" is a path trigger, activated by the files installed
+// at or below it; any other target names a trigger, which on rpm and
+// pacman is the name of the package whose installation activates it.
+type Trigger struct {
+	Target  string
+	Handler string
+}
+
+// Parse parses a triggers=() entry. Path targets must be clean absolute
+// paths; the handler is the name of a PKGBUILD function.
+func Parse(entry string) (Trigger, error) {
+	idx := strings.LastIndex(entry, ":")
+	if idx < 0 {
+		return Trigger{}, invalidEntry(entry)
+	}
+
+	trigger := Trigger{Target: entry[:idx], Handler: entry[idx+1:]}
+
+	if trigger.Target == "" || trigger.Handler == "" ||
+		strings.ContainsAny(trigger.Target, " \t\n") || strings.ContainsAny(trigger.Handler, " \t\n/:=()") {
+		return Trigger{}, invalidEntry(entry)
+	}
+
+	if trigger.IsPath() && (trigger.Target == "/" || path.Clean(trigger.Target) != trigger.Target) {
+		return Trigger{}, invalidEntry(entry)
+	}
+
+	if !trigger.IsPath() && strings.Contains(trigger.Target, "/") {
+		return Trigger{}, invalidEntry(entry)
+	}
+
+	return trigger, nil
+}
+
+// ParseAll parses the triggers=() entries of a PKGBUILD.
+func ParseAll(entries []string) ([]Trigger, error) {
+	triggers := make([]Trigger, 0, len(entries))
+
+	for _, entry := range entries {
+		trigger, err := Parse(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		triggers = append(triggers, trigger)
+	}
+
+	return triggers, nil
+}
+
+func invalidEntry(entry string) error {
+	return errors.New(errors.ErrTypeValidation, i18n.T("errors.triggers.invalid_entry")).
+		WithOperation("Parse").
+		WithContext("entry", entry)
+}
+
+// IsPath reports whether the trigger watches a path rather than a name.
+func (trigger Trigger) IsPath() bool {
+	return strings.HasPrefix(trigger.Target, "/")
+}
+
+// Transaction records the files a package transaction installs and the
+// named triggers it activates, so that the triggers can be matched once
+// the transaction is over. The zero value is ready to use.
+type Transaction struct {
+	paths     []string
+	activated map[string]bool
+}
+
+// Install records installed paths. They are taken relative to the install
+// root, with or without a leading "/" or "./".
+func (tx *Transaction) Install(paths ...string) {
+	for _, p := range paths {
+		p = path.Clean("/" + strings.TrimPrefix(p, "./"))
+		if p != "/" {
+			tx.paths = append(tx.paths, p)
+		}
+	}
+}
+
+// Activate records explicitly activated named triggers.
+func (tx *Transaction) Activate(names ...string) {
+	if tx.activated == nil {
+		tx.activated = make(map[string]bool)
+	}
+
+	for _, name := range names {
+		tx.activated[name] = true
+	}
+}
+
+// Paths returns the installed paths at or below target, in installation
+// order.
+func (tx *Transaction) Paths(target string) []string {
+	var matched []string
+
+	for _, p := range tx.paths {
+		if p == target || strings.HasPrefix(p, target+"/") {
+			matched = append(matched, p)
+		}
+	}
+
+	return matched
+}
+
+// Activated reports whether the transaction activates target: a path
+// target when a file was installed at or below it, a named one when it
+// was activated explicitly.
+func (tx *Transaction) Activated(target string) bool {
+	if strings.HasPrefix(target, "/") {
+		return len(tx.Paths(target)) > 0
+	}
+
+	return tx.activated[target]
+}
+
+// Dirs returns, sorted and without duplicates, the directories matching
+// the shell pattern among those holding installed files and their
+// parents, the way apk matches the globs of its triggers against the
+// directories a transaction changed.
+func (tx *Transaction) Dirs(pattern string) []string {
+	var dirs []string
+
+	for _, p := range tx.paths {
+		for dir := path.Dir(p); dir != "/"; dir = path.Dir(dir) {
+			if ok, _ := path.Match(pattern, dir); ok {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+
+	slices.Sort(dirs)
+
+	return slices.Compact(dirs)
+}
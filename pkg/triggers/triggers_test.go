@@ -0,0 +1,72 @@
+package triggers_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/M0Rf30/yap/v2/pkg/triggers"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		entry string
+		want  triggers.Trigger
+		path  bool
+	}{
+		{"/usr/share/icons:_update_icons", triggers.Trigger{Target: "/usr/share/icons", Handler: "_update_icons"}, true},
+		{"ldconfig:_ldconfig", triggers.Trigger{Target: "ldconfig", Handler: "_ldconfig"}, false},
+	}
+
+	for _, tt := range tests {
+		got, err := triggers.Parse(tt.entry)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.entry, err)
+		}
+
+		if got != tt.want || got.IsPath() != tt.path {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.entry, got, tt.want)
+		}
+	}
+
+	for _, entry := range []string{
+		"/usr/share/icons",
+		"/usr/share/icons:",
+		":_update_icons",
+		"/:_update_icons",
+		"/usr/share/icons/:_update_icons",
+		"share/icons:_update_icons",
+		"/usr/share/icons:_update icons",
+	} {
+		if _, err := triggers.Parse(entry); err == nil {
+			t.Errorf("Parse(%q) should fail", entry)
+		}
+	}
+}
+
+func TestTransaction(t *testing.T) {
+	var tx triggers.Transaction
+
+	tx.Install("./usr/share/icons/hicolor/48x48/apps/foo.png", "usr/bin/foo", "/usr/share/iconsets/bar")
+	tx.Activate("ldconfig")
+
+	if got, want := tx.Paths("/usr/share/icons"), []string{"/usr/share/icons/hicolor/48x48/apps/foo.png"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Paths() = %v, want %v", got, want)
+	}
+
+	for target, want := range map[string]bool{
+		"/usr/share/icons": true,
+		"/usr/bin/foo":     true,
+		"/usr/share/man":   false,
+		"ldconfig":         true,
+		"man-db":           false,
+	} {
+		if got := tx.Activated(target); got != want {
+			t.Errorf("Activated(%q) = %v, want %v", target, got, want)
+		}
+	}
+
+	want := []string{"/usr/share/icons/hicolor"}
+	if got := tx.Dirs("/usr/share/icons/*"); !reflect.DeepEqual(got, want) {
+		t.Errorf("Dirs() = %v, want %v", got, want)
+	}
+}
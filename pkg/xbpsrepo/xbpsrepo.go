@@ -0,0 +1,323 @@
+// Package xbpsrepo writes the <arch>-repodata index of a directory of xbps
+// packages, as xbps-rindex --add does.
+package xbpsrepo
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/M0Rf30/yap/v2/pkg/errors"
+	"github.com/M0Rf30/yap/v2/pkg/i18n"
+	"github.com/M0Rf30/yap/v2/pkg/plist"
+)
+
+const (
+	// indexFileName holds the properties of every package, by name.
+	indexFileName = "index.plist"
+	// indexMetaFileName holds the public key of a signed repository.
+	indexMetaFileName = "index-meta.plist"
+	// noarch is the architecture of the packages indexed for every
+	// architecture.
+	noarch = "noarch"
+	// unsignedMeta is the index-meta.plist of an unsigned repository.
+	unsignedMeta = "DEADBEEF"
+)
+
+// droppedProps are the package properties xbps-rindex leaves out of the
+// index: the name is the key and the version is part of pkgver.
+var droppedProps = []string{"pkgname", "version", "packaged-with"}
+
+// Options configures WriteIndex.
+type Options struct {
+	// Arch restricts the index to one architecture; every architecture of
+	// the packages is indexed when empty.
+	Arch string
+	// PublicKey is the key of the .sig2 signatures of the packages. The
+	// repository is unsigned when nil.
+	PublicKey *rsa.PublicKey
+	// SignedBy names the owner of PublicKey, shown by xbps when importing it.
+	SignedBy string
+}
+
+// WriteIndex indexes the .xbps packages in dir and writes one
+// <arch>-repodata file per architecture, returning their paths.
+// Packages built for noarch are listed in every index; when a name appears
+// twice, the most recent build wins.
+func WriteIndex(dir string, opts Options) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.xbps"))
+	if err != nil {
+		return nil, err
+	}
+
+	byArch := make(map[string]map[string]plist.Dict)
+
+	for _, path := range matches {
+		props, err := ReadProps(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := addFileProps(props, path); err != nil {
+			return nil, err
+		}
+
+		arch, _ := props["architecture"].(string)
+		if byArch[arch] == nil {
+			byArch[arch] = make(map[string]plist.Dict)
+		}
+
+		add(byArch[arch], props)
+	}
+
+	archs := make([]string, 0, len(byArch))
+
+	for arch := range byArch {
+		if arch != noarch && (opts.Arch == "" || arch == opts.Arch) {
+			archs = append(archs, arch)
+		}
+	}
+
+	if opts.Arch != "" && !slices.Contains(archs, opts.Arch) {
+		archs = append(archs, opts.Arch)
+	}
+
+	if len(archs) == 0 {
+		if len(byArch) == 0 {
+			return nil, errors.New(errors.ErrTypeValidation, i18n.T("errors.xbpsrepo.no_packages")).
+				WithOperation("WriteIndex").
+				WithContext("dir", dir)
+		}
+
+		return nil, errors.New(errors.ErrTypeValidation, i18n.T("errors.xbpsrepo.no_architecture")).
+			WithOperation("WriteIndex").
+			WithContext("dir", dir)
+	}
+
+	slices.Sort(archs)
+
+	meta, err := indexMeta(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, len(archs))
+
+	for _, arch := range archs {
+		pkgs := make(map[string]plist.Dict)
+
+		for _, props := range byArch[noarch] {
+			add(pkgs, props)
+		}
+
+		for _, props := range byArch[arch] {
+			add(pkgs, props)
+		}
+
+		index := plist.Dict{}
+		for name, props := range pkgs {
+			index[name] = indexEntry(props)
+		}
+
+		data, err := plist.Marshal(index)
+		if err != nil {
+			return nil, err
+		}
+
+		path := filepath.Join(dir, arch+"-repodata")
+		if err := writeRepodata(path, data, meta); err != nil {
+			return nil, err
+		}
+
+		paths = append(paths, path)
+	}
+
+	return paths, nil
+}
+
+// ReadProps returns the props.plist dictionary of an xbps package.
+func ReadProps(path string) (plist.Dict, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	zr, err := zstd.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+
+	defer zr.Close()
+
+	tr := tar.NewReader(zr)
+
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			return nil, errors.Wrap(err, errors.ErrTypeValidation, i18n.T("errors.xbpsrepo.invalid_package")).
+				WithOperation("ReadProps").
+				WithContext("path", path)
+		}
+
+		if strings.TrimPrefix(hdr.Name, "./") != "props.plist" {
+			continue
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+
+		props, err := plist.Unmarshal(data)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := props["pkgname"].(string); !ok {
+			return nil, errors.New(errors.ErrTypeValidation, i18n.T("errors.xbpsrepo.invalid_package")).
+				WithOperation("ReadProps").
+				WithContext("path", path)
+		}
+
+		return props, nil
+	}
+}
+
+// addFileProps records the checksum and size of the package file.
+func addFileProps(props plist.Dict, path string) error {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	hash := sha256.New()
+
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return err
+	}
+
+	props["filename-sha256"] = hex.EncodeToString(hash.Sum(nil))
+	props["filename-size"] = size
+
+	return nil
+}
+
+// add adds a package to pkgs, unless it holds a more recent build of it.
+func add(pkgs map[string]plist.Dict, props plist.Dict) {
+	name, _ := props["pkgname"].(string)
+
+	if current, ok := pkgs[name]; ok && buildDate(current) > buildDate(props) {
+		return
+	}
+
+	pkgs[name] = props
+}
+
+// indexEntry returns the index entry of a package.
+func indexEntry(props plist.Dict) plist.Dict {
+	entry := plist.Dict{}
+
+	for key, value := range props {
+		if !slices.Contains(droppedProps, key) {
+			entry[key] = value
+		}
+	}
+
+	return entry
+}
+
+// buildDate returns the build-date of a package, which sorts in time
+// order as it is written in UTC.
+func buildDate(props plist.Dict) string {
+	date, _ := props["build-date"].(string)
+
+	return date
+}
+
+// indexMeta returns the index-meta.plist contents.
+func indexMeta(opts Options) ([]byte, error) {
+	if opts.PublicKey == nil {
+		return []byte(unsignedMeta), nil
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(opts.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return plist.Marshal(plist.Dict{
+		"public-key":      pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}),
+		"public-key-size": opts.PublicKey.N.BitLen(),
+		"signature-by":    opts.SignedBy,
+		"signature-type":  "rsa",
+	})
+}
+
+// writeRepodata writes a zstd-compressed tar archive holding the index and
+// its metadata.
+func writeRepodata(path string, index, meta []byte) error {
+	var buf bytes.Buffer
+
+	zw, err := zstd.NewWriter(&buf)
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(zw)
+	modTime := time.Now()
+
+	for _, file := range []struct {
+		name string
+		data []byte
+	}{{indexFileName, index}, {indexMetaFileName, meta}} {
+		hdr := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     file.name,
+			Mode:     0o644,
+			Size:     int64(len(file.data)),
+			ModTime:  modTime,
+			Uname:    "root",
+			Gname:    "root",
+		}
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+
+		if _, err := tw.Write(file.data); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	//nolint:gosec // repository indexes are public
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
@@ -0,0 +1,215 @@
+package xbpsrepo_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/M0Rf30/yap/v2/pkg/plist"
+	"github.com/M0Rf30/yap/v2/pkg/xbpsrepo"
+)
+
+func TestWriteIndex(t *testing.T) {
+	dir := t.TempDir()
+
+	writePackage(t, dir, "foo-1.0_1.x86_64.xbps", plist.Dict{
+		"pkgname": "foo", "version": "1.0_1", "pkgver": "foo-1.0_1",
+		"architecture": "x86_64", "build-date": "2024-01-01 10:00 UTC", "packaged-with": "yap-2.0.0",
+	})
+	writePackage(t, dir, "foo-1.1_1.x86_64.xbps", plist.Dict{
+		"pkgname": "foo", "version": "1.1_1", "pkgver": "foo-1.1_1",
+		"architecture": "x86_64", "build-date": "2024-02-01 10:00 UTC",
+	})
+	writePackage(t, dir, "bar-1.0_1.aarch64.xbps", plist.Dict{
+		"pkgname": "bar", "pkgver": "bar-1.0_1", "architecture": "aarch64",
+	})
+	writePackage(t, dir, "data-1.0_1.noarch.xbps", plist.Dict{
+		"pkgname": "data", "pkgver": "data-1.0_1", "architecture": "noarch",
+	})
+
+	paths, err := xbpsrepo.WriteIndex(dir, xbpsrepo.Options{})
+	if err != nil {
+		t.Fatalf("WriteIndex() error = %v", err)
+	}
+
+	want := []string{filepath.Join(dir, "aarch64-repodata"), filepath.Join(dir, "x86_64-repodata")}
+	if !reflect.DeepEqual(paths, want) {
+		t.Fatalf("WriteIndex() = %v, want %v", paths, want)
+	}
+
+	index, meta := readRepodata(t, paths[1])
+
+	if string(meta) != "DEADBEEF" {
+		t.Errorf("index-meta.plist = %q", meta)
+	}
+
+	if len(index) != 2 {
+		t.Fatalf("x86_64 index = %v", index)
+	}
+
+	foo := index["foo"].(plist.Dict)
+	if foo["pkgver"] != "foo-1.1_1" {
+		t.Errorf("foo pkgver = %v, want the most recent build", foo["pkgver"])
+	}
+
+	for _, key := range []string{"pkgname", "version", "packaged-with"} {
+		if _, ok := foo[key]; ok {
+			t.Errorf("index entry has %s", key)
+		}
+	}
+
+	if size, _ := foo["filename-size"].(int64); size == 0 || len(foo["filename-sha256"].(string)) != 64 {
+		t.Errorf("foo file properties = %v, %v", foo["filename-size"], foo["filename-sha256"])
+	}
+
+	if _, ok := index["data"]; !ok {
+		t.Error("noarch package missing from the x86_64 index")
+	}
+}
+
+func TestWriteIndexSigned(t *testing.T) {
+	dir := t.TempDir()
+
+	writePackage(t, dir, "foo-1.0_1.noarch.xbps", plist.Dict{
+		"pkgname": "foo", "pkgver": "foo-1.0_1", "architecture": "noarch",
+	})
+
+	if _, err := xbpsrepo.WriteIndex(dir, xbpsrepo.Options{}); err == nil {
+		t.Error("WriteIndex() indexed noarch packages without an architecture")
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths, err := xbpsrepo.WriteIndex(dir, xbpsrepo.Options{
+		Arch: "x86_64", PublicKey: &key.PublicKey, SignedBy: "Jane Doe <jane@example.com>",
+	})
+	if err != nil {
+		t.Fatalf("WriteIndex() error = %v", err)
+	}
+
+	_, metaData := readRepodata(t, paths[0])
+
+	meta, err := plist.Unmarshal(metaData)
+	if err != nil {
+		t.Fatalf("index-meta.plist: %v", err)
+	}
+
+	if meta["signature-type"] != "rsa" || meta["public-key-size"] != int64(2048) ||
+		meta["signature-by"] != "Jane Doe <jane@example.com>" ||
+		!bytes.HasPrefix(meta["public-key"].([]byte), []byte("-----BEGIN PUBLIC KEY-----")) {
+		t.Errorf("index-meta.plist = %v", meta)
+	}
+}
+
+func TestWriteIndexEmpty(t *testing.T) {
+	if _, err := xbpsrepo.WriteIndex(t.TempDir(), xbpsrepo.Options{}); err == nil {
+		t.Error("WriteIndex() succeeded without packages")
+	}
+}
+
+// writePackage writes an xbps package holding only props.plist.
+func writePackage(t *testing.T, dir, name string, props plist.Dict) {
+	t.Helper()
+
+	data, err := plist.Marshal(props)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeArchive(t, filepath.Join(dir, name), map[string][]byte{"./props.plist": data})
+}
+
+// writeArchive writes a zstd-compressed tar archive.
+func writeArchive(t *testing.T, path string, files map[string][]byte) {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	zw, err := zstd.NewWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tw := tar.NewWriter(zw)
+
+	for name, data := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data))}); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readRepodata returns the index and the raw index-meta.plist of a
+// repodata file.
+func readRepodata(t *testing.T, path string) (plist.Dict, []byte) {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	zr, err := zstd.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer zr.Close()
+
+	contents := map[string][]byte{}
+	tr := tar.NewReader(zr)
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if contents[hdr.Name], err = io.ReadAll(tr); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	index, err := plist.Unmarshal(contents["index.plist"])
+	if err != nil {
+		t.Fatalf("index.plist: %v", err)
+	}
+
+	return index, contents["index-meta.plist"]
+}